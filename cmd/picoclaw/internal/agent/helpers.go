@@ -37,6 +37,18 @@ func agentCmd(message, sessionKey, model string, debug bool) error {
 		cfg.Agents.Defaults.ModelName = model
 	}
 
+	closeAudit, err := internal.OpenAuditLog(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeAudit()
+
+	closeTracing, err := internal.OpenTracing(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeTracing()
+
 	provider, modelID, err := providers.CreateProvider(cfg)
 	if err != nil {
 		return fmt.Errorf("error creating provider: %w", err)
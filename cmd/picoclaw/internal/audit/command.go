@@ -0,0 +1,40 @@
+package audit
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sipeed/picoclaw/cmd/picoclaw/internal"
+)
+
+func NewAuditCommand() *cobra.Command {
+	var logPath string
+
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Inspect the audit log of tool calls and messages",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Help()
+		},
+		PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
+			cfg, err := internal.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("error loading config: %w", err)
+			}
+			logPath = cfg.AuditPath()
+			return nil
+		},
+	}
+
+	pathFn := func() string { return logPath }
+
+	cmd.AddCommand(
+		newShowCommand(pathFn),
+		newTailCommand(pathFn),
+		newVerifyCommand(pathFn),
+	)
+
+	return cmd
+}
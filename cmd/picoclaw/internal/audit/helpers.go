@@ -0,0 +1,249 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sipeed/picoclaw/pkg/audit"
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+// filterFlags are the record filters shared by show and tail.
+type filterFlags struct {
+	kind    string
+	agent   string
+	session string
+	channel string
+	sender  string
+	tool    string
+	status  string
+	since   string
+}
+
+func (f *filterFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.kind, "kind", "", "Record kind: tool or message")
+	cmd.Flags().StringVar(&f.agent, "agent", "", "Agent ID")
+	cmd.Flags().StringVar(&f.session, "session", "", "Session key")
+	cmd.Flags().StringVar(&f.channel, "channel", "", "Channel name")
+	cmd.Flags().StringVar(&f.sender, "sender", "", "Sender ID")
+	cmd.Flags().StringVar(&f.tool, "tool", "", "Tool name")
+	cmd.Flags().StringVar(&f.status, "status", "", "Status: ok, error, async or denied")
+	cmd.Flags().StringVar(&f.since, "since", "", "Only records newer than a duration (24h) or time (2006-01-02, RFC 3339)")
+}
+
+func (f *filterFlags) build() (audit.Filter, error) {
+	filter := audit.Filter{
+		Kind:    f.kind,
+		Agent:   f.agent,
+		Session: f.session,
+		Channel: f.channel,
+		Sender:  f.sender,
+		Tool:    f.tool,
+		Status:  f.status,
+	}
+	if f.since != "" {
+		since, err := parseSince(f.since, time.Now())
+		if err != nil {
+			return filter, err
+		}
+		filter.Since = since
+	}
+	return filter, nil
+}
+
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: use a duration like 24h or a date like 2006-01-02", value)
+}
+
+func auditShowCmd(w io.Writer, path string, filter audit.Filter, limit int, asJSON bool) error {
+	if len(audit.Files(path)) == 0 {
+		fmt.Fprintf(w, "No audit log at %s. Enable it with \"audit\": {\"enabled\": true} in config.json.\n", path)
+		return nil
+	}
+
+	var records []audit.Record
+	err := audit.Read(path, func(rec audit.Record) error {
+		if !filter.Match(rec) {
+			return nil
+		}
+		records = append(records, rec)
+		// Keep memory bounded when only the tail is wanted.
+		if limit > 0 && len(records) > 2*limit {
+			records = append(records[:0], records[len(records)-limit:]...)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if limit > 0 && len(records) > limit {
+		records = records[len(records)-limit:]
+	}
+
+	for _, rec := range records {
+		printRecord(w, rec, asJSON)
+	}
+	return nil
+}
+
+// auditFollowCmd polls the log for new records until ctx is done. A file
+// that shrank is assumed to have been rotated and is read from the start.
+func auditFollowCmd(ctx context.Context, w io.Writer, path string, filter audit.Filter, asJSON bool, poll time.Duration) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var offset int64
+	if info, err := os.Stat(path); err == nil {
+		offset = info.Size()
+	}
+
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		next, err := readNew(w, path, offset, filter, asJSON)
+		if err != nil {
+			return err
+		}
+		offset = next
+	}
+}
+
+func readNew(w io.Writer, path string, offset int64, filter audit.Filter, asJSON bool) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return offset, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return offset, err
+	}
+	if info.Size() < offset {
+		offset = 0
+	}
+	if info.Size() == offset {
+		return offset, nil
+	}
+
+	data := make([]byte, info.Size()-offset)
+	if _, err := f.ReadAt(data, offset); err != nil && err != io.EOF {
+		return offset, err
+	}
+	// Leave a partially written last line for the next poll.
+	end := bytes.LastIndexByte(data, '\n')
+	if end < 0 {
+		return offset, nil
+	}
+	err = audit.ReadFrom(bytes.NewReader(data[:end+1]), path, func(rec audit.Record) error {
+		if filter.Match(rec) {
+			printRecord(w, rec, asJSON)
+		}
+		return nil
+	})
+	return offset + int64(end) + 1, err
+}
+
+func auditVerifyCmd(w io.Writer, path string) error {
+	if len(audit.Files(path)) == 0 {
+		return fmt.Errorf("no audit log at %s", path)
+	}
+	n, err := audit.Verify(path)
+	if err != nil {
+		return fmt.Errorf("audit log verification failed: %w", err)
+	}
+	fmt.Fprintf(w, "✓ %d records verified, hash chain intact\n", n)
+	return nil
+}
+
+func printRecord(w io.Writer, rec audit.Record, asJSON bool) {
+	if asJSON {
+		data, _ := json.Marshal(rec)
+		fmt.Fprintln(w, string(data))
+		return
+	}
+	fmt.Fprintln(w, formatRecord(rec))
+}
+
+// formatRecord renders a record on one line, e.g.
+// "2026-02-01 12:00:00  tool     exec           ok      12ms  agent=main sender=42 chat=telegram:42 args={...}".
+func formatRecord(rec audit.Record) string {
+	name := rec.Tool
+	if rec.Kind == audit.KindMessage {
+		name = "→"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s  %-7s  %-14s %-6s %6dms",
+		rec.Time.Local().Format("2006-01-02 15:04:05"), rec.Kind, name, rec.Status, rec.DurationMS)
+
+	for _, kv := range [][2]string{
+		{"agent", rec.Agent},
+		{"session", rec.SessionKey},
+		{"sender", rec.Sender},
+		{"chat", joinChat(rec.Channel, rec.ChatID)},
+	} {
+		if kv[1] != "" {
+			fmt.Fprintf(&b, "  %s=%s", kv[0], kv[1])
+		}
+	}
+	if len(rec.Args) > 0 {
+		b.WriteString("  args=" + formatArgs(rec.Args))
+	}
+	if rec.Content != "" {
+		fmt.Fprintf(&b, "  content=%q", rec.Content)
+	}
+	if rec.Error != "" {
+		fmt.Fprintf(&b, "  error=%q", rec.Error)
+	}
+	return b.String()
+}
+
+func joinChat(channel, chatID string) string {
+	if chatID == "" {
+		return channel
+	}
+	return channel + ":" + chatID
+}
+
+func formatArgs(args map[string]any) string {
+	keys := make([]string, 0, len(args))
+	for k := range args {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		v, _ := json.Marshal(args[k])
+		parts = append(parts, k+"="+utils.Truncate(string(v), 80))
+	}
+	return "{" + strings.Join(parts, " ") + "}"
+}
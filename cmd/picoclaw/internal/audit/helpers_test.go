@@ -0,0 +1,100 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sipeed/picoclaw/pkg/audit"
+)
+
+func writeRecords(t *testing.T, path string, records ...audit.Record) {
+	t.Helper()
+	l, err := audit.Open(audit.Options{Path: path, HashChain: true})
+	require.NoError(t, err)
+	defer l.Close()
+	for _, rec := range records {
+		require.NoError(t, l.Write(rec))
+	}
+}
+
+func TestAuditShowCmd_FilterAndLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	writeRecords(t, path,
+		audit.Record{Kind: audit.KindTool, Tool: "exec", Sender: "42", Args: map[string]any{"command": "ls"}, Status: audit.StatusOK},
+		audit.Record{Kind: audit.KindTool, Tool: "read_file", Sender: "42", Status: audit.StatusOK},
+		audit.Record{Kind: audit.KindTool, Tool: "exec", Sender: "99", Status: audit.StatusError, Error: "boom"},
+		audit.Record{Kind: audit.KindMessage, Channel: "telegram", ChatID: "7", Content: "hi", Status: audit.StatusOK},
+	)
+
+	var out bytes.Buffer
+	require.NoError(t, auditShowCmd(&out, path, audit.Filter{Tool: "exec"}, 0, false))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `args={command="ls"}`)
+	assert.Contains(t, lines[1], `error="boom"`)
+
+	out.Reset()
+	require.NoError(t, auditShowCmd(&out, path, audit.Filter{}, 1, true))
+	assert.Equal(t, 1, strings.Count(out.String(), "\n"))
+	assert.Contains(t, out.String(), `"kind":"message"`)
+}
+
+func TestAuditShowCmd_MissingLog(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, auditShowCmd(&out, filepath.Join(t.TempDir(), "none.jsonl"), audit.Filter{}, 0, false))
+	assert.Contains(t, out.String(), "No audit log")
+}
+
+func TestAuditFollowCmd_PrintsNewRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	writeRecords(t, path, audit.Record{Kind: audit.KindTool, Tool: "old", Status: audit.StatusOK})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var out bytes.Buffer
+	done := make(chan error)
+	go func() { done <- auditFollowCmd(ctx, &out, path, audit.Filter{}, false, 10*time.Millisecond) }()
+
+	time.Sleep(30 * time.Millisecond)
+	writeRecords(t, path, audit.Record{Kind: audit.KindTool, Tool: "new_tool", Status: audit.StatusOK})
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+
+	assert.Contains(t, out.String(), "new_tool")
+	assert.NotContains(t, out.String(), "old")
+}
+
+func TestAuditVerifyCmd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	writeRecords(t, path,
+		audit.Record{Kind: audit.KindTool, Tool: "exec", Status: audit.StatusOK},
+		audit.Record{Kind: audit.KindTool, Tool: "exec", Status: audit.StatusOK},
+	)
+
+	var out bytes.Buffer
+	require.NoError(t, auditVerifyCmd(&out, path))
+	assert.Contains(t, out.String(), "2 records verified")
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	got, err := parseSince("2h", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-2*time.Hour), got)
+
+	got, err = parseSince("2026-02-01", now)
+	require.NoError(t, err)
+	assert.Equal(t, 2026, got.Year())
+	assert.Equal(t, time.February, got.Month())
+
+	_, err = parseSince("yesterday", now)
+	assert.Error(t, err)
+}
@@ -0,0 +1,32 @@
+package audit
+
+import "github.com/spf13/cobra"
+
+func newShowCommand(logPath func() string) *cobra.Command {
+	var (
+		filter filterFlags
+		limit  int
+		asJSON bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "Show audit records matching a filter",
+		Args:  cobra.NoArgs,
+		Example: `picoclaw audit show --tool exec --since 24h
+picoclaw audit show --sender 123456 --limit 50 --json`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			f, err := filter.build()
+			if err != nil {
+				return err
+			}
+			return auditShowCmd(cmd.OutOrStdout(), logPath(), f, limit, asJSON)
+		},
+	}
+
+	filter.register(cmd)
+	cmd.Flags().IntVarP(&limit, "limit", "n", 0, "Show only the last N matching records (0 = all)")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print raw JSON lines")
+
+	return cmd
+}
@@ -0,0 +1,23 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewShowSubcommand(t *testing.T) {
+	cmd := newShowCommand(func() string { return "" })
+
+	require.NotNil(t, cmd)
+
+	assert.Equal(t, "Show audit records matching a filter", cmd.Short)
+
+	assert.True(t, cmd.HasExample())
+	assert.True(t, cmd.HasFlags())
+
+	for _, name := range []string{"tool", "sender", "since", "limit", "json"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), "missing flag %q", name)
+	}
+}
@@ -0,0 +1,44 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func newTailCommand(logPath func() string) *cobra.Command {
+	var (
+		filter filterFlags
+		lines  int
+		follow bool
+		asJSON bool
+	)
+
+	cmd := &cobra.Command{
+		Use:     "tail",
+		Short:   "Print the latest audit records",
+		Args:    cobra.NoArgs,
+		Example: `picoclaw audit tail -f --kind tool`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			f, err := filter.build()
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			if err := auditShowCmd(out, logPath(), f, lines, asJSON); err != nil {
+				return err
+			}
+			if !follow {
+				return nil
+			}
+			return auditFollowCmd(cmd.Context(), out, logPath(), f, asJSON, 500*time.Millisecond)
+		},
+	}
+
+	filter.register(cmd)
+	cmd.Flags().IntVarP(&lines, "lines", "n", 20, "Number of records to print")
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "Keep printing new records as they are written")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print raw JSON lines")
+
+	return cmd
+}
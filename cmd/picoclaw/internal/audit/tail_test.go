@@ -0,0 +1,22 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTailSubcommand(t *testing.T) {
+	cmd := newTailCommand(func() string { return "" })
+
+	require.NotNil(t, cmd)
+
+	assert.Equal(t, "Print the latest audit records", cmd.Short)
+
+	assert.True(t, cmd.HasExample())
+	assert.True(t, cmd.HasFlags())
+
+	assert.NotNil(t, cmd.Flags().Lookup("follow"))
+	assert.NotNil(t, cmd.Flags().Lookup("lines"))
+}
@@ -0,0 +1,16 @@
+package audit
+
+import "github.com/spf13/cobra"
+
+func newVerifyCommand(logPath func() string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Check the hash chain of the audit log",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return auditVerifyCmd(cmd.OutOrStdout(), logPath())
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,19 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewVerifySubcommand(t *testing.T) {
+	cmd := newVerifyCommand(func() string { return "" })
+
+	require.NotNil(t, cmd)
+
+	assert.Equal(t, "Check the hash chain of the audit log", cmd.Short)
+
+	assert.False(t, cmd.HasExample())
+	assert.False(t, cmd.HasFlags())
+}
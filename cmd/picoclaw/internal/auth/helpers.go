@@ -324,11 +324,14 @@ func authLogoutCmd(provider string) error {
 }
 
 func authStatusCmd() error {
-	store, err := auth.LoadStore()
+	backend := auth.ActiveBackend()
+	store, err := backend.Load()
 	if err != nil {
 		return fmt.Errorf("failed to load auth store: %w", err)
 	}
 
+	fmt.Printf("Credential store: %s\n", backend.Name())
+
 	if len(store.Credentials) == 0 {
 		fmt.Println("No authenticated providers.")
 		fmt.Println("Run: picoclaw auth login --provider <name>")
@@ -2,6 +2,7 @@ package cron
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -17,6 +18,11 @@ func newAddCommand(storePath func() string) *cobra.Command {
 		deliver bool
 		channel string
 		to      string
+		timeout int
+		misfire string
+		overlap string
+		human   string
+		opts    cron.ScheduleOptions
 	)
 
 	cmd := &cobra.Command{
@@ -24,16 +30,31 @@ func newAddCommand(storePath func() string) *cobra.Command {
 		Short: "Add a new scheduled job",
 		Args:  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			if every <= 0 && cronExp == "" {
-				return fmt.Errorf("either --every or --cron must be specified")
+			if every <= 0 && cronExp == "" && human == "" {
+				return fmt.Errorf("one of --schedule, --every or --cron must be specified")
+			}
+			if !cron.ValidMisfirePolicy(misfire) {
+				return fmt.Errorf("unknown misfire policy %q", misfire)
+			}
+			if !cron.ValidOverlapPolicy(overlap) {
+				return fmt.Errorf("unknown overlap policy %q", overlap)
 			}
 
 			var schedule cron.CronSchedule
 			if every > 0 {
 				everyMS := every * 1000
 				schedule = cron.CronSchedule{Kind: "every", EveryMS: &everyMS}
-			} else {
+			} else if cronExp != "" {
 				schedule = cron.CronSchedule{Kind: "cron", Expr: cronExp}
+			} else {
+				parsed, err := cron.ParseSchedule(human, opts.TZ, time.Now())
+				if err != nil {
+					return err
+				}
+				schedule = parsed
+			}
+			if err := opts.Apply(&schedule); err != nil {
+				return err
 			}
 
 			cs := cron.NewCronService(storePath(), nil)
@@ -41,6 +62,14 @@ func newAddCommand(storePath func() string) *cobra.Command {
 			if err != nil {
 				return fmt.Errorf("error adding job: %w", err)
 			}
+			if timeout > 0 || misfire != "" || overlap != "" {
+				job.TimeoutSec = timeout
+				job.MisfirePolicy = misfire
+				job.OverlapPolicy = overlap
+				if err := cs.UpdateJob(job); err != nil {
+					return fmt.Errorf("error adding job: %w", err)
+				}
+			}
 
 			fmt.Printf("✓ Added job '%s' (%s)\n", job.Name, job.ID)
 
@@ -52,13 +81,22 @@ func newAddCommand(storePath func() string) *cobra.Command {
 	cmd.Flags().StringVarP(&message, "message", "m", "", "Message for agent")
 	cmd.Flags().Int64VarP(&every, "every", "e", 0, "Run every N seconds")
 	cmd.Flags().StringVarP(&cronExp, "cron", "c", "", "Cron expression (e.g. '0 9 * * *')")
+	cmd.Flags().StringVarP(&human, "schedule", "s", "", "Schedule in words (e.g. 'every weekday at 9am')")
+	cmd.Flags().StringVar(&opts.TZ, "tz", "", "IANA time zone for the schedule (default local)")
+	cmd.Flags().IntVar(&opts.JitterSec, "jitter", 0, "Delay each run by a random 0..N seconds")
+	cmd.Flags().StringVar(&opts.Until, "until", "", "Stop after this date or time (e.g. 2026-12-31)")
+	cmd.Flags().IntVar(&opts.MaxRuns, "max-runs", 0, "Stop after N runs")
+	cmd.Flags().StringVar(&opts.QuietHours, "quiet", "", "Defer runs inside this window (e.g. 22:00-07:00)")
 	cmd.Flags().BoolVarP(&deliver, "deliver", "d", false, "Deliver response to channel")
 	cmd.Flags().StringVar(&to, "to", "", "Recipient for delivery")
 	cmd.Flags().StringVar(&channel, "channel", "", "Channel for delivery")
+	cmd.Flags().IntVar(&timeout, "timeout", 0, "Stop a run after N seconds (0 = no timeout)")
+	cmd.Flags().StringVar(&misfire, "misfire", "", "Runs missed while stopped: skip, run_once or run_all")
+	cmd.Flags().StringVar(&overlap, "overlap", "", "Due while still running: skip, queue or allow")
 
 	_ = cmd.MarkFlagRequired("name")
 	_ = cmd.MarkFlagRequired("message")
-	cmd.MarkFlagsMutuallyExclusive("every", "cron")
+	cmd.MarkFlagsMutuallyExclusive("every", "cron", "schedule")
 
 	return cmd
 }
@@ -24,6 +24,15 @@ func TestNewAddSubcommand(t *testing.T) {
 	assert.NotNil(t, cmd.Flags().Lookup("deliver"))
 	assert.NotNil(t, cmd.Flags().Lookup("to"))
 	assert.NotNil(t, cmd.Flags().Lookup("channel"))
+	assert.NotNil(t, cmd.Flags().Lookup("timeout"))
+	assert.NotNil(t, cmd.Flags().Lookup("misfire"))
+	assert.NotNil(t, cmd.Flags().Lookup("overlap"))
+	assert.NotNil(t, cmd.Flags().Lookup("schedule"))
+	assert.NotNil(t, cmd.Flags().Lookup("tz"))
+	assert.NotNil(t, cmd.Flags().Lookup("jitter"))
+	assert.NotNil(t, cmd.Flags().Lookup("until"))
+	assert.NotNil(t, cmd.Flags().Lookup("max-runs"))
+	assert.NotNil(t, cmd.Flags().Lookup("quiet"))
 
 	nameFlag := cmd.Flags().Lookup("name")
 	require.NotNil(t, nameFlag)
@@ -55,3 +64,17 @@ func TestNewAddCommandEveryAndCronMutuallyExclusive(t *testing.T) {
 	err := cmd.Execute()
 	require.Error(t, err)
 }
+
+func TestNewAddCommandScheduleAndCronMutuallyExclusive(t *testing.T) {
+	cmd := newAddCommand(func() string { return "testing" })
+
+	cmd.SetArgs([]string{
+		"--name", "job",
+		"--message", "hello",
+		"--schedule", "every weekday at 9am",
+		"--cron", "0 9 * * *",
+	})
+
+	err := cmd.Execute()
+	require.Error(t, err)
+}
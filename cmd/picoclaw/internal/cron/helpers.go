@@ -2,6 +2,7 @@ package cron
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/sipeed/picoclaw/pkg/cron"
@@ -24,6 +25,9 @@ func cronListCmd(storePath string) {
 			schedule = fmt.Sprintf("every %ds", *job.Schedule.EveryMS/1000)
 		} else if job.Schedule.Kind == "cron" {
 			schedule = job.Schedule.Expr
+			if job.Schedule.TZ != "" {
+				schedule += " (" + job.Schedule.TZ + ")"
+			}
 		} else {
 			schedule = "one-time"
 		}
@@ -64,3 +68,30 @@ func cronSetJobEnabled(storePath, jobID string, enabled bool) {
 		fmt.Printf("✗ Job %s not found\n", jobID)
 	}
 }
+
+func cronHistoryCmd(storePath, jobID string) {
+	cs := cron.NewCronService(storePath, nil)
+	runs, ok := cs.History(jobID)
+	if !ok {
+		fmt.Printf("✗ Job %s not found\n", jobID)
+		return
+	}
+	if len(runs) == 0 {
+		fmt.Println("No runs recorded.")
+		return
+	}
+
+	fmt.Printf("\nRuns of %s (most recent first):\n", jobID)
+	fmt.Println("----------------")
+	for _, run := range runs {
+		started := time.UnixMilli(run.StartedAtMS).Format("2006-01-02 15:04:05")
+		duration := (time.Duration(run.DurationMS) * time.Millisecond).Round(time.Millisecond)
+		fmt.Printf("  %s  %-8s %-8s %s\n", started, run.Status, run.Trigger, duration)
+		if run.Error != "" {
+			fmt.Printf("    Error: %s\n", run.Error)
+		}
+		if run.Output != "" {
+			fmt.Printf("    Output: %s\n", strings.ReplaceAll(run.Output, "\n", " "))
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package cron
+
+import "github.com/spf13/cobra"
+
+func newHistoryCommand(storePath func() string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "history",
+		Short:   "Show recent runs of a job",
+		Args:    cobra.ExactArgs(1),
+		Example: `picoclaw cron history 1`,
+		RunE: func(_ *cobra.Command, args []string) error {
+			cronHistoryCmd(storePath(), args[0])
+			return nil
+		},
+	}
+
+	return cmd
+}
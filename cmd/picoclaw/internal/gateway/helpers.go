@@ -13,17 +13,22 @@ import (
 
 	"github.com/sipeed/picoclaw/cmd/picoclaw/internal"
 	"github.com/sipeed/picoclaw/pkg/agent"
+	"github.com/sipeed/picoclaw/pkg/auth"
 	"github.com/sipeed/picoclaw/pkg/bus"
 	"github.com/sipeed/picoclaw/pkg/channels"
 	"github.com/sipeed/picoclaw/pkg/config"
 	"github.com/sipeed/picoclaw/pkg/cron"
+	"github.com/sipeed/picoclaw/pkg/dashboard"
 	"github.com/sipeed/picoclaw/pkg/devices"
+	"github.com/sipeed/picoclaw/pkg/devices/events"
 	"github.com/sipeed/picoclaw/pkg/health"
 	"github.com/sipeed/picoclaw/pkg/heartbeat"
 	"github.com/sipeed/picoclaw/pkg/logger"
 	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/secrets"
 	"github.com/sipeed/picoclaw/pkg/state"
 	"github.com/sipeed/picoclaw/pkg/tools"
+	"github.com/sipeed/picoclaw/pkg/triggers"
 	"github.com/sipeed/picoclaw/pkg/voice"
 )
 
@@ -38,6 +43,18 @@ func gatewayCmd(debug bool) error {
 		return fmt.Errorf("error loading config: %w", err)
 	}
 
+	closeAudit, err := internal.OpenAuditLog(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeAudit()
+
+	closeTracing, err := internal.OpenTracing(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeTracing()
+
 	provider, modelID, err := providers.CreateProvider(cfg)
 	if err != nil {
 		return fmt.Errorf("error creating provider: %w", err)
@@ -86,14 +103,14 @@ func gatewayCmd(debug bool) error {
 		cfg.Heartbeat.Enabled,
 	)
 	heartbeatService.SetBus(msgBus)
-	heartbeatService.SetHandler(func(prompt, channel, chatID string) *tools.ToolResult {
+	heartbeatService.SetHandler(func(agentID, prompt, channel, chatID string) *tools.ToolResult {
 		// Use cli:direct as fallback if no valid channel
 		if channel == "" || chatID == "" {
 			channel, chatID = "cli", "direct"
 		}
 		// Use ProcessHeartbeat - no session history, each heartbeat is independent
 		var response string
-		response, err = agentLoop.ProcessHeartbeat(context.Background(), prompt, channel, chatID)
+		response, err = agentLoop.ProcessHeartbeatWithAgent(context.Background(), agentID, prompt, channel, chatID)
 		if err != nil {
 			return tools.ErrorResult(fmt.Sprintf("Heartbeat error: %v", err))
 		}
@@ -172,6 +189,8 @@ func gatewayCmd(debug bool) error {
 	}
 	fmt.Println("✓ Heartbeat service started")
 
+	auth.StartBackgroundRefresh(ctx)
+
 	stateManager := state.NewManager(cfg.WorkspacePath())
 	deviceService := devices.NewService(devices.Config{
 		Enabled:    cfg.Devices.Enabled,
@@ -184,17 +203,49 @@ func gatewayCmd(debug bool) error {
 		fmt.Println("✓ Device event service started")
 	}
 
+	triggerService := setupTriggers(ctx, cfg, agentLoop, msgBus, deviceService, channelManager)
+
 	if err := channelManager.StartAll(ctx); err != nil {
 		fmt.Printf("Error starting channels: %v\n", err)
 	}
 
+	providers.StartEndpointProbes(ctx, cfg,
+		time.Duration(cfg.Gateway.ModelProbeInterval)*time.Second,
+		time.Duration(cfg.Gateway.ModelProbeTimeout)*time.Second)
+
 	healthServer := health.NewServer(cfg.Gateway.Host, cfg.Gateway.Port)
+	healthServer.HandleJSON("/health/models", func() any {
+		return map[string]any{"endpoints": cfg.EndpointStats()}
+	})
+	if cfg.Gateway.Dashboard.Enabled {
+		dash := dashboard.New(dashboard.Options{
+			Token:       cfg.Gateway.Dashboard.Token,
+			AllowRemote: cfg.Gateway.Dashboard.AllowRemote,
+			Channels:    channelManager,
+			Agent:       agentLoop,
+			Cron:        cronService,
+			Heartbeat:   heartbeatService,
+		})
+		healthServer.Handle(dashboard.Prefix, dash)
+		dashURL := fmt.Sprintf("http://%s:%d%s", cfg.Gateway.Host, cfg.Gateway.Port, dashboard.Prefix)
+		if cfg.Gateway.Dashboard.Token == "" {
+			// Generated tokens change on every start; show it once here.
+			dashURL += "?token=" + dash.Token()
+		}
+		fmt.Printf("✓ Dashboard available at %s\n", dashURL)
+	}
+	if triggerService != nil && triggerService.HasSource(triggers.SourceWebhook) {
+		healthServer.Handle(triggers.WebhookPrefix, triggerService)
+		fmt.Printf("✓ Webhook triggers available at http://%s:%d%s<name>\n",
+			cfg.Gateway.Host, cfg.Gateway.Port, triggers.WebhookPrefix)
+	}
 	go func() {
 		if err := healthServer.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			logger.ErrorCF("health", "Health server error", map[string]any{"error": err.Error()})
 		}
 	}()
-	fmt.Printf("✓ Health endpoints available at http://%s:%d/health and /ready\n", cfg.Gateway.Host, cfg.Gateway.Port)
+	fmt.Printf("✓ Health endpoints available at http://%s:%d/health, /ready, /health/models and /metrics\n",
+		cfg.Gateway.Host, cfg.Gateway.Port)
 
 	go agentLoop.Run(ctx)
 
@@ -208,6 +259,9 @@ func gatewayCmd(debug bool) error {
 	}
 	cancel()
 	healthServer.Stop(context.Background())
+	if triggerService != nil {
+		triggerService.Stop()
+	}
 	deviceService.Stop()
 	heartbeatService.Stop()
 	cronService.Stop()
@@ -236,10 +290,64 @@ func setupCronTool(
 	agentLoop.RegisterTool(cronTool)
 
 	// Set the onJob handler
-	cronService.SetOnJob(func(job *cron.CronJob) (string, error) {
-		result := cronTool.ExecuteJob(context.Background(), job)
-		return result, nil
-	})
+	cronService.SetOnJob(cronTool.ExecuteJob)
 
 	return cronService
 }
+
+func setupTriggers(
+	ctx context.Context,
+	cfg *config.Config,
+	agentLoop *agent.AgentLoop,
+	msgBus *bus.MessageBus,
+	deviceService *devices.Service,
+	channelManager *channels.Manager,
+) *triggers.Service {
+	if !cfg.Triggers.Enabled {
+		return nil
+	}
+
+	triggerService := triggers.NewService(triggers.Options{
+		Rules:     cfg.Triggers.Rules,
+		Workspace: cfg.WorkspacePath(),
+		Runner:    agentLoop,
+		Bus:       msgBus,
+		Resolver:  secrets.NewResolver(secrets.DefaultPaths(internal.GetConfigPath())),
+	})
+
+	if triggerService.HasSource(triggers.SourceUSB) {
+		if !cfg.Devices.Enabled || !cfg.Devices.MonitorUSB {
+			logger.WarnC("triggers", "USB triggers need devices.enabled and devices.monitor_usb")
+		}
+		deviceService.OnEvent(func(ev *events.DeviceEvent) {
+			triggerService.Fire(triggers.Event{
+				Source: triggers.SourceUSB,
+				Type:   string(ev.Action),
+				Data: map[string]any{
+					"kind":         string(ev.Kind),
+					"device_id":    ev.DeviceID,
+					"vendor":       ev.Vendor,
+					"product":      ev.Product,
+					"serial":       ev.Serial,
+					"capabilities": ev.Capabilities,
+				},
+			})
+		})
+	}
+
+	if triggerService.HasSource(triggers.SourceMaixCam) {
+		ch, ok := channelManager.GetChannel("maixcam")
+		mc, isMaixCam := ch.(*channels.MaixCamChannel)
+		if !ok || !isMaixCam {
+			logger.WarnC("triggers", "MaixCam triggers need the maixcam channel enabled")
+		} else {
+			mc.SetEventHandler(func(eventType string, data map[string]any) {
+				triggerService.Fire(triggers.Event{Source: triggers.SourceMaixCam, Type: eventType, Data: data})
+			})
+		}
+	}
+
+	triggerService.Start(ctx)
+	fmt.Println("✓ Trigger service started")
+	return triggerService
+}
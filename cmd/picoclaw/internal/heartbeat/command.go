@@ -0,0 +1,22 @@
+package heartbeat
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewHeartbeatCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "heartbeat",
+		Short: "Run the periodic tasks in HEARTBEAT.md",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(
+		newRunCommand(),
+	)
+
+	return cmd
+}
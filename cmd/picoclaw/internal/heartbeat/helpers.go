@@ -0,0 +1,87 @@
+package heartbeat
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sipeed/picoclaw/cmd/picoclaw/internal"
+	"github.com/sipeed/picoclaw/pkg/agent"
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/heartbeat"
+	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+func heartbeatRunCmd(section string) error {
+	cfg, err := internal.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+
+	closeAudit, err := internal.OpenAuditLog(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeAudit()
+
+	closeTracing, err := internal.OpenTracing(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeTracing()
+
+	provider, modelID, err := providers.CreateProvider(cfg)
+	if err != nil {
+		return fmt.Errorf("error creating provider: %w", err)
+	}
+	if modelID != "" {
+		cfg.Agents.Defaults.ModelName = modelID
+	}
+
+	agentLoop := agent.NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+
+	hs := heartbeat.NewHeartbeatService(cfg.WorkspacePath(), cfg.Heartbeat.Interval, true)
+	hs.SetHandler(func(agentID, prompt, channel, chatID string) *tools.ToolResult {
+		if channel == "" || chatID == "" {
+			channel, chatID = "cli", "direct"
+		}
+		response, err := agentLoop.ProcessHeartbeatWithAgent(context.Background(), agentID, prompt, channel, chatID)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return tools.ErrorResult(fmt.Sprintf("Heartbeat error: %v", err))
+		}
+		fmt.Printf("\n%s %s\n\n", internal.Logo, response)
+		// Printed here; nothing is delivered from the CLI.
+		return tools.SilentResult(response)
+	})
+
+	sections, err := hs.Sections()
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+
+	var selected []*heartbeat.Section
+	for _, s := range sections {
+		if section == "" || strings.EqualFold(s.Name, section) {
+			selected = append(selected, s)
+		}
+	}
+	if len(selected) == 0 {
+		// Reports the missing section along with the available ones.
+		return hs.RunNow(section)
+	}
+
+	for _, s := range selected {
+		target := s.Target()
+		if target == "" {
+			target = "last active chat"
+		}
+		fmt.Printf("Running %q (%s, reports to %s)\n", s.Name, s.Schedule(), target)
+		if err := hs.RunNow(s.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,22 @@
+package heartbeat
+
+import "github.com/spf13/cobra"
+
+func newRunCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run [section]",
+		Short: "Run heartbeat sections now and print the responses",
+		Args:  cobra.MaximumNArgs(1),
+		Example: `picoclaw heartbeat run
+picoclaw heartbeat run "Morning briefing"`,
+		RunE: func(_ *cobra.Command, args []string) error {
+			section := ""
+			if len(args) == 1 {
+				section = args[0]
+			}
+			return heartbeatRunCmd(section)
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,19 @@
+package heartbeat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRunSubcommand(t *testing.T) {
+	cmd := newRunCommand()
+
+	require.NotNil(t, cmd)
+
+	assert.Equal(t, "Run heartbeat sections now and print the responses", cmd.Short)
+
+	assert.True(t, cmd.HasExample())
+	assert.Error(t, cmd.Args(cmd, []string{"a", "b"}))
+}
@@ -6,7 +6,10 @@ import (
 	"path/filepath"
 	"runtime"
 
+	"github.com/sipeed/picoclaw/pkg/audit"
 	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/redact"
+	"github.com/sipeed/picoclaw/pkg/tracing"
 )
 
 const Logo = "🦞"
@@ -24,7 +27,74 @@ func GetConfigPath() string {
 }
 
 func LoadConfig() (*config.Config, error) {
-	return config.LoadConfig(GetConfigPath())
+	cfg, err := config.LoadConfig(GetConfigPath())
+	if err != nil {
+		return nil, err
+	}
+	ConfigureRedaction(cfg)
+	return cfg, nil
+}
+
+// ConfigureRedaction applies the redaction settings and registers the
+// credentials from cfg so they are masked in logs and saved sessions.
+func ConfigureRedaction(cfg *config.Config) {
+	r := redact.Default()
+	err := r.Configure(redact.Options{
+		Enabled: cfg.Redaction.Enabled,
+		PII:     cfg.Redaction.PII,
+		Extra:   cfg.Redaction.Patterns,
+	})
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	r.AddSecrets(cfg.SecretValues()...)
+}
+
+// OpenAuditLog starts the audit log when it is enabled in cfg and installs
+// it as the default. The returned function closes it.
+func OpenAuditLog(cfg *config.Config) (func(), error) {
+	if !cfg.Audit.Enabled {
+		return func() {}, nil
+	}
+	l, err := audit.Open(audit.Options{
+		Path:      cfg.AuditPath(),
+		MaxSizeMB: cfg.Audit.MaxSizeMB,
+		MaxFiles:  cfg.Audit.MaxFiles,
+		HashChain: cfg.Audit.HashChain,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("opening audit log: %w", err)
+	}
+	audit.SetDefault(l)
+	return func() {
+		audit.SetDefault(nil)
+		l.Close()
+	}, nil
+}
+
+// OpenTracing starts exporting spans when tracing is enabled in cfg and
+// installs the tracer as the default. The returned function flushes pending
+// spans and must be called on exit.
+func OpenTracing(cfg *config.Config) (func(), error) {
+	if !cfg.Tracing.Enabled {
+		return func() {}, nil
+	}
+	var exporter tracing.Exporter
+	if cfg.Tracing.Endpoint != "" {
+		exporter = tracing.NewOTLPExporter(cfg.Tracing.Endpoint, cfg.Tracing.Headers, cfg.Tracing.ServiceName)
+	} else {
+		fe, err := tracing.NewFileExporter(cfg.TracingPath())
+		if err != nil {
+			return nil, fmt.Errorf("opening trace file: %w", err)
+		}
+		exporter = fe
+	}
+	t := tracing.NewTracer(exporter)
+	tracing.SetDefault(t)
+	return func() {
+		tracing.SetDefault(nil)
+		t.Shutdown()
+	}, nil
 }
 
 // FormatVersion returns the version string with optional git commit
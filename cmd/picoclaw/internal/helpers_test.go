@@ -8,6 +8,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/redact"
 )
 
 func TestGetConfigPath(t *testing.T) {
@@ -95,3 +98,14 @@ func TestGetConfigPath_Windows(t *testing.T) {
 func TestGetVersion(t *testing.T) {
 	assert.Equal(t, "dev", GetVersion())
 }
+
+func TestConfigureRedaction_RegistersConfigSecrets(t *testing.T) {
+	t.Cleanup(func() { _ = redact.Default().Configure(redact.Options{Enabled: true}) })
+
+	cfg := config.DefaultConfig()
+	cfg.Channels.Telegram.Token = "123456:telegram-test-token"
+	ConfigureRedaction(cfg)
+
+	got := redact.String("bot token 123456:telegram-test-token")
+	assert.Equal(t, "bot token [REDACTED:secret]", got)
+}
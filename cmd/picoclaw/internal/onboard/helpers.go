@@ -36,6 +36,7 @@ func onboard() {
 	fmt.Printf("%s picoclaw is ready!\n", internal.Logo)
 	fmt.Println("\nNext steps:")
 	fmt.Println("  1. Add your API key to", configPath)
+	fmt.Println("     (or store it with `picoclaw secrets set <name>` and use \"secret://<name>\")")
 	fmt.Println("")
 	fmt.Println("     Recommended:")
 	fmt.Println("     - OpenRouter: https://openrouter.ai/keys (access 100+ models)")
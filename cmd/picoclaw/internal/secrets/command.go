@@ -0,0 +1,48 @@
+package secrets
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sipeed/picoclaw/cmd/picoclaw/internal"
+	"github.com/sipeed/picoclaw/pkg/secrets"
+)
+
+func NewSecretsCommand() *cobra.Command {
+	var store *secrets.Store
+
+	cmd := &cobra.Command{
+		Use:   "secrets",
+		Short: "Manage encrypted secrets referenced from config",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Help()
+		},
+		// The config is not loaded here: it may reference secrets that do not exist yet.
+		PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
+			s, err := secrets.Open(secrets.DefaultPaths(internal.GetConfigPath()))
+			if err != nil {
+				return fmt.Errorf("error opening secret store: %w", err)
+			}
+			store = s
+			return nil
+		},
+	}
+
+	storeFn := func() (*secrets.Store, error) {
+		if store == nil {
+			return nil, fmt.Errorf("secret store is not initialized")
+		}
+		return store, nil
+	}
+
+	cmd.AddCommand(
+		newSetCommand(storeFn),
+		newGetCommand(storeFn),
+		newListCommand(storeFn),
+		newRemoveCommand(storeFn),
+	)
+
+	return cmd
+}
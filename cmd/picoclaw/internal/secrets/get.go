@@ -0,0 +1,25 @@
+package secrets
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/sipeed/picoclaw/pkg/secrets"
+)
+
+func newGetCommand(storeFn func() (*secrets.Store, error)) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "get <name>",
+		Short:   "Print a decrypted secret",
+		Args:    cobra.ExactArgs(1),
+		Example: `picoclaw secrets get openai-key`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := storeFn()
+			if err != nil {
+				return err
+			}
+			return secretsGetCmd(store, cmd.OutOrStdout(), args[0])
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,19 @@
+package secrets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewGetSubcommand(t *testing.T) {
+	cmd := newGetCommand(nil)
+
+	require.NotNil(t, cmd)
+
+	assert.Equal(t, "Print a decrypted secret", cmd.Short)
+
+	assert.True(t, cmd.HasExample())
+	assert.False(t, cmd.HasFlags())
+}
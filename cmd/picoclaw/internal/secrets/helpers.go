@@ -0,0 +1,73 @@
+package secrets
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/secrets"
+)
+
+func secretsSetCmd(store *secrets.Store, stdin io.Reader, out io.Writer, args []string) error {
+	name := args[0]
+	var value string
+	if len(args) == 2 {
+		value = args[1]
+	} else {
+		line, err := bufio.NewReader(stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("error reading secret: %w", err)
+		}
+		value = strings.TrimRight(line, "\r\n")
+	}
+	if value == "" {
+		return fmt.Errorf("secret value is empty")
+	}
+
+	if err := store.Set(name, value); err != nil {
+		return fmt.Errorf("error storing secret: %w", err)
+	}
+	fmt.Fprintf(out, "✓ Stored secret '%s'. Reference it as secret://%s\n", name, name)
+	return nil
+}
+
+func secretsGetCmd(store *secrets.Store, out io.Writer, name string) error {
+	value, err := store.Get(name)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, value)
+	return nil
+}
+
+func secretsListCmd(store *secrets.Store, out io.Writer) {
+	names := store.List()
+	if len(names) == 0 {
+		fmt.Fprintln(out, "No secrets stored.")
+		return
+	}
+
+	fmt.Fprintln(out, "\nSecrets:")
+	fmt.Fprintln(out, "--------")
+	for _, name := range names {
+		if updated, ok := store.UpdatedAt(name); ok {
+			fmt.Fprintf(out, "  %s (updated %s)\n", name, updated.Local().Format("2006-01-02 15:04"))
+		} else {
+			fmt.Fprintf(out, "  %s\n", name)
+		}
+	}
+}
+
+func secretsRemoveCmd(store *secrets.Store, out io.Writer, name string) error {
+	removed, err := store.Remove(name)
+	if err != nil {
+		return fmt.Errorf("error removing secret: %w", err)
+	}
+	if removed {
+		fmt.Fprintf(out, "✓ Removed secret %s\n", name)
+	} else {
+		fmt.Fprintf(out, "✗ Secret %s not found\n", name)
+	}
+	return nil
+}
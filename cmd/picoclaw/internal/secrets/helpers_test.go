@@ -0,0 +1,45 @@
+package secrets
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sipeed/picoclaw/pkg/secrets"
+)
+
+func TestSecretsCommands_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	store, err := secrets.Open(filepath.Join(dir, "secrets.json"), filepath.Join(dir, "secrets.key"))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, secretsSetCmd(store, strings.NewReader("sk-from-stdin\n"), &out, []string{"openai"}))
+	assert.Contains(t, out.String(), "secret://openai")
+
+	out.Reset()
+	require.NoError(t, secretsGetCmd(store, &out, "openai"))
+	assert.Equal(t, "sk-from-stdin\n", out.String())
+
+	out.Reset()
+	secretsListCmd(store, &out)
+	assert.Contains(t, out.String(), "openai")
+	assert.NotContains(t, out.String(), "sk-from-stdin")
+
+	out.Reset()
+	require.NoError(t, secretsRemoveCmd(store, &out, "openai"))
+	assert.Equal(t, []string{}, store.List())
+}
+
+func TestSecretsSetCmd_EmptyValue(t *testing.T) {
+	dir := t.TempDir()
+	store, err := secrets.Open(filepath.Join(dir, "secrets.json"), filepath.Join(dir, "secrets.key"))
+	require.NoError(t, err)
+
+	err = secretsSetCmd(store, strings.NewReader(""), &bytes.Buffer{}, []string{"empty"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,25 @@
+package secrets
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/sipeed/picoclaw/pkg/secrets"
+)
+
+func newListCommand(storeFn func() (*secrets.Store, error)) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List secret names",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			store, err := storeFn()
+			if err != nil {
+				return err
+			}
+			secretsListCmd(store, cmd.OutOrStdout())
+			return nil
+		},
+	}
+
+	return cmd
+}
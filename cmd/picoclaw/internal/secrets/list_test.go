@@ -0,0 +1,19 @@
+package secrets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewListSubcommand(t *testing.T) {
+	cmd := newListCommand(nil)
+
+	require.NotNil(t, cmd)
+
+	assert.Equal(t, "List secret names", cmd.Short)
+
+	assert.False(t, cmd.HasExample())
+	assert.False(t, cmd.HasFlags())
+}
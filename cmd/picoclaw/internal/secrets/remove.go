@@ -0,0 +1,26 @@
+package secrets
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/sipeed/picoclaw/pkg/secrets"
+)
+
+func newRemoveCommand(storeFn func() (*secrets.Store, error)) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "rm <name>",
+		Aliases: []string{"remove"},
+		Short:   "Remove a secret",
+		Args:    cobra.ExactArgs(1),
+		Example: `picoclaw secrets rm openai-key`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := storeFn()
+			if err != nil {
+				return err
+			}
+			return secretsRemoveCmd(store, cmd.OutOrStdout(), args[0])
+		},
+	}
+
+	return cmd
+}
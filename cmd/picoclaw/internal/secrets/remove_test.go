@@ -0,0 +1,25 @@
+package secrets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRemoveSubcommand(t *testing.T) {
+	cmd := newRemoveCommand(nil)
+
+	require.NotNil(t, cmd)
+
+	assert.Equal(t, "Remove a secret", cmd.Short)
+
+	assert.True(t, cmd.HasExample())
+	assert.False(t, cmd.HasFlags())
+}
+
+func TestNewRemoveSubcommand_Alias(t *testing.T) {
+	cmd := newRemoveCommand(nil)
+
+	assert.True(t, cmd.HasAlias("remove"))
+}
@@ -0,0 +1,28 @@
+package secrets
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/sipeed/picoclaw/pkg/secrets"
+)
+
+func newSetCommand(storeFn func() (*secrets.Store, error)) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set <name> [value]",
+		Short: "Store a secret",
+		Long: "Store a secret in the encrypted store. Reference it from config.json as secret://<name>.\n" +
+			"If value is omitted it is read from stdin, which keeps it out of shell history.",
+		Args: cobra.RangeArgs(1, 2),
+		Example: `picoclaw secrets set openai-key
+echo "$TOKEN" | picoclaw secrets set telegram-token`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := storeFn()
+			if err != nil {
+				return err
+			}
+			return secretsSetCmd(store, cmd.InOrStdin(), cmd.OutOrStdout(), args)
+		},
+	}
+
+	return cmd
+}
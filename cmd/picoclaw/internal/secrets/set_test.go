@@ -0,0 +1,19 @@
+package secrets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSetSubcommand(t *testing.T) {
+	cmd := newSetCommand(nil)
+
+	require.NotNil(t, cmd)
+
+	assert.Equal(t, "Store a secret", cmd.Short)
+
+	assert.True(t, cmd.HasExample())
+	assert.False(t, cmd.HasFlags())
+}
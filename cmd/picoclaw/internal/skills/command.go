@@ -7,10 +7,12 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/sipeed/picoclaw/cmd/picoclaw/internal"
+	"github.com/sipeed/picoclaw/pkg/config"
 	"github.com/sipeed/picoclaw/pkg/skills"
 )
 
 type deps struct {
+	cfg          *config.Config
 	workspace    string
 	installer    *skills.SkillInstaller
 	skillsLoader *skills.SkillsLoader
@@ -28,6 +30,7 @@ func NewSkillsCommand() *cobra.Command {
 				return fmt.Errorf("error loading config: %w", err)
 			}
 
+			d.cfg = cfg
 			d.workspace = cfg.WorkspacePath()
 			d.installer = skills.NewSkillInstaller(d.workspace)
 
@@ -36,6 +39,7 @@ func NewSkillsCommand() *cobra.Command {
 			globalSkillsDir := filepath.Join(globalDir, "skills")
 			builtinSkillsDir := filepath.Join(globalDir, "picoclaw", "skills")
 			d.skillsLoader = skills.NewSkillsLoader(d.workspace, globalSkillsDir, builtinSkillsDir)
+			d.skillsLoader.SetDeniedPermissions(cfg.Tools.Skills.DeniedPermissions)
 
 			return nil
 		},
@@ -58,6 +62,13 @@ func NewSkillsCommand() *cobra.Command {
 		return d.skillsLoader, nil
 	}
 
+	configFn := func() (*config.Config, error) {
+		if d.cfg == nil {
+			return nil, fmt.Errorf("config is not initialized")
+		}
+		return d.cfg, nil
+	}
+
 	workspaceFn := func() (string, error) {
 		if d.workspace == "" {
 			return "", fmt.Errorf("workspace is not initialized")
@@ -73,6 +84,9 @@ func NewSkillsCommand() *cobra.Command {
 		newRemoveCommand(installerFn),
 		newSearchCommand(installerFn),
 		newShowCommand(loaderFn),
+		newOutdatedCommand(configFn),
+		newUpdateCommand(configFn),
+		newRollbackCommand(workspaceFn),
 	)
 
 	return cmd
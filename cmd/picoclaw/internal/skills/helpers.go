@@ -2,6 +2,7 @@ package skills
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -26,7 +27,11 @@ func skillsListCmd(loader *skills.SkillsLoader) {
 	fmt.Println("\nInstalled Skills:")
 	fmt.Println("------------------")
 	for _, skill := range allSkills {
-		fmt.Printf("  ✓ %s (%s)\n", skill.Name, skill.Source)
+		if skill.Available() {
+			fmt.Printf("  ✓ %s (%s)\n", skill.Name, skill.Source)
+		} else {
+			fmt.Printf("  ✗ %s (%s) unavailable because %s\n", skill.Name, skill.Source, skill.Unavailable)
+		}
 		if skill.Description != "" {
 			fmt.Printf("    %s\n", skill.Description)
 		}
@@ -62,12 +67,7 @@ func skillsInstallFromRegistry(cfg *config.Config, registryName, slug string) er
 
 	fmt.Printf("Installing skill '%s' from %s registry...\n", slug, registryName)
 
-	registryMgr := skills.NewRegistryManagerFromConfig(skills.RegistryConfig{
-		MaxConcurrentSearches: cfg.Tools.Skills.MaxConcurrentSearches,
-		ClawHub:               skills.ClawHubConfig(cfg.Tools.Skills.Registries.ClawHub),
-	})
-
-	registry := registryMgr.GetRegistry(registryName)
+	registry := skills.NewRegistryManagerFromConfig(skills.RegistryConfigFrom(cfg)).GetRegistry(registryName)
 	if registry == nil {
 		return fmt.Errorf("✗  registry '%s' not found or not enabled. check your config.json.", registryName)
 	}
@@ -104,6 +104,13 @@ func skillsInstallFromRegistry(cfg *config.Config, registryName, slug string) er
 		return fmt.Errorf("\u2717 Skill '%s' is flagged as malicious and cannot be installed.\n", slug)
 	}
 
+	if err := skills.WriteOrigin(targetDir, registry.Name(), slug, result.Version); err != nil {
+		fmt.Printf("\u26a0\ufe0f  Warning: failed to write origin metadata: %v\n", err)
+	}
+	if err := skills.RecordInstall(workspace, registry.Name(), slug, result.Version); err != nil {
+		fmt.Printf("\u26a0\ufe0f  Warning: failed to update %s: %v\n", skills.LockFileName, err)
+	}
+
 	if result.IsSuspicious {
 		fmt.Printf("\u26a0\ufe0f  Warning: skill '%s' is flagged as suspicious.\n", slug)
 	}
@@ -116,6 +123,109 @@ func skillsInstallFromRegistry(cfg *config.Config, registryName, slug string) er
 	return nil
 }
 
+func skillsOutdatedCmd(cfg *config.Config) error {
+	updater := skills.NewUpdater(cfg.WorkspacePath(), skills.NewRegistryManagerFromConfig(skills.RegistryConfigFrom(cfg)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	statuses, err := updater.Status(ctx, true)
+	if err != nil {
+		return fmt.Errorf("\u2717 failed to read %s: %w", skills.LockFileName, err)
+	}
+	if len(statuses) == 0 {
+		fmt.Println("No skills installed from a registry.")
+		return nil
+	}
+
+	outdated := 0
+	for _, st := range statuses {
+		modified := ""
+		if st.Modified {
+			modified = " (modified locally)"
+		}
+		switch {
+		case st.Error != "":
+			fmt.Printf("  ? %s %s [%s]%s: %s\n", st.Name, st.Version, st.Registry, modified, st.Error)
+		case st.Outdated():
+			outdated++
+			fmt.Printf("  \u2191 %s %s \u2192 %s [%s]%s\n", st.Name, st.Version, st.Latest, st.Registry, modified)
+		default:
+			fmt.Printf("  \u2713 %s %s [%s]%s\n", st.Name, st.Version, st.Registry, modified)
+		}
+	}
+	if outdated == 0 {
+		fmt.Println("\nAll skills are up to date.")
+	} else {
+		fmt.Printf("\n%d skill(s) can be updated with 'picoclaw skills update'.\n", outdated)
+	}
+	return nil
+}
+
+func skillsUpdateCmd(cfg *config.Config, name string, force bool) error {
+	workspace := cfg.WorkspacePath()
+	updater := skills.NewUpdater(workspace, skills.NewRegistryManagerFromConfig(skills.RegistryConfigFrom(cfg)))
+
+	names := []string{name}
+	if name == "" {
+		statuses, err := updater.Status(context.Background(), false)
+		if err != nil {
+			return fmt.Errorf("\u2717 failed to read %s: %w", skills.LockFileName, err)
+		}
+		names = names[:0]
+		for _, st := range statuses {
+			names = append(names, st.Name)
+		}
+		if len(names) == 0 {
+			fmt.Println("No skills installed from a registry.")
+			return nil
+		}
+	}
+
+	failed := 0
+	for _, n := range names {
+		lock, err := skills.LoadLock(workspace)
+		if err != nil {
+			return err
+		}
+		before := ""
+		if entry, ok := lock.Skills[n]; ok {
+			before = entry.Version
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		entry, err := updater.Update(ctx, n, force)
+		cancel()
+
+		switch {
+		case errors.Is(err, skills.ErrLocallyModified):
+			failed++
+			fmt.Printf("\u2717 %s has local modifications, skipped. Use --force to overwrite them.\n", n)
+		case err != nil:
+			failed++
+			fmt.Printf("\u2717 %v\n", err)
+		case entry.Version == before:
+			fmt.Printf("\u2713 %s %s is up to date\n", n, entry.Version)
+		default:
+			fmt.Printf("\u2713 %s updated %s \u2192 %s (undo with 'picoclaw skills rollback %s')\n",
+				n, before, entry.Version, n)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d skill(s) not updated", failed)
+	}
+	return nil
+}
+
+func skillsRollbackCmd(workspace, name string) error {
+	entry, err := skills.NewUpdater(workspace, nil).Rollback(name)
+	if err != nil {
+		return fmt.Errorf("\u2717 %w", err)
+	}
+	fmt.Printf("\u2713 %s rolled back to %s\n", name, entry.Version)
+	return nil
+}
+
 func skillsRemoveCmd(installer *skills.SkillInstaller, skillName string) {
 	fmt.Printf("Removing skill '%s'...\n", skillName)
 
@@ -257,9 +367,41 @@ func skillsShowCmd(loader *skills.SkillsLoader, skillName string) {
 
 	fmt.Printf("\n📦 Skill: %s\n", skillName)
 	fmt.Println("----------------------")
+	for _, info := range loader.ListSkills() {
+		if info.Name == skillName {
+			printSkillManifest(info)
+			break
+		}
+	}
 	fmt.Println(content)
 }
 
+// printSkillManifest prints what the frontmatter of a skill declares.
+func printSkillManifest(info skills.SkillInfo) {
+	if info.Version != "" {
+		fmt.Printf("Version:     %s\n", info.Version)
+	}
+	if !info.Available() {
+		fmt.Printf("Status:      unavailable because %s\n", info.Unavailable)
+	}
+	if len(info.Requires.Bins) > 0 {
+		fmt.Printf("Binaries:    %s\n", strings.Join(info.Requires.Bins, ", "))
+	}
+	if len(info.Requires.Env) > 0 {
+		fmt.Printf("Env vars:    %s\n", strings.Join(info.Requires.Env, ", "))
+	}
+	if len(info.Requires.Tools) > 0 {
+		fmt.Printf("Uses tools:  %s\n", strings.Join(info.Requires.Tools, ", "))
+	}
+	if perms := info.Needs(); len(perms) > 0 {
+		fmt.Printf("Permissions: %s\n", strings.Join(perms, ", "))
+	}
+	for _, spec := range info.Tools {
+		fmt.Printf("Tool:        %s - %s\n", spec.Name, spec.Description)
+	}
+	fmt.Println()
+}
+
 func copyDirectory(src, dst string) error {
 	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
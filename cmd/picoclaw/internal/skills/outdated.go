@@ -0,0 +1,25 @@
+package skills
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func newOutdatedCommand(configFn func() (*config.Config, error)) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "outdated",
+		Short:   "List registry skills with newer versions",
+		Args:    cobra.NoArgs,
+		Example: `picoclaw skills outdated`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			cfg, err := configFn()
+			if err != nil {
+				return err
+			}
+			return skillsOutdatedCmd(cfg)
+		},
+	}
+
+	return cmd
+}
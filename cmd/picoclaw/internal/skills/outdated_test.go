@@ -0,0 +1,27 @@
+package skills
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewOutdatedSubcommand(t *testing.T) {
+	cmd := newOutdatedCommand(nil)
+
+	require.NotNil(t, cmd)
+
+	assert.Equal(t, "outdated", cmd.Use)
+	assert.Equal(t, "List registry skills with newer versions", cmd.Short)
+
+	assert.Nil(t, cmd.Run)
+	assert.NotNil(t, cmd.RunE)
+
+	assert.True(t, cmd.HasExample())
+	assert.False(t, cmd.HasSubCommands())
+
+	assert.False(t, cmd.HasFlags())
+
+	assert.Len(t, cmd.Aliases, 0)
+}
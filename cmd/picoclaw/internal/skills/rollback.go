@@ -0,0 +1,21 @@
+package skills
+
+import "github.com/spf13/cobra"
+
+func newRollbackCommand(workspaceFn func() (string, error)) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "rollback",
+		Short:   "Restore the version replaced by the last update",
+		Args:    cobra.ExactArgs(1),
+		Example: `picoclaw skills rollback github`,
+		RunE: func(_ *cobra.Command, args []string) error {
+			workspace, err := workspaceFn()
+			if err != nil {
+				return err
+			}
+			return skillsRollbackCmd(workspace, args[0])
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,35 @@
+package skills
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func newUpdateCommand(configFn func() (*config.Config, error)) *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "update [name]",
+		Short: "Update registry skills to their latest version",
+		Args:  cobra.MaximumNArgs(1),
+		Example: `picoclaw skills update
+picoclaw skills update github
+picoclaw skills update github --force`,
+		RunE: func(_ *cobra.Command, args []string) error {
+			cfg, err := configFn()
+			if err != nil {
+				return err
+			}
+			name := ""
+			if len(args) == 1 {
+				name = args[0]
+			}
+			return skillsUpdateCmd(cfg, name, force)
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite local modifications (they are kept for rollback)")
+
+	return cmd
+}
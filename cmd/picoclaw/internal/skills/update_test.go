@@ -0,0 +1,28 @@
+package skills
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUpdateSubcommand(t *testing.T) {
+	cmd := newUpdateCommand(nil)
+
+	require.NotNil(t, cmd)
+
+	assert.Equal(t, "update [name]", cmd.Use)
+	assert.Equal(t, "Update registry skills to their latest version", cmd.Short)
+
+	assert.Nil(t, cmd.Run)
+	assert.NotNil(t, cmd.RunE)
+
+	assert.True(t, cmd.HasExample())
+	assert.False(t, cmd.HasSubCommands())
+
+	assert.True(t, cmd.HasFlags())
+	assert.NotNil(t, cmd.Flags().Lookup("force"))
+
+	assert.Len(t, cmd.Aliases, 0)
+}
@@ -34,11 +34,14 @@ func TestNewPicoclawCommand(t *testing.T) {
 
 	allowedCommands := []string{
 		"agent",
+		"audit",
 		"auth",
 		"cron",
 		"gateway",
+		"heartbeat",
 		"migrate",
 		"onboard",
+		"secrets",
 		"skills",
 		"status",
 		"version",
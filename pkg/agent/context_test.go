@@ -1,6 +1,9 @@
 package agent
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/sipeed/picoclaw/pkg/providers"
@@ -207,3 +210,42 @@ func assertRoles(t *testing.T, msgs []providers.Message, expected ...string) {
 		}
 	}
 }
+
+func TestBuildMessages_RelevantSkills(t *testing.T) {
+	workspace := t.TempDir()
+	for name, desc := range map[string]string{
+		"weather":  "Forecasts for any city",
+		"github":   "Issues and pull requests",
+		"calendar": "Meetings and events",
+	} {
+		dir := filepath.Join(workspace, "skills", name)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		skill := "---\nname: " + name + "\ndescription: " + desc + "\n---\n"
+		if err := os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte(skill), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cb := NewContextBuilder(workspace)
+	if prompt := cb.BuildSystemPrompt(); !strings.Contains(prompt, "<name>calendar</name>") {
+		t.Fatal("without a limit every skill should be in the static prompt")
+	}
+
+	cb.SetSkillsTopK(2)
+	cb.InvalidateCache()
+	static := cb.BuildSystemPromptWithCache()
+	if strings.Contains(static, "<name>") || !strings.Contains(static, "3 skills extend") {
+		t.Errorf("static prompt lists skills: %s", static)
+	}
+
+	system := cb.BuildMessages(nil, "", "Will it rain? Check the forecast", nil, "cli", "direct")[0].Content
+	if !strings.Contains(system, "<name>weather</name>") || strings.Contains(system, "<name>github</name>") {
+		t.Errorf("relevant skills not listed for the message: %s", system)
+	}
+	system = cb.BuildMessages(nil, "", "hello", nil, "cli", "direct")[0].Content
+	if !strings.Contains(system, "No installed skill matches") {
+		t.Errorf("unrelated message: %s", system)
+	}
+}
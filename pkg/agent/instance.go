@@ -1,14 +1,20 @@
 package agent
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
 	"github.com/sipeed/picoclaw/pkg/providers"
 	"github.com/sipeed/picoclaw/pkg/routing"
 	"github.com/sipeed/picoclaw/pkg/session"
+	"github.com/sipeed/picoclaw/pkg/skills"
 	"github.com/sipeed/picoclaw/pkg/tools"
 )
 
@@ -31,6 +37,10 @@ type AgentInstance struct {
 	Subagents      *config.SubagentsConfig
 	SkillsFilter   []string
 	Candidates     []providers.FallbackCandidate
+
+	execTool   *tools.ExecTool
+	skillMu    sync.Mutex
+	skillTools map[string]bool // Names of registered skill tools
 }
 
 // NewAgentInstance creates an agent instance from config.
@@ -51,7 +61,8 @@ func NewAgentInstance(
 	toolsRegistry.Register(tools.NewReadFileTool(workspace, restrict))
 	toolsRegistry.Register(tools.NewWriteFileTool(workspace, restrict))
 	toolsRegistry.Register(tools.NewListDirTool(workspace, restrict))
-	toolsRegistry.Register(tools.NewExecToolWithConfig(workspace, restrict, cfg))
+	execTool := tools.NewExecToolWithConfig(workspace, restrict, cfg)
+	toolsRegistry.Register(execTool)
 	toolsRegistry.Register(tools.NewEditFileTool(workspace, restrict))
 	toolsRegistry.Register(tools.NewAppendFileTool(workspace, restrict))
 
@@ -59,6 +70,15 @@ func NewAgentInstance(
 	sessionsManager := session.NewSessionManager(sessionsDir)
 
 	contextBuilder := NewContextBuilder(workspace)
+	contextBuilder.skillsLoader.SetToolChecker(func(name string) bool {
+		_, ok := toolsRegistry.Get(name)
+		return ok
+	})
+	if cfg != nil {
+		contextBuilder.skillsLoader.SetDeniedPermissions(cfg.Tools.Skills.DeniedPermissions)
+		contextBuilder.SetSkillsTopK(cfg.Tools.Skills.SummaryTopK)
+	}
+	toolsRegistry.Register(tools.NewUseSkillTool(contextBuilder.skillsLoader, workspace))
 
 	agentID := routing.DefaultAgentID
 	agentName := ""
@@ -94,7 +114,12 @@ func NewAgentInstance(
 	}
 	candidates := providers.ResolveCandidates(modelCfg, defaults.Provider)
 
-	return &AgentInstance{
+	contextWindow := maxTokens
+	if detected := detectContextWindow(agentID, provider, model); detected > 0 {
+		contextWindow = detected
+	}
+
+	agent := &AgentInstance{
 		ID:             agentID,
 		Name:           agentName,
 		Model:          model,
@@ -103,7 +128,7 @@ func NewAgentInstance(
 		MaxIterations:  maxIter,
 		MaxTokens:      maxTokens,
 		Temperature:    temperature,
-		ContextWindow:  maxTokens,
+		ContextWindow:  contextWindow,
 		Provider:       provider,
 		Sessions:       sessionsManager,
 		ContextBuilder: contextBuilder,
@@ -111,7 +136,81 @@ func NewAgentInstance(
 		Subagents:      subagents,
 		SkillsFilter:   skillsFilter,
 		Candidates:     candidates,
+		execTool:       execTool,
+	}
+	contextBuilder.SetSkillsObserver(agent.syncSkillTools)
+	return agent
+}
+
+// syncSkillTools registers the script-backed tools of the available skills
+// and removes those of skills that were deleted or became unavailable. A
+// skill tool never replaces a built-in tool of the same name.
+func (a *AgentInstance) syncSkillTools(list []skills.SkillInfo) {
+	a.skillMu.Lock()
+	defer a.skillMu.Unlock()
+
+	want := make(map[string]tools.Tool)
+	for _, s := range list {
+		if !s.Available() {
+			continue
+		}
+		for _, spec := range s.Tools {
+			if _, taken := want[spec.Name]; taken {
+				continue
+			}
+			if _, exists := a.Tools.Get(spec.Name); exists && !a.skillTools[spec.Name] {
+				logger.WarnCF("agent", "Skill tool shadows a built-in tool, skipping",
+					map[string]any{"skill": s.Name, "tool": spec.Name})
+				continue
+			}
+			want[spec.Name] = tools.NewSkillTool(s, spec, a.execTool)
+		}
+	}
+
+	for name := range a.skillTools {
+		if _, ok := want[name]; !ok {
+			a.Tools.Unregister(name)
+		}
+	}
+	registered := make(map[string]bool, len(want))
+	for name, tool := range want {
+		a.Tools.Register(tool)
+		registered[name] = true
+	}
+	a.skillTools = registered
+}
+
+// capabilityTimeout bounds the startup lookup of model capabilities.
+const capabilityTimeout = 10 * time.Second
+
+// detectContextWindow asks providers that can report model capabilities for
+// the context window of the model. It returns 0 when unknown.
+func detectContextWindow(agentID string, provider providers.LLMProvider, model string) int {
+	cp, ok := provider.(providers.CapabilityProvider)
+	if !ok {
+		return 0
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), capabilityTimeout)
+	defer cancel()
+
+	caps, err := cp.ModelCapabilities(ctx, model)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return 0
+	}
+	if err != nil {
+		logger.WarnCF("agent", "Model capability detection failed",
+			map[string]any{"agent_id": agentID, "model": model, "error": err.Error()})
+		return 0
 	}
+	logger.InfoCF("agent", "Detected model capabilities",
+		map[string]any{
+			"agent_id":       agentID,
+			"model":          model,
+			"context_window": caps.ContextWindow,
+			"vision":         caps.Vision,
+			"tools":          caps.Tools,
+		})
+	return caps.ContextWindow
 }
 
 // resolveAgentWorkspace determines the workspace directory for an agent.
@@ -1,10 +1,13 @@
 package agent
 
 import (
+	"context"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/providers"
 )
 
 func TestNewAgentInstance_UsesDefaultsTemperatureAndMaxTokens(t *testing.T) {
@@ -93,3 +96,80 @@ func TestNewAgentInstance_DefaultsTemperatureWhenUnset(t *testing.T) {
 		t.Fatalf("Temperature = %f, want %f", agent.Temperature, 0.7)
 	}
 }
+
+// capabilityProvider reports a fixed context window for any model.
+type capabilityProvider struct {
+	mockProvider
+	contextWindow int
+}
+
+func (p *capabilityProvider) ModelCapabilities(ctx context.Context, model string) (*providers.ModelCapabilities, error) {
+	return &providers.ModelCapabilities{ContextWindow: p.contextWindow, Tools: true}, nil
+}
+
+func TestNewAgentInstance_UsesDetectedContextWindow(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace: t.TempDir(),
+				Model:     "local-model",
+				MaxTokens: 1024,
+			},
+		},
+	}
+
+	agent := NewAgentInstance(nil, &cfg.Agents.Defaults, cfg, &capabilityProvider{contextWindow: 32768})
+	if agent.ContextWindow != 32768 {
+		t.Fatalf("ContextWindow = %d, want detected 32768", agent.ContextWindow)
+	}
+
+	agent = NewAgentInstance(nil, &cfg.Agents.Defaults, cfg, &mockProvider{})
+	if agent.ContextWindow != 1024 {
+		t.Fatalf("ContextWindow = %d, want MaxTokens fallback 1024", agent.ContextWindow)
+	}
+}
+
+func TestAgentInstance_SyncsSkillTools(t *testing.T) {
+	workspace := t.TempDir()
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{Workspace: workspace, Model: "test-model"},
+		},
+	}
+	agent := NewAgentInstance(nil, &cfg.Agents.Defaults, cfg, &mockProvider{})
+
+	skillDir := filepath.Join(workspace, "skills", "clock")
+	os.MkdirAll(skillDir, 0o755)
+	os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte(`---
+name: clock
+description: Tell the time
+tools:
+  - name: now
+    description: Current time
+    command: date
+  - name: exec
+    description: Shadows a built-in tool
+    command: "true"
+---
+`), 0o644)
+
+	agent.ContextBuilder.BuildSystemPromptWithCache()
+	if _, ok := agent.Tools.Get("now"); !ok {
+		t.Fatal("skill tool not registered")
+	}
+	if _, ok := agent.Tools.Get("exec"); !ok {
+		t.Fatal("built-in exec tool missing")
+	} else if tool, _ := agent.Tools.Get("exec"); tool.Description() == "Shadows a built-in tool (from skill clock)" {
+		t.Error("skill tool replaced the built-in exec tool")
+	}
+
+	os.RemoveAll(skillDir)
+	agent.ContextBuilder.InvalidateCache()
+	agent.ContextBuilder.BuildSystemPromptWithCache()
+	if _, ok := agent.Tools.Get("now"); ok {
+		t.Error("tool of a removed skill is still registered")
+	}
+	if _, ok := agent.Tools.Get("exec"); !ok {
+		t.Error("removing the skill unregistered the built-in exec tool")
+	}
+}
@@ -10,22 +10,29 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 	"unicode/utf8"
 
+	"github.com/sipeed/picoclaw/pkg/audit"
 	"github.com/sipeed/picoclaw/pkg/bus"
 	"github.com/sipeed/picoclaw/pkg/channels"
 	"github.com/sipeed/picoclaw/pkg/config"
 	"github.com/sipeed/picoclaw/pkg/constants"
+	"github.com/sipeed/picoclaw/pkg/cron"
 	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/permissions"
 	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/redact"
 	"github.com/sipeed/picoclaw/pkg/routing"
+	"github.com/sipeed/picoclaw/pkg/session"
 	"github.com/sipeed/picoclaw/pkg/skills"
 	"github.com/sipeed/picoclaw/pkg/state"
 	"github.com/sipeed/picoclaw/pkg/tools"
+	"github.com/sipeed/picoclaw/pkg/tracing"
 	"github.com/sipeed/picoclaw/pkg/utils"
 )
 
@@ -38,6 +45,14 @@ type AgentLoop struct {
 	summarizing    sync.Map
 	fallback       *providers.FallbackChain
 	channelManager *channels.Manager
+
+	modelProviders   map[string]*modelProvider
+	modelProvidersMu sync.Mutex
+
+	responseCache *providers.ResponseCache
+	cacheSites    map[string]bool
+
+	permissions *permissions.Policy
 }
 
 // processOptions configures how a message is processed
@@ -45,16 +60,23 @@ type processOptions struct {
 	SessionKey      string // Session identifier for history/context
 	Channel         string // Target channel for tool execution
 	ChatID          string // Target chat ID for tool execution
+	SenderID        string // Who caused this turn, for the audit log
 	UserMessage     string // User message content (may include prefix)
 	DefaultResponse string // Response when LLM returns empty
 	EnableSummary   bool   // Whether to trigger summarization
 	SendResponse    bool   // Whether to send response via bus
 	NoHistory       bool   // If true, don't load session history (for heartbeat)
+	CacheSite       string // Response cache call site, if any
+	Untrusted       string // Source of untrusted content in UserMessage; taints the turn
+
+	Overrides session.Overrides // Per-session model/temperature/iteration overrides
 }
 
 const defaultResponse = "I've completed processing but have no response to give. Increase `max_tool_iterations` in config.json."
 
 func NewAgentLoop(cfg *config.Config, msgBus *bus.MessageBus, provider providers.LLMProvider) *AgentLoop {
+	// Every LLM request (turns, summaries, subagents) is counted in /metrics.
+	provider = providers.NewMeteredProvider(provider, providers.ProviderLabeler(cfg.ModelList))
 	registry := NewAgentRegistry(cfg, provider)
 
 	// Register shared tools to all agents
@@ -64,6 +86,15 @@ func NewAgentLoop(cfg *config.Config, msgBus *bus.MessageBus, provider providers
 	cooldown := providers.NewCooldownTracker()
 	fallbackChain := providers.NewFallbackChain(cooldown)
 
+	// Rate limits are shared by every provider built from the same model_list entry.
+	providers.RegisterRateLimits(cfg.ModelList)
+	if maxWait := cfg.Agents.Defaults.MaxQueueWaitSeconds; maxWait > 0 {
+		fallbackChain.SetQueueWaitLimit(time.Duration(maxWait)*time.Second,
+			func(c providers.FallbackCandidate) time.Duration {
+				return providers.EstimateRateLimitWait(c.Model)
+			})
+	}
+
 	// Create state manager using default agent's workspace for channel recording
 	defaultAgent := registry.GetDefaultAgent()
 	var stateManager *state.Manager
@@ -71,13 +102,18 @@ func NewAgentLoop(cfg *config.Config, msgBus *bus.MessageBus, provider providers
 		stateManager = state.NewManager(defaultAgent.Workspace)
 	}
 
+	responseCache, cacheSites := newResponseCache(cfg)
+
 	return &AgentLoop{
-		bus:         msgBus,
-		cfg:         cfg,
-		registry:    registry,
-		state:       stateManager,
-		summarizing: sync.Map{},
-		fallback:    fallbackChain,
+		bus:           msgBus,
+		cfg:           cfg,
+		registry:      registry,
+		state:         stateManager,
+		summarizing:   sync.Map{},
+		fallback:      fallbackChain,
+		responseCache: responseCache,
+		cacheSites:    cacheSites,
+		permissions:   permissions.NewPolicy(cfg.Permissions, cfg.Session.IdentityLinks),
 	}
 }
 
@@ -114,27 +150,24 @@ func registerSharedTools(
 		}
 		agent.Tools.Register(tools.NewWebFetchToolWithProxy(50000, cfg.Tools.Web.Proxy))
 
-		// Hardware tools (I2C, SPI) - Linux only, returns error on other platforms
+		// Hardware tools (I2C, SPI, GPIO, PWM, serial, sensors) - Linux only, returns error on other platforms
 		agent.Tools.Register(tools.NewI2CTool())
 		agent.Tools.Register(tools.NewSPITool())
+		agent.Tools.Register(tools.NewGPIOTool())
+		agent.Tools.Register(tools.NewPWMTool())
+		agent.Tools.Register(tools.NewSerialTool())
+		agent.Tools.Register(tools.NewSensorReadTool(agent.Workspace))
 
 		// Message tool
 		messageTool := tools.NewMessageTool()
-		messageTool.SetSendCallback(func(channel, chatID, content string) error {
-			msgBus.PublishOutbound(bus.OutboundMessage{
-				Channel: channel,
-				ChatID:  chatID,
-				Content: content,
-			})
+		messageTool.SetSendCallback(func(ctx context.Context, channel, chatID, content string) error {
+			msgBus.PublishOutbound(outboundMessage(ctx, channel, chatID, content))
 			return nil
 		})
 		agent.Tools.Register(messageTool)
 
 		// Skill discovery and installation tools
-		registryMgr := skills.NewRegistryManagerFromConfig(skills.RegistryConfig{
-			MaxConcurrentSearches: cfg.Tools.Skills.MaxConcurrentSearches,
-			ClawHub:               skills.ClawHubConfig(cfg.Tools.Skills.Registries.ClawHub),
-		})
+		registryMgr := skills.NewRegistryManagerFromConfig(skills.RegistryConfigFrom(cfg))
 		searchCache := skills.NewSearchCache(
 			cfg.Tools.Skills.SearchCache.MaxSize,
 			time.Duration(cfg.Tools.Skills.SearchCache.TTLSeconds)*time.Second,
@@ -145,6 +178,7 @@ func registerSharedTools(
 		// Spawn tool with allowlist checker
 		subagentManager := tools.NewSubagentManager(provider, agent.Model, agent.Workspace, msgBus)
 		subagentManager.SetLLMOptions(agent.MaxTokens, agent.Temperature)
+		subagentManager.SetUntrustedPolicy(cfg.Tools.UntrustedContent.Policy, cfg.Tools.UntrustedContent.HighRiskTools)
 		spawnTool := tools.NewSpawnTool(subagentManager)
 		currentAgentID := agentID
 		spawnTool.SetAllowlistChecker(func(targetAgentID string) bool {
@@ -167,8 +201,14 @@ func (al *AgentLoop) Run(ctx context.Context) error {
 				continue
 			}
 
-			response, err := al.processMessage(ctx, msg)
+			msgCtx, span := tracing.Start(ctx, "inbound.receive", map[string]any{
+				"channel":   msg.Channel,
+				"chat_id":   msg.ChatID,
+				"sender_id": msg.SenderID,
+			})
+			response, actor, err := al.routeMessage(msgCtx, msg)
 			if err != nil {
+				span.SetError(err)
 				response = fmt.Sprintf("Error processing message: %v", err)
 			}
 
@@ -187,13 +227,11 @@ func (al *AgentLoop) Run(ctx context.Context) error {
 				}
 
 				if !alreadySent {
-					al.bus.PublishOutbound(bus.OutboundMessage{
-						Channel: msg.Channel,
-						ChatID:  msg.ChatID,
-						Content: response,
-					})
+					al.bus.PublishOutbound(outboundMessage(audit.WithActor(msgCtx, actor),
+						msg.Channel, msg.ChatID, response))
 				}
 			}
+			span.End()
 		}
 	}
 
@@ -235,13 +273,29 @@ func (al *AgentLoop) RecordLastChatID(chatID string) error {
 }
 
 func (al *AgentLoop) ProcessDirect(ctx context.Context, content, sessionKey string) (string, error) {
-	return al.ProcessDirectWithChannel(ctx, content, sessionKey, "cli", "direct")
+	return al.processDirect(ctx, content, sessionKey, "cli", "direct")
 }
 
+// ProcessDirectWithChannel runs a scheduled (cron) turn. It runs under the
+// role of the sender who scheduled the job (see cron.CreatorFrom), or the
+// system role when no user did.
 func (al *AgentLoop) ProcessDirectWithChannel(
 	ctx context.Context,
 	content, sessionKey, channel, chatID string,
 ) (string, error) {
+	access := al.permissions.ResolveSystem("cron")
+	if creator := cron.CreatorFrom(ctx); creator != nil {
+		if creatorAccess := al.permissions.Resolve(creator.Channel, creator.SenderID); creatorAccess != nil {
+			access = creatorAccess
+		}
+	}
+	if access != nil {
+		ctx = permissions.WithAccess(ctx, access)
+	}
+	return al.processDirect(ctx, content, sessionKey, channel, chatID)
+}
+
+func (al *AgentLoop) processDirect(ctx context.Context, content, sessionKey, channel, chatID string) (string, error) {
 	msg := bus.InboundMessage{
 		Channel:    channel,
 		SenderID:   "cron",
@@ -256,20 +310,93 @@ func (al *AgentLoop) ProcessDirectWithChannel(
 // ProcessHeartbeat processes a heartbeat request without session history.
 // Each heartbeat is independent and doesn't accumulate context.
 func (al *AgentLoop) ProcessHeartbeat(ctx context.Context, content, channel, chatID string) (string, error) {
-	agent := al.registry.GetDefaultAgent()
+	return al.ProcessHeartbeatWithAgent(ctx, "", content, channel, chatID)
+}
+
+// ProcessHeartbeatWithAgent is ProcessHeartbeat on agentID, or the default
+// agent when it is empty or unknown.
+func (al *AgentLoop) ProcessHeartbeatWithAgent(ctx context.Context, agentID, content, channel, chatID string) (string, error) {
+	agent, ok := al.registry.GetAgent(agentID)
+	if !ok {
+		agent = al.registry.GetDefaultAgent()
+	}
+	if access := al.permissions.ResolveSystem("heartbeat"); access != nil {
+		ctx = permissions.WithAccess(ctx, access)
+	}
 	return al.runAgentLoop(ctx, agent, processOptions{
 		SessionKey:      "heartbeat",
 		Channel:         channel,
 		ChatID:          chatID,
+		SenderID:        "heartbeat",
 		UserMessage:     content,
 		DefaultResponse: defaultResponse,
 		EnableSummary:   false,
 		SendResponse:    false,
 		NoHistory:       true, // Don't load session history for heartbeat
+		CacheSite:       cacheSiteHeartbeat,
+	})
+}
+
+// ProcessTrigger runs an event-triggered prompt on agentID, or the default
+// agent when it is empty or unknown, in the session
+// "agent:<id>:<sessionKey>". The response is returned, not sent. The prompt
+// carries event data, so the turn runs under the system role and counts as
+// having read untrusted content.
+func (al *AgentLoop) ProcessTrigger(ctx context.Context, agentID, sessionKey, content, channel, chatID string) (string, error) {
+	agent := al.triggerAgent(agentID)
+	if access := al.permissions.ResolveSystem(sessionKey); access != nil {
+		ctx = permissions.WithAccess(ctx, access)
+	}
+	return al.runAgentLoop(ctx, agent, processOptions{
+		SessionKey:      "agent:" + agent.ID + ":" + sessionKey,
+		Channel:         channel,
+		ChatID:          chatID,
+		SenderID:        "trigger",
+		UserMessage:     content,
+		DefaultResponse: defaultResponse,
+		EnableSummary:   true,
+		SendResponse:    false,
+		Untrusted:       sessionKey,
 	})
 }
 
+// ResolveAgentID returns the ID of the agent ProcessTrigger runs for agentID.
+func (al *AgentLoop) ResolveAgentID(agentID string) string {
+	return al.triggerAgent(agentID).ID
+}
+
+func (al *AgentLoop) triggerAgent(agentID string) *AgentInstance {
+	if agent, ok := al.registry.GetAgent(agentID); ok {
+		return agent
+	}
+	return al.registry.GetDefaultAgent()
+}
+
 func (al *AgentLoop) processMessage(ctx context.Context, msg bus.InboundMessage) (string, error) {
+	response, _, err := al.routeMessage(ctx, msg)
+	return response, err
+}
+
+// outboundMessage builds a message attributed to the turn in ctx, so the
+// audit log records which agent, session and sender produced it.
+func outboundMessage(ctx context.Context, channel, chatID, content string) bus.OutboundMessage {
+	actor := audit.ActorFrom(ctx)
+	return bus.OutboundMessage{
+		Channel:     channel,
+		ChatID:      chatID,
+		Content:     content,
+		Agent:       actor.Agent,
+		SessionKey:  actor.SessionKey,
+		Sender:      actor.Sender,
+		Traceparent: tracing.Traceparent(ctx),
+	}
+}
+
+// routeMessage processes msg and also returns who handles it, for
+// attributing the reply.
+func (al *AgentLoop) routeMessage(ctx context.Context, msg bus.InboundMessage) (string, audit.Actor, error) {
+	actor := audit.Actor{Channel: msg.Channel, ChatID: msg.ChatID, Sender: msg.SenderID}
+
 	// Add message preview to log (show full content for error messages)
 	var logContent string
 	if strings.Contains(msg.Content, "Error:") || strings.Contains(msg.Content, "error") {
@@ -278,24 +405,21 @@ func (al *AgentLoop) processMessage(ctx context.Context, msg bus.InboundMessage)
 		logContent = utils.Truncate(msg.Content, 80)
 	}
 	logger.InfoCF("agent", fmt.Sprintf("Processing message from %s:%s: %s", msg.Channel, msg.SenderID, logContent),
-		map[string]any{
+		tracing.Fields(ctx, map[string]any{
 			"channel":     msg.Channel,
 			"chat_id":     msg.ChatID,
 			"sender_id":   msg.SenderID,
 			"session_key": msg.SessionKey,
-		})
+		}))
 
 	// Route system messages to processSystemMessage
 	if msg.Channel == "system" {
-		return al.processSystemMessage(ctx, msg)
-	}
-
-	// Check for commands
-	if response, handled := al.handleCommand(ctx, msg); handled {
-		return response, nil
+		response, err := al.processSystemMessage(ctx, msg)
+		return response, actor, err
 	}
 
 	// Route to determine agent and session key
+	_, routeSpan := tracing.Start(ctx, "route", nil)
 	route := al.registry.ResolveRoute(routing.RouteInput{
 		Channel:    msg.Channel,
 		AccountID:  msg.Metadata["account_id"],
@@ -309,29 +433,69 @@ func (al *AgentLoop) processMessage(ctx context.Context, msg bus.InboundMessage)
 	if !ok {
 		agent = al.registry.GetDefaultAgent()
 	}
+	routeSpan.SetAttr("agent_id", agent.ID)
+	routeSpan.SetAttr("matched_by", route.MatchedBy)
+	routeSpan.End()
 
 	// Use routed session key, but honor pre-set agent-scoped keys (for ProcessDirect/cron)
 	sessionKey := route.SessionKey
 	if msg.SessionKey != "" && strings.HasPrefix(msg.SessionKey, "agent:") {
 		sessionKey = msg.SessionKey
 	}
+	actor.Agent, actor.SessionKey = agent.ID, sessionKey
+
+	// Resolve the sender's role, unless the caller already set one (cron);
+	// tool execution checks it again via ctx.
+	access := permissions.AccessFrom(ctx)
+	if access == nil {
+		access = al.permissions.Resolve(msg.Channel, msg.SenderID)
+	}
+	if access != nil {
+		ctx = permissions.WithAccess(ctx, access)
+	}
+
+	// Check for commands
+	if response, handled := al.handleCommand(ctx, msg, agent, sessionKey); handled {
+		return response, actor, nil
+	}
+
+	// Overrides live on the routed session; an /agent override hands the
+	// conversation to another agent with its own history.
+	overrides := agent.Sessions.GetOverrides(sessionKey)
+	agent, sessionKey = al.bindSessionAgent(agent, sessionKey, overrides)
+	actor.Agent, actor.SessionKey = agent.ID, sessionKey
+
+	if access != nil {
+		if !access.AllowsAgent(agent.ID) {
+			logger.WarnCF("agent", "Sender may not reach agent",
+				map[string]any{"agent_id": agent.ID, "identity": access.Identity, "role": access.Role})
+			return fmt.Sprintf("You don't have access to agent %s.", agent.ID), actor, nil
+		}
+		if al.permissions.OverBudget(access) {
+			return fmt.Sprintf("Your daily token budget (%d) is used up. It resets at midnight.",
+				access.DailyTokenBudget()), actor, nil
+		}
+	}
 
 	logger.InfoCF("agent", "Routed message",
-		map[string]any{
+		tracing.Fields(ctx, map[string]any{
 			"agent_id":    agent.ID,
 			"session_key": sessionKey,
 			"matched_by":  route.MatchedBy,
-		})
+		}))
 
-	return al.runAgentLoop(ctx, agent, processOptions{
+	response, err := al.runAgentLoop(ctx, agent, processOptions{
 		SessionKey:      sessionKey,
 		Channel:         msg.Channel,
 		ChatID:          msg.ChatID,
+		SenderID:        msg.SenderID,
 		UserMessage:     msg.Content,
 		DefaultResponse: defaultResponse,
 		EnableSummary:   true,
 		SendResponse:    false,
+		Overrides:       overrides,
 	})
+	return response, actor, err
 }
 
 func (al *AgentLoop) processSystemMessage(ctx context.Context, msg bus.InboundMessage) (string, error) {
@@ -376,6 +540,16 @@ func (al *AgentLoop) processSystemMessage(ctx context.Context, msg bus.InboundMe
 	// Use default agent for system messages
 	agent := al.registry.GetDefaultAgent()
 
+	// Run with the role of the turn that started the background work, or the
+	// system role when it is not known.
+	access := al.permissions.ResolveSystem(msg.SenderID)
+	if role := msg.Metadata[permissions.MetadataRole]; role != "" {
+		access = al.permissions.ResolveRole(msg.Metadata[permissions.MetadataIdentity], role)
+	}
+	if access != nil {
+		ctx = permissions.WithAccess(ctx, access)
+	}
+
 	// Use the origin session for context
 	sessionKey := routing.BuildAgentMainSessionKey(agent.ID)
 
@@ -383,6 +557,7 @@ func (al *AgentLoop) processSystemMessage(ctx context.Context, msg bus.InboundMe
 		SessionKey:      sessionKey,
 		Channel:         originChannel,
 		ChatID:          originChatID,
+		SenderID:        msg.SenderID,
 		UserMessage:     fmt.Sprintf("[System: %s] %s", msg.SenderID, msg.Content),
 		DefaultResponse: "Background task completed.",
 		EnableSummary:   false,
@@ -392,6 +567,15 @@ func (al *AgentLoop) processSystemMessage(ctx context.Context, msg bus.InboundMe
 
 // runAgentLoop is the core message processing logic.
 func (al *AgentLoop) runAgentLoop(ctx context.Context, agent *AgentInstance, opts processOptions) (string, error) {
+	// Child of inbound.receive for bus messages; the root span of the turn
+	// for direct, cron and heartbeat calls.
+	ctx, span := tracing.Start(ctx, "agent.run", map[string]any{
+		"agent_id":    agent.ID,
+		"session_key": opts.SessionKey,
+		"channel":     opts.Channel,
+	})
+	defer span.End()
+
 	// 0. Record last channel for heartbeat notifications (skip internal channels)
 	if opts.Channel != "" && opts.ChatID != "" {
 		// Don't record internal channels (cli, system, subagent)
@@ -403,8 +587,15 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, agent *AgentInstance, opt
 		}
 	}
 
-	// 1. Update tool contexts
+	// 1. Update tool contexts and attribute tool calls in the audit log
 	al.updateToolContexts(agent, opts.Channel, opts.ChatID)
+	ctx = audit.WithActor(ctx, audit.Actor{
+		Agent:      agent.ID,
+		SessionKey: opts.SessionKey,
+		Channel:    opts.Channel,
+		ChatID:     opts.ChatID,
+		Sender:     opts.SenderID,
+	})
 
 	// 2. Build messages (skip history for heartbeat)
 	var history []providers.Message
@@ -413,6 +604,7 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, agent *AgentInstance, opt
 		history = agent.Sessions.GetHistory(opts.SessionKey)
 		summary = agent.Sessions.GetSummary(opts.SessionKey)
 	}
+	_, buildSpan := tracing.Start(ctx, "context.build", nil)
 	messages := agent.ContextBuilder.BuildMessages(
 		history,
 		summary,
@@ -421,6 +613,8 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, agent *AgentInstance, opt
 		opts.Channel,
 		opts.ChatID,
 	)
+	buildSpan.SetAttr("messages", len(messages))
+	buildSpan.End()
 
 	// 3. Save user message to session
 	agent.Sessions.AddMessage(opts.SessionKey, "user", opts.UserMessage)
@@ -428,8 +622,10 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, agent *AgentInstance, opt
 	// 4. Run LLM iteration loop
 	finalContent, iteration, err := al.runLLMIteration(ctx, agent, messages, opts)
 	if err != nil {
+		span.SetError(err)
 		return "", err
 	}
+	span.SetAttr("iterations", iteration)
 
 	// If last tool had ForUser content and we already sent it, we might not need to send final response
 	// This is controlled by the tool's Silent flag and ForUser content
@@ -445,27 +641,23 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, agent *AgentInstance, opt
 
 	// 7. Optional: summarization
 	if opts.EnableSummary {
-		al.maybeSummarize(agent, opts.SessionKey, opts.Channel, opts.ChatID)
+		al.maybeSummarize(ctx, agent, opts.SessionKey, opts.Channel, opts.ChatID)
 	}
 
 	// 8. Optional: send response via bus
 	if opts.SendResponse {
-		al.bus.PublishOutbound(bus.OutboundMessage{
-			Channel: opts.Channel,
-			ChatID:  opts.ChatID,
-			Content: finalContent,
-		})
+		al.bus.PublishOutbound(outboundMessage(ctx, opts.Channel, opts.ChatID, finalContent))
 	}
 
 	// 9. Log response
 	responsePreview := utils.Truncate(finalContent, 120)
 	logger.InfoCF("agent", fmt.Sprintf("Response: %s", responsePreview),
-		map[string]any{
+		tracing.Fields(ctx, map[string]any{
 			"agent_id":     agent.ID,
 			"session_key":  opts.SessionKey,
 			"iterations":   iteration,
 			"final_length": len(finalContent),
-		})
+		}))
 
 	return finalContent, nil
 }
@@ -480,29 +672,46 @@ func (al *AgentLoop) runLLMIteration(
 	iteration := 0
 	var finalContent string
 
-	for iteration < agent.MaxIterations {
+	settings := al.resolveTurnSettings(agent, opts.Overrides)
+	settings.Provider = al.cachedProvider(settings.Provider, opts.CacheSite)
+
+	// Untrusted content read during this turn restricts high-risk tools until
+	// the next user message.
+	untrusted := al.cfg.Tools.UntrustedContent
+	guard := tools.NewUntrustedGuard(untrusted.Policy, untrusted.HighRiskTools, opts.Channel, opts.ChatID)
+	guard.SetTools(agent.Tools)
+	if opts.Untrusted != "" {
+		guard.Taint(opts.Untrusted)
+	}
+
+	for iteration < settings.MaxIterations {
 		iteration++
 
 		logger.DebugCF("agent", "LLM iteration",
 			map[string]any{
 				"agent_id":  agent.ID,
 				"iteration": iteration,
-				"max":       agent.MaxIterations,
+				"max":       settings.MaxIterations,
 			})
 
-		// Build tool definitions
-		providerToolDefs := agent.Tools.ToProviderDefs()
+		// Build tool definitions, limited to what the sender's role permits
+		var providerToolDefs []providers.ToolDefinition
+		if access := permissions.AccessFrom(ctx); access != nil {
+			providerToolDefs = agent.Tools.ToProviderDefsFiltered(access.AllowsTool)
+		} else {
+			providerToolDefs = agent.Tools.ToProviderDefs()
+		}
 
 		// Log LLM request details
 		logger.DebugCF("agent", "LLM request",
 			map[string]any{
 				"agent_id":          agent.ID,
 				"iteration":         iteration,
-				"model":             agent.Model,
+				"model":             settings.Model,
 				"messages_count":    len(messages),
 				"tools_count":       len(providerToolDefs),
 				"max_tokens":        agent.MaxTokens,
-				"temperature":       agent.Temperature,
+				"temperature":       settings.Temperature,
 				"system_prompt_len": len(messages[0].Content),
 			})
 
@@ -518,13 +727,27 @@ func (al *AgentLoop) runLLMIteration(
 		var response *providers.LLMResponse
 		var err error
 
-		callLLM := func() (*providers.LLMResponse, error) {
-			if len(agent.Candidates) > 1 && al.fallback != nil {
-				fbResult, fbErr := al.fallback.Execute(ctx, agent.Candidates,
+		callLLM := func() (resp *providers.LLMResponse, err error) {
+			ctx, span := tracing.Start(ctx, "llm.call", map[string]any{
+				"agent_id":  agent.ID,
+				"model":     settings.Model,
+				"iteration": iteration,
+			})
+			defer func() {
+				if resp != nil && resp.Usage != nil {
+					span.SetAttr("prompt_tokens", resp.Usage.PromptTokens)
+					span.SetAttr("completion_tokens", resp.Usage.CompletionTokens)
+				}
+				span.SetError(err)
+				span.End()
+			}()
+
+			if len(settings.Candidates) > 1 && al.fallback != nil {
+				fbResult, fbErr := al.fallback.Execute(ctx, settings.Candidates,
 					func(ctx context.Context, provider, model string) (*providers.LLMResponse, error) {
-						return agent.Provider.Chat(ctx, messages, providerToolDefs, model, map[string]any{
+						return settings.Provider.Chat(ctx, messages, providerToolDefs, model, map[string]any{
 							"max_tokens":       agent.MaxTokens,
-							"temperature":      agent.Temperature,
+							"temperature":      settings.Temperature,
 							"prompt_cache_key": agent.ID,
 						})
 					},
@@ -535,13 +758,13 @@ func (al *AgentLoop) runLLMIteration(
 				if fbResult.Provider != "" && len(fbResult.Attempts) > 0 {
 					logger.InfoCF("agent", fmt.Sprintf("Fallback: succeeded with %s/%s after %d attempts",
 						fbResult.Provider, fbResult.Model, len(fbResult.Attempts)+1),
-						map[string]any{"agent_id": agent.ID, "iteration": iteration})
+						tracing.Fields(ctx, map[string]any{"agent_id": agent.ID, "iteration": iteration}))
 				}
 				return fbResult.Response, nil
 			}
-			return agent.Provider.Chat(ctx, messages, providerToolDefs, agent.Model, map[string]any{
+			return settings.Provider.Chat(ctx, messages, providerToolDefs, settings.Model, map[string]any{
 				"max_tokens":       agent.MaxTokens,
-				"temperature":      agent.Temperature,
+				"temperature":      settings.Temperature,
 				"prompt_cache_key": agent.ID,
 			})
 		}
@@ -567,11 +790,8 @@ func (al *AgentLoop) runLLMIteration(
 				})
 
 				if retry == 0 && !constants.IsInternalChannel(opts.Channel) {
-					al.bus.PublishOutbound(bus.OutboundMessage{
-						Channel: opts.Channel,
-						ChatID:  opts.ChatID,
-						Content: "Context window exceeded. Compressing history and retrying...",
-					})
+					al.bus.PublishOutbound(outboundMessage(ctx, opts.Channel, opts.ChatID,
+						"Context window exceeded. Compressing history and retrying..."))
 				}
 
 				al.forceCompression(agent, opts.SessionKey)
@@ -588,14 +808,16 @@ func (al *AgentLoop) runLLMIteration(
 
 		if err != nil {
 			logger.ErrorCF("agent", "LLM call failed",
-				map[string]any{
+				tracing.Fields(ctx, map[string]any{
 					"agent_id":  agent.ID,
 					"iteration": iteration,
 					"error":     err.Error(),
-				})
+				}))
 			return "", iteration, fmt.Errorf("LLM call failed after retries: %w", err)
 		}
 
+		al.chargeTokens(ctx, messages, response)
+
 		// Check if no tool calls - we're done
 		if len(response.ToolCalls) == 0 {
 			finalContent = response.Content
@@ -619,12 +841,12 @@ func (al *AgentLoop) runLLMIteration(
 			toolNames = append(toolNames, tc.Name)
 		}
 		logger.InfoCF("agent", "LLM requested tool calls",
-			map[string]any{
+			tracing.Fields(ctx, map[string]any{
 				"agent_id":  agent.ID,
 				"tools":     toolNames,
 				"count":     len(normalizedToolCalls),
 				"iteration": iteration,
-			})
+			}))
 
 		// Build assistant message with tool calls
 		assistantMsg := providers.Message{
@@ -664,11 +886,11 @@ func (al *AgentLoop) runLLMIteration(
 			argsJSON, _ := json.Marshal(tc.Arguments)
 			argsPreview := utils.Truncate(string(argsJSON), 200)
 			logger.InfoCF("agent", fmt.Sprintf("Tool call: %s(%s)", tc.Name, argsPreview),
-				map[string]any{
+				tracing.Fields(ctx, map[string]any{
 					"agent_id":  agent.ID,
 					"tool":      tc.Name,
 					"iteration": iteration,
-				})
+				}))
 
 			// Create async callback for tools that implement AsyncTool
 			// NOTE: Following openclaw's design, async tools do NOT send results directly to users.
@@ -686,22 +908,29 @@ func (al *AgentLoop) runLLMIteration(
 				}
 			}
 
-			toolResult := agent.Tools.ExecuteWithContext(
-				ctx,
-				tc.Name,
-				tc.Arguments,
-				opts.Channel,
-				opts.ChatID,
-				asyncCallback,
-			)
+			toolResult := guard.Check(tc.Name, tc.Arguments)
+			if toolResult != nil {
+				logger.WarnCF("agent", "Tool call held back after untrusted content",
+					map[string]any{
+						"agent_id": agent.ID,
+						"tool":     tc.Name,
+						"policy":   untrusted.Policy,
+					})
+				audit.LogTool(ctx, tc.Name, tc.Arguments, audit.StatusDenied, toolResult.ForLLM, 0)
+			} else {
+				toolResult = agent.Tools.ExecuteWithContext(
+					guard.WithTaint(ctx),
+					tc.Name,
+					tc.Arguments,
+					opts.Channel,
+					opts.ChatID,
+					asyncCallback,
+				)
+			}
 
 			// Send ForUser content to user immediately if not Silent
 			if !toolResult.Silent && toolResult.ForUser != "" && opts.SendResponse {
-				al.bus.PublishOutbound(bus.OutboundMessage{
-					Channel: opts.Channel,
-					ChatID:  opts.ChatID,
-					Content: toolResult.ForUser,
-				})
+				al.bus.PublishOutbound(outboundMessage(ctx, opts.Channel, opts.ChatID, toolResult.ForUser))
 				logger.DebugCF("agent", "Sent tool result to user",
 					map[string]any{
 						"tool":        tc.Name,
@@ -714,6 +943,10 @@ func (al *AgentLoop) runLLMIteration(
 			if contentForLLM == "" && toolResult.Err != nil {
 				contentForLLM = toolResult.Err.Error()
 			}
+			if al.cfg.Redaction.Enabled && al.cfg.Redaction.ToolResults {
+				contentForLLM = redact.String(contentForLLM)
+			}
+			contentForLLM = guard.Observe(toolResult, contentForLLM)
 
 			toolResultMsg := providers.Message{
 				Role:       "tool",
@@ -751,7 +984,8 @@ func (al *AgentLoop) updateToolContexts(agent *AgentInstance, channel, chatID st
 }
 
 // maybeSummarize triggers summarization if the session history exceeds thresholds.
-func (al *AgentLoop) maybeSummarize(agent *AgentInstance, sessionKey, channel, chatID string) {
+// The summary runs in the background, outliving ctx but keeping its trace.
+func (al *AgentLoop) maybeSummarize(ctx context.Context, agent *AgentInstance, sessionKey, channel, chatID string) {
 	newHistory := agent.Sessions.GetHistory(sessionKey)
 	tokenEstimate := al.estimateTokens(newHistory)
 	threshold := agent.ContextWindow * 75 / 100
@@ -759,10 +993,11 @@ func (al *AgentLoop) maybeSummarize(agent *AgentInstance, sessionKey, channel, c
 	if len(newHistory) > 20 || tokenEstimate > threshold {
 		summarizeKey := agent.ID + ":" + sessionKey
 		if _, loading := al.summarizing.LoadOrStore(summarizeKey, true); !loading {
+			ctx := context.WithoutCancel(ctx)
 			go func() {
 				defer al.summarizing.Delete(summarizeKey)
 				logger.Debug("Memory threshold reached. Optimizing conversation history...")
-				al.summarizeSession(agent, sessionKey)
+				al.summarizeSession(ctx, agent, sessionKey)
 			}()
 		}
 	}
@@ -849,6 +1084,49 @@ func (al *AgentLoop) GetStartupInfo() map[string]any {
 	return info
 }
 
+// SessionInfo describes a stored session of one agent.
+type SessionInfo struct {
+	AgentID string `json:"agent_id"`
+	session.Info
+}
+
+// ListSessions returns the sessions of every agent, most recently updated first.
+func (al *AgentLoop) ListSessions() []SessionInfo {
+	var infos []SessionInfo
+	for _, id := range al.registry.ListAgentIDs() {
+		agent, ok := al.registry.GetAgent(id)
+		if !ok {
+			continue
+		}
+		for _, info := range agent.Sessions.List() {
+			infos = append(infos, SessionInfo{AgentID: id, Info: info})
+		}
+	}
+	sort.SliceStable(infos, func(i, j int) bool {
+		return infos[i].Updated.After(infos[j].Updated)
+	})
+	return infos
+}
+
+// SessionHistory returns the messages of a session of agentID. It reports
+// false for an unknown agent.
+func (al *AgentLoop) SessionHistory(agentID, sessionKey string) ([]providers.Message, bool) {
+	agent, ok := al.registry.GetAgent(agentID)
+	if !ok {
+		return nil, false
+	}
+	return agent.Sessions.GetHistory(sessionKey), true
+}
+
+// ListSkills returns the skills installed for the default agent.
+func (al *AgentLoop) ListSkills() []skills.SkillInfo {
+	agent := al.registry.GetDefaultAgent()
+	if agent == nil {
+		return nil
+	}
+	return agent.ContextBuilder.ListSkills()
+}
+
 // formatMessagesForLog formats messages for logging
 func formatMessagesForLog(messages []providers.Message) string {
 	if len(messages) == 0 {
@@ -901,9 +1179,11 @@ func formatToolsForLog(toolDefs []providers.ToolDefinition) string {
 }
 
 // summarizeSession summarizes the conversation history for a session.
-func (al *AgentLoop) summarizeSession(agent *AgentInstance, sessionKey string) {
-	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+func (al *AgentLoop) summarizeSession(ctx context.Context, agent *AgentInstance, sessionKey string) {
+	ctx, cancel := context.WithTimeout(ctx, 120*time.Second)
 	defer cancel()
+	ctx, span := tracing.Start(ctx, "summarize", map[string]any{"session_key": sessionKey})
+	defer span.End()
 
 	history := agent.Sessions.GetHistory(sessionKey)
 	summary := agent.Sessions.GetSummary(sessionKey)
@@ -951,7 +1231,7 @@ func (al *AgentLoop) summarizeSession(agent *AgentInstance, sessionKey string) {
 			s1,
 			s2,
 		)
-		resp, err := agent.Provider.Chat(
+		resp, err := al.cachedProvider(agent.Provider, cacheSiteSummary).Chat(
 			ctx,
 			[]providers.Message{{Role: "user", Content: mergePrompt}},
 			nil,
@@ -1002,7 +1282,7 @@ func (al *AgentLoop) summarizeBatch(
 	}
 	prompt := sb.String()
 
-	response, err := agent.Provider.Chat(
+	response, err := al.cachedProvider(agent.Provider, cacheSiteSummary).Chat(
 		ctx,
 		[]providers.Message{{Role: "user", Content: prompt}},
 		nil,
@@ -1019,6 +1299,23 @@ func (al *AgentLoop) summarizeBatch(
 	return response.Content, nil
 }
 
+// chargeTokens counts an LLM call against the sender's daily token budget,
+// estimating when the provider reports no usage.
+func (al *AgentLoop) chargeTokens(ctx context.Context, messages []providers.Message, response *providers.LLMResponse) {
+	access := permissions.AccessFrom(ctx)
+	if access == nil {
+		return
+	}
+	tokens := 0
+	if response.Usage != nil {
+		tokens = response.Usage.TotalTokens
+	}
+	if tokens == 0 {
+		tokens = al.estimateTokens(messages) + utf8.RuneCountInString(response.Content)*2/5
+	}
+	al.permissions.Charge(access, tokens)
+}
+
 // estimateTokens estimates the number of tokens in a message list.
 // Uses a safe heuristic of 2.5 characters per token to account for CJK and other
 // overheads better than the previous 3 chars/token.
@@ -1031,7 +1328,23 @@ func (al *AgentLoop) estimateTokens(messages []providers.Message) int {
 	return totalChars * 2 / 5
 }
 
-func (al *AgentLoop) handleCommand(ctx context.Context, msg bus.InboundMessage) (string, bool) {
+// adminCommands may only be used by senders whose role has admin set, when
+// permissions are enabled. The session override commands are included
+// because they change the model and loop limits of the whole session.
+var adminCommands = map[string]bool{
+	"/switch":     true,
+	"/model":      true,
+	"/temp":       true,
+	"/iterations": true,
+	"/reload":     true,
+}
+
+func (al *AgentLoop) handleCommand(
+	ctx context.Context,
+	msg bus.InboundMessage,
+	agent *AgentInstance,
+	sessionKey string,
+) (string, bool) {
 	content := strings.TrimSpace(msg.Content)
 	if !strings.HasPrefix(content, "/") {
 		return "", false
@@ -1045,18 +1358,23 @@ func (al *AgentLoop) handleCommand(ctx context.Context, msg bus.InboundMessage)
 	cmd := parts[0]
 	args := parts[1:]
 
+	access := permissions.AccessFrom(ctx)
+	if access != nil && adminCommands[cmd] && !access.IsAdmin() {
+		return fmt.Sprintf("%s is restricted to admins.", cmd), true
+	}
+
 	switch cmd {
 	case "/show":
 		if len(args) < 1 {
-			return "Usage: /show [model|channel|agents]", true
+			return "Usage: /show [model|agent|session|channel|agents]", true
 		}
 		switch args[0] {
 		case "model":
-			defaultAgent := al.registry.GetDefaultAgent()
-			if defaultAgent == nil {
-				return "No default agent configured", true
-			}
-			return fmt.Sprintf("Current model: %s", defaultAgent.Model), true
+			return fmt.Sprintf("Current model: %s", al.effectiveModel(agent, sessionKey)), true
+		case "agent":
+			return al.handleAgentCommand(agent, sessionKey, nil), true
+		case "session":
+			return formatOverrides(agent.Sessions.GetOverrides(sessionKey)), true
 		case "channel":
 			return fmt.Sprintf("Current channel: %s", msg.Channel), true
 		case "agents":
@@ -1072,7 +1390,11 @@ func (al *AgentLoop) handleCommand(ctx context.Context, msg bus.InboundMessage)
 		}
 		switch args[0] {
 		case "models":
-			return "Available models: configured in config.json per agent", true
+			names := al.configuredModelNames()
+			if len(names) == 0 {
+				return "No models configured in model_list", true
+			}
+			return fmt.Sprintf("Available models: %s", strings.Join(names, ", ")), true
 		case "channels":
 			if al.channelManager == nil {
 				return "Channel manager not initialized", true
@@ -1098,13 +1420,7 @@ func (al *AgentLoop) handleCommand(ctx context.Context, msg bus.InboundMessage)
 
 		switch target {
 		case "model":
-			defaultAgent := al.registry.GetDefaultAgent()
-			if defaultAgent == nil {
-				return "No default agent configured", true
-			}
-			oldModel := defaultAgent.Model
-			defaultAgent.Model = value
-			return fmt.Sprintf("Switched model from %s to %s", oldModel, value), true
+			return al.handleModelCommand(agent, sessionKey, []string{value}), true
 		case "channel":
 			if al.channelManager == nil {
 				return "Channel manager not initialized", true
@@ -1116,6 +1432,25 @@ func (al *AgentLoop) handleCommand(ctx context.Context, msg bus.InboundMessage)
 		default:
 			return fmt.Sprintf("Unknown switch target: %s", target), true
 		}
+
+	case "/model":
+		return al.handleModelCommand(agent, sessionKey, args), true
+
+	case "/agent":
+		if access != nil && len(args) > 0 && !isResetArg(args[0]) && !access.AllowsAgent(args[0]) {
+			return fmt.Sprintf("You don't have access to agent %s.", args[0]), true
+		}
+		return al.handleAgentCommand(agent, sessionKey, args), true
+
+	case "/temp":
+		return al.handleTempCommand(agent, sessionKey, args), true
+
+	case "/iterations":
+		return al.handleIterationsCommand(agent, sessionKey, args), true
+
+	case "/reload":
+		agent.ContextBuilder.InvalidateCache()
+		return "Reloaded workspace files and skills.", true
 	}
 
 	return "", false
@@ -0,0 +1,294 @@
+package agent
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/routing"
+	"github.com/sipeed/picoclaw/pkg/session"
+)
+
+// turnSettings is the effective LLM configuration for a single turn,
+// after applying session overrides on top of the agent defaults.
+type turnSettings struct {
+	Provider      providers.LLMProvider
+	Model         string
+	Temperature   float64
+	MaxIterations int
+	Candidates    []providers.FallbackCandidate
+}
+
+// modelProvider caches a provider created for a model_list entry.
+type modelProvider struct {
+	provider providers.LLMProvider
+	modelID  string
+}
+
+// resolveTurnSettings merges session overrides with the agent defaults.
+// If an overridden model cannot be resolved, the agent default is used.
+func (al *AgentLoop) resolveTurnSettings(agent *AgentInstance, overrides session.Overrides) turnSettings {
+	settings := turnSettings{
+		Provider:      agent.Provider,
+		Model:         agent.Model,
+		Temperature:   agent.Temperature,
+		MaxIterations: agent.MaxIterations,
+		Candidates:    agent.Candidates,
+	}
+
+	if overrides.Model != "" {
+		mp, err := al.providerForModel(overrides.Model)
+		if err != nil {
+			logger.WarnCF("agent", "Session model override unavailable, using agent default",
+				map[string]any{
+					"agent_id": agent.ID,
+					"model":    overrides.Model,
+					"error":    err.Error(),
+				})
+		} else {
+			settings.Provider = mp.provider
+			settings.Model = mp.modelID
+			// The override pins a single model; agent fallbacks no longer apply.
+			settings.Candidates = nil
+		}
+	}
+	if overrides.Temperature != nil {
+		settings.Temperature = *overrides.Temperature
+	}
+	// The override can only lower the agent's configured limit; it may have
+	// been set while the session was bound to another agent.
+	if overrides.MaxIterations > 0 && overrides.MaxIterations < agent.MaxIterations {
+		settings.MaxIterations = overrides.MaxIterations
+	}
+
+	return settings
+}
+
+// providerForModel returns a provider for a model_list entry, creating it on first use.
+func (al *AgentLoop) providerForModel(modelName string) (*modelProvider, error) {
+	al.modelProvidersMu.Lock()
+	defer al.modelProvidersMu.Unlock()
+
+	if mp, ok := al.modelProviders[modelName]; ok {
+		return mp, nil
+	}
+
+	if _, err := al.cfg.GetModelConfig(modelName); err != nil {
+		return nil, err
+	}
+
+	provider, modelID, err := providers.CreateProviderForModel(al.cfg, modelName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create provider for model %q: %w", modelName, err)
+	}
+
+	provider = providers.NewMeteredProvider(provider, providers.ProviderLabeler(al.cfg.ModelList))
+	mp := &modelProvider{provider: provider, modelID: modelID}
+	if al.modelProviders == nil {
+		al.modelProviders = make(map[string]*modelProvider)
+	}
+	al.modelProviders[modelName] = mp
+	return mp, nil
+}
+
+// configuredModelNames returns the distinct model_name values in model_list, in config order.
+func (al *AgentLoop) configuredModelNames() []string {
+	seen := make(map[string]bool)
+	names := make([]string, 0, len(al.cfg.ModelList))
+	for _, mc := range al.cfg.ModelList {
+		if mc.ModelName == "" || seen[mc.ModelName] {
+			continue
+		}
+		seen[mc.ModelName] = true
+		names = append(names, mc.ModelName)
+	}
+	return names
+}
+
+// bindSessionAgent applies an /agent override: it returns the bound agent and
+// the session key rewritten for that agent. Without an override (or if the
+// bound agent no longer exists) the routed agent and key are returned unchanged.
+func (al *AgentLoop) bindSessionAgent(
+	agent *AgentInstance,
+	sessionKey string,
+	overrides session.Overrides,
+) (*AgentInstance, string) {
+	if overrides.AgentID == "" {
+		return agent, sessionKey
+	}
+	bound, ok := al.registry.GetAgent(overrides.AgentID)
+	if !ok || bound.ID == agent.ID {
+		return agent, sessionKey
+	}
+	return bound, rebindSessionKey(sessionKey, bound.ID)
+}
+
+// rebindSessionKey replaces the agent ID of an agent-scoped session key.
+// Keys that are not agent-scoped are returned unchanged.
+func rebindSessionKey(sessionKey, agentID string) string {
+	parsed := routing.ParseAgentSessionKey(sessionKey)
+	if parsed == nil {
+		return sessionKey
+	}
+	return fmt.Sprintf("agent:%s:%s", routing.NormalizeAgentID(agentID), parsed.Rest)
+}
+
+// isResetArg reports whether a command argument asks to clear an override.
+func isResetArg(arg string) bool {
+	switch strings.ToLower(arg) {
+	case "reset", "default", "clear":
+		return true
+	}
+	return false
+}
+
+// updateOverrides applies fn to the session's overrides and persists the result.
+func updateOverrides(agent *AgentInstance, sessionKey string, fn func(o *session.Overrides)) {
+	overrides := agent.Sessions.GetOverrides(sessionKey)
+	fn(&overrides)
+	agent.Sessions.SetOverrides(sessionKey, overrides)
+	if err := agent.Sessions.Save(sessionKey); err != nil {
+		logger.WarnCF("agent", "Failed to save session overrides",
+			map[string]any{"session_key": sessionKey, "error": err.Error()})
+	}
+}
+
+// effectiveModel returns the model name in effect for a session.
+func (al *AgentLoop) effectiveModel(agent *AgentInstance, sessionKey string) string {
+	overrides := agent.Sessions.GetOverrides(sessionKey)
+	if overrides.Model != "" {
+		return overrides.Model
+	}
+	bound, _ := al.bindSessionAgent(agent, sessionKey, overrides)
+	return bound.Model
+}
+
+func (al *AgentLoop) handleModelCommand(agent *AgentInstance, sessionKey string, args []string) string {
+	if len(args) == 0 {
+		return fmt.Sprintf("Current model: %s", al.effectiveModel(agent, sessionKey))
+	}
+
+	value := args[0]
+	if isResetArg(value) {
+		updateOverrides(agent, sessionKey, func(o *session.Overrides) { o.Model = "" })
+		return fmt.Sprintf("Model reset to default: %s", al.effectiveModel(agent, sessionKey))
+	}
+
+	names := al.configuredModelNames()
+	if len(names) == 0 {
+		return "No models configured in model_list"
+	}
+	found := false
+	for _, name := range names {
+		if name == value {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Sprintf("Unknown model '%s'. Available models: %s", value, strings.Join(names, ", "))
+	}
+	if _, err := al.providerForModel(value); err != nil {
+		return fmt.Sprintf("Model '%s' is not usable: %v", value, err)
+	}
+
+	oldModel := al.effectiveModel(agent, sessionKey)
+	updateOverrides(agent, sessionKey, func(o *session.Overrides) { o.Model = value })
+	return fmt.Sprintf("Switched model from %s to %s for this session", oldModel, value)
+}
+
+func (al *AgentLoop) handleAgentCommand(agent *AgentInstance, sessionKey string, args []string) string {
+	if len(args) == 0 {
+		bound, _ := al.bindSessionAgent(agent, sessionKey, agent.Sessions.GetOverrides(sessionKey))
+		return fmt.Sprintf("Current agent: %s", bound.ID)
+	}
+
+	value := args[0]
+	if isResetArg(value) {
+		updateOverrides(agent, sessionKey, func(o *session.Overrides) { o.AgentID = "" })
+		return fmt.Sprintf("Agent reset to default: %s", agent.ID)
+	}
+
+	target, ok := al.registry.GetAgent(value)
+	if !ok {
+		return fmt.Sprintf("Unknown agent '%s'. Registered agents: %s",
+			value, strings.Join(al.registry.ListAgentIDs(), ", "))
+	}
+
+	updateOverrides(agent, sessionKey, func(o *session.Overrides) {
+		if target.ID == agent.ID {
+			o.AgentID = ""
+		} else {
+			o.AgentID = target.ID
+		}
+	})
+	return fmt.Sprintf("This session is now handled by agent %s", target.ID)
+}
+
+func (al *AgentLoop) handleTempCommand(agent *AgentInstance, sessionKey string, args []string) string {
+	if len(args) == 0 {
+		overrides := agent.Sessions.GetOverrides(sessionKey)
+		bound, _ := al.bindSessionAgent(agent, sessionKey, overrides)
+		return fmt.Sprintf("Current temperature: %g", al.resolveTurnSettings(bound, overrides).Temperature)
+	}
+
+	value := args[0]
+	if isResetArg(value) {
+		updateOverrides(agent, sessionKey, func(o *session.Overrides) { o.Temperature = nil })
+		return "Temperature reset to default"
+	}
+
+	temp, err := strconv.ParseFloat(value, 64)
+	if err != nil || temp < 0 || temp > 2 {
+		return "Usage: /temp <0.0-2.0|reset>"
+	}
+	updateOverrides(agent, sessionKey, func(o *session.Overrides) { o.Temperature = &temp })
+	return fmt.Sprintf("Temperature set to %g for this session", temp)
+}
+
+// handleIterationsCommand lowers the tool loop limit of the session. The
+// agent's max_tool_iterations stays the ceiling.
+func (al *AgentLoop) handleIterationsCommand(agent *AgentInstance, sessionKey string, args []string) string {
+	overrides := agent.Sessions.GetOverrides(sessionKey)
+	bound, _ := al.bindSessionAgent(agent, sessionKey, overrides)
+	if len(args) == 0 {
+		return fmt.Sprintf("Current max iterations: %d", al.resolveTurnSettings(bound, overrides).MaxIterations)
+	}
+
+	value := args[0]
+	if isResetArg(value) {
+		updateOverrides(agent, sessionKey, func(o *session.Overrides) { o.MaxIterations = 0 })
+		return "Max iterations reset to default"
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 || n > bound.MaxIterations {
+		return fmt.Sprintf("Usage: /iterations <1-%d|reset>", bound.MaxIterations)
+	}
+	updateOverrides(agent, sessionKey, func(o *session.Overrides) { o.MaxIterations = n })
+	return fmt.Sprintf("Max iterations set to %d for this session", n)
+}
+
+// formatOverrides renders the session overrides for /show.
+func formatOverrides(overrides session.Overrides) string {
+	if overrides.IsEmpty() {
+		return "No session overrides (using agent defaults)"
+	}
+	var sb strings.Builder
+	sb.WriteString("Session overrides:")
+	if overrides.AgentID != "" {
+		fmt.Fprintf(&sb, "\n  agent: %s", overrides.AgentID)
+	}
+	if overrides.Model != "" {
+		fmt.Fprintf(&sb, "\n  model: %s", overrides.Model)
+	}
+	if overrides.Temperature != nil {
+		fmt.Fprintf(&sb, "\n  temperature: %g", *overrides.Temperature)
+	}
+	if overrides.MaxIterations > 0 {
+		fmt.Fprintf(&sb, "\n  max_iterations: %d", overrides.MaxIterations)
+	}
+	return sb.String()
+}
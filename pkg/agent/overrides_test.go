@@ -0,0 +1,199 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+// recordingProvider records the model and temperature of each Chat call.
+type recordingProvider struct {
+	models       []string
+	temperatures []float64
+}
+
+func (p *recordingProvider) Chat(
+	ctx context.Context,
+	messages []providers.Message,
+	tools []providers.ToolDefinition,
+	model string,
+	opts map[string]any,
+) (*providers.LLMResponse, error) {
+	p.models = append(p.models, model)
+	if temp, ok := opts["temperature"].(float64); ok {
+		p.temperatures = append(p.temperatures, temp)
+	}
+	return &providers.LLMResponse{Content: "ok"}, nil
+}
+
+func (p *recordingProvider) GetDefaultModel() string {
+	return "recording-model"
+}
+
+func newOverridesTestLoop(t *testing.T, provider providers.LLMProvider) *AgentLoop {
+	t.Helper()
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+			},
+			List: []config.AgentConfig{
+				{ID: "main", Default: true},
+				{ID: "coder", Workspace: t.TempDir()},
+			},
+		},
+		ModelList: []config.ModelConfig{
+			{ModelName: "test-model", Model: "openai/test-model", APIBase: "http://localhost:1"},
+			{ModelName: "fast", Model: "openai/fast-id", APIBase: "http://localhost:1"},
+		},
+	}
+	return NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+}
+
+func sendDirect(t *testing.T, al *AgentLoop, content string) string {
+	t.Helper()
+	resp, err := al.processMessage(context.Background(), bus.InboundMessage{
+		Channel:  "telegram",
+		SenderID: "user1",
+		ChatID:   "chat1",
+		Content:  content,
+		Metadata: map[string]string{"peer_kind": "direct"},
+	})
+	if err != nil {
+		t.Fatalf("processMessage(%q) failed: %v", content, err)
+	}
+	return resp
+}
+
+func TestModelOverride_IsPerSession(t *testing.T) {
+	defaultProvider := &recordingProvider{}
+	al := newOverridesTestLoop(t, defaultProvider)
+
+	fastProvider := &recordingProvider{}
+	al.modelProviders = map[string]*modelProvider{
+		"fast": {provider: fastProvider, modelID: "fast-id"},
+	}
+
+	if resp := sendDirect(t, al, "/model fast"); !strings.Contains(resp, "to fast") {
+		t.Fatalf("unexpected /model response: %q", resp)
+	}
+
+	sendDirect(t, al, "hello")
+	if len(fastProvider.models) != 1 || fastProvider.models[0] != "fast-id" {
+		t.Errorf("expected override provider to be called with fast-id, got %v", fastProvider.models)
+	}
+	if len(defaultProvider.models) != 0 {
+		t.Errorf("expected default provider to be unused, got %v", defaultProvider.models)
+	}
+
+	// The agent default must not be mutated globally.
+	if got := al.registry.GetDefaultAgent().Model; got != "test-model" {
+		t.Errorf("agent default model changed to %q", got)
+	}
+
+	// Another session still uses the default model.
+	_, err := al.ProcessDirectWithChannel(context.Background(), "hi", "agent:main:other", "cli", "direct")
+	if err != nil {
+		t.Fatalf("ProcessDirectWithChannel failed: %v", err)
+	}
+	if len(defaultProvider.models) != 1 || defaultProvider.models[0] != "test-model" {
+		t.Errorf("expected other session to use test-model, got %v", defaultProvider.models)
+	}
+
+	if resp := sendDirect(t, al, "/show session"); !strings.Contains(resp, "model: fast") {
+		t.Errorf("expected /show session to report override, got %q", resp)
+	}
+}
+
+func TestModelOverride_RejectsUnknownModel(t *testing.T) {
+	al := newOverridesTestLoop(t, &recordingProvider{})
+
+	resp := sendDirect(t, al, "/model nope")
+	if !strings.Contains(resp, "Unknown model 'nope'") || !strings.Contains(resp, "fast") {
+		t.Errorf("unexpected response: %q", resp)
+	}
+	if resp := sendDirect(t, al, "/show model"); resp != "Current model: test-model" {
+		t.Errorf("unexpected /show model response: %q", resp)
+	}
+}
+
+func TestTempOverride_AppliesAndResets(t *testing.T) {
+	provider := &recordingProvider{}
+	al := newOverridesTestLoop(t, provider)
+
+	if resp := sendDirect(t, al, "/temp 3"); !strings.HasPrefix(resp, "Usage:") {
+		t.Errorf("expected usage for out-of-range temperature, got %q", resp)
+	}
+
+	sendDirect(t, al, "/temp 0.1")
+	sendDirect(t, al, "hello")
+	sendDirect(t, al, "/temp reset")
+	sendDirect(t, al, "hello again")
+
+	if len(provider.temperatures) != 2 {
+		t.Fatalf("expected 2 LLM calls, got %d", len(provider.temperatures))
+	}
+	if provider.temperatures[0] != 0.1 {
+		t.Errorf("expected overridden temperature 0.1, got %g", provider.temperatures[0])
+	}
+	if provider.temperatures[1] != 0.7 {
+		t.Errorf("expected default temperature 0.7 after reset, got %g", provider.temperatures[1])
+	}
+}
+
+func TestIterationsOverride_CappedAtAgentLimit(t *testing.T) {
+	al := newOverridesTestLoop(t, &recordingProvider{})
+
+	if resp := sendDirect(t, al, "/iterations 1000000"); resp != "Usage: /iterations <1-10|reset>" {
+		t.Errorf("expected usage for a limit above max_tool_iterations, got %q", resp)
+	}
+	if resp := sendDirect(t, al, "/iterations 3"); !strings.Contains(resp, "set to 3") {
+		t.Errorf("unexpected response: %q", resp)
+	}
+	if resp := sendDirect(t, al, "/iterations"); resp != "Current max iterations: 3" {
+		t.Errorf("unexpected /iterations response: %q", resp)
+	}
+}
+
+func TestAgentOverride_BindsSessionToAgent(t *testing.T) {
+	al := newOverridesTestLoop(t, &recordingProvider{})
+
+	if resp := sendDirect(t, al, "/agent ghost"); !strings.Contains(resp, "Unknown agent") {
+		t.Errorf("expected unknown agent error, got %q", resp)
+	}
+
+	sendDirect(t, al, "/agent coder")
+	sendDirect(t, al, "hello coder")
+
+	coder, _ := al.registry.GetAgent("coder")
+	history := coder.Sessions.GetHistory("agent:coder:main")
+	if len(history) == 0 || history[0].Content != "hello coder" {
+		t.Errorf("expected message in coder session, got %+v", history)
+	}
+
+	if resp := sendDirect(t, al, "/show agent"); resp != "Current agent: coder" {
+		t.Errorf("unexpected /show agent response: %q", resp)
+	}
+}
+
+func TestRebindSessionKey(t *testing.T) {
+	tests := []struct {
+		key, agentID, want string
+	}{
+		{"agent:main:telegram:direct:1", "coder", "agent:coder:telegram:direct:1"},
+		{"agent:main:main", "Coder", "agent:coder:main"},
+		{"heartbeat", "coder", "heartbeat"},
+	}
+	for _, tt := range tests {
+		if got := rebindSessionKey(tt.key, tt.agentID); got != tt.want {
+			t.Errorf("rebindSessionKey(%q, %q) = %q, want %q", tt.key, tt.agentID, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+// Call sites that may use the response cache.
+const (
+	cacheSiteSummary   = "summary"
+	cacheSiteHeartbeat = "heartbeat"
+)
+
+// newResponseCache opens the workspace response cache when enabled and
+// returns it with the set of call sites allowed to use it.
+func newResponseCache(cfg *config.Config) (*providers.ResponseCache, map[string]bool) {
+	rc := cfg.Agents.Defaults.ResponseCache
+	if !rc.Enabled {
+		return nil, nil
+	}
+
+	sites := make(map[string]bool)
+	for _, site := range rc.Sites {
+		site = strings.ToLower(strings.TrimSpace(site))
+		switch site {
+		case cacheSiteSummary, cacheSiteHeartbeat:
+			sites[site] = true
+		default:
+			logger.WarnCF("agent", "Unknown response cache site ignored", map[string]any{"site": site})
+		}
+	}
+	if len(rc.Sites) == 0 {
+		sites[cacheSiteSummary] = true
+		sites[cacheSiteHeartbeat] = true
+	}
+
+	dir := filepath.Join(cfg.WorkspacePath(), "cache", "llm")
+	cache, err := providers.NewResponseCache(dir, time.Duration(rc.TTLSeconds)*time.Second, rc.MaxEntries)
+	if err != nil {
+		logger.WarnCF("agent", "Response cache disabled", map[string]any{"dir": dir, "error": err.Error()})
+		return nil, nil
+	}
+	return cache, sites
+}
+
+// cachedProvider wraps provider with the response cache if site uses it.
+func (al *AgentLoop) cachedProvider(provider providers.LLMProvider, site string) providers.LLMProvider {
+	if al.responseCache == nil || !al.cacheSites[site] {
+		return provider
+	}
+	var opts []providers.CachingOption
+	if site == cacheSiteHeartbeat {
+		opts = append(opts, providers.WithCacheKeyNormalizer(stableSystemPrompt))
+	}
+	return providers.NewCachingProvider(provider, al.responseCache, site, opts...)
+}
+
+// reCurrentMinute matches the minutes of the "## Current Time" line the
+// context builder writes into every system prompt.
+var reCurrentMinute = regexp.MustCompile(`(## Current Time\n\d{4}-\d{2}-\d{2} \d{2}):\d{2}`)
+
+// stableSystemPrompt drops the minutes from the current time in system
+// messages, so heartbeats within the same hour share a cache key while a
+// heartbeat in the next hour or on another day does not reuse an answer
+// that may depend on the time.
+func stableSystemPrompt(messages []providers.Message) []providers.Message {
+	out := make([]providers.Message, len(messages))
+	for i, m := range messages {
+		if m.Role == "system" {
+			m.Content = reCurrentMinute.ReplaceAllString(m.Content, "$1")
+			if len(m.SystemParts) > 0 {
+				parts := make([]providers.ContentBlock, len(m.SystemParts))
+				for j, block := range m.SystemParts {
+					block.Text = reCurrentMinute.ReplaceAllString(block.Text, "$1")
+					parts[j] = block
+				}
+				m.SystemParts = parts
+			}
+		}
+		out[i] = m
+	}
+	return out
+}
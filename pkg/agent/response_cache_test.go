@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+func newResponseCacheTestLoop(t *testing.T, provider providers.LLMProvider, rc config.ResponseCacheConfig) *AgentLoop {
+	t.Helper()
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+				ResponseCache:     rc,
+			},
+		},
+	}
+	return NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+}
+
+func TestHeartbeat_UsesResponseCache(t *testing.T) {
+	provider := &recordingProvider{}
+	al := newResponseCacheTestLoop(t, provider, config.ResponseCacheConfig{Enabled: true})
+
+	for i := 0; i < 2; i++ {
+		if _, err := al.ProcessHeartbeat(context.Background(), "check tasks", "cli", "direct"); err != nil {
+			t.Fatalf("ProcessHeartbeat() error = %v", err)
+		}
+	}
+	if len(provider.models) != 1 {
+		t.Errorf("provider calls = %d, want 1 (second heartbeat served from cache)", len(provider.models))
+	}
+	if stats := al.responseCache.Stats()[cacheSiteHeartbeat]; stats.Hits != 1 {
+		t.Errorf("heartbeat stats = %+v, want 1 hit", stats)
+	}
+
+	// Regular messages never go through the cache.
+	for i := 0; i < 2; i++ {
+		if _, err := al.ProcessDirect(context.Background(), "hello", "agent:main:test"); err != nil {
+			t.Fatalf("ProcessDirect() error = %v", err)
+		}
+	}
+	if len(provider.models) != 3 {
+		t.Errorf("provider calls = %d, want 3", len(provider.models))
+	}
+}
+
+func TestHeartbeat_ResponseCacheSiteDisabled(t *testing.T) {
+	provider := &recordingProvider{}
+	al := newResponseCacheTestLoop(t, provider, config.ResponseCacheConfig{
+		Enabled: true,
+		Sites:   []string{cacheSiteSummary},
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, err := al.ProcessHeartbeat(context.Background(), "check tasks", "cli", "direct"); err != nil {
+			t.Fatalf("ProcessHeartbeat() error = %v", err)
+		}
+	}
+	if len(provider.models) != 2 {
+		t.Errorf("provider calls = %d, want 2 when heartbeat site is not enabled", len(provider.models))
+	}
+}
+
+func heartbeatPrompt(now string) []providers.Message {
+	dynamic := "## Current Time\n" + now + "\n\n## Runtime\nlinux amd64"
+	return []providers.Message{
+		{
+			Role:    "system",
+			Content: "static\n\n---\n\n" + dynamic,
+			SystemParts: []providers.ContentBlock{
+				{Type: "text", Text: "static", CacheControl: &providers.CacheControl{Type: "ephemeral"}},
+				{Type: "text", Text: dynamic},
+			},
+		},
+		{Role: "user", Content: "check tasks"},
+	}
+}
+
+func TestStableSystemPrompt(t *testing.T) {
+	msgs := heartbeatPrompt("2026-03-02 10:17 (Monday)")
+
+	got := stableSystemPrompt(msgs)
+	if got[0].SystemParts[0].Text != "static" ||
+		!strings.Contains(got[0].SystemParts[1].Text, "2026-03-02 10 (Monday)\n\n## Runtime") ||
+		!strings.Contains(got[0].Content, "2026-03-02 10 (Monday)") {
+		t.Errorf("system message = %+v, want the time without minutes", got[0])
+	}
+	if got[1].Content != "check tasks" {
+		t.Errorf("user message changed: %+v", got[1])
+	}
+	if !strings.Contains(msgs[0].SystemParts[1].Text, "10:17") {
+		t.Error("input messages must not be modified")
+	}
+}
+
+func TestHeartbeatCacheKey_FollowsTheHour(t *testing.T) {
+	cache, err := providers.NewResponseCache(t.TempDir(), time.Hour, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &recordingProvider{}
+	cached := providers.NewCachingProvider(provider, cache, cacheSiteHeartbeat,
+		providers.WithCacheKeyNormalizer(stableSystemPrompt))
+
+	for _, now := range []string{
+		"2026-03-02 10:00 (Monday)",
+		"2026-03-02 10:30 (Monday)", // Same hour: served from the cache
+		"2026-03-02 11:00 (Monday)", // Next hour: asks the provider again
+		"2026-03-03 10:00 (Tuesday)",
+	} {
+		if _, err := cached.Chat(context.Background(), heartbeatPrompt(now), nil, "test-model", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(provider.models) != 3 {
+		t.Errorf("provider calls = %d, want 3 (one hit within the hour)", len(provider.models))
+	}
+}
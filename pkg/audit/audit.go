@@ -0,0 +1,361 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package audit writes an append-only JSONL record of every tool invocation
+// and outbound message, with optional hash chaining for tamper evidence.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/redact"
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+// Record kinds.
+const (
+	KindTool    = "tool"
+	KindMessage = "message"
+)
+
+// Record statuses.
+const (
+	StatusOK     = "ok"
+	StatusError  = "error"
+	StatusAsync  = "async"
+	StatusDenied = "denied"
+)
+
+// previewLen caps error text and message content stored in a record.
+const previewLen = 200
+
+// maxArgsSize caps the encoded arguments of a tool record; larger arguments
+// (e.g. a whole file for write_file) are replaced by a truncated preview.
+const maxArgsSize = 4096
+
+// Record is one line of the audit log.
+type Record struct {
+	Time       time.Time      `json:"time"`
+	Kind       string         `json:"kind"`
+	Agent      string         `json:"agent,omitempty"`
+	SessionKey string         `json:"session_key,omitempty"`
+	Channel    string         `json:"channel,omitempty"`
+	ChatID     string         `json:"chat_id,omitempty"`
+	Sender     string         `json:"sender,omitempty"`
+	Tool       string         `json:"tool,omitempty"`
+	Args       map[string]any `json:"args,omitempty"`
+	Content    string         `json:"content,omitempty"`
+	Status     string         `json:"status"`
+	Error      string         `json:"error,omitempty"`
+	DurationMS int64          `json:"duration_ms"`
+
+	// Prev and Hash are set when hash chaining is enabled. Hash covers the
+	// record with Hash empty, including Prev, so editing or removing any
+	// line breaks every later link.
+	Prev string `json:"prev,omitempty"`
+	Hash string `json:"hash,omitempty"`
+}
+
+// Options configures a Log.
+type Options struct {
+	Path      string
+	MaxSizeMB int  // rotate when the file would exceed this size; 0 disables rotation
+	MaxFiles  int  // rotated files to keep (path.1 … path.N)
+	HashChain bool // link records with SHA-256 hashes
+}
+
+// Log is an append-only audit file. It is safe for concurrent use.
+type Log struct {
+	opts Options
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	last string // hash of the last record, for chaining
+}
+
+// Open opens or creates the audit log at opts.Path. With hash chaining the
+// chain continues from the last record already in the file.
+func Open(opts Options) (*Log, error) {
+	if opts.Path == "" {
+		return nil, fmt.Errorf("audit log path is required")
+	}
+	if err := os.MkdirAll(filepath.Dir(opts.Path), 0o700); err != nil {
+		return nil, err
+	}
+
+	l := &Log{opts: opts}
+	if opts.HashChain {
+		last, err := lastHash(opts.Path)
+		if err != nil {
+			return nil, err
+		}
+		l.last = last
+	}
+	if err := l.openFile(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *Log) openFile() error {
+	f, err := os.OpenFile(l.opts.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.file = f
+	l.size = info.Size()
+	return nil
+}
+
+// Write appends rec, filling in the time and, with chaining, the hashes.
+func (l *Log) Write(rec Record) error {
+	if rec.Time.IsZero() {
+		rec.Time = time.Now().UTC()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return fmt.Errorf("audit log is closed")
+	}
+
+	if l.opts.HashChain {
+		rec.Prev = l.last
+		hash, err := hashRecord(rec)
+		if err != nil {
+			return err
+		}
+		rec.Hash = hash
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	maxSize := int64(l.opts.MaxSizeMB) * 1024 * 1024
+	if maxSize > 0 && l.size > 0 && l.size+int64(len(line)) > maxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	if err != nil {
+		return err
+	}
+	l.last = rec.Hash
+	return nil
+}
+
+// rotate shifts path → path.1 → path.2 … and drops files beyond MaxFiles.
+func (l *Log) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	l.file = nil
+
+	keep := max(l.opts.MaxFiles, 1)
+	_ = os.Remove(rotatedName(l.opts.Path, keep))
+	for i := keep - 1; i >= 1; i-- {
+		if err := os.Rename(rotatedName(l.opts.Path, i), rotatedName(l.opts.Path, i+1)); err != nil &&
+			!os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(l.opts.Path, rotatedName(l.opts.Path, 1)); err != nil {
+		return err
+	}
+	return l.openFile()
+}
+
+// Close closes the file. Later writes fail.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+func rotatedName(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+func hashRecord(rec Record) (string, error) {
+	rec.Hash = ""
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// lastHash returns the hash of the final record in path, if any. It reads
+// backwards from the end until it has the whole last line.
+func lastHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	const chunk = 64 * 1024
+	end := info.Size()
+	var line []byte
+	for offset := end; offset > 0; {
+		n := min(offset, chunk)
+		offset -= n
+		buf := make([]byte, n)
+		if _, err := f.ReadAt(buf, offset); err != nil && err != io.EOF {
+			return "", err
+		}
+		line = append(buf, line...)
+		// Ignore the newline that ends the file.
+		trimmed := strings.TrimRight(string(line), "\n")
+		if i := strings.LastIndexByte(trimmed, '\n'); i >= 0 {
+			line = []byte(trimmed[i+1:])
+			break
+		}
+	}
+
+	last := strings.TrimRight(string(line), "\n")
+	if last == "" {
+		return "", nil
+	}
+	var rec Record
+	if err := json.Unmarshal([]byte(last), &rec); err != nil {
+		return "", fmt.Errorf("audit log %s: last record is unreadable: %w", path, err)
+	}
+	return rec.Hash, nil
+}
+
+// Actor identifies who caused a tool call. The agent loop attaches it to
+// the context of a turn.
+type Actor struct {
+	Agent      string
+	SessionKey string
+	Channel    string
+	ChatID     string
+	Sender     string
+}
+
+type actorKey struct{}
+
+// WithActor returns a context carrying actor.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor attached to ctx, if any.
+func ActorFrom(ctx context.Context) Actor {
+	actor, _ := ctx.Value(actorKey{}).(Actor)
+	return actor
+}
+
+var std atomic.Pointer[Log]
+
+// SetDefault installs the process-wide audit log used by LogTool and
+// LogMessage. Passing nil disables auditing.
+func SetDefault(l *Log) {
+	std.Store(l)
+}
+
+// Default returns the process-wide audit log, or nil when auditing is off.
+func Default() *Log {
+	return std.Load()
+}
+
+// LogTool records a tool invocation in the default log. Arguments are
+// redacted; errText is truncated.
+func LogTool(ctx context.Context, tool string, args map[string]any, status, errText string, d time.Duration) {
+	l := Default()
+	if l == nil {
+		return
+	}
+	actor := ActorFrom(ctx)
+	redacted, _ := redact.Default().Value(args).(map[string]any)
+	if data, err := json.Marshal(redacted); err == nil && len(data) > maxArgsSize {
+		redacted = map[string]any{"truncated": utils.Truncate(string(data), maxArgsSize)}
+	}
+	write(l, Record{
+		Kind:       KindTool,
+		Agent:      actor.Agent,
+		SessionKey: actor.SessionKey,
+		Channel:    actor.Channel,
+		ChatID:     actor.ChatID,
+		Sender:     actor.Sender,
+		Tool:       tool,
+		Args:       redacted,
+		Status:     status,
+		Error:      utils.Truncate(redact.String(errText), previewLen),
+		DurationMS: d.Milliseconds(),
+	})
+}
+
+// LogMessage records an outbound message in the default log. The actor
+// names the destination and who produced the message. Only a redacted
+// preview of the content is kept.
+func LogMessage(actor Actor, content string, err error, d time.Duration) {
+	l := Default()
+	if l == nil {
+		return
+	}
+	rec := Record{
+		Kind:       KindMessage,
+		Agent:      actor.Agent,
+		SessionKey: actor.SessionKey,
+		Channel:    actor.Channel,
+		ChatID:     actor.ChatID,
+		Sender:     actor.Sender,
+		Content:    utils.Truncate(redact.String(content), previewLen),
+		Status:     StatusOK,
+		DurationMS: d.Milliseconds(),
+	}
+	if err != nil {
+		rec.Status = StatusError
+		rec.Error = utils.Truncate(redact.String(err.Error()), previewLen)
+	}
+	write(l, rec)
+}
+
+func write(l *Log, rec Record) {
+	if err := l.Write(rec); err != nil {
+		logger.ErrorCF("audit", "Failed to write audit record",
+			map[string]any{"kind": rec.Kind, "tool": rec.Tool, "error": err.Error()})
+	}
+}
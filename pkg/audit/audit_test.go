@@ -0,0 +1,220 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/redact"
+)
+
+func readAll(t *testing.T, path string) []Record {
+	t.Helper()
+	var records []Record
+	if err := Read(path, func(rec Record) error {
+		records = append(records, rec)
+		return nil
+	}); err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	return records
+}
+
+func TestLog_WriteAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "audit.jsonl")
+	l, err := Open(Options{Path: path})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer l.Close()
+
+	if err := l.Write(Record{Kind: KindTool, Tool: "exec", Status: StatusOK}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := l.Write(Record{Kind: KindMessage, Channel: "telegram", Status: StatusError}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	records := readAll(t, path)
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+	if records[0].Tool != "exec" || records[0].Time.IsZero() || records[0].Hash != "" {
+		t.Errorf("unexpected first record: %+v", records[0])
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("audit log mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestLog_Rotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := Open(Options{Path: path, MaxSizeMB: 1, MaxFiles: 2})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer l.Close()
+
+	big := strings.Repeat("x", 300*1024)
+	for i := 0; i < 12; i++ {
+		if err := l.Write(Record{Kind: KindMessage, Content: big, Status: StatusOK}); err != nil {
+			t.Fatalf("Write %d: %v", i, err)
+		}
+	}
+
+	files := Files(path)
+	if len(files) != 3 || files[0] != path+".2" || files[2] != path {
+		t.Fatalf("Files = %v, want path.2, path.1, path", files)
+	}
+	for _, f := range files {
+		info, _ := os.Stat(f)
+		if info.Size() > 1024*1024 {
+			t.Errorf("%s is %d bytes, over the 1 MB limit", f, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("expected files beyond max_files to be removed")
+	}
+}
+
+func TestLog_HashChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := Open(Options{Path: path, HashChain: true})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	for _, tool := range []string{"read_file", "exec"} {
+		if err := l.Write(Record{Kind: KindTool, Tool: tool, Status: StatusOK}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.Close()
+
+	// Reopening continues the chain from the last record on disk.
+	l, err = Open(Options{Path: path, HashChain: true})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if err := l.Write(Record{Kind: KindTool, Tool: "write_file", Status: StatusOK}); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+
+	n, err := Verify(path)
+	if err != nil || n != 3 {
+		t.Fatalf("Verify = %d, %v; want 3, nil", n, err)
+	}
+
+	// Rewriting a tool name must break verification.
+	data, _ := os.ReadFile(path)
+	tampered := strings.Replace(string(data), `"tool":"exec"`, `"tool":"echo"`, 1)
+	os.WriteFile(path, []byte(tampered), 0o600)
+	if _, err := Verify(path); err == nil || !strings.Contains(err.Error(), "record 2") {
+		t.Errorf("Verify after tampering = %v, want error for record 2", err)
+	}
+
+	// Deleting a line must break the chain too.
+	lines := strings.SplitAfter(string(data), "\n")
+	os.WriteFile(path, []byte(lines[0]+lines[2]), 0o600)
+	if _, err := Verify(path); err == nil {
+		t.Error("Verify should fail when a record is removed")
+	}
+}
+
+func TestFilter_Match(t *testing.T) {
+	now := time.Now()
+	rec := Record{Time: now, Kind: KindTool, Agent: "main", Sender: "42", Tool: "exec", Status: StatusOK}
+
+	tests := []struct {
+		filter Filter
+		want   bool
+	}{
+		{Filter{}, true},
+		{Filter{Tool: "exec", Sender: "42"}, true},
+		{Filter{Tool: "read_file"}, false},
+		{Filter{Kind: KindMessage}, false},
+		{Filter{Since: now.Add(-time.Minute)}, true},
+		{Filter{Since: now.Add(time.Minute)}, false},
+	}
+	for i, tt := range tests {
+		if got := tt.filter.Match(rec); got != tt.want {
+			t.Errorf("case %d: Match = %v, want %v", i, got, tt.want)
+		}
+	}
+}
+
+func TestLogTool_UsesActorAndRedactsArgs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := Open(Options{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	SetDefault(l)
+	defer SetDefault(nil)
+
+	secret := "sk-" + strings.Repeat("a1", 12)
+	redact.Default().AddSecrets(secret)
+
+	ctx := WithActor(context.Background(), Actor{Agent: "main", SessionKey: "s1", Channel: "telegram", ChatID: "7", Sender: "42"})
+	LogTool(ctx, "exec", map[string]any{"command": "curl -H 'Authorization: " + secret + "'"}, StatusOK, "", 5*time.Millisecond)
+	LogMessage(ActorFrom(ctx), "hello", nil, time.Millisecond)
+	LogTool(ctx, "write_file", map[string]any{"content": strings.Repeat("x", 10*maxArgsSize)}, StatusOK, "", 0)
+
+	records := readAll(t, path)
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3", len(records))
+	}
+	tool := records[0]
+	if tool.Agent != "main" || tool.Sender != "42" || tool.SessionKey != "s1" || tool.DurationMS != 5 {
+		t.Errorf("actor not recorded: %+v", tool)
+	}
+	args, _ := json.Marshal(tool.Args)
+	if strings.Contains(string(args), secret) {
+		t.Errorf("arguments were not redacted: %s", args)
+	}
+	if msg := records[1]; msg.Kind != KindMessage || msg.Content != "hello" || msg.Agent != "main" ||
+		msg.SessionKey != "s1" || msg.Sender != "42" {
+		t.Errorf("unexpected message record: %+v", msg)
+	}
+	if args, _ := json.Marshal(records[2].Args); len(args) > 2*maxArgsSize {
+		t.Errorf("large arguments were stored in full (%d bytes)", len(args))
+	}
+}
+
+func TestOpen_LargeLastRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := Open(Options{Path: path, HashChain: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Write(Record{Kind: KindTool, Tool: "a", Status: StatusOK}); err != nil {
+		t.Fatal(err)
+	}
+	// Larger than one read chunk, as written before arguments were capped.
+	big := Record{Kind: KindTool, Tool: "write_file", Status: StatusOK,
+		Args: map[string]any{"content": strings.Repeat("y", 200*1024)}}
+	if err := l.Write(big); err != nil {
+		t.Fatal(err)
+	}
+	want := l.last
+	l.Close()
+
+	l, err = Open(Options{Path: path, HashChain: true})
+	if err != nil {
+		t.Fatalf("Open() after a large record: %v", err)
+	}
+	defer l.Close()
+	if l.last != want {
+		t.Errorf("chain continues from %q, want %q", l.last, want)
+	}
+}
@@ -0,0 +1,134 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// maxLineSize bounds a single record when reading; arguments of large file
+// writes can be long.
+const maxLineSize = 4 * 1024 * 1024
+
+// Filter selects records. Empty fields match everything.
+type Filter struct {
+	Kind    string
+	Agent   string
+	Session string
+	Channel string
+	Sender  string
+	Tool    string
+	Status  string
+	Since   time.Time
+}
+
+// Match reports whether rec passes the filter.
+func (f Filter) Match(rec Record) bool {
+	return matches(f.Kind, rec.Kind) &&
+		matches(f.Agent, rec.Agent) &&
+		matches(f.Session, rec.SessionKey) &&
+		matches(f.Channel, rec.Channel) &&
+		matches(f.Sender, rec.Sender) &&
+		matches(f.Tool, rec.Tool) &&
+		matches(f.Status, rec.Status) &&
+		(f.Since.IsZero() || !rec.Time.Before(f.Since))
+}
+
+func matches(want, got string) bool {
+	return want == "" || want == got
+}
+
+// Files returns the existing log files for path, oldest first: the rotated
+// path.N … path.1 followed by path itself.
+func Files(path string) []string {
+	var rotated []string
+	for i := 1; ; i++ {
+		name := rotatedName(path, i)
+		if _, err := os.Stat(name); err != nil {
+			break
+		}
+		rotated = append(rotated, name)
+	}
+
+	files := make([]string, 0, len(rotated)+1)
+	for i := len(rotated) - 1; i >= 0; i-- {
+		files = append(files, rotated[i])
+	}
+	if _, err := os.Stat(path); err == nil {
+		files = append(files, path)
+	}
+	return files
+}
+
+// Read calls fn for every record in path and its rotated files, oldest
+// first. It stops at the first error returned by fn.
+func Read(path string, fn func(Record) error) error {
+	for _, name := range Files(path) {
+		if err := readFile(name, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readFile(name string, fn func(Record) error) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return ReadFrom(f, name, fn)
+}
+
+// ReadFrom decodes records line by line from r. name is used in errors.
+func ReadFrom(r io.Reader, name string, fn func(Record) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal([]byte(text), &rec); err != nil {
+			return fmt.Errorf("%s:%d: %w", name, line, err)
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Verify checks the hash chain across path and its rotated files. It
+// returns the number of records checked. The first record may link to a
+// file that has since been rotated away, so its Prev is not checked.
+func Verify(path string) (int, error) {
+	count := 0
+	prev := ""
+	err := Read(path, func(rec Record) error {
+		count++
+		if rec.Hash == "" {
+			return fmt.Errorf("record %d (%s) has no hash; was hash_chain enabled?", count, rec.Time.Format(time.RFC3339))
+		}
+		if count > 1 && rec.Prev != prev {
+			return fmt.Errorf("record %d (%s) does not link to the previous record", count, rec.Time.Format(time.RFC3339))
+		}
+		want, err := hashRecord(rec)
+		if err != nil {
+			return err
+		}
+		if want != rec.Hash {
+			return fmt.Errorf("record %d (%s) was modified", count, rec.Time.Format(time.RFC3339))
+		}
+		prev = rec.Hash
+		return nil
+	})
+	return count, err
+}
@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/sipeed/picoclaw/pkg/fileutil"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/secrets"
+)
+
+// BackendEnv selects the credential backend: "encrypted" (default) or
+// "plaintext". The encrypted backend takes its key from
+// PICOCLAW_SECRETS_PASSPHRASE or the machine-local ~/.picoclaw/secrets.key.
+const BackendEnv = "PICOCLAW_AUTH_BACKEND"
+
+const (
+	BackendEncrypted = "encrypted"
+	BackendPlaintext = "plaintext"
+
+	// credentialsEntry is the secret that holds the whole encrypted AuthStore.
+	credentialsEntry = "credentials"
+)
+
+// StoreBackend persists an AuthStore.
+type StoreBackend interface {
+	// Name describes the backend for display, e.g. "encrypted (keyfile)".
+	Name() string
+	Load() (*AuthStore, error)
+	Save(store *AuthStore) error
+	Delete() error
+}
+
+var (
+	backendMu     sync.Mutex
+	activeBackend StoreBackend
+	activeKey     string
+)
+
+// ActiveBackend returns the backend selected by BackendEnv for the current home directory.
+func ActiveBackend() StoreBackend {
+	home, _ := os.UserHomeDir()
+	dir := filepath.Join(home, ".picoclaw")
+	kind := os.Getenv(BackendEnv)
+	if kind != BackendPlaintext {
+		kind = BackendEncrypted
+	}
+
+	backendMu.Lock()
+	defer backendMu.Unlock()
+
+	// Rebuild when HOME, the selection or the key source changes, so the
+	// cached key never outlives the files and settings it belongs to.
+	key := fmt.Sprintf("%s\x00%s\x00%t", kind, dir, os.Getenv(secrets.PassphraseEnv) != "")
+	if activeBackend == nil || activeKey != key {
+		legacy := &plaintextBackend{path: filepath.Join(dir, "auth.json")}
+		if kind == BackendPlaintext {
+			activeBackend = legacy
+		} else {
+			activeBackend = &encryptedBackend{
+				path:    filepath.Join(dir, "auth.enc.json"),
+				keyFile: filepath.Join(dir, "secrets.key"),
+				legacy:  legacy,
+			}
+		}
+		activeKey = key
+	}
+	return activeBackend
+}
+
+// plaintextBackend is the original auth.json format.
+type plaintextBackend struct {
+	path string
+}
+
+func (b *plaintextBackend) Name() string {
+	return BackendPlaintext + " (" + b.path + ")"
+}
+
+func (b *plaintextBackend) Load() (*AuthStore, error) {
+	data, err := os.ReadFile(b.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &AuthStore{Credentials: make(map[string]*AuthCredential)}, nil
+		}
+		return nil, err
+	}
+	return decodeStore(data)
+}
+
+func (b *plaintextBackend) Save(store *AuthStore) error {
+	data, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Use unified atomic write utility with explicit sync for flash storage reliability.
+	return fileutil.WriteFileAtomic(b.path, data, 0o600)
+}
+
+func (b *plaintextBackend) Delete() error {
+	if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (b *plaintextBackend) exists() bool {
+	_, err := os.Stat(b.path)
+	return err == nil
+}
+
+// encryptedBackend seals the AuthStore with ChaCha20-Poly1305 in a secrets.Store.
+// An existing plaintext auth.json is migrated on first load and then removed.
+type encryptedBackend struct {
+	path    string
+	keyFile string
+	legacy  *plaintextBackend
+
+	mu    sync.Mutex
+	store *secrets.Store
+}
+
+func (b *encryptedBackend) Name() string {
+	source := "keyfile"
+	if s, err := b.open(); err == nil {
+		source = s.KeySource()
+	}
+	return fmt.Sprintf("%s (%s, %s)", BackendEncrypted, source, b.path)
+}
+
+// open returns the secrets store, re-reading the file so that changes made
+// by other processes are seen while the derived key stays cached.
+func (b *encryptedBackend) open() (*secrets.Store, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.store == nil {
+		s, err := secrets.Open(b.path, b.keyFile)
+		if err != nil {
+			return nil, err
+		}
+		b.store = s
+		return s, nil
+	}
+	if err := b.store.Reload(); err != nil {
+		return nil, err
+	}
+	return b.store, nil
+}
+
+func (b *encryptedBackend) Load() (*AuthStore, error) {
+	s, err := b.open()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.Get(credentialsEntry)
+	if errors.Is(err, secrets.ErrNotFound) {
+		if b.legacy != nil && b.legacy.exists() {
+			return b.migrate()
+		}
+		return &AuthStore{Credentials: make(map[string]*AuthCredential)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeStore([]byte(data))
+}
+
+func (b *encryptedBackend) migrate() (*AuthStore, error) {
+	store, err := b.legacy.Load()
+	if err != nil {
+		return nil, err
+	}
+	if err := b.Save(store); err != nil {
+		return nil, fmt.Errorf("migrating %s: %w", b.legacy.path, err)
+	}
+	if err := b.legacy.Delete(); err != nil {
+		return nil, err
+	}
+	logger.InfoCF("auth", "Migrated credentials to encrypted store",
+		map[string]any{"from": b.legacy.path, "to": b.path, "providers": len(store.Credentials)})
+	return store, nil
+}
+
+func (b *encryptedBackend) Save(store *AuthStore) error {
+	s, err := b.open()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(store)
+	if err != nil {
+		return err
+	}
+	return s.Set(credentialsEntry, string(data))
+}
+
+func (b *encryptedBackend) Delete() error {
+	if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if b.legacy != nil {
+		return b.legacy.Delete()
+	}
+	return nil
+}
+
+func decodeStore(data []byte) (*AuthStore, error) {
+	var store AuthStore
+	if err := json.Unmarshal(data, &store); err != nil {
+		return nil, err
+	}
+	if store.Credentials == nil {
+		store.Credentials = make(map[string]*AuthCredential)
+	}
+	return &store, nil
+}
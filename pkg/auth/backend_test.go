@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/secrets"
+)
+
+func TestEncryptedBackend_NoPlaintextOnDisk(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv(BackendEnv, "")
+	t.Setenv(secrets.PassphraseEnv, "")
+
+	cred := &AuthCredential{AccessToken: "at-plain", RefreshToken: "rt-plain", Provider: "openai", AuthMethod: "oauth"}
+	if err := SetCredential("openai", cred); err != nil {
+		t.Fatalf("SetCredential() error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, ".picoclaw", "auth.enc.json"))
+	if err != nil {
+		t.Fatalf("ReadFile() error: %v", err)
+	}
+	for _, token := range []string{"at-plain", "rt-plain"} {
+		if strings.Contains(string(data), token) {
+			t.Errorf("encrypted store contains %q in cleartext", token)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, ".picoclaw", "auth.json")); !os.IsNotExist(err) {
+		t.Error("encrypted backend should not write auth.json")
+	}
+	if name := ActiveBackend().Name(); !strings.HasPrefix(name, "encrypted (keyfile") {
+		t.Errorf("Name() = %q, want encrypted (keyfile ...)", name)
+	}
+}
+
+func TestEncryptedBackend_MigratesLegacyFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv(secrets.PassphraseEnv, "")
+
+	// Write a legacy auth.json with the plaintext backend.
+	t.Setenv(BackendEnv, BackendPlaintext)
+	legacy := &AuthCredential{AccessToken: "legacy-token", Provider: "anthropic", AuthMethod: "token"}
+	if err := SetCredential("anthropic", legacy); err != nil {
+		t.Fatalf("SetCredential() error: %v", err)
+	}
+	legacyPath := filepath.Join(tmpDir, ".picoclaw", "auth.json")
+	if _, err := os.Stat(legacyPath); err != nil {
+		t.Fatalf("plaintext backend did not write auth.json: %v", err)
+	}
+
+	t.Setenv(BackendEnv, "")
+	loaded, err := GetCredential("anthropic")
+	if err != nil {
+		t.Fatalf("GetCredential() error: %v", err)
+	}
+	if loaded == nil || loaded.AccessToken != "legacy-token" {
+		t.Fatalf("GetCredential() = %+v, want migrated legacy credential", loaded)
+	}
+	if _, err := os.Stat(legacyPath); !os.IsNotExist(err) {
+		t.Error("auth.json should be removed after migration")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, ".picoclaw", "auth.enc.json")); err != nil {
+		t.Errorf("encrypted store not written: %v", err)
+	}
+}
+
+func TestEncryptedBackend_Passphrase(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv(BackendEnv, "")
+	t.Setenv(secrets.PassphraseEnv, "hunter2")
+
+	if err := SetCredential("openai", &AuthCredential{AccessToken: "tok", Provider: "openai"}); err != nil {
+		t.Fatalf("SetCredential() error: %v", err)
+	}
+	if name := ActiveBackend().Name(); !strings.HasPrefix(name, "encrypted (passphrase") {
+		t.Errorf("Name() = %q, want encrypted (passphrase ...)", name)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, ".picoclaw", "secrets.key")); !os.IsNotExist(err) {
+		t.Error("passphrase backend should not create a key file")
+	}
+
+	t.Setenv(secrets.PassphraseEnv, "")
+	if _, err := GetCredential("openai"); err == nil {
+		t.Error("GetCredential() without passphrase should fail")
+	}
+}
+
+func TestDeleteAllCredentials_RemovesBothFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv(BackendEnv, "")
+	t.Setenv(secrets.PassphraseEnv, "")
+
+	if err := SetCredential("openai", &AuthCredential{AccessToken: "x", ExpiresAt: time.Now()}); err != nil {
+		t.Fatalf("SetCredential() error: %v", err)
+	}
+	if err := DeleteAllCredentials(); err != nil {
+		t.Fatalf("DeleteAllCredentials() error: %v", err)
+	}
+	store, err := LoadStore()
+	if err != nil {
+		t.Fatalf("LoadStore() error: %v", err)
+	}
+	if len(store.Credentials) != 0 {
+		t.Errorf("credentials = %d, want 0", len(store.Credentials))
+	}
+}
@@ -26,17 +26,19 @@ type OAuthProviderConfig struct {
 	ClientSecret string // Required for Google OAuth (confidential client)
 	TokenURL     string // Override token endpoint (Google uses a different URL than issuer)
 	Scopes       string
+	RefreshScope string // Scope sent with refresh requests; empty omits it
 	Originator   string
 	Port         int
 }
 
 func OpenAIOAuthConfig() OAuthProviderConfig {
 	return OAuthProviderConfig{
-		Issuer:     "https://auth.openai.com",
-		ClientID:   "app_EMoamEEZ73f0CkXaXp7hrann",
-		Scopes:     "openid profile email offline_access",
-		Originator: "codex_cli_rs",
-		Port:       1455,
+		Issuer:       "https://auth.openai.com",
+		ClientID:     "app_EMoamEEZ73f0CkXaXp7hrann",
+		Scopes:       "openid profile email offline_access",
+		RefreshScope: "openid profile email",
+		Originator:   "codex_cli_rs",
+		Port:         1455,
 	}
 }
 
@@ -54,10 +56,22 @@ func GoogleAntigravityOAuthConfig() OAuthProviderConfig {
 		ClientID:     clientID,
 		ClientSecret: clientSecret,
 		Scopes:       "https://www.googleapis.com/auth/cloud-platform https://www.googleapis.com/auth/userinfo.email https://www.googleapis.com/auth/userinfo.profile https://www.googleapis.com/auth/cclog https://www.googleapis.com/auth/experimentsandconfigs",
+		RefreshScope: "openid profile email",
 		Port:         51121,
 	}
 }
 
+// AnthropicOAuthConfig returns the OAuth configuration of Claude accounts,
+// used to refresh "oauth" credentials for the anthropic provider.
+func AnthropicOAuthConfig() OAuthProviderConfig {
+	return OAuthProviderConfig{
+		Issuer:   "https://console.anthropic.com",
+		TokenURL: "https://console.anthropic.com/v1/oauth/token",
+		ClientID: "9d1c250a-e61b-44d9-88ed-5944d1962f5e",
+		Scopes:   "org:create_api_key user:profile user:inference",
+	}
+}
+
 func decodeBase64(s string) string {
 	data, err := base64.StdEncoding.DecodeString(s)
 	if err != nil {
@@ -330,7 +344,9 @@ func RefreshAccessToken(cred *AuthCredential, cfg OAuthProviderConfig) (*AuthCre
 		"client_id":     {cfg.ClientID},
 		"grant_type":    {"refresh_token"},
 		"refresh_token": {cred.RefreshToken},
-		"scope":         {"openid profile email"},
+	}
+	if cfg.RefreshScope != "" {
+		data.Set("scope", cfg.RefreshScope)
 	}
 	if cfg.ClientSecret != "" {
 		data.Set("client_secret", cfg.ClientSecret)
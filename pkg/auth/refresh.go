@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+const (
+	// refreshAhead is how long before expiry the background refresher renews a
+	// token. It is well ahead of the NeedsRefresh window, so requests rarely
+	// have to refresh inline.
+	refreshAhead = 15 * time.Minute
+
+	refreshCheckInterval = time.Minute
+)
+
+// refreshToken is swapped in tests.
+var refreshToken = RefreshAccessToken
+
+// OAuthConfigForProvider returns the OAuth client configuration used to
+// refresh credentials of the given provider.
+func OAuthConfigForProvider(provider string) (OAuthProviderConfig, bool) {
+	switch provider {
+	case "openai":
+		return OpenAIOAuthConfig(), true
+	case "google-antigravity", "antigravity":
+		return GoogleAntigravityOAuthConfig(), true
+	case "anthropic":
+		return AnthropicOAuthConfig(), true
+	}
+	return OAuthProviderConfig{}, false
+}
+
+// StartBackgroundRefresh renews OAuth tokens shortly before they expire until
+// ctx is cancelled.
+func StartBackgroundRefresh(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(refreshCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			RefreshDueCredentials(time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RefreshDueCredentials refreshes every OAuth credential that expires within
+// refreshAhead of now. It returns the providers that were refreshed.
+//
+// The store is not locked during the network calls, so a slow token
+// endpoint does not hold up other credential updates; the results are
+// written back under the lock afterwards.
+func RefreshDueCredentials(now time.Time) []string {
+	store, err := LoadStore()
+	if err != nil {
+		logger.WarnCF("auth", "Background refresh could not load credentials",
+			map[string]any{"error": err.Error()})
+		return nil
+	}
+
+	due := make(map[string]*AuthCredential)
+	for provider, cred := range store.Credentials {
+		if cred.AuthMethod != "oauth" || cred.RefreshToken == "" || cred.ExpiresAt.IsZero() {
+			continue
+		}
+		if now.Add(refreshAhead).Before(cred.ExpiresAt) {
+			continue
+		}
+		due[provider] = cred
+	}
+
+	fresh := make(map[string]*AuthCredential)
+	for provider, cred := range due {
+		cfg, ok := OAuthConfigForProvider(provider)
+		if !ok {
+			continue
+		}
+		refreshed, err := refreshToken(cred, cfg)
+		if err != nil {
+			logger.WarnCF("auth", "Background token refresh failed",
+				map[string]any{"provider": provider, "expires_at": cred.ExpiresAt, "error": err.Error()})
+			continue
+		}
+		fresh[provider] = refreshed
+	}
+	if len(fresh) == 0 {
+		return nil
+	}
+
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	store, err = LoadStore()
+	if err != nil {
+		logger.ErrorCF("auth", "Failed to save refreshed credentials",
+			map[string]any{"error": err.Error()})
+		return nil
+	}
+	var refreshed []string
+	for provider, cred := range fresh {
+		// Keep a credential replaced while refreshing, e.g. by a new login.
+		current := store.Credentials[provider]
+		if current == nil || current.RefreshToken != due[provider].RefreshToken {
+			continue
+		}
+		store.Credentials[provider] = cred
+		refreshed = append(refreshed, provider)
+		logger.InfoCF("auth", "Refreshed OAuth token",
+			map[string]any{"provider": provider, "expires_at": cred.ExpiresAt})
+	}
+
+	if len(refreshed) > 0 {
+		if err := SaveStore(store); err != nil {
+			logger.ErrorCF("auth", "Failed to save refreshed credentials",
+				map[string]any{"error": err.Error()})
+			return nil
+		}
+	}
+	return refreshed
+}
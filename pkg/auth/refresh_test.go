@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/secrets"
+)
+
+func TestRefreshDueCredentials(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(BackendEnv, "")
+	t.Setenv(secrets.PassphraseEnv, "")
+
+	now := time.Now()
+	creds := map[string]*AuthCredential{
+		// Expires inside the refresh window: refreshed.
+		"openai": {AccessToken: "old", RefreshToken: "rt", Provider: "openai", AuthMethod: "oauth", ExpiresAt: now.Add(10 * time.Minute)},
+		// Expires much later: left alone.
+		"google-antigravity": {AccessToken: "ok", RefreshToken: "rt", Provider: "google-antigravity", AuthMethod: "oauth", ExpiresAt: now.Add(time.Hour)},
+		// Pasted tokens cannot be refreshed.
+		"anthropic": {AccessToken: "tok", Provider: "anthropic", AuthMethod: "token", ExpiresAt: now.Add(time.Minute)},
+	}
+	for p, c := range creds {
+		if err := SetCredential(p, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var calls []string
+	orig := refreshToken
+	defer func() { refreshToken = orig }()
+	refreshToken = func(cred *AuthCredential, cfg OAuthProviderConfig) (*AuthCredential, error) {
+		calls = append(calls, cred.Provider)
+		if cfg.ClientID == "" {
+			return nil, errors.New("missing client id")
+		}
+		fresh := *cred
+		fresh.AccessToken = "new"
+		fresh.ExpiresAt = now.Add(time.Hour)
+		return &fresh, nil
+	}
+
+	refreshed := RefreshDueCredentials(now)
+	if len(refreshed) != 1 || refreshed[0] != "openai" {
+		t.Fatalf("refreshed = %v, want [openai]", refreshed)
+	}
+	if len(calls) != 1 {
+		t.Errorf("refresh calls = %v, want one", calls)
+	}
+
+	got, err := GetCredential("openai")
+	if err != nil || got.AccessToken != "new" {
+		t.Errorf("openai credential = %+v, %v; want refreshed token", got, err)
+	}
+	if got, _ := GetCredential("google-antigravity"); got.AccessToken != "ok" {
+		t.Errorf("antigravity token = %q, should not be refreshed", got.AccessToken)
+	}
+}
+
+func TestRefreshDueCredentials_FailureKeepsCredential(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(BackendEnv, "")
+	t.Setenv(secrets.PassphraseEnv, "")
+
+	now := time.Now()
+	if err := SetCredential("openai", &AuthCredential{
+		AccessToken: "old", RefreshToken: "rt", Provider: "openai", AuthMethod: "oauth", ExpiresAt: now.Add(time.Minute),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	orig := refreshToken
+	defer func() { refreshToken = orig }()
+	refreshToken = func(*AuthCredential, OAuthProviderConfig) (*AuthCredential, error) {
+		return nil, errors.New("network down")
+	}
+
+	if refreshed := RefreshDueCredentials(now); len(refreshed) != 0 {
+		t.Errorf("refreshed = %v, want none", refreshed)
+	}
+	if got, _ := GetCredential("openai"); got == nil || got.AccessToken != "old" {
+		t.Errorf("credential = %+v, want the original", got)
+	}
+}
+
+func TestRefreshDueCredentials_StoreUnlockedDuringRefresh(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(BackendEnv, "")
+	t.Setenv(secrets.PassphraseEnv, "")
+
+	now := time.Now()
+	if err := SetCredential("anthropic", &AuthCredential{
+		AccessToken: "old", RefreshToken: "rt", Provider: "anthropic", AuthMethod: "oauth", ExpiresAt: now.Add(time.Minute),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	orig := refreshToken
+	defer func() { refreshToken = orig }()
+	refreshToken = func(cred *AuthCredential, cfg OAuthProviderConfig) (*AuthCredential, error) {
+		// A write while the token endpoint is slow must not block.
+		done := make(chan error, 1)
+		go func() { done <- SetCredential("openai", &AuthCredential{AccessToken: "x", Provider: "openai"}) }()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("SetCredential() error = %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Error("SetCredential() blocked while a refresh was in flight")
+		}
+		fresh := *cred
+		fresh.AccessToken = "new"
+		return &fresh, nil
+	}
+
+	if refreshed := RefreshDueCredentials(now); len(refreshed) != 1 || refreshed[0] != "anthropic" {
+		t.Errorf("refreshed = %v, want [anthropic]", refreshed)
+	}
+	if got, _ := GetCredential("anthropic"); got == nil || got.AccessToken != "new" {
+		t.Errorf("anthropic credential = %+v, want the refreshed token", got)
+	}
+	if got, _ := GetCredential("openai"); got == nil || got.AccessToken != "x" {
+		t.Errorf("openai credential = %+v, the concurrent write was lost", got)
+	}
+}
+
+func TestOAuthConfigForProvider(t *testing.T) {
+	for _, p := range []string{"openai", "google-antigravity", "antigravity", "anthropic"} {
+		if _, ok := OAuthConfigForProvider(p); !ok {
+			t.Errorf("OAuthConfigForProvider(%q) not found", p)
+		}
+	}
+	if _, ok := OAuthConfigForProvider("github-copilot"); ok {
+		t.Error("github-copilot has no OAuth refresh config")
+	}
+}
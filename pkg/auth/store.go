@@ -1,12 +1,8 @@
 package auth
 
 import (
-	"encoding/json"
-	"os"
-	"path/filepath"
+	"sync"
 	"time"
-
-	"github.com/sipeed/picoclaw/pkg/fileutil"
 )
 
 type AuthCredential struct {
@@ -38,40 +34,17 @@ func (c *AuthCredential) NeedsRefresh() bool {
 	return time.Now().Add(5 * time.Minute).After(c.ExpiresAt)
 }
 
-func authFilePath() string {
-	home, _ := os.UserHomeDir()
-	return filepath.Join(home, ".picoclaw", "auth.json")
-}
+// storeMu serializes read-modify-write cycles on the credential store.
+var storeMu sync.Mutex
 
+// LoadStore reads all credentials from the active backend.
 func LoadStore() (*AuthStore, error) {
-	path := authFilePath()
-	data, err := os.ReadFile(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return &AuthStore{Credentials: make(map[string]*AuthCredential)}, nil
-		}
-		return nil, err
-	}
-
-	var store AuthStore
-	if err := json.Unmarshal(data, &store); err != nil {
-		return nil, err
-	}
-	if store.Credentials == nil {
-		store.Credentials = make(map[string]*AuthCredential)
-	}
-	return &store, nil
+	return ActiveBackend().Load()
 }
 
+// SaveStore writes all credentials to the active backend.
 func SaveStore(store *AuthStore) error {
-	path := authFilePath()
-	data, err := json.MarshalIndent(store, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	// Use unified atomic write utility with explicit sync for flash storage reliability.
-	return fileutil.WriteFileAtomic(path, data, 0o600)
+	return ActiveBackend().Save(store)
 }
 
 func GetCredential(provider string) (*AuthCredential, error) {
@@ -87,6 +60,9 @@ func GetCredential(provider string) (*AuthCredential, error) {
 }
 
 func SetCredential(provider string, cred *AuthCredential) error {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
 	store, err := LoadStore()
 	if err != nil {
 		return err
@@ -96,6 +72,9 @@ func SetCredential(provider string, cred *AuthCredential) error {
 }
 
 func DeleteCredential(provider string) error {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
 	store, err := LoadStore()
 	if err != nil {
 		return err
@@ -105,9 +84,8 @@ func DeleteCredential(provider string) error {
 }
 
 func DeleteAllCredentials() error {
-	path := authFilePath()
-	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
-		return err
-	}
-	return nil
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	return ActiveBackend().Delete()
 }
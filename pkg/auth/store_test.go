@@ -102,14 +102,16 @@ func TestStoreFilePermissions(t *testing.T) {
 		t.Fatalf("SetCredential() error: %v", err)
 	}
 
-	path := filepath.Join(tmpDir, ".picoclaw", "auth.json")
-	info, err := os.Stat(path)
-	if err != nil {
-		t.Fatalf("Stat() error: %v", err)
-	}
-	perm := info.Mode().Perm()
-	if perm != 0o600 {
-		t.Errorf("file permissions = %o, want 0600", perm)
+	for _, name := range []string{"auth.enc.json", "secrets.key"} {
+		path := filepath.Join(tmpDir, ".picoclaw", name)
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Stat() error: %v", err)
+		}
+		perm := info.Mode().Perm()
+		if perm != 0o600 {
+			t.Errorf("%s permissions = %o, want 0600", name, perm)
+		}
 	}
 }
 
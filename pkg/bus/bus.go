@@ -3,8 +3,14 @@ package bus
 import (
 	"context"
 	"sync"
+
+	"github.com/sipeed/picoclaw/pkg/metrics"
 )
 
+var messagesTotal = metrics.NewCounter("picoclaw_messages_total",
+	"Messages published on the bus, by channel and direction (inbound or outbound).",
+	"channel", "direction")
+
 type MessageBus struct {
 	inbound  chan InboundMessage
 	outbound chan OutboundMessage
@@ -14,11 +20,16 @@ type MessageBus struct {
 }
 
 func NewMessageBus() *MessageBus {
-	return &MessageBus{
+	mb := &MessageBus{
 		inbound:  make(chan InboundMessage, 100),
 		outbound: make(chan OutboundMessage, 100),
 		handlers: make(map[string]MessageHandler),
 	}
+	metrics.NewGaugeFunc("picoclaw_bus_inbound_queue_depth", "Inbound messages waiting for the agent.",
+		func() float64 { return float64(len(mb.inbound)) })
+	metrics.NewGaugeFunc("picoclaw_bus_outbound_queue_depth", "Outbound messages waiting for a channel.",
+		func() float64 { return float64(len(mb.outbound)) })
+	return mb
 }
 
 func (mb *MessageBus) PublishInbound(msg InboundMessage) {
@@ -27,6 +38,7 @@ func (mb *MessageBus) PublishInbound(msg InboundMessage) {
 	if mb.closed {
 		return
 	}
+	messagesTotal.Inc(msg.Channel, "inbound")
 	mb.inbound <- msg
 }
 
@@ -45,6 +57,7 @@ func (mb *MessageBus) PublishOutbound(msg OutboundMessage) {
 	if mb.closed {
 		return
 	}
+	messagesTotal.Inc(msg.Channel, "outbound")
 	mb.outbound <- msg
 }
 
@@ -14,6 +14,13 @@ type OutboundMessage struct {
 	Channel string `json:"channel"`
 	ChatID  string `json:"chat_id"`
 	Content string `json:"content"`
+
+	// Who produced the message, recorded in the audit log.
+	Agent      string `json:"agent,omitempty"`
+	SessionKey string `json:"session_key,omitempty"`
+	Sender     string `json:"sender,omitempty"` // sender of the message being answered, or the system source
+
+	Traceparent string `json:"traceparent,omitempty"` // W3C traceparent of the turn that produced the message
 }
 
 type MessageHandler func(InboundMessage) error
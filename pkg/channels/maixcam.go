@@ -18,6 +18,8 @@ type MaixCamChannel struct {
 	listener   net.Listener
 	clients    map[net.Conn]bool
 	clientsMux sync.RWMutex
+	onEvent    func(eventType string, data map[string]any)
+	onEventMux sync.RWMutex
 }
 
 type MaixCamMessage struct {
@@ -124,7 +126,24 @@ func (c *MaixCamChannel) handleConnection(conn net.Conn, ctx context.Context) {
 	}
 }
 
+// SetEventHandler registers fn to be called with every detection and status
+// message from a device, in addition to the normal channel handling.
+func (c *MaixCamChannel) SetEventHandler(fn func(eventType string, data map[string]any)) {
+	c.onEventMux.Lock()
+	defer c.onEventMux.Unlock()
+	c.onEvent = fn
+}
+
 func (c *MaixCamChannel) processMessage(msg MaixCamMessage, conn net.Conn) {
+	if msg.Type != "heartbeat" {
+		c.onEventMux.RLock()
+		onEvent := c.onEvent
+		c.onEventMux.RUnlock()
+		if onEvent != nil {
+			onEvent(msg.Type, msg.Data)
+		}
+	}
+
 	switch msg.Type {
 	case "person_detected":
 		c.handlePersonDetection(msg)
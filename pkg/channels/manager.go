@@ -10,11 +10,22 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
+	"github.com/sipeed/picoclaw/pkg/audit"
 	"github.com/sipeed/picoclaw/pkg/bus"
 	"github.com/sipeed/picoclaw/pkg/config"
 	"github.com/sipeed/picoclaw/pkg/constants"
 	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/metrics"
+	"github.com/sipeed/picoclaw/pkg/tracing"
+)
+
+var (
+	sendDuration = metrics.NewHistogram("picoclaw_channel_send_duration_seconds",
+		"Time to deliver an outbound message, by channel.", nil, "channel")
+	sendFailures = metrics.NewCounter("picoclaw_channel_send_failures_total",
+		"Outbound messages a channel failed to deliver.", "channel")
 )
 
 type Manager struct {
@@ -298,11 +309,28 @@ func (m *Manager) dispatchOutbound(ctx context.Context) {
 				continue
 			}
 
-			if err := channel.Send(ctx, msg); err != nil {
-				logger.ErrorCF("channels", "Error sending message to channel", map[string]any{
+			// Continue the trace of the turn that produced the message.
+			sendCtx, span := tracing.Start(tracing.WithTraceparent(ctx, msg.Traceparent), "outbound.send",
+				map[string]any{"channel": msg.Channel, "chat_id": msg.ChatID})
+			start := time.Now()
+			err := channel.Send(sendCtx, msg)
+			elapsed := time.Since(start)
+			audit.LogMessage(audit.Actor{
+				Agent:      msg.Agent,
+				SessionKey: msg.SessionKey,
+				Channel:    msg.Channel,
+				ChatID:     msg.ChatID,
+				Sender:     msg.Sender,
+			}, msg.Content, err, elapsed)
+			sendDuration.Observe(elapsed.Seconds(), msg.Channel)
+			span.SetError(err)
+			span.End()
+			if err != nil {
+				sendFailures.Inc(msg.Channel)
+				logger.ErrorCF("channels", "Error sending message to channel", tracing.Fields(sendCtx, map[string]any{
 					"channel": msg.Channel,
 					"error":   err.Error(),
-				})
+				}))
 			}
 		}
 	}
@@ -126,10 +126,6 @@ func (c *TelegramChannel) Start(ctx context.Context) error {
 		return c.commands.Start(ctx, message)
 	}, th.CommandEqual("start"))
 
-	bh.HandleMessage(func(ctx *th.Context, message telego.Message) error {
-		return c.commands.Show(ctx, message)
-	}, th.CommandEqual("show"))
-
 	bh.HandleMessage(func(ctx *th.Context, message telego.Message) error {
 		return c.commands.List(ctx, message)
 	}, th.CommandEqual("list"))
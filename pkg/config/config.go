@@ -58,6 +58,14 @@ type Config struct {
 	Tools     ToolsConfig     `json:"tools"`
 	Heartbeat HeartbeatConfig `json:"heartbeat"`
 	Devices   DevicesConfig   `json:"devices"`
+	Triggers  TriggersConfig  `json:"triggers"`
+	Redaction RedactionConfig `json:"redaction"`
+	Audit     AuditConfig     `json:"audit"`
+	Tracing   TracingConfig   `json:"tracing"`
+
+	Permissions PermissionsConfig `json:"permissions"`
+
+	secretRefs map[string]secretRef // references resolved by LoadConfig, restored by SaveConfig
 }
 
 // MarshalJSON implements custom JSON marshaling for Config
@@ -179,6 +187,18 @@ type AgentDefaults struct {
 	MaxTokens           int      `json:"max_tokens"                      env:"PICOCLAW_AGENTS_DEFAULTS_MAX_TOKENS"`
 	Temperature         *float64 `json:"temperature,omitempty"           env:"PICOCLAW_AGENTS_DEFAULTS_TEMPERATURE"`
 	MaxToolIterations   int      `json:"max_tool_iterations"             env:"PICOCLAW_AGENTS_DEFAULTS_MAX_TOOL_ITERATIONS"`
+	MaxQueueWaitSeconds int      `json:"max_queue_wait_seconds"          env:"PICOCLAW_AGENTS_DEFAULTS_MAX_QUEUE_WAIT_SECONDS"` // Rate-limit wait before trying the next fallback
+
+	ResponseCache ResponseCacheConfig `json:"response_cache,omitempty"`
+}
+
+// ResponseCacheConfig controls on-disk caching of LLM responses for
+// deterministic call sites. Sites: "summary", "heartbeat" (all when empty).
+type ResponseCacheConfig struct {
+	Enabled    bool     `json:"enabled"     env:"PICOCLAW_AGENTS_DEFAULTS_RESPONSE_CACHE_ENABLED"`
+	Sites      []string `json:"sites,omitempty"`
+	MaxEntries int      `json:"max_entries" env:"PICOCLAW_AGENTS_DEFAULTS_RESPONSE_CACHE_MAX_ENTRIES"`
+	TTLSeconds int      `json:"ttl_seconds" env:"PICOCLAW_AGENTS_DEFAULTS_RESPONSE_CACHE_TTL_SECONDS"`
 }
 
 // GetModelName returns the effective model name for the agent defaults.
@@ -317,6 +337,78 @@ type DevicesConfig struct {
 	MonitorUSB bool `json:"monitor_usb" env:"PICOCLAW_DEVICES_MONITOR_USB"`
 }
 
+// TriggersConfig holds event-triggered automations. Rules from
+// TRIGGERS.json in the workspace are added to the ones listed here.
+type TriggersConfig struct {
+	Enabled bool          `json:"enabled" env:"PICOCLAW_TRIGGERS_ENABLED"`
+	Rules   []TriggerRule `json:"rules"`
+}
+
+// TriggerRule maps an event source to an agent prompt and a delivery target.
+type TriggerRule struct {
+	Name            string   `json:"name"`
+	Source          string   `json:"source"`            // webhook, file, usb or maixcam
+	Secret          string   `json:"secret,omitempty"`  // webhook: bearer token or HMAC-SHA256 key
+	Paths           []string `json:"paths,omitempty"`   // file: globs relative to the workspace
+	Events          []string `json:"events,omitempty"`  // Event types to react to; empty means all
+	Prompt          string   `json:"prompt"`            // Go template over the event
+	Agent           string   `json:"agent,omitempty"`   // Empty uses the default agent
+	Channel         string   `json:"channel,omitempty"` // Where to send the response; empty keeps it silent
+	ChatID          string   `json:"chat_id,omitempty"`
+	CooldownSeconds int      `json:"cooldown_seconds,omitempty"` // Ignore events for N seconds after a run
+}
+
+// RedactionConfig controls masking of secrets in logs, saved sessions and,
+// optionally, tool output sent to the LLM.
+type RedactionConfig struct {
+	Enabled     bool     `json:"enabled"      env:"PICOCLAW_REDACTION_ENABLED"`
+	PII         bool     `json:"pii"          env:"PICOCLAW_REDACTION_PII"`          // Also mask emails, phone and card numbers
+	ToolResults bool     `json:"tool_results" env:"PICOCLAW_REDACTION_TOOL_RESULTS"` // Mask tool output before it reaches the LLM
+	Patterns    []string `json:"patterns"     env:"PICOCLAW_REDACTION_PATTERNS"`     // Extra regular expressions to mask
+}
+
+// PermissionsConfig assigns roles to senders. Users maps an identity to a
+// role name. An identity is "channel:sender_id", a bare sender ID or username,
+// or a canonical name from session.identity_links, which links one person
+// across channels. Senders without an entry get DefaultRole.
+type PermissionsConfig struct {
+	Enabled     bool                  `json:"enabled"      env:"PICOCLAW_PERMISSIONS_ENABLED"`
+	DefaultRole string                `json:"default_role" env:"PICOCLAW_PERMISSIONS_DEFAULT_ROLE"`
+	SystemRole  string                `json:"system_role"  env:"PICOCLAW_PERMISSIONS_SYSTEM_ROLE"` // Cron, trigger and heartbeat turns; empty uses default_role
+	Roles       map[string]RoleConfig `json:"roles"`
+	Users       map[string]string     `json:"users"`
+}
+
+// RoleConfig lists what members of a role may do. Tool and agent lists accept
+// glob patterns; an empty allow list allows everything.
+type RoleConfig struct {
+	Admin            bool     `json:"admin,omitempty"`              // May use admin chat commands such as /switch
+	Tools            []string `json:"tools,omitempty"`              // Tools offered to the LLM and allowed to run
+	DenyTools        []string `json:"deny_tools,omitempty"`         // Always denied, even if matched by Tools
+	Agents           []string `json:"agents,omitempty"`             // Agents the sender may reach, by route, /agent or spawn
+	DailyTokenBudget int      `json:"daily_token_budget,omitempty"` // LLM tokens per identity per day; 0 is unlimited
+}
+
+// AuditConfig controls the append-only JSONL log of tool invocations and
+// outbound messages.
+type AuditConfig struct {
+	Enabled   bool   `json:"enabled"     env:"PICOCLAW_AUDIT_ENABLED"`
+	Path      string `json:"path"        env:"PICOCLAW_AUDIT_PATH"`
+	MaxSizeMB int    `json:"max_size_mb" env:"PICOCLAW_AUDIT_MAX_SIZE_MB"` // Rotate when the file reaches this size
+	MaxFiles  int    `json:"max_files"   env:"PICOCLAW_AUDIT_MAX_FILES"`   // Rotated files to keep
+	HashChain bool   `json:"hash_chain"  env:"PICOCLAW_AUDIT_HASH_CHAIN"`  // Link records with SHA-256 for tamper evidence
+}
+
+// TracingConfig controls spans of agent turns. Spans go to the OTLP/HTTP
+// collector at Endpoint when set, otherwise to the JSONL file at Path.
+type TracingConfig struct {
+	Enabled     bool              `json:"enabled"                env:"PICOCLAW_TRACING_ENABLED"`
+	Endpoint    string            `json:"endpoint,omitempty"     env:"PICOCLAW_TRACING_ENDPOINT"` // OTLP/HTTP collector, e.g. http://localhost:4318
+	Headers     map[string]string `json:"headers,omitempty"`                                      // Sent with every export, e.g. an auth token
+	Path        string            `json:"path"                   env:"PICOCLAW_TRACING_PATH"`
+	ServiceName string            `json:"service_name,omitempty" env:"PICOCLAW_TRACING_SERVICE_NAME"`
+}
+
 type ProvidersConfig struct {
 	Anthropic     ProviderConfig       `json:"anthropic"`
 	OpenAI        OpenAIProviderConfig `json:"openai"`
@@ -404,11 +496,22 @@ type ModelConfig struct {
 	AuthMethod  string `json:"auth_method,omitempty"`  // Authentication method: oauth, token
 	ConnectMode string `json:"connect_mode,omitempty"` // Connection mode: stdio, grpc
 	Workspace   string `json:"workspace,omitempty"`    // Workspace path for CLI-based providers
+	NativeTools bool   `json:"native_tools,omitempty"` // claude-cli: serve tools over MCP instead of the prompt
 
 	// Optional optimizations
 	RPM            int    `json:"rpm,omitempty"`              // Requests per minute limit
+	TPM            int    `json:"tpm,omitempty"`              // Tokens per minute limit
 	MaxTokensField string `json:"max_tokens_field,omitempty"` // Field name for max tokens (e.g., "max_completion_tokens")
 	RequestTimeout int    `json:"request_timeout,omitempty"`
+
+	// Ollama native protocol (ollama/...)
+	KeepAlive string `json:"keep_alive,omitempty"` // How long the model stays loaded (e.g. "10m", "-1")
+	NumCtx    int    `json:"num_ctx,omitempty"`    // Context window; the Modelfile or Ollama default when unset
+	AutoPull  bool   `json:"auto_pull,omitempty"`  // Pull the model in the background if it is missing
+
+	// Load balancing across entries sharing this model_name
+	LoadBalance string `json:"load_balance,omitempty"` // round_robin (default), weighted or least_latency
+	Weight      int    `json:"weight,omitempty"`       // Relative share for the weighted strategy (default 1)
 }
 
 // Validate checks if the ModelConfig has all required fields.
@@ -419,12 +522,34 @@ func (c *ModelConfig) Validate() error {
 	if c.Model == "" {
 		return fmt.Errorf("model is required")
 	}
+	switch c.LoadBalance {
+	case "", LoadBalanceRoundRobin, LoadBalanceWeighted, LoadBalanceLeastLatency:
+	default:
+		return fmt.Errorf("load_balance %q is not one of round_robin, weighted, least_latency", c.LoadBalance)
+	}
+	if c.Weight < 0 {
+		return fmt.Errorf("weight must not be negative")
+	}
 	return nil
 }
 
 type GatewayConfig struct {
 	Host string `json:"host" env:"PICOCLAW_GATEWAY_HOST"`
 	Port int    `json:"port" env:"PICOCLAW_GATEWAY_PORT"`
+
+	// Background health probes of load-balanced model_list endpoints
+	ModelProbeInterval int `json:"model_probe_interval" env:"PICOCLAW_GATEWAY_MODEL_PROBE_INTERVAL"` // seconds, 0 disables
+	ModelProbeTimeout  int `json:"model_probe_timeout"  env:"PICOCLAW_GATEWAY_MODEL_PROBE_TIMEOUT"`  // seconds
+
+	Dashboard DashboardConfig `json:"dashboard"`
+}
+
+// DashboardConfig controls the admin web UI served by the gateway at
+// /dashboard/.
+type DashboardConfig struct {
+	Enabled     bool   `json:"enabled"      env:"PICOCLAW_GATEWAY_DASHBOARD_ENABLED"`
+	Token       string `json:"token"        env:"PICOCLAW_GATEWAY_DASHBOARD_TOKEN"`        // Empty generates a new token at every start
+	AllowRemote bool   `json:"allow_remote" env:"PICOCLAW_GATEWAY_DASHBOARD_ALLOW_REMOTE"` // Accept clients other than localhost
 }
 
 type BraveConfig struct {
@@ -470,17 +595,30 @@ type ExecConfig struct {
 	CustomDenyPatterns []string `json:"custom_deny_patterns" env:"PICOCLAW_TOOLS_EXEC_CUSTOM_DENY_PATTERNS"`
 }
 
+// UntrustedContentConfig controls how tool output from third parties (web
+// pages, skill registries, downloaded skills) is handled. Policy is "off",
+// "approve" or "block" and applies to HighRiskTools for the rest of a turn
+// once such content has been read.
+type UntrustedContentConfig struct {
+	Policy        string   `json:"policy"          env:"PICOCLAW_TOOLS_UNTRUSTED_CONTENT_POLICY"`
+	HighRiskTools []string `json:"high_risk_tools" env:"PICOCLAW_TOOLS_UNTRUSTED_CONTENT_HIGH_RISK_TOOLS"`
+}
+
 type ToolsConfig struct {
-	Web    WebToolsConfig    `json:"web"`
-	Cron   CronToolsConfig   `json:"cron"`
-	Exec   ExecConfig        `json:"exec"`
-	Skills SkillsToolsConfig `json:"skills"`
+	Web              WebToolsConfig         `json:"web"`
+	Cron             CronToolsConfig        `json:"cron"`
+	Exec             ExecConfig             `json:"exec"`
+	Skills           SkillsToolsConfig      `json:"skills"`
+	UntrustedContent UntrustedContentConfig `json:"untrusted_content"`
 }
 
 type SkillsToolsConfig struct {
 	Registries            SkillsRegistriesConfig `json:"registries"`
 	MaxConcurrentSearches int                    `json:"max_concurrent_searches" env:"PICOCLAW_SKILLS_MAX_CONCURRENT_SEARCHES"`
+	SummaryTopK           int                    `json:"summary_top_k"           env:"PICOCLAW_SKILLS_SUMMARY_TOP_K"`
 	SearchCache           SearchCacheConfig      `json:"search_cache"`
+	// DeniedPermissions makes skills that declare any of these unavailable.
+	DeniedPermissions []string `json:"denied_permissions,omitempty"`
 }
 
 type SearchCacheConfig struct {
@@ -490,6 +628,25 @@ type SearchCacheConfig struct {
 
 type SkillsRegistriesConfig struct {
 	ClawHub ClawHubRegistryConfig `json:"clawhub"`
+	Git     []GitRegistryConfig   `json:"git"`
+	Local   []LocalRegistryConfig `json:"local"`
+}
+
+// GitRegistryConfig is a git repository of skills, or a GitHub
+// organization whose repositories each hold one skill.
+type GitRegistryConfig struct {
+	Name           string `json:"name"`
+	URL            string `json:"url"`
+	Ref            string `json:"ref,omitempty"`
+	Path           string `json:"path,omitempty"`
+	AuthToken      string `json:"auth_token,omitempty"`
+	RefreshMinutes int    `json:"refresh_minutes,omitempty"`
+}
+
+// LocalRegistryConfig is a directory of skills, e.g. a shared team folder.
+type LocalRegistryConfig struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
 }
 
 type ClawHubRegistryConfig struct {
@@ -542,6 +699,11 @@ func LoadConfig(path string) (*Config, error) {
 		cfg.ModelList = ConvertProvidersToModelList(cfg)
 	}
 
+	// Resolve secret://, env:// and file:// references after env overrides,
+	// so environment variables may hold references too, and after migration,
+	// so migrated entries remember the reference they were copied from.
+	resolveSecretRefs(cfg, path)
+
 	// Validate model_list for uniqueness and required fields
 	if err := cfg.ValidateModelList(); err != nil {
 		return nil, err
@@ -550,8 +712,14 @@ func LoadConfig(path string) (*Config, error) {
 	return cfg, nil
 }
 
+// SaveConfig writes cfg to path. Values loaded from secret references are
+// written back as the reference, never as the resolved secret.
 func SaveConfig(path string, cfg *Config) error {
-	data, err := json.MarshalIndent(cfg, "", "  ")
+	out, err := withSecretRefs(cfg)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
 	if err != nil {
 		return err
 	}
@@ -564,6 +732,16 @@ func (c *Config) WorkspacePath() string {
 	return expandHome(c.Agents.Defaults.Workspace)
 }
 
+// AuditPath returns the audit log location with ~ expanded.
+func (c *Config) AuditPath() string {
+	return expandHome(c.Audit.Path)
+}
+
+// TracingPath returns the trace file location with ~ expanded.
+func (c *Config) TracingPath() string {
+	return expandHome(c.Tracing.Path)
+}
+
 func (c *Config) GetAPIKey() string {
 	if c.Providers.OpenRouter.APIKey != "" {
 		return c.Providers.OpenRouter.APIKey
@@ -626,20 +804,31 @@ func expandHome(path string) string {
 }
 
 // GetModelConfig returns the ModelConfig for the given model name.
-// If multiple configs exist with the same model_name, it uses round-robin
-// selection for load balancing. Returns an error if the model is not found.
+// If multiple configs exist with the same model_name, one is chosen by the
+// group's load_balance strategy, skipping endpoints in cooldown.
+// Returns an error if the model is not found.
 func (c *Config) GetModelConfig(modelName string) (*ModelConfig, error) {
+	mc, _, err := c.SelectModelConfig(modelName)
+	return mc, err
+}
+
+// SelectModelConfig is GetModelConfig that also returns the EndpointID of the
+// chosen entry, under which its health is recorded.
+func (c *Config) SelectModelConfig(modelName string) (*ModelConfig, string, error) {
 	matches := c.findMatches(modelName)
 	if len(matches) == 0 {
-		return nil, fmt.Errorf("model %q not found in model_list or providers", modelName)
+		return nil, "", fmt.Errorf("model %q not found in model_list or providers", modelName)
 	}
-	if len(matches) == 1 {
-		return &matches[0], nil
+	idx := 0
+	if len(matches) > 1 {
+		idx = endpointHealth.pick(modelName, matches)
 	}
+	return &matches[idx], EndpointID(modelName, idx), nil
+}
 
-	// Multiple configs - use round-robin for load balancing
-	idx := rrCounter.Add(1) % uint64(len(matches))
-	return &matches[idx], nil
+// ModelEndpointCount returns how many model_list entries share modelName.
+func (c *Config) ModelEndpointCount(modelName string) int {
+	return len(c.findMatches(modelName))
 }
 
 // findMatches finds all ModelConfig entries with the given model_name.
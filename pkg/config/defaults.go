@@ -17,6 +17,12 @@ func DefaultConfig() *Config {
 				MaxTokens:           32768,
 				Temperature:         nil, // nil means use provider default
 				MaxToolIterations:   50,
+				MaxQueueWaitSeconds: 10,
+				ResponseCache: ResponseCacheConfig{
+					Enabled:    false,
+					MaxEntries: 500,
+					TTLSeconds: 3600,
+				},
 			},
 		},
 		Bindings: []AgentBinding{},
@@ -251,7 +257,7 @@ func DefaultConfig() *Config {
 			{
 				ModelName: "llama3",
 				Model:     "ollama/llama3",
-				APIBase:   "http://localhost:11434/v1",
+				APIBase:   "http://localhost:11434",
 				APIKey:    "ollama",
 			},
 
@@ -272,8 +278,15 @@ func DefaultConfig() *Config {
 			},
 		},
 		Gateway: GatewayConfig{
-			Host: "127.0.0.1",
-			Port: 18790,
+			Host:               "127.0.0.1",
+			Port:               18790,
+			ModelProbeInterval: 0,
+			ModelProbeTimeout:  10,
+			Dashboard: DashboardConfig{
+				Enabled:     false,
+				Token:       "",
+				AllowRemote: false,
+			},
 		},
 		Tools: ToolsConfig{
 			Web: WebToolsConfig{
@@ -299,6 +312,13 @@ func DefaultConfig() *Config {
 			Exec: ExecConfig{
 				EnableDenyPatterns: true,
 			},
+			UntrustedContent: UntrustedContentConfig{
+				Policy: "approve",
+				HighRiskTools: []string{
+					"exec", "write_file", "edit_file", "append_file", "message", "install_skill",
+					"spawn", "subagent", "cron",
+				},
+			},
 			Skills: SkillsToolsConfig{
 				Registries: SkillsRegistriesConfig{
 					ClawHub: ClawHubRegistryConfig{
@@ -307,6 +327,7 @@ func DefaultConfig() *Config {
 					},
 				},
 				MaxConcurrentSearches: 2,
+				SummaryTopK:           8,
 				SearchCache: SearchCacheConfig{
 					MaxSize:    50,
 					TTLSeconds: 300,
@@ -321,5 +342,43 @@ func DefaultConfig() *Config {
 			Enabled:    false,
 			MonitorUSB: true,
 		},
+		Triggers: TriggersConfig{
+			Enabled: false,
+			Rules:   []TriggerRule{},
+		},
+		Redaction: RedactionConfig{
+			Enabled:     true,
+			PII:         false,
+			ToolResults: false,
+			Patterns:    []string{},
+		},
+		Audit: AuditConfig{
+			Enabled:   false,
+			Path:      "~/.picoclaw/audit/audit.jsonl",
+			MaxSizeMB: 10,
+			MaxFiles:  5,
+			HashChain: false,
+		},
+		Tracing: TracingConfig{
+			Enabled:     false,
+			Path:        "~/.picoclaw/traces/traces.jsonl",
+			ServiceName: "picoclaw",
+		},
+		Permissions: PermissionsConfig{
+			Enabled:     false,
+			DefaultRole: "member",
+			Roles: map[string]RoleConfig{
+				"admin": {Admin: true},
+				"member": {
+					DenyTools: []string{"exec", "write_file", "edit_file", "append_file", "spawn", "install_skill"},
+				},
+				"guest": {
+					Tools:            []string{"web_search", "web_fetch", "message"},
+					Agents:           []string{"main"},
+					DailyTokenBudget: 50000,
+				},
+			},
+			Users: map[string]string{},
+		},
 	}
 }
@@ -0,0 +1,313 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Load-balancing strategies for model_list entries that share a model_name.
+// The strategy is taken from the first entry of the group that sets one.
+const (
+	LoadBalanceRoundRobin   = "round_robin"   // rotate across available endpoints
+	LoadBalanceWeighted     = "weighted"      // random, by weight scaled by health
+	LoadBalanceLeastLatency = "least_latency" // fastest healthy endpoint
+)
+
+const (
+	latencyAlpha   = 0.3  // EWMA factor for observed latency
+	errorRateAlpha = 0.2  // EWMA factor for the error rate
+	minHealth      = 0.05 // floor so a failing endpoint is still retried now and then
+)
+
+// EndpointID identifies the index-th model_list entry named modelName.
+func EndpointID(modelName string, index int) string {
+	return fmt.Sprintf("%s#%d", modelName, index)
+}
+
+// EndpointStat is a snapshot of one load-balanced endpoint.
+type EndpointStat struct {
+	ID              string    `json:"id"`
+	ModelName       string    `json:"model_name"`
+	Model           string    `json:"model"`
+	APIBase         string    `json:"api_base,omitempty"`
+	Strategy        string    `json:"strategy"`
+	Weight          int       `json:"weight"`
+	Available       bool      `json:"available"`
+	CooldownSeconds int       `json:"cooldown_seconds,omitempty"`
+	Requests        int64     `json:"requests"`
+	Failures        int64     `json:"failures"`
+	ErrorRate       float64   `json:"error_rate"`
+	LatencyMS       int64     `json:"latency_ms,omitempty"`
+	LastError       string    `json:"last_error,omitempty"`
+	LastUsed        time.Time `json:"last_used,omitzero"`
+	ProbeOK         *bool     `json:"probe_ok,omitempty"`
+	ProbeLatencyMS  int64     `json:"probe_latency_ms,omitempty"`
+	LastProbe       time.Time `json:"last_probe,omitzero"`
+}
+
+type endpointState struct {
+	requests  int64
+	failures  int64
+	errorRate float64
+	latency   time.Duration // EWMA of successful requests; zero until measured
+	lastError string
+	lastUsed  time.Time
+
+	probed       bool
+	probeOK      bool
+	probeLatency time.Duration
+	lastProbe    time.Time
+}
+
+// EndpointHealth tracks the observed health of model_list endpoints and
+// drives the selection in GetModelConfig. It is safe for concurrent use.
+type EndpointHealth struct {
+	mu       sync.Mutex
+	states   map[string]*endpointState
+	cooldown func(id string) time.Duration
+	rand     func() float64
+	now      func() time.Time
+}
+
+// NewEndpointHealth creates an empty registry.
+func NewEndpointHealth() *EndpointHealth {
+	return &EndpointHealth{
+		states: make(map[string]*endpointState),
+		rand:   rand.Float64,
+		now:    time.Now,
+	}
+}
+
+var endpointHealth = NewEndpointHealth()
+
+// Endpoints returns the process-wide endpoint health registry.
+func Endpoints() *EndpointHealth {
+	return endpointHealth
+}
+
+// SetCooldownSource installs the function reporting how long an endpoint
+// stays in cooldown. Endpoints in cooldown are skipped while others remain.
+func (h *EndpointHealth) SetCooldownSource(fn func(id string) time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cooldown = fn
+}
+
+// Record stores the outcome of a request sent to endpoint id.
+func (h *EndpointHealth) Record(id string, latency time.Duration, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := h.state(id)
+	s.requests++
+	s.lastUsed = h.now()
+	if err != nil {
+		s.failures++
+		s.errorRate += errorRateAlpha * (1 - s.errorRate)
+		s.lastError = err.Error()
+		return
+	}
+	s.errorRate -= errorRateAlpha * s.errorRate
+	if s.latency == 0 {
+		s.latency = latency
+	} else {
+		s.latency = time.Duration(latencyAlpha*float64(latency) + (1-latencyAlpha)*float64(s.latency))
+	}
+}
+
+// RecordProbe stores the outcome of a background probe of endpoint id.
+// A failed probe also raises the error rate.
+func (h *EndpointHealth) RecordProbe(id string, latency time.Duration, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := h.state(id)
+	s.probed = true
+	s.probeOK = err == nil
+	s.probeLatency = latency
+	s.lastProbe = h.now()
+	if err != nil {
+		s.errorRate += errorRateAlpha * (1 - s.errorRate)
+		s.lastError = "probe: " + err.Error()
+	}
+}
+
+func (h *EndpointHealth) state(id string) *endpointState {
+	s := h.states[id]
+	if s == nil {
+		s = &endpointState{}
+		h.states[id] = s
+	}
+	return s
+}
+
+func (h *EndpointHealth) cooldownRemaining(id string) time.Duration {
+	if h.cooldown == nil {
+		return 0
+	}
+	return h.cooldown(id)
+}
+
+// pick chooses the index of one of the entries sharing modelName.
+func (h *EndpointHealth) pick(modelName string, entries []ModelConfig) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Skip endpoints in cooldown; if all are, rather try one than fail.
+	candidates := make([]int, 0, len(entries))
+	for i := range entries {
+		if h.cooldownRemaining(EndpointID(modelName, i)) <= 0 {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		for i := range entries {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+
+	switch loadBalanceStrategy(entries) {
+	case LoadBalanceWeighted:
+		return h.pickWeighted(modelName, entries, candidates)
+	case LoadBalanceLeastLatency:
+		return h.pickLeastLatency(modelName, candidates)
+	default:
+		return candidates[rrCounter.Add(1)%uint64(len(candidates))]
+	}
+}
+
+// pickWeighted draws an endpoint with probability proportional to its
+// configured weight, scaled down by its error rate and by how much slower it
+// is than the fastest measured endpoint.
+func (h *EndpointHealth) pickWeighted(modelName string, entries []ModelConfig, candidates []int) int {
+	var fastest time.Duration
+	for _, i := range candidates {
+		if s := h.states[EndpointID(modelName, i)]; s != nil && s.latency > 0 {
+			if fastest == 0 || s.latency < fastest {
+				fastest = s.latency
+			}
+		}
+	}
+
+	scores := make([]float64, len(candidates))
+	total := 0.0
+	for n, i := range candidates {
+		score := float64(max(entries[i].Weight, 1))
+		if s := h.states[EndpointID(modelName, i)]; s != nil {
+			score *= math.Max(1-s.errorRate, minHealth)
+			if s.latency > 0 {
+				score *= float64(fastest) / float64(s.latency)
+			}
+		}
+		scores[n] = score
+		total += score
+	}
+
+	r := h.rand() * total
+	for n, score := range scores {
+		if r < score {
+			return candidates[n]
+		}
+		r -= score
+	}
+	return candidates[len(candidates)-1]
+}
+
+// pickLeastLatency returns the endpoint with the lowest latency adjusted for
+// its error rate. Endpoints without measurements go first so every endpoint
+// gets measured.
+func (h *EndpointHealth) pickLeastLatency(modelName string, candidates []int) int {
+	var unmeasured []int
+	best, bestScore := -1, 0.0
+	for _, i := range candidates {
+		s := h.states[EndpointID(modelName, i)]
+		if s == nil || s.requests == 0 {
+			unmeasured = append(unmeasured, i)
+			continue
+		}
+		score := math.Inf(1) // tried, but never succeeded
+		if s.latency > 0 {
+			score = float64(s.latency) / math.Max(1-s.errorRate, minHealth)
+		}
+		if best < 0 || score < bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if len(unmeasured) > 0 {
+		return unmeasured[rrCounter.Add(1)%uint64(len(unmeasured))]
+	}
+	return best
+}
+
+// loadBalanceStrategy returns the strategy configured for a model_name group.
+func loadBalanceStrategy(entries []ModelConfig) string {
+	for i := range entries {
+		if entries[i].LoadBalance != "" {
+			return entries[i].LoadBalance
+		}
+	}
+	return LoadBalanceRoundRobin
+}
+
+// EndpointStats returns a snapshot of every model_list entry whose model_name
+// is shared with other entries, ordered by model_name.
+func (c *Config) EndpointStats() []EndpointStat {
+	groups := make(map[string][]ModelConfig)
+	for _, mc := range c.ModelList {
+		groups[mc.ModelName] = append(groups[mc.ModelName], mc)
+	}
+	names := make([]string, 0, len(groups))
+	for name, entries := range groups {
+		if len(entries) > 1 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	h := endpointHealth
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var stats []EndpointStat
+	for _, name := range names {
+		entries := groups[name]
+		strategy := loadBalanceStrategy(entries)
+		for i, mc := range entries {
+			id := EndpointID(name, i)
+			cooldown := h.cooldownRemaining(id)
+			stat := EndpointStat{
+				ID:              id,
+				ModelName:       name,
+				Model:           mc.Model,
+				APIBase:         mc.APIBase,
+				Strategy:        strategy,
+				Weight:          max(mc.Weight, 1),
+				Available:       cooldown <= 0,
+				CooldownSeconds: int(math.Ceil(max(cooldown, 0).Seconds())),
+			}
+			if s := h.states[id]; s != nil {
+				stat.Requests = s.requests
+				stat.Failures = s.failures
+				stat.ErrorRate = math.Round(s.errorRate*1000) / 1000
+				stat.LatencyMS = s.latency.Milliseconds()
+				stat.LastError = s.lastError
+				stat.LastUsed = s.lastUsed
+				if s.probed {
+					ok := s.probeOK
+					stat.ProbeOK = &ok
+					stat.ProbeLatencyMS = s.probeLatency.Milliseconds()
+					stat.LastProbe = s.lastProbe
+				}
+			}
+			stats = append(stats, stat)
+		}
+	}
+	return stats
+}
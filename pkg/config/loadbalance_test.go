@@ -0,0 +1,168 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// withEndpointHealth swaps the process-wide registry for the test.
+func withEndpointHealth(t *testing.T) *EndpointHealth {
+	t.Helper()
+	prev := endpointHealth
+	endpointHealth = NewEndpointHealth()
+	t.Cleanup(func() { endpointHealth = prev })
+	return endpointHealth
+}
+
+func lbConfig(strategy string, weights ...int) *Config {
+	cfg := &Config{}
+	for i, w := range weights {
+		cfg.ModelList = append(cfg.ModelList, ModelConfig{
+			ModelName:   "lb",
+			Model:       "openai/m" + string(rune('0'+i)),
+			APIKey:      "k",
+			LoadBalance: strategy,
+			Weight:      w,
+		})
+	}
+	return cfg
+}
+
+func TestSelectModelConfig_SkipsCooldown(t *testing.T) {
+	h := withEndpointHealth(t)
+	h.SetCooldownSource(func(id string) time.Duration {
+		if id == EndpointID("lb", 1) {
+			return time.Minute
+		}
+		return 0
+	})
+
+	for _, strategy := range []string{"", LoadBalanceWeighted, LoadBalanceLeastLatency} {
+		cfg := lbConfig(strategy, 1, 1)
+		for range 20 {
+			mc, id, err := cfg.SelectModelConfig("lb")
+			if err != nil {
+				t.Fatalf("SelectModelConfig() error = %v", err)
+			}
+			if id != "lb#0" || mc.Model != "openai/m0" {
+				t.Fatalf("strategy %q selected %s (%s), want lb#0", strategy, id, mc.Model)
+			}
+		}
+	}
+}
+
+func TestSelectModelConfig_AllInCooldown(t *testing.T) {
+	h := withEndpointHealth(t)
+	h.SetCooldownSource(func(string) time.Duration { return time.Minute })
+
+	cfg := lbConfig("", 1, 1)
+	if _, _, err := cfg.SelectModelConfig("lb"); err != nil {
+		t.Fatalf("SelectModelConfig() error = %v, want an endpoint anyway", err)
+	}
+}
+
+func TestSelectModelConfig_Weighted(t *testing.T) {
+	h := withEndpointHealth(t)
+	draws := []float64{0.1, 0.5, 0.74, 0.76, 0.99}
+	n := 0
+	h.rand = func() float64 { r := draws[n%len(draws)]; n++; return r }
+
+	cfg := lbConfig(LoadBalanceWeighted, 3, 1)
+	counts := map[string]int{}
+	for range draws {
+		_, id, _ := cfg.SelectModelConfig("lb")
+		counts[id]++
+	}
+	if counts["lb#0"] != 3 || counts["lb#1"] != 2 {
+		t.Errorf("counts = %v, want 3 for lb#0 (weight 3) and 2 for lb#1", counts)
+	}
+
+	// A failing endpoint loses most of its share.
+	for range 10 {
+		h.Record("lb#0", time.Second, errors.New("boom"))
+	}
+	h.Record("lb#1", time.Second, nil)
+	h.rand = func() float64 { return 0.5 }
+	if _, id, _ := cfg.SelectModelConfig("lb"); id != "lb#1" {
+		t.Errorf("selected %s, want the healthy lb#1", id)
+	}
+}
+
+func TestSelectModelConfig_LeastLatency(t *testing.T) {
+	h := withEndpointHealth(t)
+	cfg := lbConfig(LoadBalanceLeastLatency, 0, 0, 0)
+
+	// Unmeasured endpoints are tried first.
+	h.Record("lb#0", 300*time.Millisecond, nil)
+	h.Record("lb#1", 100*time.Millisecond, nil)
+	if _, id, _ := cfg.SelectModelConfig("lb"); id != "lb#2" {
+		t.Fatalf("selected %s, want unmeasured lb#2", id)
+	}
+
+	h.Record("lb#2", 200*time.Millisecond, nil)
+	if _, id, _ := cfg.SelectModelConfig("lb"); id != "lb#1" {
+		t.Errorf("selected %s, want fastest lb#1", id)
+	}
+
+	// Errors make the fastest endpoint look slower.
+	for range 5 {
+		h.Record("lb#1", 0, errors.New("boom"))
+	}
+	if _, id, _ := cfg.SelectModelConfig("lb"); id != "lb#2" {
+		t.Errorf("selected %s, want lb#2 after lb#1 errors", id)
+	}
+}
+
+func TestEndpointStats(t *testing.T) {
+	h := withEndpointHealth(t)
+	h.SetCooldownSource(func(id string) time.Duration {
+		if id == "lb#1" {
+			return 90 * time.Second
+		}
+		return 0
+	})
+	h.Record("lb#0", 200*time.Millisecond, nil)
+	h.Record("lb#0", 100*time.Millisecond, nil)
+	h.Record("lb#1", 0, errors.New("HTTP 503"))
+	h.RecordProbe("lb#1", 50*time.Millisecond, errors.New("timeout"))
+
+	cfg := lbConfig(LoadBalanceWeighted, 2, 0)
+	cfg.ModelList = append(cfg.ModelList, ModelConfig{ModelName: "single", Model: "openai/x"})
+
+	stats := cfg.EndpointStats()
+	if len(stats) != 2 {
+		t.Fatalf("len(stats) = %d, want 2 (single-entry models are not listed)", len(stats))
+	}
+	s0, s1 := stats[0], stats[1]
+	if s0.ID != "lb#0" || s0.Strategy != LoadBalanceWeighted || s0.Weight != 2 || !s0.Available {
+		t.Errorf("stats[0] = %+v", s0)
+	}
+	if s0.Requests != 2 || s0.LatencyMS != 170 {
+		t.Errorf("stats[0] requests/latency = %d/%dms, want 2/170ms", s0.Requests, s0.LatencyMS)
+	}
+	if s1.Available || s1.CooldownSeconds != 90 || s1.Failures != 1 {
+		t.Errorf("stats[1] = %+v, want unavailable for 90s with 1 failure", s1)
+	}
+	if s1.ProbeOK == nil || *s1.ProbeOK || s1.LastError != "probe: timeout" {
+		t.Errorf("stats[1] probe = %v, last error %q", s1.ProbeOK, s1.LastError)
+	}
+	if s1.ErrorRate <= 0.2 {
+		t.Errorf("stats[1] error rate = %v, want above 0.2", s1.ErrorRate)
+	}
+}
+
+func TestModelConfig_ValidateLoadBalance(t *testing.T) {
+	mc := ModelConfig{ModelName: "m", Model: "openai/m", LoadBalance: "fastest"}
+	if err := mc.Validate(); err == nil {
+		t.Error("Validate() accepted an unknown load_balance")
+	}
+	mc = ModelConfig{ModelName: "m", Model: "openai/m", Weight: -1}
+	if err := mc.Validate(); err == nil {
+		t.Error("Validate() accepted a negative weight")
+	}
+	mc = ModelConfig{ModelName: "m", Model: "openai/m", LoadBalance: LoadBalanceLeastLatency, Weight: 2}
+	if err := mc.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/secrets"
+)
+
+// secretRef remembers where a resolved value came from, so SaveConfig can
+// write the reference back instead of the secret.
+type secretRef struct {
+	ref      string
+	resolved string
+}
+
+// resolveSecretRefs replaces every secret://, env:// and file:// reference
+// held by a credential field of cfg (see isSecretField) with the value it
+// points to. The store lives next to the config file. A reference that
+// cannot be resolved is logged and leaves its field empty, so one missing
+// secret only disables what needs it.
+func resolveSecretRefs(cfg *Config, configPath string) {
+	resolver := secrets.NewResolver(secrets.DefaultPaths(configPath))
+	refs := make(map[string]secretRef)
+
+	_ = walkStrings(reflect.ValueOf(cfg).Elem(), "", func(path string, v reflect.Value) error {
+		ref := v.String()
+		if !isSecretField(path) || !secrets.IsReference(ref) {
+			return nil
+		}
+		value, err := resolver.Resolve(ref)
+		if err != nil {
+			logger.WarnCF("config", "Failed to resolve secret reference", map[string]any{
+				"field": path,
+				"error": err.Error(),
+			})
+			value = ""
+		}
+		v.SetString(value)
+		refs[path] = secretRef{ref: ref, resolved: value}
+		return nil
+	})
+
+	cfg.secretRefs = refs
+}
+
+// withSecretRefs returns a copy of cfg in which resolved values are replaced
+// by their original references. Values changed since loading are kept as-is.
+func withSecretRefs(cfg *Config) (*Config, error) {
+	if len(cfg.secretRefs) == 0 {
+		return cfg, nil
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	out := &Config{}
+	if err := json.Unmarshal(data, out); err != nil {
+		return nil, err
+	}
+
+	err = walkStrings(reflect.ValueOf(out).Elem(), "", func(path string, v reflect.Value) error {
+		if r, ok := cfg.secretRefs[path]; ok && v.String() == r.resolved {
+			v.SetString(r.ref)
+		}
+		return nil
+	})
+	return out, err
+}
+
+// SecretValues returns the credential values in cfg: every string field whose
+// name ends in key, token, secret or password, plus values resolved from
+// secret references. It is used to mask them in logs and saved sessions.
+func (c *Config) SecretValues() []string {
+	var values []string
+	_ = walkStrings(reflect.ValueOf(c).Elem(), "", func(path string, v reflect.Value) error {
+		if v.String() != "" && isSecretField(path) {
+			values = append(values, v.String())
+		}
+		return nil
+	})
+	for _, r := range c.secretRefs {
+		if r.resolved != "" {
+			values = append(values, r.resolved)
+		}
+	}
+	return values
+}
+
+func isSecretField(path string) bool {
+	name := strings.ToLower(path[strings.LastIndex(path, ".")+1:])
+	for _, suffix := range []string{"key", "token", "secret", "password"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// walkStrings calls fn for every settable string reachable from v. Paths use
+// JSON field names, e.g. "model_list[0].api_key".
+func walkStrings(v reflect.Value, path string, fn func(path string, v reflect.Value) error) error {
+	switch v.Kind() {
+	case reflect.String:
+		if v.CanSet() {
+			return fn(path, v)
+		}
+
+	case reflect.Pointer:
+		if !v.IsNil() {
+			return walkStrings(v.Elem(), path, fn)
+		}
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := jsonFieldName(field)
+			if name == "-" {
+				continue
+			}
+			if err := walkStrings(v.Field(i), joinPath(path, name), fn); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := walkStrings(v.Index(i), path+"["+strconv.Itoa(i)+"]", fn); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			// Map values are not addressable: walk a copy and store it back
+			// only if fn changed it, so read-only walks never write to cfg.
+			elem := reflect.New(iter.Value().Type()).Elem()
+			elem.Set(iter.Value())
+			if err := walkStrings(elem, joinPath(path, iter.Key().String()), fn); err != nil {
+				return err
+			}
+			if !reflect.DeepEqual(elem.Interface(), iter.Value().Interface()) {
+				v.SetMapIndex(iter.Key(), elem)
+			}
+		}
+	}
+	return nil
+}
+
+func jsonFieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
@@ -0,0 +1,220 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/secrets"
+)
+
+func TestLoadConfig_ResolvesSecretRefs(t *testing.T) {
+	t.Setenv(secrets.PassphraseEnv, "")
+	t.Setenv("PICOCLAW_TEST_TG_TOKEN", "tg-from-env")
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	store, err := secrets.Open(secrets.DefaultPaths(configPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("openai", "sk-resolved"); err != nil {
+		t.Fatal(err)
+	}
+
+	data := `{
+		"model_list": [{"model_name": "gpt", "model": "openai/gpt-4o", "api_key": "secret://openai"}],
+		"channels": {"telegram": {"token": "env://PICOCLAW_TEST_TG_TOKEN"}}
+	}`
+	if err := os.WriteFile(configPath, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+	if got := cfg.ModelList[0].APIKey; got != "sk-resolved" {
+		t.Errorf("api_key = %q, want %q", got, "sk-resolved")
+	}
+	if got := cfg.Channels.Telegram.Token; got != "tg-from-env" {
+		t.Errorf("telegram token = %q, want %q", got, "tg-from-env")
+	}
+
+	// Saving writes the references back, not the secrets.
+	cfg.Channels.Telegram.Proxy = "http://proxy:8080"
+	if err := SaveConfig(configPath, cfg); err != nil {
+		t.Fatalf("SaveConfig() error: %v", err)
+	}
+	saved, _ := os.ReadFile(configPath)
+	for _, leaked := range []string{"sk-resolved", "tg-from-env"} {
+		if strings.Contains(string(saved), leaked) {
+			t.Errorf("saved config contains resolved secret %q", leaked)
+		}
+	}
+	for _, ref := range []string{"secret://openai", "env://PICOCLAW_TEST_TG_TOKEN", "http://proxy:8080"} {
+		if !strings.Contains(string(saved), ref) {
+			t.Errorf("saved config missing %q", ref)
+		}
+	}
+	if cfg.ModelList[0].APIKey != "sk-resolved" {
+		t.Error("SaveConfig() must not modify the in-memory config")
+	}
+}
+
+func TestSaveConfig_ChangedValueReplacesRef(t *testing.T) {
+	t.Setenv("PICOCLAW_TEST_KEY", "old")
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	data := `{"model_list": [{"model_name": "gpt", "model": "openai/gpt-4o", "api_key": "env://PICOCLAW_TEST_KEY"}]}`
+	if err := os.WriteFile(configPath, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+	cfg.ModelList[0].APIKey = "new-key"
+	if err := SaveConfig(configPath, cfg); err != nil {
+		t.Fatalf("SaveConfig() error: %v", err)
+	}
+	saved, _ := os.ReadFile(configPath)
+	if !strings.Contains(string(saved), "new-key") || strings.Contains(string(saved), "env://PICOCLAW_TEST_KEY") {
+		t.Errorf("saved config should contain the edited value, got:\n%s", saved)
+	}
+}
+
+func TestLoadConfig_UnresolvedSecretRef(t *testing.T) {
+	t.Setenv(secrets.PassphraseEnv, "")
+	t.Setenv("PICOCLAW_TEST_TG_TOKEN", "tg-from-env")
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	data := `{
+		"model_list": [{"model_name": "gpt", "model": "openai/gpt-4o", "api_key": "secret://missing"}],
+		"channels": {"telegram": {"token": "env://PICOCLAW_TEST_TG_TOKEN"}}
+	}`
+	if err := os.WriteFile(configPath, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// A missing secret empties its field instead of failing the whole load.
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+	if cfg.ModelList[0].APIKey != "" {
+		t.Errorf("api_key = %q, want empty", cfg.ModelList[0].APIKey)
+	}
+	if cfg.Channels.Telegram.Token != "tg-from-env" {
+		t.Errorf("telegram token = %q, want %q", cfg.Channels.Telegram.Token, "tg-from-env")
+	}
+	if err := SaveConfig(configPath, cfg); err != nil {
+		t.Fatalf("SaveConfig() error: %v", err)
+	}
+	if saved, _ := os.ReadFile(configPath); !strings.Contains(string(saved), "secret://missing") {
+		t.Errorf("saved config lost the unresolved reference:\n%s", saved)
+	}
+}
+
+func TestLoadConfig_OnlyCredentialFieldsResolved(t *testing.T) {
+	t.Setenv("PICOCLAW_TEST_DIR", "/tmp/should-not-be-used")
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	data := `{"agents": {"defaults": {"workspace": "env://PICOCLAW_TEST_DIR"}}}`
+	if err := os.WriteFile(configPath, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+	if got := cfg.Agents.Defaults.Workspace; got != "env://PICOCLAW_TEST_DIR" {
+		t.Errorf("workspace = %q, want the literal value", got)
+	}
+}
+
+func TestLoadConfig_MigratedSecretRefsSurviveSave(t *testing.T) {
+	t.Setenv("PICOCLAW_TEST_OPENAI_KEY", "sk-from-env")
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "anthropic.key")
+	if err := os.WriteFile(keyFile, []byte("sk-ant-from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(dir, "config.json")
+	data := `{"model_list": [], "providers": {
+		"openai": {"api_key": "env://PICOCLAW_TEST_OPENAI_KEY"},
+		"anthropic": {"api_key": "file://` + keyFile + `"}
+	}}`
+	if err := os.WriteFile(configPath, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for round := 0; round < 2; round++ {
+		cfg, err := LoadConfig(configPath)
+		if err != nil {
+			t.Fatalf("LoadConfig() #%d error: %v", round, err)
+		}
+		keys := map[string]string{}
+		for _, m := range cfg.ModelList {
+			keys[m.APIKey] = m.ModelName
+		}
+		if _, ok := keys["sk-from-env"]; !ok {
+			t.Errorf("round %d: migrated model_list lacks the env:// key: %v", round, keys)
+		}
+		if _, ok := keys["sk-ant-from-file"]; !ok {
+			t.Errorf("round %d: migrated model_list lacks the file:// key: %v", round, keys)
+		}
+		if err := SaveConfig(configPath, cfg); err != nil {
+			t.Fatalf("SaveConfig() #%d error: %v", round, err)
+		}
+
+		saved, _ := os.ReadFile(configPath)
+		for _, leaked := range []string{"sk-from-env", "sk-ant-from-file"} {
+			if strings.Contains(string(saved), leaked) {
+				t.Fatalf("round %d: saved config contains resolved secret %q:\n%s", round, leaked, saved)
+			}
+		}
+	}
+}
+
+func TestConfig_SecretValues(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ModelList = []ModelConfig{{ModelName: "gpt", Model: "openai/gpt-4o", APIKey: "sk-model-key", MaxTokensField: "max_completion_tokens"}}
+	cfg.Channels.Telegram.Token = "telegram-bot-token"
+	cfg.Channels.WeComApp.CorpSecret = "corp-secret-value"
+	cfg.secretRefs = map[string]secretRef{"x": {ref: "env://X", resolved: "resolved-value"}}
+
+	values := strings.Join(cfg.SecretValues(), "\n")
+	for _, want := range []string{"sk-model-key", "telegram-bot-token", "corp-secret-value", "resolved-value"} {
+		if !strings.Contains(values, want) {
+			t.Errorf("SecretValues() missing %q", want)
+		}
+	}
+	for _, notWant := range []string{"max_completion_tokens", "openai/gpt-4o"} {
+		if strings.Contains(values, notWant) {
+			t.Errorf("SecretValues() should not include %q", notWant)
+		}
+	}
+}
+
+func TestConfig_SecretValuesDoesNotWrite(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Tracing.Headers = map[string]string{"authorization_token": "header-token"}
+
+	// SecretValues runs while other goroutines read the config; -race
+	// catches a write to the map.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			for range cfg.Tracing.Headers {
+			}
+		}
+	}()
+	values := cfg.SecretValues()
+	<-done
+
+	if !strings.Contains(strings.Join(values, "\n"), "header-token") {
+		t.Errorf("SecretValues() = %v, want the header token", values)
+	}
+}
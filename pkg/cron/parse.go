@@ -0,0 +1,335 @@
+package cron
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adhocore/gronx"
+)
+
+// defaultHour is the time of day for day-based schedules given without one,
+// e.g. "first monday of the month".
+const defaultHour = 9
+
+var (
+	weekdays = map[string]int{
+		"sunday": 0, "sun": 0,
+		"monday": 1, "mon": 1,
+		"tuesday": 2, "tue": 2, "tues": 2,
+		"wednesday": 3, "wed": 3,
+		"thursday": 4, "thu": 4, "thur": 4, "thurs": 4,
+		"friday": 5, "fri": 5,
+		"saturday": 6, "sat": 6,
+	}
+	ordinals = map[string]int{"first": 1, "second": 2, "third": 3, "fourth": 4, "last": -1}
+	units    = map[string]time.Duration{
+		"second": time.Second, "sec": time.Second,
+		"minute": time.Minute, "min": time.Minute,
+		"hour": time.Hour, "hr": time.Hour,
+		"day":  24 * time.Hour,
+		"week": 7 * 24 * time.Hour,
+	}
+
+	atTimeRe   = regexp.MustCompile(`^(.*?)\s*\bat (noon|midnight|\d{1,2}(?::\d{2})?\s*(?:am|pm)?)$`)
+	bareTimeRe = regexp.MustCompile(`^(.*?)\s*\b(noon|midnight|\d{1,2}(?::\d{2})?\s*(?:am|pm)|\d{1,2}:\d{2})$`)
+	clockRe    = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?\s*(am|pm)?$`)
+
+	inRe         = regexp.MustCompile(`^in (\d+|a|an|one) ([a-z]+)$`)
+	everyRe      = regexp.MustCompile(`^every (?:(\d+) )?([a-z]+)$`)
+	weekdaysRe   = regexp.MustCompile(`^every ([a-z ]+)$`)
+	monthDayRe   = regexp.MustCompile(`^(?:every month on the|on the|every|the) (\d{1,2})(?:st|nd|rd|th)(?: day)?(?: of (?:the|every) month)?$`)
+	nthWeekdayRe = regexp.MustCompile(`^(?:every |on the |the )?(first|second|third|fourth|last) ([a-z]+) of (?:the|every) month$`)
+	lastDayRe    = regexp.MustCompile(`^(?:every |on the |the )?last day of (?:the|every) month$`)
+	onWeekdayRe  = regexp.MustCompile(`^(?:on |next )?([a-z]+)$`)
+	onDateRe     = regexp.MustCompile(`^(?:on )?(\d{4}-\d{2}-\d{2})$`)
+)
+
+// ParseSchedule turns a human schedule such as "every weekday at 9am",
+// "in 20 minutes" or "first monday of the month" into a CronSchedule. tz is
+// an IANA zone name; empty means the local zone. Day-based schedules given
+// without a time run at 09:00. A cron expression is accepted as is.
+func ParseSchedule(text, tz string, now time.Time) (CronSchedule, error) {
+	loc, err := loadLocation(tz)
+	if err != nil {
+		return CronSchedule{}, err
+	}
+	now = now.In(loc)
+
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return CronSchedule{}, fmt.Errorf("empty schedule")
+	}
+	if strings.Count(trimmed, " ") >= 4 && gronx.New().IsValid(trimmed) {
+		return CronSchedule{Kind: "cron", Expr: trimmed, TZ: tz}, nil
+	}
+
+	s := normalizeSchedule(trimmed)
+	hour, minute, hasTime, rest, err := splitTime(s)
+	if err != nil {
+		return CronSchedule{}, err
+	}
+	if !hasTime {
+		hour, minute = defaultHour, 0
+	}
+	cronAt := func(dom, dow string) CronSchedule {
+		return CronSchedule{Kind: "cron", Expr: fmt.Sprintf("%d %d %s * %s", minute, hour, dom, dow), TZ: tz}
+	}
+	at := func(t time.Time) CronSchedule {
+		ms := t.UnixMilli()
+		return CronSchedule{Kind: "at", AtMS: &ms, TZ: tz}
+	}
+
+	switch rest {
+	case "hourly":
+		rest = "every hour"
+	case "daily", "every day":
+		return cronAt("*", "*"), nil
+	case "weekly", "every week":
+		return cronAt("*", "1"), nil
+	case "monthly", "every month":
+		return cronAt("1", "*"), nil
+	case "every weekday", "weekdays":
+		return cronAt("*", "1-5"), nil
+	case "every weekend", "weekends":
+		return cronAt("*", "0,6"), nil
+	case "", "today":
+		if !hasTime {
+			break
+		}
+		t := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, loc)
+		if !t.After(now) {
+			if rest == "today" {
+				return CronSchedule{}, fmt.Errorf("%s has already passed today", t.Format("15:04"))
+			}
+			t = t.AddDate(0, 0, 1)
+		}
+		return at(t), nil
+	case "tomorrow":
+		return at(time.Date(now.Year(), now.Month(), now.Day()+1, hour, minute, 0, 0, loc)), nil
+	}
+
+	if m := inRe.FindStringSubmatch(rest); m != nil && !hasTime {
+		n := 1
+		if v, err := strconv.Atoi(m[1]); err == nil {
+			n = v
+		}
+		if d, ok := lookupUnit(m[2]); ok && n > 0 {
+			return at(now.Add(time.Duration(n) * d)), nil
+		}
+	}
+
+	if m := everyRe.FindStringSubmatch(rest); m != nil && !hasTime {
+		n := 1
+		if m[1] != "" {
+			n, _ = strconv.Atoi(m[1])
+		}
+		if d, ok := lookupUnit(m[2]); ok && n > 0 {
+			everyMS := (time.Duration(n) * d).Milliseconds()
+			return CronSchedule{Kind: "every", EveryMS: &everyMS, TZ: tz}, nil
+		}
+	}
+
+	if m := monthDayRe.FindStringSubmatch(rest); m != nil {
+		day, _ := strconv.Atoi(m[1])
+		if day < 1 || day > 31 {
+			return CronSchedule{}, fmt.Errorf("day of month %d out of range", day)
+		}
+		return cronAt(strconv.Itoa(day), "*"), nil
+	}
+
+	if lastDayRe.MatchString(rest) {
+		return cronAt("L", "*"), nil
+	}
+
+	if m := nthWeekdayRe.FindStringSubmatch(rest); m != nil {
+		if wd, ok := lookupWeekday(m[2]); ok {
+			if n := ordinals[m[1]]; n > 0 {
+				return cronAt("*", fmt.Sprintf("%d#%d", wd, n)), nil
+			}
+			return cronAt("*", fmt.Sprintf("%dL", wd)), nil
+		}
+	}
+
+	if m := weekdaysRe.FindStringSubmatch(rest); m != nil {
+		var days []string
+		for _, name := range strings.Fields(m[1]) {
+			wd, ok := lookupWeekday(name)
+			if !ok {
+				days = nil
+				break
+			}
+			days = append(days, strconv.Itoa(wd))
+		}
+		if len(days) > 0 {
+			return cronAt("*", strings.Join(days, ",")), nil
+		}
+	}
+
+	if m := onWeekdayRe.FindStringSubmatch(rest); m != nil {
+		if wd, ok := lookupWeekday(m[1]); ok {
+			t := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, loc)
+			for int(t.Weekday()) != wd || !t.After(now) {
+				t = t.AddDate(0, 0, 1)
+			}
+			return at(t), nil
+		}
+	}
+
+	if m := onDateRe.FindStringSubmatch(rest); m != nil {
+		d, err := time.ParseInLocation("2006-01-02", m[1], loc)
+		if err != nil {
+			return CronSchedule{}, fmt.Errorf("invalid date %q", m[1])
+		}
+		t := time.Date(d.Year(), d.Month(), d.Day(), hour, minute, 0, 0, loc)
+		if !t.After(now) {
+			return CronSchedule{}, fmt.Errorf("%s is in the past", t.Format("2006-01-02 15:04"))
+		}
+		return at(t), nil
+	}
+
+	return CronSchedule{}, fmt.Errorf(
+		"unrecognized schedule %q (try \"in 20 minutes\", \"every 2 hours\", \"every weekday at 9am\", "+
+			"\"first monday of the month\" or a cron expression)", text)
+}
+
+// ParseEndTime parses the end of a schedule: a date ("2026-12-31", meaning
+// the end of that day), a date and time ("2026-12-31 18:00") or RFC 3339.
+func ParseEndTime(text, tz string) (int64, error) {
+	loc, err := loadLocation(tz)
+	if err != nil {
+		return 0, err
+	}
+	text = strings.TrimSpace(text)
+	if t, err := time.Parse(time.RFC3339, text); err == nil {
+		return t.UnixMilli(), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", text, loc); err == nil {
+		return t.UnixMilli(), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", text, loc); err == nil {
+		return t.AddDate(0, 0, 1).UnixMilli() - 1, nil
+	}
+	return 0, fmt.Errorf("invalid end time %q: want YYYY-MM-DD, YYYY-MM-DD HH:MM or RFC 3339", text)
+}
+
+// ScheduleOptions are the settings shared by every schedule kind.
+type ScheduleOptions struct {
+	TZ         string
+	JitterSec  int
+	Until      string // See ParseEndTime
+	MaxRuns    int
+	QuietHours string // See ParseQuietHours
+}
+
+// Apply validates the options and sets them on s. A time zone already set
+// on s, e.g. by ParseSchedule, is kept when TZ is empty.
+func (o ScheduleOptions) Apply(s *CronSchedule) error {
+	if o.TZ != "" {
+		if _, err := loadLocation(o.TZ); err != nil {
+			return err
+		}
+		s.TZ = o.TZ
+	}
+	if o.JitterSec < 0 || o.MaxRuns < 0 {
+		return fmt.Errorf("jitter and max runs must not be negative")
+	}
+	s.JitterSec = o.JitterSec
+	s.MaxRuns = o.MaxRuns
+	if o.Until != "" {
+		end, err := ParseEndTime(o.Until, s.TZ)
+		if err != nil {
+			return err
+		}
+		s.EndAtMS = &end
+	}
+	if o.QuietHours != "" {
+		if _, _, err := ParseQuietHours(o.QuietHours); err != nil {
+			return fmt.Errorf("quiet hours: %w", err)
+		}
+		s.QuietHours = o.QuietHours
+	}
+	return nil
+}
+
+func loadLocation(tz string) (*time.Location, error) {
+	if tz == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", tz)
+	}
+	return loc, nil
+}
+
+func normalizeSchedule(s string) string {
+	s = strings.ToLower(s)
+	s = strings.TrimSuffix(s, ".")
+	s = strings.NewReplacer(",", " ", " and ", " ", "o'clock", "").Replace(s)
+	s = strings.Join(strings.Fields(s), " ")
+	if rest, ok := strings.CutPrefix(s, "each "); ok {
+		s = "every " + rest
+	}
+	return s
+}
+
+// splitTime cuts a trailing time of day ("at 9am", "17:30", "noon") off s.
+func splitTime(s string) (hour, minute int, ok bool, rest string, err error) {
+	m := atTimeRe.FindStringSubmatch(s)
+	if m == nil {
+		m = bareTimeRe.FindStringSubmatch(s)
+	}
+	if m == nil {
+		return 0, 0, false, s, nil
+	}
+	rest = strings.TrimSpace(m[1])
+
+	switch m[2] {
+	case "noon":
+		return 12, 0, true, rest, nil
+	case "midnight":
+		return 0, 0, true, rest, nil
+	}
+
+	c := clockRe.FindStringSubmatch(m[2])
+	if c == nil {
+		return 0, 0, false, s, fmt.Errorf("invalid time %q", m[2])
+	}
+	hour, _ = strconv.Atoi(c[1])
+	if c[2] != "" {
+		minute, _ = strconv.Atoi(c[2])
+	}
+	switch c[3] {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, false, s, fmt.Errorf("invalid time %q", m[2])
+		}
+		hour %= 12
+		if c[3] == "pm" {
+			hour += 12
+		}
+	}
+	if hour > 23 || minute > 59 {
+		return 0, 0, false, s, fmt.Errorf("invalid time %q", m[2])
+	}
+	return hour, minute, true, rest, nil
+}
+
+func lookupUnit(name string) (time.Duration, bool) {
+	if d, ok := units[name]; ok {
+		return d, true
+	}
+	d, ok := units[strings.TrimSuffix(name, "s")]
+	return d, ok
+}
+
+func lookupWeekday(name string) (int, bool) {
+	if wd, ok := weekdays[name]; ok {
+		return wd, true
+	}
+	wd, ok := weekdays[strings.TrimSuffix(name, "s")]
+	return wd, ok
+}
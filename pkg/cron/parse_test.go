@@ -0,0 +1,121 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+	// Sunday 18 October 2026, 10:30 in Berlin.
+	now := time.Date(2026, 10, 18, 10, 30, 0, 0, loc)
+
+	cronTests := map[string]string{
+		"every weekday at 9am":               "0 9 * * 1-5",
+		"Every day at 17:30":                 "30 17 * * *",
+		"daily at noon":                      "0 12 * * *",
+		"each monday and friday at 8:15 pm":  "15 20 * * 1,5",
+		"every tuesday, thursday 7am":        "0 7 * * 2,4",
+		"every weekend":                      "0 9 * * 0,6",
+		"first Monday of the month":          "0 9 * * 1#1",
+		"last friday of every month at 6pm":  "0 18 * * 5L",
+		"the last day of the month at 23:00": "0 23 L * *",
+		"every month on the 15th at 10am":    "0 10 15 * *",
+		"on the 1st of every month":          "0 9 1 * *",
+		"monthly":                            "0 9 1 * *",
+		"*/5 * * * *":                        "*/5 * * * *",
+	}
+	for text, want := range cronTests {
+		s, err := ParseSchedule(text, "Europe/Berlin", now)
+		if err != nil {
+			t.Errorf("%q: %v", text, err)
+			continue
+		}
+		if s.Kind != "cron" || s.Expr != want || s.TZ != "Europe/Berlin" {
+			t.Errorf("%q = %+v, want cron %q", text, s, want)
+		}
+	}
+
+	everyTests := map[string]time.Duration{
+		"every 2 hours":    2 * time.Hour,
+		"every minute":     time.Minute,
+		"every 30 seconds": 30 * time.Second,
+		"hourly":           time.Hour,
+		"every 3 days":     72 * time.Hour,
+	}
+	for text, want := range everyTests {
+		s, err := ParseSchedule(text, "Europe/Berlin", now)
+		if err != nil || s.Kind != "every" || *s.EveryMS != want.Milliseconds() {
+			t.Errorf("%q = %+v, %v; want every %s", text, s, err, want)
+		}
+	}
+
+	atTests := map[string]time.Time{
+		"in 20 minutes":          now.Add(20 * time.Minute),
+		"in an hour":             now.Add(time.Hour),
+		"at 9am":                 time.Date(2026, 10, 19, 9, 0, 0, 0, loc), // already past today
+		"at 11":                  time.Date(2026, 10, 18, 11, 0, 0, 0, loc),
+		"tomorrow":               time.Date(2026, 10, 19, 9, 0, 0, 0, loc),
+		"tomorrow at 12am":       time.Date(2026, 10, 19, 0, 0, 0, 0, loc),
+		"on friday at 3pm":       time.Date(2026, 10, 23, 15, 0, 0, 0, loc),
+		"sunday at 8pm":          time.Date(2026, 10, 18, 20, 0, 0, 0, loc),
+		"on 2026-12-24 at 18:00": time.Date(2026, 12, 24, 18, 0, 0, 0, loc),
+	}
+	for text, want := range atTests {
+		s, err := ParseSchedule(text, "Europe/Berlin", now)
+		if err != nil || s.Kind != "at" || *s.AtMS != want.UnixMilli() {
+			t.Errorf("%q = %+v, %v; want at %s", text, s, err, want)
+		}
+	}
+
+	for _, text := range []string{"", "whenever", "every 2 days at 9am", "today at 8am", "at 25:00", "at 13pm", "on the 40th", "on 2020-01-01"} {
+		if s, err := ParseSchedule(text, "Europe/Berlin", now); err == nil {
+			t.Errorf("%q = %+v, want error", text, s)
+		}
+	}
+	if _, err := ParseSchedule("daily", "Mars/Olympus", now); err == nil {
+		t.Error("unknown time zone accepted")
+	}
+}
+
+func TestParseEndTime(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+	tests := map[string]time.Time{
+		"2026-12-31":           time.Date(2027, 1, 1, 0, 0, 0, 0, loc).Add(-time.Millisecond),
+		"2026-12-31 18:00":     time.Date(2026, 12, 31, 18, 0, 0, 0, loc),
+		"2026-12-31T18:00:00Z": time.Date(2026, 12, 31, 18, 0, 0, 0, time.UTC),
+	}
+	for text, want := range tests {
+		got, err := ParseEndTime(text, "Asia/Tokyo")
+		if err != nil || got != want.UnixMilli() {
+			t.Errorf("%q = %d, %v; want %d", text, got, err, want.UnixMilli())
+		}
+	}
+	if _, err := ParseEndTime("next year", ""); err == nil {
+		t.Error("invalid end time accepted")
+	}
+}
+
+func TestScheduleOptions_Apply(t *testing.T) {
+	s := CronSchedule{Kind: "cron", Expr: "0 9 * * *", TZ: "UTC"}
+	opts := ScheduleOptions{JitterSec: 30, Until: "2026-12-31", MaxRuns: 5, QuietHours: "22:00-07:00"}
+	if err := opts.Apply(&s); err != nil {
+		t.Fatal(err)
+	}
+	end := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli() - 1
+	if s.TZ != "UTC" || s.JitterSec != 30 || s.MaxRuns != 5 || s.QuietHours != "22:00-07:00" || *s.EndAtMS != end {
+		t.Errorf("schedule = %+v", s)
+	}
+
+	for _, bad := range []ScheduleOptions{{TZ: "Nowhere/Land"}, {Until: "soon"}, {QuietHours: "late"}, {MaxRuns: -1}} {
+		if err := bad.Apply(&CronSchedule{}); err == nil {
+			t.Errorf("%+v accepted", bad)
+		}
+	}
+}
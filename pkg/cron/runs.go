@@ -0,0 +1,351 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+// Misfire policies decide what happens to runs that fell due while the
+// service was stopped.
+const (
+	MisfireSkip    = "skip"     // Drop missed runs and wait for the next one
+	MisfireRunOnce = "run_once" // Run once on start, however many were missed
+	MisfireRunAll  = "run_all"  // Run every missed occurrence, one after another
+)
+
+// Overlap policies decide what happens when a job falls due while a
+// previous run is still in progress.
+const (
+	OverlapSkip  = "skip"  // Drop the new run
+	OverlapQueue = "queue" // Start it when the current run finishes
+	OverlapAllow = "allow" // Run in parallel
+)
+
+// Run triggers recorded in the history.
+const (
+	TriggerSchedule = "schedule"
+	TriggerMisfire  = "misfire"
+	TriggerManual   = "manual"
+)
+
+// Run statuses recorded in the history. LastStatus uses the same values.
+const (
+	RunOK      = "ok"
+	RunError   = "error"
+	RunTimeout = "timeout"
+	RunSkipped = "skipped"
+)
+
+const (
+	maxRunHistory  = 20  // Runs kept per job
+	maxRunExcerpt  = 500 // Characters of output kept per run
+	maxCatchUpRuns = 100 // Missed runs replayed by run_all
+	maxPendingRuns = 10  // Runs queued behind an active one
+)
+
+// CronRun is one entry of a job's run history.
+type CronRun struct {
+	StartedAtMS int64  `json:"startedAtMs"`
+	DurationMS  int64  `json:"durationMs"`
+	Trigger     string `json:"trigger"`
+	Status      string `json:"status"`
+	Output      string `json:"output,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// ValidMisfirePolicy reports whether p is a known misfire policy. Empty
+// means the default.
+func ValidMisfirePolicy(p string) bool {
+	return p == "" || p == MisfireSkip || p == MisfireRunOnce || p == MisfireRunAll
+}
+
+// ValidOverlapPolicy reports whether p is a known overlap policy. Empty
+// means the default.
+func ValidOverlapPolicy(p string) bool {
+	return p == "" || p == OverlapSkip || p == OverlapQueue || p == OverlapAllow
+}
+
+// History returns the recorded runs of a job, most recent first. It
+// reports false if no job has the ID.
+func (cs *CronService) History(jobID string) ([]CronRun, bool) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	job := cs.findJobUnsafe(jobID)
+	if job == nil {
+		return nil, false
+	}
+	runs := make([]CronRun, len(job.History))
+	for i, run := range job.History {
+		runs[len(runs)-1-i] = run
+	}
+	return runs, true
+}
+
+func (cs *CronService) findJobUnsafe(jobID string) *CronJob {
+	for i := range cs.store.Jobs {
+		if cs.store.Jobs[i].ID == jobID {
+			return &cs.store.Jobs[i]
+		}
+	}
+	return nil
+}
+
+// dispatchUnsafe starts a run of job in the background, subject to its
+// overlap policy. Caller holds cs.mu.
+func (cs *CronService) dispatchUnsafe(job *CronJob, trigger string) {
+	if run, ok := cs.acquireUnsafe(job, trigger); ok {
+		go cs.execute(run, trigger)
+	}
+}
+
+// acquireUnsafe applies the overlap policy and, when the run may start now,
+// marks it active and returns a copy of the job to hand to the handler.
+// Caller holds cs.mu.
+func (cs *CronService) acquireUnsafe(job *CronJob, trigger string) (CronJob, bool) {
+	if cs.active[job.ID] > 0 {
+		switch job.OverlapPolicy {
+		case OverlapAllow:
+		case OverlapQueue:
+			if len(cs.pending[job.ID]) < maxPendingRuns {
+				cs.pending[job.ID] = append(cs.pending[job.ID], trigger)
+			} else {
+				cs.recordRunUnsafe(job, CronRun{
+					StartedAtMS: time.Now().UnixMilli(),
+					Trigger:     trigger,
+					Status:      RunSkipped,
+					Error:       "run queue is full",
+				})
+			}
+			return CronJob{}, false
+		default:
+			cs.recordRunUnsafe(job, CronRun{
+				StartedAtMS: time.Now().UnixMilli(),
+				Trigger:     trigger,
+				Status:      RunSkipped,
+				Error:       "previous run still in progress",
+			})
+			return CronJob{}, false
+		}
+	}
+
+	cs.active[job.ID]++
+	run := *job
+	run.History = nil
+	return run, true
+}
+
+// startPendingUnsafe starts the next queued run of job, if any and if no
+// other run is active. Caller holds cs.mu.
+func (cs *CronService) startPendingUnsafe(job *CronJob) {
+	queue := cs.pending[job.ID]
+	if len(queue) == 0 || cs.active[job.ID] > 0 {
+		return
+	}
+	if len(queue) == 1 {
+		delete(cs.pending, job.ID)
+	} else {
+		cs.pending[job.ID] = queue[1:]
+	}
+	cs.dispatchUnsafe(job, queue[0])
+}
+
+// execute runs the handler for a job acquired with acquireUnsafe and
+// records the outcome.
+func (cs *CronService) execute(job CronJob, trigger string) {
+	start := time.Now()
+	output, err := cs.invoke(&job)
+	duration := time.Since(start)
+
+	status := RunOK
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		status = RunTimeout
+		err = fmt.Errorf("timed out after %ds", job.TimeoutSec)
+	case err != nil:
+		status = RunError
+	}
+
+	cronDuration.Observe(duration.Seconds())
+	cronRuns.Inc(status)
+
+	run := CronRun{
+		StartedAtMS: start.UnixMilli(),
+		DurationMS:  duration.Milliseconds(),
+		Trigger:     trigger,
+		Status:      status,
+		Output:      utils.Truncate(output, maxRunExcerpt),
+	}
+	if err != nil {
+		run.Error = utils.Truncate(err.Error(), maxRunExcerpt)
+	}
+	cs.finish(job.ID, run)
+}
+
+// invoke calls the handler with the job's timeout. When the timeout expires
+// the handler's context is cancelled and the run is recorded as timed out
+// without waiting, so the job's slot is freed. A handler that ignores the
+// cancellation keeps running in the background until it returns; its late
+// result is discarded.
+func (cs *CronService) invoke(job *CronJob) (string, error) {
+	cs.mu.RLock()
+	handler := cs.onJob
+	cs.mu.RUnlock()
+	if handler == nil {
+		return "", nil
+	}
+
+	ctx := context.Background()
+	if job.TimeoutSec <= 0 {
+		return handler(ctx, job)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(job.TimeoutSec)*time.Second)
+	defer cancel()
+
+	type result struct {
+		output string
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		output, err := handler(ctx, job)
+		done <- result{output, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil && ctx.Err() != nil {
+			return r.output, ctx.Err()
+		}
+		return r.output, r.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// finish records a completed run and starts the next queued one.
+func (cs *CronService) finish(jobID string, run CronRun) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.active[jobID]--; cs.active[jobID] <= 0 {
+		delete(cs.active, jobID)
+	}
+
+	job := cs.findJobUnsafe(jobID)
+	if job == nil {
+		delete(cs.pending, jobID)
+		log.Printf("[cron] job %s disappeared before state update", jobID)
+		return
+	}
+
+	cs.recordRunUnsafe(job, run)
+	job.State.LastRunAtMS = &run.StartedAtMS
+	job.State.LastStatus = run.Status
+	job.State.LastError = run.Error
+	job.UpdatedAtMS = time.Now().UnixMilli()
+
+	// Running a one-shot job early by hand leaves its scheduled run in place.
+	if job.Schedule.Kind == "at" && run.Trigger != TriggerManual {
+		delete(cs.pending, jobID)
+		if job.DeleteAfterRun {
+			cs.removeJobUnsafe(jobID)
+			return
+		}
+		job.Enabled = false
+		job.State.NextRunAtMS = nil
+	}
+
+	if err := cs.saveStoreUnsafe(); err != nil {
+		log.Printf("[cron] failed to save store: %v", err)
+	}
+
+	if job.Enabled {
+		cs.startPendingUnsafe(job)
+	} else {
+		delete(cs.pending, jobID)
+	}
+}
+
+// recordRunUnsafe appends run to the job's history, dropping the oldest
+// entries beyond maxRunHistory. Caller holds cs.mu.
+func (cs *CronService) recordRunUnsafe(job *CronJob, run CronRun) {
+	history := job.History
+	if len(history) >= maxRunHistory {
+		history = history[len(history)-maxRunHistory+1:]
+	}
+	// Copy so slices handed out by ListJobs are never written to.
+	job.History = append(append(make([]CronRun, 0, len(history)+1), history...), run)
+}
+
+// applyMisfiresUnsafe handles enabled jobs whose next run passed while the
+// service was stopped, according to each job's misfire policy. Caller holds
+// cs.mu and recomputes next runs afterwards.
+func (cs *CronService) applyMisfiresUnsafe(nowMS int64) {
+	for i := range cs.store.Jobs {
+		job := &cs.store.Jobs[i]
+		if !job.Enabled || job.State.NextRunAtMS == nil || *job.State.NextRunAtMS > nowMS {
+			continue
+		}
+
+		missed := cs.countMissed(job, *job.State.NextRunAtMS, nowMS)
+		log.Printf("[cron] job %s missed %d run(s) while stopped (misfire policy: %s)",
+			job.ID, missed, policyOrDefault(job.MisfirePolicy, MisfireSkip))
+
+		switch job.MisfirePolicy {
+		case MisfireRunOnce:
+			cs.pending[job.ID] = append(cs.pending[job.ID], TriggerMisfire)
+			job.State.Runs++
+		case MisfireRunAll:
+			if limit := job.Schedule.MaxRuns; limit > 0 {
+				missed = max(0, min(missed, limit-job.State.Runs))
+			}
+			for range missed {
+				cs.pending[job.ID] = append(cs.pending[job.ID], TriggerMisfire)
+			}
+			job.State.Runs += missed
+		default:
+			cs.recordRunUnsafe(job, CronRun{
+				StartedAtMS: nowMS,
+				Trigger:     TriggerMisfire,
+				Status:      RunSkipped,
+				Error:       fmt.Sprintf("%d run(s) missed while stopped", missed),
+			})
+			if job.Schedule.Kind == "at" {
+				job.Enabled = false
+				job.State.NextRunAtMS = nil
+			}
+		}
+	}
+}
+
+// countMissed counts the occurrences of a job's schedule from firstMS up to
+// nowMS or the schedule's end, capped at maxCatchUpRuns.
+func (cs *CronService) countMissed(job *CronJob, firstMS, nowMS int64) int {
+	if end := job.Schedule.EndAtMS; end != nil && *end < nowMS {
+		nowMS = *end
+	}
+	count := 0
+	for t := firstMS; t <= nowMS && count < maxCatchUpRuns; {
+		count++
+		next := cs.computeNextRun(&job.Schedule, t)
+		if next == nil || *next <= t {
+			break
+		}
+		t = *next
+	}
+	return count
+}
+
+func policyOrDefault(policy, def string) string {
+	if policy == "" {
+		return def
+	}
+	return policy
+}
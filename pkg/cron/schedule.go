@@ -0,0 +1,114 @@
+package cron
+
+import (
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"time"
+)
+
+// nextRun is computeNextRun with the job's run limits, quiet hours and
+// jitter applied. It returns nil once the schedule is used up.
+//
+// Recurring runs are counted from the previous planned time, before jitter
+// and quiet hours, so the delays do not accumulate over runs. The planned
+// time is kept in job.State.PlannedAtMS.
+func (cs *CronService) nextRun(job *CronJob, nowMS int64) *int64 {
+	s := &job.Schedule
+	from := nowMS
+	if p := job.State.PlannedAtMS; p != nil && s.Kind != "at" && *p <= nowMS {
+		from = *p
+	}
+	job.State.PlannedAtMS = nil
+	if s.MaxRuns > 0 && job.State.Runs >= s.MaxRuns {
+		return nil
+	}
+
+	next := cs.computeNextRun(s, from)
+	if next != nil && *next <= nowMS {
+		// Runs missed in between are the misfire policy's business; keep
+		// the phase of "every" schedules and continue from now.
+		if s.Kind == "every" {
+			every := *s.EveryMS
+			n := *next + ((nowMS-*next)/every+1)*every
+			next = &n
+		} else {
+			next = cs.computeNextRun(s, nowMS)
+		}
+	}
+	if next == nil {
+		return nil
+	}
+	planned := *next
+	job.State.PlannedAtMS = &planned
+
+	t := *next
+	if s.Kind != "at" && s.JitterSec > 0 {
+		t += rand.Int64N(int64(s.JitterSec) * 1000)
+	}
+	if s.QuietHours != "" {
+		t = deferQuietHours(t, s)
+	}
+	if s.EndAtMS != nil && t > *s.EndAtMS {
+		return nil
+	}
+	return &t
+}
+
+// deferQuietHours moves a run that falls inside the schedule's quiet hours
+// to the end of the window.
+func deferQuietHours(atMS int64, s *CronSchedule) int64 {
+	start, end, err := ParseQuietHours(s.QuietHours)
+	if err != nil {
+		log.Printf("[cron] ignoring quiet hours: %v", err)
+		return atMS
+	}
+
+	t := time.UnixMilli(atMS).In(scheduleLocation(s))
+	m := t.Hour()*60 + t.Minute()
+	inside := m >= start && m < end
+	if start > end { // Window spans midnight
+		inside = m >= start || m < end
+	}
+	if !inside {
+		return atMS
+	}
+
+	resume := time.Date(t.Year(), t.Month(), t.Day(), end/60, end%60, 0, 0, t.Location())
+	if !resume.After(t) {
+		resume = resume.AddDate(0, 0, 1)
+	}
+	return resume.UnixMilli()
+}
+
+// ParseQuietHours parses a daily window such as "22:00-07:00" into minutes
+// after midnight. The window may span midnight. Heartbeat active hours use
+// the same format.
+func ParseQuietHours(window string) (start, end int, err error) {
+	var sh, sm, eh, em int
+	if _, err := fmt.Sscanf(window, "%d:%d-%d:%d", &sh, &sm, &eh, &em); err != nil {
+		return 0, 0, fmt.Errorf("want HH:MM-HH:MM, got %q", window)
+	}
+	if sh < 0 || sh > 23 || eh < 0 || eh > 23 || sm < 0 || sm > 59 || em < 0 || em > 59 {
+		return 0, 0, fmt.Errorf("time out of range in %q", window)
+	}
+	start, end = sh*60+sm, eh*60+em
+	if start == end {
+		return 0, 0, fmt.Errorf("empty window %q", window)
+	}
+	return start, end, nil
+}
+
+// scheduleLocation returns the zone of a schedule, falling back to the local
+// zone when it is unset or unknown.
+func scheduleLocation(s *CronSchedule) *time.Location {
+	if s.TZ == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(s.TZ)
+	if err != nil {
+		log.Printf("[cron] unknown time zone %q, using local time", s.TZ)
+		return time.Local
+	}
+	return loc
+}
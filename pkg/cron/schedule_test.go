@@ -0,0 +1,136 @@
+package cron
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNextRun_QuietHours(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+	cs := NewCronService(filepath.Join(t.TempDir(), "jobs.json"), nil)
+
+	tests := []struct {
+		quiet string
+		due   time.Time
+		want  time.Time
+	}{
+		{"22:00-07:00", time.Date(2026, 10, 18, 23, 30, 0, 0, loc), time.Date(2026, 10, 19, 7, 0, 0, 0, loc)},
+		{"22:00-07:00", time.Date(2026, 10, 19, 6, 59, 0, 0, loc), time.Date(2026, 10, 19, 7, 0, 0, 0, loc)},
+		{"22:00-07:00", time.Date(2026, 10, 19, 7, 0, 0, 0, loc), time.Date(2026, 10, 19, 7, 0, 0, 0, loc)},
+		{"12:00-13:30", time.Date(2026, 10, 19, 12, 15, 0, 0, loc), time.Date(2026, 10, 19, 13, 30, 0, 0, loc)},
+		{"12:00-13:30", time.Date(2026, 10, 19, 14, 0, 0, 0, loc), time.Date(2026, 10, 19, 14, 0, 0, 0, loc)},
+	}
+	for _, tt := range tests {
+		atMS := tt.due.UnixMilli()
+		job := &CronJob{Schedule: CronSchedule{Kind: "at", AtMS: &atMS, TZ: "America/New_York", QuietHours: tt.quiet}}
+		next := cs.nextRun(job, tt.due.Add(-time.Minute).UnixMilli())
+		if next == nil || *next != tt.want.UnixMilli() {
+			t.Errorf("%s due %s: next = %v, want %s", tt.quiet, tt.due.Format("15:04"), next, tt.want)
+		}
+	}
+
+	for _, window := range []string{"22:00", "25:00-07:00", "07:00-07:00"} {
+		if _, _, err := ParseQuietHours(window); err == nil {
+			t.Errorf("ParseQuietHours(%q) accepted", window)
+		}
+	}
+}
+
+func TestNextRun_Limits(t *testing.T) {
+	cs := NewCronService(filepath.Join(t.TempDir(), "jobs.json"), nil)
+	now := time.Now().UnixMilli()
+	every := int64(60000)
+
+	job := &CronJob{Schedule: CronSchedule{Kind: "every", EveryMS: &every, MaxRuns: 2}}
+	job.State.Runs = 1
+	if cs.nextRun(job, now) == nil {
+		t.Error("job with a run left has no next run")
+	}
+	job.State.Runs = 2
+	if cs.nextRun(job, now) != nil {
+		t.Error("job past max runs still scheduled")
+	}
+
+	end := now + 30000
+	job = &CronJob{Schedule: CronSchedule{Kind: "every", EveryMS: &every, EndAtMS: &end}}
+	if cs.nextRun(job, now) != nil {
+		t.Error("run after the end time still scheduled")
+	}
+
+	job = &CronJob{Schedule: CronSchedule{Kind: "every", EveryMS: &every, JitterSec: 10}}
+	for range 20 {
+		next := cs.nextRun(job, now)
+		if *next < now+every || *next >= now+every+10000 {
+			t.Fatalf("jittered run at +%dms, want within [60000, 70000)", *next-now)
+		}
+	}
+
+	// Each run is planned from the previous plan, not from the jittered
+	// time it fired at, so jitter does not drift the schedule.
+	job = &CronJob{Schedule: CronSchedule{Kind: "every", EveryMS: &every, JitterSec: 10}}
+	fired := now
+	for i := int64(1); i <= 20; i++ {
+		next := cs.nextRun(job, fired)
+		base := now + i*every
+		if *next < base || *next >= base+10000 {
+			t.Fatalf("run %d at +%dms, want within [%d, %d)", i, *next-now, base-now, base-now+10000)
+		}
+		fired = *next
+	}
+
+	// After a pause the phase is kept.
+	next := cs.nextRun(job, fired+5*every)
+	if base := now + 26*every; *next < base || *next >= base+10000 {
+		t.Errorf("run after pause at +%dms, want within [%d, %d)", *next-now, base-now, base-now+10000)
+	}
+}
+
+func TestComputeNextRun_TimeZone(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+	cs := NewCronService(filepath.Join(t.TempDir(), "jobs.json"), nil)
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC) // 21:00 in Tokyo
+
+	next := cs.computeNextRun(&CronSchedule{Kind: "cron", Expr: "0 9 * * *", TZ: "Asia/Tokyo"}, now.UnixMilli())
+	if want := time.Date(2026, 10, 19, 9, 0, 0, 0, loc); next == nil || *next != want.UnixMilli() {
+		t.Errorf("next = %v, want %s", next, want)
+	}
+}
+
+func TestCheckJobs_MaxRunsDisablesJob(t *testing.T) {
+	ran := make(chan struct{}, 4)
+	cs := NewCronService(filepath.Join(t.TempDir(), "jobs.json"), func(_ context.Context, _ *CronJob) (string, error) {
+		ran <- struct{}{}
+		return "ok", nil
+	})
+	job, _ := cs.AddJob("once", CronSchedule{Kind: "every", EveryMS: int64Ptr(3600000), MaxRuns: 1}, "hi", false, "cli", "direct")
+
+	cs.mu.Lock()
+	cs.running = true
+	past := time.Now().Add(-time.Second).UnixMilli()
+	cs.findJobUnsafe(job.ID).State.NextRunAtMS = &past
+	cs.mu.Unlock()
+	cs.checkJobs()
+
+	select {
+	case <-ran:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job did not run")
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if runs, _ := cs.History(job.ID); len(runs) == 1 || time.Now().After(deadline) {
+			break
+		}
+	}
+	got := cs.ListJobs(true)[0]
+	if got.Enabled || got.State.NextRunAtMS != nil || got.State.Runs != 1 {
+		t.Errorf("job after its last run = enabled %v, next %v, runs %d", got.Enabled, got.State.NextRunAtMS, got.State.Runs)
+	}
+}
@@ -1,6 +1,7 @@
 package cron
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
@@ -13,14 +14,26 @@ import (
 	"github.com/adhocore/gronx"
 
 	"github.com/sipeed/picoclaw/pkg/fileutil"
+	"github.com/sipeed/picoclaw/pkg/metrics"
+)
+
+var (
+	cronRuns = metrics.NewCounter("picoclaw_cron_runs_total",
+		"Cron job runs by status (ok, error or timeout).", "status")
+	cronDuration = metrics.NewHistogram("picoclaw_cron_run_duration_seconds",
+		"Cron job run time.", nil)
 )
 
 type CronSchedule struct {
-	Kind    string `json:"kind"`
-	AtMS    *int64 `json:"atMs,omitempty"`
-	EveryMS *int64 `json:"everyMs,omitempty"`
-	Expr    string `json:"expr,omitempty"`
-	TZ      string `json:"tz,omitempty"`
+	Kind       string `json:"kind"`
+	AtMS       *int64 `json:"atMs,omitempty"`
+	EveryMS    *int64 `json:"everyMs,omitempty"`
+	Expr       string `json:"expr,omitempty"`
+	TZ         string `json:"tz,omitempty"`
+	JitterSec  int    `json:"jitterSec,omitempty"`  // Random delay of up to N seconds per recurring run
+	EndAtMS    *int64 `json:"endAtMs,omitempty"`    // No runs after this time
+	MaxRuns    int    `json:"maxRuns,omitempty"`    // Stop after N scheduled runs
+	QuietHours string `json:"quietHours,omitempty"` // e.g. "22:00-07:00"; runs due inside wait for its end
 }
 
 type CronPayload struct {
@@ -37,6 +50,8 @@ type CronJobState struct {
 	LastRunAtMS *int64 `json:"lastRunAtMs,omitempty"`
 	LastStatus  string `json:"lastStatus,omitempty"`
 	LastError   string `json:"lastError,omitempty"`
+	Runs        int    `json:"runs,omitempty"`        // Scheduled runs so far, for MaxRuns
+	PlannedAtMS *int64 `json:"plannedAtMs,omitempty"` // Next run before jitter and quiet hours
 }
 
 type CronJob struct {
@@ -49,6 +64,31 @@ type CronJob struct {
 	CreatedAtMS    int64        `json:"createdAtMs"`
 	UpdatedAtMS    int64        `json:"updatedAtMs"`
 	DeleteAfterRun bool         `json:"deleteAfterRun"`
+	MisfirePolicy  string       `json:"misfirePolicy,omitempty"` // skip (default), run_once or run_all
+	OverlapPolicy  string       `json:"overlapPolicy,omitempty"` // skip (default), queue or allow
+	TimeoutSec     int          `json:"timeoutSec,omitempty"`    // 0 means no timeout
+	History        []CronRun    `json:"history,omitempty"`       // Most recent last
+	Creator        *CronCreator `json:"creator,omitempty"`       // nil when no user scheduled it
+}
+
+// CronCreator is the sender who scheduled a job. Agent-mode runs use their
+// role, looked up again on every run so role changes apply.
+type CronCreator struct {
+	Channel  string `json:"channel"`
+	SenderID string `json:"senderId"`
+}
+
+type creatorKey struct{}
+
+// WithCreator returns a context carrying the creator of the job being run.
+func WithCreator(ctx context.Context, creator *CronCreator) context.Context {
+	return context.WithValue(ctx, creatorKey{}, creator)
+}
+
+// CreatorFrom returns the creator attached to ctx, or nil.
+func CreatorFrom(ctx context.Context) *CronCreator {
+	creator, _ := ctx.Value(creatorKey{}).(*CronCreator)
+	return creator
 }
 
 type CronStore struct {
@@ -56,7 +96,11 @@ type CronStore struct {
 	Jobs    []CronJob `json:"jobs"`
 }
 
-type JobHandler func(job *CronJob) (string, error)
+// JobHandler runs a job. The context is cancelled when the job's timeout
+// expires; the service does not wait for the handler after that, so it
+// should return promptly. The returned string is kept as the run's output
+// excerpt.
+type JobHandler func(ctx context.Context, job *CronJob) (string, error)
 
 type CronService struct {
 	storePath string
@@ -66,6 +110,8 @@ type CronService struct {
 	running   bool
 	stopChan  chan struct{}
 	gronx     *gronx.Gronx
+	active    map[string]int      // Runs in progress per job ID
+	pending   map[string][]string // Queued run triggers per job ID
 }
 
 func NewCronService(storePath string, onJob JobHandler) *CronService {
@@ -73,6 +119,8 @@ func NewCronService(storePath string, onJob JobHandler) *CronService {
 		storePath: storePath,
 		onJob:     onJob,
 		gronx:     gronx.New(),
+		active:    make(map[string]int),
+		pending:   make(map[string][]string),
 	}
 	// Initialize and load store on creation
 	cs.loadStore()
@@ -91,6 +139,7 @@ func (cs *CronService) Start() error {
 		return fmt.Errorf("failed to load store: %w", err)
 	}
 
+	cs.applyMisfiresUnsafe(time.Now().UnixMilli())
 	cs.recomputeNextRuns()
 	if err := cs.saveStoreUnsafe(); err != nil {
 		return fmt.Errorf("failed to save store: %w", err)
@@ -100,6 +149,11 @@ func (cs *CronService) Start() error {
 	cs.running = true
 	go cs.runLoop(cs.stopChan)
 
+	// Catch-up runs queued by the misfire policies start right away.
+	for i := range cs.store.Jobs {
+		cs.startPendingUnsafe(&cs.store.Jobs[i])
+	}
+
 	return nil
 }
 
@@ -134,112 +188,41 @@ func (cs *CronService) runLoop(stopChan chan struct{}) {
 
 func (cs *CronService) checkJobs() {
 	cs.mu.Lock()
+	defer cs.mu.Unlock()
 
 	if !cs.running {
-		cs.mu.Unlock()
 		return
 	}
 
 	now := time.Now().UnixMilli()
-	var dueJobIDs []string
-
-	// Collect jobs that are due (we need to copy them to execute outside lock)
-	for i := range cs.store.Jobs {
-		job := &cs.store.Jobs[i]
-		if job.Enabled && job.State.NextRunAtMS != nil && *job.State.NextRunAtMS <= now {
-			dueJobIDs = append(dueJobIDs, job.ID)
-		}
-	}
-
-	// Reset next run for due jobs before unlocking to avoid duplicate execution.
-	dueMap := make(map[string]bool, len(dueJobIDs))
-	for _, jobID := range dueJobIDs {
-		dueMap[jobID] = true
-	}
-	for i := range cs.store.Jobs {
-		if dueMap[cs.store.Jobs[i].ID] {
-			cs.store.Jobs[i].State.NextRunAtMS = nil
-		}
-	}
-
-	if err := cs.saveStoreUnsafe(); err != nil {
-		log.Printf("[cron] failed to save store: %v", err)
-	}
-
-	cs.mu.Unlock()
-
-	// Execute jobs outside lock.
-	for _, jobID := range dueJobIDs {
-		cs.executeJobByID(jobID)
-	}
-}
-
-func (cs *CronService) executeJobByID(jobID string) {
-	startTime := time.Now().UnixMilli()
-
-	cs.mu.RLock()
-	var callbackJob *CronJob
+	due := false
 	for i := range cs.store.Jobs {
 		job := &cs.store.Jobs[i]
-		if job.ID == jobID {
-			jobCopy := *job
-			callbackJob = &jobCopy
-			break
+		if !job.Enabled || job.State.NextRunAtMS == nil || *job.State.NextRunAtMS > now {
+			continue
 		}
-	}
-	cs.mu.RUnlock()
+		due = true
+		job.State.Runs++
 
-	if callbackJob == nil {
-		return
-	}
-
-	var err error
-	if cs.onJob != nil {
-		_, err = cs.onJob(callbackJob)
-	}
-
-	// Now acquire lock to update state
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
-
-	var job *CronJob
-	for i := range cs.store.Jobs {
-		if cs.store.Jobs[i].ID == jobID {
-			job = &cs.store.Jobs[i]
-			break
-		}
-	}
-	if job == nil {
-		log.Printf("[cron] job %s disappeared before state update", jobID)
-		return
-	}
-
-	job.State.LastRunAtMS = &startTime
-	job.UpdatedAtMS = time.Now().UnixMilli()
-
-	if err != nil {
-		job.State.LastStatus = "error"
-		job.State.LastError = err.Error()
-	} else {
-		job.State.LastStatus = "ok"
-		job.State.LastError = ""
-	}
-
-	// Compute next run time
-	if job.Schedule.Kind == "at" {
-		if job.DeleteAfterRun {
-			cs.removeJobUnsafe(job.ID)
-		} else {
-			job.Enabled = false
+		// The next run is scheduled before this one starts, so a run that
+		// outlasts the interval meets the next one and the overlap policy
+		// decides what happens.
+		if job.Schedule.Kind == "at" {
 			job.State.NextRunAtMS = nil
+		} else {
+			job.State.NextRunAtMS = cs.nextRun(job, now)
+			if job.State.NextRunAtMS == nil {
+				log.Printf("[cron] job %s reached the end of its schedule", job.ID)
+				job.Enabled = false
+			}
 		}
-	} else {
-		nextRun := cs.computeNextRun(&job.Schedule, time.Now().UnixMilli())
-		job.State.NextRunAtMS = nextRun
+		cs.dispatchUnsafe(job, TriggerSchedule)
 	}
 
-	if err := cs.saveStoreUnsafe(); err != nil {
-		log.Printf("[cron] failed to save store: %v", err)
+	if due {
+		if err := cs.saveStoreUnsafe(); err != nil {
+			log.Printf("[cron] failed to save store: %v", err)
+		}
 	}
 }
 
@@ -264,8 +247,8 @@ func (cs *CronService) computeNextRun(schedule *CronSchedule, nowMS int64) *int6
 			return nil
 		}
 
-		// Use gronx to calculate next run time
-		now := time.UnixMilli(nowMS)
+		// Use gronx to calculate next run time in the schedule's zone
+		now := time.UnixMilli(nowMS).In(scheduleLocation(schedule))
 		nextTime, err := gronx.NextTickAfter(schedule.Expr, now, false)
 		if err != nil {
 			log.Printf("[cron] failed to compute next run for expr '%s': %v", schedule.Expr, err)
@@ -283,9 +266,14 @@ func (cs *CronService) recomputeNextRuns() {
 	now := time.Now().UnixMilli()
 	for i := range cs.store.Jobs {
 		job := &cs.store.Jobs[i]
-		if job.Enabled {
-			job.State.NextRunAtMS = cs.computeNextRun(&job.Schedule, now)
+		if !job.Enabled {
+			continue
 		}
+		// A one-time run deferred past its time by quiet hours keeps its slot.
+		if job.Schedule.Kind == "at" && job.State.NextRunAtMS != nil && *job.State.NextRunAtMS > now {
+			continue
+		}
+		job.State.NextRunAtMS = cs.nextRun(job, now)
 	}
 }
 
@@ -367,13 +355,11 @@ func (cs *CronService) AddJob(
 			Channel: channel,
 			To:      to,
 		},
-		State: CronJobState{
-			NextRunAtMS: cs.computeNextRun(&schedule, now),
-		},
 		CreatedAtMS:    now,
 		UpdatedAtMS:    now,
 		DeleteAfterRun: deleteAfterRun,
 	}
+	job.State.NextRunAtMS = cs.nextRun(&job, now)
 
 	cs.store.Jobs = append(cs.store.Jobs, job)
 	if err := cs.saveStoreUnsafe(); err != nil {
@@ -435,7 +421,7 @@ func (cs *CronService) EnableJob(jobID string, enabled bool) *CronJob {
 			job.UpdatedAtMS = time.Now().UnixMilli()
 
 			if enabled {
-				job.State.NextRunAtMS = cs.computeNextRun(&job.Schedule, time.Now().UnixMilli())
+				job.State.NextRunAtMS = cs.nextRun(job, time.Now().UnixMilli())
 			} else {
 				job.State.NextRunAtMS = nil
 			}
@@ -450,6 +436,25 @@ func (cs *CronService) EnableJob(jobID string, enabled bool) *CronJob {
 	return nil
 }
 
+// RunJob runs a job now, outside its schedule, and waits for it to finish.
+// The job's overlap policy applies if it is already running. It reports
+// false if no job has the ID.
+func (cs *CronService) RunJob(jobID string) bool {
+	cs.mu.Lock()
+	job := cs.findJobUnsafe(jobID)
+	if job == nil {
+		cs.mu.Unlock()
+		return false
+	}
+	run, ok := cs.acquireUnsafe(job, TriggerManual)
+	cs.mu.Unlock()
+
+	if ok {
+		cs.execute(run, TriggerManual)
+	}
+	return true
+}
+
 func (cs *CronService) ListJobs(includeDisabled bool) []CronJob {
 	cs.mu.RLock()
 	defer cs.mu.RUnlock()
@@ -1,10 +1,15 @@
 package cron
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestSaveStore_FilePermissions(t *testing.T) {
@@ -33,6 +38,242 @@ func TestSaveStore_FilePermissions(t *testing.T) {
 	}
 }
 
+func TestRunJob(t *testing.T) {
+	var ran []string
+	cs := NewCronService(filepath.Join(t.TempDir(), "jobs.json"), func(_ context.Context, job *CronJob) (string, error) {
+		ran = append(ran, job.ID)
+		return "ok", nil
+	})
+
+	job, err := cs.AddJob("test", CronSchedule{Kind: "every", EveryMS: int64Ptr(3600000)}, "hello", false, "cli", "direct")
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+
+	if !cs.RunJob(job.ID) {
+		t.Fatal("RunJob returned false for an existing job")
+	}
+	if len(ran) != 1 || ran[0] != job.ID {
+		t.Errorf("handler ran %v, want [%s]", ran, job.ID)
+	}
+	jobs := cs.ListJobs(true)
+	if jobs[0].State.LastStatus != "ok" || jobs[0].State.LastRunAtMS == nil {
+		t.Errorf("state = %+v, want a recorded ok run", jobs[0].State)
+	}
+
+	if cs.RunJob("missing") {
+		t.Error("RunJob returned true for a missing job")
+	}
+}
+
+func TestRunJob_AtJobKeepsSchedule(t *testing.T) {
+	cs := NewCronService(filepath.Join(t.TempDir(), "jobs.json"), func(_ context.Context, _ *CronJob) (string, error) {
+		return "ok", nil
+	})
+	atMS := time.Now().Add(time.Hour).UnixMilli()
+	job, err := cs.AddJob("reminder", CronSchedule{Kind: "at", AtMS: &atMS}, "hello", false, "cli", "direct")
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+
+	cs.RunJob(job.ID)
+
+	jobs := cs.ListJobs(true)
+	if len(jobs) != 1 {
+		t.Fatalf("jobs = %d, want the one-shot job kept after a manual run", len(jobs))
+	}
+	if !jobs[0].Enabled || jobs[0].State.NextRunAtMS == nil || *jobs[0].State.NextRunAtMS != atMS {
+		t.Errorf("job = enabled %v, next run %v; want still due at %d", jobs[0].Enabled, jobs[0].State.NextRunAtMS, atMS)
+	}
+	if jobs[0].State.LastStatus != RunOK {
+		t.Errorf("state = %+v, want the manual run recorded", jobs[0].State)
+	}
+}
+
+func TestRunJob_History(t *testing.T) {
+	calls := 0
+	cs := NewCronService(filepath.Join(t.TempDir(), "jobs.json"), func(_ context.Context, _ *CronJob) (string, error) {
+		calls++
+		if calls == 2 {
+			return "", errors.New("boom")
+		}
+		return strings.Repeat("x", 2*maxRunExcerpt), nil
+	})
+	job, _ := cs.AddJob("test", CronSchedule{Kind: "every", EveryMS: int64Ptr(3600000)}, "hello", false, "cli", "direct")
+
+	for range maxRunHistory + 2 {
+		cs.RunJob(job.ID)
+	}
+
+	runs, ok := cs.History(job.ID)
+	if !ok || len(runs) != maxRunHistory {
+		t.Fatalf("History = %d runs, %v; want %d", len(runs), ok, maxRunHistory)
+	}
+	if runs[0].Status != RunOK || runs[0].Trigger != TriggerManual || len([]rune(runs[0].Output)) != maxRunExcerpt {
+		t.Errorf("latest run = %+v", runs[0])
+	}
+	// The two oldest runs, including the failed one, were dropped.
+	if last := runs[len(runs)-1]; last.Status != RunOK {
+		t.Errorf("oldest run = %+v, want the first failure dropped", last)
+	}
+
+	reloaded := NewCronService(cs.storePath, nil)
+	if runs, _ := reloaded.History(job.ID); len(runs) != maxRunHistory {
+		t.Errorf("persisted history = %d runs, want %d", len(runs), maxRunHistory)
+	}
+	if _, ok := cs.History("missing"); ok {
+		t.Error("History reported a missing job")
+	}
+}
+
+func TestRunJob_Timeout(t *testing.T) {
+	cs := NewCronService(filepath.Join(t.TempDir(), "jobs.json"), func(ctx context.Context, _ *CronJob) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	job, _ := cs.AddJob("slow", CronSchedule{Kind: "every", EveryMS: int64Ptr(3600000)}, "hello", false, "cli", "direct")
+	job.TimeoutSec = 1
+	if err := cs.UpdateJob(job); err != nil {
+		t.Fatal(err)
+	}
+
+	cs.RunJob(job.ID)
+
+	runs, _ := cs.History(job.ID)
+	if len(runs) != 1 || runs[0].Status != RunTimeout {
+		t.Fatalf("runs = %+v, want one timeout", runs)
+	}
+	if state := cs.ListJobs(true)[0].State; state.LastStatus != RunTimeout || state.LastError == "" {
+		t.Errorf("state = %+v", state)
+	}
+}
+
+func TestOverlapPolicy(t *testing.T) {
+	tests := []struct {
+		policy    string
+		wantRuns  int
+		wantSkips int
+	}{
+		{OverlapSkip, 1, 1},
+		{OverlapQueue, 2, 0},
+		{OverlapAllow, 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			release := make(chan struct{})
+			started := make(chan struct{}, 2)
+			cs := NewCronService(filepath.Join(t.TempDir(), "jobs.json"), func(_ context.Context, _ *CronJob) (string, error) {
+				started <- struct{}{}
+				<-release
+				return "ok", nil
+			})
+			job, _ := cs.AddJob("slow", CronSchedule{Kind: "every", EveryMS: int64Ptr(3600000)}, "hello", false, "cli", "direct")
+			job.OverlapPolicy = tt.policy
+			cs.UpdateJob(job)
+
+			go cs.RunJob(job.ID)
+			<-started
+			second := make(chan struct{})
+			go func() {
+				cs.RunJob(job.ID)
+				close(second)
+			}()
+
+			if tt.policy == OverlapAllow {
+				<-started // both runs are in progress at once
+			} else {
+				<-second // skipped or queued without waiting
+			}
+			close(release)
+
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				runs, _ := cs.History(job.ID)
+				ok, skipped := 0, 0
+				for _, r := range runs {
+					switch r.Status {
+					case RunOK:
+						ok++
+					case RunSkipped:
+						skipped++
+					}
+				}
+				if ok == tt.wantRuns && skipped == tt.wantSkips {
+					return
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("runs = %+v, want %d ok and %d skipped", runs, tt.wantRuns, tt.wantSkips)
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		})
+	}
+}
+
+func TestStart_MisfirePolicy(t *testing.T) {
+	tests := []struct {
+		policy   string
+		wantRuns int
+	}{
+		{MisfireSkip, 0},
+		{MisfireRunOnce, 1},
+		{MisfireRunAll, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			var mu sync.Mutex
+			ran := 0
+			storePath := filepath.Join(t.TempDir(), "jobs.json")
+			cs := NewCronService(storePath, func(_ context.Context, _ *CronJob) (string, error) {
+				mu.Lock()
+				ran++
+				mu.Unlock()
+				return "ok", nil
+			})
+			job, _ := cs.AddJob("hourly", CronSchedule{Kind: "every", EveryMS: int64Ptr(3600000)}, "hello", false, "cli", "direct")
+
+			// The gateway was down for two and a half hours: three runs missed.
+			missed := time.Now().Add(-150 * time.Minute).UnixMilli()
+			job.State.NextRunAtMS = &missed
+			job.MisfirePolicy = tt.policy
+			cs.UpdateJob(job)
+
+			if err := cs.Start(); err != nil {
+				t.Fatal(err)
+			}
+			defer cs.Stop()
+
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				runs, _ := cs.History(job.ID)
+				if len(runs) == max(tt.wantRuns, 1) {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("runs = %+v", runs)
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if ran != tt.wantRuns {
+				t.Errorf("handler ran %d times, want %d", ran, tt.wantRuns)
+			}
+			runs, _ := cs.History(job.ID)
+			if runs[0].Trigger != TriggerMisfire {
+				t.Errorf("trigger = %q, want misfire", runs[0].Trigger)
+			}
+			if tt.policy == MisfireSkip && runs[0].Status != RunSkipped {
+				t.Errorf("skip policy recorded %+v", runs[0])
+			}
+			if next := cs.ListJobs(true)[0].State.NextRunAtMS; next == nil || *next <= time.Now().UnixMilli() {
+				t.Error("next run was not rescheduled into the future")
+			}
+		})
+	}
+}
+
 func int64Ptr(v int64) *int64 {
 	return &v
 }
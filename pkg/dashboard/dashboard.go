@@ -0,0 +1,276 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package dashboard serves the admin web UI of the gateway: channel status,
+// sessions, cron jobs, heartbeat, skills, usage and recent errors. The static
+// assets are embedded in the binary; data comes from a small JSON API under
+// /dashboard/api/.
+package dashboard
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"io/fs"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/agent"
+	"github.com/sipeed/picoclaw/pkg/cron"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/skills"
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+// Prefix is the path the dashboard is mounted at.
+const Prefix = "/dashboard/"
+
+const cookieName = "picoclaw_dashboard"
+
+// ChannelSource reports channel status, e.g. *channels.Manager.
+type ChannelSource interface {
+	GetStatus() map[string]any
+}
+
+// AgentSource lists sessions and skills, e.g. *agent.AgentLoop.
+type AgentSource interface {
+	ListSessions() []agent.SessionInfo
+	SessionHistory(agentID, sessionKey string) ([]providers.Message, bool)
+	ListSkills() []skills.SkillInfo
+}
+
+// CronSource lists and controls cron jobs, e.g. *cron.CronService.
+type CronSource interface {
+	ListJobs(includeDisabled bool) []cron.CronJob
+	EnableJob(jobID string, enabled bool) *cron.CronJob
+	RunJob(jobID string) bool
+}
+
+// HeartbeatSource reports heartbeat state, e.g. *heartbeat.HeartbeatService.
+type HeartbeatSource interface {
+	Status() map[string]any
+}
+
+// Options configures a Dashboard. Nil sources show as empty sections.
+type Options struct {
+	Token       string // Empty generates a random token
+	AllowRemote bool   // Serve clients other than localhost
+
+	Channels  ChannelSource
+	Agent     AgentSource
+	Cron      CronSource
+	Heartbeat HeartbeatSource
+}
+
+// Dashboard is the http.Handler of the admin UI.
+type Dashboard struct {
+	opts Options
+	mux  *http.ServeMux
+}
+
+// New creates a dashboard to be mounted at Prefix.
+func New(opts Options) *Dashboard {
+	if opts.Token == "" {
+		b := make([]byte, 16)
+		_, _ = rand.Read(b)
+		opts.Token = hex.EncodeToString(b)
+	}
+	d := &Dashboard{opts: opts, mux: http.NewServeMux()}
+
+	static, _ := fs.Sub(staticFiles, "static")
+	d.mux.Handle("GET "+Prefix+"{$}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFileFS(w, r, static, "index.html")
+	}))
+	d.mux.Handle("GET "+Prefix+"static/", http.StripPrefix(Prefix+"static/", http.FileServerFS(static)))
+
+	d.mux.HandleFunc("GET "+Prefix+"api/channels", d.handleChannels)
+	d.mux.HandleFunc("GET "+Prefix+"api/sessions", d.handleSessions)
+	d.mux.HandleFunc("GET "+Prefix+"api/history", d.handleHistory)
+	d.mux.HandleFunc("GET "+Prefix+"api/cron", d.handleCron)
+	d.mux.HandleFunc("POST "+Prefix+"api/cron/{id}/{action}", d.handleCronAction)
+	d.mux.HandleFunc("GET "+Prefix+"api/heartbeat", d.handleHeartbeat)
+	d.mux.HandleFunc("GET "+Prefix+"api/skills", d.handleSkills)
+	d.mux.HandleFunc("GET "+Prefix+"api/usage", d.handleUsage)
+	d.mux.HandleFunc("GET "+Prefix+"api/errors", d.handleErrors)
+	return d
+}
+
+// Token returns the token that unlocks the dashboard.
+func (d *Dashboard) Token() string {
+	return d.opts.Token
+}
+
+func (d *Dashboard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !d.opts.AllowRemote && !isLoopback(r.RemoteAddr) {
+		http.Error(w, "dashboard is only available from localhost", http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'self'")
+	w.Header().Set("Cache-Control", "no-store")
+
+	// A token in the URL is exchanged for a cookie, then dropped from the
+	// address bar so it does not end up in history or screenshots.
+	if token := r.URL.Query().Get("token"); token != "" && r.Method == http.MethodGet {
+		if !d.validToken(token) {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     cookieName,
+			Value:    d.opts.Token,
+			Path:     Prefix,
+			HttpOnly: true,
+			SameSite: http.SameSiteStrictMode,
+		})
+		http.Redirect(w, r, r.URL.Path, http.StatusSeeOther)
+		return
+	}
+
+	if !d.authorized(r) {
+		http.Error(w, "unauthorized: open "+Prefix+"?token=<dashboard token>", http.StatusUnauthorized)
+		return
+	}
+	d.mux.ServeHTTP(w, r)
+}
+
+func (d *Dashboard) authorized(r *http.Request) bool {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return d.validToken(token)
+	}
+	if c, err := r.Cookie(cookieName); err == nil {
+		return d.validToken(c.Value)
+	}
+	return false
+}
+
+func (d *Dashboard) validToken(token string) bool {
+	return subtle.ConstantTimeCompare([]byte(token), []byte(d.opts.Token)) == 1
+}
+
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+func (d *Dashboard) handleChannels(w http.ResponseWriter, _ *http.Request) {
+	status := map[string]any{}
+	if d.opts.Channels != nil {
+		status = d.opts.Channels.GetStatus()
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (d *Dashboard) handleSessions(w http.ResponseWriter, _ *http.Request) {
+	sessions := []agent.SessionInfo{}
+	if d.opts.Agent != nil {
+		sessions = append(sessions, d.opts.Agent.ListSessions()...)
+	}
+	writeJSON(w, http.StatusOK, sessions)
+}
+
+func (d *Dashboard) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if d.opts.Agent == nil {
+		writeError(w, http.StatusNotFound, "no agent")
+		return
+	}
+	history, ok := d.opts.Agent.SessionHistory(r.URL.Query().Get("agent"), r.URL.Query().Get("key"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown agent")
+		return
+	}
+	writeJSON(w, http.StatusOK, history)
+}
+
+func (d *Dashboard) handleCron(w http.ResponseWriter, _ *http.Request) {
+	jobs := []cron.CronJob{}
+	if d.opts.Cron != nil {
+		jobs = append(jobs, d.opts.Cron.ListJobs(true)...)
+	}
+	writeJSON(w, http.StatusOK, jobs)
+}
+
+func (d *Dashboard) handleCronAction(w http.ResponseWriter, r *http.Request) {
+	if d.opts.Cron == nil {
+		writeError(w, http.StatusNotFound, "cron is not running")
+		return
+	}
+	id, action := r.PathValue("id"), r.PathValue("action")
+
+	switch action {
+	case "enable", "disable":
+		job := d.opts.Cron.EnableJob(id, action == "enable")
+		if job == nil {
+			writeError(w, http.StatusNotFound, "job not found")
+			return
+		}
+		logger.InfoCF("dashboard", "Cron job "+action+"d", map[string]any{"job_id": id})
+		writeJSON(w, http.StatusOK, job)
+	case "run":
+		found := false
+		for _, job := range d.opts.Cron.ListJobs(true) {
+			if job.ID == id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			writeError(w, http.StatusNotFound, "job not found")
+			return
+		}
+		// Jobs can run for minutes; answer now and let the UI poll.
+		go d.opts.Cron.RunJob(id)
+		logger.InfoCF("dashboard", "Cron job run requested", map[string]any{"job_id": id})
+		writeJSON(w, http.StatusAccepted, map[string]string{"status": "started"})
+	default:
+		writeError(w, http.StatusNotFound, "unknown action")
+	}
+}
+
+func (d *Dashboard) handleHeartbeat(w http.ResponseWriter, _ *http.Request) {
+	status := map[string]any{}
+	if d.opts.Heartbeat != nil {
+		status = d.opts.Heartbeat.Status()
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (d *Dashboard) handleSkills(w http.ResponseWriter, _ *http.Request) {
+	list := []skills.SkillInfo{}
+	if d.opts.Agent != nil {
+		list = append(list, d.opts.Agent.ListSkills()...)
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (d *Dashboard) handleUsage(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, providers.Usage())
+}
+
+func (d *Dashboard) handleErrors(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, logger.RecentErrors())
+}
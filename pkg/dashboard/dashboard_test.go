@@ -0,0 +1,233 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/agent"
+	"github.com/sipeed/picoclaw/pkg/cron"
+	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/session"
+	"github.com/sipeed/picoclaw/pkg/skills"
+)
+
+type fakeAgent struct{}
+
+func (fakeAgent) ListSessions() []agent.SessionInfo {
+	return []agent.SessionInfo{{AgentID: "main", Info: session.Info{Key: "agent:main:telegram:1", Messages: 2}}}
+}
+
+func (fakeAgent) SessionHistory(agentID, key string) ([]providers.Message, bool) {
+	if agentID != "main" {
+		return nil, false
+	}
+	return []providers.Message{{Role: "user", Content: "hi"}, {Role: "assistant", Content: "hello"}}, true
+}
+
+func (fakeAgent) ListSkills() []skills.SkillInfo {
+	return []skills.SkillInfo{{Name: "weather", Source: "workspace"}}
+}
+
+type fakeCron struct {
+	mu      sync.Mutex
+	enabled bool
+	ran     chan string
+}
+
+func (c *fakeCron) ListJobs(bool) []cron.CronJob {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return []cron.CronJob{{ID: "j1", Name: "daily", Enabled: c.enabled}}
+}
+
+func (c *fakeCron) EnableJob(id string, enabled bool) *cron.CronJob {
+	if id != "j1" {
+		return nil
+	}
+	c.mu.Lock()
+	c.enabled = enabled
+	c.mu.Unlock()
+	return &cron.CronJob{ID: id, Enabled: enabled}
+}
+
+func (c *fakeCron) RunJob(id string) bool {
+	c.ran <- id
+	return true
+}
+
+type fakeChannels struct{}
+
+func (fakeChannels) GetStatus() map[string]any {
+	return map[string]any{"telegram": map[string]any{"enabled": true, "running": true}}
+}
+
+func newTestDashboard(allowRemote bool) (*Dashboard, *fakeCron) {
+	fc := &fakeCron{enabled: true, ran: make(chan string, 1)}
+	return New(Options{
+		Token:       "secret",
+		AllowRemote: allowRemote,
+		Channels:    fakeChannels{},
+		Agent:       fakeAgent{},
+		Cron:        fc,
+	}), fc
+}
+
+func serve(d *Dashboard, method, target, remote string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	req.RemoteAddr = remote
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	d.ServeHTTP(rec, req)
+	return rec
+}
+
+var bearer = http.Header{"Authorization": {"Bearer secret"}}
+
+func TestDashboard_LocalhostOnly(t *testing.T) {
+	d, _ := newTestDashboard(false)
+	if rec := serve(d, "GET", "/dashboard/api/channels", "192.168.1.5:4000", bearer); rec.Code != http.StatusForbidden {
+		t.Errorf("remote client: status = %d, want 403", rec.Code)
+	}
+	if rec := serve(d, "GET", "/dashboard/api/channels", "[::1]:4000", bearer); rec.Code != http.StatusOK {
+		t.Errorf("loopback client: status = %d, want 200", rec.Code)
+	}
+
+	d, _ = newTestDashboard(true)
+	if rec := serve(d, "GET", "/dashboard/api/channels", "192.168.1.5:4000", bearer); rec.Code != http.StatusOK {
+		t.Errorf("remote client with allow_remote: status = %d, want 200", rec.Code)
+	}
+}
+
+func TestDashboard_Token(t *testing.T) {
+	d, _ := newTestDashboard(false)
+	const local = "127.0.0.1:4000"
+
+	if rec := serve(d, "GET", "/dashboard/api/channels", local, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("no token: status = %d, want 401", rec.Code)
+	}
+	wrong := http.Header{"Authorization": {"Bearer nope"}}
+	if rec := serve(d, "GET", "/dashboard/api/channels", local, wrong); rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong token: status = %d, want 401", rec.Code)
+	}
+	if rec := serve(d, "GET", "/dashboard/?token=nope", local, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong query token: status = %d, want 401", rec.Code)
+	}
+
+	// The query token is swapped for a cookie and removed from the URL.
+	rec := serve(d, "GET", "/dashboard/?token=secret", local, nil)
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/dashboard/" {
+		t.Fatalf("query token: status = %d, location = %q", rec.Code, rec.Header().Get("Location"))
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || !cookies[0].HttpOnly || cookies[0].SameSite != http.SameSiteStrictMode {
+		t.Fatalf("cookies = %+v", cookies)
+	}
+	withCookie := http.Header{"Cookie": {cookies[0].Name + "=" + cookies[0].Value}}
+	rec = serve(d, "GET", "/dashboard/", local, withCookie)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "PicoClaw Dashboard") {
+		t.Errorf("index with cookie: status = %d", rec.Code)
+	}
+}
+
+func TestDashboard_GeneratesToken(t *testing.T) {
+	a, b := New(Options{}), New(Options{})
+	if len(a.Token()) != 32 || a.Token() == b.Token() {
+		t.Errorf("generated tokens %q and %q", a.Token(), b.Token())
+	}
+}
+
+func TestDashboard_API(t *testing.T) {
+	d, _ := newTestDashboard(false)
+	const local = "127.0.0.1:4000"
+
+	var sessions []agent.SessionInfo
+	decode(t, serve(d, "GET", "/dashboard/api/sessions", local, bearer), &sessions)
+	if len(sessions) != 1 || sessions[0].AgentID != "main" || sessions[0].Key != "agent:main:telegram:1" {
+		t.Errorf("sessions = %+v", sessions)
+	}
+
+	var history []providers.Message
+	decode(t, serve(d, "GET", "/dashboard/api/history?agent=main&key=agent:main:telegram:1", local, bearer), &history)
+	if len(history) != 2 || history[1].Content != "hello" {
+		t.Errorf("history = %+v", history)
+	}
+	if rec := serve(d, "GET", "/dashboard/api/history?agent=other&key=x", local, bearer); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown agent history: status = %d, want 404", rec.Code)
+	}
+
+	var status map[string]map[string]bool
+	decode(t, serve(d, "GET", "/dashboard/api/channels", local, bearer), &status)
+	if !status["telegram"]["running"] {
+		t.Errorf("channels = %v", status)
+	}
+
+	var list []skills.SkillInfo
+	decode(t, serve(d, "GET", "/dashboard/api/skills", local, bearer), &list)
+	if len(list) != 1 || list[0].Name != "weather" {
+		t.Errorf("skills = %+v", list)
+	}
+
+	// Sections without a source render as empty values, not errors.
+	var hb map[string]any
+	decode(t, serve(d, "GET", "/dashboard/api/heartbeat", local, bearer), &hb)
+	if len(hb) != 0 {
+		t.Errorf("heartbeat without source = %v, want empty", hb)
+	}
+	for _, path := range []string{"usage", "errors"} {
+		if rec := serve(d, "GET", "/dashboard/api/"+path, local, bearer); rec.Code != http.StatusOK {
+			t.Errorf("%s: status = %d", path, rec.Code)
+		}
+	}
+}
+
+func TestDashboard_CronActions(t *testing.T) {
+	d, fc := newTestDashboard(false)
+	const local = "127.0.0.1:4000"
+
+	if rec := serve(d, "POST", "/dashboard/api/cron/j1/disable", local, bearer); rec.Code != http.StatusOK {
+		t.Fatalf("disable: status = %d", rec.Code)
+	}
+	var jobs []cron.CronJob
+	decode(t, serve(d, "GET", "/dashboard/api/cron", local, bearer), &jobs)
+	if len(jobs) != 1 || jobs[0].Enabled {
+		t.Errorf("jobs after disable = %+v", jobs)
+	}
+
+	if rec := serve(d, "POST", "/dashboard/api/cron/j1/run", local, bearer); rec.Code != http.StatusAccepted {
+		t.Fatalf("run: status = %d", rec.Code)
+	}
+	select {
+	case id := <-fc.ran:
+		if id != "j1" {
+			t.Errorf("ran job %q, want j1", id)
+		}
+	case <-time.After(time.Second):
+		t.Error("job was not run")
+	}
+
+	for _, target := range []string{"/dashboard/api/cron/missing/run", "/dashboard/api/cron/missing/enable", "/dashboard/api/cron/j1/explode"} {
+		if rec := serve(d, "POST", target, local, bearer); rec.Code != http.StatusNotFound {
+			t.Errorf("%s: status = %d, want 404", target, rec.Code)
+		}
+	}
+	if rec := serve(d, "GET", "/dashboard/api/cron/j1/run", local, bearer); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET run: status = %d, want 405", rec.Code)
+	}
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("decode %s: %v", rec.Body.String(), err)
+	}
+}
@@ -0,0 +1,216 @@
+// PicoClaw dashboard. Polls the JSON API and renders each section; all text
+// goes through textContent so nothing from sessions or logs is parsed as HTML.
+"use strict";
+
+const api = (path, options) =>
+  fetch("api/" + path, options).then((resp) => {
+    if (resp.status === 401) {
+      throw new Error("Session expired: reopen the dashboard with ?token=...");
+    }
+    if (!resp.ok) {
+      throw new Error(path + ": HTTP " + resp.status);
+    }
+    return resp.json();
+  });
+
+function el(tag, text, className) {
+  const node = document.createElement(tag);
+  if (text !== undefined && text !== null) node.textContent = String(text);
+  if (className) node.className = className;
+  return node;
+}
+
+function fillTable(sectionId, rows, emptyText) {
+  const tbody = document.querySelector("#" + sectionId + " tbody");
+  const columns = document.querySelectorAll("#" + sectionId + " thead th").length;
+  tbody.replaceChildren();
+  if (rows.length === 0) {
+    const tr = el("tr");
+    const td = el("td", emptyText, "empty");
+    td.colSpan = columns;
+    tr.append(td);
+    tbody.append(tr);
+    return;
+  }
+  for (const cells of rows) {
+    const tr = el("tr");
+    for (const cell of cells) {
+      tr.append(cell instanceof Node ? wrapCell(cell) : el("td", cell));
+    }
+    tbody.append(tr);
+  }
+}
+
+function wrapCell(node) {
+  if (node.tagName === "TD") return node;
+  const td = el("td");
+  td.append(node);
+  return td;
+}
+
+function fmtTime(value) {
+  if (!value) return "—";
+  const d = new Date(value);
+  return isNaN(d) ? "—" : d.toLocaleString();
+}
+
+function fmtSchedule(s) {
+  if (s.kind === "cron") return s.expr + (s.tz ? " (" + s.tz + ")" : "");
+  if (s.kind === "every") return "every " + Math.round(s.everyMs / 1000) + "s";
+  if (s.kind === "at") return "at " + fmtTime(s.atMs);
+  return s.kind;
+}
+
+function button(label, onClick) {
+  const b = el("button", label);
+  b.type = "button";
+  b.addEventListener("click", onClick);
+  return b;
+}
+
+async function loadChannels() {
+  const status = await api("channels");
+  const rows = Object.keys(status).sort().map((name) => [
+    name,
+    status[name].enabled ? "yes" : "no",
+    el("td", status[name].running ? "running" : "stopped", status[name].running ? "ok" : "bad"),
+  ]);
+  fillTable("channels", rows, "No channels enabled");
+}
+
+async function loadSessions() {
+  const sessions = await api("sessions");
+  const rows = sessions.map((s) => [
+    s.agent_id,
+    s.key,
+    s.messages,
+    fmtTime(s.updated),
+    button("History", () => showHistory(s.agent_id, s.key)),
+  ]);
+  fillTable("sessions", rows, "No sessions yet");
+}
+
+async function showHistory(agentID, key) {
+  const query = "agent=" + encodeURIComponent(agentID) + "&key=" + encodeURIComponent(key);
+  const messages = await api("history?" + query);
+  const list = document.getElementById("history-messages");
+  list.replaceChildren();
+  for (const m of messages) {
+    const li = el("li");
+    li.append(el("span", m.role, "role"));
+    let text = m.content || "";
+    if (m.tool_calls && m.tool_calls.length) {
+      text += (text ? "\n" : "") + "→ " + m.tool_calls.map((tc) => (tc.function ? tc.function.name : tc.id)).join(", ");
+    }
+    li.append(document.createTextNode(text));
+    list.append(li);
+  }
+  document.getElementById("history-title").textContent = agentID + " / " + key;
+  document.getElementById("history").hidden = false;
+}
+
+async function loadCron() {
+  const jobs = await api("cron");
+  const rows = jobs.map((job) => {
+    const actions = el("td");
+    actions.append(
+      button(job.enabled ? "Disable" : "Enable", () => cronAction(job.id, job.enabled ? "disable" : "enable")),
+      button("Run now", () => cronAction(job.id, "run")),
+    );
+    const state = job.state || {};
+    const status = state.lastStatus
+      ? el("td", state.lastStatus + (state.lastError ? ": " + state.lastError : ""), state.lastStatus === "ok" ? "ok" : "bad")
+      : "—";
+    return [
+      job.name + (job.enabled ? "" : " (disabled)"),
+      fmtSchedule(job.schedule),
+      fmtTime(state.nextRunAtMs),
+      fmtTime(state.lastRunAtMs),
+      status,
+      actions,
+    ];
+  });
+  fillTable("cron", rows, "No cron jobs");
+}
+
+async function cronAction(id, action) {
+  try {
+    await api("cron/" + encodeURIComponent(id) + "/" + action, { method: "POST" });
+  } catch (err) {
+    showError(err);
+  }
+  refresh();
+}
+
+async function loadHeartbeat() {
+  const status = await api("heartbeat");
+  const dl = document.querySelector("#heartbeat dl");
+  dl.replaceChildren();
+  const entries = Object.keys(status).sort();
+  if (entries.length === 0) {
+    dl.append(el("dt", "Heartbeat"), el("dd", "not available"));
+    return;
+  }
+  for (const key of entries) {
+    if (key === "sections") continue;
+    const value = key === "last_run" ? fmtTime(status[key]) : status[key];
+    dl.append(el("dt", key.replace(/_/g, " ")), el("dd", value));
+  }
+  const rows = (status.sections || []).map((s) => [
+    s.name,
+    s.schedule + (s.active ? " (" + s.active + ")" : ""),
+    s.target || "last chat",
+    fmtTime(s.last_run),
+    s.last_result ? el("td", s.last_result + (s.last_error ? ": " + s.last_error : ""), s.last_result === "error" ? "bad" : "ok") : "—",
+  ]);
+  fillTable("heartbeat", rows, "No sections");
+}
+
+async function loadSkills() {
+  const skills = await api("skills");
+  fillTable("skills", skills.map((s) => [s.name, s.source, s.description]), "No skills installed");
+}
+
+async function loadUsage() {
+  const usage = await api("usage");
+  const rows = usage.map((u) => [
+    u.provider || "—",
+    u.model,
+    u.requests,
+    u.errors,
+    u.prompt_tokens,
+    u.completion_tokens,
+  ]);
+  fillTable("usage", rows, "No LLM requests yet");
+}
+
+async function loadErrors() {
+  const entries = await api("errors");
+  const rows = entries.map((e) => [
+    fmtTime(e.timestamp),
+    el("td", e.level, e.level === "WARN" ? "" : "bad"),
+    e.component || "",
+    e.message + (e.fields && e.fields.error ? ": " + e.fields.error : ""),
+  ]);
+  fillTable("errors", rows, "Nothing logged");
+}
+
+function showError(err) {
+  document.getElementById("updated").textContent = err.message;
+}
+
+async function refresh() {
+  try {
+    await Promise.all([loadChannels(), loadSessions(), loadCron(), loadHeartbeat(), loadSkills(), loadUsage(), loadErrors()]);
+    document.getElementById("updated").textContent = "Updated " + new Date().toLocaleTimeString();
+  } catch (err) {
+    showError(err);
+  }
+}
+
+document.getElementById("history-close").addEventListener("click", () => {
+  document.getElementById("history").hidden = true;
+});
+
+refresh();
+setInterval(refresh, 5000);
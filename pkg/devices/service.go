@@ -14,13 +14,14 @@ import (
 )
 
 type Service struct {
-	bus     *bus.MessageBus
-	state   *state.Manager
-	sources []events.EventSource
-	enabled bool
-	ctx     context.Context
-	cancel  context.CancelFunc
-	mu      sync.RWMutex
+	bus       *bus.MessageBus
+	state     *state.Manager
+	sources   []events.EventSource
+	listeners []func(*events.DeviceEvent)
+	enabled   bool
+	ctx       context.Context
+	cancel    context.CancelFunc
+	mu        sync.RWMutex
 }
 
 type Config struct {
@@ -49,6 +50,14 @@ func (s *Service) SetBus(msgBus *bus.MessageBus) {
 	s.bus = msgBus
 }
 
+// OnEvent registers fn to be called with every device event, in addition
+// to the notification sent to the last active channel.
+func (s *Service) OnEvent(fn func(*events.DeviceEvent)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
 func (s *Service) Start(ctx context.Context) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -101,6 +110,13 @@ func (s *Service) handleEvents(kind events.Kind, eventCh <-chan *events.DeviceEv
 			continue
 		}
 		s.sendNotification(ev)
+
+		s.mu.RLock()
+		listeners := s.listeners
+		s.mu.RUnlock()
+		for _, fn := range listeners {
+			fn(ev)
+		}
 	}
 }
 
@@ -131,6 +147,7 @@ func (s *Service) sendNotification(ev *events.DeviceEvent) {
 		Channel: platform,
 		ChatID:  userID,
 		Content: msg,
+		Sender:  "devices",
 	})
 
 	logger.InfoCF("devices", "Device notification sent", map[string]any{
@@ -0,0 +1,275 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package heartbeat
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/adhocore/gronx"
+
+	"github.com/sipeed/picoclaw/pkg/constants"
+	"github.com/sipeed/picoclaw/pkg/cron"
+)
+
+// DefaultSection is the name of the section made of everything in
+// HEARTBEAT.md that is not inside a scheduled section.
+const DefaultSection = "default"
+
+var headingRe = regexp.MustCompile(`^##\s+(.+?)\s*#*\s*$`)
+
+// Section is one independently scheduled part of HEARTBEAT.md: a "## Name"
+// heading directly followed by a settings comment, for example
+//
+//	## Morning briefing
+//	<!-- cron: 0 8 * * 1-5; target: telegram:123456; active: 07:00-22:00 -->
+//
+// A section without every or cron runs at the global interval; one without
+// target reports to the last active chat.
+type Section struct {
+	Name    string
+	Body    string
+	Every   time.Duration
+	Cron    string
+	Channel string
+	ChatID  string
+	Agent   string
+	Active  string // Window such as "08:00-22:00"; empty means always
+	TZ      string
+
+	activeStart, activeEnd int // Minutes after midnight
+	loc                    *time.Location
+}
+
+// Schedule describes when the section runs, e.g. "every 30m" or
+// "cron 0 8 * * *".
+func (s *Section) Schedule() string {
+	if s.Cron != "" {
+		return "cron " + s.Cron
+	}
+	every := s.Every.String()
+	if strings.HasSuffix(every, "m0s") {
+		every = strings.TrimSuffix(every, "0s")
+	}
+	if strings.HasSuffix(every, "h0m") {
+		every = strings.TrimSuffix(every, "0m")
+	}
+	return "every " + every
+}
+
+// Target returns "channel:chat_id", or "" for the last active chat.
+func (s *Section) Target() string {
+	if s.Channel == "" {
+		return ""
+	}
+	return s.Channel + ":" + s.ChatID
+}
+
+// parseSections splits a HEARTBEAT.md into sections. Invalid scheduled
+// sections are reported and left out; their text does not fall back into
+// the default section.
+func parseSections(content string, interval time.Duration) ([]*Section, []error) {
+	var (
+		sections []*Section
+		errs     []error
+		rest     []string // Lines of the default section
+		current  *Section
+		body     []string
+		seen     = map[string]bool{DefaultSection: true}
+	)
+
+	flush := func() {
+		if current != nil {
+			current.Body = strings.TrimSpace(strings.Join(body, "\n"))
+			sections = append(sections, current)
+		}
+		current, body = nil, nil
+	}
+
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		m := headingRe.FindStringSubmatch(lines[i])
+		if m == nil {
+			if current != nil || body != nil {
+				body = append(body, lines[i])
+			} else {
+				rest = append(rest, lines[i])
+			}
+			continue
+		}
+
+		settings, end, ok := settingsComment(lines, i+1)
+		if !ok {
+			// A plain heading ends any scheduled section and belongs to
+			// the default one.
+			flush()
+			rest = append(rest, lines[i])
+			continue
+		}
+
+		flush()
+		name := m[1]
+		s, err := newSection(name, settings, interval)
+		if err == nil && seen[strings.ToLower(name)] {
+			err = fmt.Errorf("duplicate section name")
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("section %q: %w", name, err))
+			body = []string{} // Swallow the section's text
+		} else {
+			seen[strings.ToLower(name)] = true
+			current, body = s, []string{lines[i]}
+		}
+		i = end
+	}
+	flush()
+
+	if text := strings.TrimSpace(strings.Join(rest, "\n")); text != "" {
+		def := &Section{Name: DefaultSection, Body: text, Every: interval}
+		sections = append([]*Section{def}, sections...)
+	}
+	return sections, errs
+}
+
+// settingsComment reads the HTML comment starting at the first non-blank
+// line from start. It returns the key/value pairs and the index of the
+// comment's last line, or false if there is no comment with known keys.
+func settingsComment(lines []string, start int) (map[string]string, int, bool) {
+	i := start
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	if i == len(lines) || !strings.HasPrefix(strings.TrimSpace(lines[i]), "<!--") {
+		return nil, 0, false
+	}
+
+	var text strings.Builder
+	end := i
+	for ; end < len(lines); end++ {
+		text.WriteString(lines[end])
+		text.WriteString("\n")
+		if strings.Contains(lines[end], "-->") {
+			break
+		}
+	}
+	if end == len(lines) {
+		return nil, 0, false
+	}
+
+	inner := strings.TrimSpace(text.String())
+	inner = strings.TrimPrefix(inner, "<!--")
+	inner = inner[:strings.Index(inner, "-->")]
+
+	settings := make(map[string]string)
+	known := false
+	for _, field := range strings.FieldsFunc(inner, func(r rune) bool { return r == '\n' || r == ';' }) {
+		key, value, ok := strings.Cut(field, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		settings[key] = strings.TrimSpace(value)
+		switch key {
+		case "every", "cron", "target", "agent", "active", "tz":
+			known = true
+		}
+	}
+	if !known {
+		return nil, 0, false
+	}
+	return settings, end, true
+}
+
+func newSection(name string, settings map[string]string, interval time.Duration) (*Section, error) {
+	s := &Section{Name: name, Every: interval, loc: time.Local}
+
+	for key, value := range settings {
+		switch key {
+		case "every":
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("every: %w", err)
+			}
+			if d < minIntervalMinutes*time.Minute {
+				return nil, fmt.Errorf("every: minimum is %dm", minIntervalMinutes)
+			}
+			s.Every = d
+		case "cron":
+			if !gronx.IsValid(value) {
+				return nil, fmt.Errorf("cron: invalid expression %q", value)
+			}
+			s.Cron = value
+		case "target":
+			channel, chatID, ok := strings.Cut(value, ":")
+			if !ok || channel == "" || chatID == "" {
+				return nil, fmt.Errorf("target: want channel:chat_id, got %q", value)
+			}
+			if constants.IsInternalChannel(channel) {
+				return nil, fmt.Errorf("target: %s is an internal channel", channel)
+			}
+			s.Channel, s.ChatID = channel, chatID
+		case "agent":
+			s.Agent = value
+		case "active":
+			start, end, err := cron.ParseQuietHours(value)
+			if err != nil {
+				return nil, fmt.Errorf("active: %w", err)
+			}
+			s.Active, s.activeStart, s.activeEnd = value, start, end
+		case "tz":
+			loc, err := time.LoadLocation(value)
+			if err != nil {
+				return nil, fmt.Errorf("tz: %w", err)
+			}
+			s.TZ, s.loc = value, loc
+		default:
+			return nil, fmt.Errorf("unknown setting %q", key)
+		}
+	}
+	if _, ok := settings["every"]; ok && s.Cron != "" {
+		return nil, fmt.Errorf("every and cron are mutually exclusive")
+	}
+	return s, nil
+}
+
+// activeAt reports whether t falls in the section's active hours.
+func (s *Section) activeAt(t time.Time) bool {
+	if s.Active == "" {
+		return true
+	}
+	local := t.In(s.location())
+	minute := local.Hour()*60 + local.Minute()
+	if s.activeStart < s.activeEnd {
+		return minute >= s.activeStart && minute < s.activeEnd
+	}
+	return minute >= s.activeStart || minute < s.activeEnd
+}
+
+// dueAt reports whether the section should run at now, given its last run
+// and when the service started.
+func (s *Section) dueAt(now, lastRun, started time.Time) bool {
+	if !s.activeAt(now) {
+		return false
+	}
+	if s.Cron != "" {
+		base := lastRun
+		if base.IsZero() {
+			base = started
+		}
+		next, err := gronx.NextTickAfter(s.Cron, base.In(s.location()), false)
+		return err == nil && !next.After(now)
+	}
+	return lastRun.IsZero() || now.Sub(lastRun) >= s.Every
+}
+
+func (s *Section) location() *time.Location {
+	if s.loc == nil {
+		return time.Local
+	}
+	return s.loc
+}
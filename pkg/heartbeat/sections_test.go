@@ -0,0 +1,149 @@
+package heartbeat
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const multiSection = `# Periodic Tasks
+
+- Check my email
+
+## Morning briefing
+<!-- cron: 0 8 * * *; target: telegram:42; agent: ops -->
+- Summarize today's calendar
+
+### Details
+- Include travel time
+
+## Notes
+Plain section, part of the default one.
+
+## Plants
+<!--
+every: 2h
+active: 08:00-20:00
+tz: Europe/Berlin
+-->
+- Check the soil moisture sensor
+
+## Broken
+<!-- every: 1m -->
+- Never runs
+`
+
+func TestParseSections(t *testing.T) {
+	sections, errs := parseSections(multiSection, 30*time.Minute)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), `"Broken"`) {
+		t.Errorf("errs = %v, want one for Broken", errs)
+	}
+	if len(sections) != 3 {
+		t.Fatalf("got %d sections, want 3", len(sections))
+	}
+
+	def := sections[0]
+	if def.Name != DefaultSection || def.Every != 30*time.Minute {
+		t.Errorf("default = %+v", def)
+	}
+	if !strings.Contains(def.Body, "Check my email") || !strings.Contains(def.Body, "## Notes") ||
+		strings.Contains(def.Body, "calendar") || strings.Contains(def.Body, "Never runs") {
+		t.Errorf("default body = %q", def.Body)
+	}
+
+	morning := sections[1]
+	if morning.Name != "Morning briefing" || morning.Cron != "0 8 * * *" || morning.Target() != "telegram:42" ||
+		morning.Agent != "ops" {
+		t.Errorf("morning = %+v", morning)
+	}
+	if !strings.HasPrefix(morning.Body, "## Morning briefing\n- Summarize") ||
+		!strings.Contains(morning.Body, "travel time") || strings.Contains(morning.Body, "<!--") {
+		t.Errorf("morning body = %q", morning.Body)
+	}
+
+	plants := sections[2]
+	if plants.Every != 2*time.Hour || plants.Active != "08:00-20:00" || plants.TZ != "Europe/Berlin" ||
+		plants.Target() != "" {
+		t.Errorf("plants = %+v", plants)
+	}
+}
+
+func TestParseSections_PlainFile(t *testing.T) {
+	sections, errs := parseSections("## Tasks\n<!-- just a note -->\n- Check email\n", time.Hour)
+	if len(errs) != 0 || len(sections) != 1 || sections[0].Name != DefaultSection ||
+		!strings.Contains(sections[0].Body, "just a note") {
+		t.Errorf("sections = %+v, errs = %v", sections, errs)
+	}
+
+	if sections, _ := parseSections("  \n", time.Hour); len(sections) != 0 {
+		t.Errorf("empty file gave %d sections", len(sections))
+	}
+}
+
+func TestParseSections_Invalid(t *testing.T) {
+	for _, settings := range []string{
+		"every: 1h; cron: 0 * * * *",
+		"cron: every day",
+		"target: telegram",
+		"target: cli:direct",
+		"active: 8-20",
+		"tz: Mars/Olympus",
+		"agent: ops; colour: red",
+	} {
+		content := "## Task\n<!-- " + settings + " -->\n- x\n"
+		if _, errs := parseSections(content, time.Hour); len(errs) != 1 {
+			t.Errorf("%q: errs = %v, want one", settings, errs)
+		}
+	}
+
+	content := "## Task\n<!-- every: 1h -->\n- x\n## task\n<!-- every: 2h -->\n- y\n"
+	if sections, errs := parseSections(content, time.Hour); len(sections) != 1 || len(errs) != 1 {
+		t.Errorf("duplicate: sections = %d, errs = %v", len(sections), errs)
+	}
+}
+
+func TestSection_DueAt(t *testing.T) {
+	start := time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC)
+
+	every := &Section{Name: "e", Every: time.Hour}
+	if !every.dueAt(start, time.Time{}, start) {
+		t.Error("interval section never run should be due")
+	}
+	if every.dueAt(start.Add(59*time.Minute), start, start) || !every.dueAt(start.Add(time.Hour), start, start) {
+		t.Error("interval section due at the wrong time")
+	}
+
+	cron := &Section{Name: "c", Cron: "0 8 * * *", loc: time.UTC}
+	if cron.dueAt(start.Add(59*time.Minute), time.Time{}, start) || !cron.dueAt(start.Add(time.Hour), time.Time{}, start) {
+		t.Error("cron section should first run at 08:00")
+	}
+	ran := start.Add(time.Hour)
+	if cron.dueAt(ran.Add(time.Hour), ran, start) || !cron.dueAt(ran.Add(24*time.Hour), ran, start) {
+		t.Error("cron section should run again the next day")
+	}
+
+	night := &Section{Name: "n", Every: time.Hour, loc: time.UTC}
+	night.Active, night.activeStart, night.activeEnd = "22:00-06:00", 22*60, 6*60
+	if night.dueAt(start, time.Time{}, start) {
+		t.Error("section due outside its active hours")
+	}
+	if !night.dueAt(start.Add(-2*time.Hour), time.Time{}, start) {
+		t.Error("section not due inside its active hours across midnight")
+	}
+}
+
+func TestSection_Schedule(t *testing.T) {
+	for _, tc := range []struct {
+		s    Section
+		want string
+	}{
+		{Section{Every: 30 * time.Minute}, "every 30m"},
+		{Section{Every: 2 * time.Hour}, "every 2h"},
+		{Section{Every: 90 * time.Minute}, "every 1h30m"},
+		{Section{Cron: "0 8 * * *"}, "cron 0 8 * * *"},
+	} {
+		if got := tc.s.Schedule(); got != tc.want {
+			t.Errorf("Schedule() = %q, want %q", got, tc.want)
+		}
+	}
+}
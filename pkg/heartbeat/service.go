@@ -7,6 +7,7 @@
 package heartbeat
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -25,12 +26,24 @@ import (
 const (
 	minIntervalMinutes     = 5
 	defaultIntervalMinutes = 30
+
+	// checkInterval is how often the service looks for due sections.
+	checkInterval = time.Minute
 )
 
 // HeartbeatHandler is the function type for handling heartbeat.
 // It returns a ToolResult that can indicate async operations.
-// channel and chatID are derived from the last active user channel.
-type HeartbeatHandler func(prompt, channel, chatID string) *tools.ToolResult
+// agentID is the section's agent, empty for the default agent. channel and
+// chatID are the section's target or the last active user channel.
+type HeartbeatHandler func(agentID, prompt, channel, chatID string) *tools.ToolResult
+
+// SectionState is the outcome of a section's last run. It is kept in
+// state/heartbeat.json so schedules survive restarts.
+type SectionState struct {
+	LastRun    time.Time `json:"last_run"`
+	LastResult string    `json:"last_result"` // ok, sent, async, error or skipped
+	LastError  string    `json:"last_error,omitempty"`
+}
 
 // HeartbeatService manages periodic heartbeat checks
 type HeartbeatService struct {
@@ -42,6 +55,15 @@ type HeartbeatService struct {
 	enabled   bool
 	mu        sync.RWMutex
 	stopChan  chan struct{}
+	started   time.Time
+
+	runMu    sync.Mutex // Serializes section runs
+	sections []*Section // As of the last check
+	runs     map[string]SectionState
+
+	lastRun    time.Time
+	lastResult string // ok, sent, async, error or skipped
+	lastError  string
 }
 
 // NewHeartbeatService creates a new heartbeat service
@@ -55,12 +77,15 @@ func NewHeartbeatService(workspace string, intervalMinutes int, enabled bool) *H
 		intervalMinutes = defaultIntervalMinutes
 	}
 
-	return &HeartbeatService{
+	hs := &HeartbeatService{
 		workspace: workspace,
 		interval:  time.Duration(intervalMinutes) * time.Minute,
 		enabled:   enabled,
 		state:     state.NewManager(workspace),
+		started:   time.Now(),
 	}
+	hs.runs = hs.loadRuns()
+	return hs
 }
 
 // SetBus sets the message bus for delivering heartbeat results.
@@ -93,6 +118,7 @@ func (hs *HeartbeatService) Start() error {
 	}
 
 	hs.stopChan = make(chan struct{})
+	hs.started = time.Now()
 	go hs.runLoop(hs.stopChan)
 
 	logger.InfoCF("heartbeat", "Heartbeat service started", map[string]any{
@@ -123,9 +149,101 @@ func (hs *HeartbeatService) IsRunning() bool {
 	return hs.stopChan != nil
 }
 
+// Status reports whether the service runs and the outcome of the last
+// heartbeat.
+func (hs *HeartbeatService) Status() map[string]any {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+
+	status := map[string]any{
+		"enabled":          hs.enabled,
+		"running":          hs.stopChan != nil,
+		"interval_minutes": hs.interval.Minutes(),
+	}
+	if !hs.lastRun.IsZero() {
+		status["last_run"] = hs.lastRun
+		status["last_result"] = hs.lastResult
+	}
+	if hs.lastError != "" {
+		status["last_error"] = hs.lastError
+	}
+
+	sections := make([]map[string]any, 0, len(hs.sections))
+	for _, s := range hs.sections {
+		entry := map[string]any{
+			"name":     s.Name,
+			"schedule": s.Schedule(),
+		}
+		if target := s.Target(); target != "" {
+			entry["target"] = target
+		}
+		if s.Agent != "" {
+			entry["agent"] = s.Agent
+		}
+		if s.Active != "" {
+			entry["active"] = s.Active
+		}
+		if run, ok := hs.runs[s.Name]; ok {
+			entry["last_run"] = run.LastRun
+			entry["last_result"] = run.LastResult
+			if run.LastError != "" {
+				entry["last_error"] = run.LastError
+			}
+		}
+		sections = append(sections, entry)
+	}
+	if len(sections) > 0 {
+		status["sections"] = sections
+	}
+	return status
+}
+
+// recordRun records the outcome of a run of section, or of a check that
+// found nothing to run when section is empty.
+func (hs *HeartbeatService) recordRun(section, result, errText string) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.lastRun = time.Now()
+	hs.lastResult = result
+	hs.lastError = errText
+
+	if section == "" {
+		return
+	}
+	hs.runs[section] = SectionState{LastRun: hs.lastRun, LastResult: result, LastError: errText}
+	if err := hs.saveRunsUnsafe(); err != nil {
+		hs.logErrorf("Failed to save heartbeat state: %v", err)
+	}
+}
+
+func (hs *HeartbeatService) runsFile() string {
+	return filepath.Join(hs.workspace, "state", "heartbeat.json")
+}
+
+func (hs *HeartbeatService) loadRuns() map[string]SectionState {
+	runs := make(map[string]SectionState)
+	data, err := os.ReadFile(hs.runsFile())
+	if err != nil {
+		return runs
+	}
+	if err := json.Unmarshal(data, &runs); err != nil {
+		hs.logErrorf("Ignoring corrupt heartbeat state: %v", err)
+		return make(map[string]SectionState)
+	}
+	return runs
+}
+
+func (hs *HeartbeatService) saveRunsUnsafe() error {
+	data, err := json.MarshalIndent(hs.runs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteFileAtomic(hs.runsFile(), data, 0o644)
+}
+
 // runLoop runs the heartbeat ticker
 func (hs *HeartbeatService) runLoop(stopChan chan struct{}) {
-	ticker := time.NewTicker(hs.interval)
+	ticker := time.NewTicker(checkInterval)
 	defer ticker.Stop()
 
 	// Run first heartbeat after initial delay
@@ -143,58 +261,135 @@ func (hs *HeartbeatService) runLoop(stopChan chan struct{}) {
 	}
 }
 
-// executeHeartbeat performs a single heartbeat check
+// executeHeartbeat runs every section that is due
 func (hs *HeartbeatService) executeHeartbeat() {
 	hs.mu.RLock()
-	enabled := hs.enabled
-	handler := hs.handler
 	if !hs.enabled || hs.stopChan == nil {
 		hs.mu.RUnlock()
 		return
 	}
 	hs.mu.RUnlock()
 
-	if !enabled {
-		return
-	}
+	hs.runDue(time.Now())
+}
+
+// runDue runs the sections that are due at now, one after another.
+func (hs *HeartbeatService) runDue(now time.Time) {
+	hs.runMu.Lock()
+	defer hs.runMu.Unlock()
 
 	logger.DebugC("heartbeat", "Executing heartbeat")
 
-	prompt := hs.buildPrompt()
-	if prompt == "" {
+	sections, err := hs.loadSections()
+	if err != nil {
+		hs.logErrorf("%v", err)
+	}
+	if len(sections) == 0 {
 		logger.InfoC("heartbeat", "No heartbeat prompt (HEARTBEAT.md empty or missing)")
+		hs.recordRun("", "skipped", "")
 		return
 	}
 
+	hs.mu.RLock()
+	started := hs.started
+	due := make([]*Section, 0, len(sections))
+	for _, s := range sections {
+		if s.dueAt(now, hs.runs[s.Name].LastRun, started) {
+			due = append(due, s)
+		}
+	}
+	hs.mu.RUnlock()
+
+	for _, s := range due {
+		hs.runSection(s)
+	}
+}
+
+// Sections parses HEARTBEAT.md. Sections with invalid settings are left out
+// and reported in the error.
+func (hs *HeartbeatService) Sections() ([]*Section, error) {
+	return hs.loadSections()
+}
+
+// RunNow runs the named section immediately, ignoring its schedule and
+// active hours. An empty name runs every section.
+func (hs *HeartbeatService) RunNow(name string) error {
+	hs.runMu.Lock()
+	defer hs.runMu.Unlock()
+
+	sections, _ := hs.loadSections()
+	var selected []*Section
+	names := make([]string, 0, len(sections))
+	for _, s := range sections {
+		names = append(names, s.Name)
+		if name == "" || strings.EqualFold(s.Name, name) {
+			selected = append(selected, s)
+		}
+	}
+	if len(selected) == 0 {
+		if name == "" {
+			return fmt.Errorf("HEARTBEAT.md has no tasks")
+		}
+		return fmt.Errorf("no heartbeat section %q (have: %s)", name, strings.Join(names, ", "))
+	}
+
+	hs.mu.RLock()
+	handler := hs.handler
+	hs.mu.RUnlock()
+	if handler == nil {
+		return fmt.Errorf("heartbeat handler not configured")
+	}
+
+	for _, s := range selected {
+		hs.runSection(s)
+	}
+	return nil
+}
+
+// runSection runs one section through the handler and delivers the result.
+func (hs *HeartbeatService) runSection(s *Section) {
+	hs.mu.RLock()
+	handler := hs.handler
+	hs.mu.RUnlock()
+
 	if handler == nil {
 		hs.logErrorf("Heartbeat handler not configured")
+		hs.recordRun(s.Name, "error", "handler not configured")
 		return
 	}
 
-	// Get last channel info for context
-	lastChannel := hs.state.GetLastChannel()
-	channel, chatID := hs.parseLastChannel(lastChannel)
+	channel, chatID := s.Channel, s.ChatID
+	if channel == "" {
+		// Get last channel info for context
+		lastChannel := hs.state.GetLastChannel()
+		channel, chatID = hs.parseLastChannel(lastChannel)
 
-	// Debug log for channel resolution
-	hs.logInfof("Resolved channel: %s, chatID: %s (from lastChannel: %s)", channel, chatID, lastChannel)
+		// Debug log for channel resolution
+		hs.logInfof("[%s] Resolved channel: %s, chatID: %s (from lastChannel: %s)",
+			s.Name, channel, chatID, lastChannel)
+	}
 
-	result := handler(prompt, channel, chatID)
+	result := handler(s.Agent, hs.buildPrompt(s), channel, chatID)
 
 	if result == nil {
-		hs.logInfof("Heartbeat handler returned nil result")
+		hs.logInfof("[%s] Heartbeat handler returned nil result", s.Name)
+		hs.recordRun(s.Name, "ok", "")
 		return
 	}
 
 	// Handle different result types
 	if result.IsError {
-		hs.logErrorf("Heartbeat error: %s", result.ForLLM)
+		hs.logErrorf("[%s] Heartbeat error: %s", s.Name, result.ForLLM)
+		hs.recordRun(s.Name, "error", result.ForLLM)
 		return
 	}
 
 	if result.Async {
-		hs.logInfof("Async task started: %s", result.ForLLM)
+		hs.recordRun(s.Name, "async", "")
+		hs.logInfof("[%s] Async task started: %s", s.Name, result.ForLLM)
 		logger.InfoCF("heartbeat", "Async heartbeat task started",
 			map[string]any{
+				"section": s.Name,
 				"message": result.ForLLM,
 			})
 		return
@@ -202,40 +397,55 @@ func (hs *HeartbeatService) executeHeartbeat() {
 
 	// Check if silent
 	if result.Silent {
-		hs.logInfof("Heartbeat OK - silent")
+		hs.logInfof("[%s] Heartbeat OK - silent", s.Name)
+		hs.recordRun(s.Name, "ok", "")
 		return
 	}
 
 	// Send result to user
 	if result.ForUser != "" {
-		hs.sendResponse(result.ForUser)
+		hs.sendResponse(channel, chatID, result.ForUser)
 	} else if result.ForLLM != "" {
-		hs.sendResponse(result.ForLLM)
+		hs.sendResponse(channel, chatID, result.ForLLM)
 	}
 
-	hs.logInfof("Heartbeat completed: %s", result.ForLLM)
+	hs.recordRun(s.Name, "sent", "")
+	hs.logInfof("[%s] Heartbeat completed: %s", s.Name, result.ForLLM)
 }
 
-// buildPrompt builds the heartbeat prompt from HEARTBEAT.md
-func (hs *HeartbeatService) buildPrompt() string {
+// loadSections reads HEARTBEAT.md, creating the default template when it
+// is missing, and remembers the sections for Status.
+func (hs *HeartbeatService) loadSections() ([]*Section, error) {
 	heartbeatPath := filepath.Join(hs.workspace, "HEARTBEAT.md")
 
 	data, err := os.ReadFile(heartbeatPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			hs.createDefaultHeartbeatTemplate()
-			return ""
+			return nil, nil
 		}
-		hs.logErrorf("Error reading HEARTBEAT.md: %v", err)
-		return ""
+		return nil, fmt.Errorf("error reading HEARTBEAT.md: %w", err)
 	}
 
-	content := string(data)
-	if len(content) == 0 {
-		return ""
+	sections, errs := parseSections(string(data), hs.interval)
+
+	hs.mu.Lock()
+	hs.sections = sections
+	hs.mu.Unlock()
+
+	if len(errs) > 0 {
+		msgs := make([]string, len(errs))
+		for i, e := range errs {
+			msgs[i] = e.Error()
+		}
+		return sections, fmt.Errorf("HEARTBEAT.md: %s", strings.Join(msgs, "; "))
 	}
+	return sections, nil
+}
 
-	now := time.Now().Format("2006-01-02 15:04:05")
+// buildPrompt builds the heartbeat prompt for a section
+func (hs *HeartbeatService) buildPrompt(s *Section) string {
+	now := time.Now().In(s.location()).Format("2006-01-02 15:04:05")
 	return fmt.Sprintf(`# Heartbeat Check
 
 Current time: %s
@@ -245,7 +455,7 @@ Review the following tasks and execute any necessary actions using available ski
 If there is nothing that requires attention, respond ONLY with: HEARTBEAT_OK
 
 %s
-`, now, content)
+`, now, s.Body)
 }
 
 // createDefaultHeartbeatTemplate creates the default HEARTBEAT.md file
@@ -262,6 +472,19 @@ This file contains tasks for the heartbeat service to check periodically.
 - Review upcoming calendar events
 - Check device status (e.g., MaixCam)
 
+## Scheduled sections
+
+A "##" heading followed by a settings comment runs on its own schedule,
+for example:
+
+    ## Morning briefing
+    <!-- cron: 0 8 * * 1-5; target: telegram:123456; active: 07:00-22:00 -->
+    - Summarize today's calendar
+
+Settings: every (e.g. 2h), cron, target (channel:chat_id), agent, active
+(HH:MM-HH:MM) and tz. Everything else in this file runs at the global
+heartbeat interval and reports to the last active chat.
+
 ## Instructions
 
 - Execute ALL tasks listed below. Do NOT skip any task.
@@ -283,8 +506,8 @@ Add your heartbeat tasks below this line:
 	}
 }
 
-// sendResponse sends the heartbeat response to the last channel
-func (hs *HeartbeatService) sendResponse(response string) {
+// sendResponse sends the heartbeat response to the section's chat
+func (hs *HeartbeatService) sendResponse(platform, userID, response string) {
 	hs.mu.RLock()
 	msgBus := hs.bus
 	hs.mu.RUnlock()
@@ -294,17 +517,9 @@ func (hs *HeartbeatService) sendResponse(response string) {
 		return
 	}
 
-	// Get last channel from state
-	lastChannel := hs.state.GetLastChannel()
-	if lastChannel == "" {
-		hs.logInfof("No last channel recorded, heartbeat result not sent")
-		return
-	}
-
-	platform, userID := hs.parseLastChannel(lastChannel)
-
 	// Skip internal channels that can't receive messages
 	if platform == "" || userID == "" {
+		hs.logInfof("No target chat, heartbeat result not sent")
 		return
 	}
 
@@ -312,6 +527,7 @@ func (hs *HeartbeatService) sendResponse(response string) {
 		Channel: platform,
 		ChatID:  userID,
 		Content: response,
+		Sender:  "heartbeat",
 	})
 
 	hs.logInfof("Heartbeat result sent to %s", platform)
@@ -1,11 +1,14 @@
 package heartbeat
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/sipeed/picoclaw/pkg/bus"
 	"github.com/sipeed/picoclaw/pkg/tools"
 )
 
@@ -28,7 +31,7 @@ func TestExecuteHeartbeat_Async(t *testing.T) {
 		Async:   true,
 	}
 
-	hs.SetHandler(func(prompt, channel, chatID string) *tools.ToolResult {
+	hs.SetHandler(func(agentID, prompt, channel, chatID string) *tools.ToolResult {
 		asyncCalled = true
 		if prompt == "" {
 			t.Error("Expected non-empty prompt")
@@ -57,7 +60,7 @@ func TestExecuteHeartbeat_Error(t *testing.T) {
 	hs := NewHeartbeatService(tmpDir, 30, true)
 	hs.stopChan = make(chan struct{}) // Enable for testing
 
-	hs.SetHandler(func(prompt, channel, chatID string) *tools.ToolResult {
+	hs.SetHandler(func(agentID, prompt, channel, chatID string) *tools.ToolResult {
 		return &tools.ToolResult{
 			ForLLM:  "Heartbeat failed: connection error",
 			ForUser: "",
@@ -83,6 +86,11 @@ func TestExecuteHeartbeat_Error(t *testing.T) {
 	if logContent == "" {
 		t.Error("Expected log file to contain error message")
 	}
+
+	status := hs.Status()
+	if status["last_result"] != "error" || status["last_error"] != "Heartbeat failed: connection error" {
+		t.Errorf("Status() = %v, want the failed run", status)
+	}
 }
 
 func TestExecuteHeartbeat_Silent(t *testing.T) {
@@ -95,7 +103,7 @@ func TestExecuteHeartbeat_Silent(t *testing.T) {
 	hs := NewHeartbeatService(tmpDir, 30, true)
 	hs.stopChan = make(chan struct{}) // Enable for testing
 
-	hs.SetHandler(func(prompt, channel, chatID string) *tools.ToolResult {
+	hs.SetHandler(func(agentID, prompt, channel, chatID string) *tools.ToolResult {
 		return &tools.ToolResult{
 			ForLLM:  "Heartbeat completed successfully",
 			ForUser: "",
@@ -169,7 +177,7 @@ func TestExecuteHeartbeat_NilResult(t *testing.T) {
 	hs := NewHeartbeatService(tmpDir, 30, true)
 	hs.stopChan = make(chan struct{}) // Enable for testing
 
-	hs.SetHandler(func(prompt, channel, chatID string) *tools.ToolResult {
+	hs.SetHandler(func(agentID, prompt, channel, chatID string) *tools.ToolResult {
 		return nil
 	})
 
@@ -211,7 +219,7 @@ func TestHeartbeatFilePath(t *testing.T) {
 	hs := NewHeartbeatService(tmpDir, 30, true)
 
 	// Trigger default template creation
-	hs.buildPrompt()
+	hs.loadSections()
 
 	// Verify HEARTBEAT.md exists at workspace root
 	expectedPath := filepath.Join(tmpDir, "HEARTBEAT.md")
@@ -219,3 +227,73 @@ func TestHeartbeatFilePath(t *testing.T) {
 		t.Errorf("Expected HEARTBEAT.md at %s, but it doesn't exist", expectedPath)
 	}
 }
+
+func TestRunDue_Sections(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := "- Check email\n\n## Plants\n<!-- every: 2h; target: telegram:42; agent: garden -->\n- Water them\n"
+	os.WriteFile(filepath.Join(tmpDir, "HEARTBEAT.md"), []byte(content), 0o644)
+
+	hs := NewHeartbeatService(tmpDir, 30, true)
+	msgBus := bus.NewMessageBus()
+	hs.SetBus(msgBus)
+
+	var runs []string
+	hs.SetHandler(func(agentID, prompt, channel, chatID string) *tools.ToolResult {
+		runs = append(runs, agentID+"@"+channel+":"+chatID)
+		if strings.Contains(prompt, "Water them") {
+			return &tools.ToolResult{ForUser: "Plants are dry"}
+		}
+		return tools.SilentResult("HEARTBEAT_OK")
+	})
+
+	now := time.Now()
+	hs.runDue(now)
+	if len(runs) != 2 || runs[0] != "@:" || runs[1] != "garden@telegram:42" {
+		t.Fatalf("runs = %v", runs)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	out, ok := msgBus.SubscribeOutbound(ctx)
+	if !ok || out.Channel != "telegram" || out.ChatID != "42" || out.Content != "Plants are dry" {
+		t.Errorf("outbound = %+v, %v", out, ok)
+	}
+
+	// Only the default section is due again after its 30 minutes.
+	runs = nil
+	hs.runDue(now.Add(31 * time.Minute))
+	if len(runs) != 1 || runs[0] != "@:" {
+		t.Errorf("runs after 31m = %v", runs)
+	}
+
+	// State survives a restart.
+	hs2 := NewHeartbeatService(tmpDir, 30, true)
+	hs2.loadSections()
+	sections, _ := hs2.Status()["sections"].([]map[string]any)
+	if len(sections) != 2 || sections[1]["name"] != "Plants" || sections[1]["last_result"] != "sent" {
+		t.Errorf("sections status = %v", sections)
+	}
+}
+
+func TestRunNow(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := "## Plants\n<!-- cron: 0 8 * * *; active: 08:00-08:01 -->\n- Water them\n"
+	os.WriteFile(filepath.Join(tmpDir, "HEARTBEAT.md"), []byte(content), 0o644)
+
+	hs := NewHeartbeatService(tmpDir, 30, true)
+	if err := hs.RunNow("plants"); err == nil {
+		t.Error("RunNow() without handler should fail")
+	}
+
+	calls := 0
+	hs.SetHandler(func(agentID, prompt, channel, chatID string) *tools.ToolResult {
+		calls++
+		return nil
+	})
+	if err := hs.RunNow("plants"); err != nil || calls != 1 {
+		t.Errorf("RunNow(plants) = %v, calls = %d", err, calls)
+	}
+	if err := hs.RunNow("weather"); err == nil || !strings.Contains(err.Error(), "Plants") {
+		t.Errorf("RunNow(weather) = %v, want error listing sections", err)
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/sipeed/picoclaw/pkg/redact"
 )
 
 type LogLevel int
@@ -34,8 +36,14 @@ var (
 	logger       *Logger
 	once         sync.Once
 	mu           sync.RWMutex
+
+	recentMu     sync.Mutex
+	recentErrors []LogEntry // ring buffer of the last maxRecentErrors warnings and errors
+	recentNext   int
 )
 
+const maxRecentErrors = 100
+
 type Logger struct {
 	file *os.File
 }
@@ -101,6 +109,10 @@ func logMessage(level LogLevel, component string, message string, fields map[str
 		return
 	}
 
+	// Mask credentials before the entry reaches any sink.
+	message = redact.String(message)
+	fields = redactFields(fields)
+
 	entry := LogEntry{
 		Level:     logLevelNames[level],
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
@@ -116,6 +128,10 @@ func logMessage(level LogLevel, component string, message string, fields map[str
 		}
 	}
 
+	if level >= WARN {
+		recordRecent(entry)
+	}
+
 	if logger.file != nil {
 		jsonData, err := json.Marshal(entry)
 		if err == nil {
@@ -145,6 +161,28 @@ func logMessage(level LogLevel, component string, message string, fields map[str
 	}
 }
 
+func recordRecent(entry LogEntry) {
+	recentMu.Lock()
+	defer recentMu.Unlock()
+	if len(recentErrors) < maxRecentErrors {
+		recentErrors = append(recentErrors, entry)
+		return
+	}
+	recentErrors[recentNext] = entry
+	recentNext = (recentNext + 1) % maxRecentErrors
+}
+
+// RecentErrors returns the most recent warnings and errors, newest first.
+func RecentErrors() []LogEntry {
+	recentMu.Lock()
+	defer recentMu.Unlock()
+	out := make([]LogEntry, 0, len(recentErrors))
+	for i := len(recentErrors) - 1; i >= 0; i-- {
+		out = append(out, recentErrors[(recentNext+i)%len(recentErrors)])
+	}
+	return out
+}
+
 func formatComponent(component string) string {
 	if component == "" {
 		return ""
@@ -152,6 +190,19 @@ func formatComponent(component string) string {
 	return fmt.Sprintf(" %s:", component)
 }
 
+// redactFields returns a masked copy of fields; the caller's map is not modified.
+func redactFields(fields map[string]any) map[string]any {
+	if len(fields) == 0 {
+		return fields
+	}
+	r := redact.Default()
+	out := make(map[string]any, len(fields))
+	for k, v := range fields {
+		out[k] = r.Value(v)
+	}
+	return out
+}
+
 func formatFields(fields map[string]any) string {
 	parts := make([]string, 0, len(fields))
 	for k, v := range fields {
@@ -1,6 +1,9 @@
 package logger
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -137,3 +140,55 @@ func TestLoggerHelperFunctions(t *testing.T) {
 	DebugC("test", "Debug with component")
 	WarnF("Warning with fields", map[string]any{"key": "value"})
 }
+
+func TestLogFileIsRedacted(t *testing.T) {
+	initialLevel := GetLevel()
+	defer SetLevel(initialLevel)
+	SetLevel(INFO)
+
+	path := filepath.Join(t.TempDir(), "test.log")
+	if err := EnableFileLogging(path); err != nil {
+		t.Fatalf("EnableFileLogging() error = %v", err)
+	}
+	defer DisableFileLogging()
+
+	token := "xoxb-" + strings.Repeat("9", 20)
+	fields := map[string]any{"args": map[string]any{"command": "curl -H 'Authorization: " + token + "'"}}
+	InfoCF("tool", "Tool call: exec "+token, fields)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if strings.Contains(string(data), token) {
+		t.Errorf("log file contains the token: %s", data)
+	}
+	if !strings.Contains(string(data), "[REDACTED:slack_token]") {
+		t.Errorf("log file missing redaction marker: %s", data)
+	}
+	// The caller's map is left as it was.
+	if !strings.Contains(fields["args"].(map[string]any)["command"].(string), token) {
+		t.Error("logging must not modify the caller's fields")
+	}
+}
+
+func TestRecentErrors(t *testing.T) {
+	for i := 0; i < maxRecentErrors+5; i++ {
+		WarnC("test", "warning")
+	}
+	InfoC("test", "not recorded")
+	ErrorCF("test", "newest", map[string]any{"n": 1})
+
+	recent := RecentErrors()
+	if len(recent) != maxRecentErrors {
+		t.Fatalf("len(RecentErrors()) = %d, want %d", len(recent), maxRecentErrors)
+	}
+	if recent[0].Message != "newest" || recent[0].Level != "ERROR" {
+		t.Errorf("newest entry = %+v", recent[0])
+	}
+	for _, e := range recent {
+		if e.Level == "INFO" {
+			t.Errorf("info entry recorded: %+v", e)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func render(t *testing.T, r *Registry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := r.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	return buf.String()
+}
+
+func TestCounter(t *testing.T) {
+	c := NewCounter("test_messages_total", "Messages.\nSecond line.", "channel", "direction")
+	c.Inc("telegram", "inbound")
+	c.Inc("telegram", "inbound")
+	c.Add(3, `we"ird\`, "outbound")
+	c.Add(-1, "telegram", "inbound") // ignored
+
+	if got := c.Value("telegram", "inbound"); got != 2 {
+		t.Errorf("Value() = %v, want 2", got)
+	}
+
+	r := NewRegistry()
+	r.register(c.name, c)
+	want := `# HELP test_messages_total Messages.\nSecond line.
+# TYPE test_messages_total counter
+test_messages_total{channel="telegram",direction="inbound"} 2
+test_messages_total{channel="we\"ird\\",direction="outbound"} 3
+`
+	if got := render(t, r); got != want {
+		t.Errorf("exposition =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCounter_Each(t *testing.T) {
+	c := NewCounter("test_each_total", "Each.", "a", "b")
+	c.Add(2, "y", "1")
+	c.Add(1, "x", "2")
+
+	var got []string
+	c.Each(func(lv []string, v float64) {
+		got = append(got, lv[0]+lv[1]+"="+formatFloat(v))
+	})
+	if len(got) != 2 || got[0] != "x2=1" || got[1] != "y1=2" {
+		t.Errorf("Each() visited %v, want [x2=1 y1=2]", got)
+	}
+}
+
+func TestCounter_LabelMismatchPanics(t *testing.T) {
+	c := NewCounter("test_mismatch_total", "x", "a")
+	defer func() {
+		if recover() == nil {
+			t.Error("Inc() with wrong label count did not panic")
+		}
+	}()
+	c.Inc()
+}
+
+func TestGaugeAndGaugeFunc(t *testing.T) {
+	g := NewGauge("test_depth", "Depth.")
+	g.Set(5)
+	g.Add(-2)
+	if got := g.Value(); got != 3 {
+		t.Errorf("Value() = %v, want 3", got)
+	}
+
+	n := 0.0
+	NewGaugeFunc("test_queue", "Queue.", func() float64 { n++; return n })
+
+	out := render(t, Default())
+	for _, want := range []string{"test_depth 3\n", "# TYPE test_queue gauge\ntest_queue 1\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("exposition missing %q", want)
+		}
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	h := NewHistogram("test_latency_seconds", "Latency.", []float64{1, 0.1}, "model")
+	for _, v := range []float64{0.05, 0.1, 0.5, 2} {
+		h.Observe(v, "m")
+	}
+	if got := h.Count("m"); got != 4 {
+		t.Errorf("Count() = %d, want 4", got)
+	}
+
+	r := NewRegistry()
+	r.register(h.name, h)
+	want := `# HELP test_latency_seconds Latency.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{model="m",le="0.1"} 2
+test_latency_seconds_bucket{model="m",le="1"} 3
+test_latency_seconds_bucket{model="m",le="+Inf"} 4
+test_latency_seconds_sum{model="m"} 2.65
+test_latency_seconds_count{model="m"} 4
+`
+	if got := render(t, r); got != want {
+		t.Errorf("exposition =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	NewCounter("test_handler_total", "Handler.").Inc()
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{"test_handler_total 1\n", "go_goroutines ", "go_memstats_alloc_bytes "} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body missing %q", want)
+		}
+	}
+}
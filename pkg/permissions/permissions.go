@@ -0,0 +1,264 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package permissions resolves the role of a message sender and decides which
+// tools, agents and admin commands it may use.
+package permissions
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/constants"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/routing"
+)
+
+// Access is what one sender may do, resolved from their role.
+type Access struct {
+	Identity string // identity the role and token budget are tracked under
+	Role     string
+
+	role    config.RoleConfig
+	unknown bool // the assigned role is not defined: deny everything
+}
+
+// IsAdmin reports whether the sender may use admin chat commands.
+func (a *Access) IsAdmin() bool {
+	return !a.unknown && a.role.Admin
+}
+
+// AllowsTool reports whether the tool may be offered to the LLM and run.
+func (a *Access) AllowsTool(name string) bool {
+	if a.unknown || matchAny(a.role.DenyTools, name) {
+		return false
+	}
+	return len(a.role.Tools) == 0 || matchAny(a.role.Tools, name)
+}
+
+// AllowsAgent reports whether the sender may reach the agent.
+func (a *Access) AllowsAgent(agentID string) bool {
+	if a.unknown {
+		return false
+	}
+	return len(a.role.Agents) == 0 || matchAny(a.role.Agents, routing.NormalizeAgentID(agentID))
+}
+
+// DailyTokenBudget returns the daily token limit, or 0 when unlimited.
+func (a *Access) DailyTokenBudget() int {
+	return a.role.DailyTokenBudget
+}
+
+// CheckTool returns an error when the sender may not run the tool with args.
+// Tools that take an agent_id (spawn, subagent) are also checked against the
+// reachable agents.
+func (a *Access) CheckTool(name string, args map[string]any) error {
+	if !a.AllowsTool(name) {
+		return fmt.Errorf("tool %q is not permitted for role %q", name, a.Role)
+	}
+	if agentID, _ := args["agent_id"].(string); agentID != "" && !a.AllowsAgent(agentID) {
+		return fmt.Errorf("agent %q is not reachable for role %q", agentID, a.Role)
+	}
+	return nil
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if p == "*" || p == name {
+			return true
+		}
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Metadata keys that carry a role across the bus, from the turn that
+// spawned background work to the turn that reports its result.
+const (
+	MetadataRole     = "origin_role"
+	MetadataIdentity = "origin_identity"
+)
+
+type accessKey struct{}
+
+// WithAccess returns a context carrying access. Tool execution reads it back
+// to enforce the role a second time.
+func WithAccess(ctx context.Context, access *Access) context.Context {
+	return context.WithValue(ctx, accessKey{}, access)
+}
+
+// AccessFrom returns the access attached to ctx, or nil when the turn is not
+// subject to permissions.
+func AccessFrom(ctx context.Context) *Access {
+	access, _ := ctx.Value(accessKey{}).(*Access)
+	return access
+}
+
+// Policy maps senders to roles and tracks their daily token use. Usage is
+// kept in memory only, so budgets start over when the process restarts.
+type Policy struct {
+	cfg   config.PermissionsConfig
+	links map[string][]string
+	users map[string]string // lower-cased identity -> role
+
+	mu    sync.Mutex
+	usage map[string]dayUsage
+	now   func() time.Time
+}
+
+type dayUsage struct {
+	day    string
+	tokens int
+}
+
+// NewPolicy creates a policy. identityLinks is session.identity_links.
+func NewPolicy(cfg config.PermissionsConfig, identityLinks map[string][]string) *Policy {
+	users := make(map[string]string, len(cfg.Users))
+	for identity, role := range cfg.Users {
+		users[strings.ToLower(strings.TrimSpace(identity))] = role
+	}
+	return &Policy{
+		cfg:   cfg,
+		links: identityLinks,
+		users: users,
+		usage: make(map[string]dayUsage),
+		now:   time.Now,
+	}
+}
+
+// Enabled reports whether roles are enforced.
+func (p *Policy) Enabled() bool {
+	return p != nil && p.cfg.Enabled
+}
+
+// Resolve returns the access of senderID on channel. It returns nil, meaning
+// unrestricted, when permissions are disabled or the channel is internal
+// (cli, system, subagent).
+func (p *Policy) Resolve(channel, senderID string) *Access {
+	if !p.Enabled() || constants.IsInternalChannel(channel) {
+		return nil
+	}
+
+	identity, roleName := p.lookup(channel, senderID)
+	return p.access(identity, roleName)
+}
+
+// ResolveSystem returns the access of a turn no sender started directly:
+// cron jobs, event triggers, heartbeats and spawn results whose origin is
+// unknown. These run under system_role, or default_role when it is unset.
+// It returns nil when permissions are disabled.
+func (p *Policy) ResolveSystem(source string) *Access {
+	if !p.Enabled() {
+		return nil
+	}
+	roleName := p.cfg.SystemRole
+	if roleName == "" {
+		roleName = p.cfg.DefaultRole
+	}
+	return p.access("system:"+source, roleName)
+}
+
+// ResolveRole returns the access of a role carried over from an earlier turn,
+// e.g. the sender who spawned a subagent. It returns nil when permissions are
+// disabled.
+func (p *Policy) ResolveRole(identity, roleName string) *Access {
+	if !p.Enabled() {
+		return nil
+	}
+	return p.access(identity, roleName)
+}
+
+func (p *Policy) access(identity, roleName string) *Access {
+	access := &Access{Identity: identity, Role: roleName}
+	role, ok := p.cfg.Roles[roleName]
+	if !ok {
+		logger.WarnCF("permissions", "Sender has an undefined role; denying all tools",
+			map[string]any{"identity": identity, "role": roleName})
+		access.unknown = true
+		return access
+	}
+	access.role = role
+	return access
+}
+
+// lookup finds the identity and role of a sender. Canonical names from
+// identity links take precedence over channel-scoped IDs, which take
+// precedence over bare IDs and usernames.
+func (p *Policy) lookup(channel, senderID string) (identity, role string) {
+	channel = strings.ToLower(strings.TrimSpace(channel))
+	parts := []string{senderID}
+	// Telegram and others send "id|username".
+	if id, user, ok := strings.Cut(senderID, "|"); ok {
+		parts = []string{id, user, senderID}
+	}
+
+	var linked, scoped, bare []string
+	for _, part := range parts {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		if name := routing.LinkedIdentity(p.links, channel, part); name != "" {
+			linked = append(linked, strings.ToLower(name))
+		}
+		scoped = append(scoped, channel+":"+part)
+		bare = append(bare, part, "@"+part)
+	}
+
+	identity = channel + ":" + strings.ToLower(parts[0])
+	if len(linked) > 0 {
+		identity = linked[0]
+	}
+	for _, candidate := range append(append(linked, scoped...), bare...) {
+		if r, ok := p.users[candidate]; ok {
+			return identity, r
+		}
+	}
+	return identity, p.cfg.DefaultRole
+}
+
+// Charge adds tokens to the identity's usage for today.
+func (p *Policy) Charge(access *Access, tokens int) {
+	if access == nil || tokens <= 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	today := p.now().Format("2006-01-02")
+	u := p.usage[access.Identity]
+	if u.day != today {
+		u = dayUsage{day: today}
+	}
+	u.tokens += tokens
+	p.usage[access.Identity] = u
+}
+
+// Used returns the tokens the identity has used today.
+func (p *Policy) Used(access *Access) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	u := p.usage[access.Identity]
+	if u.day != p.now().Format("2006-01-02") {
+		return 0
+	}
+	return u.tokens
+}
+
+// OverBudget reports whether the identity has used up its daily budget.
+func (p *Policy) OverBudget(access *Access) bool {
+	if access == nil || access.DailyTokenBudget() <= 0 {
+		return false
+	}
+	return p.Used(access) >= access.DailyTokenBudget()
+}
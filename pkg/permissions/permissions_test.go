@@ -0,0 +1,185 @@
+package permissions
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func testPolicy() *Policy {
+	return NewPolicy(config.PermissionsConfig{
+		Enabled:     true,
+		DefaultRole: "member",
+		Roles: map[string]config.RoleConfig{
+			"admin":  {Admin: true},
+			"member": {DenyTools: []string{"exec", "mcp_*"}},
+			"guest": {
+				Tools:            []string{"web_*"},
+				Agents:           []string{"main"},
+				DailyTokenBudget: 100,
+			},
+		},
+		Users: map[string]string{
+			"Alice":           "admin",
+			"telegram:555":    "guest",
+			"@bob":            "guest",
+			"discord:missing": "ghost",
+		},
+	}, map[string][]string{
+		"alice": {"telegram:111", "discord:222"},
+	})
+}
+
+func TestResolve_Roles(t *testing.T) {
+	p := testPolicy()
+
+	tests := []struct {
+		channel, sender string
+		identity, role  string
+	}{
+		{"telegram", "111", "alice", "admin"},
+		{"discord", "222", "alice", "admin"},
+		{"telegram", "555", "telegram:555", "guest"},
+		{"slack", "555", "slack:555", "member"},
+		{"telegram", "777|bob", "telegram:777", "guest"},
+		{"telegram", "888|carol", "telegram:888", "member"},
+		{"discord", "missing", "discord:missing", "ghost"},
+	}
+	for _, tt := range tests {
+		access := p.Resolve(tt.channel, tt.sender)
+		if access == nil {
+			t.Fatalf("%s/%s: expected access", tt.channel, tt.sender)
+		}
+		if access.Identity != tt.identity || access.Role != tt.role {
+			t.Errorf("%s/%s: got identity=%q role=%q, want %q %q",
+				tt.channel, tt.sender, access.Identity, access.Role, tt.identity, tt.role)
+		}
+	}
+}
+
+func TestResolve_DisabledOrInternal(t *testing.T) {
+	if NewPolicy(config.PermissionsConfig{}, nil).Resolve("telegram", "1") != nil {
+		t.Error("disabled policy should not restrict")
+	}
+	if testPolicy().Resolve("cli", "direct") != nil {
+		t.Error("internal channels should not be restricted")
+	}
+	var p *Policy
+	if p.Resolve("telegram", "1") != nil {
+		t.Error("nil policy should not restrict")
+	}
+}
+
+func TestResolveSystemAndRole(t *testing.T) {
+	p := testPolicy()
+	sys := p.ResolveSystem("cron")
+	if sys == nil || sys.Identity != "system:cron" || sys.Role != "member" {
+		t.Fatalf("ResolveSystem = %+v, want member under system:cron", sys)
+	}
+	if sys.AllowsTool("exec") {
+		t.Error("system turns should get the default role's restrictions")
+	}
+
+	p.cfg.SystemRole = "guest"
+	if got := p.ResolveSystem("heartbeat"); got.Role != "guest" {
+		t.Errorf("ResolveSystem role = %q, want system_role guest", got.Role)
+	}
+
+	carried := p.ResolveRole("telegram:555", "guest")
+	if carried.Identity != "telegram:555" || carried.AllowsTool("exec") {
+		t.Errorf("ResolveRole = %+v, want guest restrictions", carried)
+	}
+	if p.ResolveRole("x", "ghost").AllowsTool("web_search") {
+		t.Error("an undefined carried role should deny everything")
+	}
+
+	disabled := NewPolicy(config.PermissionsConfig{}, nil)
+	if disabled.ResolveSystem("cron") != nil || disabled.ResolveRole("x", "admin") != nil {
+		t.Error("disabled policy should not restrict")
+	}
+}
+
+func TestAccess_Tools(t *testing.T) {
+	p := testPolicy()
+	admin := p.Resolve("telegram", "111")
+	member := p.Resolve("slack", "1")
+	guest := p.Resolve("telegram", "555")
+	ghost := p.Resolve("discord", "missing")
+
+	cases := []struct {
+		access *Access
+		tool   string
+		want   bool
+	}{
+		{admin, "exec", true},
+		{member, "exec", false},
+		{member, "mcp_github", false},
+		{member, "read_file", true},
+		{guest, "web_fetch", true},
+		{guest, "read_file", false},
+		{ghost, "read_file", false},
+	}
+	for _, c := range cases {
+		if got := c.access.AllowsTool(c.tool); got != c.want {
+			t.Errorf("%s.AllowsTool(%s) = %v, want %v", c.access.Role, c.tool, got, c.want)
+		}
+	}
+
+	if !admin.IsAdmin() || member.IsAdmin() || ghost.IsAdmin() {
+		t.Error("only admin should be admin")
+	}
+}
+
+func TestAccess_CheckToolAgents(t *testing.T) {
+	guest := testPolicy().Resolve("telegram", "555")
+	guest.role.Tools = append(guest.role.Tools, "spawn")
+
+	if err := guest.CheckTool("spawn", map[string]any{"task": "x", "agent_id": "main"}); err != nil {
+		t.Errorf("spawn to main should be allowed: %v", err)
+	}
+	if err := guest.CheckTool("spawn", map[string]any{"task": "x", "agent_id": "coder"}); err == nil {
+		t.Error("spawn to an unreachable agent should be denied")
+	}
+	if err := guest.CheckTool("exec", nil); err == nil {
+		t.Error("exec should be denied for guest")
+	}
+}
+
+func TestPolicy_Budget(t *testing.T) {
+	p := testPolicy()
+	now := time.Date(2026, 1, 1, 23, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+
+	guest := p.Resolve("telegram", "555")
+	p.Charge(guest, 60)
+	if p.OverBudget(guest) {
+		t.Fatal("60/100 should be within budget")
+	}
+	p.Charge(guest, 40)
+	if !p.OverBudget(guest) {
+		t.Fatal("100/100 should exhaust the budget")
+	}
+
+	// The same identity from another channel shares nothing unless linked.
+	if p.OverBudget(p.Resolve("slack", "555")) {
+		t.Error("unlimited member should never be over budget")
+	}
+
+	now = now.Add(2 * time.Hour)
+	if p.OverBudget(guest) || p.Used(guest) != 0 {
+		t.Error("budget should reset on a new day")
+	}
+}
+
+func TestWithAccess(t *testing.T) {
+	ctx := context.Background()
+	if AccessFrom(ctx) != nil {
+		t.Fatal("empty context should have no access")
+	}
+	access := &Access{Identity: "alice", Role: "admin"}
+	if got := AccessFrom(WithAccess(ctx, access)); got != access {
+		t.Errorf("AccessFrom = %v, want %v", got, access)
+	}
+}
@@ -0,0 +1,188 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// endpointCooldown tracks cooldowns per load-balanced endpoint (keyed by
+// config.EndpointID), separately from the per-provider fallback cooldowns.
+var (
+	endpointCooldown     = NewCooldownTracker()
+	endpointCooldownOnce sync.Once
+)
+
+// registerEndpointCooldown lets config.GetModelConfig skip endpoints that
+// are cooling down.
+func registerEndpointCooldown() {
+	endpointCooldownOnce.Do(func() {
+		config.Endpoints().SetCooldownSource(func(id string) time.Duration {
+			return endpointCooldown.CooldownRemaining(id)
+		})
+	})
+}
+
+// CreateProviderForModel creates the provider for a model_name. When several
+// model_list entries share the name, the provider picks an endpoint for every
+// request instead of once at creation. Returns the provider and the model ID
+// of the first entry.
+func CreateProviderForModel(cfg *config.Config, modelName string) (LLMProvider, string, error) {
+	if cfg.ModelEndpointCount(modelName) > 1 {
+		return NewBalancedProvider(cfg, modelName)
+	}
+
+	modelCfg, err := cfg.GetModelConfig(modelName)
+	if err != nil {
+		return nil, "", err
+	}
+	if modelCfg.Workspace == "" {
+		modelCfg.Workspace = cfg.WorkspacePath()
+	}
+	return CreateProviderFromConfig(modelCfg)
+}
+
+// BalancedProvider spreads requests for one model_name across its model_list
+// entries. Each request goes to the endpoint chosen by cfg.SelectModelConfig;
+// its latency and outcome feed back into the endpoint health, and a retriable
+// failure puts the endpoint in cooldown and retries on another one.
+type BalancedProvider struct {
+	cfg       *config.Config
+	modelName string
+	modelID   string // model ID of the first entry, reported to callers
+	endpoints map[string]*balancedEndpoint
+}
+
+type balancedEndpoint struct {
+	provider LLMProvider
+	modelID  string
+	protocol string
+}
+
+// NewBalancedProvider creates a provider for every entry named modelName.
+func NewBalancedProvider(cfg *config.Config, modelName string) (*BalancedProvider, string, error) {
+	registerEndpointCooldown()
+
+	p := &BalancedProvider{
+		cfg:       cfg,
+		modelName: modelName,
+		endpoints: make(map[string]*balancedEndpoint),
+	}
+	index := 0
+	for i := range cfg.ModelList {
+		mc := cfg.ModelList[i]
+		if mc.ModelName != modelName {
+			continue
+		}
+		if mc.Workspace == "" {
+			mc.Workspace = cfg.WorkspacePath()
+		}
+		provider, modelID, err := CreateProviderFromConfig(&mc)
+		if err != nil {
+			p.Close()
+			return nil, "", fmt.Errorf("model_list entry %d of %q: %w", index, modelName, err)
+		}
+		protocol, _ := ExtractProtocol(mc.Model)
+		p.endpoints[config.EndpointID(modelName, index)] = &balancedEndpoint{
+			provider: provider,
+			modelID:  modelID,
+			protocol: protocol,
+		}
+		if index == 0 {
+			p.modelID = modelID
+		}
+		index++
+	}
+	if index == 0 {
+		return nil, "", fmt.Errorf("model %q not found in model_list or providers", modelName)
+	}
+	return p, p.modelID, nil
+}
+
+func (p *BalancedProvider) Chat(
+	ctx context.Context,
+	messages []Message,
+	tools []ToolDefinition,
+	model string,
+	options map[string]any,
+) (*LLMResponse, error) {
+	tried := make(map[string]bool, len(p.endpoints))
+	var lastErr error
+	for range p.endpoints {
+		_, id, err := p.cfg.SelectModelConfig(p.modelName)
+		if err != nil {
+			return nil, err
+		}
+		ep := p.endpoints[id]
+		if ep == nil || tried[id] {
+			// Everything left is cooling down.
+			break
+		}
+		tried[id] = true
+
+		// Entries may name the model differently; fallback candidates sent
+		// through this provider keep their own model.
+		reqModel := model
+		if model == "" || model == p.modelID {
+			reqModel = ep.modelID
+		}
+
+		start := time.Now()
+		resp, err := ep.provider.Chat(ctx, messages, tools, reqModel, options)
+		elapsed := time.Since(start)
+		if err == nil {
+			endpointCooldown.MarkSuccess(id)
+			config.Endpoints().Record(id, elapsed, nil)
+			return resp, nil
+		}
+		if errors.Is(err, context.Canceled) || ctx.Err() != nil {
+			return nil, err
+		}
+
+		config.Endpoints().Record(id, elapsed, err)
+		lastErr = err
+		failErr := ClassifyError(err, ep.protocol, reqModel)
+		if failErr == nil || !failErr.IsRetriable() {
+			return nil, err
+		}
+		endpointCooldown.MarkFailure(id, failErr.Reason)
+		logger.WarnCF("provider.balance", "Endpoint failed, trying another",
+			map[string]any{
+				"endpoint": id,
+				"reason":   string(failErr.Reason),
+				"cooldown": endpointCooldown.CooldownRemaining(id).Round(time.Second).String(),
+			})
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no endpoint available for model %q", p.modelName)
+	}
+	return nil, lastErr
+}
+
+func (p *BalancedProvider) GetDefaultModel() string {
+	return p.modelID
+}
+
+// ModelCapabilities asks the first entry, which names the model callers see.
+func (p *BalancedProvider) ModelCapabilities(ctx context.Context, model string) (*ModelCapabilities, error) {
+	ep := p.endpoints[config.EndpointID(p.modelName, 0)]
+	cp, ok := ep.provider.(CapabilityProvider)
+	if !ok {
+		return nil, errors.ErrUnsupported
+	}
+	return cp.ModelCapabilities(ctx, model)
+}
+
+// Close closes every endpoint provider that holds resources.
+func (p *BalancedProvider) Close() {
+	for _, ep := range p.endpoints {
+		if sp, ok := ep.provider.(StatefulProvider); ok {
+			sp.Close()
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+// newChatServer serves OpenAI-style chat completions, or status when non-zero.
+func newChatServer(t *testing.T, status int, hits *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if status != 0 {
+			http.Error(w, `{"error":"unavailable"}`, status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// balancedModelName returns a model_name not used by earlier runs, with a
+// fresh endpoint cooldown tracker, since endpoint health is process-wide.
+func balancedModelName(t *testing.T) string {
+	t.Helper()
+	registerEndpointCooldown()
+	prev := endpointCooldown
+	endpointCooldown = NewCooldownTracker()
+	t.Cleanup(func() { endpointCooldown = prev })
+	return fmt.Sprintf("%s-%d", t.Name(), time.Now().UnixNano())
+}
+
+func TestCreateProviderForModel_Single(t *testing.T) {
+	cfg := &config.Config{ModelList: []config.ModelConfig{
+		{ModelName: "solo", Model: "openai/gpt-solo", APIKey: "k"},
+	}}
+	provider, modelID, err := CreateProviderForModel(cfg, "solo")
+	if err != nil {
+		t.Fatalf("CreateProviderForModel() error = %v", err)
+	}
+	if _, ok := provider.(*BalancedProvider); ok {
+		t.Error("single entry should not be balanced")
+	}
+	if modelID != "gpt-solo" {
+		t.Errorf("modelID = %q, want gpt-solo", modelID)
+	}
+}
+
+func TestBalancedProvider_FailsOverAndCoolsDown(t *testing.T) {
+	var badHits, goodHits atomic.Int32
+	bad := newChatServer(t, http.StatusServiceUnavailable, &badHits)
+	good := newChatServer(t, 0, &goodHits)
+
+	name := balancedModelName(t)
+	cfg := &config.Config{ModelList: []config.ModelConfig{
+		{ModelName: name, Model: "openai/m-bad", APIKey: "k", APIBase: bad.URL},
+		{ModelName: name, Model: "openai/m-good", APIKey: "k", APIBase: good.URL},
+	}}
+
+	provider, modelID, err := CreateProviderForModel(cfg, name)
+	if err != nil {
+		t.Fatalf("CreateProviderForModel() error = %v", err)
+	}
+	if _, ok := provider.(*BalancedProvider); !ok {
+		t.Fatalf("provider = %T, want *BalancedProvider", provider)
+	}
+	if modelID != "m-bad" {
+		t.Errorf("modelID = %q, want the first entry's m-bad", modelID)
+	}
+
+	for i := range 4 {
+		resp, err := provider.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil, modelID, nil)
+		if err != nil {
+			t.Fatalf("Chat() #%d error = %v", i, err)
+		}
+		if resp.Content != "ok" {
+			t.Errorf("Chat() #%d content = %q", i, resp.Content)
+		}
+	}
+
+	// The failing endpoint is tried once, then skipped in cooldown.
+	if n := badHits.Load(); n != 1 {
+		t.Errorf("failing endpoint got %d requests, want 1", n)
+	}
+	if n := goodHits.Load(); n != 4 {
+		t.Errorf("healthy endpoint got %d requests, want 4", n)
+	}
+	if endpointCooldown.IsAvailable(config.EndpointID(name, 0)) {
+		t.Error("failing endpoint is not in cooldown")
+	}
+
+	var stats []config.EndpointStat
+	for _, s := range cfg.EndpointStats() {
+		if s.ModelName == name {
+			stats = append(stats, s)
+		}
+	}
+	if len(stats) != 2 || stats[1].Requests != 4 || stats[1].LatencyMS < 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestBalancedProvider_AllFailing(t *testing.T) {
+	var hits atomic.Int32
+	bad1 := newChatServer(t, http.StatusBadGateway, &hits)
+	bad2 := newChatServer(t, http.StatusBadGateway, &hits)
+
+	name := balancedModelName(t)
+	cfg := &config.Config{ModelList: []config.ModelConfig{
+		{ModelName: name, Model: "openai/m", APIKey: "k", APIBase: bad1.URL},
+		{ModelName: name, Model: "openai/m", APIKey: "k", APIBase: bad2.URL},
+	}}
+	provider, _, err := NewBalancedProvider(cfg, name)
+	if err != nil {
+		t.Fatalf("NewBalancedProvider() error = %v", err)
+	}
+
+	if _, err := provider.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil, "m", nil); err == nil {
+		t.Fatal("Chat() expected error when every endpoint fails")
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("endpoints got %d requests, want each tried once", n)
+	}
+}
+
+func TestEndpointProbes(t *testing.T) {
+	var okHits, badHits atomic.Int32
+	okSrv := newChatServer(t, 0, &okHits)
+	badSrv := newChatServer(t, http.StatusUnauthorized, &badHits)
+
+	name := balancedModelName(t)
+	cfg := &config.Config{ModelList: []config.ModelConfig{
+		{ModelName: name, Model: "openai/m", APIKey: "k", APIBase: okSrv.URL},
+		{ModelName: name, Model: "openai/m", APIKey: "k", APIBase: badSrv.URL},
+		{ModelName: name, Model: "claude-cli/m"},
+		{ModelName: "probe-single", Model: "openai/m", APIKey: "k", APIBase: okSrv.URL},
+	}}
+
+	probeEndpoints(context.Background(), &http.Client{Timeout: time.Second}, cfg)
+
+	if okHits.Load() != 1 || badHits.Load() != 1 {
+		t.Errorf("probe hits = %d/%d, want 1/1 (single-entry models are skipped)", okHits.Load(), badHits.Load())
+	}
+	if !endpointCooldown.IsAvailable(config.EndpointID(name, 0)) {
+		t.Error("healthy endpoint should be available")
+	}
+	if endpointCooldown.IsAvailable(config.EndpointID(name, 1)) {
+		t.Error("endpoint failing its probe should be in cooldown")
+	}
+
+	for _, s := range cfg.EndpointStats() {
+		switch s.ID {
+		case config.EndpointID(name, 0):
+			if s.ProbeOK == nil || !*s.ProbeOK {
+				t.Errorf("%s probe_ok = %v, want true", s.ID, s.ProbeOK)
+			}
+		case config.EndpointID(name, 2):
+			if s.ProbeOK != nil {
+				t.Errorf("%s should not be probed", s.ID)
+			}
+		}
+	}
+}
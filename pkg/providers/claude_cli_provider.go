@@ -1,18 +1,43 @@
 package providers
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os/exec"
 	"strings"
+	"sync"
 )
 
+// claudeMCPServerName is the SDK MCP server name under which PicoClaw tools
+// are exposed to the claude CLI. The CLI prefixes tool names with
+// "mcp__<server>__" in tool_use events.
+const claudeMCPServerName = "picoclaw"
+
+const claudeMCPToolPrefix = "mcp__" + claudeMCPServerName + "__"
+
 // ClaudeCliProvider implements LLMProvider using the claude CLI as a subprocess.
+//
+// The CLI runs in stream-json mode. By default PicoClaw tools are described
+// in the system prompt and the model answers with a {"tool_calls": [...]}
+// JSON object, which is parsed from the reply text.
+//
+// With native tools enabled, the tools are instead declared through an
+// in-process ("sdk") MCP server answered over the CLI's control protocol, so
+// the model emits real tool_use blocks with structured input. When the CLI
+// asks to call one of those tools, the process is stopped and the calls are
+// returned to the agent loop; the next Chat resumes the CLI session and feeds
+// the results back as tool_result blocks. This relies on an undocumented
+// part of the CLI's protocol, so it is opt-in.
 type ClaudeCliProvider struct {
-	command   string
-	workspace string
+	command     string
+	workspace   string
+	nativeTools bool
+
+	sessions cliSessions // tool_use ID -> CLI session ID awaiting its result
 }
 
 // NewClaudeCliProvider creates a new Claude CLI provider.
@@ -23,40 +48,118 @@ func NewClaudeCliProvider(workspace string) *ClaudeCliProvider {
 	}
 }
 
+// NewClaudeCliProviderWithNativeTools creates a Claude CLI provider that
+// serves tools to the CLI over MCP when nativeTools is set.
+func NewClaudeCliProviderWithNativeTools(workspace string, nativeTools bool) *ClaudeCliProvider {
+	p := NewClaudeCliProvider(workspace)
+	p.nativeTools = nativeTools
+	return p
+}
+
 // Chat implements LLMProvider.Chat by executing the claude CLI.
 func (p *ClaudeCliProvider) Chat(
 	ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]any,
 ) (*LLMResponse, error) {
-	systemPrompt := p.buildSystemPrompt(messages, tools)
-	prompt := p.messagesToPrompt(messages)
+	native := p.nativeTools && len(tools) > 0
+	var resumeID string
+	var toolResults []claudeContentBlock
+	promptTools := tools
+	if native {
+		resumeID, toolResults = p.pendingToolResults(messages)
+		promptTools = nil
+	}
+	systemPrompt := p.buildSystemPrompt(messages, promptTools)
 
-	args := []string{"-p", "--output-format", "json", "--dangerously-skip-permissions", "--no-chrome"}
+	args := []string{
+		"-p", "--verbose",
+		"--input-format", "stream-json",
+		"--output-format", "stream-json",
+		"--dangerously-skip-permissions", "--no-chrome",
+	}
+	if resumeID != "" {
+		args = append(args, "--resume", resumeID)
+	}
 	if systemPrompt != "" {
 		args = append(args, "--system-prompt", systemPrompt)
 	}
 	if model != "" && model != "claude-code" {
 		args = append(args, "--model", model)
 	}
-	args = append(args, "-") // read from stdin
+	if native {
+		args = append(args, "--mcp-config", claudeMCPConfig())
+	}
 
-	cmd := exec.CommandContext(ctx, p.command, args...)
+	var input claudeStreamInput
+	if resumeID != "" {
+		input = claudeUserMessage(toolResults)
+	} else {
+		input = claudeUserMessage([]claudeContentBlock{{Type: "text", Text: p.messagesToPrompt(messages)}})
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cmd := exec.CommandContext(runCtx, p.command, args...)
 	if p.workspace != "" {
 		cmd.Dir = p.workspace
 	}
-	cmd.Stdin = bytes.NewReader([]byte(prompt))
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("claude cli error: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("claude cli error: %w", err)
+	}
+	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
-	if err := cmd.Run(); err != nil {
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("claude cli error: %w", err)
+	}
+
+	session := &claudeStreamSession{stdin: stdin}
+	if native {
+		session.tools = tools
+	}
+	// Write the prompt asynchronously: a CLI that exits early (or never reads
+	// stdin) must not block us, and write errors surface via the exit status.
+	go session.send(input)
+
+	resp, parseErr := session.read(stdout)
+	if session.stopped {
+		// The CLI is waiting for a tool result we will deliver via --resume.
+		cancel()
+	}
+	session.closeStdin()
+	_, _ = io.Copy(io.Discard, stdout)
+	waitErr := cmd.Wait()
+
+	if resp != nil && (session.stopped || session.result != nil) {
+		if session.result != nil && session.result.IsError {
+			return nil, fmt.Errorf("claude cli returned error: %s", session.result.Result)
+		}
+		if native {
+			p.sessions.remember(resp.ToolCalls, session.sessionID)
+		} else if len(promptTools) > 0 {
+			p.extractPromptToolCalls(resp)
+		}
+		return resp, nil
+	}
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if waitErr != nil {
 		if stderrStr := stderr.String(); stderrStr != "" {
 			return nil, fmt.Errorf("claude cli error: %s", stderrStr)
 		}
-		return nil, fmt.Errorf("claude cli error: %w", err)
+		return nil, fmt.Errorf("claude cli error: %w", waitErr)
 	}
-
-	return p.parseClaudeCliResponse(stdout.String())
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	return nil, fmt.Errorf("failed to parse claude cli response: no result event in output")
 }
 
 // GetDefaultModel returns the default model identifier.
@@ -65,6 +168,7 @@ func (p *ClaudeCliProvider) GetDefaultModel() string {
 }
 
 // messagesToPrompt converts messages to a CLI-compatible prompt string.
+// It is used for fresh CLI sessions, where earlier turns are replayed as a transcript.
 func (p *ClaudeCliProvider) messagesToPrompt(messages []Message) string {
 	var parts []string
 
@@ -89,7 +193,8 @@ func (p *ClaudeCliProvider) messagesToPrompt(messages []Message) string {
 	return strings.Join(parts, "\n")
 }
 
-// buildSystemPrompt combines system messages and tool definitions.
+// buildSystemPrompt combines system messages and, when tools are not
+// served over MCP, the tool definitions.
 func (p *ClaudeCliProvider) buildSystemPrompt(messages []Message, tools []ToolDefinition) string {
 	var parts []string
 
@@ -138,82 +243,372 @@ func (p *ClaudeCliProvider) buildToolsPrompt(tools []ToolDefinition) string {
 	return sb.String()
 }
 
-// parseClaudeCliResponse parses the JSON output from the claude CLI.
-func (p *ClaudeCliProvider) parseClaudeCliResponse(output string) (*LLMResponse, error) {
-	var resp claudeCliJSONResponse
-	if err := json.Unmarshal([]byte(output), &resp); err != nil {
-		return nil, fmt.Errorf("failed to parse claude cli response: %w", err)
+// extractPromptToolCalls moves tool calls the model wrote into its reply
+// text, as asked by buildToolsPrompt, into resp.ToolCalls.
+func (p *ClaudeCliProvider) extractPromptToolCalls(resp *LLMResponse) {
+	toolCalls := extractToolCallsFromText(resp.Content)
+	if len(toolCalls) == 0 {
+		return
+	}
+	resp.ToolCalls = toolCalls
+	resp.Content = stripToolCallsFromText(resp.Content)
+	resp.FinishReason = "tool_calls"
+}
+
+// findMatchingBrace finds the index after the closing brace matching the opening brace at pos.
+func findMatchingBrace(text string, pos int) int {
+	depth := 0
+	for i := pos; i < len(text); i++ {
+		if text[i] == '{' {
+			depth++
+		} else if text[i] == '}' {
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return pos
+}
+
+// pendingToolResults returns the CLI session to resume and the tool_result
+// blocks to send when the conversation ends with results for tool calls that
+// this provider issued. Otherwise it returns an empty session ID.
+func (p *ClaudeCliProvider) pendingToolResults(messages []Message) (string, []claudeContentBlock) {
+	start := len(messages)
+	for start > 0 && messages[start-1].Role == "tool" {
+		start--
+	}
+	if start == len(messages) {
+		return "", nil
+	}
+
+	sessionID := p.sessions.take(messages[start:])
+	if sessionID == "" {
+		return "", nil
+	}
+
+	blocks := make([]claudeContentBlock, 0, len(messages)-start)
+	for _, msg := range messages[start:] {
+		blocks = append(blocks, claudeContentBlock{
+			Type:      "tool_result",
+			ToolUseID: msg.ToolCallID,
+			Content:   msg.Content,
+		})
+	}
+	return sessionID, blocks
+}
+
+// claudeMCPConfig declares the in-process MCP server that serves PicoClaw tools.
+func claudeMCPConfig() string {
+	cfg := map[string]any{
+		"mcpServers": map[string]any{
+			claudeMCPServerName: map[string]any{"type": "sdk", "name": claudeMCPServerName},
+		},
+	}
+	data, _ := json.Marshal(cfg)
+	return string(data)
+}
+
+// claudeStreamSession drives one stream-json exchange with the CLI.
+type claudeStreamSession struct {
+	tools []ToolDefinition
+
+	writeMu     sync.Mutex
+	stdin       io.WriteCloser
+	stdinClosed bool
+
+	sessionID string
+	text      []string
+	toolCalls []ToolCall
+	usage     claudeCliUsageInfo
+	result    *claudeStreamEvent
+	stopped   bool // a PicoClaw tool was called; the turn is handed back to the agent loop
+}
+
+func (s *claudeStreamSession) send(v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if s.stdinClosed {
+		return
+	}
+	_, _ = s.stdin.Write(append(data, '\n'))
+}
+
+func (s *claudeStreamSession) closeStdin() {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if !s.stdinClosed {
+		s.stdinClosed = true
+		_ = s.stdin.Close()
+	}
+}
+
+// read consumes stream-json events until the CLI finishes or calls a PicoClaw tool.
+// It returns a response once either happened, or an error if the output held no events.
+func (s *claudeStreamSession) read(stdout io.Reader) (*LLMResponse, error) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	sawEvent := false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var event claudeStreamEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil || event.Type == "" {
+			continue
+		}
+		sawEvent = true
+
+		switch event.Type {
+		case "system":
+			if event.SessionID != "" {
+				s.sessionID = event.SessionID
+			}
+		case "assistant":
+			s.handleAssistant(event.Message)
+		case "control_request":
+			s.handleControlRequest(event)
+		case "result":
+			if event.SessionID != "" {
+				s.sessionID = event.SessionID
+			}
+			s.result = &event
+			s.closeStdin()
+		}
+
+		if s.stopped || s.result != nil {
+			return s.response(), nil
+		}
+	}
+
+	if !sawEvent {
+		return nil, fmt.Errorf("failed to parse claude cli response: no stream-json events in output")
+	}
+	return nil, nil
+}
+
+func (s *claudeStreamSession) handleAssistant(msg *claudeStreamMessage) {
+	if msg == nil {
+		return
+	}
+	for _, block := range msg.Content {
+		switch block.Type {
+		case "text":
+			if block.Text != "" {
+				s.text = append(s.text, block.Text)
+			}
+		case "tool_use":
+			// Tools other than ours are the CLI's built-ins; it runs those itself.
+			name, ok := strings.CutPrefix(block.Name, claudeMCPToolPrefix)
+			if !ok {
+				continue
+			}
+			args := block.Input
+			if args == nil {
+				args = map[string]any{}
+			}
+			argsJSON, _ := json.Marshal(args)
+			s.toolCalls = append(s.toolCalls, ToolCall{
+				ID:        block.ID,
+				Type:      "function",
+				Name:      name,
+				Arguments: args,
+				Function: &FunctionCall{
+					Name:      name,
+					Arguments: string(argsJSON),
+				},
+			})
+		}
+	}
+	if msg.Usage != nil {
+		s.usage.InputTokens += msg.Usage.InputTokens
+		s.usage.OutputTokens += msg.Usage.OutputTokens
+		s.usage.CacheCreationInputTokens += msg.Usage.CacheCreationInputTokens
+		s.usage.CacheReadInputTokens += msg.Usage.CacheReadInputTokens
+	}
+}
+
+// handleControlRequest answers the CLI's MCP requests for the PicoClaw server.
+func (s *claudeStreamSession) handleControlRequest(event claudeStreamEvent) {
+	req := event.Request
+	if req == nil || req.Subtype != "mcp_message" || req.ServerName != claudeMCPServerName {
+		s.send(claudeControlError(event.RequestID, "unsupported control request"))
+		return
+	}
+
+	var rpc struct {
+		ID     json.RawMessage `json:"id,omitempty"`
+		Method string          `json:"method"`
+	}
+	if err := json.Unmarshal(req.Message, &rpc); err != nil {
+		s.send(claudeControlError(event.RequestID, "invalid mcp message"))
+		return
+	}
+
+	var result any
+	switch rpc.Method {
+	case "initialize":
+		result = map[string]any{
+			"protocolVersion": "2024-11-05",
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": claudeMCPServerName, "version": "1.0.0"},
+		}
+	case "tools/list":
+		result = map[string]any{"tools": claudeMCPTools(s.tools)}
+	case "tools/call":
+		// The model finished its message; PicoClaw executes the tool itself.
+		s.stopped = true
+		return
+	default:
+		result = map[string]any{}
 	}
 
-	if resp.IsError {
-		return nil, fmt.Errorf("claude cli returned error: %s", resp.Result)
+	mcpResponse := map[string]any{"jsonrpc": "2.0", "result": result}
+	if len(rpc.ID) > 0 {
+		mcpResponse["id"] = rpc.ID
 	}
+	s.send(map[string]any{
+		"type": "control_response",
+		"response": map[string]any{
+			"subtype":    "success",
+			"request_id": event.RequestID,
+			"response":   map[string]any{"mcp_response": mcpResponse},
+		},
+	})
+}
 
-	toolCalls := p.extractToolCalls(resp.Result)
+func (s *claudeStreamSession) response() *LLMResponse {
+	usage := s.usage
+	content := strings.Join(s.text, "\n")
+	if s.result != nil {
+		if s.result.Usage != nil {
+			usage = *s.result.Usage
+		}
+		if s.result.Result != "" && len(s.toolCalls) == 0 {
+			content = s.result.Result
+		}
+	}
 
 	finishReason := "stop"
-	content := resp.Result
-	if len(toolCalls) > 0 {
+	if len(s.toolCalls) > 0 {
 		finishReason = "tool_calls"
-		content = p.stripToolCallsJSON(resp.Result)
 	}
 
-	var usage *UsageInfo
-	if resp.Usage.InputTokens > 0 || resp.Usage.OutputTokens > 0 {
-		usage = &UsageInfo{
-			PromptTokens:     resp.Usage.InputTokens + resp.Usage.CacheCreationInputTokens + resp.Usage.CacheReadInputTokens,
-			CompletionTokens: resp.Usage.OutputTokens,
-			TotalTokens:      resp.Usage.InputTokens + resp.Usage.CacheCreationInputTokens + resp.Usage.CacheReadInputTokens + resp.Usage.OutputTokens,
+	var usageInfo *UsageInfo
+	if usage.InputTokens > 0 || usage.OutputTokens > 0 {
+		promptTokens := usage.InputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens
+		usageInfo = &UsageInfo{
+			PromptTokens:     promptTokens,
+			CompletionTokens: usage.OutputTokens,
+			TotalTokens:      promptTokens + usage.OutputTokens,
 		}
 	}
 
 	return &LLMResponse{
 		Content:      strings.TrimSpace(content),
-		ToolCalls:    toolCalls,
+		ToolCalls:    s.toolCalls,
 		FinishReason: finishReason,
-		Usage:        usage,
-	}, nil
+		Usage:        usageInfo,
+	}
 }
 
-// extractToolCalls delegates to the shared extractToolCallsFromText function.
-func (p *ClaudeCliProvider) extractToolCalls(text string) []ToolCall {
-	return extractToolCallsFromText(text)
+// claudeMCPTools converts tool definitions to MCP tools/list entries.
+func claudeMCPTools(tools []ToolDefinition) []map[string]any {
+	result := make([]map[string]any, 0, len(tools))
+	for _, tool := range tools {
+		if tool.Type != "function" {
+			continue
+		}
+		schema := tool.Function.Parameters
+		if len(schema) == 0 {
+			schema = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+		result = append(result, map[string]any{
+			"name":        tool.Function.Name,
+			"description": tool.Function.Description,
+			"inputSchema": schema,
+		})
+	}
+	return result
 }
 
-// stripToolCallsJSON delegates to the shared stripToolCallsFromText function.
-func (p *ClaudeCliProvider) stripToolCallsJSON(text string) string {
-	return stripToolCallsFromText(text)
+func claudeControlError(requestID, msg string) map[string]any {
+	return map[string]any{
+		"type": "control_response",
+		"response": map[string]any{
+			"subtype":    "error",
+			"request_id": requestID,
+			"error":      msg,
+		},
+	}
 }
 
-// findMatchingBrace finds the index after the closing brace matching the opening brace at pos.
-func findMatchingBrace(text string, pos int) int {
-	depth := 0
-	for i := pos; i < len(text); i++ {
-		if text[i] == '{' {
-			depth++
-		} else if text[i] == '}' {
-			depth--
-			if depth == 0 {
-				return i + 1
-			}
-		}
+func claudeUserMessage(content []claudeContentBlock) claudeStreamInput {
+	return claudeStreamInput{
+		Type:    "user",
+		Message: claudeInputMessage{Role: "user", Content: content},
 	}
-	return pos
 }
 
-// claudeCliJSONResponse represents the JSON output from the claude CLI.
-// Matches the real claude CLI v2.x output format.
-type claudeCliJSONResponse struct {
-	Type         string             `json:"type"`
-	Subtype      string             `json:"subtype"`
-	IsError      bool               `json:"is_error"`
-	Result       string             `json:"result"`
-	SessionID    string             `json:"session_id"`
-	TotalCostUSD float64            `json:"total_cost_usd"`
-	DurationMS   int                `json:"duration_ms"`
-	DurationAPI  int                `json:"duration_api_ms"`
-	NumTurns     int                `json:"num_turns"`
-	Usage        claudeCliUsageInfo `json:"usage"`
+// claudeStreamInput is one stream-json line written to the CLI's stdin.
+type claudeStreamInput struct {
+	Type    string             `json:"type"`
+	Message claudeInputMessage `json:"message"`
+}
+
+type claudeInputMessage struct {
+	Role    string               `json:"role"`
+	Content []claudeContentBlock `json:"content"`
+}
+
+// claudeContentBlock is an Anthropic-style message content block.
+type claudeContentBlock struct {
+	Type      string         `json:"type"`
+	Text      string         `json:"text,omitempty"`
+	ID        string         `json:"id,omitempty"`
+	Name      string         `json:"name,omitempty"`
+	Input     map[string]any `json:"input,omitempty"`
+	ToolUseID string         `json:"tool_use_id,omitempty"`
+	Content   string         `json:"content,omitempty"`
+}
+
+// claudeStreamEvent is one stream-json line emitted by the claude CLI.
+// Matches the real claude CLI v2.x stream-json output format.
+type claudeStreamEvent struct {
+	Type      string               `json:"type"`
+	Subtype   string               `json:"subtype,omitempty"`
+	SessionID string               `json:"session_id,omitempty"`
+	Message   *claudeStreamMessage `json:"message,omitempty"`
+
+	// result events
+	IsError      bool                `json:"is_error,omitempty"`
+	Result       string              `json:"result,omitempty"`
+	TotalCostUSD float64             `json:"total_cost_usd,omitempty"`
+	NumTurns     int                 `json:"num_turns,omitempty"`
+	Usage        *claudeCliUsageInfo `json:"usage,omitempty"`
+
+	// control_request events
+	RequestID string                `json:"request_id,omitempty"`
+	Request   *claudeControlRequest `json:"request,omitempty"`
+}
+
+type claudeStreamMessage struct {
+	Role    string               `json:"role"`
+	Content []claudeContentBlock `json:"content"`
+	Usage   *claudeCliUsageInfo  `json:"usage,omitempty"`
+}
+
+type claudeControlRequest struct {
+	Subtype    string          `json:"subtype"`
+	ServerName string          `json:"server_name,omitempty"`
+	Message    json.RawMessage `json:"message,omitempty"`
 }
 
 // claudeCliUsageInfo represents token usage from the claude CLI response.
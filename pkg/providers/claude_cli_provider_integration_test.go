@@ -91,7 +91,7 @@ func TestIntegration_RealClaudeCLI_ParsesRealJSON(t *testing.T) {
 	}
 
 	// Run claude directly and verify our parser handles real output
-	cmd := exec.Command("claude", "-p", "--output-format", "json",
+	cmd := exec.Command("claude", "-p", "--verbose", "--output-format", "stream-json",
 		"--dangerously-skip-permissions", "--no-chrome", "--no-session-persistence", "-")
 	cmd.Stdin = strings.NewReader("Say hi")
 	cmd.Dir = t.TempDir()
@@ -104,10 +104,10 @@ func TestIntegration_RealClaudeCLI_ParsesRealJSON(t *testing.T) {
 	t.Logf("Raw CLI output: %s", string(output))
 
 	// Verify our parser can handle real output
-	p := NewClaudeCliProvider("")
-	resp, err := p.parseClaudeCliResponse(string(output))
-	if err != nil {
-		t.Fatalf("parseClaudeCliResponse() failed on real CLI output: %v", err)
+	session := &claudeStreamSession{stdin: &nopWriteCloser{}}
+	resp, err := session.read(strings.NewReader(string(output)))
+	if err != nil || resp == nil {
+		t.Fatalf("stream parser failed on real CLI output: %v", err)
 	}
 
 	if resp.Content == "" {
@@ -122,3 +122,47 @@ func TestIntegration_RealClaudeCLI_ParsesRealJSON(t *testing.T) {
 
 	t.Logf("Parsed: content=%q, finish=%s, usage=%+v", resp.Content, resp.FinishReason, resp.Usage)
 }
+
+// TestIntegration_RealClaudeCLI_NativeTools checks the MCP control protocol
+// used by native_tools against a real claude CLI: the tool call must arrive
+// as a tool_use and the result must be accepted through --resume.
+func TestIntegration_RealClaudeCLI_NativeTools(t *testing.T) {
+	if _, err := exec.LookPath("claude"); err != nil {
+		t.Skip("claude CLI not found in PATH")
+	}
+
+	p := NewClaudeCliProviderWithNativeTools(t.TempDir(), true)
+	tools := []ToolDefinition{{
+		Type: "function",
+		Function: ToolFunctionDefinition{
+			Name:        "get_secret_word",
+			Description: "Returns the secret word.",
+			Parameters:  map[string]any{"type": "object", "properties": map[string]any{}},
+		},
+	}}
+	messages := []Message{{Role: "user", Content: "Call get_secret_word, then reply with only the word it returns."}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	defer cancel()
+
+	resp, err := p.Chat(ctx, messages, tools, "", nil)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Name != "get_secret_word" {
+		t.Fatalf("resp = %+v, want a get_secret_word call", resp)
+	}
+
+	messages = append(messages,
+		Message{Role: "assistant", ToolCalls: resp.ToolCalls},
+		Message{Role: "tool", ToolCallID: resp.ToolCalls[0].ID, Content: "pineapple"},
+	)
+	resp, err = p.Chat(ctx, messages, tools, "", nil)
+	if err != nil {
+		t.Fatalf("Chat() resume error = %v", err)
+	}
+	t.Logf("Response: %q", resp.Content)
+	if !strings.Contains(strings.ToLower(resp.Content), "pineapple") {
+		t.Errorf("Content = %q, expected the tool result to reach the resumed session", resp.Content)
+	}
+}
@@ -2,6 +2,7 @@ package providers
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -182,33 +183,6 @@ func TestChat_IsErrorResponse(t *testing.T) {
 	}
 }
 
-func TestChat_WithToolCallsInResponse(t *testing.T) {
-	mockJSON := `{"type":"result","subtype":"success","is_error":false,"result":"Checking weather.\n{\"tool_calls\":[{\"id\":\"call_1\",\"type\":\"function\",\"function\":{\"name\":\"get_weather\",\"arguments\":\"{\\\"location\\\":\\\"NYC\\\"}\"}}]}","session_id":"s1","total_cost_usd":0.01,"usage":{"input_tokens":5,"output_tokens":20,"cache_creation_input_tokens":0,"cache_read_input_tokens":0}}`
-	script := createMockCLI(t, mockJSON, "", 0)
-
-	p := NewClaudeCliProvider(t.TempDir())
-	p.command = script
-
-	resp, err := p.Chat(context.Background(), []Message{
-		{Role: "user", Content: "What's the weather?"},
-	}, nil, "", nil)
-	if err != nil {
-		t.Fatalf("Chat() error = %v", err)
-	}
-	if resp.FinishReason != "tool_calls" {
-		t.Errorf("FinishReason = %q, want %q", resp.FinishReason, "tool_calls")
-	}
-	if len(resp.ToolCalls) != 1 {
-		t.Fatalf("ToolCalls len = %d, want 1", len(resp.ToolCalls))
-	}
-	if resp.ToolCalls[0].Name != "get_weather" {
-		t.Errorf("ToolCalls[0].Name = %q, want %q", resp.ToolCalls[0].Name, "get_weather")
-	}
-	if resp.ToolCalls[0].Arguments["location"] != "NYC" {
-		t.Errorf("ToolCalls[0].Arguments[location] = %v, want NYC", resp.ToolCalls[0].Arguments["location"])
-	}
-}
-
 func TestChat_StderrError(t *testing.T) {
 	script := createMockCLI(t, "", "Error: rate limited", 1)
 
@@ -414,7 +388,7 @@ func TestChat_EmptyWorkspaceDoesNotSetDir(t *testing.T) {
 func TestCreateProvider_ClaudeCli(t *testing.T) {
 	cfg := config.DefaultConfig()
 	cfg.ModelList = []config.ModelConfig{
-		{ModelName: "claude-sonnet-4.6", Model: "claude-cli/claude-sonnet-4.6", Workspace: "/test/ws"},
+		{ModelName: "claude-sonnet-4.6", Model: "claude-cli/claude-sonnet-4.6", Workspace: "/test/ws", NativeTools: true},
 	}
 	cfg.Agents.Defaults.Model = "claude-sonnet-4.6"
 
@@ -430,6 +404,9 @@ func TestCreateProvider_ClaudeCli(t *testing.T) {
 	if cliProvider.workspace != "/test/ws" {
 		t.Errorf("workspace = %q, want %q", cliProvider.workspace, "/test/ws")
 	}
+	if !cliProvider.nativeTools {
+		t.Error("native_tools should enable native tool calls")
+	}
 }
 
 func TestCreateProvider_ClaudeCode(t *testing.T) {
@@ -484,6 +461,9 @@ func TestCreateProvider_ClaudeCliDefaultWorkspace(t *testing.T) {
 	if cliProvider.workspace != "." {
 		t.Errorf("workspace = %q, want %q (default)", cliProvider.workspace, ".")
 	}
+	if cliProvider.nativeTools {
+		t.Error("native tool calls should be off by default")
+	}
 }
 
 // --- messagesToPrompt tests ---
@@ -702,81 +682,79 @@ func TestBuildToolsPrompt_NoParameters(t *testing.T) {
 	}
 }
 
-// --- parseClaudeCliResponse tests ---
-
-func TestParseClaudeCliResponse_TextOnly(t *testing.T) {
-	p := NewClaudeCliProvider("/workspace")
-	output := `{"type":"result","subtype":"success","is_error":false,"result":"Hello, world!","session_id":"abc123","total_cost_usd":0.01,"duration_ms":500,"usage":{"input_tokens":10,"output_tokens":20,"cache_creation_input_tokens":0,"cache_read_input_tokens":0}}`
+// --- claudeMCPTools tests ---
 
-	resp, err := p.parseClaudeCliResponse(output)
-	if err != nil {
-		t.Fatalf("parseClaudeCliResponse() error = %v", err)
-	}
-	if resp.Content != "Hello, world!" {
-		t.Errorf("Content = %q, want %q", resp.Content, "Hello, world!")
-	}
-	if resp.FinishReason != "stop" {
-		t.Errorf("FinishReason = %q, want %q", resp.FinishReason, "stop")
-	}
-	if len(resp.ToolCalls) != 0 {
-		t.Errorf("ToolCalls = %d, want 0", len(resp.ToolCalls))
+func TestClaudeMCPTools_SkipsNonFunction(t *testing.T) {
+	tools := []ToolDefinition{
+		{Type: "other", Function: ToolFunctionDefinition{Name: "skip_me"}},
+		{Type: "function", Function: ToolFunctionDefinition{Name: "include_me", Description: "Included"}},
 	}
-	if resp.Usage == nil {
-		t.Fatal("Usage should not be nil")
+	got := claudeMCPTools(tools)
+	if len(got) != 1 {
+		t.Fatalf("claudeMCPTools() = %d tools, want 1", len(got))
 	}
-	if resp.Usage.PromptTokens != 10 {
-		t.Errorf("PromptTokens = %d, want 10", resp.Usage.PromptTokens)
+	if got[0]["name"] != "include_me" {
+		t.Errorf("name = %v, want include_me", got[0]["name"])
 	}
-	if resp.Usage.CompletionTokens != 20 {
-		t.Errorf("CompletionTokens = %d, want 20", resp.Usage.CompletionTokens)
+	schema, ok := got[0]["inputSchema"].(map[string]any)
+	if !ok || schema["type"] != "object" {
+		t.Errorf("inputSchema = %v, want an object schema for tools without parameters", got[0]["inputSchema"])
 	}
 }
 
-func TestParseClaudeCliResponse_EmptyResult(t *testing.T) {
-	p := NewClaudeCliProvider("/workspace")
-	output := `{"type":"result","subtype":"success","is_error":false,"result":"","session_id":"abc"}`
+// --- stream-json parsing tests ---
+
+type nopWriteCloser struct{ strings.Builder }
+
+func (w *nopWriteCloser) Close() error { return nil }
+
+// readClaudeStream feeds stream-json output through a session and returns the
+// response and whatever the session wrote back to the CLI.
+func readClaudeStream(t *testing.T, output string, tools []ToolDefinition) (*LLMResponse, string, error) {
+	t.Helper()
+	stdin := &nopWriteCloser{}
+	s := &claudeStreamSession{stdin: stdin, tools: tools}
+	resp, err := s.read(strings.NewReader(output))
+	return resp, stdin.String(), err
+}
+
+func TestClaudeStream_TextOnly(t *testing.T) {
+	output := `{"type":"system","subtype":"init","session_id":"abc123"}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Hello, world!"}]}}
+{"type":"result","subtype":"success","is_error":false,"result":"Hello, world!","session_id":"abc123","usage":{"input_tokens":10,"output_tokens":20,"cache_creation_input_tokens":0,"cache_read_input_tokens":0}}`
 
-	resp, err := p.parseClaudeCliResponse(output)
+	resp, _, err := readClaudeStream(t, output, nil)
 	if err != nil {
-		t.Fatalf("error = %v", err)
+		t.Fatalf("read() error = %v", err)
 	}
-	if resp.Content != "" {
-		t.Errorf("Content = %q, want empty", resp.Content)
+	if resp.Content != "Hello, world!" {
+		t.Errorf("Content = %q, want %q", resp.Content, "Hello, world!")
 	}
 	if resp.FinishReason != "stop" {
 		t.Errorf("FinishReason = %q, want %q", resp.FinishReason, "stop")
 	}
-}
-
-func TestParseClaudeCliResponse_IsError(t *testing.T) {
-	p := NewClaudeCliProvider("/workspace")
-	output := `{"type":"result","subtype":"error","is_error":true,"result":"Something went wrong","session_id":"abc"}`
-
-	_, err := p.parseClaudeCliResponse(output)
-	if err == nil {
-		t.Fatal("expected error when is_error=true")
-	}
-	if !strings.Contains(err.Error(), "Something went wrong") {
-		t.Errorf("error = %q, want to contain 'Something went wrong'", err.Error())
+	if resp.Usage == nil || resp.Usage.PromptTokens != 10 || resp.Usage.CompletionTokens != 20 {
+		t.Errorf("Usage = %+v, want 10 prompt / 20 completion tokens", resp.Usage)
 	}
 }
 
-func TestParseClaudeCliResponse_NoUsage(t *testing.T) {
-	p := NewClaudeCliProvider("/workspace")
-	output := `{"type":"result","subtype":"success","is_error":false,"result":"hi","session_id":"s"}`
+func TestClaudeStream_WhitespaceResult(t *testing.T) {
+	output := `{"type":"result","subtype":"success","is_error":false,"result":"  hello  \n  ","session_id":"s"}`
 
-	resp, err := p.parseClaudeCliResponse(output)
+	resp, _, err := readClaudeStream(t, output, nil)
 	if err != nil {
-		t.Fatalf("error = %v", err)
+		t.Fatalf("read() error = %v", err)
+	}
+	if resp.Content != "hello" {
+		t.Errorf("Content = %q, want %q (should be trimmed)", resp.Content, "hello")
 	}
 	if resp.Usage != nil {
 		t.Errorf("Usage should be nil when no tokens, got %+v", resp.Usage)
 	}
 }
 
-func TestParseClaudeCliResponse_InvalidJSON(t *testing.T) {
-	p := NewClaudeCliProvider("/workspace")
-	_, err := p.parseClaudeCliResponse("not json")
+func TestClaudeStream_InvalidJSON(t *testing.T) {
+	_, _, err := readClaudeStream(t, "not json", nil)
 	if err == nil {
 		t.Fatal("expected error for invalid JSON")
 	}
@@ -785,70 +763,280 @@ func TestParseClaudeCliResponse_InvalidJSON(t *testing.T) {
 	}
 }
 
-func TestParseClaudeCliResponse_WithToolCalls(t *testing.T) {
-	p := NewClaudeCliProvider("/workspace")
-	output := `{"type":"result","subtype":"success","is_error":false,"result":"Let me check.\n{\"tool_calls\":[{\"id\":\"call_1\",\"type\":\"function\",\"function\":{\"name\":\"get_weather\",\"arguments\":\"{\\\"location\\\":\\\"Tokyo\\\"}\"}}]}","session_id":"abc123","total_cost_usd":0.01}`
-
-	resp, err := p.parseClaudeCliResponse(output)
+func TestClaudeStream_ToolUse(t *testing.T) {
+	tools := []ToolDefinition{{
+		Type: "function",
+		Function: ToolFunctionDefinition{
+			Name:        "get_weather",
+			Description: "Get weather for a location",
+			Parameters:  map[string]any{"type": "object"},
+		},
+	}}
+	output := `{"type":"system","subtype":"init","session_id":"sess-1"}
+{"type":"control_request","request_id":"req-1","request":{"subtype":"mcp_message","server_name":"picoclaw","message":{"jsonrpc":"2.0","id":1,"method":"tools/list"}}}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Let me check."},{"type":"tool_use","id":"toolu_1","name":"mcp__picoclaw__get_weather","input":{"location":"Tokyo"}},{"type":"tool_use","id":"toolu_2","name":"Bash","input":{"command":"ls"}}],"usage":{"input_tokens":7,"output_tokens":3}}}
+{"type":"control_request","request_id":"req-2","request":{"subtype":"mcp_message","server_name":"picoclaw","message":{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"get_weather"}}}}
+{"type":"result","subtype":"success","is_error":false,"result":"should not be reached"}`
+
+	resp, written, err := readClaudeStream(t, output, tools)
 	if err != nil {
-		t.Fatalf("error = %v", err)
+		t.Fatalf("read() error = %v", err)
 	}
 	if resp.FinishReason != "tool_calls" {
 		t.Errorf("FinishReason = %q, want %q", resp.FinishReason, "tool_calls")
 	}
 	if len(resp.ToolCalls) != 1 {
-		t.Fatalf("ToolCalls = %d, want 1", len(resp.ToolCalls))
+		t.Fatalf("ToolCalls = %d, want 1 (built-in CLI tools must be ignored)", len(resp.ToolCalls))
 	}
 	tc := resp.ToolCalls[0]
-	if tc.Name != "get_weather" {
-		t.Errorf("Name = %q, want %q", tc.Name, "get_weather")
-	}
-	if tc.Function == nil {
-		t.Fatal("Function is nil")
-	}
-	if tc.Function.Name != "get_weather" {
-		t.Errorf("Function.Name = %q, want %q", tc.Function.Name, "get_weather")
+	if tc.ID != "toolu_1" || tc.Name != "get_weather" {
+		t.Errorf("ToolCall = %s/%s, want toolu_1/get_weather", tc.ID, tc.Name)
 	}
 	if tc.Arguments["location"] != "Tokyo" {
 		t.Errorf("Arguments[location] = %v, want Tokyo", tc.Arguments["location"])
 	}
-	if strings.Contains(resp.Content, "tool_calls") {
-		t.Errorf("Content should not contain tool_calls JSON, got %q", resp.Content)
+	if tc.Function == nil || tc.Function.Arguments != `{"location":"Tokyo"}` {
+		t.Errorf("Function = %+v, want JSON-encoded arguments", tc.Function)
 	}
 	if resp.Content != "Let me check." {
 		t.Errorf("Content = %q, want %q", resp.Content, "Let me check.")
 	}
+	if resp.Usage == nil || resp.Usage.TotalTokens != 10 {
+		t.Errorf("Usage = %+v, want 10 total tokens", resp.Usage)
+	}
+
+	// The tools/list request must be answered with our tool schema.
+	var reply struct {
+		Type     string `json:"type"`
+		Response struct {
+			RequestID string `json:"request_id"`
+			Response  struct {
+				MCPResponse struct {
+					ID     int `json:"id"`
+					Result struct {
+						Tools []struct {
+							Name string `json:"name"`
+						} `json:"tools"`
+					} `json:"result"`
+				} `json:"mcp_response"`
+			} `json:"response"`
+		} `json:"response"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(written)), &reply); err != nil {
+		t.Fatalf("control response is not a single JSON line: %q (%v)", written, err)
+	}
+	if reply.Type != "control_response" || reply.Response.RequestID != "req-1" {
+		t.Errorf("control response = %+v, want control_response for req-1", reply)
+	}
+	mcp := reply.Response.Response.MCPResponse
+	if mcp.ID != 1 || len(mcp.Result.Tools) != 1 || mcp.Result.Tools[0].Name != "get_weather" {
+		t.Errorf("mcp_response = %+v, want tools/list result with get_weather", mcp)
+	}
 }
 
-func TestParseClaudeCliResponse_WhitespaceResult(t *testing.T) {
-	p := NewClaudeCliProvider("/workspace")
-	output := `{"type":"result","subtype":"success","is_error":false,"result":"  hello  \n  ","session_id":"s"}`
+// --- Native tool calling with a fake CLI ---
+
+// createToolCallingCLI creates a fake claude CLI. A fresh run lists tools,
+// calls get_weather and then waits for a result that never comes over stdin.
+// A --resume run records the input it receives and finishes the turn.
+func createToolCallingCLI(t *testing.T) (script, dir string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("mock CLI scripts not supported on Windows")
+	}
+
+	dir = t.TempDir()
+	script = filepath.Join(dir, "claude")
+	content := fmt.Sprintf(`#!/bin/sh
+echo "$@" >> '%[1]s/args.txt'
+case "$*" in
+*--resume*)
+	read -r line
+	echo "$line" > '%[1]s/resume-input.txt'
+	cat <<'EOFMOCK'
+{"type":"system","subtype":"init","session_id":"sess-42"}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"It is sunny in NYC."}]}}
+{"type":"result","subtype":"success","is_error":false,"result":"It is sunny in NYC.","session_id":"sess-42","usage":{"input_tokens":30,"output_tokens":6,"cache_creation_input_tokens":0,"cache_read_input_tokens":0}}
+EOFMOCK
+	;;
+*)
+	cat <<'EOFMOCK'
+{"type":"system","subtype":"init","session_id":"sess-42"}
+{"type":"control_request","request_id":"req-1","request":{"subtype":"mcp_message","server_name":"picoclaw","message":{"jsonrpc":"2.0","id":1,"method":"tools/list"}}}
+EOFMOCK
+	read -r first
+	read -r second
+	printf '%%s\n%%s\n' "$first" "$second" > '%[1]s/first-input.txt'
+	cat <<'EOFMOCK'
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","id":"toolu_1","name":"mcp__picoclaw__get_weather","input":{"location":"NYC"}}],"usage":{"input_tokens":12,"output_tokens":4}}}
+{"type":"control_request","request_id":"req-2","request":{"subtype":"mcp_message","server_name":"picoclaw","message":{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"get_weather","arguments":{"location":"NYC"}}}}}
+EOFMOCK
+	while read -r line; do :; done
+	;;
+esac
+`, dir)
+	if err := os.WriteFile(script, []byte(content), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return script, dir
+}
 
-	resp, err := p.parseClaudeCliResponse(output)
+func TestChat_NativeToolCallAndResume(t *testing.T) {
+	script, dir := createToolCallingCLI(t)
+	p := NewClaudeCliProviderWithNativeTools(t.TempDir(), true)
+	p.command = script
+
+	tools := []ToolDefinition{{
+		Type: "function",
+		Function: ToolFunctionDefinition{
+			Name:        "get_weather",
+			Description: "Get weather for a location",
+			Parameters: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"location": map[string]any{"type": "string"}},
+			},
+		},
+	}}
+	messages := []Message{{Role: "user", Content: "What's the weather in NYC?"}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resp, err := p.Chat(ctx, messages, tools, "", nil)
 	if err != nil {
-		t.Fatalf("error = %v", err)
+		t.Fatalf("Chat() error = %v", err)
 	}
-	if resp.Content != "hello" {
-		t.Errorf("Content = %q, want %q (should be trimmed)", resp.Content, "hello")
+	if resp.FinishReason != "tool_calls" || len(resp.ToolCalls) != 1 {
+		t.Fatalf("resp = %+v, want one tool call", resp)
+	}
+	if resp.ToolCalls[0].Name != "get_weather" || resp.ToolCalls[0].Arguments["location"] != "NYC" {
+		t.Errorf("ToolCalls[0] = %+v, want get_weather(NYC)", resp.ToolCalls[0])
+	}
+
+	firstInput, _ := os.ReadFile(filepath.Join(dir, "first-input.txt"))
+	if !strings.Contains(string(firstInput), "What's the weather in NYC?") {
+		t.Errorf("prompt not sent as a stream-json user message, stdin was: %s", firstInput)
+	}
+
+	messages = append(messages,
+		Message{Role: "assistant", ToolCalls: resp.ToolCalls},
+		Message{Role: "tool", ToolCallID: resp.ToolCalls[0].ID, Content: "Sunny, 25C"},
+	)
+	resp, err = p.Chat(ctx, messages, tools, "", nil)
+	if err != nil {
+		t.Fatalf("Chat() resume error = %v", err)
+	}
+	if resp.Content != "It is sunny in NYC." || resp.FinishReason != "stop" {
+		t.Errorf("resp = %+v, want final answer", resp)
+	}
+
+	args, _ := os.ReadFile(filepath.Join(dir, "args.txt"))
+	if !strings.Contains(string(args), "--resume sess-42") {
+		t.Errorf("second call should resume the CLI session, args:\n%s", args)
+	}
+
+	var input claudeStreamInput
+	resumeInput, _ := os.ReadFile(filepath.Join(dir, "resume-input.txt"))
+	if err := json.Unmarshal(resumeInput, &input); err != nil {
+		t.Fatalf("resume input is not stream-json: %q (%v)", resumeInput, err)
+	}
+	if len(input.Message.Content) != 1 {
+		t.Fatalf("resume content = %+v, want one tool_result block", input.Message.Content)
+	}
+	block := input.Message.Content[0]
+	if block.Type != "tool_result" || block.ToolUseID != "toolu_1" || block.Content != "Sunny, 25C" {
+		t.Errorf("resume block = %+v, want tool_result for toolu_1", block)
+	}
+}
+
+// --- Prompt-based tool calls (the default) ---
+
+func TestChat_PromptToolCalls(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("mock CLI scripts not supported on Windows")
+	}
+	dir := t.TempDir()
+	script := filepath.Join(dir, "claude")
+	content := fmt.Sprintf(`#!/bin/sh
+echo "$@" > '%s/args.txt'
+cat <<'EOFMOCK'
+{"type":"system","subtype":"init","session_id":"s1"}
+{"type":"result","subtype":"success","is_error":false,"result":"Checking weather.\n{\"tool_calls\":[{\"id\":\"call_1\",\"type\":\"function\",\"function\":{\"name\":\"get_weather\",\"arguments\":\"{\\\"location\\\":\\\"NYC\\\"}\"}}]}","session_id":"s1","usage":{"input_tokens":5,"output_tokens":20,"cache_creation_input_tokens":0,"cache_read_input_tokens":0}}
+EOFMOCK
+`, dir)
+	if err := os.WriteFile(script, []byte(content), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewClaudeCliProvider(t.TempDir())
+	p.command = script
+	tools := []ToolDefinition{{
+		Type:     "function",
+		Function: ToolFunctionDefinition{Name: "get_weather", Description: "Get weather for a location"},
+	}}
+
+	resp, err := p.Chat(context.Background(), []Message{{Role: "user", Content: "What's the weather?"}}, tools, "", nil)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if resp.FinishReason != "tool_calls" || len(resp.ToolCalls) != 1 {
+		t.Fatalf("resp = %+v, want one tool call", resp)
+	}
+	if resp.ToolCalls[0].Name != "get_weather" || resp.ToolCalls[0].Arguments["location"] != "NYC" {
+		t.Errorf("ToolCalls[0] = %+v, want get_weather(NYC)", resp.ToolCalls[0])
+	}
+	if resp.Content != "Checking weather." {
+		t.Errorf("Content = %q, want the text without the tool call JSON", resp.Content)
+	}
+
+	args, _ := os.ReadFile(filepath.Join(dir, "args.txt"))
+	if strings.Contains(string(args), "--mcp-config") || !strings.Contains(string(args), "Available Tools") {
+		t.Errorf("tools should be described in the system prompt, not served over MCP, args:\n%s", args)
+	}
+	if len(p.sessions.calls) != 0 {
+		t.Errorf("prompt-based tool calls should not be kept for --resume: %v", p.sessions.calls)
+	}
+}
+
+func TestCliSessions_EvictsOldest(t *testing.T) {
+	var s cliSessions
+	s.remember([]ToolCall{{ID: "old"}}, "sess-old")
+	for i := range maxPendingCLISessions - 1 {
+		s.remember([]ToolCall{{ID: fmt.Sprintf("call-%d", i)}}, "sess-busy")
+	}
+	s.remember([]ToolCall{{ID: "new"}}, "sess-new")
+
+	if len(s.calls) != maxPendingCLISessions {
+		t.Errorf("pending calls = %d, want %d", len(s.calls), maxPendingCLISessions)
+	}
+	if got := s.take([]Message{{Role: "tool", ToolCallID: "old"}}); got != "" {
+		t.Errorf("oldest call should have been evicted, got session %q", got)
+	}
+	if got := s.take([]Message{{Role: "tool", ToolCallID: "call-0"}}); got != "sess-busy" {
+		t.Errorf("call-0 session = %q, want sess-busy", got)
+	}
+	if got := s.take([]Message{{Role: "tool", ToolCallID: "new"}, {Role: "tool", ToolCallID: "call-1"}}); got != "sess-new" {
+		t.Errorf("new session = %q, want sess-new", got)
+	}
+	if _, ok := s.calls["call-1"]; ok {
+		t.Error("take should forget every result's tool call")
 	}
 }
 
 // --- extractToolCalls tests ---
 
 func TestExtractToolCalls_NoToolCalls(t *testing.T) {
-	p := NewClaudeCliProvider("/workspace")
-	got := p.extractToolCalls("Just a regular response.")
+	got := extractToolCallsFromText("Just a regular response.")
 	if len(got) != 0 {
 		t.Errorf("extractToolCalls() = %d, want 0", len(got))
 	}
 }
 
 func TestExtractToolCalls_WithToolCalls(t *testing.T) {
-	p := NewClaudeCliProvider("/workspace")
 	text := `Here's the result:
 {"tool_calls":[{"id":"call_1","type":"function","function":{"name":"test","arguments":"{}"}}]}`
 
-	got := p.extractToolCalls(text)
+	got := extractToolCallsFromText(text)
 	if len(got) != 1 {
 		t.Fatalf("extractToolCalls() = %d, want 1", len(got))
 	}
@@ -864,18 +1052,16 @@ func TestExtractToolCalls_WithToolCalls(t *testing.T) {
 }
 
 func TestExtractToolCalls_InvalidJSON(t *testing.T) {
-	p := NewClaudeCliProvider("/workspace")
-	got := p.extractToolCalls(`{"tool_calls":invalid}`)
+	got := extractToolCallsFromText(`{"tool_calls":invalid}`)
 	if len(got) != 0 {
 		t.Errorf("extractToolCalls() with invalid JSON = %d, want 0", len(got))
 	}
 }
 
 func TestExtractToolCalls_MultipleToolCalls(t *testing.T) {
-	p := NewClaudeCliProvider("/workspace")
 	text := `{"tool_calls":[{"id":"call_1","type":"function","function":{"name":"read_file","arguments":"{\"path\":\"/tmp/test\"}"}},{"id":"call_2","type":"function","function":{"name":"write_file","arguments":"{\"path\":\"/tmp/out\",\"content\":\"hello\"}"}}]}`
 
-	got := p.extractToolCalls(text)
+	got := extractToolCallsFromText(text)
 	if len(got) != 2 {
 		t.Fatalf("extractToolCalls() = %d, want 2", len(got))
 	}
@@ -895,18 +1081,16 @@ func TestExtractToolCalls_MultipleToolCalls(t *testing.T) {
 }
 
 func TestExtractToolCalls_UnmatchedBrace(t *testing.T) {
-	p := NewClaudeCliProvider("/workspace")
-	got := p.extractToolCalls(`{"tool_calls":[{"id":"call_1"`)
+	got := extractToolCallsFromText(`{"tool_calls":[{"id":"call_1"`)
 	if len(got) != 0 {
 		t.Errorf("extractToolCalls() with unmatched brace = %d, want 0", len(got))
 	}
 }
 
 func TestExtractToolCalls_ToolCallArgumentsParsing(t *testing.T) {
-	p := NewClaudeCliProvider("/workspace")
 	text := `{"tool_calls":[{"id":"c1","type":"function","function":{"name":"fn","arguments":"{\"num\":42,\"flag\":true,\"name\":\"test\"}"}}]}`
 
-	got := p.extractToolCalls(text)
+	got := extractToolCallsFromText(text)
 	if len(got) != 1 {
 		t.Fatalf("len = %d, want 1", len(got))
 	}
@@ -929,12 +1113,11 @@ func TestExtractToolCalls_ToolCallArgumentsParsing(t *testing.T) {
 // --- stripToolCallsJSON tests ---
 
 func TestStripToolCallsJSON(t *testing.T) {
-	p := NewClaudeCliProvider("/workspace")
 	text := `Let me check the weather.
 {"tool_calls":[{"id":"call_1","type":"function","function":{"name":"test","arguments":"{}"}}]}
 Done.`
 
-	got := p.stripToolCallsJSON(text)
+	got := stripToolCallsFromText(text)
 	if strings.Contains(got, "tool_calls") {
 		t.Errorf("should remove tool_calls JSON, got %q", got)
 	}
@@ -947,18 +1130,16 @@ Done.`
 }
 
 func TestStripToolCallsJSON_NoToolCalls(t *testing.T) {
-	p := NewClaudeCliProvider("/workspace")
 	text := "Just regular text."
-	got := p.stripToolCallsJSON(text)
+	got := stripToolCallsFromText(text)
 	if got != text {
 		t.Errorf("stripToolCallsJSON() = %q, want %q", got, text)
 	}
 }
 
 func TestStripToolCallsJSON_OnlyToolCalls(t *testing.T) {
-	p := NewClaudeCliProvider("/workspace")
 	text := `{"tool_calls":[{"id":"c1","type":"function","function":{"name":"fn","arguments":"{}"}}]}`
-	got := p.stripToolCallsJSON(text)
+	got := stripToolCallsFromText(text)
 	if got != "" {
 		t.Errorf("stripToolCallsJSON() = %q, want empty", got)
 	}
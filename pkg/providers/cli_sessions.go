@@ -0,0 +1,69 @@
+package providers
+
+import "sync"
+
+// maxPendingCLISessions bounds the tool-call -> CLI session map so abandoned
+// tool calls (e.g. a turn that hit max iterations) cannot grow it forever.
+const maxPendingCLISessions = 1024
+
+// cliSessions remembers which CLI session (claude session or codex thread)
+// issued each tool call, so the results can be sent back to it. When full,
+// the oldest tool calls are forgotten first. The zero value is ready to use.
+type cliSessions struct {
+	mu      sync.Mutex
+	calls   map[string]cliSessionEntry
+	counter uint64
+}
+
+type cliSessionEntry struct {
+	sessionID string
+	seq       uint64 // Insertion order, for evicting the oldest entry
+}
+
+// remember records sessionID as the issuer of toolCalls.
+func (s *cliSessions) remember(toolCalls []ToolCall, sessionID string) {
+	if len(toolCalls) == 0 || sessionID == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.calls == nil {
+		s.calls = make(map[string]cliSessionEntry)
+	}
+	for _, tc := range toolCalls {
+		if _, ok := s.calls[tc.ID]; !ok && len(s.calls) >= maxPendingCLISessions {
+			s.evictOldestUnsafe()
+		}
+		s.counter++
+		s.calls[tc.ID] = cliSessionEntry{sessionID: sessionID, seq: s.counter}
+	}
+}
+
+// take returns the session that issued the first of results and forgets
+// all of them. It returns "" if the first was not issued by a known session.
+func (s *cliSessions) take(results []Message) string {
+	if len(results) == 0 {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.calls[results[0].ToolCallID]
+	if !ok {
+		return ""
+	}
+	for _, msg := range results {
+		delete(s.calls, msg.ToolCallID)
+	}
+	return entry.sessionID
+}
+
+func (s *cliSessions) evictOldestUnsafe() {
+	var oldest string
+	first := true
+	for id, entry := range s.calls {
+		if first || entry.seq < s.calls[oldest].seq {
+			oldest, first = id, false
+		}
+	}
+	delete(s.calls, oldest)
+}
@@ -6,14 +6,26 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+
+	"github.com/google/uuid"
 )
 
 // CodexCliProvider implements LLMProvider by wrapping the codex CLI as a subprocess.
+//
+// The codex CLI cannot register external tools, so these are not native tool
+// calls: when tools are offered they are described in the prompt and the CLI
+// is run with --output-schema so the final agent message is a JSON object
+// with "content" and "tool_calls" fields. Tool results are sent back by
+// resuming the same codex thread.
 type CodexCliProvider struct {
 	command   string
 	workspace string
+
+	threads cliSessions // tool call ID -> codex thread ID awaiting its result
 }
 
 // NewCodexCliProvider creates a new Codex CLI provider.
@@ -32,7 +44,10 @@ func (p *CodexCliProvider) Chat(
 		return nil, fmt.Errorf("codex command not configured")
 	}
 
-	prompt := p.buildPrompt(messages, tools)
+	threadID, prompt := p.pendingToolResults(messages)
+	if threadID == "" {
+		prompt = p.buildPrompt(messages, tools)
+	}
 
 	args := []string{
 		"exec",
@@ -47,6 +62,18 @@ func (p *CodexCliProvider) Chat(
 	if p.workspace != "" {
 		args = append(args, "-C", p.workspace)
 	}
+	structured := len(tools) > 0
+	if structured {
+		schemaPath, cleanup, err := writeCodexReplySchema()
+		if err != nil {
+			return nil, fmt.Errorf("codex cli: writing output schema: %w", err)
+		}
+		defer cleanup()
+		args = append(args, "--output-schema", schemaPath)
+	}
+	if threadID != "" {
+		args = append(args, "resume", threadID)
+	}
 	args = append(args, "-") // read prompt from stdin
 
 	cmd := exec.CommandContext(ctx, p.command, args...)
@@ -63,8 +90,8 @@ func (p *CodexCliProvider) Chat(
 	// but still produces valid JSONL output.
 	if stdoutStr := stdout.String(); stdoutStr != "" {
 		resp, parseErr := p.parseJSONLEvents(stdoutStr)
-		if parseErr == nil && resp != nil && (resp.Content != "" || len(resp.ToolCalls) > 0) {
-			return resp, nil
+		if parseErr == nil && resp != nil && resp.Content != "" {
+			return p.finishResponse(resp, stdoutStr, structured)
 		}
 	}
 
@@ -78,7 +105,82 @@ func (p *CodexCliProvider) Chat(
 		return nil, fmt.Errorf("codex cli error: %w", err)
 	}
 
-	return p.parseJSONLEvents(stdout.String())
+	resp, err := p.parseJSONLEvents(stdout.String())
+	if err != nil {
+		return nil, err
+	}
+	return p.finishResponse(resp, stdout.String(), structured)
+}
+
+// finishResponse decodes a structured reply into content and tool calls and
+// records the thread that issued them so their results can resume it.
+func (p *CodexCliProvider) finishResponse(resp *LLMResponse, output string, structured bool) (*LLMResponse, error) {
+	if !structured || resp.Content == "" {
+		return resp, nil
+	}
+
+	reply, err := decodeCodexReply(resp.Content)
+	if err != nil {
+		// The model ignored the schema; treat the message as plain text.
+		return resp, nil
+	}
+
+	resp.Content = strings.TrimSpace(reply.Content)
+	resp.ToolCalls = nil
+	for _, call := range reply.ToolCalls {
+		if call.Name == "" {
+			continue
+		}
+		args := map[string]any{}
+		if call.Arguments != "" {
+			if err := json.Unmarshal([]byte(call.Arguments), &args); err != nil {
+				// Hand the raw text to the tool, whose validation error
+				// lets the model retry instead of failing the turn.
+				args = map[string]any{"raw": call.Arguments}
+			}
+		}
+		argsJSON, _ := json.Marshal(args)
+		resp.ToolCalls = append(resp.ToolCalls, ToolCall{
+			ID:        "call_" + strings.ReplaceAll(uuid.NewString(), "-", "")[:16],
+			Type:      "function",
+			Name:      call.Name,
+			Arguments: args,
+			Function: &FunctionCall{
+				Name:      call.Name,
+				Arguments: string(argsJSON),
+			},
+		})
+	}
+	if len(resp.ToolCalls) > 0 {
+		resp.FinishReason = "tool_calls"
+		p.threads.remember(resp.ToolCalls, codexThreadID(output))
+	}
+	return resp, nil
+}
+
+// pendingToolResults returns the codex thread to resume and a prompt carrying
+// the tool results when the conversation ends with results for tool calls
+// this provider issued. Otherwise it returns an empty thread ID.
+func (p *CodexCliProvider) pendingToolResults(messages []Message) (string, string) {
+	start := len(messages)
+	for start > 0 && messages[start-1].Role == "tool" {
+		start--
+	}
+	if start == len(messages) {
+		return "", ""
+	}
+
+	threadID := p.threads.take(messages[start:])
+	if threadID == "" {
+		return "", ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Tool results:\n")
+	for _, msg := range messages[start:] {
+		sb.WriteString(fmt.Sprintf("\n[Tool Result for %s]: %s\n", msg.ToolCallID, msg.Content))
+	}
+	return threadID, sb.String()
 }
 
 // GetDefaultModel returns the default model identifier.
@@ -133,13 +235,9 @@ func (p *CodexCliProvider) buildToolsPrompt(tools []ToolDefinition) string {
 	var sb strings.Builder
 
 	sb.WriteString("## Available Tools\n\n")
-	sb.WriteString("When you need to use a tool, respond with ONLY a JSON object:\n\n")
-	sb.WriteString("```json\n")
-	sb.WriteString(
-		`{"tool_calls":[{"id":"call_xxx","type":"function","function":{"name":"tool_name","arguments":"{...}"}}]}`,
-	)
-	sb.WriteString("\n```\n\n")
-	sb.WriteString("CRITICAL: The 'arguments' field MUST be a JSON-encoded STRING.\n\n")
+	sb.WriteString("These tools are executed by the host, not by you. To use them, list the calls in the ")
+	sb.WriteString("`tool_calls` field of your reply, with `arguments` as a JSON-encoded object matching ")
+	sb.WriteString("the tool's parameters. Leave `tool_calls` empty when you are done.\n\n")
 	sb.WriteString("### Tool Definitions:\n\n")
 
 	for _, tool := range tools {
@@ -237,19 +335,79 @@ func (p *CodexCliProvider) parseJSONLEvents(output string) (*LLMResponse, error)
 
 	content := strings.Join(contentParts, "\n")
 
-	// Extract tool calls from response text (same pattern as ClaudeCliProvider)
-	toolCalls := extractToolCallsFromText(content)
-
-	finishReason := "stop"
-	if len(toolCalls) > 0 {
-		finishReason = "tool_calls"
-		content = stripToolCallsFromText(content)
-	}
-
 	return &LLMResponse{
 		Content:      strings.TrimSpace(content),
-		ToolCalls:    toolCalls,
-		FinishReason: finishReason,
+		FinishReason: "stop",
 		Usage:        usage,
 	}, nil
 }
+
+// codexThreadID returns the thread ID announced in the JSONL output, if any.
+func codexThreadID(output string) string {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		var event codexEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(scanner.Text())), &event); err != nil {
+			continue
+		}
+		if event.Type == "thread.started" && event.ThreadID != "" {
+			return event.ThreadID
+		}
+	}
+	return ""
+}
+
+// codexReply is the structured final message requested via --output-schema.
+type codexReply struct {
+	Content   string `json:"content"`
+	ToolCalls []struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"tool_calls"`
+}
+
+func decodeCodexReply(text string) (*codexReply, error) {
+	var reply codexReply
+	if err := json.Unmarshal([]byte(strings.TrimSpace(text)), &reply); err != nil {
+		return nil, err
+	}
+	return &reply, nil
+}
+
+// codexReplySchema constrains the final agent message when tools are offered.
+// Arguments are a JSON-encoded string because strict schemas cannot describe
+// arbitrary per-tool objects.
+const codexReplySchema = `{
+  "type": "object",
+  "properties": {
+    "content": {"type": "string"},
+    "tool_calls": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "name": {"type": "string"},
+          "arguments": {"type": "string"}
+        },
+        "required": ["name", "arguments"],
+        "additionalProperties": false
+      }
+    }
+  },
+  "required": ["content", "tool_calls"],
+  "additionalProperties": false
+}`
+
+func writeCodexReplySchema() (string, func(), error) {
+	dir, err := os.MkdirTemp("", "picoclaw-codex-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	path := filepath.Join(dir, "reply-schema.json")
+	if err := os.WriteFile(path, []byte(codexReplySchema), 0o600); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return path, cleanup, nil
+}
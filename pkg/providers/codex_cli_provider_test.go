@@ -47,69 +47,82 @@ func TestParseJSONLEvents_AgentMessage(t *testing.T) {
 	}
 }
 
-func TestParseJSONLEvents_ToolCallExtraction(t *testing.T) {
+func TestParseJSONLEvents_LeavesToolCallTextAlone(t *testing.T) {
+	// Tool calls come only from structured replies; JSON in plain text is content.
+	text := `{"tool_calls":[{"name":"read_file","arguments":"{}"}]}`
+	events := `{"type":"item.completed","item":{"id":"item_1","type":"agent_message","text":` + fmt.Sprintf("%q", text) + `}}`
+
 	p := &CodexCliProvider{}
-	toolCallText := `Let me read that file.
-{"tool_calls":[{"id":"call_1","type":"function","function":{"name":"read_file","arguments":"{\"path\":\"/tmp/test.txt\"}"}}]}`
-	// Build valid JSONL by marshaling the event
-	item := codexEvent{
-		Type: "item.completed",
-		Item: &codexEventItem{ID: "item_1", Type: "agent_message", Text: toolCallText},
+	resp, err := p.parseJSONLEvents(events)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
-	itemJSON, _ := json.Marshal(item)
-	usageEvt := `{"type":"turn.completed","usage":{"input_tokens":50,"cached_input_tokens":0,"output_tokens":20}}`
-	events := `{"type":"turn.started"}` + "\n" + string(itemJSON) + "\n" + usageEvt
+	if len(resp.ToolCalls) != 0 {
+		t.Errorf("ToolCalls = %d, want 0", len(resp.ToolCalls))
+	}
+	if resp.Content != text {
+		t.Errorf("Content = %q, want %q", resp.Content, text)
+	}
+}
 
-	resp, err := p.parseJSONLEvents(events)
+// --- Structured Reply Tests ---
+
+func TestFinishResponse_StructuredToolCalls(t *testing.T) {
+	output := `{"type":"thread.started","thread_id":"thread-1"}`
+	reply := `{"content":"Reading files.","tool_calls":[` +
+		`{"name":"read_file","arguments":"{\"path\":\"a.txt\"}"},` +
+		`{"name":"write_file","arguments":"{\"path\":\"b.txt\",\"content\":\"hello\"}"}]}`
+
+	p := &CodexCliProvider{}
+	resp, err := p.finishResponse(&LLMResponse{Content: reply, FinishReason: "stop"}, output, true)
 	if err != nil {
-		t.Fatalf("parseJSONLEvents() error: %v", err)
+		t.Fatalf("unexpected error: %v", err)
 	}
 	if resp.FinishReason != "tool_calls" {
 		t.Errorf("FinishReason = %q, want %q", resp.FinishReason, "tool_calls")
 	}
-	if len(resp.ToolCalls) != 1 {
-		t.Fatalf("ToolCalls count = %d, want 1", len(resp.ToolCalls))
+	if resp.Content != "Reading files." {
+		t.Errorf("Content = %q, want %q", resp.Content, "Reading files.")
+	}
+	if len(resp.ToolCalls) != 2 {
+		t.Fatalf("ToolCalls = %d, want 2", len(resp.ToolCalls))
 	}
-	if resp.ToolCalls[0].Name != "read_file" {
-		t.Errorf("ToolCalls[0].Name = %q, want %q", resp.ToolCalls[0].Name, "read_file")
+	if resp.ToolCalls[0].Name != "read_file" || resp.ToolCalls[0].Arguments["path"] != "a.txt" {
+		t.Errorf("ToolCalls[0] = %+v, want read_file(a.txt)", resp.ToolCalls[0])
 	}
-	if resp.ToolCalls[0].ID != "call_1" {
-		t.Errorf("ToolCalls[0].ID = %q, want %q", resp.ToolCalls[0].ID, "call_1")
+	if resp.ToolCalls[1].Function == nil || resp.ToolCalls[1].Function.Name != "write_file" {
+		t.Errorf("ToolCalls[1].Function = %+v, want write_file", resp.ToolCalls[1].Function)
 	}
-	if resp.ToolCalls[0].Function.Arguments != `{"path":"/tmp/test.txt"}` {
-		t.Errorf("ToolCalls[0].Function.Arguments = %q", resp.ToolCalls[0].Function.Arguments)
+	if resp.ToolCalls[0].ID == "" || resp.ToolCalls[0].ID == resp.ToolCalls[1].ID {
+		t.Errorf("tool call IDs must be unique and non-empty: %q, %q", resp.ToolCalls[0].ID, resp.ToolCalls[1].ID)
 	}
-	// Content should have the tool call JSON stripped
-	if strings.Contains(resp.Content, "tool_calls") {
-		t.Errorf("Content should not contain tool_calls JSON, got: %q", resp.Content)
+	for _, tc := range resp.ToolCalls {
+		if p.threads.calls[tc.ID].sessionID != "thread-1" {
+			t.Errorf("tool call %s not mapped to thread-1", tc.ID)
+		}
 	}
 }
 
-func TestParseJSONLEvents_MultipleToolCalls(t *testing.T) {
+func TestFinishResponse_PlainTextFallback(t *testing.T) {
 	p := &CodexCliProvider{}
-	toolCallText := `{"tool_calls":[{"id":"call_1","type":"function","function":{"name":"read_file","arguments":"{\"path\":\"a.txt\"}"}},{"id":"call_2","type":"function","function":{"name":"write_file","arguments":"{\"path\":\"b.txt\",\"content\":\"hello\"}"}}]}`
-	item := codexEvent{
-		Type: "item.completed",
-		Item: &codexEventItem{ID: "item_1", Type: "agent_message", Text: toolCallText},
-	}
-	itemJSON, _ := json.Marshal(item)
-	events := `{"type":"turn.started"}` + "\n" + string(itemJSON) + "\n" + `{"type":"turn.completed"}`
-
-	resp, err := p.parseJSONLEvents(events)
+	resp, err := p.finishResponse(&LLMResponse{Content: "Just text.", FinishReason: "stop"}, "", true)
 	if err != nil {
-		t.Fatalf("parseJSONLEvents() error: %v", err)
-	}
-	if len(resp.ToolCalls) != 2 {
-		t.Fatalf("ToolCalls count = %d, want 2", len(resp.ToolCalls))
+		t.Fatalf("unexpected error: %v", err)
 	}
-	if resp.ToolCalls[0].Name != "read_file" {
-		t.Errorf("ToolCalls[0].Name = %q, want %q", resp.ToolCalls[0].Name, "read_file")
+	if resp.Content != "Just text." || len(resp.ToolCalls) != 0 {
+		t.Errorf("resp = %+v, want plain text passthrough", resp)
 	}
-	if resp.ToolCalls[1].Name != "write_file" {
-		t.Errorf("ToolCalls[1].Name = %q, want %q", resp.ToolCalls[1].Name, "write_file")
+}
+
+func TestFinishResponse_InvalidArguments(t *testing.T) {
+	p := &CodexCliProvider{}
+	reply := `{"content":"","tool_calls":[{"name":"read_file","arguments":"not json"}]}`
+	resp, err := p.finishResponse(&LLMResponse{Content: reply}, "", true)
+	if err != nil {
+		t.Fatalf("invalid arguments should not fail the turn: %v", err)
 	}
-	if resp.FinishReason != "tool_calls" {
-		t.Errorf("FinishReason = %q, want %q", resp.FinishReason, "tool_calls")
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Arguments["raw"] != "not json" {
+		t.Errorf("tool calls = %+v, want read_file with the raw arguments", resp.ToolCalls)
 	}
 }
 
@@ -583,3 +596,82 @@ func TestCodexCliProvider_Integration(t *testing.T) {
 		t.Errorf("Content = %q, expected to contain 'hello'", resp.Content)
 	}
 }
+
+func TestCodexCliProvider_MockCLI_ToolCallAndResume(t *testing.T) {
+	tmpDir := t.TempDir()
+	scriptPath := filepath.Join(tmpDir, "codex")
+	script := fmt.Sprintf(`#!/bin/bash
+echo "$@" >> '%[1]s/args.txt'
+cat > '%[1]s/stdin-$#.txt'
+for arg in "$@"; do
+	if [ "$prev" = "--output-schema" ]; then cp "$arg" '%[1]s/schema.json'; fi
+	prev="$arg"
+done
+case "$*" in
+*" resume "*)
+	echo '{"type":"thread.started","thread_id":"thread-7"}'
+	echo '{"type":"item.completed","item":{"id":"item_2","type":"agent_message","text":"{\"content\":\"Sunny.\",\"tool_calls\":[]}"}}'
+	echo '{"type":"turn.completed","usage":{"input_tokens":20,"cached_input_tokens":0,"output_tokens":3}}'
+	;;
+*)
+	echo '{"type":"thread.started","thread_id":"thread-7"}'
+	echo '{"type":"item.completed","item":{"id":"item_1","type":"agent_message","text":"{\"content\":\"\",\"tool_calls\":[{\"name\":\"get_weather\",\"arguments\":\"{\\\"city\\\":\\\"NYC\\\"}\"}]}"}}'
+	echo '{"type":"turn.completed","usage":{"input_tokens":10,"cached_input_tokens":0,"output_tokens":5}}'
+	;;
+esac
+`, tmpDir)
+	if err := os.WriteFile(scriptPath, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &CodexCliProvider{command: scriptPath}
+	tools := []ToolDefinition{{
+		Type:     "function",
+		Function: ToolFunctionDefinition{Name: "get_weather", Description: "Get current weather"},
+	}}
+	messages := []Message{{Role: "user", Content: "Weather in NYC?"}}
+
+	resp, err := p.Chat(context.Background(), messages, tools, "", nil)
+	if err != nil {
+		t.Fatalf("Chat() error: %v", err)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Arguments["city"] != "NYC" {
+		t.Fatalf("resp = %+v, want get_weather(NYC)", resp)
+	}
+
+	schema, err := os.ReadFile(filepath.Join(tmpDir, "schema.json"))
+	if err != nil {
+		t.Fatalf("--output-schema file was not passed: %v", err)
+	}
+	if !json.Valid(schema) || !strings.Contains(string(schema), "tool_calls") {
+		t.Errorf("unexpected schema: %s", schema)
+	}
+
+	messages = append(messages,
+		Message{Role: "assistant", ToolCalls: resp.ToolCalls},
+		Message{Role: "tool", ToolCallID: resp.ToolCalls[0].ID, Content: "Sunny, 25C"},
+	)
+	resp, err = p.Chat(context.Background(), messages, tools, "", nil)
+	if err != nil {
+		t.Fatalf("Chat() resume error: %v", err)
+	}
+	if resp.Content != "Sunny." || len(resp.ToolCalls) != 0 {
+		t.Errorf("resp = %+v, want final answer", resp)
+	}
+
+	args, _ := os.ReadFile(filepath.Join(tmpDir, "args.txt"))
+	if !strings.Contains(string(args), "resume thread-7 -") {
+		t.Errorf("second call should resume the codex thread, args:\n%s", args)
+	}
+	matches, _ := filepath.Glob(filepath.Join(tmpDir, "stdin-*.txt"))
+	var resumePrompt string
+	for _, m := range matches {
+		data, _ := os.ReadFile(m)
+		if strings.Contains(string(data), "Tool results") {
+			resumePrompt = string(data)
+		}
+	}
+	if !strings.Contains(resumePrompt, "[Tool Result for "+messages[2].ToolCallID+"]: Sunny, 25C") {
+		t.Errorf("resume prompt should carry the tool result, got %q", resumePrompt)
+	}
+}
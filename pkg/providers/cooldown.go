@@ -55,6 +55,7 @@ func (ct *CooldownTracker) MarkFailure(provider string, reason FailoverReason) {
 	entry.ErrorCount++
 	entry.FailureCounts[reason]++
 	entry.LastFailure = now
+	cooldowns.Inc(provider, string(reason))
 
 	if reason == FailoverBilling {
 		billingCount := entry.FailureCounts[FailoverBilling]
@@ -0,0 +1,138 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/providers/ollama"
+)
+
+// StartEndpointProbes checks every load-balanced HTTP endpoint each interval
+// until ctx is done. A probe lists the endpoint's models, which costs no
+// tokens. A failed probe puts the endpoint in cooldown; a successful one ends
+// its cooldown early. Entries without an HTTP API (CLI and OAuth providers)
+// are not probed.
+func StartEndpointProbes(ctx context.Context, cfg *config.Config, interval, timeout time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	registerEndpointCooldown()
+	client := &http.Client{Timeout: timeout}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			probeEndpoints(ctx, client, cfg)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func probeEndpoints(ctx context.Context, client *http.Client, cfg *config.Config) {
+	counts := make(map[string]int)
+	for i := range cfg.ModelList {
+		counts[cfg.ModelList[i].ModelName]++
+	}
+
+	index := make(map[string]int)
+	for i := range cfg.ModelList {
+		mc := &cfg.ModelList[i]
+		id := config.EndpointID(mc.ModelName, index[mc.ModelName])
+		index[mc.ModelName]++
+		if counts[mc.ModelName] < 2 {
+			continue
+		}
+		req, ok := newProbeRequest(ctx, mc)
+		if !ok {
+			continue
+		}
+		latency, err := probeEndpoint(client, req)
+		if ctx.Err() != nil {
+			return
+		}
+		config.Endpoints().RecordProbe(id, latency, err)
+		if err != nil {
+			endpointCooldown.MarkFailure(id, FailoverTimeout)
+			logger.WarnCF("provider.balance", "Endpoint probe failed",
+				map[string]any{"endpoint": id, "error": err.Error()})
+		} else {
+			endpointCooldown.MarkSuccess(id)
+		}
+	}
+}
+
+// newProbeRequest builds the model listing request for an entry, or reports
+// false when the entry has no HTTP API to probe.
+func newProbeRequest(ctx context.Context, mc *config.ModelConfig) (*http.Request, bool) {
+	if mc.AuthMethod == "oauth" || mc.AuthMethod == "token" {
+		return nil, false
+	}
+	protocol, _ := ExtractProtocol(mc.Model)
+	apiBase := mc.APIBase
+	if apiBase == "" {
+		apiBase = getDefaultAPIBase(protocol)
+	}
+
+	var url string
+	switch protocol {
+	case "anthropic":
+		if apiBase == "" {
+			apiBase = "https://api.anthropic.com/v1"
+		}
+		url = strings.TrimRight(apiBase, "/") + "/models"
+	case "ollama":
+		if apiBase == "" {
+			apiBase = ollama.DefaultAPIBase
+		}
+		url = strings.TrimSuffix(strings.TrimRight(apiBase, "/"), "/v1") + "/api/tags"
+	default:
+		if apiBase == "" || !strings.HasPrefix(apiBase, "http") {
+			return nil, false
+		}
+		url = strings.TrimRight(apiBase, "/") + "/models"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, false
+	}
+	if mc.APIKey != "" {
+		if protocol == "anthropic" {
+			req.Header.Set("x-api-key", mc.APIKey)
+			req.Header.Set("anthropic-version", "2023-06-01")
+		} else {
+			req.Header.Set("Authorization", "Bearer "+mc.APIKey)
+		}
+	}
+	return req, true
+}
+
+// probeEndpoint sends req. Rejected credentials, rate limiting and server
+// errors fail the probe; a server without a model listing route passes.
+func probeEndpoint(client *http.Client, req *http.Request) (time.Duration, error) {
+	start := time.Now()
+	resp, err := client.Do(req)
+	latency := time.Since(start)
+	if err != nil {
+		return latency, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode < 400, resp.StatusCode == http.StatusNotFound,
+		resp.StatusCode == http.StatusMethodNotAllowed:
+		return latency, nil
+	default:
+		return latency, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/providers/ollama"
 )
 
 // createClaudeAuthProvider creates a Claude provider using OAuth credentials from auth store.
@@ -53,9 +54,21 @@ func ExtractProtocol(model string) (protocol, modelID string) {
 
 // CreateProviderFromConfig creates a provider based on the ModelConfig.
 // It uses the protocol prefix in the Model field to determine which provider to create.
-// Supported protocols: openai, anthropic, antigravity, claude-cli, codex-cli, github-copilot
+// Supported protocols: openai, anthropic, ollama, antigravity, claude-cli, codex-cli, github-copilot
+// When the entry sets rpm or tpm, the provider is wrapped with the entry's shared rate limiter.
 // Returns the provider, the model ID (without protocol prefix), and any error.
 func CreateProviderFromConfig(cfg *config.ModelConfig) (LLMProvider, string, error) {
+	provider, modelID, err := createProviderFromConfig(cfg)
+	if err != nil {
+		return nil, "", err
+	}
+	if limiter := rateLimits.forEntry(cfg); limiter != nil {
+		return NewRateLimitedProvider(provider, limiter, modelID), modelID, nil
+	}
+	return provider, modelID, nil
+}
+
+func createProviderFromConfig(cfg *config.ModelConfig) (LLMProvider, string, error) {
 	if cfg == nil {
 		return nil, "", fmt.Errorf("config is nil")
 	}
@@ -93,7 +106,7 @@ func CreateProviderFromConfig(cfg *config.ModelConfig) (LLMProvider, string, err
 		), modelID, nil
 
 	case "openrouter", "groq", "zhipu", "gemini", "nvidia",
-		"ollama", "moonshot", "shengsuanyun", "deepseek", "cerebras",
+		"moonshot", "shengsuanyun", "deepseek", "cerebras",
 		"volcengine", "vllm", "qwen", "mistral":
 		// All other OpenAI-compatible HTTP providers
 		if cfg.APIKey == "" && cfg.APIBase == "" {
@@ -111,6 +124,15 @@ func CreateProviderFromConfig(cfg *config.ModelConfig) (LLMProvider, string, err
 			cfg.RequestTimeout,
 		), modelID, nil
 
+	case "ollama":
+		// Native /api/chat; api_key is optional for a local server.
+		provider := NewOllamaProvider(cfg)
+		if cfg.AutoPull {
+			// Pull in the background; a large model can take many minutes.
+			go provider.ensureModel(modelID)
+		}
+		return provider, modelID, nil
+
 	case "anthropic":
 		if cfg.AuthMethod == "oauth" || cfg.AuthMethod == "token" {
 			// Use OAuth credentials from auth store
@@ -144,7 +166,7 @@ func CreateProviderFromConfig(cfg *config.ModelConfig) (LLMProvider, string, err
 		if workspace == "" {
 			workspace = "."
 		}
-		return NewClaudeCliProvider(workspace), modelID, nil
+		return NewClaudeCliProviderWithNativeTools(workspace, cfg.NativeTools), modelID, nil
 
 	case "codex-cli", "codexcli":
 		workspace := cfg.Workspace
@@ -189,7 +211,7 @@ func getDefaultAPIBase(protocol string) string {
 	case "nvidia":
 		return "https://integrate.api.nvidia.com/v1"
 	case "ollama":
-		return "http://localhost:11434/v1"
+		return ollama.DefaultAPIBase
 	case "moonshot":
 		return "https://api.moonshot.cn/v1"
 	case "shengsuanyun":
@@ -6,6 +6,7 @@
 package providers
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -111,7 +112,6 @@ func TestCreateProviderFromConfig_DefaultAPIBase(t *testing.T) {
 		{"qwen", "qwen"},
 		{"vllm", "vllm"},
 		{"deepseek", "deepseek"},
-		{"ollama", "ollama"},
 	}
 
 	for _, tt := range tests {
@@ -154,6 +154,64 @@ func TestCreateProviderFromConfig_Anthropic(t *testing.T) {
 	}
 }
 
+func TestCreateProviderFromConfig_Ollama(t *testing.T) {
+	cfg := &config.ModelConfig{
+		ModelName: "test-ollama",
+		Model:     "ollama/qwen2.5:7b",
+	}
+
+	provider, modelID, err := CreateProviderFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("CreateProviderFromConfig() error = %v", err)
+	}
+	if _, ok := provider.(*OllamaProvider); !ok {
+		t.Fatalf("expected *OllamaProvider, got %T", provider)
+	}
+	if _, ok := provider.(CapabilityProvider); !ok {
+		t.Error("OllamaProvider should implement CapabilityProvider")
+	}
+	if modelID != "qwen2.5:7b" {
+		t.Errorf("modelID = %q, want %q", modelID, "qwen2.5:7b")
+	}
+}
+
+func TestCreateProviderFromConfig_OllamaAutoPull(t *testing.T) {
+	pulled := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		switch r.URL.Path {
+		case "/api/show":
+			http.Error(w, `{"error":"model not found"}`, http.StatusNotFound)
+		case "/api/pull":
+			pulled <- body["model"].(string)
+			_, _ = w.Write([]byte(`{"status":"success"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	cfg := &config.ModelConfig{
+		ModelName: "local",
+		Model:     "ollama/llama3.2:1b",
+		APIBase:   server.URL + "/v1",
+		AutoPull:  true,
+	}
+	if _, _, err := CreateProviderFromConfig(cfg); err != nil {
+		t.Fatalf("CreateProviderFromConfig() error = %v", err)
+	}
+	// The pull runs in the background so it does not hold up startup.
+	select {
+	case model := <-pulled:
+		if model != "llama3.2:1b" {
+			t.Errorf("pulled = %q, want %q", model, "llama3.2:1b")
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("model was not pulled")
+	}
+}
+
 func TestCreateProviderFromConfig_Antigravity(t *testing.T) {
 	cfg := &config.ModelConfig{
 		ModelName: "test-antigravity",
@@ -208,6 +266,38 @@ func TestCreateProviderFromConfig_CodexCLI(t *testing.T) {
 	}
 }
 
+func TestCreateProviderFromConfig_RateLimited(t *testing.T) {
+	cfg := &config.ModelConfig{
+		ModelName: "test-limited",
+		Model:     "openai/gpt-4o",
+		APIKey:    "test-key",
+		APIBase:   "https://limited.example.com/v1",
+		RPM:       30,
+		TPM:       10000,
+	}
+
+	provider, _, err := CreateProviderFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("CreateProviderFromConfig() error = %v", err)
+	}
+	rl, ok := provider.(*RateLimitedProvider)
+	if !ok {
+		t.Fatalf("expected *RateLimitedProvider, got %T", provider)
+	}
+	if _, ok := rl.delegate.(*HTTPProvider); !ok {
+		t.Errorf("delegate = %T, want *HTTPProvider", rl.delegate)
+	}
+
+	// A second provider for the same entry shares the limiter.
+	again, _, err := CreateProviderFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("CreateProviderFromConfig() error = %v", err)
+	}
+	if again.(*RateLimitedProvider).limiter != rl.limiter {
+		t.Error("providers for the same model_list entry should share one limiter")
+	}
+}
+
 func TestCreateProviderFromConfig_MissingAPIKey(t *testing.T) {
 	cfg := &config.ModelConfig{
 		ModelName: "test-no-key",
@@ -5,11 +5,18 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/sipeed/picoclaw/pkg/tracing"
 )
 
 // FallbackChain orchestrates model fallback across multiple candidates.
 type FallbackChain struct {
 	cooldown *CooldownTracker
+
+	// Optional rate-limit awareness: a candidate whose queue wait exceeds
+	// maxQueueWait is tried only after the other candidates.
+	maxQueueWait time.Duration
+	queueWait    func(FallbackCandidate) time.Duration
 }
 
 // FallbackCandidate represents one model/provider to try.
@@ -41,6 +48,15 @@ func NewFallbackChain(cooldown *CooldownTracker) *FallbackChain {
 	return &FallbackChain{cooldown: cooldown}
 }
 
+// SetQueueWaitLimit makes Execute skip candidates that would wait longer than
+// max for rate-limit capacity, as reported by estimate. If no other candidate
+// succeeds, the least loaded skipped one is waited on. A non-positive max
+// disables the check.
+func (fc *FallbackChain) SetQueueWaitLimit(max time.Duration, estimate func(FallbackCandidate) time.Duration) {
+	fc.maxQueueWait = max
+	fc.queueWait = estimate
+}
+
 // ResolveCandidates parses model config into a deduplicated candidate list.
 func ResolveCandidates(cfg ModelConfig, defaultProvider string) []FallbackCandidate {
 	seen := make(map[string]bool)
@@ -78,6 +94,8 @@ func ResolveCandidates(cfg ModelConfig, defaultProvider string) []FallbackCandid
 //
 // Behavior:
 //   - Candidates in cooldown are skipped (logged as skipped attempt).
+//   - Candidates whose rate-limit queue is too long are skipped; if no other
+//     candidate succeeds, the request waits on the least loaded of them.
 //   - context.Canceled aborts immediately (user abort, no fallback).
 //   - Non-retriable errors (format) abort immediately.
 //   - Retriable errors trigger fallback to next candidate.
@@ -95,6 +113,10 @@ func (fc *FallbackChain) Execute(
 	result := &FallbackResult{
 		Attempts: make([]FallbackAttempt, 0, len(candidates)),
 	}
+	defer recordFallbackAttempts(result)
+
+	// Indexes of candidates skipped for a long rate-limit queue.
+	var queued []int
 
 	for i, candidate := range candidates {
 		// Check context before each attempt.
@@ -119,60 +141,109 @@ func (fc *FallbackChain) Execute(
 			continue
 		}
 
-		// Execute the run function.
-		start := time.Now()
-		resp, err := run(ctx, candidate.Provider, candidate.Model)
-		elapsed := time.Since(start)
+		// Check rate-limit queue: prefer another candidate over a long wait.
+		if fc.queueWait != nil && fc.maxQueueWait > 0 {
+			if wait := fc.queueWait(candidate); wait > fc.maxQueueWait {
+				result.Attempts = append(result.Attempts, FallbackAttempt{
+					Provider: candidate.Provider,
+					Model:    candidate.Model,
+					Skipped:  true,
+					Reason:   FailoverRateLimit,
+					Error: fmt.Errorf(
+						"%s/%s rate limit queue wait %s exceeds %s",
+						candidate.Provider,
+						candidate.Model,
+						wait.Round(time.Second),
+						fc.maxQueueWait,
+					),
+				})
+				queued = append(queued, i)
+				continue
+			}
+		}
 
-		if err == nil {
-			// Success.
-			fc.cooldown.MarkSuccess(candidate.Provider)
-			result.Response = resp
-			result.Provider = candidate.Provider
-			result.Model = candidate.Model
+		if done, err := fc.attempt(ctx, run, candidates, i, result); done {
+			if err != nil {
+				return nil, err
+			}
 			return result, nil
 		}
+	}
 
-		// Context cancellation: abort immediately, no fallback.
-		if ctx.Err() == context.Canceled {
-			result.Attempts = append(result.Attempts, FallbackAttempt{
-				Provider: candidate.Provider,
-				Model:    candidate.Model,
-				Error:    err,
-				Duration: elapsed,
-			})
-			return nil, context.Canceled
+	// Nothing else worked: waiting for capacity beats failing the request.
+	if len(queued) > 0 && ctx.Err() == nil {
+		best := queued[0]
+		bestWait := fc.queueWait(candidates[best])
+		for _, i := range queued[1:] {
+			if wait := fc.queueWait(candidates[i]); wait < bestWait {
+				best, bestWait = i, wait
+			}
 		}
+		if done, err := fc.attempt(ctx, run, candidates, best, result); done {
+			if err != nil {
+				return nil, err
+			}
+			return result, nil
+		}
+	}
 
-		// Classify the error.
-		failErr := ClassifyError(err, candidate.Provider, candidate.Model)
+	// All candidates failed or were skipped.
+	return nil, &FallbackExhaustedError{Attempts: result.Attempts}
+}
 
-		if failErr == nil {
-			// Unclassifiable error: do not fallback, return immediately.
-			result.Attempts = append(result.Attempts, FallbackAttempt{
-				Provider: candidate.Provider,
-				Model:    candidate.Model,
-				Error:    err,
-				Duration: elapsed,
-			})
-			return nil, fmt.Errorf("fallback: unclassified error from %s/%s: %w",
-				candidate.Provider, candidate.Model, err)
-		}
+// attempt runs candidates[i] and records the outcome in result. It reports
+// done when Execute should stop: on success (nil error) or on an error that
+// must not fall back. Retriable failures put the provider in cooldown.
+func (fc *FallbackChain) attempt(
+	ctx context.Context,
+	run func(ctx context.Context, provider, model string) (*LLMResponse, error),
+	candidates []FallbackCandidate,
+	i int,
+	result *FallbackResult,
+) (bool, error) {
+	candidate := candidates[i]
+
+	start := time.Now()
+	resp, err := runAttempt(ctx, run, candidate, i)
+	elapsed := time.Since(start)
+
+	if err == nil {
+		// Success.
+		fc.cooldown.MarkSuccess(candidate.Provider)
+		result.Response = resp
+		result.Provider = candidate.Provider
+		result.Model = candidate.Model
+		return true, nil
+	}
 
-		// Non-retriable error: abort immediately.
-		if !failErr.IsRetriable() {
-			result.Attempts = append(result.Attempts, FallbackAttempt{
-				Provider: candidate.Provider,
-				Model:    candidate.Model,
-				Error:    failErr,
-				Reason:   failErr.Reason,
-				Duration: elapsed,
-			})
-			return nil, failErr
-		}
+	// Context cancellation: abort immediately, no fallback.
+	if ctx.Err() == context.Canceled {
+		result.Attempts = append(result.Attempts, FallbackAttempt{
+			Provider: candidate.Provider,
+			Model:    candidate.Model,
+			Error:    err,
+			Duration: elapsed,
+		})
+		return true, context.Canceled
+	}
+
+	// Classify the error.
+	failErr := ClassifyError(err, candidate.Provider, candidate.Model)
+
+	if failErr == nil {
+		// Unclassifiable error: do not fallback, return immediately.
+		result.Attempts = append(result.Attempts, FallbackAttempt{
+			Provider: candidate.Provider,
+			Model:    candidate.Model,
+			Error:    err,
+			Duration: elapsed,
+		})
+		return true, fmt.Errorf("fallback: unclassified error from %s/%s: %w",
+			candidate.Provider, candidate.Model, err)
+	}
 
-		// Retriable error: mark failure and continue to next candidate.
-		fc.cooldown.MarkFailure(candidate.Provider, failErr.Reason)
+	// Non-retriable error: abort immediately.
+	if !failErr.IsRetriable() {
 		result.Attempts = append(result.Attempts, FallbackAttempt{
 			Provider: candidate.Provider,
 			Model:    candidate.Model,
@@ -180,15 +251,19 @@ func (fc *FallbackChain) Execute(
 			Reason:   failErr.Reason,
 			Duration: elapsed,
 		})
-
-		// If this was the last candidate, return aggregate error.
-		if i == len(candidates)-1 {
-			return nil, &FallbackExhaustedError{Attempts: result.Attempts}
-		}
+		return true, failErr
 	}
 
-	// All candidates were skipped (all in cooldown).
-	return nil, &FallbackExhaustedError{Attempts: result.Attempts}
+	// Retriable error: mark failure and continue to next candidate.
+	fc.cooldown.MarkFailure(candidate.Provider, failErr.Reason)
+	result.Attempts = append(result.Attempts, FallbackAttempt{
+		Provider: candidate.Provider,
+		Model:    candidate.Model,
+		Error:    failErr,
+		Reason:   failErr.Reason,
+		Duration: elapsed,
+	})
+	return false, nil
 }
 
 // ExecuteImage runs the fallback chain for image/vision requests.
@@ -206,6 +281,7 @@ func (fc *FallbackChain) ExecuteImage(
 	result := &FallbackResult{
 		Attempts: make([]FallbackAttempt, 0, len(candidates)),
 	}
+	defer recordFallbackAttempts(result)
 
 	for i, candidate := range candidates {
 		if ctx.Err() == context.Canceled {
@@ -213,7 +289,7 @@ func (fc *FallbackChain) ExecuteImage(
 		}
 
 		start := time.Now()
-		resp, err := run(ctx, candidate.Provider, candidate.Model)
+		resp, err := runAttempt(ctx, run, candidate, i)
 		elapsed := time.Since(start)
 
 		if err == nil {
@@ -267,6 +343,25 @@ func (fc *FallbackChain) ExecuteImage(
 	return nil, &FallbackExhaustedError{Attempts: result.Attempts}
 }
 
+// runAttempt calls run for one candidate inside an "llm.attempt" span, a
+// child of the caller's LLM call span.
+func runAttempt(
+	ctx context.Context,
+	run func(ctx context.Context, provider, model string) (*LLMResponse, error),
+	candidate FallbackCandidate,
+	index int,
+) (*LLMResponse, error) {
+	ctx, span := tracing.Start(ctx, "llm.attempt", map[string]any{
+		"provider": candidate.Provider,
+		"model":    candidate.Model,
+		"attempt":  index + 1,
+	})
+	defer span.End()
+	resp, err := run(ctx, candidate.Provider, candidate.Model)
+	span.SetError(err)
+	return resp, err
+}
+
 // FallbackExhaustedError indicates all fallback candidates were tried and failed.
 type FallbackExhaustedError struct {
 	Attempts []FallbackAttempt
@@ -285,3 +380,17 @@ func (e *FallbackExhaustedError) Error() string {
 	}
 	return sb.String()
 }
+
+// recordFallbackAttempts counts the failed and skipped attempts of a run.
+func recordFallbackAttempts(result *FallbackResult) {
+	for _, a := range result.Attempts {
+		reason := string(a.Reason)
+		switch {
+		case a.Skipped:
+			reason = "skipped"
+		case reason == "":
+			reason = string(FailoverUnknown)
+		}
+		fallbackAttempts.Inc(a.Provider, a.Model, reason)
+	}
+}
@@ -191,6 +191,69 @@ func TestFallback_CooldownSkip(t *testing.T) {
 	}
 }
 
+func TestFallback_QueueWaitSkip(t *testing.T) {
+	fc := NewFallbackChain(NewCooldownTracker())
+	fc.SetQueueWaitLimit(10*time.Second, func(c FallbackCandidate) time.Duration {
+		return map[string]time.Duration{"gpt-4": 2 * time.Minute, "claude": time.Minute}[c.Model]
+	})
+
+	candidates := []FallbackCandidate{
+		makeCandidate("openai", "gpt-4"),
+		makeCandidate("anthropic", "claude"),
+	}
+
+	var called []string
+	run := func(ctx context.Context, provider, model string) (*LLMResponse, error) {
+		called = append(called, model)
+		return &LLMResponse{Content: "ok", FinishReason: "stop"}, nil
+	}
+
+	result, err := fc.Execute(context.Background(), candidates, run)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Every queue is long, so the request waits on the least loaded one.
+	if len(called) != 1 || called[0] != "claude" {
+		t.Errorf("called = %v, want [claude]", called)
+	}
+	if len(result.Attempts) != 2 || !result.Attempts[0].Skipped ||
+		result.Attempts[0].Reason != FailoverRateLimit {
+		t.Errorf("attempts = %+v, want two skipped rate_limit attempts", result.Attempts)
+	}
+}
+
+func TestFallback_QueueWaitAfterFailure(t *testing.T) {
+	fc := NewFallbackChain(NewCooldownTracker())
+	fc.SetQueueWaitLimit(10*time.Second, func(c FallbackCandidate) time.Duration {
+		if c.Model == "gpt-4" {
+			return time.Minute
+		}
+		return 0
+	})
+
+	candidates := []FallbackCandidate{
+		makeCandidate("openai", "gpt-4"),
+		makeCandidate("anthropic", "claude"),
+	}
+
+	var called []string
+	run := func(ctx context.Context, provider, model string) (*LLMResponse, error) {
+		called = append(called, model)
+		if model == "claude" {
+			return nil, errors.New("rate limit exceeded")
+		}
+		return &LLMResponse{Content: "ok", FinishReason: "stop"}, nil
+	}
+
+	result, err := fc.Execute(context.Background(), candidates, run)
+	if err != nil {
+		t.Fatalf("the queued candidate should be waited on, got %v", err)
+	}
+	if len(called) != 2 || called[0] != "claude" || called[1] != "gpt-4" || result.Model != "gpt-4" {
+		t.Errorf("called = %v, result model = %s, want claude then gpt-4", called, result.Model)
+	}
+}
+
 func TestFallback_AllInCooldown(t *testing.T) {
 	ct := NewCooldownTracker()
 	fc := NewFallbackChain(ct)
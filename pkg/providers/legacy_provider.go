@@ -40,19 +40,14 @@ func CreateProvider(cfg *config.Config) (LLMProvider, string, error) {
 		return nil, "", fmt.Errorf("no providers configured. Please add entries to model_list in your config")
 	}
 
-	// Get model config from model_list
-	modelCfg, err := cfg.GetModelConfig(model)
-	if err != nil {
+	// Check the model exists in model_list
+	if _, err := cfg.GetModelConfig(model); err != nil {
 		return nil, "", fmt.Errorf("model %q not found in model_list: %w", model, err)
 	}
 
-	// Inject global workspace if not set in model config
-	if modelCfg.Workspace == "" {
-		modelCfg.Workspace = cfg.WorkspacePath()
-	}
-
-	// Use factory to create provider
-	provider, modelID, err := CreateProviderFromConfig(modelCfg)
+	// Use factory to create provider; duplicate model_name entries are
+	// balanced per request.
+	provider, modelID, err := CreateProviderForModel(cfg, model)
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to create provider for model %q: %w", model, err)
 	}
@@ -0,0 +1,157 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/metrics"
+)
+
+var (
+	llmRequests = metrics.NewCounter("picoclaw_llm_requests_total",
+		"LLM requests by provider, model and status (ok or error).", "provider", "model", "status")
+	llmDuration = metrics.NewHistogram("picoclaw_llm_request_duration_seconds",
+		"LLM request latency by provider and model.", nil, "provider", "model")
+	llmTokens = metrics.NewCounter("picoclaw_llm_tokens_total",
+		"Tokens reported by the LLM, by provider, model and type (prompt or completion).",
+		"provider", "model", "type")
+	fallbackAttempts = metrics.NewCounter("picoclaw_fallback_attempts_total",
+		"Fallback candidates that failed or were skipped, by provider, model and reason.",
+		"provider", "model", "reason")
+	cooldowns = metrics.NewCounter("picoclaw_cooldowns_total",
+		"Cooldowns started by the fallback chain and endpoint balancer, by key and reason.", "key", "reason")
+)
+
+// RecordChat records the latency, outcome and token usage of one LLM request.
+func RecordChat(provider, model string, d time.Duration, resp *LLMResponse, err error) {
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	llmRequests.Inc(provider, model, status)
+	llmDuration.Observe(d.Seconds(), provider, model)
+	if resp != nil && resp.Usage != nil {
+		llmTokens.Add(float64(resp.Usage.PromptTokens), provider, model, "prompt")
+		llmTokens.Add(float64(resp.Usage.CompletionTokens), provider, model, "completion")
+	}
+}
+
+// ModelUsage totals the requests and tokens of one provider and model since
+// startup.
+type ModelUsage struct {
+	Provider         string `json:"provider"`
+	Model            string `json:"model"`
+	Requests         int    `json:"requests"`
+	Errors           int    `json:"errors"`
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+}
+
+// Usage returns the usage recorded by RecordChat, sorted by provider and model.
+func Usage() []ModelUsage {
+	byModel := make(map[[2]string]*ModelUsage)
+	get := func(provider, model string) *ModelUsage {
+		k := [2]string{provider, model}
+		u := byModel[k]
+		if u == nil {
+			u = &ModelUsage{Provider: provider, Model: model}
+			byModel[k] = u
+		}
+		return u
+	}
+	llmRequests.Each(func(lv []string, v float64) {
+		u := get(lv[0], lv[1])
+		u.Requests += int(v)
+		if lv[2] == "error" {
+			u.Errors += int(v)
+		}
+	})
+	llmTokens.Each(func(lv []string, v float64) {
+		u := get(lv[0], lv[1])
+		if lv[2] == "prompt" {
+			u.PromptTokens += int(v)
+		} else {
+			u.CompletionTokens += int(v)
+		}
+	})
+
+	usage := make([]ModelUsage, 0, len(byModel))
+	for _, u := range byModel {
+		usage = append(usage, *u)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Provider != usage[j].Provider {
+			return usage[i].Provider < usage[j].Provider
+		}
+		return usage[i].Model < usage[j].Model
+	})
+	return usage
+}
+
+// MeteredProvider records metrics for every request sent to the wrapped
+// provider. label maps the requested model to the provider label.
+type MeteredProvider struct {
+	delegate LLMProvider
+	label    func(model string) string
+}
+
+// NewMeteredProvider wraps delegate. A nil label reports an empty provider.
+func NewMeteredProvider(delegate LLMProvider, label func(model string) string) *MeteredProvider {
+	if label == nil {
+		label = func(string) string { return "" }
+	}
+	return &MeteredProvider{delegate: delegate, label: label}
+}
+
+func (p *MeteredProvider) Chat(
+	ctx context.Context,
+	messages []Message,
+	tools []ToolDefinition,
+	model string,
+	options map[string]any,
+) (*LLMResponse, error) {
+	start := time.Now()
+	resp, err := p.delegate.Chat(ctx, messages, tools, model, options)
+	RecordChat(p.label(model), model, time.Since(start), resp, err)
+	return resp, err
+}
+
+func (p *MeteredProvider) GetDefaultModel() string {
+	return p.delegate.GetDefaultModel()
+}
+
+// ModelCapabilities forwards to the wrapped provider when it supports lookups.
+func (p *MeteredProvider) ModelCapabilities(ctx context.Context, model string) (*ModelCapabilities, error) {
+	cp, ok := p.delegate.(CapabilityProvider)
+	if !ok {
+		return nil, errors.ErrUnsupported
+	}
+	return cp.ModelCapabilities(ctx, model)
+}
+
+// Close forwards to the wrapped provider when it holds resources.
+func (p *MeteredProvider) Close() {
+	if sp, ok := p.delegate.(StatefulProvider); ok {
+		sp.Close()
+	}
+}
+
+// ProviderLabeler returns a label function that names the protocol of the
+// model_list entry serving a model ID or model_name, e.g. "openai".
+func ProviderLabeler(modelList []config.ModelConfig) func(model string) string {
+	labels := make(map[string]string, len(modelList)*2)
+	for _, mc := range modelList {
+		protocol, modelID := ExtractProtocol(mc.Model)
+		for _, name := range []string{modelID, mc.ModelName} {
+			if _, ok := labels[name]; !ok && name != "" {
+				labels[name] = protocol
+			}
+		}
+	}
+	return func(model string) string {
+		return labels[model]
+	}
+}
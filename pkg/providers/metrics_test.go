@@ -0,0 +1,113 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+type scriptedChatProvider struct {
+	resp *LLMResponse
+	err  error
+}
+
+func (p *scriptedChatProvider) Chat(
+	ctx context.Context,
+	messages []Message,
+	tools []ToolDefinition,
+	model string,
+	options map[string]any,
+) (*LLMResponse, error) {
+	return p.resp, p.err
+}
+
+func (p *scriptedChatProvider) GetDefaultModel() string { return "scripted" }
+
+func TestMeteredProvider(t *testing.T) {
+	label := ProviderLabeler([]config.ModelConfig{
+		{ModelName: "fast", Model: "groq/metered-llama"},
+	})
+	ok := NewMeteredProvider(&scriptedChatProvider{
+		resp: &LLMResponse{Content: "hi", Usage: &UsageInfo{PromptTokens: 10, CompletionTokens: 4}},
+	}, label)
+	failing := NewMeteredProvider(&scriptedChatProvider{err: errors.New("boom")}, label)
+
+	// Metrics are process-wide; compare against the values before the calls.
+	okBefore := llmRequests.Value("groq", "metered-llama", "ok")
+	errBefore := llmRequests.Value("groq", "fast", "error")
+	promptBefore := llmTokens.Value("groq", "metered-llama", "prompt")
+	completionBefore := llmTokens.Value("groq", "metered-llama", "completion")
+	countBefore := llmDuration.Count("groq", "metered-llama")
+
+	for range 2 {
+		if _, err := ok.Chat(context.Background(), nil, nil, "metered-llama", nil); err != nil {
+			t.Fatalf("Chat() error = %v", err)
+		}
+	}
+	if _, err := failing.Chat(context.Background(), nil, nil, "fast", nil); err == nil {
+		t.Fatal("Chat() expected error")
+	}
+
+	if got := llmRequests.Value("groq", "metered-llama", "ok") - okBefore; got != 2 {
+		t.Errorf("ok requests = %v, want 2", got)
+	}
+	if got := llmRequests.Value("groq", "fast", "error") - errBefore; got != 1 {
+		t.Errorf("error requests = %v, want 1 (labelled by model_name)", got)
+	}
+	if got := llmTokens.Value("groq", "metered-llama", "prompt") - promptBefore; got != 20 {
+		t.Errorf("prompt tokens = %v, want 20", got)
+	}
+	if got := llmTokens.Value("groq", "metered-llama", "completion") - completionBefore; got != 8 {
+		t.Errorf("completion tokens = %v, want 8", got)
+	}
+	if got := llmDuration.Count("groq", "metered-llama") - countBefore; got != 2 {
+		t.Errorf("latency observations = %d, want 2", got)
+	}
+
+	for _, u := range Usage() {
+		if u.Provider == "groq" && u.Model == "metered-llama" {
+			if u.Requests < 2 || u.PromptTokens < 20 || u.CompletionTokens < 8 {
+				t.Errorf("Usage() = %+v, want at least this test's requests and tokens", u)
+			}
+			return
+		}
+	}
+	t.Error("Usage() has no entry for groq/metered-llama")
+}
+
+func TestFallback_RecordsAttemptMetrics(t *testing.T) {
+	ct := NewCooldownTracker()
+	fc := NewFallbackChain(ct)
+	ct.MarkFailure("metrics-cold", FailoverTimeout)
+	skippedBefore := fallbackAttempts.Value("metrics-cold", "m1", "skipped")
+	failedBefore := fallbackAttempts.Value("metrics-a", "m2", string(FailoverTimeout))
+	cooldownsBefore := cooldowns.Value("metrics-a", string(FailoverTimeout))
+
+	candidates := []FallbackCandidate{
+		makeCandidate("metrics-cold", "m1"),
+		makeCandidate("metrics-a", "m2"),
+		makeCandidate("metrics-b", "m3"),
+	}
+	_, err := fc.Execute(context.Background(), candidates,
+		func(ctx context.Context, provider, model string) (*LLMResponse, error) {
+			if provider == "metrics-a" {
+				return nil, errors.New("status: 503 service unavailable")
+			}
+			return &LLMResponse{Content: "ok"}, nil
+		})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if got := fallbackAttempts.Value("metrics-cold", "m1", "skipped") - skippedBefore; got != 1 {
+		t.Errorf("skipped attempts = %v, want 1", got)
+	}
+	if got := fallbackAttempts.Value("metrics-a", "m2", string(FailoverTimeout)) - failedBefore; got != 1 {
+		t.Errorf("failed attempts = %v, want 1", got)
+	}
+	if got := cooldowns.Value("metrics-a", string(FailoverTimeout)) - cooldownsBefore; got != 1 {
+		t.Errorf("cooldowns = %v, want 1", got)
+	}
+}
@@ -0,0 +1,505 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/providers/protocoltypes"
+)
+
+type (
+	ToolCall       = protocoltypes.ToolCall
+	FunctionCall   = protocoltypes.FunctionCall
+	LLMResponse    = protocoltypes.LLMResponse
+	UsageInfo      = protocoltypes.UsageInfo
+	Message        = protocoltypes.Message
+	ToolDefinition = protocoltypes.ToolDefinition
+)
+
+const (
+	DefaultAPIBase        = "http://localhost:11434"
+	defaultRequestTimeout = 300 * time.Second
+	showTimeout           = 15 * time.Second
+)
+
+// ErrModelNotFound is returned by Show when the model is not present locally.
+var ErrModelNotFound = errors.New("ollama: model not found")
+
+// Capabilities describes a model as reported by /api/show.
+type Capabilities struct {
+	// ContextLength is the context window the model runs with: num_ctx from
+	// the provider options or the Modelfile. It is zero when neither sets it,
+	// as Ollama then uses its own default rather than the trained length.
+	ContextLength int
+	Vision        bool
+	Tools         bool
+}
+
+// Provider talks to Ollama's native API (/api/chat, /api/show, /api/pull).
+type Provider struct {
+	apiKey     string
+	apiBase    string
+	keepAlive  string
+	numCtx     int
+	httpClient *http.Client
+
+	mu   sync.Mutex
+	caps map[string]*Capabilities
+}
+
+type Option func(*Provider)
+
+// WithKeepAlive sets how long Ollama keeps the model loaded after a request
+// (e.g. "10m", "-1" to keep it loaded indefinitely).
+func WithKeepAlive(keepAlive string) Option {
+	return func(p *Provider) {
+		p.keepAlive = keepAlive
+	}
+}
+
+// WithNumCtx pins the context window instead of using the detected one.
+func WithNumCtx(numCtx int) Option {
+	return func(p *Provider) {
+		if numCtx > 0 {
+			p.numCtx = numCtx
+		}
+	}
+}
+
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(p *Provider) {
+		if timeout > 0 {
+			p.httpClient.Timeout = timeout
+		}
+	}
+}
+
+func NewProvider(apiKey, apiBase, proxy string, opts ...Option) *Provider {
+	client := &http.Client{
+		Timeout: defaultRequestTimeout,
+	}
+
+	if proxy != "" {
+		parsed, err := url.Parse(proxy)
+		if err == nil {
+			client.Transport = &http.Transport{
+				Proxy: http.ProxyURL(parsed),
+			}
+		} else {
+			log.Printf("ollama: invalid proxy URL %q: %v", proxy, err)
+		}
+	}
+
+	p := &Provider{
+		apiKey:     apiKey,
+		apiBase:    normalizeAPIBase(apiBase),
+		httpClient: client,
+		caps:       make(map[string]*Capabilities),
+	}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(p)
+		}
+	}
+
+	return p
+}
+
+func (p *Provider) Chat(
+	ctx context.Context,
+	messages []Message,
+	tools []ToolDefinition,
+	model string,
+	options map[string]any,
+) (*LLMResponse, error) {
+	model = normalizeModel(model)
+
+	// Capability detection is best effort; without it we send the request as-is.
+	caps, err := p.Show(ctx, model)
+	if err != nil && !errors.Is(err, ErrModelNotFound) {
+		log.Printf("ollama: capability detection for %q failed: %v", model, err)
+	}
+
+	requestBody := map[string]any{
+		"model":    model,
+		"messages": toOllamaMessages(messages, caps == nil || caps.Vision),
+		"stream":   false,
+	}
+
+	// Models without tool support reject requests that carry tools.
+	if len(tools) > 0 && (caps == nil || caps.Tools) {
+		requestBody["tools"] = tools
+	}
+
+	modelOptions := map[string]any{}
+	if maxTokens, ok := asInt(options["max_tokens"]); ok {
+		modelOptions["num_predict"] = maxTokens
+	}
+	if temperature, ok := asFloat(options["temperature"]); ok {
+		modelOptions["temperature"] = temperature
+	}
+	// num_ctx is only sent when configured: the KV cache grows with it, and
+	// the trained length of many models does not fit on small boards.
+	if numCtx, ok := asInt(options["num_ctx"]); ok && numCtx > 0 {
+		modelOptions["num_ctx"] = numCtx
+	} else if p.numCtx > 0 {
+		modelOptions["num_ctx"] = p.numCtx
+	}
+	if len(modelOptions) > 0 {
+		requestBody["options"] = modelOptions
+	}
+
+	keepAlive := p.keepAlive
+	if v, ok := options["keep_alive"].(string); ok && v != "" {
+		keepAlive = v
+	}
+	if keepAlive != "" {
+		requestBody["keep_alive"] = keepAliveValue(keepAlive)
+	}
+
+	// Structured output: "json" or a JSON schema object.
+	if format, ok := options["format"]; ok && format != nil && format != "" {
+		requestBody["format"] = format
+	}
+
+	body, err := p.post(ctx, "/api/chat", requestBody)
+	if err != nil {
+		return nil, err
+	}
+	return parseChatResponse(body)
+}
+
+func (p *Provider) GetDefaultModel() string {
+	return ""
+}
+
+// Show returns the capabilities of a model, querying /api/show on first use.
+func (p *Provider) Show(ctx context.Context, model string) (*Capabilities, error) {
+	model = normalizeModel(model)
+
+	p.mu.Lock()
+	if caps, ok := p.caps[model]; ok {
+		p.mu.Unlock()
+		return caps, nil
+	}
+	p.mu.Unlock()
+
+	showCtx, cancel := context.WithTimeout(ctx, showTimeout)
+	defer cancel()
+
+	body, err := p.post(showCtx, "/api/show", map[string]any{"model": model})
+	if err != nil {
+		var statusErr *statusError
+		if errors.As(err, &statusErr) && statusErr.status == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: %s", ErrModelNotFound, model)
+		}
+		return nil, err
+	}
+
+	caps, err := parseShowResponse(body)
+	if err != nil {
+		return nil, err
+	}
+	if p.numCtx > 0 {
+		caps.ContextLength = p.numCtx
+	}
+
+	p.mu.Lock()
+	p.caps[model] = caps
+	p.mu.Unlock()
+	return caps, nil
+}
+
+// Pull downloads a model. It blocks until the pull completes.
+func (p *Provider) Pull(ctx context.Context, model string) error {
+	model = normalizeModel(model)
+	body, err := p.post(ctx, "/api/pull", map[string]any{"model": model, "stream": false})
+	if err != nil {
+		return fmt.Errorf("pulling %s: %w", model, err)
+	}
+	var result struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("pulling %s: failed to unmarshal response: %w", model, err)
+	}
+	if result.Error != "" {
+		return fmt.Errorf("pulling %s: %s", model, result.Error)
+	}
+	if result.Status != "success" {
+		return fmt.Errorf("pulling %s: unexpected status %q", model, result.Status)
+	}
+	return nil
+}
+
+// EnsureModel pulls the model if it is not present locally.
+// It reports whether a pull was performed.
+func (p *Provider) EnsureModel(ctx context.Context, model string) (bool, error) {
+	_, err := p.Show(ctx, model)
+	if err == nil {
+		return false, nil
+	}
+	if !errors.Is(err, ErrModelNotFound) {
+		return false, err
+	}
+	if err := p.Pull(ctx, model); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+type statusError struct {
+	status int
+	body   string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("API request failed:\n  Status: %d\n  Body:   %s", e.status, e.body)
+}
+
+func (p *Provider) post(ctx context.Context, path string, payload any) ([]byte, error) {
+	if p.apiBase == "" {
+		return nil, fmt.Errorf("API base not configured")
+	}
+
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.apiBase+path, bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &statusError{status: resp.StatusCode, body: string(body)}
+	}
+	return body, nil
+}
+
+// ollamaMessage is the wire-format message for /api/chat.
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+	Images    []string         `json:"images,omitempty"`
+}
+
+type ollamaToolCall struct {
+	Function ollamaFunction `json:"function"`
+}
+
+type ollamaFunction struct {
+	Name      string         `json:"name"`
+	Arguments map[string]any `json:"arguments"`
+}
+
+// toOllamaMessages converts messages to the native format. Ollama does not use
+// tool call IDs, so tool results are labelled with the name of the tool instead.
+// Images are dropped for models without vision, which reject them.
+func toOllamaMessages(messages []Message, vision bool) []ollamaMessage {
+	toolNames := make(map[string]string)
+	out := make([]ollamaMessage, 0, len(messages))
+	for _, m := range messages {
+		msg := ollamaMessage{Role: m.Role, Content: m.Content}
+		if vision {
+			msg.Images = m.Images
+		}
+		for _, tc := range m.ToolCalls {
+			name, args := tc.Name, tc.Arguments
+			if tc.Function != nil {
+				if name == "" {
+					name = tc.Function.Name
+				}
+				if args == nil && tc.Function.Arguments != "" {
+					_ = json.Unmarshal([]byte(tc.Function.Arguments), &args)
+				}
+			}
+			if args == nil {
+				args = map[string]any{}
+			}
+			toolNames[tc.ID] = name
+			msg.ToolCalls = append(msg.ToolCalls, ollamaToolCall{
+				Function: ollamaFunction{Name: name, Arguments: args},
+			})
+		}
+		if m.Role == "tool" {
+			msg.ToolName = toolNames[m.ToolCallID]
+		}
+		out = append(out, msg)
+	}
+	return out
+}
+
+func parseChatResponse(body []byte) (*LLMResponse, error) {
+	var apiResponse struct {
+		Message struct {
+			Content   string           `json:"content"`
+			Thinking  string           `json:"thinking"`
+			ToolCalls []ollamaToolCall `json:"tool_calls"`
+		} `json:"message"`
+		DoneReason      string `json:"done_reason"`
+		PromptEvalCount int    `json:"prompt_eval_count"`
+		EvalCount       int    `json:"eval_count"`
+	}
+	if err := json.Unmarshal(body, &apiResponse); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	toolCalls := make([]ToolCall, 0, len(apiResponse.Message.ToolCalls))
+	for i, tc := range apiResponse.Message.ToolCalls {
+		args := tc.Function.Arguments
+		if args == nil {
+			args = map[string]any{}
+		}
+		argsJSON, _ := json.Marshal(args)
+		toolCalls = append(toolCalls, ToolCall{
+			ID:        fmt.Sprintf("call_%d_%d", time.Now().UnixNano(), i),
+			Type:      "function",
+			Name:      tc.Function.Name,
+			Arguments: args,
+			Function: &FunctionCall{
+				Name:      tc.Function.Name,
+				Arguments: string(argsJSON),
+			},
+		})
+	}
+
+	finishReason := apiResponse.DoneReason
+	if finishReason == "" {
+		finishReason = "stop"
+	}
+	if len(toolCalls) > 0 {
+		finishReason = "tool_calls"
+	}
+
+	var usage *UsageInfo
+	if apiResponse.PromptEvalCount > 0 || apiResponse.EvalCount > 0 {
+		usage = &UsageInfo{
+			PromptTokens:     apiResponse.PromptEvalCount,
+			CompletionTokens: apiResponse.EvalCount,
+			TotalTokens:      apiResponse.PromptEvalCount + apiResponse.EvalCount,
+		}
+	}
+
+	return &LLMResponse{
+		Content:          apiResponse.Message.Content,
+		ReasoningContent: apiResponse.Message.Thinking,
+		ToolCalls:        toolCalls,
+		FinishReason:     finishReason,
+		Usage:            usage,
+	}, nil
+}
+
+func parseShowResponse(body []byte) (*Capabilities, error) {
+	var show struct {
+		Capabilities []string `json:"capabilities"`
+		Parameters   string   `json:"parameters"`
+	}
+	if err := json.Unmarshal(body, &show); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal show response: %w", err)
+	}
+
+	caps := &Capabilities{}
+	for _, c := range show.Capabilities {
+		switch c {
+		case "vision":
+			caps.Vision = true
+		case "tools":
+			caps.Tools = true
+		}
+	}
+
+	// A num_ctx set in the Modelfile is what the model actually runs with.
+	for _, line := range strings.Split(show.Parameters, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "num_ctx" {
+			if n, err := strconv.Atoi(fields[1]); err == nil && n > 0 {
+				caps.ContextLength = n
+			}
+		}
+	}
+	return caps, nil
+}
+
+// normalizeAPIBase strips the OpenAI-compatible /v1 suffix so configs written
+// for the old compatibility path keep working.
+func normalizeAPIBase(apiBase string) string {
+	apiBase = strings.TrimRight(strings.TrimSpace(apiBase), "/")
+	if apiBase == "" {
+		return DefaultAPIBase
+	}
+	return strings.TrimSuffix(apiBase, "/v1")
+}
+
+func normalizeModel(model string) string {
+	return strings.TrimPrefix(model, "ollama/")
+}
+
+// keepAliveValue sends numeric keep_alive values (e.g. "-1", "0") as numbers,
+// since Ollama reads bare numbers as seconds.
+func keepAliveValue(keepAlive string) any {
+	if n, err := strconv.Atoi(keepAlive); err == nil {
+		return n
+	}
+	return keepAlive
+}
+
+func asInt(v any) (int, bool) {
+	switch val := v.(type) {
+	case int:
+		return val, true
+	case int64:
+		return int(val), true
+	case float64:
+		return int(val), true
+	case float32:
+		return int(val), true
+	default:
+		return 0, false
+	}
+}
+
+func asFloat(v any) (float64, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case float32:
+		return float64(val), true
+	case int:
+		return float64(val), true
+	case int64:
+		return float64(val), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,224 @@
+package ollama
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestServer serves /api/show with the given body and records /api/chat requests.
+func newTestServer(t *testing.T, show string, chatResponse string, chatBody *map[string]any) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/show":
+			if show == "" {
+				http.Error(w, `{"error":"model not found"}`, http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(show))
+		case "/api/chat":
+			if chatBody != nil {
+				if err := json.NewDecoder(r.Body).Decode(chatBody); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+			_, _ = w.Write([]byte(chatResponse))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestProviderChat_NativeRequest(t *testing.T) {
+	var body map[string]any
+	server := newTestServer(t,
+		`{"capabilities":["completion","tools"],"model_info":{"general.architecture":"qwen2","qwen2.context_length":32768}}`,
+		`{"message":{"role":"assistant","content":"hi"},"done":true,"done_reason":"stop","prompt_eval_count":12,"eval_count":3}`,
+		&body,
+	)
+
+	p := NewProvider("", server.URL+"/v1/", "", WithKeepAlive("-1"))
+	resp, err := p.Chat(
+		t.Context(),
+		[]Message{{Role: "user", Content: "hello"}},
+		[]ToolDefinition{{Type: "function"}},
+		"ollama/qwen2.5:7b",
+		map[string]any{"max_tokens": 256, "temperature": 0.2, "format": "json"},
+	)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	if body["model"] != "qwen2.5:7b" {
+		t.Errorf("model = %v, want qwen2.5:7b", body["model"])
+	}
+	if body["stream"] != false {
+		t.Errorf("stream = %v, want false", body["stream"])
+	}
+	if body["keep_alive"] != float64(-1) {
+		t.Errorf("keep_alive = %v, want -1", body["keep_alive"])
+	}
+	if body["format"] != "json" {
+		t.Errorf("format = %v, want json", body["format"])
+	}
+	if _, ok := body["tools"]; !ok {
+		t.Error("tools should be sent to a tool-capable model")
+	}
+	opts, _ := body["options"].(map[string]any)
+	if opts["num_predict"] != float64(256) || opts["temperature"] != 0.2 {
+		t.Errorf("options = %v, want num_predict and temperature", opts)
+	}
+	if _, ok := opts["num_ctx"]; ok {
+		t.Errorf("num_ctx = %v, want it unset so the trained length is not allocated", opts["num_ctx"])
+	}
+
+	if resp.Content != "hi" || resp.FinishReason != "stop" {
+		t.Errorf("resp = %+v", resp)
+	}
+	if resp.Usage == nil || resp.Usage.TotalTokens != 15 {
+		t.Errorf("Usage = %+v, want 15 total tokens", resp.Usage)
+	}
+}
+
+func TestProviderChat_OmitsToolsForModelsWithoutSupport(t *testing.T) {
+	var body map[string]any
+	server := newTestServer(t,
+		`{"capabilities":["completion"]}`,
+		`{"message":{"role":"assistant","content":"ok"},"done":true}`,
+		&body,
+	)
+
+	p := NewProvider("", server.URL, "", WithNumCtx(4096))
+	_, err := p.Chat(t.Context(), []Message{{Role: "user", Content: "hi"}},
+		[]ToolDefinition{{Type: "function"}}, "gemma", nil)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if _, ok := body["tools"]; ok {
+		t.Error("tools should be omitted for models without tool support")
+	}
+	opts, _ := body["options"].(map[string]any)
+	if opts["num_ctx"] != float64(4096) {
+		t.Errorf("num_ctx = %v, want configured 4096", opts["num_ctx"])
+	}
+}
+
+func TestProviderChat_ToolCallRoundTrip(t *testing.T) {
+	var body map[string]any
+	server := newTestServer(t,
+		`{"capabilities":["completion","tools"]}`,
+		`{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"get_weather","arguments":{"city":"Paris"}}}]},"done":true,"done_reason":"stop"}`,
+		&body,
+	)
+
+	p := NewProvider("", server.URL, "")
+	resp, err := p.Chat(t.Context(), []Message{
+		{Role: "user", Content: "weather?"},
+		{Role: "assistant", ToolCalls: []ToolCall{{
+			ID:       "call_1",
+			Function: &FunctionCall{Name: "get_time", Arguments: `{"tz":"UTC"}`},
+		}}},
+		{Role: "tool", ToolCallID: "call_1", Content: "12:00"},
+	}, nil, "llama3.1", nil)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	if resp.FinishReason != "tool_calls" || len(resp.ToolCalls) != 1 {
+		t.Fatalf("resp = %+v, want one tool call", resp)
+	}
+	tc := resp.ToolCalls[0]
+	if tc.ID == "" || tc.Name != "get_weather" || tc.Arguments["city"] != "Paris" {
+		t.Errorf("tool call = %+v", tc)
+	}
+	if tc.Function == nil || tc.Function.Arguments != `{"city":"Paris"}` {
+		t.Errorf("Function = %+v", tc.Function)
+	}
+
+	messages, _ := body["messages"].([]any)
+	if len(messages) != 3 {
+		t.Fatalf("messages = %v", messages)
+	}
+	assistant := messages[1].(map[string]any)
+	calls := assistant["tool_calls"].([]any)
+	fn := calls[0].(map[string]any)["function"].(map[string]any)
+	if fn["name"] != "get_time" || fn["arguments"].(map[string]any)["tz"] != "UTC" {
+		t.Errorf("assistant tool call = %v, want object arguments", fn)
+	}
+	if tool := messages[2].(map[string]any); tool["tool_name"] != "get_time" {
+		t.Errorf("tool message = %v, want tool_name get_time", tool)
+	}
+}
+
+func TestProviderShow_ContextLengthPrecedence(t *testing.T) {
+	server := newTestServer(t,
+		`{"capabilities":["vision"],"parameters":"stop \"<eos>\"\nnum_ctx 8192","model_info":{"gemma3.context_length":131072}}`,
+		"", nil)
+
+	p := NewProvider("", server.URL, "")
+	caps, err := p.Show(t.Context(), "gemma3")
+	if err != nil {
+		t.Fatalf("Show() error = %v", err)
+	}
+	if caps.ContextLength != 8192 {
+		t.Errorf("ContextLength = %d, want Modelfile num_ctx 8192", caps.ContextLength)
+	}
+	if !caps.Vision || caps.Tools {
+		t.Errorf("caps = %+v, want vision only", caps)
+	}
+
+	server = newTestServer(t, `{"capabilities":["completion"],"model_info":{"qwen2.context_length":131072}}`, "", nil)
+	p = NewProvider("", server.URL, "")
+	if caps, err := p.Show(t.Context(), "qwen2"); err != nil || caps.ContextLength != 0 {
+		t.Errorf("Show() = %+v, %v, want no context length without num_ctx", caps, err)
+	}
+}
+
+func TestProviderChat_Images(t *testing.T) {
+	var body map[string]any
+	server := newTestServer(t,
+		`{"capabilities":["completion","vision"]}`,
+		`{"message":{"role":"assistant","content":"a cat"},"done":true}`,
+		&body,
+	)
+
+	p := NewProvider("", server.URL, "")
+	msgs := []Message{{Role: "user", Content: "what is this?", Images: []string{"aGVsbG8="}}}
+	if _, err := p.Chat(t.Context(), msgs, nil, "llava", nil); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	messages, _ := body["messages"].([]any)
+	images, _ := messages[0].(map[string]any)["images"].([]any)
+	if len(images) != 1 || images[0] != "aGVsbG8=" {
+		t.Errorf("images = %v, want the attached image", images)
+	}
+
+	server = newTestServer(t, `{"capabilities":["completion"]}`, `{"message":{"content":"ok"},"done":true}`, &body)
+	p = NewProvider("", server.URL, "")
+	if _, err := p.Chat(t.Context(), msgs, nil, "qwen2", nil); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	messages, _ = body["messages"].([]any)
+	if _, ok := messages[0].(map[string]any)["images"]; ok {
+		t.Error("images should be omitted for models without vision")
+	}
+}
+
+func TestProviderEnsureModel_MissingModel(t *testing.T) {
+	server := newTestServer(t, "", "", nil)
+
+	p := NewProvider("", server.URL, "")
+	if _, err := p.Show(t.Context(), "missing"); !errors.Is(err, ErrModelNotFound) {
+		t.Fatalf("Show() error = %v, want ErrModelNotFound", err)
+	}
+	// The test server has no /api/pull, so the pull itself fails.
+	if _, err := p.EnsureModel(t.Context(), "missing"); err == nil {
+		t.Fatal("EnsureModel() expected pull error")
+	}
+}
@@ -0,0 +1,78 @@
+package providers
+
+import (
+	"context"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/providers/ollama"
+)
+
+// ollamaPullTimeout bounds the background model pull; large models take a while.
+const ollamaPullTimeout = 30 * time.Minute
+
+// OllamaProvider uses Ollama's native API instead of its OpenAI-compatible endpoint.
+type OllamaProvider struct {
+	delegate *ollama.Provider
+}
+
+func NewOllamaProvider(cfg *config.ModelConfig) *OllamaProvider {
+	return &OllamaProvider{
+		delegate: ollama.NewProvider(
+			cfg.APIKey,
+			cfg.APIBase,
+			cfg.Proxy,
+			ollama.WithKeepAlive(cfg.KeepAlive),
+			ollama.WithNumCtx(cfg.NumCtx),
+			ollama.WithRequestTimeout(time.Duration(cfg.RequestTimeout)*time.Second),
+		),
+	}
+}
+
+func (p *OllamaProvider) Chat(
+	ctx context.Context,
+	messages []Message,
+	tools []ToolDefinition,
+	model string,
+	options map[string]any,
+) (*LLMResponse, error) {
+	return p.delegate.Chat(ctx, messages, tools, model, options)
+}
+
+func (p *OllamaProvider) GetDefaultModel() string {
+	return p.delegate.GetDefaultModel()
+}
+
+// ModelCapabilities implements CapabilityProvider using /api/show.
+func (p *OllamaProvider) ModelCapabilities(ctx context.Context, model string) (*ModelCapabilities, error) {
+	caps, err := p.delegate.Show(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+	return &ModelCapabilities{
+		ContextWindow: caps.ContextLength,
+		Vision:        caps.Vision,
+		Tools:         caps.Tools,
+	}, nil
+}
+
+// ensureModel pulls the model if Ollama does not have it yet.
+// Failures are logged; the first chat request will surface them again.
+func (p *OllamaProvider) ensureModel(model string) {
+	ctx, cancel := context.WithTimeout(context.Background(), ollamaPullTimeout)
+	defer cancel()
+
+	logger.InfoCF("provider.ollama", "Checking model availability", map[string]any{"model": model})
+	pulled, err := p.delegate.EnsureModel(ctx, model)
+	if err != nil {
+		logger.WarnCF("provider.ollama", "Model pull failed", map[string]any{
+			"model": model,
+			"error": err.Error(),
+		})
+		return
+	}
+	if pulled {
+		logger.InfoCF("provider.ollama", "Model pulled", map[string]any{"model": model})
+	}
+}
@@ -60,6 +60,7 @@ type Message struct {
 	SystemParts      []ContentBlock `json:"system_parts,omitempty"` // structured system blocks for cache-aware adapters
 	ToolCalls        []ToolCall     `json:"tool_calls,omitempty"`
 	ToolCallID       string         `json:"tool_call_id,omitempty"`
+	Images           []string       `json:"images,omitempty"` // base64-encoded images, sent by providers with vision support
 }
 
 type ToolDefinition struct {
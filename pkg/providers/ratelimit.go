@@ -0,0 +1,377 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// RateLimiter enforces requests-per-minute and tokens-per-minute limits for
+// one model_list entry using token buckets. Requests that exceed the limit
+// queue: each reservation pushes the bucket further into debt, so later
+// callers wait behind earlier ones.
+type RateLimiter struct {
+	mu       sync.Mutex
+	requests *tokenBucket // nil when RPM is unset
+	tokens   *tokenBucket // nil when TPM is unset
+	now      func() time.Time
+}
+
+// NewRateLimiter returns a limiter for the given limits, or nil if both are unset.
+func NewRateLimiter(rpm, tpm int) *RateLimiter {
+	if rpm <= 0 && tpm <= 0 {
+		return nil
+	}
+	l := &RateLimiter{now: time.Now}
+	now := l.now()
+	if rpm > 0 {
+		l.requests = newTokenBucket(rpm, now)
+	}
+	if tpm > 0 {
+		l.tokens = newTokenBucket(tpm, now)
+	}
+	return l
+}
+
+// EstimateWait returns how long a request of the given token estimate would
+// queue if it were made now.
+func (l *RateLimiter) EstimateWait(tokens int) time.Duration {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.delayLocked(l.now(), tokens)
+}
+
+// Wait reserves capacity for one request and blocks until it is available.
+// If ctx ends first, the reservation is returned and ctx.Err() is reported.
+func (l *RateLimiter) Wait(ctx context.Context, tokens int) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := l.now()
+	delay := l.delayLocked(now, tokens)
+	requestCost, tokenCost := 1.0, l.tokenCost(tokens)
+	if l.requests != nil {
+		l.requests.available -= requestCost
+	}
+	if l.tokens != nil {
+		l.tokens.available -= tokenCost
+	}
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		if l.requests != nil {
+			l.requests.refund(requestCost)
+		}
+		if l.tokens != nil {
+			l.tokens.refund(tokenCost)
+		}
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// setLimits applies new limits in place, so providers already holding the
+// limiter pick them up. Queued debt carries over, clamped to the new size.
+func (l *RateLimiter) setLimits(rpm, tpm int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.requests = resizeBucket(l.requests, rpm, now)
+	l.tokens = resizeBucket(l.tokens, tpm, now)
+}
+
+// Adjust corrects the token bucket once the real usage of a request is known.
+// A positive delta charges extra tokens, a negative one returns them.
+func (l *RateLimiter) Adjust(delta int) {
+	if l == nil || l.tokens == nil || delta == 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens.refill(l.now())
+	if delta > 0 {
+		l.tokens.available -= float64(delta)
+	} else {
+		l.tokens.refund(float64(-delta))
+	}
+}
+
+func (l *RateLimiter) delayLocked(now time.Time, tokens int) time.Duration {
+	var delay time.Duration
+	if l.requests != nil {
+		l.requests.refill(now)
+		delay = l.requests.delay(1)
+	}
+	if l.tokens != nil {
+		l.tokens.refill(now)
+		if d := l.tokens.delay(l.tokenCost(tokens)); d > delay {
+			delay = d
+		}
+	}
+	return delay
+}
+
+// tokenCost clamps a request to the bucket size so oversized requests can
+// still proceed once the bucket is full.
+func (l *RateLimiter) tokenCost(tokens int) float64 {
+	if l.tokens == nil || tokens <= 0 {
+		return 0
+	}
+	return math.Min(float64(tokens), l.tokens.capacity)
+}
+
+type tokenBucket struct {
+	capacity  float64
+	available float64
+	perSecond float64
+	last      time.Time
+}
+
+func newTokenBucket(perMinute int, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		capacity:  float64(perMinute),
+		available: float64(perMinute),
+		perSecond: float64(perMinute) / 60,
+		last:      now,
+	}
+}
+
+// resizeBucket returns b with a new per-minute rate, a fresh bucket if b is
+// nil, or nil when the limit is unset.
+func resizeBucket(b *tokenBucket, perMinute int, now time.Time) *tokenBucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	if b == nil {
+		return newTokenBucket(perMinute, now)
+	}
+	b.refill(now)
+	b.capacity = float64(perMinute)
+	b.perSecond = float64(perMinute) / 60
+	b.available = math.Min(b.capacity, b.available)
+	return b
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.available = math.Min(b.capacity, b.available+elapsed*b.perSecond)
+		b.last = now
+	}
+}
+
+func (b *tokenBucket) delay(n float64) time.Duration {
+	if b.available >= n {
+		return 0
+	}
+	return time.Duration((n - b.available) / b.perSecond * float64(time.Second))
+}
+
+func (b *tokenBucket) refund(n float64) {
+	b.available = math.Min(b.capacity, b.available+n)
+}
+
+// rateLimitRegistry holds one limiter per model_list entry so every provider
+// created for that entry (main loop, subagents, summaries, overrides) shares it.
+type rateLimitRegistry struct {
+	mu      sync.Mutex
+	byEntry map[string]*RateLimiter
+	byModel map[string][]*RateLimiter // model_name and model ID -> entry limiters
+}
+
+var rateLimits = &rateLimitRegistry{
+	byEntry: make(map[string]*RateLimiter),
+	byModel: make(map[string][]*RateLimiter),
+}
+
+func rateLimitKey(mc *config.ModelConfig) string {
+	return strings.Join([]string{mc.ModelName, mc.Model, mc.APIBase}, "\x00")
+}
+
+// forEntry returns the shared limiter for a model_list entry, or nil if it has no limits.
+func (r *rateLimitRegistry) forEntry(mc *config.ModelConfig) *RateLimiter {
+	if mc == nil || (mc.RPM <= 0 && mc.TPM <= 0) {
+		return nil
+	}
+	key := rateLimitKey(mc)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if l, ok := r.byEntry[key]; ok {
+		return l
+	}
+	l := NewRateLimiter(mc.RPM, mc.TPM)
+	r.addLocked(key, mc, l)
+	return l
+}
+
+func (r *rateLimitRegistry) addLocked(key string, mc *config.ModelConfig, l *RateLimiter) {
+	r.byEntry[key] = l
+	_, modelID := ExtractProtocol(mc.Model)
+	for _, name := range []string{mc.ModelName, modelID} {
+		if name != "" {
+			r.byModel[name] = append(r.byModel[name], l)
+		}
+	}
+}
+
+// forModel returns the least busy limiter registered for a model name or ID.
+func (r *rateLimitRegistry) forModel(model string, tokens int) *RateLimiter {
+	r.mu.Lock()
+	limiters := r.byModel[model]
+	r.mu.Unlock()
+
+	var best *RateLimiter
+	var bestWait time.Duration
+	for _, l := range limiters {
+		if w := l.EstimateWait(tokens); best == nil || w < bestWait {
+			best, bestWait = l, w
+		}
+	}
+	return best
+}
+
+// RegisterRateLimits creates limiters for every model_list entry with RPM or TPM
+// set, so queue waits can be estimated before a provider for them exists.
+// Calling it again with a reloaded model_list applies edited limits to the
+// limiters in use and lifts the limits of entries that no longer have any.
+func RegisterRateLimits(modelList []config.ModelConfig) {
+	rateLimits.reset(modelList)
+}
+
+// reset rebuilds the registry from modelList, keeping the limiter (and its
+// queue) of every entry that still has limits.
+func (r *rateLimitRegistry) reset(modelList []config.ModelConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old := r.byEntry
+	r.byEntry = make(map[string]*RateLimiter)
+	r.byModel = make(map[string][]*RateLimiter)
+	for i := range modelList {
+		mc := &modelList[i]
+		if mc.RPM <= 0 && mc.TPM <= 0 {
+			continue
+		}
+		key := rateLimitKey(mc)
+		if _, ok := r.byEntry[key]; ok {
+			continue
+		}
+		l, ok := old[key]
+		if ok {
+			l.setLimits(mc.RPM, mc.TPM)
+			delete(old, key)
+		} else {
+			l = NewRateLimiter(mc.RPM, mc.TPM)
+		}
+		r.addLocked(key, mc, l)
+	}
+	for _, l := range old {
+		l.setLimits(0, 0)
+	}
+}
+
+// EstimateRateLimitWait returns the queue wait for a request to model
+// (a model_name or model ID); zero if the model has no limits.
+func EstimateRateLimitWait(model string) time.Duration {
+	return rateLimits.forModel(model, 0).EstimateWait(0)
+}
+
+// RateLimitedProvider queues requests according to the limits of the
+// model_list entry it was created for.
+type RateLimitedProvider struct {
+	delegate LLMProvider
+	limiter  *RateLimiter
+	modelID  string
+}
+
+func NewRateLimitedProvider(delegate LLMProvider, limiter *RateLimiter, modelID string) *RateLimitedProvider {
+	return &RateLimitedProvider{delegate: delegate, limiter: limiter, modelID: modelID}
+}
+
+func (p *RateLimitedProvider) Chat(
+	ctx context.Context,
+	messages []Message,
+	tools []ToolDefinition,
+	model string,
+	options map[string]any,
+) (*LLMResponse, error) {
+	estimate := estimateRequestTokens(messages, tools)
+
+	// Fallback candidates may be sent through this provider with another model.
+	limiter := p.limiter
+	if model != p.modelID {
+		limiter = rateLimits.forModel(model, estimate)
+	}
+
+	if limiter != nil {
+		if wait := limiter.EstimateWait(estimate); wait > 0 {
+			logger.DebugCF("provider.ratelimit", "Request queued by rate limit",
+				map[string]any{"model": model, "wait": wait.String()})
+		}
+		if err := limiter.Wait(ctx, estimate); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := p.delegate.Chat(ctx, messages, tools, model, options)
+	if limiter != nil && err == nil && resp != nil && resp.Usage != nil {
+		limiter.Adjust(resp.Usage.TotalTokens - estimate)
+	}
+	return resp, err
+}
+
+func (p *RateLimitedProvider) GetDefaultModel() string {
+	return p.delegate.GetDefaultModel()
+}
+
+// ModelCapabilities forwards to the wrapped provider when it supports lookups.
+func (p *RateLimitedProvider) ModelCapabilities(ctx context.Context, model string) (*ModelCapabilities, error) {
+	cp, ok := p.delegate.(CapabilityProvider)
+	if !ok {
+		return nil, errors.ErrUnsupported
+	}
+	return cp.ModelCapabilities(ctx, model)
+}
+
+// Close forwards to the wrapped provider when it holds resources.
+func (p *RateLimitedProvider) Close() {
+	if sp, ok := p.delegate.(StatefulProvider); ok {
+		sp.Close()
+	}
+}
+
+// estimateRequestTokens approximates prompt tokens at 2.5 characters per token.
+func estimateRequestTokens(messages []Message, tools []ToolDefinition) int {
+	chars := 0
+	for _, m := range messages {
+		chars += len(m.Content)
+	}
+	if len(tools) > 0 {
+		if data, err := json.Marshal(tools); err == nil {
+			chars += len(data)
+		}
+	}
+	return chars * 2 / 5
+}
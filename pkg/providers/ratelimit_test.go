@@ -0,0 +1,215 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func newTestLimiter(rpm, tpm int, now *time.Time) *RateLimiter {
+	l := NewRateLimiter(rpm, tpm)
+	l.now = func() time.Time { return *now }
+	l.mu.Lock()
+	if l.requests != nil {
+		l.requests.last = *now
+	}
+	if l.tokens != nil {
+		l.tokens.last = *now
+	}
+	l.mu.Unlock()
+	return l
+}
+
+func TestNewRateLimiter_Unset(t *testing.T) {
+	if l := NewRateLimiter(0, 0); l != nil {
+		t.Fatalf("NewRateLimiter(0, 0) = %v, want nil", l)
+	}
+	var l *RateLimiter
+	if err := l.Wait(context.Background(), 100); err != nil {
+		t.Errorf("nil limiter Wait() error = %v", err)
+	}
+	if w := l.EstimateWait(100); w != 0 {
+		t.Errorf("nil limiter EstimateWait() = %v, want 0", w)
+	}
+}
+
+func TestRateLimiter_RPMQueues(t *testing.T) {
+	now := time.Now()
+	l := newTestLimiter(2, 0, &now)
+
+	for i := 0; i < 2; i++ {
+		if err := l.Wait(context.Background(), 0); err != nil {
+			t.Fatalf("Wait() #%d error = %v", i, err)
+		}
+	}
+	// Bucket is empty: the next request needs half a minute of refill at 2 RPM.
+	if w := l.EstimateWait(0); w != 30*time.Second {
+		t.Errorf("EstimateWait() = %v, want 30s", w)
+	}
+
+	now = now.Add(30 * time.Second)
+	if w := l.EstimateWait(0); w != 0 {
+		t.Errorf("EstimateWait() after refill = %v, want 0", w)
+	}
+}
+
+func TestRateLimiter_CancelRefunds(t *testing.T) {
+	now := time.Now()
+	l := newTestLimiter(1, 0, &now)
+
+	if err := l.Wait(context.Background(), 0); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Wait(ctx, 0); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Wait() error = %v, want context.Canceled", err)
+	}
+	// The cancelled reservation must not push later callers back.
+	if w := l.EstimateWait(0); w != time.Minute {
+		t.Errorf("EstimateWait() = %v, want 1m", w)
+	}
+}
+
+func TestRateLimiter_TPMAdjust(t *testing.T) {
+	now := time.Now()
+	l := newTestLimiter(0, 600, &now)
+
+	if err := l.Wait(context.Background(), 100); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if w := l.EstimateWait(500); w != 0 {
+		t.Errorf("EstimateWait(500) = %v, want 0", w)
+	}
+
+	// Real usage was 300 tokens more than estimated.
+	l.Adjust(300)
+	if w := l.EstimateWait(500); w != 30*time.Second {
+		t.Errorf("EstimateWait(500) after Adjust = %v, want 30s", w)
+	}
+
+	l.Adjust(-300)
+	if w := l.EstimateWait(500); w != 0 {
+		t.Errorf("EstimateWait(500) after refund = %v, want 0", w)
+	}
+}
+
+func TestRateLimiter_OversizedRequestClamped(t *testing.T) {
+	now := time.Now()
+	l := newTestLimiter(0, 100, &now)
+
+	if w := l.EstimateWait(1000); w != 0 {
+		t.Errorf("EstimateWait(1000) with full bucket = %v, want 0", w)
+	}
+}
+
+func TestRateLimitRegistry_SharedPerEntry(t *testing.T) {
+	r := &rateLimitRegistry{
+		byEntry: make(map[string]*RateLimiter),
+		byModel: make(map[string][]*RateLimiter),
+	}
+	mc := config.ModelConfig{ModelName: "fast", Model: "openai/gpt-4o-mini", APIBase: "https://a", RPM: 10}
+
+	a := r.forEntry(&mc)
+	copied := mc
+	b := r.forEntry(&copied)
+	if a == nil || a != b {
+		t.Fatalf("forEntry() returned different limiters for the same entry: %p %p", a, b)
+	}
+	if r.forModel("fast", 0) != a || r.forModel("gpt-4o-mini", 0) != a {
+		t.Error("forModel() should find the entry by model_name and model ID")
+	}
+	if r.forEntry(&config.ModelConfig{ModelName: "free", Model: "openai/x"}) != nil {
+		t.Error("forEntry() without limits should return nil")
+	}
+}
+
+func TestRateLimitRegistry_PicksLeastBusy(t *testing.T) {
+	r := &rateLimitRegistry{
+		byEntry: make(map[string]*RateLimiter),
+		byModel: make(map[string][]*RateLimiter),
+	}
+	busy := r.forEntry(&config.ModelConfig{ModelName: "lb", Model: "openai/gpt", APIBase: "https://a", RPM: 1})
+	idle := r.forEntry(&config.ModelConfig{ModelName: "lb", Model: "openai/gpt", APIBase: "https://b", RPM: 1})
+	if err := busy.Wait(context.Background(), 0); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if got := r.forModel("lb", 0); got != idle {
+		t.Error("forModel() should pick the limiter with the shortest queue")
+	}
+}
+
+func TestRateLimitRegistry_Reset(t *testing.T) {
+	r := &rateLimitRegistry{
+		byEntry: make(map[string]*RateLimiter),
+		byModel: make(map[string][]*RateLimiter),
+	}
+	edited := config.ModelConfig{ModelName: "a", Model: "openai/a", RPM: 1}
+	removed := config.ModelConfig{ModelName: "b", Model: "openai/b", RPM: 1}
+	r.reset([]config.ModelConfig{edited, removed})
+	a, b := r.forEntry(&edited), r.forEntry(&removed)
+	for _, l := range []*RateLimiter{a, b} {
+		if err := l.Wait(context.Background(), 0); err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+	}
+
+	// A reloaded config raises one limit and drops the other.
+	edited.RPM = 60
+	removed.RPM = 0
+	r.reset([]config.ModelConfig{edited, removed})
+	if got := r.forEntry(&edited); got != a {
+		t.Error("an edited entry should keep the limiter providers already hold")
+	}
+	if w := a.EstimateWait(0); w > time.Second {
+		t.Errorf("EstimateWait() after raising RPM = %v, want at most 1s", w)
+	}
+	if w := b.EstimateWait(0); w != 0 {
+		t.Errorf("EstimateWait() after removing the limit = %v, want 0", w)
+	}
+	if r.forModel("b", 0) != nil {
+		t.Error("an entry without limits should be dropped from the registry")
+	}
+}
+
+func TestRateLimitedProvider_AdjustsUsage(t *testing.T) {
+	now := time.Now()
+	l := newTestLimiter(0, 600, &now)
+	delegate := &countingProvider{resp: &LLMResponse{
+		Content: "ok",
+		Usage:   &UsageInfo{TotalTokens: 600},
+	}}
+	p := NewRateLimitedProvider(delegate, l, "gpt")
+
+	if _, err := p.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil, "gpt", nil); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if delegate.calls != 1 {
+		t.Errorf("delegate calls = %d, want 1", delegate.calls)
+	}
+	if w := l.EstimateWait(60); w != 6*time.Second {
+		t.Errorf("EstimateWait(60) = %v, want 6s after charging real usage", w)
+	}
+}
+
+func TestRateLimitedProvider_CancelledWhileQueued(t *testing.T) {
+	now := time.Now()
+	l := newTestLimiter(1, 0, &now)
+	delegate := &countingProvider{resp: &LLMResponse{Content: "ok"}}
+	p := NewRateLimitedProvider(delegate, l, "gpt")
+
+	if _, err := p.Chat(context.Background(), nil, nil, "gpt", nil); err != nil {
+		t.Fatalf("first Chat() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := p.Chat(ctx, nil, nil, "gpt", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("second Chat() error = %v, want deadline exceeded", err)
+	}
+	if delegate.calls != 1 {
+		t.Errorf("delegate calls = %d, want 1", delegate.calls)
+	}
+}
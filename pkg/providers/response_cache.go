@@ -0,0 +1,343 @@
+package providers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/fileutil"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+const (
+	DefaultResponseCacheTTL        = time.Hour
+	DefaultResponseCacheMaxEntries = 500
+
+	// responseCacheLogEvery controls how often per-site hit rates are logged at info level.
+	responseCacheLogEvery = 20
+)
+
+// ResponseCache is a bounded on-disk LRU of LLM responses with a TTL.
+// Each entry is one JSON file named after its key; file mtimes record
+// last access so the LRU order survives restarts.
+type ResponseCache struct {
+	dir        string
+	ttl        time.Duration
+	maxEntries int
+
+	mu     sync.Mutex
+	access map[string]time.Time // key -> last access
+	stats  map[string]*ResponseCacheStats
+}
+
+// ResponseCacheStats counts lookups for one call site.
+type ResponseCacheStats struct {
+	Hits   int64
+	Misses int64
+}
+
+// HitRate returns the fraction of lookups served from the cache.
+func (s ResponseCacheStats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+type responseCacheEntry struct {
+	CreatedAt time.Time    `json:"created_at"`
+	Site      string       `json:"site,omitempty"`
+	Model     string       `json:"model"`
+	Response  *LLMResponse `json:"response"`
+}
+
+// NewResponseCache opens (or creates) a response cache in dir.
+// Non-positive ttl or maxEntries fall back to the defaults.
+func NewResponseCache(dir string, ttl time.Duration, maxEntries int) (*ResponseCache, error) {
+	if ttl <= 0 {
+		ttl = DefaultResponseCacheTTL
+	}
+	if maxEntries <= 0 {
+		maxEntries = DefaultResponseCacheMaxEntries
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	c := &ResponseCache{
+		dir:        dir,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		access:     make(map[string]time.Time),
+		stats:      make(map[string]*ResponseCacheStats),
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		key, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		c.access[key] = info.ModTime()
+	}
+	c.mu.Lock()
+	c.evictLocked()
+	c.mu.Unlock()
+
+	return c, nil
+}
+
+// Get returns a cached response, or nil on a miss or expired entry.
+func (c *ResponseCache) Get(key string) *LLMResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.access[key]; !ok {
+		return nil
+	}
+	path := c.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		delete(c.access, key)
+		return nil
+	}
+	var entry responseCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Response == nil {
+		c.removeLocked(key)
+		return nil
+	}
+	if time.Since(entry.CreatedAt) > c.ttl {
+		c.removeLocked(key)
+		return nil
+	}
+
+	now := time.Now()
+	c.access[key] = now
+	_ = os.Chtimes(path, now, now)
+	restoreToolCalls(entry.Response.ToolCalls)
+	return entry.Response
+}
+
+// restoreToolCalls refills the fields of ToolCall that are not serialized.
+func restoreToolCalls(toolCalls []ToolCall) {
+	for i := range toolCalls {
+		tc := &toolCalls[i]
+		if tc.Function == nil {
+			continue
+		}
+		if tc.Name == "" {
+			tc.Name = tc.Function.Name
+		}
+		if tc.Arguments == nil {
+			tc.Arguments = map[string]any{}
+			if tc.Function.Arguments != "" {
+				_ = json.Unmarshal([]byte(tc.Function.Arguments), &tc.Arguments)
+			}
+		}
+	}
+}
+
+// Put stores a response and evicts the least recently used entries beyond the bound.
+func (c *ResponseCache) Put(key, site, model string, resp *LLMResponse) error {
+	// Name and Arguments are not serialized; make sure Function carries them.
+	stored := *resp
+	stored.ToolCalls = make([]ToolCall, len(resp.ToolCalls))
+	for i, tc := range resp.ToolCalls {
+		if tc.Function == nil {
+			argsJSON, _ := json.Marshal(tc.Arguments)
+			tc.Function = &FunctionCall{Name: tc.Name, Arguments: string(argsJSON)}
+		}
+		stored.ToolCalls[i] = tc
+	}
+
+	data, err := json.Marshal(responseCacheEntry{
+		CreatedAt: time.Now(),
+		Site:      site,
+		Model:     model,
+		Response:  &stored,
+	})
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := fileutil.WriteFileAtomic(c.path(key), data, 0o600); err != nil {
+		return err
+	}
+	c.access[key] = time.Now()
+	c.evictLocked()
+	return nil
+}
+
+// Len returns the number of entries currently indexed.
+func (c *ResponseCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.access)
+}
+
+// Stats returns a snapshot of the lookup counters per call site.
+func (c *ResponseCache) Stats() map[string]ResponseCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]ResponseCacheStats, len(c.stats))
+	for site, s := range c.stats {
+		out[site] = *s
+	}
+	return out
+}
+
+// record counts a lookup and logs the running hit rate for the site.
+func (c *ResponseCache) record(site string, hit bool) {
+	c.mu.Lock()
+	s, ok := c.stats[site]
+	if !ok {
+		s = &ResponseCacheStats{}
+		c.stats[site] = s
+	}
+	if hit {
+		s.Hits++
+	} else {
+		s.Misses++
+	}
+	snapshot := *s
+	c.mu.Unlock()
+
+	fields := map[string]any{
+		"site":     site,
+		"hit":      hit,
+		"hits":     snapshot.Hits,
+		"misses":   snapshot.Misses,
+		"hit_rate": snapshot.HitRate(),
+	}
+	logger.DebugCF("provider.cache", "Response cache lookup", fields)
+	if (snapshot.Hits+snapshot.Misses)%responseCacheLogEvery == 0 {
+		logger.InfoCF("provider.cache", "Response cache hit rate", fields)
+	}
+}
+
+func (c *ResponseCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+func (c *ResponseCache) removeLocked(key string) {
+	delete(c.access, key)
+	_ = os.Remove(c.path(key))
+}
+
+func (c *ResponseCache) evictLocked() {
+	if len(c.access) <= c.maxEntries {
+		return
+	}
+	keys := make([]string, 0, len(c.access))
+	for k := range c.access {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return c.access[keys[i]].Before(c.access[keys[j]]) })
+	for _, k := range keys[:len(keys)-c.maxEntries] {
+		c.removeLocked(k)
+	}
+}
+
+// CachingProvider is an LLMProvider decorator that serves repeated identical
+// requests from a ResponseCache. It is meant for deterministic call sites
+// (summaries, heartbeats), not for interactive chat.
+type CachingProvider struct {
+	delegate  LLMProvider
+	cache     *ResponseCache
+	site      string
+	normalize func([]Message) []Message
+}
+
+type CachingOption func(*CachingProvider)
+
+// WithCacheKeyNormalizer rewrites messages before they are hashed, e.g. to
+// drop per-request context such as timestamps. The request itself is unchanged.
+func WithCacheKeyNormalizer(fn func([]Message) []Message) CachingOption {
+	return func(p *CachingProvider) {
+		p.normalize = fn
+	}
+}
+
+// NewCachingProvider wraps provider with cache for the named call site.
+func NewCachingProvider(provider LLMProvider, cache *ResponseCache, site string, opts ...CachingOption) *CachingProvider {
+	p := &CachingProvider{delegate: provider, cache: cache, site: site}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(p)
+		}
+	}
+	return p
+}
+
+func (p *CachingProvider) Chat(
+	ctx context.Context,
+	messages []Message,
+	tools []ToolDefinition,
+	model string,
+	options map[string]any,
+) (*LLMResponse, error) {
+	keyMessages := messages
+	if p.normalize != nil {
+		keyMessages = p.normalize(messages)
+	}
+	key, err := ResponseCacheKey(keyMessages, tools, model, options)
+	if err != nil {
+		return p.delegate.Chat(ctx, messages, tools, model, options)
+	}
+
+	if resp := p.cache.Get(key); resp != nil {
+		p.cache.record(p.site, true)
+		return resp, nil
+	}
+	p.cache.record(p.site, false)
+
+	resp, err := p.delegate.Chat(ctx, messages, tools, model, options)
+	if err != nil {
+		return nil, err
+	}
+	if resp != nil && (resp.Content != "" || len(resp.ToolCalls) > 0) {
+		if err := p.cache.Put(key, p.site, model, resp); err != nil {
+			logger.WarnCF("provider.cache", "Failed to store cached response",
+				map[string]any{"site": p.site, "error": err.Error()})
+		}
+	}
+	return resp, nil
+}
+
+func (p *CachingProvider) GetDefaultModel() string {
+	return p.delegate.GetDefaultModel()
+}
+
+// ResponseCacheKey hashes everything that determines a response.
+// Map keys are sorted by encoding/json, so equal inputs give equal keys.
+func ResponseCacheKey(messages []Message, tools []ToolDefinition, model string, options map[string]any) (string, error) {
+	data, err := json.Marshal(struct {
+		Model    string           `json:"model"`
+		Messages []Message        `json:"messages"`
+		Tools    []ToolDefinition `json:"tools,omitempty"`
+		Options  map[string]any   `json:"options,omitempty"`
+	}{model, messages, tools, options})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,153 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// countingProvider returns a fixed response and counts calls.
+type countingProvider struct {
+	calls int
+	resp  *LLMResponse
+	err   error
+}
+
+func (p *countingProvider) Chat(
+	ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]any,
+) (*LLMResponse, error) {
+	p.calls++
+	return p.resp, p.err
+}
+
+func (p *countingProvider) GetDefaultModel() string { return "counting" }
+
+func TestCachingProvider_HitAndMiss(t *testing.T) {
+	cache, err := NewResponseCache(t.TempDir(), time.Hour, 10)
+	if err != nil {
+		t.Fatalf("NewResponseCache() error = %v", err)
+	}
+	inner := &countingProvider{resp: &LLMResponse{Content: "summary", FinishReason: "stop"}}
+	p := NewCachingProvider(inner, cache, "summary")
+
+	msgs := []Message{{Role: "user", Content: "summarize this"}}
+	opts := map[string]any{"temperature": 0.3, "max_tokens": 1024}
+
+	for i := 0; i < 3; i++ {
+		resp, err := p.Chat(context.Background(), msgs, nil, "model-a", opts)
+		if err != nil {
+			t.Fatalf("Chat() error = %v", err)
+		}
+		if resp.Content != "summary" {
+			t.Errorf("Content = %q, want summary", resp.Content)
+		}
+	}
+	if inner.calls != 1 {
+		t.Errorf("inner calls = %d, want 1", inner.calls)
+	}
+
+	// Any change in model or options is a different key.
+	_, _ = p.Chat(context.Background(), msgs, nil, "model-b", opts)
+	_, _ = p.Chat(context.Background(), msgs, nil, "model-a", map[string]any{"temperature": 0.5})
+	if inner.calls != 3 {
+		t.Errorf("inner calls = %d, want 3", inner.calls)
+	}
+
+	stats := cache.Stats()["summary"]
+	if stats.Hits != 2 || stats.Misses != 3 {
+		t.Errorf("stats = %+v, want 2 hits / 3 misses", stats)
+	}
+}
+
+func TestCachingProvider_DoesNotCacheErrors(t *testing.T) {
+	cache, _ := NewResponseCache(t.TempDir(), time.Hour, 10)
+	inner := &countingProvider{err: errors.New("boom")}
+	p := NewCachingProvider(inner, cache, "heartbeat")
+
+	msgs := []Message{{Role: "user", Content: "ping"}}
+	for i := 0; i < 2; i++ {
+		if _, err := p.Chat(context.Background(), msgs, nil, "m", nil); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+	if inner.calls != 2 || cache.Len() != 0 {
+		t.Errorf("calls = %d, entries = %d; errors must not be cached", inner.calls, cache.Len())
+	}
+}
+
+func TestCachingProvider_KeyNormalizer(t *testing.T) {
+	cache, _ := NewResponseCache(t.TempDir(), time.Hour, 10)
+	inner := &countingProvider{resp: &LLMResponse{Content: "ok"}}
+	dropSystem := func(msgs []Message) []Message { return msgs[1:] }
+	p := NewCachingProvider(inner, cache, "heartbeat", WithCacheKeyNormalizer(dropSystem))
+
+	_, _ = p.Chat(context.Background(), []Message{{Role: "system", Content: "10:00"}, {Role: "user", Content: "hi"}}, nil, "m", nil)
+	_, _ = p.Chat(context.Background(), []Message{{Role: "system", Content: "10:05"}, {Role: "user", Content: "hi"}}, nil, "m", nil)
+	if inner.calls != 1 {
+		t.Errorf("inner calls = %d, want 1", inner.calls)
+	}
+}
+
+func TestResponseCache_TTLAndPersistence(t *testing.T) {
+	dir := t.TempDir()
+	cache, _ := NewResponseCache(dir, time.Hour, 10)
+
+	resp := &LLMResponse{
+		Content:      "",
+		FinishReason: "tool_calls",
+		ToolCalls: []ToolCall{{
+			ID: "call_1", Type: "function", Name: "read_file",
+			Arguments: map[string]any{"path": "a.txt"},
+		}},
+	}
+	if err := cache.Put("k1", "heartbeat", "m", resp); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	reopened, err := NewResponseCache(dir, time.Hour, 10)
+	if err != nil {
+		t.Fatalf("NewResponseCache() error = %v", err)
+	}
+	got := reopened.Get("k1")
+	if got == nil || len(got.ToolCalls) != 1 {
+		t.Fatalf("Get() after reopen = %+v, want cached tool call", got)
+	}
+	if got.ToolCalls[0].Name != "read_file" || got.ToolCalls[0].Arguments["path"] != "a.txt" {
+		t.Errorf("tool call not restored: %+v", got.ToolCalls[0])
+	}
+
+	expired, _ := NewResponseCache(dir, time.Nanosecond, 10)
+	time.Sleep(time.Millisecond)
+	if expired.Get("k1") != nil {
+		t.Error("expected expired entry to be a miss")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "k1.json")); !os.IsNotExist(err) {
+		t.Error("expired entry should be removed from disk")
+	}
+}
+
+func TestResponseCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache, _ := NewResponseCache(t.TempDir(), time.Hour, 2)
+	resp := &LLMResponse{Content: "x"}
+
+	_ = cache.Put("a", "s", "m", resp)
+	time.Sleep(5 * time.Millisecond)
+	_ = cache.Put("b", "s", "m", resp)
+	time.Sleep(5 * time.Millisecond)
+	cache.Get("a") // a is now more recent than b
+	time.Sleep(5 * time.Millisecond)
+	_ = cache.Put("c", "s", "m", resp)
+
+	if cache.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", cache.Len())
+	}
+	if cache.Get("b") != nil {
+		t.Error("b should have been evicted")
+	}
+	if cache.Get("a") == nil || cache.Get("c") == nil {
+		t.Error("a and c should remain")
+	}
+}
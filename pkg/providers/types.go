@@ -37,6 +37,20 @@ type StatefulProvider interface {
 	Close()
 }
 
+// ModelCapabilities describes a model as reported by its backend.
+// Zero values mean "unknown".
+type ModelCapabilities struct {
+	ContextWindow int
+	Vision        bool
+	Tools         bool
+}
+
+// CapabilityProvider is implemented by providers that can look up model
+// capabilities (context window, vision, tool support) at runtime.
+type CapabilityProvider interface {
+	ModelCapabilities(ctx context.Context, model string) (*ModelCapabilities, error)
+}
+
 // FailoverReason classifies why an LLM request failed for fallback decisions.
 type FailoverReason string
 
@@ -0,0 +1,244 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package redact masks credentials and, optionally, personal data in text
+// before it is logged, persisted or sent to a third party.
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// minSecretLen keeps short config values (ports, "true", placeholders) from
+// being treated as secrets and blanking unrelated text.
+const minSecretLen = 8
+
+type pattern struct {
+	re   *regexp.Regexp
+	kind string
+	// keep is the number of leading submatches preserved in the output,
+	// e.g. the "password=" in "password=hunter2".
+	keep int
+	// valid, when set, must accept the match for it to be redacted.
+	valid func(string) bool
+}
+
+// tokenPatterns match credential formats that are never legitimate content.
+var tokenPatterns = []pattern{
+	{re: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`), kind: "private_key"},
+	{re: regexp.MustCompile(`\bsk-(?:proj-|ant-[a-z0-9]+-)?[A-Za-z0-9_\-]{16,}`), kind: "api_key"},
+	{re: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{22,})`), kind: "github_token"},
+	{re: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9\-]{10,}|\bxapp-[A-Za-z0-9\-]{10,}`), kind: "slack_token"},
+	{re: regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]{8,}\.eyJ[A-Za-z0-9_\-]{8,}\.[A-Za-z0-9_\-]{8,}`), kind: "jwt"},
+	{re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`), kind: "aws_access_key"},
+	{re: regexp.MustCompile(`(?i)(aws_secret_access_key["']?\s*[=:]\s*["']?)[A-Za-z0-9/+=]{40}`), kind: "aws_secret", keep: 1},
+	{re: regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}`), kind: "google_api_key"},
+	{re: regexp.MustCompile(`(?i)(\bbearer\s+)[A-Za-z0-9._~+/\-]{20,}=*`), kind: "bearer", keep: 1},
+	{re: regexp.MustCompile(`(?im)(^\s*machine\s+\S+.*?\bpassword\s+)\S+`), kind: "password", keep: 1},
+	{
+		re:   regexp.MustCompile(`(?i)(\b(?:password|passwd|secret|api[_-]?key|access[_-]?token|auth[_-]?token)["']?\s*[=:]\s*["']?)[^\s"',;]{6,}`),
+		kind: "credential",
+		keep: 1,
+	},
+}
+
+// piiPatterns are opt-in because they also match ordinary conversation.
+var piiPatterns = []pattern{
+	{re: regexp.MustCompile(`\b[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}\b`), kind: "email"},
+	{re: regexp.MustCompile(`\+\d{1,3}[\s.\-]?\(?\d{1,4}\)?(?:[\s.\-]?\d{2,4}){2,4}\b`), kind: "phone"},
+	{re: regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`), kind: "card", valid: luhn},
+}
+
+// Options configures a Redactor.
+type Options struct {
+	Enabled bool     // when false, text passes through unchanged
+	PII     bool     // also mask emails, phone numbers and card numbers
+	Extra   []string // additional regular expressions, masked as "custom"
+}
+
+// Redactor masks registered secret values and known token patterns.
+// It is safe for concurrent use.
+type Redactor struct {
+	mu       sync.RWMutex
+	enabled  bool
+	pii      bool
+	extra    []pattern
+	secrets  []string // sorted longest first so overlapping values mask fully
+	replacer *strings.Replacer
+}
+
+// New returns an enabled Redactor with the built-in token patterns.
+func New() *Redactor {
+	return &Redactor{enabled: true}
+}
+
+var std = New()
+
+// Default returns the process-wide redactor used by the logger and session store.
+func Default() *Redactor {
+	return std
+}
+
+// Configure applies opts. Invalid extra patterns are reported and skipped.
+func (r *Redactor) Configure(opts Options) error {
+	var extra []pattern
+	var errs []string
+	for _, expr := range opts.Extra {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%q: %v", expr, err))
+			continue
+		}
+		extra = append(extra, pattern{re: re, kind: "custom"})
+	}
+
+	r.mu.Lock()
+	r.enabled = opts.Enabled
+	r.pii = opts.PII
+	r.extra = extra
+	r.mu.Unlock()
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid redaction patterns: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// AddSecrets registers literal values (API keys, bot tokens) to mask wherever
+// they appear. Values shorter than 8 characters are ignored.
+func (r *Redactor) AddSecrets(values ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	seen := make(map[string]bool, len(r.secrets))
+	for _, s := range r.secrets {
+		seen[s] = true
+	}
+	changed := false
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if len(v) < minSecretLen || seen[v] {
+			continue
+		}
+		seen[v] = true
+		r.secrets = append(r.secrets, v)
+		changed = true
+	}
+	if !changed {
+		return
+	}
+
+	sort.Slice(r.secrets, func(i, j int) bool { return len(r.secrets[i]) > len(r.secrets[j]) })
+	pairs := make([]string, 0, 2*len(r.secrets))
+	for _, s := range r.secrets {
+		pairs = append(pairs, s, "[REDACTED:secret]")
+	}
+	r.replacer = strings.NewReplacer(pairs...)
+}
+
+// String returns s with secrets and matching patterns masked.
+func (r *Redactor) String(s string) string {
+	if s == "" {
+		return s
+	}
+
+	r.mu.RLock()
+	enabled, pii, extra, replacer := r.enabled, r.pii, r.extra, r.replacer
+	r.mu.RUnlock()
+
+	if !enabled {
+		return s
+	}
+	if replacer != nil {
+		s = replacer.Replace(s)
+	}
+	s = applyPatterns(s, tokenPatterns)
+	if pii {
+		s = applyPatterns(s, piiPatterns)
+	}
+	return applyPatterns(s, extra)
+}
+
+// Value redacts strings inside common log field values. Other values are
+// returned unchanged.
+func (r *Redactor) Value(v any) any {
+	switch val := v.(type) {
+	case string:
+		return r.String(val)
+	case []string:
+		out := make([]string, len(val))
+		for i, s := range val {
+			out[i] = r.String(s)
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			out[k] = r.Value(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = r.Value(item)
+		}
+		return out
+	case error:
+		return r.String(val.Error())
+	case fmt.Stringer:
+		return r.String(val.String())
+	}
+	return v
+}
+
+func applyPatterns(s string, patterns []pattern) string {
+	for _, p := range patterns {
+		p := p
+		s = p.re.ReplaceAllStringFunc(s, func(match string) string {
+			if p.valid != nil && !p.valid(match) {
+				return match
+			}
+			prefix := ""
+			if p.keep > 0 {
+				if sub := p.re.FindStringSubmatch(match); len(sub) > p.keep {
+					prefix = strings.Join(sub[1:p.keep+1], "")
+				}
+			}
+			return prefix + "[REDACTED:" + p.kind + "]"
+		})
+	}
+	return s
+}
+
+// luhn reports whether the digits in s form a valid card number checksum.
+func luhn(s string) bool {
+	sum, n, double := 0, 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
+
+// String masks s with the default redactor.
+func String(s string) string {
+	return std.String(s)
+}
@@ -0,0 +1,131 @@
+package redact
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Test tokens are assembled at runtime so the source never contains a
+// string that secret scanners would flag.
+var (
+	fakeOpenAI = "sk-" + "proj-" + strings.Repeat("A1b2", 8)
+	fakeGitHub = "ghp_" + strings.Repeat("x", 36)
+	fakeSlack  = "xoxb-" + "1234567890-abcdefghij"
+	fakeJWT    = "eyJ" + "hbGciOiJIUzI1NiJ9" + ".eyJ" + "zdWIiOiIxMjM0In0" + "." + strings.Repeat("s", 20)
+	fakeAWS    = "AKIA" + "ABCDEFGHIJKLMNOP"
+)
+
+func TestRedactor_TokenPatterns(t *testing.T) {
+	r := New()
+	tests := []struct {
+		in   string
+		kind string
+	}{
+		{"OPENAI_API_KEY=" + fakeOpenAI, "api_key"},
+		{"token " + fakeGitHub + " end", "github_token"},
+		{"SLACK=" + fakeSlack, "slack_token"},
+		{"Authorization: Bearer " + strings.Repeat("t0k", 10), "bearer"},
+		{"jwt=" + fakeJWT, "jwt"},
+		{"aws " + fakeAWS, "aws_access_key"},
+		{"aws_secret_access_key = " + strings.Repeat("k", 40), "aws_secret"},
+		{"machine api.github.com login me password hunter2hunter2", "password"},
+		{`{"password": "correct-horse"}`, "credential"},
+	}
+	for _, tt := range tests {
+		got := r.String(tt.in)
+		if !strings.Contains(got, "[REDACTED:"+tt.kind+"]") {
+			t.Errorf("String(%q) = %q, want [REDACTED:%s]", tt.in, got, tt.kind)
+		}
+	}
+}
+
+func TestRedactor_KeepsPrefix(t *testing.T) {
+	r := New()
+	got := r.String("password=hunter2hunter2 rest")
+	if got != "password=[REDACTED:credential] rest" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestRedactor_LeavesOrdinaryTextAlone(t *testing.T) {
+	r := New()
+	for _, in := range []string{
+		"The skill uses the sk- prefix for keys",
+		"Run at 2026-10-18 12:00:00 for 3 minutes",
+		"user@example.com wrote a password",
+	} {
+		if got := r.String(in); got != in {
+			t.Errorf("String(%q) = %q, want unchanged", in, got)
+		}
+	}
+}
+
+func TestRedactor_Secrets(t *testing.T) {
+	r := New()
+	r.AddSecrets("short", "my-telegram-bot-token-123", "")
+	got := r.String("token is my-telegram-bot-token-123, short stays")
+	if got != "token is [REDACTED:secret], short stays" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestRedactor_PII(t *testing.T) {
+	r := New()
+	in := "mail jane.doe@example.com, call +1 415 555 0100, card 4111 1111 1111 1111, order 1234567890123"
+	if got := r.String(in); got != in {
+		t.Errorf("PII should be kept unless enabled, got %q", got)
+	}
+
+	if err := r.Configure(Options{Enabled: true, PII: true}); err != nil {
+		t.Fatal(err)
+	}
+	got := r.String(in)
+	for _, kind := range []string{"email", "phone", "card"} {
+		if !strings.Contains(got, "[REDACTED:"+kind+"]") {
+			t.Errorf("String() = %q, missing %s", got, kind)
+		}
+	}
+	if !strings.Contains(got, "order 1234567890123") {
+		t.Errorf("non-Luhn number should be kept: %q", got)
+	}
+}
+
+func TestRedactor_ConfigureExtraAndDisable(t *testing.T) {
+	r := New()
+	err := r.Configure(Options{Enabled: true, Extra: []string{`INTERNAL-\d{4}`, `(`}})
+	if err == nil {
+		t.Error("Configure() should report the invalid pattern")
+	}
+	if got := r.String("id INTERNAL-1234"); got != "id [REDACTED:custom]" {
+		t.Errorf("String() = %q", got)
+	}
+
+	_ = r.Configure(Options{Enabled: false})
+	if got := r.String(fakeGitHub); got != fakeGitHub {
+		t.Errorf("disabled redactor changed input: %q", got)
+	}
+}
+
+func TestRedactor_Value(t *testing.T) {
+	r := New()
+	got := r.Value(map[string]any{
+		"args":  map[string]any{"command": "echo " + fakeGitHub},
+		"list":  []string{fakeSlack},
+		"error": errors.New("bad key " + fakeOpenAI),
+		"n":     3,
+	}).(map[string]any)
+
+	if s := got["args"].(map[string]any)["command"].(string); strings.Contains(s, fakeGitHub) {
+		t.Errorf("nested map not redacted: %q", s)
+	}
+	if s := got["list"].([]string)[0]; strings.Contains(s, fakeSlack) {
+		t.Errorf("slice not redacted: %q", s)
+	}
+	if s := got["error"].(string); strings.Contains(s, fakeOpenAI) {
+		t.Errorf("error not redacted: %q", s)
+	}
+	if got["n"] != 3 {
+		t.Errorf("non-string value changed: %v", got["n"])
+	}
+}
@@ -144,6 +144,12 @@ func normalizeChannel(channel string) string {
 	return c
 }
 
+// LinkedIdentity returns the canonical name that identityLinks assigns to
+// peerID on channel, or "" if the peer is not linked.
+func LinkedIdentity(identityLinks map[string][]string, channel, peerID string) string {
+	return resolveLinkedPeerID(identityLinks, channel, peerID)
+}
+
 func resolveLinkedPeerID(identityLinks map[string][]string, channel, peerID string) string {
 	if len(identityLinks) == 0 {
 		return ""
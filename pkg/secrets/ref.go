@@ -0,0 +1,76 @@
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	schemeSecret = "secret://"
+	schemeEnv    = "env://"
+	schemeFile   = "file://"
+)
+
+// IsReference reports whether v is a secret://, env:// or file:// reference.
+func IsReference(v string) bool {
+	return strings.HasPrefix(v, schemeSecret) ||
+		strings.HasPrefix(v, schemeEnv) ||
+		strings.HasPrefix(v, schemeFile)
+}
+
+// Resolver turns references into their values. The store is opened lazily,
+// so configs without secret:// references never touch the key.
+type Resolver struct {
+	storePath string
+	keyFile   string
+	store     *Store
+}
+
+// NewResolver returns a resolver backed by the store at storePath.
+func NewResolver(storePath, keyFile string) *Resolver {
+	return &Resolver{storePath: storePath, keyFile: keyFile}
+}
+
+// Resolve returns the value a reference points to. Non-reference strings
+// are returned unchanged.
+func (r *Resolver) Resolve(ref string) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, schemeSecret):
+		name := strings.TrimPrefix(ref, schemeSecret)
+		if name == "" {
+			return "", fmt.Errorf("empty secret name in %q", ref)
+		}
+		if r.store == nil {
+			store, err := Open(r.storePath, r.keyFile)
+			if err != nil {
+				return "", err
+			}
+			r.store = store
+		}
+		return r.store.Get(name)
+
+	case strings.HasPrefix(ref, schemeEnv):
+		name := strings.TrimPrefix(ref, schemeEnv)
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return v, nil
+
+	case strings.HasPrefix(ref, schemeFile):
+		path := strings.TrimPrefix(ref, schemeFile)
+		if strings.HasPrefix(path, "~/") {
+			home, _ := os.UserHomeDir()
+			path = filepath.Join(home, path[2:])
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		// Secret files (Docker, systemd credentials) usually end with a newline.
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	return ref, nil
+}
@@ -0,0 +1,63 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsReference(t *testing.T) {
+	tests := map[string]bool{
+		"secret://openai":       true,
+		"env://OPENAI_API_KEY":  true,
+		"file:///run/secrets/x": true,
+		"sk-plain":              false,
+		"https://example.com":   false,
+		"":                      false,
+	}
+	for in, want := range tests {
+		if got := IsReference(in); got != want {
+			t.Errorf("IsReference(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	t.Setenv(PassphraseEnv, "")
+	dir := t.TempDir()
+	storePath, keyFile := filepath.Join(dir, "secrets.json"), filepath.Join(dir, "secrets.key")
+
+	store, _ := Open(storePath, keyFile)
+	if err := store.Set("openai", "sk-from-store"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	secretFile := filepath.Join(dir, "token")
+	if err := os.WriteFile(secretFile, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PICOCLAW_TEST_SECRET", "from-env")
+
+	r := NewResolver(storePath, keyFile)
+	tests := map[string]string{
+		"secret://openai":            "sk-from-store",
+		"env://PICOCLAW_TEST_SECRET": "from-env",
+		"file://" + secretFile:       "from-file",
+		"plain":                      "plain",
+	}
+	for ref, want := range tests {
+		got, err := r.Resolve(ref)
+		if err != nil {
+			t.Errorf("Resolve(%q) error = %v", ref, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("Resolve(%q) = %q, want %q", ref, got, want)
+		}
+	}
+
+	for _, ref := range []string{"secret://missing", "env://PICOCLAW_TEST_UNSET", "file://" + filepath.Join(dir, "nope"), "secret://"} {
+		if _, err := r.Resolve(ref); err == nil {
+			t.Errorf("Resolve(%q) expected error", ref)
+		}
+	}
+}
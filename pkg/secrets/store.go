@@ -0,0 +1,324 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package secrets implements a small encrypted key/value store for credentials
+// and the secret://, env:// and file:// references that config values may use.
+package secrets
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+
+	"github.com/sipeed/picoclaw/pkg/fileutil"
+)
+
+// PassphraseEnv, when set, derives the store key from a passphrase instead of
+// the key file. A store created with a passphrase always needs it.
+const PassphraseEnv = "PICOCLAW_SECRETS_PASSPHRASE"
+
+const (
+	storeVersion = 1
+
+	kdfKeyFile = "keyfile"
+	kdfArgon2  = "argon2id"
+
+	// Argon2id parameters sized for small boards (16 MiB, 2 passes).
+	argonTime    = 2
+	argonMemory  = 16 * 1024
+	argonThreads = 1
+)
+
+var (
+	ErrNotFound           = errors.New("secret not found")
+	ErrPassphraseRequired = errors.New("secret store is passphrase-protected; set " + PassphraseEnv)
+	ErrDecrypt            = errors.New("cannot decrypt secret: wrong key or corrupted store")
+)
+
+// Store is a file of named secrets, each sealed with ChaCha20-Poly1305.
+// Names are stored in clear so they can be listed without the key.
+type Store struct {
+	path    string
+	keyFile string
+
+	mu   sync.Mutex
+	data storeFile
+	aead cipher.AEAD
+}
+
+type storeFile struct {
+	Version int               `json:"version"`
+	KDF     string            `json:"kdf"`
+	Salt    string            `json:"salt,omitempty"`
+	Entries map[string]*entry `json:"entries"`
+}
+
+type entry struct {
+	Nonce      string    `json:"nonce"`
+	Ciphertext string    `json:"ciphertext"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// DefaultPaths returns the store and key file locations next to a config file.
+func DefaultPaths(configPath string) (storePath, keyFile string) {
+	dir := filepath.Dir(configPath)
+	return filepath.Join(dir, "secrets.json"), filepath.Join(dir, "secrets.key")
+}
+
+// Open loads the store at path. A missing file yields an empty store that is
+// created on the first Set. keyFile holds the random key used when no
+// passphrase is configured; it is generated on first use.
+func Open(path, keyFile string) (*Store, error) {
+	s := &Store{
+		path:    path,
+		keyFile: keyFile,
+		data:    storeFile{Version: storeVersion, Entries: make(map[string]*entry)},
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.data); err != nil {
+		return nil, fmt.Errorf("parse secret store %s: %w", path, err)
+	}
+	if s.data.Entries == nil {
+		s.data.Entries = make(map[string]*entry)
+	}
+	return s, nil
+}
+
+// Reload re-reads the store file, keeping the derived key when the key
+// settings are unchanged. A missing file empties the store.
+func (s *Store) Reload() error {
+	fresh, err := Open(s.path, s.keyFile)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if fresh.data.KDF != s.data.KDF || fresh.data.Salt != s.data.Salt {
+		s.aead = nil
+	}
+	s.data = fresh.data
+	return nil
+}
+
+// KeySource reports how the key is obtained: "passphrase" or "keyfile".
+// For a store that has not been written yet it reflects PassphraseEnv.
+func (s *Store) KeySource() string {
+	s.mu.Lock()
+	kdf := s.data.KDF
+	s.mu.Unlock()
+
+	if kdf == kdfArgon2 || (kdf == "" && os.Getenv(PassphraseEnv) != "") {
+		return "passphrase"
+	}
+	return "keyfile"
+}
+
+// Path returns the store file location.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Get decrypts and returns the named secret.
+func (s *Store) Get(name string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.data.Entries[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	aead, err := s.cipherLocked()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := base64.StdEncoding.DecodeString(e.Nonce)
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	sealed, err := base64.StdEncoding.DecodeString(e.Ciphertext)
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	// The name is bound as associated data so entries cannot be swapped.
+	plain, err := aead.Open(nil, nonce, sealed, []byte(name))
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	return string(plain), nil
+}
+
+// Set encrypts value under name and writes the store to disk.
+func (s *Store) Set(name, value string) error {
+	if name == "" {
+		return errors.New("secret name is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	aead, err := s.cipherLocked()
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	s.data.Entries[name] = &entry{
+		Nonce:      base64.StdEncoding.EncodeToString(nonce),
+		Ciphertext: base64.StdEncoding.EncodeToString(aead.Seal(nil, nonce, []byte(value), []byte(name))),
+		UpdatedAt:  time.Now().UTC(),
+	}
+	return s.saveLocked()
+}
+
+// Remove deletes the named secret. It reports whether the secret existed.
+func (s *Store) Remove(name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.data.Entries[name]; !ok {
+		return false, nil
+	}
+	delete(s.data.Entries, name)
+	return true, s.saveLocked()
+}
+
+// List returns the secret names in sorted order.
+func (s *Store) List() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.data.Entries))
+	for name := range s.data.Entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UpdatedAt returns when the named secret was last set.
+func (s *Store) UpdatedAt(name string) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.data.Entries[name]
+	if !ok {
+		return time.Time{}, false
+	}
+	return e.UpdatedAt, true
+}
+
+func (s *Store) saveLocked() error {
+	data, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	return fileutil.WriteFileAtomic(s.path, data, 0o600)
+}
+
+// cipherLocked derives the key on first use. A new store picks the passphrase
+// KDF when PassphraseEnv is set, otherwise the key file.
+func (s *Store) cipherLocked() (cipher.AEAD, error) {
+	if s.aead != nil {
+		return s.aead, nil
+	}
+
+	passphrase := os.Getenv(PassphraseEnv)
+	if s.data.KDF == "" {
+		if passphrase != "" {
+			salt := make([]byte, 16)
+			if _, err := rand.Read(salt); err != nil {
+				return nil, err
+			}
+			s.data.KDF = kdfArgon2
+			s.data.Salt = base64.StdEncoding.EncodeToString(salt)
+		} else {
+			s.data.KDF = kdfKeyFile
+		}
+	}
+
+	var key []byte
+	switch s.data.KDF {
+	case kdfArgon2:
+		if passphrase == "" {
+			return nil, ErrPassphraseRequired
+		}
+		salt, err := base64.StdEncoding.DecodeString(s.data.Salt)
+		if err != nil || len(salt) == 0 {
+			return nil, fmt.Errorf("secret store %s has an invalid salt", s.path)
+		}
+		key = DeriveKey(passphrase, salt)
+	case kdfKeyFile:
+		k, err := LoadOrCreateKeyFile(s.keyFile)
+		if err != nil {
+			return nil, err
+		}
+		key = k
+	default:
+		return nil, fmt.Errorf("secret store %s uses unknown kdf %q", s.path, s.data.KDF)
+	}
+
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	s.aead = aead
+	return aead, nil
+}
+
+// DeriveKey stretches a passphrase into a ChaCha20-Poly1305 key with Argon2id.
+func DeriveKey(passphrase string, salt []byte) []byte {
+	return argon2.IDKey([]byte(passphrase), salt, argonTime, argonMemory, argonThreads, chacha20poly1305.KeySize)
+}
+
+// LoadOrCreateKeyFile reads a raw 32-byte key, generating it (mode 0600) if
+// the file does not exist yet.
+func LoadOrCreateKeyFile(path string) ([]byte, error) {
+	key, err := os.ReadFile(path)
+	if err == nil {
+		if len(key) != chacha20poly1305.KeySize {
+			return nil, fmt.Errorf("key file %s must contain %d bytes, got %d", path, chacha20poly1305.KeySize, len(key))
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	key = make([]byte, chacha20poly1305.KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	if err := fileutil.WriteFileAtomic(path, key, 0o600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
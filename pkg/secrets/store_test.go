@@ -0,0 +1,122 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func openTestStore(t *testing.T) (*Store, string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	path, keyFile := filepath.Join(dir, "secrets.json"), filepath.Join(dir, "secrets.key")
+	s, err := Open(path, keyFile)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	return s, path, keyFile
+}
+
+func TestStore_SetGetPersist(t *testing.T) {
+	t.Setenv(PassphraseEnv, "")
+	s, path, keyFile := openTestStore(t)
+
+	if err := s.Set("openai", "sk-test-123"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if strings.Contains(string(data), "sk-test-123") {
+		t.Error("store file contains the plaintext secret")
+	}
+	if info, err := os.Stat(keyFile); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("key file mode = %v, err = %v; want 0600", info.Mode().Perm(), err)
+	}
+
+	reopened, err := Open(path, keyFile)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	got, err := reopened.Get("openai")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got != "sk-test-123" {
+		t.Errorf("Get() = %q, want %q", got, "sk-test-123")
+	}
+}
+
+func TestStore_GetMissing(t *testing.T) {
+	s, _, _ := openTestStore(t)
+	if _, err := s.Get("nope"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestStore_ListAndRemove(t *testing.T) {
+	t.Setenv(PassphraseEnv, "")
+	s, _, _ := openTestStore(t)
+	for _, name := range []string{"b", "a"} {
+		if err := s.Set(name, "v"); err != nil {
+			t.Fatalf("Set(%q) error = %v", name, err)
+		}
+	}
+	if got := strings.Join(s.List(), ","); got != "a,b" {
+		t.Errorf("List() = %q, want %q", got, "a,b")
+	}
+
+	removed, err := s.Remove("a")
+	if err != nil || !removed {
+		t.Fatalf("Remove() = %v, %v; want true, nil", removed, err)
+	}
+	if removed, _ := s.Remove("a"); removed {
+		t.Error("Remove() of a missing secret should report false")
+	}
+}
+
+func TestStore_SwappedEntryFailsToDecrypt(t *testing.T) {
+	t.Setenv(PassphraseEnv, "")
+	s, _, _ := openTestStore(t)
+	_ = s.Set("a", "value-a")
+	_ = s.Set("b", "value-b")
+
+	// Ciphertexts are bound to their names.
+	s.data.Entries["a"], s.data.Entries["b"] = s.data.Entries["b"], s.data.Entries["a"]
+	if _, err := s.Get("a"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Get() error = %v, want ErrDecrypt", err)
+	}
+}
+
+func TestStore_Passphrase(t *testing.T) {
+	t.Setenv(PassphraseEnv, "correct horse")
+	s, path, keyFile := openTestStore(t)
+	if err := s.Set("token", "xoxb-1"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if _, err := os.Stat(keyFile); !os.IsNotExist(err) {
+		t.Error("passphrase store should not create a key file")
+	}
+
+	t.Setenv(PassphraseEnv, "")
+	reopened, _ := Open(path, keyFile)
+	if _, err := reopened.Get("token"); !errors.Is(err, ErrPassphraseRequired) {
+		t.Errorf("Get() without passphrase error = %v, want ErrPassphraseRequired", err)
+	}
+
+	t.Setenv(PassphraseEnv, "wrong")
+	reopened, _ = Open(path, keyFile)
+	if _, err := reopened.Get("token"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Get() with wrong passphrase error = %v, want ErrDecrypt", err)
+	}
+
+	t.Setenv(PassphraseEnv, "correct horse")
+	reopened, _ = Open(path, keyFile)
+	if got, err := reopened.Get("token"); err != nil || got != "xoxb-1" {
+		t.Errorf("Get() = %q, %v; want %q", got, err, "xoxb-1")
+	}
+}
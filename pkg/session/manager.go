@@ -4,19 +4,36 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/redact"
 )
 
 type Session struct {
-	Key      string              `json:"key"`
-	Messages []providers.Message `json:"messages"`
-	Summary  string              `json:"summary,omitempty"`
-	Created  time.Time           `json:"created"`
-	Updated  time.Time           `json:"updated"`
+	Key       string              `json:"key"`
+	Messages  []providers.Message `json:"messages"`
+	Summary   string              `json:"summary,omitempty"`
+	Overrides *Overrides          `json:"overrides,omitempty"`
+	Created   time.Time           `json:"created"`
+	Updated   time.Time           `json:"updated"`
+}
+
+// Overrides holds per-session settings changed through chat commands
+// (/model, /agent, /temp). Zero values mean "use the agent default".
+type Overrides struct {
+	Model         string   `json:"model,omitempty"`
+	Temperature   *float64 `json:"temperature,omitempty"`
+	AgentID       string   `json:"agent_id,omitempty"`
+	MaxIterations int      `json:"max_iterations,omitempty"`
+}
+
+// IsEmpty reports whether no override is set.
+func (o Overrides) IsEmpty() bool {
+	return o.Model == "" && o.Temperature == nil && o.AgentID == "" && o.MaxIterations == 0
 }
 
 type SessionManager struct {
@@ -122,6 +139,71 @@ func (sm *SessionManager) SetSummary(key string, summary string) {
 	}
 }
 
+// GetOverrides returns a copy of the session's overrides.
+// A missing session yields empty overrides.
+func (sm *SessionManager) GetOverrides(key string) Overrides {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	session, ok := sm.sessions[key]
+	if !ok || session.Overrides == nil {
+		return Overrides{}
+	}
+	return *session.Overrides
+}
+
+// SetOverrides replaces the session's overrides, creating the session if needed.
+// Passing empty overrides clears them.
+func (sm *SessionManager) SetOverrides(key string, overrides Overrides) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	session, ok := sm.sessions[key]
+	if !ok {
+		session = &Session{
+			Key:      key,
+			Messages: []providers.Message{},
+			Created:  time.Now(),
+		}
+		sm.sessions[key] = session
+	}
+
+	if overrides.IsEmpty() {
+		session.Overrides = nil
+	} else {
+		session.Overrides = &overrides
+	}
+	session.Updated = time.Now()
+}
+
+// Info summarizes a session without its messages.
+type Info struct {
+	Key      string    `json:"key"`
+	Messages int       `json:"messages"`
+	Created  time.Time `json:"created"`
+	Updated  time.Time `json:"updated"`
+}
+
+// List returns every session, most recently updated first.
+func (sm *SessionManager) List() []Info {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	infos := make([]Info, 0, len(sm.sessions))
+	for _, session := range sm.sessions {
+		infos = append(infos, Info{
+			Key:      session.Key,
+			Messages: len(session.Messages),
+			Created:  session.Created,
+			Updated:  session.Updated,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Updated.After(infos[j].Updated)
+	})
+	return infos
+}
+
 func (sm *SessionManager) TruncateHistory(key string, keepLast int) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
@@ -183,13 +265,20 @@ func (sm *SessionManager) Save(key string) error {
 		Created: stored.Created,
 		Updated: stored.Updated,
 	}
+	if stored.Overrides != nil {
+		overrides := *stored.Overrides
+		snapshot.Overrides = &overrides
+	}
 	if len(stored.Messages) > 0 {
 		snapshot.Messages = make([]providers.Message, len(stored.Messages))
-		copy(snapshot.Messages, stored.Messages)
+		for i, msg := range stored.Messages {
+			snapshot.Messages[i] = redactMessage(msg)
+		}
 	} else {
 		snapshot.Messages = []providers.Message{}
 	}
 	sm.mu.RUnlock()
+	snapshot.Summary = redact.String(snapshot.Summary)
 
 	data, err := json.MarshalIndent(snapshot, "", "  ")
 	if err != nil {
@@ -233,6 +322,28 @@ func (sm *SessionManager) Save(key string) error {
 	return nil
 }
 
+// redactMessage returns a copy of msg with credentials masked, so that tool
+// output such as `env` or `cat ~/.netrc` is not persisted verbatim.
+// The in-memory history is left untouched.
+func redactMessage(msg providers.Message) providers.Message {
+	r := redact.Default()
+	msg.Content = r.String(msg.Content)
+	msg.ReasoningContent = r.String(msg.ReasoningContent)
+	if len(msg.ToolCalls) > 0 {
+		calls := make([]providers.ToolCall, len(msg.ToolCalls))
+		for i, tc := range msg.ToolCalls {
+			if tc.Function != nil {
+				fn := *tc.Function
+				fn.Arguments = r.String(fn.Arguments)
+				tc.Function = &fn
+			}
+			calls[i] = tc
+		}
+		msg.ToolCalls = calls
+	}
+	return msg
+}
+
 func (sm *SessionManager) loadSessions() error {
 	files, err := os.ReadDir(sm.storage)
 	if err != nil {
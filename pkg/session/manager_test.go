@@ -3,7 +3,10 @@ package session
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/providers"
 )
 
 func TestSanitizeFilename(t *testing.T) {
@@ -72,3 +75,86 @@ func TestSave_RejectsPathTraversal(t *testing.T) {
 		}
 	}
 }
+
+func TestOverrides_PersistAcrossReload(t *testing.T) {
+	tmpDir := t.TempDir()
+	sm := NewSessionManager(tmpDir)
+
+	key := "agent:main:telegram:direct:42"
+	temp := 0.2
+	sm.SetOverrides(key, Overrides{Model: "gpt-4o", Temperature: &temp, MaxIterations: 5})
+	if err := sm.Save(key); err != nil {
+		t.Fatalf("Save(%q) failed: %v", key, err)
+	}
+
+	sm2 := NewSessionManager(tmpDir)
+	got := sm2.GetOverrides(key)
+	if got.Model != "gpt-4o" || got.MaxIterations != 5 {
+		t.Errorf("unexpected overrides after reload: %+v", got)
+	}
+	if got.Temperature == nil || *got.Temperature != 0.2 {
+		t.Errorf("expected temperature 0.2, got %v", got.Temperature)
+	}
+
+	sm2.SetOverrides(key, Overrides{})
+	if !sm2.GetOverrides(key).IsEmpty() {
+		t.Error("expected empty overrides after clearing")
+	}
+}
+
+func TestSave_RedactsSecrets(t *testing.T) {
+	tmpDir := t.TempDir()
+	sm := NewSessionManager(tmpDir)
+
+	token := "ghp_" + strings.Repeat("z", 36)
+	key := "cli:redact"
+	sm.AddMessage(key, "user", "show me my env")
+	sm.AddFullMessage(key, providers.Message{
+		Role: "assistant",
+		ToolCalls: []providers.ToolCall{{
+			ID:       "call_1",
+			Type:     "function",
+			Function: &providers.FunctionCall{Name: "exec", Arguments: `{"command":"echo ` + token + `"}`},
+		}},
+	})
+	sm.AddFullMessage(key, providers.Message{Role: "tool", Content: "GITHUB_TOKEN=" + token, ToolCallID: "call_1"})
+
+	if err := sm.Save(key); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, "cli_redact.json"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if strings.Contains(string(data), token) {
+		t.Error("saved session contains the token")
+	}
+	if !strings.Contains(string(data), "[REDACTED:github_token]") {
+		t.Errorf("saved session missing redaction marker:\n%s", data)
+	}
+
+	// The in-memory history keeps the original content for the running turn.
+	history := sm.GetHistory(key)
+	if !strings.Contains(history[2].Content, token) {
+		t.Error("Save must not modify the in-memory history")
+	}
+	if !strings.Contains(history[1].ToolCalls[0].Function.Arguments, token) {
+		t.Error("Save must not modify in-memory tool call arguments")
+	}
+}
+
+func TestList_NewestFirst(t *testing.T) {
+	sm := NewSessionManager("")
+	sm.AddMessage("old", "user", "hi")
+	sm.AddMessage("new", "user", "hi")
+	sm.AddMessage("new", "assistant", "hello")
+
+	infos := sm.List()
+	if len(infos) != 2 {
+		t.Fatalf("len(List()) = %d, want 2", len(infos))
+	}
+	if infos[0].Key != "new" || infos[0].Messages != 2 {
+		t.Errorf("first = %+v, want key new with 2 messages", infos[0])
+	}
+}
@@ -0,0 +1,466 @@
+package skills
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+const (
+	defaultGitRefreshMinutes = 60
+	defaultGitTimeout        = 2 * time.Minute
+	maxTarballSize           = 100 * 1024 * 1024
+	maxOrgRepos              = 100
+)
+
+// GitRegistry implements SkillRegistry for a git repository of skills, or
+// for every repository of a GitHub organization or user.
+//
+// A repository is cloned with git into the cache directory and refreshed
+// periodically; without a git binary GitHub repositories are fetched as
+// tarballs instead. Skills are the subdirectories of Path that contain a
+// SKILL.md, or the repository itself when its root has one.
+//
+// For an organization URL such as https://github.com/acme, each repository
+// with a root SKILL.md is one skill named after the repository.
+type GitRegistry struct {
+	cfg      GitConfig
+	name     string
+	cacheDir string
+	refresh  time.Duration
+	client   *http.Client
+
+	// Overridable in tests.
+	gitPath      string
+	apiBase      string
+	rawBase      string
+	codeloadBase string
+
+	mu       sync.Mutex
+	synced   map[string]time.Time // Repo URL -> last sync
+	orgIndex []indexedSkill
+	orgAt    time.Time
+}
+
+// NewGitRegistry creates a registry for cfg that keeps its checkouts under
+// cacheDir.
+func NewGitRegistry(cfg GitConfig, cacheDir string) *GitRegistry {
+	name := cfg.Name
+	if name == "" {
+		name = "git"
+	}
+	refresh := time.Duration(cfg.RefreshMinutes) * time.Minute
+	if refresh <= 0 {
+		refresh = defaultGitRefreshMinutes * time.Minute
+	}
+	gitPath, _ := exec.LookPath("git")
+	return &GitRegistry{
+		cfg:          cfg,
+		name:         name,
+		cacheDir:     filepath.Join(cacheDir, name),
+		refresh:      refresh,
+		client:       &http.Client{Timeout: defaultGitTimeout},
+		gitPath:      gitPath,
+		apiBase:      "https://api.github.com",
+		rawBase:      "https://raw.githubusercontent.com",
+		codeloadBase: "https://codeload.github.com",
+		synced:       make(map[string]time.Time),
+	}
+}
+
+func (r *GitRegistry) Name() string {
+	return r.name
+}
+
+func (r *GitRegistry) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	skills, err := r.index(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return searchIndex(skills, query, limit, r.name), nil
+}
+
+func (r *GitRegistry) GetSkillMeta(ctx context.Context, slug string) (*SkillMeta, error) {
+	skill, err := r.fetch(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	var flags InstallResult
+	if err := scanSkillDir(skill.Dir, &flags); err != nil {
+		return nil, err
+	}
+	return &SkillMeta{
+		Slug:             skill.Slug,
+		DisplayName:      skill.Name,
+		Summary:          skill.Description,
+		LatestVersion:    skill.Version,
+		IsMalwareBlocked: flags.IsMalwareBlocked,
+		IsSuspicious:     flags.IsSuspicious,
+		RegistryName:     r.name,
+	}, nil
+}
+
+// DownloadAndInstall copies the skill from the refreshed checkout into
+// targetDir. Only the version at the configured ref can be installed.
+func (r *GitRegistry) DownloadAndInstall(ctx context.Context, slug, version, targetDir string) (*InstallResult, error) {
+	skill, err := r.fetch(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(skill, version); err != nil {
+		return nil, err
+	}
+	return installFromDir(skill, targetDir)
+}
+
+// index lists the skills of the registry.
+func (r *GitRegistry) index(ctx context.Context) ([]indexedSkill, error) {
+	if org, ok := githubOrg(r.cfg.URL); ok {
+		return r.orgSkills(ctx, org)
+	}
+	dir, err := r.sync(ctx, r.cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	return indexDir(filepath.Join(dir, filepath.FromSlash(r.cfg.Path)), repoName(r.cfg.URL))
+}
+
+// fetch returns the skill with a local copy in Dir.
+func (r *GitRegistry) fetch(ctx context.Context, slug string) (indexedSkill, error) {
+	if err := utils.ValidateSkillIdentifier(slug); err != nil {
+		return indexedSkill{}, fmt.Errorf("invalid slug %q: error: %s", slug, err.Error())
+	}
+
+	if org, ok := githubOrg(r.cfg.URL); ok {
+		dir, err := r.sync(ctx, "https://github.com/"+org+"/"+slug)
+		if err != nil {
+			return indexedSkill{}, err
+		}
+		skills, err := indexDir(dir, slug)
+		if err != nil || len(skills) != 1 || skills[0].Dir != dir {
+			return indexedSkill{}, fmt.Errorf("repository %s/%s has no SKILL.md", org, slug)
+		}
+		return skills[0], nil
+	}
+
+	skills, err := r.index(ctx)
+	if err != nil {
+		return indexedSkill{}, err
+	}
+	skill, ok := findIndexed(skills, slug)
+	if !ok {
+		return indexedSkill{}, fmt.Errorf("skill %q not found in %s", slug, r.cfg.URL)
+	}
+	return skill, nil
+}
+
+// sync brings the checkout of repoURL up to date unless it was refreshed
+// recently, and returns its directory.
+func (r *GitRegistry) sync(ctx context.Context, repoURL string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	dir := filepath.Join(r.cacheDir, cacheKey(repoURL))
+	if last, ok := r.synced[repoURL]; ok && time.Since(last) < r.refresh {
+		if _, err := os.Stat(dir); err == nil {
+			return dir, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultGitTimeout)
+	defer cancel()
+
+	var err error
+	switch owner, repo, isGitHub := githubRepo(repoURL); {
+	case r.gitPath != "":
+		err = r.gitSync(ctx, repoURL, dir)
+	case isGitHub:
+		err = r.fetchTarball(ctx, owner, repo, dir)
+	default:
+		err = fmt.Errorf("git is not installed and %s is not a GitHub repository", repoURL)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to sync %s: %w", repoURL, err)
+	}
+	r.synced[repoURL] = time.Now()
+	return dir, nil
+}
+
+func (r *GitRegistry) gitSync(ctx context.Context, repoURL, dir string) error {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		ref := r.cfg.Ref
+		if ref == "" {
+			ref = "HEAD"
+		}
+		if err := r.git(ctx, dir, "fetch", "--depth", "1", "origin", ref); err != nil {
+			return err
+		}
+		return r.git(ctx, dir, "reset", "--hard", "FETCH_HEAD")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return err
+	}
+	os.RemoveAll(dir)
+	args := []string{"clone", "--depth", "1"}
+	if r.cfg.Ref != "" {
+		args = append(args, "--branch", r.cfg.Ref)
+	}
+	return r.git(ctx, "", append(args, "--", repoURL, dir)...)
+}
+
+func (r *GitRegistry) git(ctx context.Context, dir string, args ...string) error {
+	subcommand := args[0]
+	cmd := exec.CommandContext(ctx, r.gitPath, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if r.cfg.AuthToken != "" {
+		// Passed through the environment rather than "-c", which would put
+		// the token in the process list.
+		basic := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + r.cfg.AuthToken))
+		cmd.Env = append(cmd.Env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+basic,
+		)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s: %w: %s", subcommand, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// fetchTarball replaces dir with the contents of a GitHub repository
+// tarball.
+func (r *GitRegistry) fetchTarball(ctx context.Context, owner, repo, dir string) error {
+	ref := r.cfg.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	resp, err := r.get(ctx, fmt.Sprintf("%s/%s/%s/tar.gz/%s", r.codeloadBase, owner, repo, url.PathEscape(ref)))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := os.MkdirAll(r.cacheDir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp(r.cacheDir, ".tarball-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := extractTarball(io.LimitReader(resp.Body, maxTarballSize), tmp); err != nil {
+		return err
+	}
+	os.RemoveAll(dir)
+	return os.Rename(tmp, dir)
+}
+
+// extractTarball unpacks a gzipped tarball into dir, dropping the top-level
+// directory GitHub wraps the repository in.
+func extractTarball(r io.Reader, dir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		_, rel, ok := strings.Cut(strings.TrimPrefix(hdr.Name, "./"), "/")
+		if !ok || strings.Trim(rel, "/") == "" {
+			continue
+		}
+		if !filepath.IsLocal(rel) || strings.Contains(hdr.Name, "..") {
+			return fmt.Errorf("unsafe path in tarball: %s", hdr.Name)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(rel))
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, hdr.FileInfo().Mode().Perm())
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+type githubRepoInfo struct {
+	Name          string `json:"name"`
+	DefaultBranch string `json:"default_branch"`
+	Archived      bool   `json:"archived"`
+}
+
+// orgSkills indexes the root SKILL.md of every repository of org.
+func (r *GitRegistry) orgSkills(ctx context.Context, org string) ([]indexedSkill, error) {
+	r.mu.Lock()
+	if r.orgIndex != nil && time.Since(r.orgAt) < r.refresh {
+		defer r.mu.Unlock()
+		return r.orgIndex, nil
+	}
+	r.mu.Unlock()
+
+	repos, err := r.listRepos(ctx, "orgs", org)
+	if err != nil {
+		// Personal accounts are listed under /users.
+		var userErr error
+		if repos, userErr = r.listRepos(ctx, "users", org); userErr != nil {
+			return nil, err
+		}
+	}
+
+	skills := make([]indexedSkill, 0)
+	for _, repo := range repos {
+		if repo.Archived || utils.ValidateSkillIdentifier(repo.Name) != nil {
+			continue
+		}
+		ref := r.cfg.Ref
+		if ref == "" {
+			ref = repo.DefaultBranch
+		}
+		resp, err := r.get(ctx, fmt.Sprintf("%s/%s/%s/%s/SKILL.md", r.rawBase, org, repo.Name, url.PathEscape(ref)))
+		if err != nil {
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(resp.Body, maxScannedFileSize))
+		resp.Body.Close()
+		if err != nil {
+			continue
+		}
+		skills = append(skills, readSkillInfo(repo.Name, data))
+	}
+
+	r.mu.Lock()
+	r.orgIndex, r.orgAt = skills, time.Now()
+	r.mu.Unlock()
+	return skills, nil
+}
+
+func (r *GitRegistry) listRepos(ctx context.Context, kind, owner string) ([]githubRepoInfo, error) {
+	resp, err := r.get(ctx, fmt.Sprintf("%s/%s/%s/repos?per_page=%d", r.apiBase, kind, owner, maxOrgRepos))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var repos []githubRepoInfo
+	if err := json.NewDecoder(resp.Body).Decode(&repos); err != nil {
+		return nil, fmt.Errorf("failed to parse repository list: %w", err)
+	}
+	return repos, nil
+}
+
+// get performs an authenticated GET and fails on non-200 responses.
+func (r *GitRegistry) get(ctx context.Context, u string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if r.cfg.AuthToken != "" {
+		req.Header.Set("Authorization", "Bearer "+r.cfg.AuthToken)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: HTTP %d", u, resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// githubRepo extracts owner and repository from GitHub URLs such as
+// https://github.com/owner/repo(.git), github.com/owner/repo and
+// git@github.com:owner/repo.git.
+func githubRepo(raw string) (owner, repo string, ok bool) {
+	parts := githubPath(raw)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// githubOrg reports whether raw names a GitHub organization or user rather
+// than a repository.
+func githubOrg(raw string) (string, bool) {
+	parts := githubPath(raw)
+	if len(parts) != 1 {
+		return "", false
+	}
+	return parts[0], true
+}
+
+func githubPath(raw string) []string {
+	s := strings.TrimSuffix(strings.TrimSuffix(raw, "/"), ".git")
+	for _, prefix := range []string{"https://github.com/", "http://github.com/", "git@github.com:", "github.com/"} {
+		if rest, ok := strings.CutPrefix(s, prefix); ok {
+			parts := strings.Split(rest, "/")
+			for _, p := range parts {
+				if p == "" {
+					return nil
+				}
+			}
+			return parts
+		}
+	}
+	return nil
+}
+
+// repoName returns the last path element of a repository URL.
+func repoName(repoURL string) string {
+	s := strings.TrimSuffix(strings.TrimSuffix(repoURL, "/"), ".git")
+	if i := strings.LastIndexAny(s, "/:"); i >= 0 {
+		s = s[i+1:]
+	}
+	return s
+}
+
+// cacheKey turns a repository URL into a directory name.
+func cacheKey(repoURL string) string {
+	var b strings.Builder
+	for _, c := range strings.TrimSuffix(repoURL, ".git") {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '.':
+			b.WriteRune(c)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,254 @@
+package skills
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// indexedSkill is a skill found in a directory-based registry.
+type indexedSkill struct {
+	Slug        string
+	Name        string
+	Description string
+	Version     string
+	Dir         string // Local copy; empty until fetched
+}
+
+// readSkillInfo reads the frontmatter of a SKILL.md into an indexedSkill.
+// The version is the frontmatter "version" or, without one, derived from
+// the SKILL.md content so every edit counts as a new version.
+func readSkillInfo(slug string, content []byte) indexedSkill {
+	skill := indexedSkill{Slug: slug}
+	if meta, ok := parseSkillMetadata(string(content)); ok {
+		skill.Name, skill.Description, skill.Version = meta.Name, meta.Description, meta.Version
+	}
+	if skill.Name == "" {
+		skill.Name = slug
+	}
+	if skill.Version == "" {
+		sum := sha256.Sum256(content)
+		skill.Version = "sha-" + hex.EncodeToString(sum[:])[:12]
+	}
+	return skill
+}
+
+// indexDir lists the skills in root: each subdirectory with a SKILL.md, or
+// root itself as rootSlug when it has one.
+func indexDir(root, rootSlug string) ([]indexedSkill, error) {
+	if data, err := os.ReadFile(filepath.Join(root, "SKILL.md")); err == nil && rootSlug != "" {
+		skill := readSkillInfo(rootSlug, data)
+		skill.Dir = root
+		return []indexedSkill{skill}, nil
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	var skills []indexedSkill
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		dir := filepath.Join(root, e.Name())
+		data, err := os.ReadFile(filepath.Join(dir, "SKILL.md"))
+		if err != nil {
+			continue
+		}
+		skill := readSkillInfo(e.Name(), data)
+		skill.Dir = dir
+		skills = append(skills, skill)
+	}
+	return skills, nil
+}
+
+// searchIndex scores skills by how many query words appear in their slug,
+// name and description.
+func searchIndex(skills []indexedSkill, query string, limit int, registry string) []SearchResult {
+	words := strings.Fields(strings.ToLower(query))
+	var results []SearchResult
+	for _, s := range skills {
+		slug, name, desc := strings.ToLower(s.Slug), strings.ToLower(s.Name), strings.ToLower(s.Description)
+		score := 0.0
+		for _, w := range words {
+			switch {
+			case slug == w || name == w:
+				score += 1.0
+			case strings.Contains(slug, w) || strings.Contains(name, w):
+				score += 0.6
+			case strings.Contains(desc, w):
+				score += 0.3
+			}
+		}
+		if len(words) > 0 && score == 0 {
+			continue
+		}
+		if len(words) > 0 {
+			score /= float64(len(words))
+		}
+		summary := s.Description
+		if summary == "" {
+			summary = "No description"
+		}
+		results = append(results, SearchResult{
+			Score:        score,
+			Slug:         s.Slug,
+			DisplayName:  s.Name,
+			Summary:      summary,
+			Version:      s.Version,
+			RegistryName: registry,
+		})
+	}
+	sortByScoreDesc(results)
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+func findIndexed(skills []indexedSkill, slug string) (indexedSkill, bool) {
+	for _, s := range skills {
+		if s.Slug == slug {
+			return s, true
+		}
+	}
+	return indexedSkill{}, false
+}
+
+// checkVersion rejects a request for a version other than the one a
+// directory-based registry has.
+func checkVersion(skill indexedSkill, version string) error {
+	if version != "" && version != "latest" && version != skill.Version {
+		return fmt.Errorf("version %s of %q is not available (registry has %s)", version, skill.Slug, skill.Version)
+	}
+	return nil
+}
+
+// Patterns that decide the moderation flags of skills from registries
+// without their own moderation.
+var (
+	malwarePatterns = []*regexp.Regexp{
+		regexp.MustCompile(`/dev/tcp/`),
+		regexp.MustCompile(`\bnc(at)?\s+(-\w+\s+)*-e\s`),
+		regexp.MustCompile(`stratum\+tcp://`),
+		regexp.MustCompile(`(?i)\bxmrig\b`),
+	}
+	suspiciousPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`\b(curl|wget)\b[^|\n]*\|\s*(sudo\s+)?(ba|z)?sh\b`),
+		regexp.MustCompile(`\bbase64\s+(-d|--decode)\b`),
+		regexp.MustCompile(`\brm\s+-rf\s+[/~]`),
+		regexp.MustCompile(`\bchmod\s+[ug]?\+s\b`),
+		regexp.MustCompile(`\bsudo\s`),
+	}
+)
+
+const maxScannedFileSize = 1 << 20
+
+// scanSkillDir sets the moderation flags of result from the files in dir.
+func scanSkillDir(dir string, result *InstallResult) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || info.Size() > maxScannedFileSize {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), maxScannedFileSize)
+		for scanner.Scan() {
+			line := scanner.Text()
+			for _, re := range malwarePatterns {
+				if re.MatchString(line) {
+					result.IsMalwareBlocked = true
+				}
+			}
+			for _, re := range suspiciousPatterns {
+				if re.MatchString(line) {
+					result.IsSuspicious = true
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// installFromDir copies a skill into targetDir, skipping VCS metadata, and
+// returns the result with moderation flags.
+func installFromDir(skill indexedSkill, targetDir string) (*InstallResult, error) {
+	result := &InstallResult{Version: skill.Version, Summary: skill.Description}
+	if err := scanSkillDir(skill.Dir, result); err != nil {
+		return nil, fmt.Errorf("failed to scan skill: %w", err)
+	}
+	if err := copySkillDir(skill.Dir, targetDir); err != nil {
+		return nil, fmt.Errorf("failed to copy skill: %w", err)
+	}
+	return result, nil
+}
+
+func copySkillDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case d.IsDir() && d.Name() == ".git":
+			return filepath.SkipDir
+		case d.IsDir():
+			return os.MkdirAll(target, 0o755)
+		case !d.Type().IsRegular():
+			return nil // Symlinks could point outside the skill
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
+
+// expandHome replaces a leading "~/" with the user's home directory.
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
@@ -87,7 +87,7 @@ func (si *SkillInstaller) Uninstall(skillName string) error {
 		return fmt.Errorf("failed to remove skill: %w", err)
 	}
 
-	return nil
+	return RemoveFromLock(si.workspace, skillName)
 }
 
 func (si *SkillInstaller) ListAvailableSkills(ctx context.Context) ([]AvailableSkill, error) {
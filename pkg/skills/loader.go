@@ -1,13 +1,13 @@
 package skills
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/sipeed/picoclaw/pkg/logger"
@@ -25,15 +25,29 @@ const (
 )
 
 type SkillMetadata struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name        string       `json:"name"                  yaml:"name"`
+	Description string       `json:"description"           yaml:"description"`
+	Version     string       `json:"version,omitempty"     yaml:"version"`
+	Requires    Requirements `json:"requires"              yaml:"requires"`
+	Permissions []string     `json:"permissions,omitempty" yaml:"permissions"`
+	Tools       []ToolSpec   `json:"tools,omitempty"       yaml:"tools"`
 }
 
 type SkillInfo struct {
-	Name        string `json:"name"`
-	Path        string `json:"path"`
-	Source      string `json:"source"`
-	Description string `json:"description"`
+	Name        string       `json:"name"`
+	Path        string       `json:"path"`
+	Source      string       `json:"source"`
+	Description string       `json:"description"`
+	Version     string       `json:"version,omitempty"`
+	Requires    Requirements `json:"requires"`
+	Permissions []string     `json:"permissions,omitempty"`
+	Tools       []ToolSpec   `json:"tools,omitempty"`
+	Unavailable string       `json:"unavailable,omitempty"` // Why the skill cannot be used; empty when it can
+}
+
+// Available reports whether the requirements of the skill are met.
+func (info SkillInfo) Available() bool {
+	return info.Unavailable == ""
 }
 
 func (info SkillInfo) validate() error {
@@ -54,6 +68,20 @@ func (info SkillInfo) validate() error {
 	} else if len(info.Description) > MaxDescriptionLength {
 		errs = errors.Join(errs, fmt.Errorf("description exceeds %d character", MaxDescriptionLength))
 	}
+
+	for _, perm := range info.Permissions {
+		if !slices.Contains(knownPermissions, perm) {
+			errs = errors.Join(errs, fmt.Errorf("unknown permission %q", perm))
+		}
+	}
+	seen := make(map[string]bool)
+	for _, spec := range info.Tools {
+		if seen[spec.Name] {
+			errs = errors.Join(errs, fmt.Errorf("tool %q declared twice", spec.Name))
+		}
+		seen[spec.Name] = true
+		errs = errors.Join(errs, spec.validate())
+	}
 	return errs
 }
 
@@ -62,6 +90,9 @@ type SkillsLoader struct {
 	workspaceSkills string // workspace skills (project-level)
 	globalSkills    string // global skills (~/.picoclaw/skills)
 	builtinSkills   string // builtin skills
+
+	hasTool           func(name string) bool
+	deniedPermissions []string
 }
 
 func NewSkillsLoader(workspace string, globalSkills string, builtinSkills string) *SkillsLoader {
@@ -73,6 +104,17 @@ func NewSkillsLoader(workspace string, globalSkills string, builtinSkills string
 	}
 }
 
+// SetToolChecker sets how requires.tools entries are checked. Without one,
+// required tools are not checked.
+func (sl *SkillsLoader) SetToolChecker(hasTool func(name string) bool) {
+	sl.hasTool = hasTool
+}
+
+// SetDeniedPermissions makes skills that need any of perms unavailable.
+func (sl *SkillsLoader) SetDeniedPermissions(perms []string) {
+	sl.deniedPermissions = perms
+}
+
 func (sl *SkillsLoader) ListSkills() []SkillInfo {
 	skills := make([]SkillInfo, 0)
 	seen := make(map[string]bool)
@@ -102,6 +144,10 @@ func (sl *SkillsLoader) ListSkills() []SkillInfo {
 			if metadata != nil {
 				info.Description = metadata.Description
 				info.Name = metadata.Name
+				info.Version = metadata.Version
+				info.Requires = metadata.Requires
+				info.Permissions = metadata.Permissions
+				info.Tools = metadata.Tools
 			}
 			if err := info.validate(); err != nil {
 				slog.Warn("invalid skill from "+source, "name", info.Name, "error", err)
@@ -111,6 +157,7 @@ func (sl *SkillsLoader) ListSkills() []SkillInfo {
 				continue
 			}
 			seen[info.Name] = true
+			info.Unavailable = sl.unavailableReason(info)
 			skills = append(skills, info)
 		}
 	}
@@ -151,6 +198,22 @@ func (sl *SkillsLoader) LoadSkill(name string) (string, bool) {
 	return "", false
 }
 
+// LoadSkillInfo returns the content of the listed skill called name, which
+// may differ from its directory name.
+func (sl *SkillsLoader) LoadSkillInfo(name string) (SkillInfo, string, bool) {
+	for _, info := range sl.ListSkills() {
+		if info.Name != name {
+			continue
+		}
+		content, err := os.ReadFile(info.Path)
+		if err != nil {
+			return SkillInfo{}, "", false
+		}
+		return info, sl.stripFrontmatter(string(content)), true
+	}
+	return SkillInfo{}, "", false
+}
+
 func (sl *SkillsLoader) LoadSkillsForContext(skillNames []string) string {
 	if len(skillNames) == 0 {
 		return ""
@@ -168,7 +231,12 @@ func (sl *SkillsLoader) LoadSkillsForContext(skillNames []string) string {
 }
 
 func (sl *SkillsLoader) BuildSkillsSummary() string {
-	allSkills := sl.ListSkills()
+	return FormatSkillsSummary(sl.ListSkills())
+}
+
+// FormatSkillsSummary renders skills as the <skills> block of the system
+// prompt.
+func FormatSkillsSummary(allSkills []SkillInfo) string {
 	if len(allSkills) == 0 {
 		return ""
 	}
@@ -185,6 +253,19 @@ func (sl *SkillsLoader) BuildSkillsSummary() string {
 		lines = append(lines, fmt.Sprintf("    <description>%s</description>", escapedDesc))
 		lines = append(lines, fmt.Sprintf("    <location>%s</location>", escapedPath))
 		lines = append(lines, fmt.Sprintf("    <source>%s</source>", s.Source))
+		if !s.Available() {
+			lines = append(lines, fmt.Sprintf("    <status>unavailable because %s</status>", escapeXML(s.Unavailable)))
+		}
+		if perms := s.Needs(); len(perms) > 0 {
+			lines = append(lines, fmt.Sprintf("    <permissions>%s</permissions>", strings.Join(perms, ", ")))
+		}
+		if len(s.Tools) > 0 && s.Available() {
+			names := make([]string, 0, len(s.Tools))
+			for _, spec := range s.Tools {
+				names = append(names, spec.Name)
+			}
+			lines = append(lines, fmt.Sprintf("    <tools>%s</tools>", escapeXML(strings.Join(names, ", "))))
+		}
 		lines = append(lines, "  </skill>")
 	}
 	lines = append(lines, "</skills>")
@@ -203,31 +284,13 @@ func (sl *SkillsLoader) getSkillMetadata(skillPath string) *SkillMetadata {
 		return nil
 	}
 
-	frontmatter := sl.extractFrontmatter(string(content))
-	if frontmatter == "" {
+	metadata, ok := parseSkillMetadata(string(content))
+	if !ok {
 		return &SkillMetadata{
 			Name: filepath.Base(filepath.Dir(skillPath)),
 		}
 	}
-
-	// Try JSON first (for backward compatibility)
-	var jsonMeta struct {
-		Name        string `json:"name"`
-		Description string `json:"description"`
-	}
-	if err := json.Unmarshal([]byte(frontmatter), &jsonMeta); err == nil {
-		return &SkillMetadata{
-			Name:        jsonMeta.Name,
-			Description: jsonMeta.Description,
-		}
-	}
-
-	// Fall back to simple YAML parsing
-	yamlMeta := sl.parseSimpleYAML(frontmatter)
-	return &SkillMetadata{
-		Name:        yamlMeta["name"],
-		Description: yamlMeta["description"],
-	}
+	return metadata
 }
 
 // parseSimpleYAML parses simple key: value YAML format
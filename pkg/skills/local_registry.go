@@ -0,0 +1,84 @@
+package skills
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+// LocalDirRegistry implements SkillRegistry for a directory of skills, e.g.
+// a folder shared by a team. Each subdirectory with a SKILL.md is a skill.
+type LocalDirRegistry struct {
+	name string
+	root string
+}
+
+// NewLocalDirRegistry creates a registry serving the skills under cfg.Path.
+func NewLocalDirRegistry(cfg LocalDirConfig) *LocalDirRegistry {
+	name := cfg.Name
+	if name == "" {
+		name = "local"
+	}
+	return &LocalDirRegistry{name: name, root: expandHome(cfg.Path)}
+}
+
+func (r *LocalDirRegistry) Name() string {
+	return r.name
+}
+
+func (r *LocalDirRegistry) Search(_ context.Context, query string, limit int) ([]SearchResult, error) {
+	skills, err := indexDir(r.root, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", r.root, err)
+	}
+	return searchIndex(skills, query, limit, r.name), nil
+}
+
+func (r *LocalDirRegistry) GetSkillMeta(_ context.Context, slug string) (*SkillMeta, error) {
+	skill, err := r.find(slug)
+	if err != nil {
+		return nil, err
+	}
+	var flags InstallResult
+	if err := scanSkillDir(skill.Dir, &flags); err != nil {
+		return nil, err
+	}
+	return &SkillMeta{
+		Slug:             skill.Slug,
+		DisplayName:      skill.Name,
+		Summary:          skill.Description,
+		LatestVersion:    skill.Version,
+		IsMalwareBlocked: flags.IsMalwareBlocked,
+		IsSuspicious:     flags.IsSuspicious,
+		RegistryName:     r.name,
+	}, nil
+}
+
+// DownloadAndInstall copies the skill into targetDir. Only the version
+// currently in the directory can be installed.
+func (r *LocalDirRegistry) DownloadAndInstall(_ context.Context, slug, version, targetDir string) (*InstallResult, error) {
+	skill, err := r.find(slug)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(skill, version); err != nil {
+		return nil, err
+	}
+	return installFromDir(skill, targetDir)
+}
+
+func (r *LocalDirRegistry) find(slug string) (indexedSkill, error) {
+	if err := utils.ValidateSkillIdentifier(slug); err != nil {
+		return indexedSkill{}, fmt.Errorf("invalid slug %q: error: %s", slug, err.Error())
+	}
+	skills, err := indexDir(r.root, "")
+	if err != nil {
+		return indexedSkill{}, fmt.Errorf("failed to read %s: %w", r.root, err)
+	}
+	skill, ok := findIndexed(skills, slug)
+	if !ok {
+		return indexedSkill{}, fmt.Errorf("skill %q not found in %s", slug, r.root)
+	}
+	return skill, nil
+}
@@ -0,0 +1,415 @@
+package skills
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/fileutil"
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+const (
+	// LockFileName is the lockfile in the workspace recording where each
+	// registry-installed skill came from.
+	LockFileName = "skills.lock"
+
+	// OriginFileName marks a skill directory as installed from a registry.
+	OriginFileName = ".skill-origin.json"
+
+	// previousDir keeps the version replaced by the last update of each
+	// skill, under workspace/skills. The loader ignores it because skills
+	// there are nested one level deeper.
+	previousDir = ".previous"
+)
+
+// ErrLocallyModified is returned by Update when the installed files no
+// longer match the hash recorded at install time.
+var ErrLocallyModified = errors.New("skill has local modifications")
+
+// LockEntry records one installed skill.
+type LockEntry struct {
+	Registry    string     `json:"registry"`
+	Slug        string     `json:"slug"`
+	Version     string     `json:"version"`
+	Hash        string     `json:"hash"` // See HashDir
+	InstalledAt int64      `json:"installed_at"`
+	Previous    *LockEntry `json:"previous,omitempty"` // Version kept for rollback
+}
+
+// Lock is the content of skills.lock, keyed by skill directory name.
+type Lock struct {
+	Version int                   `json:"version"`
+	Skills  map[string]*LockEntry `json:"skills"`
+}
+
+// Origin is written to OriginFileName in every registry-installed skill.
+type Origin struct {
+	Version          int    `json:"version"`
+	Registry         string `json:"registry"`
+	Slug             string `json:"slug"`
+	InstalledVersion string `json:"installed_version"`
+	InstalledAt      int64  `json:"installed_at"`
+}
+
+// WriteOrigin marks dir as installed from registry.
+func WriteOrigin(dir, registry, slug, version string) error {
+	data, err := json.MarshalIndent(Origin{
+		Version:          1,
+		Registry:         registry,
+		Slug:             slug,
+		InstalledVersion: version,
+		InstalledAt:      time.Now().UnixMilli(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Use unified atomic write utility with explicit sync for flash storage reliability.
+	return fileutil.WriteFileAtomic(filepath.Join(dir, OriginFileName), data, 0o600)
+}
+
+// LoadLock reads the workspace's skills.lock. A missing file is an empty
+// lock.
+func LoadLock(workspace string) (*Lock, error) {
+	lock := &Lock{Version: 1, Skills: make(map[string]*LockEntry)}
+	data, err := os.ReadFile(filepath.Join(workspace, LockFileName))
+	if os.IsNotExist(err) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", LockFileName, err)
+	}
+	if lock.Skills == nil {
+		lock.Skills = make(map[string]*LockEntry)
+	}
+	return lock, nil
+}
+
+// Save writes the lock to the workspace.
+func (l *Lock) Save(workspace string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteFileAtomic(filepath.Join(workspace, LockFileName), data, 0o644)
+}
+
+// RecordInstall adds a freshly installed skill to skills.lock, replacing
+// any previous entry.
+func RecordInstall(workspace, registry, slug, version string) error {
+	hash, err := HashDir(filepath.Join(workspace, "skills", slug))
+	if err != nil {
+		return err
+	}
+	lock, err := LoadLock(workspace)
+	if err != nil {
+		return err
+	}
+	lock.Skills[slug] = &LockEntry{
+		Registry:    registry,
+		Slug:        slug,
+		Version:     version,
+		Hash:        hash,
+		InstalledAt: time.Now().UnixMilli(),
+	}
+	return lock.Save(workspace)
+}
+
+// RemoveFromLock forgets a skill and the version kept for its rollback.
+func RemoveFromLock(workspace, name string) error {
+	os.RemoveAll(filepath.Join(workspace, "skills", previousDir, name))
+	lock, err := LoadLock(workspace)
+	if err != nil {
+		return err
+	}
+	if _, ok := lock.Skills[name]; !ok {
+		return nil
+	}
+	delete(lock.Skills, name)
+	return lock.Save(workspace)
+}
+
+// Pinned reports whether the workspace skill directory name is recorded in
+// skills.lock and its files still match the hash recorded at install time.
+// Skills the agent wrote or edited itself are not pinned.
+func Pinned(workspace, name string) bool {
+	lock, err := LoadLock(workspace)
+	if err != nil {
+		return false
+	}
+	entry, ok := lock.Skills[name]
+	if !ok {
+		return false
+	}
+	hash, err := HashDir(filepath.Join(workspace, "skills", name))
+	return err == nil && hash == entry.Hash
+}
+
+// HashDir returns "sha256:<hex>" over the relative paths and contents of
+// the files in dir, ignoring the origin marker.
+func HashDir(dir string) (string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() && d.Name() != OriginFileName {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+
+	h := sha256.New()
+	for _, rel := range files {
+		f, err := os.Open(filepath.Join(dir, filepath.FromSlash(rel)))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s\x00", rel)
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+		h.Write([]byte{0})
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// SkillStatus describes an installed skill against its registry.
+type SkillStatus struct {
+	Name     string
+	Registry string
+	Version  string
+	Latest   string // Empty if the registry could not be asked
+	Modified bool   // Files differ from the installed version
+	Error    string // Why Latest is unknown
+}
+
+// Outdated reports whether a newer version is available.
+func (s SkillStatus) Outdated() bool {
+	return s.Latest != "" && s.Latest != s.Version
+}
+
+// Updater checks, updates and rolls back the skills recorded in
+// skills.lock.
+type Updater struct {
+	workspace  string
+	registries *RegistryManager
+}
+
+// NewUpdater creates an Updater for the skills in workspace.
+func NewUpdater(workspace string, registries *RegistryManager) *Updater {
+	return &Updater{workspace: workspace, registries: registries}
+}
+
+// Modified reports whether the files of the named skill differ from the
+// hash recorded at install time.
+func (u *Updater) Modified(name string, entry *LockEntry) bool {
+	hash, err := HashDir(filepath.Join(u.workspace, "skills", name))
+	return err != nil || hash != entry.Hash
+}
+
+// Status returns the state of every locked skill, sorted by name. The
+// registries are only asked for the latest version when check is set.
+func (u *Updater) Status(ctx context.Context, check bool) ([]SkillStatus, error) {
+	lock, err := LoadLock(u.workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]SkillStatus, 0, len(lock.Skills))
+	for name, entry := range lock.Skills {
+		st := SkillStatus{
+			Name:     name,
+			Registry: entry.Registry,
+			Version:  entry.Version,
+			Modified: u.Modified(name, entry),
+		}
+		if check {
+			st.Latest, err = u.latest(ctx, entry)
+			if err != nil {
+				st.Error = err.Error()
+			}
+		}
+		statuses = append(statuses, st)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses, nil
+}
+
+func (u *Updater) latest(ctx context.Context, entry *LockEntry) (string, error) {
+	registry := u.registries.GetRegistry(entry.Registry)
+	if registry == nil {
+		return "", fmt.Errorf("registry %q not found or not enabled", entry.Registry)
+	}
+	meta, err := registry.GetSkillMeta(ctx, entry.Slug)
+	if err != nil {
+		return "", err
+	}
+	if meta.LatestVersion == "" {
+		return "", fmt.Errorf("registry does not report a version")
+	}
+	return meta.LatestVersion, nil
+}
+
+// Update installs the latest version of the named skill and keeps the
+// current one for Rollback. It refuses to replace locally modified files
+// unless force is set. It returns the new entry, which is unchanged when
+// the skill is already up to date.
+func (u *Updater) Update(ctx context.Context, name string, force bool) (*LockEntry, error) {
+	if err := utils.ValidateSkillIdentifier(name); err != nil {
+		return nil, fmt.Errorf("invalid skill name %q: %w", name, err)
+	}
+	lock, err := LoadLock(u.workspace)
+	if err != nil {
+		return nil, err
+	}
+	entry, ok := lock.Skills[name]
+	if !ok {
+		return nil, fmt.Errorf("skill %q was not installed from a registry", name)
+	}
+	if u.Modified(name, entry) && !force {
+		return nil, fmt.Errorf("%w: %s (use force to overwrite)", ErrLocallyModified, name)
+	}
+
+	latest, err := u.latest(ctx, entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check %q: %w", name, err)
+	}
+	if latest == entry.Version {
+		return entry, nil
+	}
+
+	// Stage below previousDir so the loader never sees a half-written skill.
+	stagingDir := filepath.Join(u.workspace, "skills", previousDir)
+	if err := os.MkdirAll(stagingDir, 0o755); err != nil {
+		return nil, err
+	}
+	staging, err := os.MkdirTemp(stagingDir, ".update-"+name+"-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	registry := u.registries.GetRegistry(entry.Registry)
+	result, err := registry.DownloadAndInstall(ctx, entry.Slug, latest, staging)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %q %s: %w", name, latest, err)
+	}
+	if result.IsMalwareBlocked {
+		return nil, fmt.Errorf("skill %q %s is flagged as malicious and was not installed", name, latest)
+	}
+	hash, err := HashDir(staging)
+	if err != nil {
+		return nil, err
+	}
+	if err := WriteOrigin(staging, entry.Registry, entry.Slug, result.Version); err != nil {
+		return nil, err
+	}
+
+	if err := u.swap(name, staging); err != nil {
+		return nil, err
+	}
+
+	previous := *entry
+	previous.Previous = nil
+	updated := &LockEntry{
+		Registry:    entry.Registry,
+		Slug:        entry.Slug,
+		Version:     result.Version,
+		Hash:        hash,
+		InstalledAt: time.Now().UnixMilli(),
+		Previous:    &previous,
+	}
+	lock.Skills[name] = updated
+	if err := lock.Save(u.workspace); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// Rollback restores the version replaced by the last update of the named
+// skill. The version rolled back from is kept, so a second Rollback undoes
+// the first. Local modifications of the current version are kept too.
+func (u *Updater) Rollback(name string) (*LockEntry, error) {
+	if err := utils.ValidateSkillIdentifier(name); err != nil {
+		return nil, fmt.Errorf("invalid skill name %q: %w", name, err)
+	}
+	lock, err := LoadLock(u.workspace)
+	if err != nil {
+		return nil, err
+	}
+	entry, ok := lock.Skills[name]
+	if !ok || entry.Previous == nil {
+		return nil, fmt.Errorf("no previous version of %q to roll back to", name)
+	}
+	kept := filepath.Join(u.workspace, "skills", previousDir, name)
+	if _, err := os.Stat(kept); err != nil {
+		return nil, fmt.Errorf("previous version of %q is missing: %w", name, err)
+	}
+
+	// Move the kept version aside first so swap can put the current one
+	// in its place.
+	restoring := kept + ".restore"
+	os.RemoveAll(restoring)
+	if err := os.Rename(kept, restoring); err != nil {
+		return nil, err
+	}
+	if err := u.swap(name, restoring); err != nil {
+		os.Rename(restoring, kept)
+		return nil, err
+	}
+
+	current := *entry
+	current.Previous = nil
+	restored := *entry.Previous
+	restored.Previous = &current
+	lock.Skills[name] = &restored
+	if err := lock.Save(u.workspace); err != nil {
+		return nil, err
+	}
+	return &restored, nil
+}
+
+// swap installs dir as the named skill and keeps the current version in
+// previousDir.
+func (u *Updater) swap(name, dir string) error {
+	target := filepath.Join(u.workspace, "skills", name)
+	kept := filepath.Join(u.workspace, "skills", previousDir, name)
+
+	if err := os.MkdirAll(filepath.Dir(kept), 0o755); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(kept); err != nil {
+		return err
+	}
+	if err := os.Rename(target, kept); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to keep current version: %w", err)
+	}
+	if err := os.Rename(dir, target); err != nil {
+		os.Rename(kept, target)
+		return fmt.Errorf("failed to install new version: %w", err)
+	}
+	return nil
+}
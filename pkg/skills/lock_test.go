@@ -0,0 +1,200 @@
+package skills
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// versionedRegistry installs a SKILL.md naming the requested version.
+type versionedRegistry struct {
+	latest string
+}
+
+func (r *versionedRegistry) Name() string { return "test" }
+
+func (r *versionedRegistry) Search(context.Context, string, int) ([]SearchResult, error) {
+	return nil, nil
+}
+
+func (r *versionedRegistry) GetSkillMeta(_ context.Context, slug string) (*SkillMeta, error) {
+	return &SkillMeta{Slug: slug, LatestVersion: r.latest, RegistryName: r.Name()}, nil
+}
+
+func (r *versionedRegistry) DownloadAndInstall(_ context.Context, slug, version, targetDir string) (*InstallResult, error) {
+	if err := os.MkdirAll(targetDir, 0o755); err != nil {
+		return nil, err
+	}
+	content := "---\nname: " + slug + "\ndescription: v" + version + "\n---\n# " + slug + " " + version + "\n"
+	if err := os.WriteFile(filepath.Join(targetDir, "SKILL.md"), []byte(content), 0o644); err != nil {
+		return nil, err
+	}
+	return &InstallResult{Version: version}, nil
+}
+
+func installForTest(t *testing.T, workspace string, reg SkillRegistry, slug, version string) {
+	t.Helper()
+	target := filepath.Join(workspace, "skills", slug)
+	if _, err := reg.DownloadAndInstall(context.Background(), slug, version, target); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteOrigin(target, reg.Name(), slug, version); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordInstall(workspace, reg.Name(), slug, version); err != nil {
+		t.Fatalf("RecordInstall() error = %v", err)
+	}
+}
+
+func skillVersion(t *testing.T, workspace, slug string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(workspace, "skills", slug, "SKILL.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := strings.Fields(strings.TrimSpace(string(data)))
+	return fields[len(fields)-1]
+}
+
+func TestHashDir(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte("a"), 0o644)
+	h1, err := HashDir(dir)
+	if err != nil || !strings.HasPrefix(h1, "sha256:") {
+		t.Fatalf("HashDir() = %q, %v", h1, err)
+	}
+
+	// The origin marker does not count.
+	WriteOrigin(dir, "test", "x", "1")
+	if h2, _ := HashDir(dir); h2 != h1 {
+		t.Error("origin marker changed the hash")
+	}
+
+	os.MkdirAll(filepath.Join(dir, "scripts"), 0o755)
+	os.WriteFile(filepath.Join(dir, "scripts", "run.sh"), []byte("b"), 0o644)
+	if h3, _ := HashDir(dir); h3 == h1 {
+		t.Error("new file did not change the hash")
+	}
+}
+
+func TestUpdater_UpdateAndRollback(t *testing.T) {
+	workspace := t.TempDir()
+	reg := &versionedRegistry{latest: "1.0.0"}
+	rm := NewRegistryManager()
+	rm.AddRegistry(reg)
+	u := NewUpdater(workspace, rm)
+
+	installForTest(t, workspace, reg, "weather", "1.0.0")
+
+	statuses, err := u.Status(context.Background(), true)
+	if err != nil || len(statuses) != 1 || statuses[0].Outdated() || statuses[0].Modified {
+		t.Fatalf("Status() = %+v, %v", statuses, err)
+	}
+
+	reg.latest = "1.1.0"
+	statuses, _ = u.Status(context.Background(), true)
+	if !statuses[0].Outdated() || statuses[0].Latest != "1.1.0" {
+		t.Errorf("Status() after release = %+v", statuses[0])
+	}
+
+	entry, err := u.Update(context.Background(), "weather", false)
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if entry.Version != "1.1.0" || entry.Previous == nil || entry.Previous.Version != "1.0.0" {
+		t.Errorf("entry = %+v", entry)
+	}
+	if got := skillVersion(t, workspace, "weather"); got != "1.1.0" {
+		t.Errorf("installed version = %s, want 1.1.0", got)
+	}
+	if _, err := os.Stat(filepath.Join(workspace, "skills", "weather", OriginFileName)); err != nil {
+		t.Error("update dropped the origin marker")
+	}
+
+	// The kept version is invisible to the loader.
+	loader := NewSkillsLoader(workspace, "", "")
+	if skills := loader.ListSkills(); len(skills) != 1 {
+		t.Errorf("ListSkills() = %+v, want only the updated skill", skills)
+	}
+
+	entry, err = u.Rollback("weather")
+	if err != nil || entry.Version != "1.0.0" || entry.Previous.Version != "1.1.0" {
+		t.Fatalf("Rollback() = %+v, %v", entry, err)
+	}
+	if got := skillVersion(t, workspace, "weather"); got != "1.0.0" {
+		t.Errorf("version after rollback = %s, want 1.0.0", got)
+	}
+	if u.Modified("weather", entry) {
+		t.Error("rolled back skill reported as modified")
+	}
+
+	// A second rollback undoes the first.
+	if entry, err = u.Rollback("weather"); err != nil || skillVersion(t, workspace, "weather") != "1.1.0" {
+		t.Errorf("second Rollback() = %+v, %v", entry, err)
+	}
+}
+
+func TestUpdater_LocalModifications(t *testing.T) {
+	workspace := t.TempDir()
+	reg := &versionedRegistry{latest: "1.0.0"}
+	rm := NewRegistryManager()
+	rm.AddRegistry(reg)
+	u := NewUpdater(workspace, rm)
+
+	installForTest(t, workspace, reg, "notes", "1.0.0")
+	skillFile := filepath.Join(workspace, "skills", "notes", "SKILL.md")
+	os.WriteFile(skillFile, []byte("---\nname: notes\ndescription: mine\n---\n# my edits\n"), 0o644)
+
+	statuses, _ := u.Status(context.Background(), false)
+	if len(statuses) != 1 || !statuses[0].Modified {
+		t.Fatalf("Status() = %+v, want modified", statuses)
+	}
+
+	reg.latest = "2.0.0"
+	if _, err := u.Update(context.Background(), "notes", false); !errors.Is(err, ErrLocallyModified) {
+		t.Fatalf("Update() error = %v, want ErrLocallyModified", err)
+	}
+	if data, _ := os.ReadFile(skillFile); !strings.Contains(string(data), "my edits") {
+		t.Error("refused update still touched the skill")
+	}
+
+	if _, err := u.Update(context.Background(), "notes", true); err != nil {
+		t.Fatalf("forced Update() error = %v", err)
+	}
+	kept := filepath.Join(workspace, "skills", previousDir, "notes", "SKILL.md")
+	if data, _ := os.ReadFile(kept); !strings.Contains(string(data), "my edits") {
+		t.Error("forced update did not keep the local edits for rollback")
+	}
+}
+
+func TestUpdater_RejectsInvalidNames(t *testing.T) {
+	u := NewUpdater(t.TempDir(), NewRegistryManager())
+	for _, name := range []string{"../escape", "a/b", ""} {
+		if _, err := u.Update(context.Background(), name, true); err == nil || !strings.Contains(err.Error(), "invalid skill name") {
+			t.Errorf("Update(%q) error = %v, want invalid skill name", name, err)
+		}
+		if _, err := u.Rollback(name); err == nil || !strings.Contains(err.Error(), "invalid skill name") {
+			t.Errorf("Rollback(%q) error = %v, want invalid skill name", name, err)
+		}
+	}
+}
+
+func TestRemoveFromLock(t *testing.T) {
+	workspace := t.TempDir()
+	reg := &versionedRegistry{latest: "1.0.0"}
+	installForTest(t, workspace, reg, "weather", "1.0.0")
+
+	if err := NewSkillInstaller(workspace).Uninstall("weather"); err != nil {
+		t.Fatal(err)
+	}
+	lock, err := LoadLock(workspace)
+	if err != nil || len(lock.Skills) != 0 {
+		t.Errorf("lock after uninstall = %+v, %v", lock, err)
+	}
+	if _, err := NewUpdater(workspace, nil).Rollback("weather"); err == nil {
+		t.Error("Rollback() of an uninstalled skill should fail")
+	}
+}
@@ -0,0 +1,159 @@
+package skills
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Permissions a skill can declare. A skill with script-backed tools
+// implicitly needs PermissionExec.
+const (
+	PermissionNetwork    = "network"    // Talks to remote services
+	PermissionFilesystem = "filesystem" // Writes files outside its own directory
+	PermissionExec       = "exec"       // Runs commands
+	PermissionHardware   = "hardware"   // Uses I2C, SPI, GPIO or serial devices
+	PermissionMessaging  = "messaging"  // Sends messages to chats
+)
+
+var (
+	knownPermissions = []string{
+		PermissionNetwork, PermissionFilesystem, PermissionExec, PermissionHardware, PermissionMessaging,
+	}
+	toolNamePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]{0,63}$`)
+)
+
+// Requirements are what a skill needs from the host. A skill whose
+// requirements are not met is listed as unavailable.
+type Requirements struct {
+	Bins  []string `json:"bins,omitempty"  yaml:"bins"`  // Executables looked up in PATH
+	Env   []string `json:"env,omitempty"   yaml:"env"`   // Environment variables that must be set
+	Tools []string `json:"tools,omitempty" yaml:"tools"` // Agent tools the instructions rely on
+}
+
+// ToolSpec declares a script-backed tool. Command is run with sh -c in the
+// skill directory; each {{param}} placeholder is replaced with a quoted
+// reference to an environment variable holding the argument value.
+type ToolSpec struct {
+	Name        string         `json:"name"                 yaml:"name"`
+	Description string         `json:"description"          yaml:"description"`
+	Parameters  map[string]any `json:"parameters,omitempty" yaml:"parameters"` // JSON schema of the arguments
+	Command     string         `json:"command"              yaml:"command"`
+	Timeout     int            `json:"timeout,omitempty"    yaml:"timeout"` // Seconds, 0 = exec tool default
+}
+
+func (spec ToolSpec) validate() error {
+	var errs error
+	if !toolNamePattern.MatchString(spec.Name) {
+		errs = errors.Join(errs, fmt.Errorf("tool name %q must be a letter followed by letters, digits, _ or -", spec.Name))
+	}
+	if strings.TrimSpace(spec.Command) == "" {
+		errs = errors.Join(errs, fmt.Errorf("tool %q has no command", spec.Name))
+	}
+	if t, ok := spec.Parameters["type"]; ok && t != "object" {
+		errs = errors.Join(errs, fmt.Errorf("tool %q parameters must be an object schema", spec.Name))
+	}
+	return errs
+}
+
+// parseSkillMetadata parses the frontmatter of a SKILL.md. JSON and YAML
+// frontmatter are supported; YAML that does not parse, such as an unquoted
+// description containing a colon, falls back to plain key: value lines.
+func parseSkillMetadata(content string) (*SkillMetadata, bool) {
+	var sl SkillsLoader
+	frontmatter := sl.extractFrontmatter(content)
+	if frontmatter == "" {
+		return nil, false
+	}
+
+	var meta SkillMetadata
+	if err := json.Unmarshal([]byte(frontmatter), &meta); err == nil {
+		return &meta, true
+	}
+	meta = SkillMetadata{}
+	if err := yaml.Unmarshal([]byte(frontmatter), &meta); err == nil {
+		return &meta, true
+	}
+
+	fields := sl.parseSimpleYAML(frontmatter)
+	return &SkillMetadata{
+		Name:        fields["name"],
+		Description: fields["description"],
+		Version:     fields["version"],
+	}, true
+}
+
+// ReadManifest parses the frontmatter of the SKILL.md in dir.
+func ReadManifest(dir string) (*SkillMetadata, error) {
+	content, err := os.ReadFile(filepath.Join(dir, "SKILL.md"))
+	if err != nil {
+		return nil, err
+	}
+	meta, ok := parseSkillMetadata(string(content))
+	if !ok {
+		return &SkillMetadata{Name: filepath.Base(dir)}, nil
+	}
+	return meta, nil
+}
+
+// Needs returns the permissions the skill declares, including the implied
+// PermissionExec of script-backed tools.
+func (info SkillInfo) Needs() []string {
+	perms := slices.Clone(info.Permissions)
+	if len(info.Tools) > 0 && !slices.Contains(perms, PermissionExec) {
+		perms = append(perms, PermissionExec)
+	}
+	return perms
+}
+
+// unavailableReason checks the requirements of a skill and returns why it
+// cannot be used, or "" when it can.
+func (sl *SkillsLoader) unavailableReason(info SkillInfo) string {
+	var missingBins, missingEnv, missingTools, denied []string
+	for _, bin := range info.Requires.Bins {
+		if _, err := exec.LookPath(bin); err != nil {
+			missingBins = append(missingBins, bin)
+		}
+	}
+	for _, env := range info.Requires.Env {
+		if os.Getenv(env) == "" {
+			missingEnv = append(missingEnv, env)
+		}
+	}
+	if sl.hasTool != nil {
+		for _, tool := range info.Requires.Tools {
+			if !sl.hasTool(tool) {
+				missingTools = append(missingTools, tool)
+			}
+		}
+	}
+	for _, perm := range info.Needs() {
+		if slices.Contains(sl.deniedPermissions, perm) {
+			denied = append(denied, perm)
+		}
+	}
+
+	var reasons []string
+	for _, r := range []struct {
+		what  string
+		names []string
+	}{
+		{"missing binaries", missingBins},
+		{"missing env vars", missingEnv},
+		{"missing tools", missingTools},
+		{"denied permissions", denied},
+	} {
+		if len(r.names) > 0 {
+			reasons = append(reasons, r.what+": "+strings.Join(r.names, ", "))
+		}
+	}
+	return strings.Join(reasons, "; ")
+}
@@ -0,0 +1,123 @@
+package skills
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const richSkill = `---
+name: weather
+description: "Forecasts: hourly and daily"
+version: 1.1.0
+requires:
+  bins: [sh]
+  env: [PICOCLAW_TEST_WEATHER_KEY]
+permissions: [network]
+tools:
+  - name: forecast
+    description: Get the forecast for a city
+    command: ./forecast.sh {{city}}
+    timeout: 10
+    parameters:
+      type: object
+      properties:
+        city: {type: string}
+      required: [city]
+---
+# Weather
+`
+
+func TestParseSkillMetadata(t *testing.T) {
+	meta, ok := parseSkillMetadata(richSkill)
+	if !ok || meta.Name != "weather" || meta.Description != "Forecasts: hourly and daily" || meta.Version != "1.1.0" {
+		t.Fatalf("meta = %+v", meta)
+	}
+	if len(meta.Requires.Bins) != 1 || meta.Requires.Env[0] != "PICOCLAW_TEST_WEATHER_KEY" ||
+		meta.Permissions[0] != PermissionNetwork {
+		t.Errorf("requirements = %+v, permissions = %v", meta.Requires, meta.Permissions)
+	}
+	if len(meta.Tools) != 1 || meta.Tools[0].Command != "./forecast.sh {{city}}" || meta.Tools[0].Timeout != 10 {
+		t.Fatalf("tools = %+v", meta.Tools)
+	}
+	if props, _ := meta.Tools[0].Parameters["properties"].(map[string]any); props["city"] == nil {
+		t.Errorf("parameters = %+v", meta.Tools[0].Parameters)
+	}
+
+	// Unquoted colons are not valid YAML but were always accepted.
+	meta, _ = parseSkillMetadata("---\nname: notes\ndescription: Notes: quick ones\n---\n")
+	if meta.Name != "notes" || meta.Description != "Notes: quick ones" {
+		t.Errorf("fallback meta = %+v", meta)
+	}
+}
+
+func TestListSkills_Requirements(t *testing.T) {
+	workspace := t.TempDir()
+	writeSkill(t, filepath.Join(workspace, "skills", "weather"), richSkill, nil)
+	writeSkill(t, filepath.Join(workspace, "skills", "lights"),
+		"---\nname: lights\ndescription: Control lights\nrequires:\n  bins: [picoclaw-no-such-binary]\n  tools: [i2c]\n---\n", nil)
+
+	sl := NewSkillsLoader(workspace, "", "")
+	sl.SetToolChecker(func(name string) bool { return name != "i2c" })
+	byName := func() map[string]SkillInfo {
+		m := make(map[string]SkillInfo)
+		for _, s := range sl.ListSkills() {
+			m[s.Name] = s
+		}
+		return m
+	}
+
+	t.Setenv("PICOCLAW_TEST_WEATHER_KEY", "")
+	skills := byName()
+	if got := skills["weather"].Unavailable; got != "missing env vars: PICOCLAW_TEST_WEATHER_KEY" {
+		t.Errorf("weather unavailable = %q", got)
+	}
+	if got := skills["lights"].Unavailable; got != "missing binaries: picoclaw-no-such-binary; missing tools: i2c" {
+		t.Errorf("lights unavailable = %q", got)
+	}
+	summary := sl.BuildSkillsSummary()
+	if !strings.Contains(summary, "<status>unavailable because missing env vars: PICOCLAW_TEST_WEATHER_KEY</status>") ||
+		strings.Contains(summary, "<tools>forecast</tools>") {
+		t.Errorf("summary = %s", summary)
+	}
+
+	t.Setenv("PICOCLAW_TEST_WEATHER_KEY", "secret")
+	if w := byName()["weather"]; !w.Available() || len(w.Tools) != 1 {
+		t.Errorf("weather = %+v, want available", w)
+	}
+	summary = sl.BuildSkillsSummary()
+	if !strings.Contains(summary, "<permissions>network, exec</permissions>") ||
+		!strings.Contains(summary, "<tools>forecast</tools>") {
+		t.Errorf("summary = %s", summary)
+	}
+
+	sl.SetDeniedPermissions([]string{PermissionExec})
+	if got := byName()["weather"].Unavailable; got != "denied permissions: exec" {
+		t.Errorf("weather with exec denied = %q", got)
+	}
+}
+
+func TestSkillInfoValidate_Manifest(t *testing.T) {
+	for _, info := range []SkillInfo{
+		{Permissions: []string{"root"}},
+		{Tools: []ToolSpec{{Name: "bad name", Command: "true"}}},
+		{Tools: []ToolSpec{{Name: "noop"}}},
+		{Tools: []ToolSpec{{Name: "t", Command: "true"}, {Name: "t", Command: "true"}}},
+		{Tools: []ToolSpec{{Name: "t", Command: "true", Parameters: map[string]any{"type": "string"}}}},
+	} {
+		info.Name, info.Description = "skill", "desc"
+		if err := info.validate(); err == nil {
+			t.Errorf("validate(%+v) = nil, want error", info)
+		}
+	}
+}
+
+func TestReadManifest(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte(richSkill), 0o644)
+	meta, err := ReadManifest(dir)
+	if err != nil || meta.Name != "weather" || len(meta.Tools) != 1 {
+		t.Errorf("ReadManifest() = %+v, %v", meta, err)
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"path/filepath"
 	"sync"
 	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
 )
 
 const (
@@ -61,6 +64,9 @@ type SkillRegistry interface {
 // This is the input to NewRegistryManagerFromConfig.
 type RegistryConfig struct {
 	ClawHub               ClawHubConfig
+	Git                   []GitConfig
+	Local                 []LocalDirConfig
+	CacheDir              string // Checkouts of git registries
 	MaxConcurrentSearches int
 }
 
@@ -77,6 +83,22 @@ type ClawHubConfig struct {
 	MaxResponseSize int    // bytes, 0 = default (2MB)
 }
 
+// GitConfig configures a git registry.
+type GitConfig struct {
+	Name           string
+	URL            string // Repository URL, or a GitHub organization like https://github.com/acme
+	Ref            string // Branch or tag, empty = default branch
+	Path           string // Directory holding the skills, empty = repository root
+	AuthToken      string
+	RefreshMinutes int // 0 = default (60)
+}
+
+// LocalDirConfig configures a registry backed by a local directory.
+type LocalDirConfig struct {
+	Name string
+	Path string
+}
+
 // RegistryManager coordinates multiple skill registries.
 // It fans out search requests and routes installs to the correct registry.
 type RegistryManager struct {
@@ -93,6 +115,23 @@ func NewRegistryManager() *RegistryManager {
 	}
 }
 
+// RegistryConfigFrom converts the skill registry settings of cfg. Git
+// registries keep their checkouts under the workspace cache.
+func RegistryConfigFrom(cfg *config.Config) RegistryConfig {
+	regCfg := RegistryConfig{
+		MaxConcurrentSearches: cfg.Tools.Skills.MaxConcurrentSearches,
+		ClawHub:               ClawHubConfig(cfg.Tools.Skills.Registries.ClawHub),
+		CacheDir:              filepath.Join(cfg.WorkspacePath(), "cache", "skill-registries"),
+	}
+	for _, g := range cfg.Tools.Skills.Registries.Git {
+		regCfg.Git = append(regCfg.Git, GitConfig(g))
+	}
+	for _, l := range cfg.Tools.Skills.Registries.Local {
+		regCfg.Local = append(regCfg.Local, LocalDirConfig(l))
+	}
+	return regCfg
+}
+
 // NewRegistryManagerFromConfig builds a RegistryManager from config,
 // instantiating only the enabled registries.
 func NewRegistryManagerFromConfig(cfg RegistryConfig) *RegistryManager {
@@ -103,6 +142,12 @@ func NewRegistryManagerFromConfig(cfg RegistryConfig) *RegistryManager {
 	if cfg.ClawHub.Enabled {
 		rm.AddRegistry(NewClawHubRegistry(cfg.ClawHub))
 	}
+	for _, g := range cfg.Git {
+		rm.addUnique(NewGitRegistry(g, cfg.CacheDir))
+	}
+	for _, l := range cfg.Local {
+		rm.addUnique(NewLocalDirRegistry(l))
+	}
 	return rm
 }
 
@@ -113,6 +158,16 @@ func (rm *RegistryManager) AddRegistry(r SkillRegistry) {
 	rm.registries = append(rm.registries, r)
 }
 
+// addUnique adds r unless a registry with the same name exists, since
+// installs are routed by name.
+func (rm *RegistryManager) addUnique(r SkillRegistry) {
+	if rm.GetRegistry(r.Name()) != nil {
+		slog.Warn("skipping skill registry with duplicate name", "name", r.Name())
+		return
+	}
+	rm.AddRegistry(r)
+}
+
 // GetRegistry returns a registry by name, or nil if not found.
 func (rm *RegistryManager) GetRegistry(name string) SkillRegistry {
 	rm.mu.RLock()
@@ -0,0 +1,87 @@
+package skills
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// stopWords are ignored when matching messages against skills.
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "that": true, "this": true,
+	"from": true, "into": true, "what": true, "when": true, "how": true, "can": true,
+	"you": true, "your": true, "are": true, "was": true, "please": true, "about": true,
+	"use": true, "using": true, "want": true, "need": true, "some": true, "have": true,
+}
+
+func keywords(text string) []string {
+	var words []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(w) >= 3 && !stopWords[w] {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+// wordsMatch treats words as equal when one is a prefix of the other and
+// the shorter has at least four letters, so "forecasts" matches "forecast".
+func wordsMatch(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	return len(a) >= 4 && strings.HasPrefix(b, a)
+}
+
+// RankSkills returns up to k skills whose name or description share
+// keywords with query, most relevant first. Name matches weigh double.
+// Skills without any match are left out; k <= 0 means no limit.
+func RankSkills(list []SkillInfo, query string, k int) []SkillInfo {
+	queryWords := keywords(query)
+	if len(queryWords) == 0 {
+		return nil
+	}
+
+	type scored struct {
+		info  SkillInfo
+		score float64
+	}
+	var ranked []scored
+	for _, info := range list {
+		nameWords := keywords(strings.ReplaceAll(info.Name, "-", " "))
+		descWords := keywords(info.Description)
+		score := 0.0
+		for _, q := range queryWords {
+			for _, w := range nameWords {
+				if wordsMatch(q, w) {
+					score += 2
+					break
+				}
+			}
+			for _, w := range descWords {
+				if wordsMatch(q, w) {
+					score++
+					break
+				}
+			}
+		}
+		if score > 0 {
+			ranked = append(ranked, scored{info, score})
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+	if k > 0 && len(ranked) > k {
+		ranked = ranked[:k]
+	}
+	result := make([]SkillInfo, len(ranked))
+	for i, r := range ranked {
+		result[i] = r.info
+	}
+	return result
+}
@@ -0,0 +1,27 @@
+package skills
+
+import "testing"
+
+func TestRankSkills(t *testing.T) {
+	list := []SkillInfo{
+		{Name: "github", Description: "Work with issues and pull requests"},
+		{Name: "weather", Description: "Current conditions and forecasts"},
+		{Name: "calendar", Description: "Check events and the weather of trips"},
+		{Name: "tmux", Description: "Drive terminal sessions"},
+	}
+
+	got := RankSkills(list, "What's the weather forecast for tomorrow?", 5)
+	if len(got) != 2 || got[0].Name != "weather" || got[1].Name != "calendar" {
+		t.Fatalf("RankSkills() = %+v, want weather then calendar", got)
+	}
+
+	if got := RankSkills(list, "open a pull request", 1); len(got) != 1 || got[0].Name != "github" {
+		t.Errorf("RankSkills() limited = %+v", got)
+	}
+	if got := RankSkills(list, "hello there", 5); len(got) != 0 {
+		t.Errorf("unrelated message matched %+v", got)
+	}
+	if got := RankSkills(list, "the and for", 5); got != nil {
+		t.Errorf("stop words matched %+v", got)
+	}
+}
@@ -17,6 +17,14 @@ type ContextualTool interface {
 	SetContext(channel, chatID string)
 }
 
+// HighRiskTool is an optional interface for tools that are always high-risk,
+// whatever the configured high-risk list says. UntrustedGuard gates them
+// after untrusted content was read.
+type HighRiskTool interface {
+	Tool
+	HighRisk() bool
+}
+
 // AsyncCallback is a function type that async tools use to notify completion.
 // When an async tool finishes its work, it calls this callback with the result.
 //
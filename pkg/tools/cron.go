@@ -6,13 +6,20 @@ import (
 	"sync"
 	"time"
 
+	"github.com/sipeed/picoclaw/pkg/audit"
 	"github.com/sipeed/picoclaw/pkg/bus"
 	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/constants"
 	"github.com/sipeed/picoclaw/pkg/cron"
 	"github.com/sipeed/picoclaw/pkg/utils"
 )
 
-// JobExecutor is the interface for executing cron jobs through the agent
+// unattendedSenders are the sender IDs of turns no user started. Jobs they
+// schedule have no creator and run under the system role.
+var unattendedSenders = map[string]bool{"cron": true, "heartbeat": true, "trigger": true}
+
+// JobExecutor is the interface for executing cron jobs through the agent.
+// The context carries the job's creator (see cron.CreatorFrom).
 type JobExecutor interface {
 	ProcessDirectWithChannel(ctx context.Context, content, sessionKey, channel, chatID string) (string, error)
 }
@@ -51,7 +58,7 @@ func (t *CronTool) Name() string {
 
 // Description returns the tool description
 func (t *CronTool) Description() string {
-	return "Schedule reminders, tasks, or system commands. IMPORTANT: When user asks to be reminded or scheduled, you MUST call this tool. Use 'at_seconds' for one-time reminders (e.g., 'remind me in 10 minutes' → at_seconds=600). Use 'every_seconds' ONLY for recurring tasks (e.g., 'every 2 hours' → every_seconds=7200). Use 'cron_expr' for complex recurring schedules. Use 'command' to execute shell commands directly."
+	return "Schedule reminders, tasks, or system commands. IMPORTANT: When user asks to be reminded or scheduled, you MUST call this tool. Prefer 'schedule' and pass the user's wording in English (e.g., 'in 10 minutes', 'every weekday at 9am', 'first monday of the month'). Otherwise use 'at_seconds' for one-time reminders (e.g., 'remind me in 10 minutes' → at_seconds=600), 'every_seconds' ONLY for recurring tasks (e.g., 'every 2 hours' → every_seconds=7200) or 'cron_expr' for complex recurring schedules. Use 'command' to execute shell commands directly."
 }
 
 // Parameters returns the tool parameters schema
@@ -84,6 +91,30 @@ func (t *CronTool) Parameters() map[string]any {
 				"type":        "string",
 				"description": "Cron expression for complex recurring schedules (e.g., '0 9 * * *' for daily at 9am). Use this for complex recurring schedules.",
 			},
+			"schedule": map[string]any{
+				"type":        "string",
+				"description": "Human schedule, e.g. 'in 20 minutes', 'tomorrow at 8am', 'every 2 hours', 'every monday and friday at 18:30', 'first monday of the month', 'last day of the month at 5pm'.",
+			},
+			"timezone": map[string]any{
+				"type":        "string",
+				"description": "Optional: IANA time zone for the schedule and quiet hours (e.g., 'Europe/Berlin'). Default: the gateway's local time",
+			},
+			"jitter_seconds": map[string]any{
+				"type":        "integer",
+				"description": "Optional: delay each recurring run by a random 0..N seconds",
+			},
+			"until": map[string]any{
+				"type":        "string",
+				"description": "Optional: stop running after this date or time ('2026-12-31', '2026-12-31 18:00' or RFC 3339)",
+			},
+			"max_runs": map[string]any{
+				"type":        "integer",
+				"description": "Optional: stop after this many runs",
+			},
+			"quiet_hours": map[string]any{
+				"type":        "string",
+				"description": "Optional: window such as '22:00-07:00'; runs due inside it wait until it ends",
+			},
 			"job_id": map[string]any{
 				"type":        "string",
 				"description": "Job ID (for remove/enable/disable)",
@@ -92,6 +123,20 @@ func (t *CronTool) Parameters() map[string]any {
 				"type":        "boolean",
 				"description": "If true, send message directly to channel. If false, let agent process message (for complex tasks). Default: true",
 			},
+			"timeout_seconds": map[string]any{
+				"type":        "integer",
+				"description": "Optional: stop a run after this many seconds. Default: no timeout",
+			},
+			"misfire_policy": map[string]any{
+				"type":        "string",
+				"enum":        []string{cron.MisfireSkip, cron.MisfireRunOnce, cron.MisfireRunAll},
+				"description": "Optional: what to do with runs missed while the gateway was down. Default: skip",
+			},
+			"overlap_policy": map[string]any{
+				"type":        "string",
+				"enum":        []string{cron.OverlapSkip, cron.OverlapQueue, cron.OverlapAllow},
+				"description": "Optional: what to do when the job is due while its previous run is still going. Default: skip",
+			},
 		},
 		"required": []string{"action"},
 	}
@@ -114,7 +159,7 @@ func (t *CronTool) Execute(ctx context.Context, args map[string]any) *ToolResult
 
 	switch action {
 	case "add":
-		return t.addJob(args)
+		return t.addJob(ctx, args)
 	case "list":
 		return t.listJobs()
 	case "remove":
@@ -128,7 +173,7 @@ func (t *CronTool) Execute(ctx context.Context, args map[string]any) *ToolResult
 	}
 }
 
-func (t *CronTool) addJob(args map[string]any) *ToolResult {
+func (t *CronTool) addJob(ctx context.Context, args map[string]any) *ToolResult {
 	t.mu.RLock()
 	channel := t.channel
 	chatID := t.chatID
@@ -145,13 +190,22 @@ func (t *CronTool) addJob(args map[string]any) *ToolResult {
 
 	var schedule cron.CronSchedule
 
-	// Check for at_seconds (one-time), every_seconds (recurring), or cron_expr
+	// Check for schedule, at_seconds (one-time), every_seconds (recurring) or cron_expr
+	human, _ := args["schedule"].(string)
 	atSeconds, hasAt := args["at_seconds"].(float64)
 	everySeconds, hasEvery := args["every_seconds"].(float64)
 	cronExpr, hasCron := args["cron_expr"].(string)
-
-	// Priority: at_seconds > every_seconds > cron_expr
-	if hasAt {
+	timezone, _ := args["timezone"].(string)
+
+	// Priority: schedule > at_seconds > every_seconds > cron_expr, matching
+	// the order the description asks the model to prefer them in
+	if human != "" {
+		parsed, err := cron.ParseSchedule(human, timezone, time.Now())
+		if err != nil {
+			return ErrorResult(err.Error())
+		}
+		schedule = parsed
+	} else if hasAt {
 		atMS := time.Now().UnixMilli() + int64(atSeconds)*1000
 		schedule = cron.CronSchedule{
 			Kind: "at",
@@ -169,7 +223,22 @@ func (t *CronTool) addJob(args map[string]any) *ToolResult {
 			Expr: cronExpr,
 		}
 	} else {
-		return ErrorResult("one of at_seconds, every_seconds, or cron_expr is required")
+		return ErrorResult("one of schedule, at_seconds, every_seconds, or cron_expr is required")
+	}
+
+	jitter, _ := args["jitter_seconds"].(float64)
+	maxRuns, _ := args["max_runs"].(float64)
+	until, _ := args["until"].(string)
+	quiet, _ := args["quiet_hours"].(string)
+	opts := cron.ScheduleOptions{
+		TZ:         timezone,
+		JitterSec:  int(jitter),
+		Until:      until,
+		MaxRuns:    int(maxRuns),
+		QuietHours: quiet,
+	}
+	if err := opts.Apply(&schedule); err != nil {
+		return ErrorResult(err.Error())
 	}
 
 	// Read deliver parameter, default to true
@@ -178,6 +247,16 @@ func (t *CronTool) addJob(args map[string]any) *ToolResult {
 		deliver = d
 	}
 
+	misfire, _ := args["misfire_policy"].(string)
+	if !cron.ValidMisfirePolicy(misfire) {
+		return ErrorResult(fmt.Sprintf("unknown misfire_policy: %s", misfire))
+	}
+	overlap, _ := args["overlap_policy"].(string)
+	if !cron.ValidOverlapPolicy(overlap) {
+		return ErrorResult(fmt.Sprintf("unknown overlap_policy: %s", overlap))
+	}
+	timeout, _ := args["timeout_seconds"].(float64)
+
 	command, _ := args["command"].(string)
 	if command != "" {
 		// Commands must be processed by agent/exec tool, so deliver must be false (or handled specifically)
@@ -202,8 +281,13 @@ func (t *CronTool) addJob(args map[string]any) *ToolResult {
 		return ErrorResult(fmt.Sprintf("Error adding job: %v", err))
 	}
 
-	if command != "" {
+	creator := jobCreator(ctx)
+	if command != "" || misfire != "" || overlap != "" || timeout > 0 || creator != nil {
 		job.Payload.Command = command
+		job.MisfirePolicy = misfire
+		job.OverlapPolicy = overlap
+		job.TimeoutSec = int(timeout)
+		job.Creator = creator
 		// Need to save the updated payload
 		t.cronService.UpdateJob(job)
 	}
@@ -211,6 +295,19 @@ func (t *CronTool) addJob(args map[string]any) *ToolResult {
 	return SilentResult(fmt.Sprintf("Cron job added: %s (id: %s)", job.Name, job.ID))
 }
 
+// jobCreator returns the sender a job scheduled in ctx should run as. A job
+// scheduled by another job inherits that job's creator.
+func jobCreator(ctx context.Context) *cron.CronCreator {
+	if creator := cron.CreatorFrom(ctx); creator != nil {
+		return creator
+	}
+	actor := audit.ActorFrom(ctx)
+	if actor.Sender == "" || unattendedSenders[actor.Sender] || constants.IsInternalChannel(actor.Channel) {
+		return nil
+	}
+	return &cron.CronCreator{Channel: actor.Channel, SenderID: actor.Sender}
+}
+
 func (t *CronTool) listJobs() *ToolResult {
 	jobs := t.cronService.ListJobs(false)
 
@@ -266,8 +363,9 @@ func (t *CronTool) enableJob(args map[string]any, enable bool) *ToolResult {
 	return SilentResult(fmt.Sprintf("Cron job '%s' %s", job.Name, status))
 }
 
-// ExecuteJob executes a cron job through the agent
-func (t *CronTool) ExecuteJob(ctx context.Context, job *cron.CronJob) string {
+// ExecuteJob executes a cron job through the agent. It returns the text that
+// was delivered or produced, which the cron service keeps in the run history.
+func (t *CronTool) ExecuteJob(ctx context.Context, job *cron.CronJob) (string, error) {
 	// Get channel/chatID from job payload
 	channel := job.Payload.Channel
 	chatID := job.Payload.To
@@ -298,8 +396,12 @@ func (t *CronTool) ExecuteJob(ctx context.Context, job *cron.CronJob) string {
 			Channel: channel,
 			ChatID:  chatID,
 			Content: output,
+			Sender:  "cron:" + job.ID,
 		})
-		return "ok"
+		if result.IsError {
+			return result.ForLLM, fmt.Errorf("command failed")
+		}
+		return result.ForLLM, nil
 	}
 
 	// If deliver=true, send message directly without agent processing
@@ -308,26 +410,21 @@ func (t *CronTool) ExecuteJob(ctx context.Context, job *cron.CronJob) string {
 			Channel: channel,
 			ChatID:  chatID,
 			Content: job.Payload.Message,
+			Sender:  "cron:" + job.ID,
 		})
-		return "ok"
+		return job.Payload.Message, nil
 	}
 
 	// For deliver=false, process through agent (for complex tasks)
 	sessionKey := fmt.Sprintf("cron-%s", job.ID)
 
-	// Call agent with job's message
-	response, err := t.executor.ProcessDirectWithChannel(
-		ctx,
+	// Call agent with job's message. The response is sent via MessageBus by
+	// AgentLoop; it is returned here only for the run history.
+	return t.executor.ProcessDirectWithChannel(
+		cron.WithCreator(ctx, job.Creator),
 		job.Payload.Message,
 		sessionKey,
 		channel,
 		chatID,
 	)
-	if err != nil {
-		return fmt.Sprintf("Error: %v", err)
-	}
-
-	// Response is automatically sent via MessageBus by AgentLoop
-	_ = response // Will be sent by AgentLoop
-	return "ok"
 }
@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/audit"
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/cron"
+)
+
+func TestCronTool_RecordsCreator(t *testing.T) {
+	workspace := t.TempDir()
+	service := cron.NewCronService(filepath.Join(workspace, "jobs.json"), nil)
+	tool := NewCronTool(service, nil, bus.NewMessageBus(), workspace, true, 0, config.DefaultConfig())
+	tool.SetContext("telegram", "9")
+
+	add := func(ctx context.Context) *cron.CronCreator {
+		t.Helper()
+		result := tool.Execute(ctx, map[string]any{"action": "add", "message": "ping", "at_seconds": float64(600)})
+		if result.IsError {
+			t.Fatalf("add failed: %s", result.ForLLM)
+		}
+		jobs := service.ListJobs(true)
+		return jobs[len(jobs)-1].Creator
+	}
+
+	user := audit.WithActor(context.Background(), audit.Actor{Channel: "telegram", Sender: "9|alice"})
+	if got := add(user); got == nil || got.Channel != "telegram" || got.SenderID != "9|alice" {
+		t.Errorf("user job creator = %+v", got)
+	}
+
+	heartbeat := audit.WithActor(context.Background(), audit.Actor{Channel: "telegram", Sender: "heartbeat"})
+	if got := add(heartbeat); got != nil {
+		t.Errorf("heartbeat job creator = %+v, want nil", got)
+	}
+
+	// A job scheduled by another job keeps that job's creator.
+	parent := &cron.CronCreator{Channel: "discord", SenderID: "2"}
+	nested := cron.WithCreator(audit.WithActor(context.Background(), audit.Actor{Channel: "telegram", Sender: "cron"}), parent)
+	if got := add(nested); got == nil || *got != *parent {
+		t.Errorf("nested job creator = %+v, want %+v", got, parent)
+	}
+}
@@ -84,7 +84,8 @@ func isWithinWorkspace(candidate, workspace string) bool {
 }
 
 type ReadFileTool struct {
-	fs fileSystem
+	fs        fileSystem
+	workspace string
 }
 
 func NewReadFileTool(workspace string, restrict bool) *ReadFileTool {
@@ -94,7 +95,7 @@ func NewReadFileTool(workspace string, restrict bool) *ReadFileTool {
 	} else {
 		fs = &hostFs{}
 	}
-	return &ReadFileTool{fs: fs}
+	return &ReadFileTool{fs: fs, workspace: workspace}
 }
 
 func (t *ReadFileTool) Name() string {
@@ -128,7 +129,12 @@ func (t *ReadFileTool) Execute(ctx context.Context, args map[string]any) *ToolRe
 	if err != nil {
 		return ErrorResult(err.Error())
 	}
-	return NewToolResult(string(content))
+	result := NewToolResult(string(content))
+	// Files of skills downloaded from a registry are third-party content.
+	if source, ok := installedSkillSource(t.workspace, path); ok {
+		result.MarkUntrusted(source)
+	}
+	return result
 }
 
 type WriteFileTool struct {
@@ -0,0 +1,184 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+)
+
+// GPIOTool reads and drives GPIO lines through the Linux GPIO character
+// device (/dev/gpiochipN, uAPI v2).
+type GPIOTool struct {
+	devDir  string        // Directory holding gpiochip devices, /dev outside tests
+	request gpioRequester // Claims lines; nil means the kernel ioctl. Tests replace it
+}
+
+func NewGPIOTool() *GPIOTool {
+	return &GPIOTool{devDir: "/dev"}
+}
+
+func (t *GPIOTool) Name() string {
+	return "gpio"
+}
+
+func (t *GPIOTool) Description() string {
+	return "Read and control GPIO lines through /dev/gpiochipN. Actions: list (chips and their lines), read (line values), write (drive an output line), wait (wait for an edge on an input line). Linux only."
+}
+
+func (t *GPIOTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"action": map[string]any{
+				"type":        "string",
+				"enum":        []string{"list", "read", "write", "wait"},
+				"description": "Action to perform: list (GPIO chips and lines), read (values of input lines), write (set an output line), wait (block until an edge on a line or timeout)",
+			},
+			"chip": map[string]any{
+				"type":        "string",
+				"description": "GPIO chip number (e.g. \"0\" for /dev/gpiochip0). Required for read/write/wait; optional filter for list.",
+			},
+			"lines": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "integer"},
+				"description": "Line offsets on the chip. Required for read (up to 64 lines); write and wait use the first line.",
+			},
+			"value": map[string]any{
+				"type":        "integer",
+				"description": "Value to drive (0 or 1). Required for write.",
+			},
+			"hold_ms": map[string]any{
+				"type":        "integer",
+				"description": "Keep driving the line for this long before releasing it (0-60000). Default: 0. Some drivers reset a line once it is released.",
+			},
+			"edge": map[string]any{
+				"type":        "string",
+				"enum":        []string{"rising", "falling", "both"},
+				"description": "Edge to wait for. Default: both. Used with wait.",
+			},
+			"bias": map[string]any{
+				"type":        "string",
+				"enum":        []string{"as-is", "pull-up", "pull-down", "disabled"},
+				"description": "Bias applied while reading or waiting. Default: as-is.",
+			},
+			"active_low": map[string]any{
+				"type":        "boolean",
+				"description": "Treat the line as active low, inverting read and written values.",
+			},
+			"timeout_ms": map[string]any{
+				"type":        "integer",
+				"description": "How long to wait for an edge (1-60000). Default: 5000.",
+			},
+			"confirm": map[string]any{
+				"type":        "boolean",
+				"description": "Must be true for write operations. Safety guard to prevent accidental writes.",
+			},
+		},
+		"required": []string{"action"},
+	}
+}
+
+func (t *GPIOTool) Execute(ctx context.Context, args map[string]any) *ToolResult {
+	if runtime.GOOS != "linux" {
+		return ErrorResult("GPIO is only supported on Linux. This tool requires /dev/gpiochip* device files.")
+	}
+
+	action, ok := args["action"].(string)
+	if !ok {
+		return ErrorResult("action is required")
+	}
+
+	switch action {
+	case "list":
+		return t.list(args)
+	case "read":
+		return t.readLines(args)
+	case "write":
+		return t.writeLine(ctx, args)
+	case "wait":
+		return t.waitEdge(ctx, args)
+	default:
+		return ErrorResult(fmt.Sprintf("unknown action: %s (valid: list, read, write, wait)", action))
+	}
+}
+
+// chips returns the gpiochip device paths in devDir, ordered by number.
+//
+//nolint:unused // Used by gpio_linux.go
+func (t *GPIOTool) chips() []string {
+	matches, _ := filepath.Glob(filepath.Join(t.devDir, "gpiochip*"))
+	re := regexp.MustCompile(`gpiochip\d+$`)
+	paths := matches[:0]
+	for _, m := range matches {
+		if re.MatchString(m) {
+			paths = append(paths, m)
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return len(paths[i]) < len(paths[j]) || len(paths[i]) == len(paths[j]) && paths[i] < paths[j]
+	})
+	return paths
+}
+
+// Helper functions for GPIO operations (used by platform-specific implementations)
+
+// parseGPIOChip extracts and validates a chip number from args
+//
+//nolint:unused // Used by gpio_linux.go
+func parseGPIOChip(args map[string]any) (string, *ToolResult) {
+	chip, ok := args["chip"].(string)
+	if !ok || chip == "" {
+		return "", ErrorResult("chip is required (e.g. \"0\" for /dev/gpiochip0)")
+	}
+	if !isValidBusID(chip) {
+		return "", ErrorResult("invalid chip identifier: must be a number (e.g. \"0\")")
+	}
+	return chip, nil
+}
+
+// parseGPIOLines extracts up to max line offsets from args
+//
+//nolint:unused // Used by gpio_linux.go
+func parseGPIOLines(args map[string]any, max int) ([]uint32, *ToolResult) {
+	raw, ok := args["lines"].([]any)
+	if !ok || len(raw) == 0 {
+		return nil, ErrorResult("lines is required (e.g. [17])")
+	}
+	if len(raw) > max {
+		return nil, ErrorResult(fmt.Sprintf("too many lines: %d (max %d)", len(raw), max))
+	}
+	lines := make([]uint32, 0, len(raw))
+	seen := map[uint32]bool{}
+	for i, v := range raw {
+		f, ok := v.(float64)
+		if !ok || f < 0 || f != float64(int(f)) {
+			return nil, ErrorResult(fmt.Sprintf("invalid line at index %d: must be a non-negative integer", i))
+		}
+		line := uint32(f)
+		if seen[line] {
+			return nil, ErrorResult(fmt.Sprintf("line %d is listed twice", line))
+		}
+		seen[line] = true
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// parseBoundedInt reads an optional integer argument, applying def when it
+// is missing and rejecting values outside [min, max].
+//
+//nolint:unused // Used by gpio_linux.go and serial_linux.go
+func parseBoundedInt(args map[string]any, name string, def, min, max int) (int, *ToolResult) {
+	v, ok := args[name].(float64)
+	if !ok {
+		return def, nil
+	}
+	n := int(v)
+	if n < min || n > max {
+		return 0, ErrorResult(fmt.Sprintf("%s must be between %d and %d", name, min, max))
+	}
+	return n, nil
+}
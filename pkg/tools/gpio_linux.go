@@ -0,0 +1,502 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// GPIO ioctl constants from Linux kernel headers (<linux/gpio.h>, uAPI v2).
+// Calculated from _IOR/_IOWR(0xB4, nr, size):
+//
+//	direction<<30 | size<<16 | type(0xB4)<<8 | nr
+const (
+	gpioGetChipInfo     = 0x8044B401 // _IOR(0xB4, 0x01, struct gpiochip_info) — 68 bytes
+	gpioV2GetLineInfo   = 0xC100B405 // _IOWR(0xB4, 0x05, struct gpio_v2_line_info) — 256 bytes
+	gpioV2GetLine       = 0xC250B407 // _IOWR(0xB4, 0x07, struct gpio_v2_line_request) — 592 bytes
+	gpioV2LineGetValues = 0xC010B40E // _IOWR(0xB4, 0x0E, struct gpio_v2_line_values) — 16 bytes
+	gpioV2LineSetValues = 0xC010B40F // _IOWR(0xB4, 0x0F, struct gpio_v2_line_values) — 16 bytes
+
+	// GPIO_V2_LINE_FLAG bits
+	gpioFlagUsed         = 1 << 0
+	gpioFlagActiveLow    = 1 << 1
+	gpioFlagInput        = 1 << 2
+	gpioFlagOutput       = 1 << 3
+	gpioFlagEdgeRising   = 1 << 4
+	gpioFlagEdgeFalling  = 1 << 5
+	gpioFlagOpenDrain    = 1 << 6
+	gpioFlagOpenSource   = 1 << 7
+	gpioFlagBiasPullUp   = 1 << 8
+	gpioFlagBiasPullDown = 1 << 9
+	gpioFlagBiasDisabled = 1 << 10
+
+	gpioAttrOutputValues = 2 // GPIO_V2_LINE_ATTR_ID_OUTPUT_VALUES
+	gpioEventRisingEdge  = 1 // GPIO_V2_LINE_EVENT_RISING_EDGE
+
+	gpioMaxLines = 64 // GPIO_V2_LINES_MAX
+	gpioConsumer = "picoclaw"
+)
+
+// gpioChipInfo matches the kernel struct gpiochip_info.
+type gpioChipInfo struct {
+	name  [32]byte
+	label [32]byte
+	lines uint32
+}
+
+// gpioLineAttribute matches the kernel struct gpio_v2_line_attribute; the
+// union of flags, values and debounce period is held in value.
+type gpioLineAttribute struct {
+	id      uint32
+	padding uint32
+	value   uint64
+}
+
+// gpioLineConfigAttribute matches the kernel struct gpio_v2_line_config_attribute.
+type gpioLineConfigAttribute struct {
+	attr gpioLineAttribute
+	mask uint64
+}
+
+// gpioLineConfig matches the kernel struct gpio_v2_line_config.
+type gpioLineConfig struct {
+	flags    uint64
+	numAttrs uint32
+	padding  [5]uint32
+	attrs    [10]gpioLineConfigAttribute
+}
+
+// gpioLineRequest matches the kernel struct gpio_v2_line_request.
+type gpioLineRequest struct {
+	offsets         [gpioMaxLines]uint32
+	consumer        [32]byte
+	config          gpioLineConfig
+	numLines        uint32
+	eventBufferSize uint32
+	padding         [5]uint32
+	fd              int32
+}
+
+// gpioLineValues matches the kernel struct gpio_v2_line_values.
+type gpioLineValues struct {
+	bits uint64
+	mask uint64
+}
+
+// gpioLineInfo matches the kernel struct gpio_v2_line_info.
+type gpioLineInfo struct {
+	name     [32]byte
+	consumer [32]byte
+	offset   uint32
+	numAttrs uint32
+	flags    uint64
+	attrs    [10]gpioLineAttribute
+	padding  [4]uint32
+}
+
+// gpioLineEvent matches the kernel struct gpio_v2_line_event.
+type gpioLineEvent struct {
+	timestampNs uint64
+	id          uint32
+	offset      uint32
+	seqno       uint32
+	lineSeqno   uint32
+	padding     [6]uint32
+}
+
+// gpioLines is a set of claimed lines. The kernel line request fd
+// implements it; tests use a fake.
+type gpioLines interface {
+	getValues(values *gpioLineValues) error
+	setValues(values *gpioLineValues) error
+	// waitEvent reports whether an edge event is ready within timeout.
+	waitEvent(timeout time.Duration) (bool, error)
+	readEvent(event *gpioLineEvent) error
+	close() error
+}
+
+// gpioRequester claims lines on a chip with config.
+type gpioRequester func(chip string, lines []uint32, config gpioLineConfig) (gpioLines, *ToolResult)
+
+// gpioLineFD is a line request fd returned by GPIO_V2_GET_LINE_IOCTL.
+type gpioLineFD int
+
+func (fd gpioLineFD) getValues(values *gpioLineValues) error {
+	return gpioIoctl(int(fd), gpioV2LineGetValues, unsafe.Pointer(values))
+}
+
+func (fd gpioLineFD) setValues(values *gpioLineValues) error {
+	return gpioIoctl(int(fd), gpioV2LineSetValues, unsafe.Pointer(values))
+}
+
+func (fd gpioLineFD) waitEvent(timeout time.Duration) (bool, error) {
+	fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+	n, err := unix.Poll(fds, int(timeout.Milliseconds())+1)
+	if err == unix.EINTR {
+		return false, nil
+	}
+	return n > 0, err
+}
+
+func (fd gpioLineFD) readEvent(event *gpioLineEvent) error {
+	buf := unsafe.Slice((*byte)(unsafe.Pointer(event)), unsafe.Sizeof(*event))
+	_, err := unix.Read(int(fd), buf)
+	return err
+}
+
+func (fd gpioLineFD) close() error {
+	return unix.Close(int(fd))
+}
+
+func gpioIoctl(fd int, req uintptr, arg unsafe.Pointer) error {
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), req, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+func openGPIOChip(path string) (int, *ToolResult) {
+	fd, err := unix.Open(path, unix.O_RDWR|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return -1, ErrorResult(fmt.Sprintf("failed to open %s: %v (check permissions and that the chip exists)", path, err))
+	}
+	return fd, nil
+}
+
+// list reports each chip with the name, consumer and configuration of its lines.
+func (t *GPIOTool) list(args map[string]any) *ToolResult {
+	paths := t.chips()
+	if chip, ok := args["chip"].(string); ok && chip != "" {
+		if !isValidBusID(chip) {
+			return ErrorResult("invalid chip identifier: must be a number (e.g. \"0\")")
+		}
+		paths = []string{filepath.Join(t.devDir, "gpiochip"+chip)}
+	}
+	if len(paths) == 0 {
+		return SilentResult(
+			"No GPIO chips found. Check that the kernel has GPIO character device support (CONFIG_GPIO_CDEV) and that pinmux routes the pins to GPIO (see hardware skill)",
+		)
+	}
+
+	type lineEntry struct {
+		Line      uint32 `json:"line"`
+		Name      string `json:"name,omitempty"`
+		Consumer  string `json:"consumer,omitempty"`
+		Direction string `json:"direction"`
+		ActiveLow bool   `json:"active_low,omitempty"`
+		Bias      string `json:"bias,omitempty"`
+		Drive     string `json:"drive,omitempty"`
+		Edge      string `json:"edge,omitempty"`
+	}
+	type chipEntry struct {
+		Path  string      `json:"path"`
+		Name  string      `json:"name"`
+		Label string      `json:"label"`
+		Lines []lineEntry `json:"lines"`
+	}
+
+	chips := make([]chipEntry, 0, len(paths))
+	for _, path := range paths {
+		fd, errResult := openGPIOChip(path)
+		if errResult != nil {
+			return errResult
+		}
+		var info gpioChipInfo
+		if err := gpioIoctl(fd, gpioGetChipInfo, unsafe.Pointer(&info)); err != nil {
+			unix.Close(fd)
+			return ErrorResult(fmt.Sprintf("failed to read chip info of %s: %v", path, err))
+		}
+
+		entry := chipEntry{Path: path, Name: cString(info.name[:]), Label: cString(info.label[:])}
+		for offset := uint32(0); offset < info.lines; offset++ {
+			li := gpioLineInfo{offset: offset}
+			if err := gpioIoctl(fd, gpioV2GetLineInfo, unsafe.Pointer(&li)); err != nil {
+				unix.Close(fd)
+				return ErrorResult(fmt.Sprintf("failed to read line %d of %s: %v", offset, path, err))
+			}
+			line := lineEntry{
+				Line:      offset,
+				Name:      cString(li.name[:]),
+				Consumer:  cString(li.consumer[:]),
+				Direction: "input",
+				ActiveLow: li.flags&gpioFlagActiveLow != 0,
+			}
+			if li.flags&gpioFlagOutput != 0 {
+				line.Direction = "output"
+			}
+			switch {
+			case li.flags&gpioFlagBiasPullUp != 0:
+				line.Bias = "pull-up"
+			case li.flags&gpioFlagBiasPullDown != 0:
+				line.Bias = "pull-down"
+			case li.flags&gpioFlagBiasDisabled != 0:
+				line.Bias = "disabled"
+			}
+			switch {
+			case li.flags&gpioFlagOpenDrain != 0:
+				line.Drive = "open-drain"
+			case li.flags&gpioFlagOpenSource != 0:
+				line.Drive = "open-source"
+			}
+			switch li.flags & (gpioFlagEdgeRising | gpioFlagEdgeFalling) {
+			case gpioFlagEdgeRising:
+				line.Edge = "rising"
+			case gpioFlagEdgeFalling:
+				line.Edge = "falling"
+			case gpioFlagEdgeRising | gpioFlagEdgeFalling:
+				line.Edge = "both"
+			}
+			if li.flags&gpioFlagUsed != 0 && line.Consumer == "" {
+				line.Consumer = "(in use)"
+			}
+			entry.Lines = append(entry.Lines, line)
+		}
+		unix.Close(fd)
+		chips = append(chips, entry)
+	}
+
+	result, _ := json.MarshalIndent(chips, "", "  ")
+	return SilentResult(fmt.Sprintf("Found %d GPIO chip(s):\n%s", len(chips), string(result)))
+}
+
+// gpioInputFlags builds the request flags for reading or waiting on lines.
+func gpioInputFlags(args map[string]any) (uint64, *ToolResult) {
+	flags := uint64(gpioFlagInput)
+	if activeLow, _ := args["active_low"].(bool); activeLow {
+		flags |= gpioFlagActiveLow
+	}
+	bias, _ := args["bias"].(string)
+	switch bias {
+	case "", "as-is":
+	case "pull-up":
+		flags |= gpioFlagBiasPullUp
+	case "pull-down":
+		flags |= gpioFlagBiasPullDown
+	case "disabled":
+		flags |= gpioFlagBiasDisabled
+	default:
+		return 0, ErrorResult(fmt.Sprintf("invalid bias: %s (valid: as-is, pull-up, pull-down, disabled)", bias))
+	}
+	return flags, nil
+}
+
+// requestLines claims lines on a chip through t.request, or the kernel.
+func (t *GPIOTool) requestLines(chip string, lines []uint32, config gpioLineConfig) (gpioLines, *ToolResult) {
+	if t.request != nil {
+		return t.request(chip, lines, config)
+	}
+
+	path := filepath.Join(t.devDir, "gpiochip"+chip)
+	fd, errResult := openGPIOChip(path)
+	if errResult != nil {
+		return nil, errResult
+	}
+	defer unix.Close(fd)
+
+	req := gpioLineRequest{config: config, numLines: uint32(len(lines))}
+	copy(req.offsets[:], lines)
+	copy(req.consumer[:], gpioConsumer)
+	if err := gpioIoctl(fd, gpioV2GetLine, unsafe.Pointer(&req)); err != nil {
+		if err == unix.EBUSY {
+			return nil, ErrorResult(fmt.Sprintf("lines %v on %s are in use by another consumer (see gpio list)", lines, path))
+		}
+		return nil, ErrorResult(fmt.Sprintf("failed to request lines %v on %s: %v", lines, path, err))
+	}
+	return gpioLineFD(req.fd), nil
+}
+
+// readLines reads the values of input lines.
+func (t *GPIOTool) readLines(args map[string]any) *ToolResult {
+	chip, errResult := parseGPIOChip(args)
+	if errResult != nil {
+		return errResult
+	}
+	lines, errResult := parseGPIOLines(args, gpioMaxLines)
+	if errResult != nil {
+		return errResult
+	}
+	flags, errResult := gpioInputFlags(args)
+	if errResult != nil {
+		return errResult
+	}
+
+	handle, errResult := t.requestLines(chip, lines, gpioLineConfig{flags: flags})
+	if errResult != nil {
+		return errResult
+	}
+	defer handle.close()
+
+	values := gpioLineValues{mask: 1<<uint(len(lines)) - 1}
+	if len(lines) == gpioMaxLines {
+		values.mask = ^uint64(0)
+	}
+	if err := handle.getValues(&values); err != nil {
+		return ErrorResult(fmt.Sprintf("failed to read lines %v: %v", lines, err))
+	}
+
+	type lineValue struct {
+		Line  uint32 `json:"line"`
+		Value int    `json:"value"`
+	}
+	result := make([]lineValue, len(lines))
+	for i, line := range lines {
+		result[i] = lineValue{Line: line, Value: int(values.bits >> uint(i) & 1)}
+	}
+	out, _ := json.MarshalIndent(map[string]any{
+		"chip":   chip,
+		"values": result,
+	}, "", "  ")
+	return SilentResult(string(out))
+}
+
+// writeLine drives an output line, optionally holding it before release.
+// Cancelling the turn ends the hold early.
+func (t *GPIOTool) writeLine(ctx context.Context, args map[string]any) *ToolResult {
+	confirm, _ := args["confirm"].(bool)
+	if !confirm {
+		return ErrorResult("write operations require confirm: true. Please confirm with the user before driving GPIO lines, as driving a pin wired as an input or to another output can damage hardware.")
+	}
+
+	chip, errResult := parseGPIOChip(args)
+	if errResult != nil {
+		return errResult
+	}
+	lines, errResult := parseGPIOLines(args, 1)
+	if errResult != nil {
+		return errResult
+	}
+	value, ok := args["value"].(float64)
+	if !ok || (value != 0 && value != 1) {
+		return ErrorResult("value is required and must be 0 or 1")
+	}
+	holdMs, errResult := parseBoundedInt(args, "hold_ms", 0, 0, 60000)
+	if errResult != nil {
+		return errResult
+	}
+
+	config := gpioLineConfig{flags: gpioFlagOutput, numAttrs: 1}
+	if activeLow, _ := args["active_low"].(bool); activeLow {
+		config.flags |= gpioFlagActiveLow
+	}
+	config.attrs[0] = gpioLineConfigAttribute{
+		attr: gpioLineAttribute{id: gpioAttrOutputValues, value: uint64(value)},
+		mask: 1,
+	}
+
+	handle, errResult := t.requestLines(chip, lines, config)
+	if errResult != nil {
+		return errResult
+	}
+	defer handle.close()
+
+	// The output value is applied when the line is requested; setting it
+	// again makes sure drivers that ignore the initial value still follow.
+	values := gpioLineValues{bits: uint64(value), mask: 1}
+	if err := handle.setValues(&values); err != nil {
+		return ErrorResult(fmt.Sprintf("failed to set line %d: %v", lines[0], err))
+	}
+	if holdMs > 0 {
+		start := time.Now()
+		timer := time.NewTimer(time.Duration(holdMs) * time.Millisecond)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ErrorResult(fmt.Sprintf("hold cancelled: line %d on gpiochip%s was set to %d and released after %dms",
+				lines[0], chip, int(value), time.Since(start).Milliseconds()))
+		}
+	}
+
+	msg := fmt.Sprintf("Set line %d on gpiochip%s to %d", lines[0], chip, int(value))
+	if holdMs > 0 {
+		msg += fmt.Sprintf(" and held it for %dms", holdMs)
+	}
+	return SilentResult(msg + " before releasing it")
+}
+
+// waitEdge blocks until an edge on a line, the timeout or cancellation.
+func (t *GPIOTool) waitEdge(ctx context.Context, args map[string]any) *ToolResult {
+	chip, errResult := parseGPIOChip(args)
+	if errResult != nil {
+		return errResult
+	}
+	lines, errResult := parseGPIOLines(args, 1)
+	if errResult != nil {
+		return errResult
+	}
+	flags, errResult := gpioInputFlags(args)
+	if errResult != nil {
+		return errResult
+	}
+	timeoutMs, errResult := parseBoundedInt(args, "timeout_ms", 5000, 1, 60000)
+	if errResult != nil {
+		return errResult
+	}
+	edge, _ := args["edge"].(string)
+	switch edge {
+	case "", "both":
+		edge = "both"
+		flags |= gpioFlagEdgeRising | gpioFlagEdgeFalling
+	case "rising":
+		flags |= gpioFlagEdgeRising
+	case "falling":
+		flags |= gpioFlagEdgeFalling
+	default:
+		return ErrorResult(fmt.Sprintf("invalid edge: %s (valid: rising, falling, both)", edge))
+	}
+
+	handle, errResult := t.requestLines(chip, lines, gpioLineConfig{flags: flags})
+	if errResult != nil {
+		return errResult
+	}
+	defer handle.close()
+
+	// Poll in short slices so that cancelling the turn stops the wait.
+	deadline := time.Now().Add(time.Duration(timeoutMs) * time.Millisecond)
+	for {
+		if ctx.Err() != nil {
+			return ErrorResult("wait cancelled")
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return SilentResult(fmt.Sprintf("No %s edge on line %d of gpiochip%s within %dms", edge, lines[0], chip, timeoutMs))
+		}
+		ready, err := handle.waitEvent(min(remaining, 100*time.Millisecond))
+		if err != nil {
+			return ErrorResult(fmt.Sprintf("failed to wait for edge: %v", err))
+		}
+		if ready {
+			break
+		}
+	}
+
+	var event gpioLineEvent
+	if err := handle.readEvent(&event); err != nil {
+		return ErrorResult(fmt.Sprintf("failed to read edge event: %v", err))
+	}
+	kind := "falling"
+	if event.id == gpioEventRisingEdge {
+		kind = "rising"
+	}
+	out, _ := json.MarshalIndent(map[string]any{
+		"chip":         chip,
+		"line":         event.offset,
+		"edge":         kind,
+		"timestamp_ns": event.timestampNs,
+	}, "", "  ")
+	return SilentResult(string(out))
+}
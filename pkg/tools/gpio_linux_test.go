@@ -0,0 +1,246 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// TestGPIOStructLayout checks the uAPI structs against the sizes encoded in
+// the ioctl numbers, which the kernel validates.
+func TestGPIOStructLayout(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		size  uintptr
+		ioctl uint32
+	}{
+		{"gpiochip_info", unsafe.Sizeof(gpioChipInfo{}), gpioGetChipInfo},
+		{"gpio_v2_line_info", unsafe.Sizeof(gpioLineInfo{}), gpioV2GetLineInfo},
+		{"gpio_v2_line_request", unsafe.Sizeof(gpioLineRequest{}), gpioV2GetLine},
+		{"gpio_v2_line_values", unsafe.Sizeof(gpioLineValues{}), gpioV2LineGetValues},
+		{"gpio_v2_line_values", unsafe.Sizeof(gpioLineValues{}), gpioV2LineSetValues},
+	} {
+		if want := uintptr(tc.ioctl >> 16 & 0x3fff); tc.size != want {
+			t.Errorf("%s is %d bytes, ioctl expects %d", tc.name, tc.size, want)
+		}
+	}
+	if size := unsafe.Sizeof(gpioLineEvent{}); size != 48 {
+		t.Errorf("gpio_v2_line_event is %d bytes, want 48", size)
+	}
+	if off := unsafe.Offsetof(gpioLineRequest{}.fd); off != 588 {
+		t.Errorf("gpio_v2_line_request.fd at offset %d, want 588", off)
+	}
+}
+
+func TestGPIOTool_NoChips(t *testing.T) {
+	tool := &GPIOTool{devDir: t.TempDir()}
+	result := tool.Execute(context.Background(), map[string]any{"action": "list"})
+	if result.IsError || !strings.Contains(result.ForLLM, "No GPIO chips found") {
+		t.Errorf("expected no chips message, got: %s", result.ForLLM)
+	}
+
+	result = tool.Execute(context.Background(), map[string]any{"action": "read", "chip": "0", "lines": []any{float64(3)}})
+	if !result.IsError || !strings.Contains(result.ForLLM, "failed to open") {
+		t.Errorf("expected open error for a missing chip, got: %s", result.ForLLM)
+	}
+}
+
+func TestGPIOTool_Validation(t *testing.T) {
+	tool := &GPIOTool{devDir: t.TempDir()}
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name string
+		args map[string]any
+		want string
+	}{
+		{"write without confirm", map[string]any{"action": "write", "chip": "0", "lines": []any{float64(1)}, "value": float64(1)}, "confirm: true"},
+		{"chip path", map[string]any{"action": "read", "chip": "../0", "lines": []any{float64(1)}}, "invalid chip"},
+		{"no lines", map[string]any{"action": "read", "chip": "0"}, "lines is required"},
+		{"duplicate line", map[string]any{"action": "read", "chip": "0", "lines": []any{float64(1), float64(1)}}, "listed twice"},
+		{"negative line", map[string]any{"action": "read", "chip": "0", "lines": []any{float64(-1)}}, "invalid line"},
+		{"bad bias", map[string]any{"action": "read", "chip": "0", "lines": []any{float64(1)}, "bias": "strong"}, "invalid bias"},
+		{"bad value", map[string]any{"action": "write", "chip": "0", "lines": []any{float64(1)}, "value": float64(2), "confirm": true}, "must be 0 or 1"},
+		{"two write lines", map[string]any{"action": "write", "chip": "0", "lines": []any{float64(1), float64(2)}, "value": float64(1), "confirm": true}, "too many lines"},
+		{"bad edge", map[string]any{"action": "wait", "chip": "0", "lines": []any{float64(1)}, "edge": "up"}, "invalid edge"},
+		{"long wait", map[string]any{"action": "wait", "chip": "0", "lines": []any{float64(1)}, "timeout_ms": float64(120000)}, "timeout_ms must be"},
+	} {
+		result := tool.Execute(ctx, tc.args)
+		if !result.IsError || !strings.Contains(result.ForLLM, tc.want) {
+			t.Errorf("%s: expected error containing %q, got: %s", tc.name, tc.want, result.ForLLM)
+		}
+	}
+}
+
+// fakeGPIOLines stands in for a kernel line request.
+type fakeGPIOLines struct {
+	chip    string
+	lines   []uint32
+	config  gpioLineConfig
+	bits    uint64   // Values returned by getValues
+	written []uint64 // Values passed to setValues
+	events  chan gpioLineEvent
+	ready   *gpioLineEvent
+	closed  bool
+}
+
+func (f *fakeGPIOLines) getValues(values *gpioLineValues) error {
+	values.bits = f.bits & values.mask
+	return nil
+}
+
+func (f *fakeGPIOLines) setValues(values *gpioLineValues) error {
+	f.written = append(f.written, values.bits&values.mask)
+	return nil
+}
+
+func (f *fakeGPIOLines) waitEvent(timeout time.Duration) (bool, error) {
+	select {
+	case event := <-f.events:
+		f.ready = &event
+		return true, nil
+	case <-time.After(timeout):
+		return false, nil
+	}
+}
+
+func (f *fakeGPIOLines) readEvent(event *gpioLineEvent) error {
+	if f.ready == nil {
+		return unix.EAGAIN
+	}
+	*event, f.ready = *f.ready, nil
+	return nil
+}
+
+func (f *fakeGPIOLines) close() error {
+	f.closed = true
+	return nil
+}
+
+// newFakeGPIOTool returns a tool whose line requests are served by fake.
+func newFakeGPIOTool(t *testing.T, fake *fakeGPIOLines) *GPIOTool {
+	t.Helper()
+	return &GPIOTool{
+		devDir: t.TempDir(),
+		request: func(chip string, lines []uint32, config gpioLineConfig) (gpioLines, *ToolResult) {
+			fake.chip, fake.lines, fake.config = chip, lines, config
+			return fake, nil
+		},
+	}
+}
+
+func TestGPIOTool_Read(t *testing.T) {
+	fake := &fakeGPIOLines{bits: 0b101}
+	tool := newFakeGPIOTool(t, fake)
+
+	result := tool.Execute(context.Background(), map[string]any{
+		"action": "read", "chip": "1", "lines": []any{float64(3), float64(4), float64(5)}, "bias": "pull-up",
+	})
+	if result.IsError {
+		t.Fatalf("read failed: %s", result.ForLLM)
+	}
+	var got struct {
+		Chip   string `json:"chip"`
+		Values []struct {
+			Line  uint32 `json:"line"`
+			Value int    `json:"value"`
+		} `json:"values"`
+	}
+	if err := json.Unmarshal([]byte(result.ForLLM), &got); err != nil {
+		t.Fatalf("result is not JSON: %v\n%s", err, result.ForLLM)
+	}
+	if got.Chip != "1" || len(got.Values) != 3 ||
+		got.Values[0].Value != 1 || got.Values[1].Value != 0 || got.Values[2].Value != 1 || got.Values[2].Line != 5 {
+		t.Errorf("values = %+v, want lines 3-5 = 1, 0, 1", got)
+	}
+	if want := uint64(gpioFlagInput | gpioFlagBiasPullUp); fake.config.flags != want {
+		t.Errorf("request flags = %#x, want %#x", fake.config.flags, want)
+	}
+	if !fake.closed {
+		t.Error("line request was not released")
+	}
+}
+
+func TestGPIOTool_Write(t *testing.T) {
+	fake := &fakeGPIOLines{}
+	tool := newFakeGPIOTool(t, fake)
+
+	result := tool.Execute(context.Background(), map[string]any{
+		"action": "write", "chip": "0", "lines": []any{float64(17)}, "value": float64(1),
+		"active_low": true, "hold_ms": float64(20), "confirm": true,
+	})
+	if result.IsError {
+		t.Fatalf("write failed: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "Set line 17 on gpiochip0 to 1 and held it for 20ms") {
+		t.Errorf("result = %s", result.ForLLM)
+	}
+	if want := uint64(gpioFlagOutput | gpioFlagActiveLow); fake.config.flags != want {
+		t.Errorf("request flags = %#x, want %#x", fake.config.flags, want)
+	}
+	if attr := fake.config.attrs[0]; fake.config.numAttrs != 1 || attr.attr.id != gpioAttrOutputValues || attr.attr.value != 1 {
+		t.Errorf("output value attribute = %+v", fake.config)
+	}
+	if len(fake.written) != 1 || fake.written[0] != 1 || !fake.closed {
+		t.Errorf("written = %v, closed = %v; want [1] and released", fake.written, fake.closed)
+	}
+}
+
+func TestGPIOTool_WriteHoldCancelled(t *testing.T) {
+	fake := &fakeGPIOLines{}
+	tool := newFakeGPIOTool(t, fake)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	result := tool.Execute(ctx, map[string]any{
+		"action": "write", "chip": "0", "lines": []any{float64(17)}, "value": float64(1),
+		"hold_ms": float64(60000), "confirm": true,
+	})
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("cancelled hold took %v", elapsed)
+	}
+	if !result.IsError || !strings.Contains(result.ForLLM, "hold cancelled") {
+		t.Errorf("result = %+v, want a cancelled hold", result)
+	}
+	if !fake.closed {
+		t.Error("line was not released after the cancelled hold")
+	}
+}
+
+func TestGPIOTool_Wait(t *testing.T) {
+	fake := &fakeGPIOLines{events: make(chan gpioLineEvent, 1)}
+	tool := newFakeGPIOTool(t, fake)
+	args := map[string]any{"action": "wait", "chip": "0", "lines": []any{float64(4)}, "edge": "rising", "timeout_ms": float64(2000)}
+
+	fake.events <- gpioLineEvent{timestampNs: 42, id: gpioEventRisingEdge, offset: 4}
+	result := tool.Execute(context.Background(), args)
+	if result.IsError {
+		t.Fatalf("wait failed: %s", result.ForLLM)
+	}
+	for _, want := range []string{`"edge": "rising"`, `"line": 4`, `"timestamp_ns": 42`} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("result should contain %s, got: %s", want, result.ForLLM)
+		}
+	}
+	if want := uint64(gpioFlagInput | gpioFlagEdgeRising); fake.config.flags != want {
+		t.Errorf("request flags = %#x, want %#x", fake.config.flags, want)
+	}
+
+	args["timeout_ms"] = float64(150)
+	if result := tool.Execute(context.Background(), args); result.IsError || !strings.Contains(result.ForLLM, "No rising edge") {
+		t.Errorf("timeout result = %+v", result)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	args["timeout_ms"] = float64(60000)
+	if result := tool.Execute(ctx, args); !result.IsError || !strings.Contains(result.ForLLM, "cancelled") {
+		t.Errorf("cancelled result = %+v", result)
+	}
+}
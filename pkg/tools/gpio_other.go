@@ -0,0 +1,28 @@
+//go:build !linux
+
+package tools
+
+import "context"
+
+// list is a stub for non-Linux platforms.
+func (t *GPIOTool) list(args map[string]any) *ToolResult {
+	return ErrorResult("GPIO is only supported on Linux")
+}
+
+// readLines is a stub for non-Linux platforms.
+func (t *GPIOTool) readLines(args map[string]any) *ToolResult {
+	return ErrorResult("GPIO is only supported on Linux")
+}
+
+// gpioRequester has no implementation outside Linux.
+type gpioRequester func()
+
+// writeLine is a stub for non-Linux platforms.
+func (t *GPIOTool) writeLine(ctx context.Context, args map[string]any) *ToolResult {
+	return ErrorResult("GPIO is only supported on Linux")
+}
+
+// waitEdge is a stub for non-Linux platforms.
+func (t *GPIOTool) waitEdge(ctx context.Context, args map[string]any) *ToolResult {
+	return ErrorResult("GPIO is only supported on Linux")
+}
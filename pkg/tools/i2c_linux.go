@@ -105,9 +105,11 @@ func (t *I2CTool) scan(args map[string]any) *ToolResult {
 	}
 
 	type deviceEntry struct {
-		Address string `json:"address"`
-		Status  string `json:"status,omitempty"`
+		Address string   `json:"address"`
+		Status  string   `json:"status,omitempty"`
+		Sensors []string `json:"possible_sensors,omitempty"` // Built-in sensor profiles at this address
 	}
+	profiles := builtinSensorProfiles()
 
 	var found []deviceEntry
 	// Scan 0x08-0x77, skipping I2C reserved addresses 0x00-0x07
@@ -119,6 +121,7 @@ func (t *I2CTool) scan(args map[string]any) *ToolResult {
 				found = append(found, deviceEntry{
 					Address: fmt.Sprintf("0x%02x", addr),
 					Status:  "busy (in use by kernel driver)",
+					Sensors: sensorProfilesAt(profiles, addr),
 				})
 			}
 			continue
@@ -127,6 +130,7 @@ func (t *I2CTool) scan(args map[string]any) *ToolResult {
 		if smbusProbe(fd, addr, hasQuick) {
 			found = append(found, deviceEntry{
 				Address: fmt.Sprintf("0x%02x", addr),
+				Sensors: sensorProfilesAt(profiles, addr),
 			})
 		}
 	}
@@ -140,7 +144,14 @@ func (t *I2CTool) scan(args map[string]any) *ToolResult {
 		"devices": found,
 		"count":   len(found),
 	}, "", "  ")
-	return SilentResult(fmt.Sprintf("Scan of %s:\n%s", devPath, string(result)))
+	msg := fmt.Sprintf("Scan of %s:\n%s", devPath, string(result))
+	for _, d := range found {
+		if len(d.Sensors) > 0 {
+			msg += "\n\nSome addresses match known sensors; use sensor_read detect to identify them and sensor_read read for calibrated values."
+			break
+		}
+	}
+	return SilentResult(msg)
 }
 
 // readDevice reads bytes from an I2C device, optionally at a specific register
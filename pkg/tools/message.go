@@ -5,7 +5,8 @@ import (
 	"fmt"
 )
 
-type SendCallback func(channel, chatID, content string) error
+// SendCallback delivers a message. ctx is the context of the turn that sent it.
+type SendCallback func(ctx context.Context, channel, chatID, content string) error
 
 type MessageTool struct {
 	sendCallback   SendCallback
@@ -86,7 +87,7 @@ func (t *MessageTool) Execute(ctx context.Context, args map[string]any) *ToolRes
 		return &ToolResult{ForLLM: "Message sending not configured", IsError: true}
 	}
 
-	if err := t.sendCallback(channel, chatID, content); err != nil {
+	if err := t.sendCallback(ctx, channel, chatID, content); err != nil {
 		return &ToolResult{
 			ForLLM:  fmt.Sprintf("sending message: %v", err),
 			IsError: true,
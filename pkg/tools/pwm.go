@@ -0,0 +1,156 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+)
+
+// PWMTool configures PWM channels through the Linux sysfs interface
+// (/sys/class/pwm/pwmchipN).
+type PWMTool struct {
+	sysfsRoot string // /sys/class/pwm outside tests
+}
+
+func NewPWMTool() *PWMTool {
+	return &PWMTool{sysfsRoot: "/sys/class/pwm"}
+}
+
+func (t *PWMTool) Name() string {
+	return "pwm"
+}
+
+func (t *PWMTool) Description() string {
+	return "Configure PWM outputs through /sys/class/pwm. Actions: list (chips and channel state), set (export a channel and set period, duty cycle, polarity and enable), disable (stop a channel), unexport (release a channel). Linux only."
+}
+
+func (t *PWMTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"action": map[string]any{
+				"type":        "string",
+				"enum":        []string{"list", "set", "disable", "unexport"},
+				"description": "Action to perform: list (PWM chips and exported channels), set (configure and enable a channel, exporting it if needed), disable (stop a channel), unexport (release a channel)",
+			},
+			"chip": map[string]any{
+				"type":        "string",
+				"description": "PWM chip number (e.g. \"0\" for pwmchip0). Required for set/disable/unexport.",
+			},
+			"channel": map[string]any{
+				"type":        "integer",
+				"description": "Channel number on the chip (0 to npwm-1). Required for set/disable/unexport.",
+			},
+			"period_ns": map[string]any{
+				"type":        "integer",
+				"description": "Period in nanoseconds (e.g. 20000000 for a 50 Hz servo signal). Used with set; defaults to the current period.",
+			},
+			"frequency_hz": map[string]any{
+				"type":        "number",
+				"description": "Frequency in Hz, an alternative to period_ns.",
+			},
+			"duty_ns": map[string]any{
+				"type":        "integer",
+				"description": "Active time per period in nanoseconds. Used with set.",
+			},
+			"duty_percent": map[string]any{
+				"type":        "number",
+				"description": "Duty cycle in percent of the period (0-100), an alternative to duty_ns.",
+			},
+			"polarity": map[string]any{
+				"type":        "string",
+				"enum":        []string{"normal", "inversed"},
+				"description": "Signal polarity. Only changed while the channel is disabled; optional.",
+			},
+			"enable": map[string]any{
+				"type":        "boolean",
+				"description": "Whether the channel runs after set. Default: true.",
+			},
+			"confirm": map[string]any{
+				"type":        "boolean",
+				"description": "Must be true for set/disable/unexport. Safety guard to prevent accidental writes.",
+			},
+		},
+		"required": []string{"action"},
+	}
+}
+
+func (t *PWMTool) Execute(ctx context.Context, args map[string]any) *ToolResult {
+	if runtime.GOOS != "linux" {
+		return ErrorResult("PWM is only supported on Linux. This tool requires /sys/class/pwm.")
+	}
+
+	action, ok := args["action"].(string)
+	if !ok {
+		return ErrorResult("action is required")
+	}
+
+	switch action {
+	case "list":
+		return t.list()
+	case "set":
+		return t.set(args)
+	case "disable":
+		return t.disable(args)
+	case "unexport":
+		return t.unexport(args)
+	default:
+		return ErrorResult(fmt.Sprintf("unknown action: %s (valid: list, set, disable, unexport)", action))
+	}
+}
+
+// Helper functions for PWM operations (used by platform-specific implementations)
+
+// parsePWMChannel extracts and validates the chip and channel from args
+//
+//nolint:unused // Used by pwm_linux.go
+func parsePWMChannel(args map[string]any) (string, int, *ToolResult) {
+	chip, ok := args["chip"].(string)
+	if !ok || chip == "" {
+		return "", 0, ErrorResult("chip is required (e.g. \"0\" for pwmchip0)")
+	}
+	if !isValidBusID(chip) {
+		return "", 0, ErrorResult("invalid chip identifier: must be a number (e.g. \"0\")")
+	}
+	channel, ok := args["channel"].(float64)
+	if !ok {
+		return "", 0, ErrorResult("channel is required (e.g. 0)")
+	}
+	if channel < 0 || channel > 1023 || channel != float64(int(channel)) {
+		return "", 0, ErrorResult("channel must be a non-negative integer")
+	}
+	return chip, int(channel), nil
+}
+
+// pwmTiming resolves the period and duty cycle requested in args, falling
+// back to the current period. duty is -1 when args leave it unchanged.
+//
+//nolint:unused // Used by pwm_linux.go
+func pwmTiming(args map[string]any, currentPeriod int64) (period, duty int64, errResult *ToolResult) {
+	period = currentPeriod
+	if v, ok := args["period_ns"].(float64); ok {
+		period = int64(v)
+	} else if hz, ok := args["frequency_hz"].(float64); ok {
+		if hz <= 0 || hz > 1e9 {
+			return 0, 0, ErrorResult("frequency_hz must be between 0 and 1e9")
+		}
+		period = int64(1e9/hz + 0.5)
+	}
+	if period <= 0 {
+		return 0, 0, ErrorResult("period_ns or frequency_hz is required while the channel has no period")
+	}
+
+	duty = -1
+	if v, ok := args["duty_ns"].(float64); ok {
+		duty = int64(v)
+	} else if pct, ok := args["duty_percent"].(float64); ok {
+		if pct < 0 || pct > 100 {
+			return 0, 0, ErrorResult("duty_percent must be between 0 and 100")
+		}
+		duty = int64(float64(period)*pct/100 + 0.5)
+	}
+	if duty != -1 && (duty < 0 || duty > period) {
+		return 0, 0, ErrorResult(fmt.Sprintf("duty cycle %dns must be between 0 and the period %dns", duty, period))
+	}
+	return period, duty, nil
+}
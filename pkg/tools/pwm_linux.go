@@ -0,0 +1,247 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pwmExportTimeout bounds the wait for a channel directory to appear after
+// export; udev may still be fixing up its permissions.
+var pwmExportTimeout = time.Second
+
+func readPWMAttr(dir, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func readPWMInt(dir, name string) int64 {
+	n, _ := strconv.ParseInt(readPWMAttr(dir, name), 10, 64)
+	return n
+}
+
+func writePWMAttr(dir, name, value string) error {
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(value), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s=%s: %w", name, value, err)
+	}
+	return nil
+}
+
+type pwmChannelState struct {
+	Channel  int    `json:"channel"`
+	PeriodNs int64  `json:"period_ns"`
+	DutyNs   int64  `json:"duty_ns"`
+	Enabled  bool   `json:"enabled"`
+	Polarity string `json:"polarity,omitempty"`
+	Summary  string `json:"summary,omitempty"`
+}
+
+func readPWMChannel(dir string, channel int) pwmChannelState {
+	state := pwmChannelState{
+		Channel:  channel,
+		PeriodNs: readPWMInt(dir, "period"),
+		DutyNs:   readPWMInt(dir, "duty_cycle"),
+		Enabled:  readPWMAttr(dir, "enable") == "1",
+		Polarity: readPWMAttr(dir, "polarity"),
+	}
+	if state.PeriodNs > 0 {
+		state.Summary = fmt.Sprintf("%.6g Hz, %.1f%% duty", 1e9/float64(state.PeriodNs),
+			float64(state.DutyNs)*100/float64(state.PeriodNs))
+	}
+	return state
+}
+
+// list reports each PWM chip with its channel count and exported channels.
+func (t *PWMTool) list() *ToolResult {
+	matches, err := filepath.Glob(filepath.Join(t.sysfsRoot, "pwmchip*"))
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to scan for PWM chips: %v", err))
+	}
+	if len(matches) == 0 {
+		return SilentResult(
+			"No PWM chips found. Check that the PWM controller is enabled in device tree and that pinmux routes the pins to PWM (see hardware skill)",
+		)
+	}
+
+	type chipInfo struct {
+		Chip     string            `json:"chip"`
+		Channels int64             `json:"channels"`
+		Exported []pwmChannelState `json:"exported"`
+	}
+	re := regexp.MustCompile(`pwmchip(\d+)$`)
+	chanRe := regexp.MustCompile(`^pwm(\d+)$`)
+	chips := make([]chipInfo, 0, len(matches))
+	for _, m := range matches {
+		sub := re.FindStringSubmatch(m)
+		if sub == nil {
+			continue
+		}
+		info := chipInfo{Chip: sub[1], Channels: readPWMInt(m, "npwm"), Exported: []pwmChannelState{}}
+		entries, _ := os.ReadDir(m)
+		for _, e := range entries {
+			if cs := chanRe.FindStringSubmatch(e.Name()); cs != nil {
+				channel, _ := strconv.Atoi(cs[1])
+				info.Exported = append(info.Exported, readPWMChannel(filepath.Join(m, e.Name()), channel))
+			}
+		}
+		chips = append(chips, info)
+	}
+
+	result, _ := json.MarshalIndent(chips, "", "  ")
+	return SilentResult(fmt.Sprintf("Found %d PWM chip(s):\n%s", len(chips), string(result)))
+}
+
+// channelDir validates chip and channel and returns their sysfs directories.
+func (t *PWMTool) channelDir(args map[string]any) (chipDir, dir string, channel int, errResult *ToolResult) {
+	chip, channel, errResult := parsePWMChannel(args)
+	if errResult != nil {
+		return "", "", 0, errResult
+	}
+	chipDir = filepath.Join(t.sysfsRoot, "pwmchip"+chip)
+	if _, err := os.Stat(chipDir); err != nil {
+		return "", "", 0, ErrorResult(fmt.Sprintf("PWM chip %s not found (use pwm list)", chip))
+	}
+	if npwm := readPWMInt(chipDir, "npwm"); npwm > 0 && int64(channel) >= npwm {
+		return "", "", 0, ErrorResult(fmt.Sprintf("channel %d out of range: pwmchip%s has %d channel(s)", channel, chip, npwm))
+	}
+	return chipDir, filepath.Join(chipDir, fmt.Sprintf("pwm%d", channel)), channel, nil
+}
+
+// exportPWMChannel makes a channel available in sysfs, waiting for its attributes to appear.
+func exportPWMChannel(chipDir, dir string, channel int) error {
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	}
+	if err := writePWMAttr(chipDir, "export", strconv.Itoa(channel)); err != nil {
+		return err
+	}
+	deadline := time.Now().Add(pwmExportTimeout)
+	for {
+		if f, err := os.OpenFile(filepath.Join(dir, "period"), os.O_WRONLY, 0); err == nil {
+			f.Close()
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("channel %d did not appear after export", channel)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// set exports a channel if needed and applies period, duty cycle, polarity
+// and the enabled state, in the order the kernel accepts them.
+func (t *PWMTool) set(args map[string]any) *ToolResult {
+	confirm, _ := args["confirm"].(bool)
+	if !confirm {
+		return ErrorResult("write operations require confirm: true. Please confirm with the user before changing PWM outputs, as they may drive motors, servos or heaters.")
+	}
+
+	chipDir, dir, channel, errResult := t.channelDir(args)
+	if errResult != nil {
+		return errResult
+	}
+	if err := exportPWMChannel(chipDir, dir, channel); err != nil {
+		return ErrorResult(fmt.Sprintf("failed to export PWM channel %d: %v", channel, err))
+	}
+
+	current := readPWMChannel(dir, channel)
+	period, duty, errResult := pwmTiming(args, current.PeriodNs)
+	if errResult != nil {
+		return errResult
+	}
+	if duty == -1 {
+		duty = current.DutyNs
+		if duty > period {
+			return ErrorResult(fmt.Sprintf("current duty cycle %dns exceeds the new period %dns; pass duty_ns or duty_percent", duty, period))
+		}
+	}
+	enable := true
+	if v, ok := args["enable"].(bool); ok {
+		enable = v
+	}
+	polarity, _ := args["polarity"].(string)
+	if polarity != "" && polarity != "normal" && polarity != "inversed" {
+		return ErrorResult(fmt.Sprintf("invalid polarity: %s (valid: normal, inversed)", polarity))
+	}
+
+	var steps [][2]string
+	if polarity != "" && polarity != current.Polarity {
+		// Most drivers only accept a polarity change while disabled.
+		if current.Enabled {
+			steps = append(steps, [2]string{"enable", "0"})
+		}
+		steps = append(steps, [2]string{"polarity", polarity})
+	}
+	// The duty cycle may never exceed the period, so shrink whichever
+	// would otherwise be violated first.
+	if period < current.DutyNs {
+		steps = append(steps, [2]string{"duty_cycle", strconv.FormatInt(duty, 10)}, [2]string{"period", strconv.FormatInt(period, 10)})
+	} else {
+		steps = append(steps, [2]string{"period", strconv.FormatInt(period, 10)}, [2]string{"duty_cycle", strconv.FormatInt(duty, 10)})
+	}
+	if enable {
+		steps = append(steps, [2]string{"enable", "1"})
+	} else {
+		steps = append(steps, [2]string{"enable", "0"})
+	}
+
+	for _, step := range steps {
+		if step[0] != "enable" && step[0] != "polarity" && readPWMAttr(dir, step[0]) == step[1] {
+			continue
+		}
+		if err := writePWMAttr(dir, step[0], step[1]); err != nil {
+			return ErrorResult(fmt.Sprintf("failed to configure PWM channel %d: %v", channel, err))
+		}
+	}
+
+	result, _ := json.MarshalIndent(readPWMChannel(dir, channel), "", "  ")
+	return SilentResult(fmt.Sprintf("Configured %s:\n%s", filepath.Base(chipDir)+"/pwm"+strconv.Itoa(channel), string(result)))
+}
+
+// disable stops an exported channel.
+func (t *PWMTool) disable(args map[string]any) *ToolResult {
+	confirm, _ := args["confirm"].(bool)
+	if !confirm {
+		return ErrorResult("write operations require confirm: true. Please confirm with the user before changing PWM outputs, as they may drive motors, servos or heaters.")
+	}
+
+	_, dir, channel, errResult := t.channelDir(args)
+	if errResult != nil {
+		return errResult
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return ErrorResult(fmt.Sprintf("PWM channel %d is not exported", channel))
+	}
+	if err := writePWMAttr(dir, "enable", "0"); err != nil {
+		return ErrorResult(fmt.Sprintf("failed to disable PWM channel %d: %v", channel, err))
+	}
+	return SilentResult(fmt.Sprintf("Disabled PWM channel %d", channel))
+}
+
+// unexport releases a channel back to the kernel.
+func (t *PWMTool) unexport(args map[string]any) *ToolResult {
+	confirm, _ := args["confirm"].(bool)
+	if !confirm {
+		return ErrorResult("write operations require confirm: true. Please confirm with the user before changing PWM outputs, as they may drive motors, servos or heaters.")
+	}
+
+	chipDir, dir, channel, errResult := t.channelDir(args)
+	if errResult != nil {
+		return errResult
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return SilentResult(fmt.Sprintf("PWM channel %d is not exported", channel))
+	}
+	if err := writePWMAttr(chipDir, "unexport", strconv.Itoa(channel)); err != nil {
+		return ErrorResult(fmt.Sprintf("failed to unexport PWM channel %d: %v", channel, err))
+	}
+	return SilentResult(fmt.Sprintf("Unexported PWM channel %d", channel))
+}
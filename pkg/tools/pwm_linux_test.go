@@ -0,0 +1,211 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakePWMChip creates a pwmchip directory in a fake /sys/class/pwm.
+func fakePWMChip(t *testing.T, root string, npwm string) string {
+	t.Helper()
+	chip := filepath.Join(root, "pwmchip0")
+	if err := os.MkdirAll(chip, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, value := range map[string]string{"npwm": npwm, "export": "", "unexport": ""} {
+		if err := os.WriteFile(filepath.Join(chip, name), []byte(value), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return chip
+}
+
+// fakePWMChannel creates an exported channel with the given attributes.
+func fakePWMChannel(t *testing.T, dir string, attrs map[string]string) {
+	t.Helper()
+	tmp := dir + ".tmp"
+	if err := os.MkdirAll(tmp, 0o755); err != nil {
+		t.Error(err)
+		return
+	}
+	for name, value := range attrs {
+		if err := os.WriteFile(filepath.Join(tmp, name), []byte(value+"\n"), 0o644); err != nil {
+			t.Error(err)
+		}
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		t.Error(err)
+	}
+}
+
+func readAttr(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func TestPWMTool_SetExportsChannel(t *testing.T) {
+	root := t.TempDir()
+	chip := fakePWMChip(t, root, "2")
+	tool := &PWMTool{sysfsRoot: root}
+
+	// Play the kernel: create the channel once its number is written to export.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			if data, _ := os.ReadFile(filepath.Join(chip, "export")); string(data) == "1" {
+				fakePWMChannel(t, filepath.Join(chip, "pwm1"), map[string]string{
+					"period": "0", "duty_cycle": "0", "enable": "0", "polarity": "normal",
+				})
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	result := tool.Execute(context.Background(), map[string]any{
+		"action":       "set",
+		"chip":         "0",
+		"channel":      float64(1),
+		"frequency_hz": float64(1000),
+		"duty_percent": float64(25),
+		"confirm":      true,
+	})
+	<-done
+	if result.IsError {
+		t.Fatalf("set failed: %s", result.ForLLM)
+	}
+
+	dir := filepath.Join(chip, "pwm1")
+	if got := readAttr(t, dir, "period"); got != "1000000" {
+		t.Errorf("period = %s, want 1000000", got)
+	}
+	if got := readAttr(t, dir, "duty_cycle"); got != "250000" {
+		t.Errorf("duty_cycle = %s, want 250000", got)
+	}
+	if got := readAttr(t, dir, "enable"); got != "1" {
+		t.Errorf("enable = %s, want 1", got)
+	}
+	if !strings.Contains(result.ForLLM, "1000 Hz, 25.0% duty") {
+		t.Errorf("result should summarize the signal, got: %s", result.ForLLM)
+	}
+}
+
+func TestPWMTool_SetKeepsDutyWithinPeriod(t *testing.T) {
+	root := t.TempDir()
+	chip := fakePWMChip(t, root, "1")
+	dir := filepath.Join(chip, "pwm0")
+	fakePWMChannel(t, dir, map[string]string{
+		"period": "20000000", "duty_cycle": "1500000", "enable": "1", "polarity": "normal",
+	})
+	tool := &PWMTool{sysfsRoot: root}
+
+	// Shrinking the period below the current duty cycle without a new duty is refused.
+	result := tool.Execute(context.Background(), map[string]any{
+		"action": "set", "chip": "0", "channel": float64(0), "period_ns": float64(1000000), "confirm": true,
+	})
+	if !result.IsError || !strings.Contains(result.ForLLM, "exceeds the new period") {
+		t.Errorf("expected a duty cycle error, got: %s", result.ForLLM)
+	}
+
+	result = tool.Execute(context.Background(), map[string]any{
+		"action": "set", "chip": "0", "channel": float64(0), "period_ns": float64(1000000),
+		"duty_ns": float64(400000), "polarity": "inversed", "enable": false, "confirm": true,
+	})
+	if result.IsError {
+		t.Fatalf("set failed: %s", result.ForLLM)
+	}
+	for name, want := range map[string]string{
+		"period": "1000000", "duty_cycle": "400000", "polarity": "inversed", "enable": "0",
+	} {
+		if got := readAttr(t, dir, name); got != want {
+			t.Errorf("%s = %s, want %s", name, got, want)
+		}
+	}
+
+	result = tool.Execute(context.Background(), map[string]any{
+		"action": "set", "chip": "0", "channel": float64(0), "duty_ns": float64(2000000), "confirm": true,
+	})
+	if !result.IsError {
+		t.Error("a duty cycle longer than the period should be rejected")
+	}
+}
+
+func TestPWMTool_RequiresConfirm(t *testing.T) {
+	root := t.TempDir()
+	chip := fakePWMChip(t, root, "1")
+	fakePWMChannel(t, filepath.Join(chip, "pwm0"), map[string]string{"period": "1000", "duty_cycle": "0", "enable": "1"})
+	tool := &PWMTool{sysfsRoot: root}
+
+	for _, action := range []string{"set", "disable", "unexport"} {
+		result := tool.Execute(context.Background(), map[string]any{
+			"action": action, "chip": "0", "channel": float64(0), "duty_ns": float64(500),
+		})
+		if !result.IsError || !strings.Contains(result.ForLLM, "confirm: true") {
+			t.Errorf("%s without confirm should be refused, got: %s", action, result.ForLLM)
+		}
+	}
+	if got := readAttr(t, filepath.Join(chip, "pwm0"), "duty_cycle"); got != "0" {
+		t.Errorf("duty_cycle changed without confirm: %s", got)
+	}
+}
+
+func TestPWMTool_ListDisableUnexport(t *testing.T) {
+	root := t.TempDir()
+	chip := fakePWMChip(t, root, "4")
+	dir := filepath.Join(chip, "pwm2")
+	fakePWMChannel(t, dir, map[string]string{"period": "20000000", "duty_cycle": "1500000", "enable": "1", "polarity": "normal"})
+	tool := &PWMTool{sysfsRoot: root}
+	ctx := context.Background()
+
+	result := tool.Execute(ctx, map[string]any{"action": "list"})
+	if result.IsError {
+		t.Fatalf("list failed: %s", result.ForLLM)
+	}
+	for _, want := range []string{`"channels": 4`, `"channel": 2`, `"enabled": true`, "50 Hz"} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("list should contain %s, got: %s", want, result.ForLLM)
+		}
+	}
+
+	result = tool.Execute(ctx, map[string]any{"action": "disable", "chip": "0", "channel": float64(2), "confirm": true})
+	if result.IsError {
+		t.Fatalf("disable failed: %s", result.ForLLM)
+	}
+	if got := readAttr(t, dir, "enable"); got != "0" {
+		t.Errorf("enable = %s, want 0", got)
+	}
+
+	result = tool.Execute(ctx, map[string]any{"action": "unexport", "chip": "0", "channel": float64(2), "confirm": true})
+	if result.IsError {
+		t.Fatalf("unexport failed: %s", result.ForLLM)
+	}
+	if got := readAttr(t, chip, "unexport"); got != "2" {
+		t.Errorf("unexport = %s, want 2", got)
+	}
+
+	result = tool.Execute(ctx, map[string]any{"action": "disable", "chip": "0", "channel": float64(7), "confirm": true})
+	if !result.IsError || !strings.Contains(result.ForLLM, "out of range") {
+		t.Errorf("expected out of range error, got: %s", result.ForLLM)
+	}
+	result = tool.Execute(ctx, map[string]any{"action": "disable", "chip": "../0", "channel": float64(0), "confirm": true})
+	if !result.IsError {
+		t.Error("a chip that is not a number should be rejected")
+	}
+}
+
+func TestPWMTool_NoChips(t *testing.T) {
+	tool := &PWMTool{sysfsRoot: t.TempDir()}
+	result := tool.Execute(context.Background(), map[string]any{"action": "list"})
+	if result.IsError || !strings.Contains(result.ForLLM, "No PWM chips found") {
+		t.Errorf("expected no chips message, got: %s", result.ForLLM)
+	}
+}
@@ -2,13 +2,25 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"sync"
 	"time"
 
+	"github.com/sipeed/picoclaw/pkg/audit"
 	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/metrics"
+	"github.com/sipeed/picoclaw/pkg/permissions"
 	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/tracing"
+)
+
+var (
+	toolCalls = metrics.NewCounter("picoclaw_tool_calls_total",
+		"Tool invocations by tool and status (ok, error, async, denied).", "tool", "status")
+	toolDuration = metrics.NewHistogram("picoclaw_tool_duration_seconds",
+		"Tool execution time, by tool.", nil, "tool")
 )
 
 type ToolRegistry struct {
@@ -28,6 +40,13 @@ func (r *ToolRegistry) Register(tool Tool) {
 	r.tools[tool.Name()] = tool
 }
 
+// Unregister removes a tool. It is a no-op when the tool is not registered.
+func (r *ToolRegistry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.tools, name)
+}
+
 func (r *ToolRegistry) Get(name string) (Tool, bool) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -49,11 +68,31 @@ func (r *ToolRegistry) ExecuteWithContext(
 	channel, chatID string,
 	asyncCallback AsyncCallback,
 ) *ToolResult {
+	ctx, span := tracing.Start(ctx, "tool.execute", map[string]any{"tool": name})
+	defer span.End()
+
 	logger.InfoCF("tool", "Tool execution started",
-		map[string]any{
+		tracing.Fields(ctx, map[string]any{
 			"tool": name,
 			"args": args,
-		})
+		}))
+
+	// The LLM only sees permitted tools, but a model may still name others.
+	if access := permissions.AccessFrom(ctx); access != nil {
+		if err := access.CheckTool(name, args); err != nil {
+			logger.WarnCF("tool", "Tool call denied by role",
+				map[string]any{
+					"tool":     name,
+					"identity": access.Identity,
+					"role":     access.Role,
+				})
+			audit.LogTool(ctx, name, args, audit.StatusDenied, err.Error(), 0)
+			toolCalls.Inc(name, audit.StatusDenied)
+			span.SetAttr("status", audit.StatusDenied)
+			span.SetError(err)
+			return ErrorResult(err.Error()).WithError(err)
+		}
+	}
 
 	tool, ok := r.Get(name)
 	if !ok {
@@ -61,6 +100,10 @@ func (r *ToolRegistry) ExecuteWithContext(
 			map[string]any{
 				"tool": name,
 			})
+		audit.LogTool(ctx, name, args, audit.StatusError, "tool not found", 0)
+		toolCalls.Inc(name, audit.StatusError)
+		span.SetAttr("status", audit.StatusError)
+		span.SetError(errors.New("tool not found"))
 		return ErrorResult(fmt.Sprintf("tool %q not found", name)).WithError(fmt.Errorf("tool not found"))
 	}
 
@@ -85,11 +128,11 @@ func (r *ToolRegistry) ExecuteWithContext(
 	// Log based on result type
 	if result.IsError {
 		logger.ErrorCF("tool", "Tool execution failed",
-			map[string]any{
+			tracing.Fields(ctx, map[string]any{
 				"tool":     name,
 				"duration": duration.Milliseconds(),
 				"error":    result.ForLLM,
-			})
+			}))
 	} else if result.Async {
 		logger.InfoCF("tool", "Tool started (async)",
 			map[string]any{
@@ -98,12 +141,25 @@ func (r *ToolRegistry) ExecuteWithContext(
 			})
 	} else {
 		logger.InfoCF("tool", "Tool execution completed",
-			map[string]any{
+			tracing.Fields(ctx, map[string]any{
 				"tool":          name,
 				"duration_ms":   duration.Milliseconds(),
 				"result_length": len(result.ForLLM),
-			})
+			}))
+	}
+
+	status, errText := audit.StatusOK, ""
+	switch {
+	case result.IsError:
+		status, errText = audit.StatusError, result.ForLLM
+		span.SetError(errors.New(errText))
+	case result.Async:
+		status = audit.StatusAsync
 	}
+	span.SetAttr("status", status)
+	audit.LogTool(ctx, name, args, status, errText, duration)
+	toolCalls.Inc(name, status)
+	toolDuration.Observe(duration.Seconds(), name)
 
 	return result
 }
@@ -136,12 +192,21 @@ func (r *ToolRegistry) GetDefinitions() []map[string]any {
 // ToProviderDefs converts tool definitions to provider-compatible format.
 // This is the format expected by LLM provider APIs.
 func (r *ToolRegistry) ToProviderDefs() []providers.ToolDefinition {
+	return r.ToProviderDefsFiltered(nil)
+}
+
+// ToProviderDefsFiltered is like ToProviderDefs but only includes tools for
+// which allow returns true. A nil allow includes every tool.
+func (r *ToolRegistry) ToProviderDefsFiltered(allow func(name string) bool) []providers.ToolDefinition {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	sorted := r.sortedToolNames()
 	definitions := make([]providers.ToolDefinition, 0, len(sorted))
 	for _, name := range sorted {
+		if allow != nil && !allow(name) {
+			continue
+		}
 		tool := r.tools[name]
 		schema := ToolToSchema(tool)
 
@@ -6,6 +6,8 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/permissions"
 	"github.com/sipeed/picoclaw/pkg/providers"
 )
 
@@ -250,6 +252,38 @@ func TestToolRegistry_ToProviderDefs(t *testing.T) {
 	}
 }
 
+func TestToolRegistry_ToProviderDefsFiltered(t *testing.T) {
+	r := NewToolRegistry()
+	r.Register(newMockTool("alpha", "A"))
+	r.Register(newMockTool("beta", "B"))
+
+	defs := r.ToProviderDefsFiltered(func(name string) bool { return name == "beta" })
+	if len(defs) != 1 || defs[0].Function.Name != "beta" {
+		t.Errorf("expected only beta, got %+v", defs)
+	}
+}
+
+func TestToolRegistry_ExecuteWithContext_DeniedByRole(t *testing.T) {
+	r := NewToolRegistry()
+	r.Register(newMockTool("exec", "runs commands"))
+	r.Register(newMockTool("read_file", "reads files"))
+
+	policy := permissions.NewPolicy(config.PermissionsConfig{
+		Enabled:     true,
+		DefaultRole: "member",
+		Roles:       map[string]config.RoleConfig{"member": {DenyTools: []string{"exec"}}},
+	}, nil)
+	ctx := permissions.WithAccess(context.Background(), policy.Resolve("telegram", "42"))
+
+	result := r.ExecuteWithContext(ctx, "exec", nil, "telegram", "42", nil)
+	if !result.IsError || !strings.Contains(result.ForLLM, "not permitted") {
+		t.Errorf("expected exec to be denied, got %+v", result)
+	}
+	if result := r.ExecuteWithContext(ctx, "read_file", nil, "telegram", "42", nil); result.IsError {
+		t.Errorf("read_file should run, got %s", result.ForLLM)
+	}
+}
+
 func TestToolRegistry_List(t *testing.T) {
 	r := NewToolRegistry()
 	r.Register(newMockTool("x", ""))
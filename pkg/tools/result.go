@@ -27,6 +27,14 @@ type ToolResult struct {
 	// When true, the tool will complete later and notify via callback.
 	Async bool `json:"async"`
 
+	// Untrusted marks ForLLM as third-party content (web pages, registry
+	// data, downloaded skills). The agent loop wraps it in a provenance block
+	// and may restrict high-risk tools for the rest of the turn.
+	Untrusted bool `json:"untrusted,omitempty"`
+
+	// Source describes where untrusted content came from, e.g. a URL.
+	Source string `json:"source,omitempty"`
+
 	// Err is the underlying error (not JSON serialized).
 	// Used for internal error handling and logging.
 	Err error `json:"-"`
@@ -141,3 +149,15 @@ func (tr *ToolResult) WithError(err error) *ToolResult {
 	tr.Err = err
 	return tr
 }
+
+// MarkUntrusted flags the result as third-party content from source and
+// returns it for chaining.
+//
+// Example:
+//
+//	result := UserResult(page).MarkUntrusted("web_fetch: " + url)
+func (tr *ToolResult) MarkUntrusted(source string) *ToolResult {
+	tr.Untrusted = true
+	tr.Source = source
+	return tr
+}
@@ -3,6 +3,7 @@ package tools
 import (
 	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 )
 
@@ -227,3 +228,22 @@ func TestToolResultJSONStructure(t *testing.T) {
 		t.Errorf("Expected silent false, got %v", parsed["silent"])
 	}
 }
+
+func TestMarkUntrusted(t *testing.T) {
+	result := UserResult("page text").MarkUntrusted("web_fetch: https://example.com")
+
+	if !result.Untrusted {
+		t.Error("Expected Untrusted to be true")
+	}
+	if result.Source != "web_fetch: https://example.com" {
+		t.Errorf("Expected source to be set, got '%s'", result.Source)
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	if !strings.Contains(string(data), `"untrusted":true`) {
+		t.Errorf("Expected untrusted in JSON, got %s", data)
+	}
+}
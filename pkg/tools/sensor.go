@@ -0,0 +1,148 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// SensorReadTool reads calibrated values from I2C sensors described by
+// declarative profiles, so the model does not have to drive register maps
+// through the raw i2c tool. Built-in profiles can be extended or replaced
+// by YAML/JSON files in the workspace sensors directory.
+type SensorReadTool struct {
+	profileDir string
+}
+
+func NewSensorReadTool(workspace string) *SensorReadTool {
+	dir := ""
+	if workspace != "" {
+		dir = filepath.Join(workspace, "sensors")
+	}
+	return &SensorReadTool{profileDir: dir}
+}
+
+func (t *SensorReadTool) Name() string {
+	return "sensor_read"
+}
+
+func (t *SensorReadTool) Description() string {
+	return "Read calibrated values (temperature, humidity, pressure, acceleration, ...) from I2C sensors using built-in device profiles instead of raw registers. Actions: list (known sensor profiles), detect (find known sensors on a bus), read (measure a sensor). Linux only for detect/read."
+}
+
+func (t *SensorReadTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"action": map[string]any{
+				"type":        "string",
+				"enum":        []string{"list", "detect", "read"},
+				"description": "Action to perform: list (sensor profiles), detect (probe a bus for known sensors), read (take a measurement)",
+			},
+			"bus": map[string]any{
+				"type":        "string",
+				"description": "I2C bus number (e.g. \"1\" for /dev/i2c-1). Required for detect/read.",
+			},
+			"sensor": map[string]any{
+				"type":        "string",
+				"description": "Sensor profile name (e.g. \"bme280\"). Required for read.",
+			},
+			"address": map[string]any{
+				"type":        "integer",
+				"description": "7-bit address of the sensor. Default: the first profile address that answers.",
+			},
+			"confirm": map[string]any{
+				"type":        "boolean",
+				"description": "Must be true to read with a workspace profile, which writes to the device. Built-in profiles do not need it.",
+			},
+		},
+		"required": []string{"action"},
+	}
+}
+
+func (t *SensorReadTool) Execute(ctx context.Context, args map[string]any) *ToolResult {
+	action, ok := args["action"].(string)
+	if !ok {
+		return ErrorResult("action is required")
+	}
+	if action != "list" && runtime.GOOS != "linux" {
+		return ErrorResult("I2C is only supported on Linux. This tool requires /dev/i2c-* device files.")
+	}
+
+	switch action {
+	case "list":
+		return t.list()
+	case "detect":
+		return t.detect(args)
+	case "read":
+		return t.read(args)
+	default:
+		return ErrorResult(fmt.Sprintf("unknown action: %s (valid: list, detect, read)", action))
+	}
+}
+
+// list describes the available profiles and any that failed to load.
+func (t *SensorReadTool) list() *ToolResult {
+	profiles, errs := loadSensorProfiles(t.profileDir)
+
+	type profileInfo struct {
+		Name        string   `json:"name"`
+		Description string   `json:"description"`
+		Addresses   []string `json:"addresses"`
+		Outputs     []string `json:"outputs"`
+		Source      string   `json:"source"`
+	}
+	infos := make([]profileInfo, 0, len(profiles))
+	for _, p := range profiles {
+		info := profileInfo{Name: p.Name, Description: p.Description, Source: p.source}
+		for _, addr := range p.Addresses {
+			info.Addresses = append(info.Addresses, fmt.Sprintf("0x%02x", addr))
+		}
+		for _, o := range p.Outputs {
+			info.Outputs = append(info.Outputs, strings.TrimSpace(o.Name+" "+o.Unit))
+		}
+		infos = append(infos, info)
+	}
+
+	result, _ := json.MarshalIndent(infos, "", "  ")
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d sensor profile(s):\n%s", len(infos), string(result))
+	if t.profileDir != "" {
+		fmt.Fprintf(&sb, "\n\nAdd profiles as YAML or JSON files in %s.", t.profileDir)
+	}
+	for _, err := range errs {
+		fmt.Fprintf(&sb, "\nSkipped invalid profile: %v", err)
+	}
+	return SilentResult(sb.String())
+}
+
+// confirmSensorProfile returns an error result unless p is built in or the
+// call has confirm: true. Workspace profiles write their own init sequence
+// to the device, like raw i2c writes.
+//
+//nolint:unused // Used by sensor_linux.go
+func confirmSensorProfile(p *sensorProfile, args map[string]any) *ToolResult {
+	if p.source == "builtin" {
+		return nil
+	}
+	if confirm, _ := args["confirm"].(bool); confirm {
+		return nil
+	}
+	return ErrorResult(fmt.Sprintf("the %s profile comes from %s and writes to the device, so reading requires confirm: true. "+
+		"Please confirm with the user before using it, as incorrect writes can misconfigure hardware.", p.Name, p.source))
+}
+
+// findSensorProfile looks up a profile by name among profiles.
+//
+//nolint:unused // Used by sensor_linux.go
+func findSensorProfile(profiles []*sensorProfile, name string) *sensorProfile {
+	for _, p := range profiles {
+		if p.Name == strings.ToLower(strings.TrimSpace(name)) {
+			return p
+		}
+	}
+	return nil
+}
@@ -0,0 +1,543 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// sensorExpr is a compiled conversion formula of a sensor profile. The
+// language is C-like arithmetic over float64: + - * / %, comparisons,
+// && || !, bit operations (on the integer part) and c ? a : b, plus the
+// functions in exprFuncs. Register blocks are only accessible through the
+// byte functions, e.g. s16le(cal, 2).
+type sensorExpr struct {
+	src  string
+	root exprNode
+}
+
+// exprEnv holds the register blocks read from the device and the values
+// computed so far.
+type exprEnv struct {
+	vars map[string]float64
+	bufs map[string][]byte
+}
+
+type exprNode interface {
+	eval(env *exprEnv) (float64, error)
+}
+
+type (
+	numNode   float64
+	identNode string
+	unaryNode struct {
+		op string
+		x  exprNode
+	}
+	binaryNode struct {
+		op   string
+		l, r exprNode
+	}
+	condNode struct {
+		cond, then, els exprNode
+	}
+	callNode struct {
+		name string
+		fn   exprFunc
+		buf  string // Register block of byte functions
+		args []exprNode
+	}
+)
+
+// exprFunc is a function callable from formulas. Byte functions take the
+// name of a register block as their first argument.
+type exprFunc struct {
+	bytes bool
+	nargs int // Numeric arguments
+	fn    func(buf []byte, args []float64) (float64, error)
+}
+
+func byteFunc(width int, signed, littleEndian bool) exprFunc {
+	return exprFunc{bytes: true, nargs: 1, fn: func(buf []byte, args []float64) (float64, error) {
+		off := int(args[0])
+		if off < 0 || off+width > len(buf) {
+			return 0, fmt.Errorf("offset %d reads past the %d byte block", off, len(buf))
+		}
+		var v uint64
+		for i := 0; i < width; i++ {
+			b := buf[off+i]
+			if littleEndian {
+				b = buf[off+width-1-i]
+			}
+			v = v<<8 | uint64(b)
+		}
+		if signed {
+			return float64(signExtend(int64(v), width*8)), nil
+		}
+		return float64(v), nil
+	}}
+}
+
+func mathFunc(nargs int, fn func(args []float64) float64) exprFunc {
+	return exprFunc{nargs: nargs, fn: func(_ []byte, args []float64) (float64, error) {
+		v := fn(args)
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return 0, fmt.Errorf("result is not a finite number")
+		}
+		return v, nil
+	}}
+}
+
+func signExtend(v int64, bits int) int64 {
+	shift := 64 - bits
+	return v << shift >> shift
+}
+
+// crc8Sensirion is the CRC-8 used by Sensirion sensors (polynomial 0x31,
+// initial value 0xFF).
+func crc8Sensirion(data []byte) byte {
+	crc := byte(0xFF)
+	for _, b := range data {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x31
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+var exprFuncs = map[string]exprFunc{
+	"u8":    byteFunc(1, false, false),
+	"s8":    byteFunc(1, true, false),
+	"u16be": byteFunc(2, false, false),
+	"u16le": byteFunc(2, false, true),
+	"s16be": byteFunc(2, true, false),
+	"s16le": byteFunc(2, true, true),
+	"u24be": byteFunc(3, false, false),
+	"u24le": byteFunc(3, false, true),
+	"crc8": {bytes: true, nargs: 2, fn: func(buf []byte, args []float64) (float64, error) {
+		off, n := int(args[0]), int(args[1])
+		if off < 0 || n < 0 || n > len(buf)-off {
+			return 0, fmt.Errorf("range %d+%d is outside the %d byte block", off, n, len(buf))
+		}
+		return float64(crc8Sensirion(buf[off : off+n])), nil
+	}},
+	"sext": {nargs: 2, fn: func(_ []byte, args []float64) (float64, error) {
+		bits := args[1]
+		if !(bits >= 1 && bits <= 64) { // Also rejects NaN
+			return 0, fmt.Errorf("sign bit count %v is outside 1-64", bits)
+		}
+		return float64(signExtend(int64(args[0]), int(bits))), nil
+	}},
+	"abs":   mathFunc(1, func(a []float64) float64 { return math.Abs(a[0]) }),
+	"sqrt":  mathFunc(1, func(a []float64) float64 { return math.Sqrt(a[0]) }),
+	"pow":   mathFunc(2, func(a []float64) float64 { return math.Pow(a[0], a[1]) }),
+	"exp":   mathFunc(1, func(a []float64) float64 { return math.Exp(a[0]) }),
+	"ln":    mathFunc(1, func(a []float64) float64 { return math.Log(a[0]) }),
+	"log10": mathFunc(1, func(a []float64) float64 { return math.Log10(a[0]) }),
+	"floor": mathFunc(1, func(a []float64) float64 { return math.Floor(a[0]) }),
+	"round": mathFunc(1, func(a []float64) float64 { return math.Round(a[0]) }),
+	"min":   mathFunc(2, func(a []float64) float64 { return math.Min(a[0], a[1]) }),
+	"max":   mathFunc(2, func(a []float64) float64 { return math.Max(a[0], a[1]) }),
+	"clamp": mathFunc(3, func(a []float64) float64 { return math.Min(math.Max(a[0], a[1]), a[2]) }),
+}
+
+// binaryPrec is the binding power of binary operators; ?: binds loosest.
+var binaryPrec = map[string]int{
+	"||": 2, "&&": 3, "|": 4, "^": 5, "&": 6,
+	"==": 7, "!=": 7, "<": 8, "<=": 8, ">": 8, ">=": 8,
+	"<<": 9, ">>": 9, "+": 10, "-": 10, "*": 11, "/": 11, "%": 11,
+}
+
+type exprToken struct {
+	kind byte // 'n' number, 'i' identifier, 'o' operator, 0 end
+	text string
+	num  float64
+}
+
+func tokenizeExpr(src string) ([]exprToken, error) {
+	var toks []exprToken
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c) || c == '.' && i+1 < len(src) && unicode.IsDigit(rune(src[i+1])):
+			j := i
+			if strings.HasPrefix(src[i:], "0x") || strings.HasPrefix(src[i:], "0X") {
+				j += 2
+				for j < len(src) && strings.ContainsRune("0123456789abcdefABCDEF", rune(src[j])) {
+					j++
+				}
+				v, err := strconv.ParseUint(src[i+2:j], 16, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid number %q", src[i:j])
+				}
+				toks = append(toks, exprToken{kind: 'n', text: src[i:j], num: float64(v)})
+				i = j
+				continue
+			}
+			for j < len(src) && (unicode.IsDigit(rune(src[j])) || src[j] == '.' ||
+				(src[j] == 'e' || src[j] == 'E') ||
+				((src[j] == '+' || src[j] == '-') && (src[j-1] == 'e' || src[j-1] == 'E'))) {
+				j++
+			}
+			v, err := strconv.ParseFloat(src[i:j], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q", src[i:j])
+			}
+			toks = append(toks, exprToken{kind: 'n', text: src[i:j], num: v})
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(src) && (unicode.IsLetter(rune(src[j])) || unicode.IsDigit(rune(src[j])) || src[j] == '_') {
+				j++
+			}
+			toks = append(toks, exprToken{kind: 'i', text: src[i:j]})
+			i = j
+		default:
+			op := ""
+			for _, two := range []string{"<<", ">>", "<=", ">=", "==", "!=", "&&", "||"} {
+				if strings.HasPrefix(src[i:], two) {
+					op = two
+					break
+				}
+			}
+			if op == "" {
+				if !strings.ContainsRune("+-*/%&|^~!<>?:(),", c) {
+					return nil, fmt.Errorf("unexpected character %q", c)
+				}
+				op = string(c)
+			}
+			toks = append(toks, exprToken{kind: 'o', text: op})
+			i += len(op)
+		}
+	}
+	return append(toks, exprToken{}), nil
+}
+
+type exprParser struct {
+	toks []exprToken
+	pos  int
+}
+
+func (p *exprParser) peek() exprToken { return p.toks[p.pos] }
+
+func (p *exprParser) next() exprToken {
+	tok := p.toks[p.pos]
+	if tok.kind != 0 {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *exprParser) expect(op string) error {
+	if tok := p.next(); tok.kind != 'o' || tok.text != op {
+		return fmt.Errorf("expected %q, got %s", op, describeToken(tok))
+	}
+	return nil
+}
+
+func describeToken(tok exprToken) string {
+	if tok.kind == 0 {
+		return "end of formula"
+	}
+	return strconv.Quote(tok.text)
+}
+
+func (p *exprParser) parse(minPrec int) (exprNode, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		if tok.kind != 'o' {
+			return left, nil
+		}
+		if tok.text == "?" {
+			if minPrec > 1 {
+				return left, nil
+			}
+			p.next()
+			then, err := p.parse(1)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			els, err := p.parse(1)
+			if err != nil {
+				return nil, err
+			}
+			left = condNode{left, then, els}
+			continue
+		}
+		prec := binaryPrec[tok.text]
+		if prec == 0 || prec < minPrec {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parse(prec + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{tok.text, left, right}
+	}
+}
+
+func (p *exprParser) unary() (exprNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case 'n':
+		return numNode(tok.num), nil
+	case 'i':
+		if next := p.peek(); next.kind != 'o' || next.text != "(" {
+			return identNode(tok.text), nil
+		}
+		p.next()
+		return p.call(tok.text)
+	case 'o':
+		switch tok.text {
+		case "-", "+", "!", "~":
+			x, err := p.unary()
+			if err != nil {
+				return nil, err
+			}
+			return unaryNode{tok.text, x}, nil
+		case "(":
+			x, err := p.parse(1)
+			if err != nil {
+				return nil, err
+			}
+			return x, p.expect(")")
+		}
+	}
+	return nil, fmt.Errorf("unexpected %s", describeToken(tok))
+}
+
+func (p *exprParser) call(name string) (exprNode, error) {
+	fn, ok := exprFuncs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s", name)
+	}
+	node := callNode{name: name, fn: fn}
+	arity := func(err error) error {
+		n := fn.nargs
+		if fn.bytes {
+			n++
+		}
+		return fmt.Errorf("%s takes %d argument(s): %w", name, n, err)
+	}
+	if fn.bytes {
+		tok := p.next()
+		if tok.kind != 'i' {
+			return nil, fmt.Errorf("%s needs a register block as its first argument", name)
+		}
+		node.buf = tok.text
+	}
+	for i := 0; i < fn.nargs; i++ {
+		if i > 0 || fn.bytes {
+			if err := p.expect(","); err != nil {
+				return nil, arity(err)
+			}
+		}
+		arg, err := p.parse(1)
+		if err != nil {
+			return nil, err
+		}
+		node.args = append(node.args, arg)
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, arity(err)
+	}
+	return node, nil
+}
+
+// compileExpr parses src into a formula.
+func compileExpr(src string) (*sensorExpr, error) {
+	toks, err := tokenizeExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{toks: toks}
+	root, err := p.parse(1)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != 0 {
+		return nil, fmt.Errorf("unexpected %s", describeToken(tok))
+	}
+	return &sensorExpr{src: src, root: root}, nil
+}
+
+// refs calls fn for every name the formula reads; bytes tells whether the
+// name is used as a register block.
+func (e *sensorExpr) refs(fn func(name string, bytes bool)) {
+	var walk func(n exprNode)
+	walk = func(n exprNode) {
+		switch n := n.(type) {
+		case identNode:
+			fn(string(n), false)
+		case unaryNode:
+			walk(n.x)
+		case binaryNode:
+			walk(n.l)
+			walk(n.r)
+		case condNode:
+			walk(n.cond)
+			walk(n.then)
+			walk(n.els)
+		case callNode:
+			if n.fn.bytes {
+				fn(n.buf, true)
+			}
+			for _, a := range n.args {
+				walk(a)
+			}
+		}
+	}
+	walk(e.root)
+}
+
+func (e *sensorExpr) eval(env *exprEnv) (float64, error) {
+	return e.root.eval(env)
+}
+
+func (n numNode) eval(*exprEnv) (float64, error) { return float64(n), nil }
+
+func (n identNode) eval(env *exprEnv) (float64, error) {
+	if v, ok := env.vars[string(n)]; ok {
+		return v, nil
+	}
+	return 0, fmt.Errorf("unknown value %s", string(n))
+}
+
+func (n unaryNode) eval(env *exprEnv) (float64, error) {
+	x, err := n.x.eval(env)
+	if err != nil {
+		return 0, err
+	}
+	switch n.op {
+	case "-":
+		return -x, nil
+	case "!":
+		return boolFloat(x == 0), nil
+	case "~":
+		return float64(^int64(x)), nil
+	}
+	return x, nil
+}
+
+func (n binaryNode) eval(env *exprEnv) (float64, error) {
+	l, err := n.l.eval(env)
+	if err != nil {
+		return 0, err
+	}
+	// && and || only evaluate their right side when needed.
+	switch n.op {
+	case "&&":
+		if l == 0 {
+			return 0, nil
+		}
+	case "||":
+		if l != 0 {
+			return 1, nil
+		}
+	}
+	r, err := n.r.eval(env)
+	if err != nil {
+		return 0, err
+	}
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return l / r, nil
+	case "%":
+		if r == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return math.Mod(l, r), nil
+	case "&":
+		return float64(int64(l) & int64(r)), nil
+	case "|":
+		return float64(int64(l) | int64(r)), nil
+	case "^":
+		return float64(int64(l) ^ int64(r)), nil
+	case "<<":
+		return float64(int64(l) << uint(r)), nil
+	case ">>":
+		return float64(int64(l) >> uint(r)), nil
+	case "==":
+		return boolFloat(l == r), nil
+	case "!=":
+		return boolFloat(l != r), nil
+	case "<":
+		return boolFloat(l < r), nil
+	case "<=":
+		return boolFloat(l <= r), nil
+	case ">":
+		return boolFloat(l > r), nil
+	case ">=":
+		return boolFloat(l >= r), nil
+	case "&&", "||":
+		return boolFloat(r != 0), nil
+	}
+	return 0, fmt.Errorf("unknown operator %s", n.op)
+}
+
+func (n condNode) eval(env *exprEnv) (float64, error) {
+	c, err := n.cond.eval(env)
+	if err != nil {
+		return 0, err
+	}
+	if c != 0 {
+		return n.then.eval(env)
+	}
+	return n.els.eval(env)
+}
+
+func (n callNode) eval(env *exprEnv) (float64, error) {
+	args := make([]float64, len(n.args))
+	for i, a := range n.args {
+		v, err := a.eval(env)
+		if err != nil {
+			return 0, err
+		}
+		args[i] = v
+	}
+	var buf []byte
+	if n.fn.bytes {
+		var ok bool
+		if buf, ok = env.bufs[n.buf]; !ok {
+			return 0, fmt.Errorf("unknown register block %s", n.buf)
+		}
+	}
+	v, err := n.fn.fn(buf, args)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", n.name, err)
+	}
+	return v, nil
+}
+
+func boolFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCompileExpr_Eval(t *testing.T) {
+	env := &exprEnv{
+		vars: map[string]float64{"x": 10, "neg": -3},
+		bufs: map[string][]byte{"buf": {0x12, 0x34, 0xFF, 0xFE, 0x80, 0x00, 0x01}},
+	}
+	for _, tc := range []struct {
+		src  string
+		want float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 - 4 - 3", 3},
+		{"x / 4", 2.5},
+		{"-x + 1", -9},
+		{"0x10 + 1.5e1", 31},
+		{"7 % 4", 3},
+		{"1 << 4 | 3", 19},
+		{"0xF0 >> 4 & 0x3", 3},
+		{"5 ^ 1", 4},
+		{"~0", -1},
+		{"x > 5 && neg < 0", 1},
+		{"x == 10 || 1 / 0", 1},
+		{"!x", 0},
+		{"x > 5 ? 1 : 2", 1},
+		{"x < 5 ? 1 : neg < 0 ? 2 : 3", 2},
+		{"u8(buf, 0)", 0x12},
+		{"u16be(buf, 0)", 0x1234},
+		{"u16le(buf, 0)", 0x3412},
+		{"s16be(buf, 2)", -2},
+		{"s16le(buf, 2)", -257},
+		{"s8(buf, 4)", -128},
+		{"u24be(buf, 4)", 0x800001},
+		{"u24le(buf, 4)", 0x010080},
+		{"sext(0xFFF, 12)", -1},
+		{"sext(0x7FF, 12)", 2047},
+		{"clamp(120, 0, 100)", 100},
+		{"max(neg, min(x, 4))", 4},
+		{"round(2.5) + floor(1.9) + abs(neg)", 7},
+		{"pow(2, 10) + sqrt(16)", 1028},
+	} {
+		e, err := compileExpr(tc.src)
+		if err != nil {
+			t.Errorf("compileExpr(%q): %v", tc.src, err)
+			continue
+		}
+		got, err := e.eval(env)
+		if err != nil {
+			t.Errorf("eval(%q): %v", tc.src, err)
+			continue
+		}
+		if math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("eval(%q) = %v, want %v", tc.src, got, tc.want)
+		}
+	}
+}
+
+func TestCompileExpr_Errors(t *testing.T) {
+	for _, tc := range []struct {
+		src, want string
+	}{
+		{"1 +", "unexpected end of formula"},
+		{"(1 + 2", `expected ")"`},
+		{"1 2", `unexpected "2"`},
+		{"foo(1)", "unknown function foo"},
+		{"u8(1, 2)", "needs a register block"},
+		{"min(1)", "takes 2 argument(s)"},
+		{"1 $ 2", "unexpected character"},
+		{"x ? 1", `expected ":"`},
+	} {
+		_, err := compileExpr(tc.src)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("compileExpr(%q) error = %v, want %q", tc.src, err, tc.want)
+		}
+	}
+
+	env := &exprEnv{vars: map[string]float64{}, bufs: map[string][]byte{"buf": {1, 2}}}
+	for _, tc := range []struct {
+		src, want string
+	}{
+		{"1 / 0", "division by zero"},
+		{"u16be(buf, 1)", "reads past the 2 byte block"},
+		{"missing + 1", "unknown value missing"},
+		{"ln(0)", "not a finite number"},
+		{"sext(1, 65)", "outside 1-64"},
+		{"sext(1, 0)", "outside 1-64"},
+		{"crc8(buf, 3, 0)", "outside the 2 byte block"},
+		{"crc8(buf, 1, 9223372036854775807)", "outside the 2 byte block"},
+	} {
+		e, err := compileExpr(tc.src)
+		if err != nil {
+			t.Fatalf("compileExpr(%q): %v", tc.src, err)
+		}
+		if _, err := e.eval(env); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("eval(%q) error = %v, want %q", tc.src, err, tc.want)
+		}
+	}
+}
+
+func TestCRC8Sensirion(t *testing.T) {
+	// Example from the SHT3x datasheet.
+	if got := crc8Sensirion([]byte{0xBE, 0xEF}); got != 0x92 {
+		t.Errorf("crc8(0xBEEF) = 0x%02x, want 0x92", got)
+	}
+}
@@ -0,0 +1,217 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"syscall"
+	"unsafe"
+)
+
+// i2cDevConn talks to one address through an i2c-dev file descriptor.
+type i2cDevConn struct {
+	fd int
+}
+
+func (c i2cDevConn) write(data []byte) error {
+	n, err := syscall.Write(c.fd, data)
+	if err != nil {
+		return err
+	}
+	if n != len(data) {
+		return io.ErrShortWrite
+	}
+	return nil
+}
+
+func (c i2cDevConn) read(buf []byte) error {
+	n, err := syscall.Read(c.fd, buf)
+	if err != nil {
+		return err
+	}
+	if n != len(buf) {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+// setI2CAddress points fd at addr, explaining the common EBUSY case.
+func setI2CAddress(fd, addr int) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), i2cSlave, uintptr(addr))
+	if errno == syscall.EBUSY {
+		return fmt.Errorf("address 0x%02x is in use by a kernel driver, whose readings may be under /sys/bus/iio/devices: %w", addr, errno)
+	}
+	if errno != 0 {
+		return fmt.Errorf("failed to set I2C address 0x%02x: %v", addr, errno)
+	}
+	return nil
+}
+
+// detect probes the addresses of the known profiles and identifies the
+// sensors that answer.
+func (t *SensorReadTool) detect(args map[string]any) *ToolResult {
+	bus, errResult := parseI2CBus(args)
+	if errResult != nil {
+		return errResult
+	}
+	profiles, _ := loadSensorProfiles(t.profileDir)
+
+	devPath := fmt.Sprintf("/dev/i2c-%s", bus)
+	fd, err := syscall.Open(devPath, syscall.O_RDWR, 0)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to open %s: %v (check permissions and i2c-dev module)", devPath, err))
+	}
+	defer syscall.Close(fd)
+
+	var funcs uintptr
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), i2cFuncs, uintptr(unsafe.Pointer(&funcs)))
+	if errno != 0 {
+		return ErrorResult(fmt.Sprintf("failed to query I2C adapter capabilities on %s: %v", devPath, errno))
+	}
+	hasQuick := funcs&i2cFuncSmbusQuick != 0
+
+	var addrs []int
+	for _, p := range profiles {
+		for _, addr := range p.Addresses {
+			if !slices.Contains(addrs, addr) {
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+	slices.Sort(addrs)
+
+	type sensorMatch struct {
+		Sensor string `json:"sensor"`
+		Match  string `json:"match"` // identified, possible
+	}
+	type deviceEntry struct {
+		Address string        `json:"address"`
+		Sensors []sensorMatch `json:"sensors,omitempty"`
+		Note    string        `json:"note,omitempty"`
+	}
+
+	var found []deviceEntry
+	for _, addr := range addrs {
+		entry := deviceEntry{Address: fmt.Sprintf("0x%02x", addr)}
+		if err := setI2CAddress(fd, addr); err != nil {
+			if errors.Is(err, syscall.EBUSY) {
+				entry.Note = err.Error()
+				found = append(found, entry)
+			}
+			continue
+		}
+		if !smbusProbe(fd, addr, hasQuick) {
+			continue
+		}
+		conn := i2cDevConn{fd: fd}
+		var ids []string
+		for _, p := range profiles {
+			if !slices.Contains(p.Addresses, addr) {
+				continue
+			}
+			ok, id, err := p.identify(conn)
+			switch {
+			case err != nil:
+				entry.Sensors = append(entry.Sensors, sensorMatch{Sensor: p.Name, Match: "possible"})
+			case ok && p.Identify != nil:
+				entry.Sensors = append(entry.Sensors, sensorMatch{Sensor: p.Name, Match: "identified"})
+			case ok:
+				entry.Sensors = append(entry.Sensors, sensorMatch{Sensor: p.Name, Match: "possible"})
+			default:
+				ids = append(ids, fmt.Sprintf("register 0x%02x reads %s, not %s", p.Identify.Register, id, p.Name))
+			}
+		}
+		if len(entry.Sensors) == 0 {
+			entry.Note = "unknown device"
+			if len(ids) > 0 {
+				entry.Note += ": " + ids[0]
+			}
+		}
+		found = append(found, entry)
+	}
+
+	if len(found) == 0 {
+		return SilentResult(fmt.Sprintf("No known sensors found on %s. Use i2c scan to look for other devices.", devPath))
+	}
+	result, _ := json.MarshalIndent(map[string]any{
+		"bus":     devPath,
+		"devices": found,
+	}, "", "  ")
+	return SilentResult(fmt.Sprintf("Known sensors on %s:\n%s", devPath, string(result)))
+}
+
+// read takes a measurement with a sensor profile.
+func (t *SensorReadTool) read(args map[string]any) *ToolResult {
+	bus, errResult := parseI2CBus(args)
+	if errResult != nil {
+		return errResult
+	}
+	name, _ := args["sensor"].(string)
+	if name == "" {
+		return ErrorResult("sensor is required (e.g. \"bme280\"); use sensor_read detect to find sensors")
+	}
+	profiles, _ := loadSensorProfiles(t.profileDir)
+	profile := findSensorProfile(profiles, name)
+	if profile == nil {
+		return ErrorResult(fmt.Sprintf("unknown sensor %q; use sensor_read list to see the profiles", name))
+	}
+	if errResult := confirmSensorProfile(profile, args); errResult != nil {
+		return errResult
+	}
+
+	addrs := profile.Addresses
+	if _, ok := args["address"]; ok {
+		addr, errResult := parseI2CAddress(args)
+		if errResult != nil {
+			return errResult
+		}
+		addrs = []int{addr}
+	}
+
+	devPath := fmt.Sprintf("/dev/i2c-%s", bus)
+	fd, err := syscall.Open(devPath, syscall.O_RDWR, 0)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to open %s: %v (check permissions and i2c-dev module)", devPath, err))
+	}
+	defer syscall.Close(fd)
+
+	// Use the first address where the device identifies as the sensor.
+	var lastErr error
+	for _, addr := range addrs {
+		if err := setI2CAddress(fd, addr); err != nil {
+			lastErr = err
+			continue
+		}
+		conn := i2cDevConn{fd: fd}
+		ok, id, err := profile.identify(conn)
+		if err != nil {
+			lastErr = fmt.Errorf("no response at 0x%02x: %v", addr, err)
+			continue
+		}
+		if !ok {
+			lastErr = fmt.Errorf("device at 0x%02x is not a %s (register 0x%02x reads %s)", addr, profile.Name, profile.Identify.Register, id)
+			continue
+		}
+
+		values, err := profile.measure(conn)
+		if err != nil && profile.Identify == nil && len(addrs) > 1 {
+			// Without an identify register, a failed measurement is how an
+			// absent device shows; try the next address.
+			lastErr = fmt.Errorf("0x%02x: %v", addr, err)
+			continue
+		}
+		if err != nil {
+			return ErrorResult(fmt.Sprintf("failed to read %s at 0x%02x on %s: %v", profile.Name, addr, devPath, err))
+		}
+		result, _ := json.MarshalIndent(map[string]any{
+			"sensor":  profile.Name,
+			"bus":     devPath,
+			"address": fmt.Sprintf("0x%02x", addr),
+			"values":  values,
+		}, "", "  ")
+		return SilentResult(string(result))
+	}
+	return ErrorResult(fmt.Sprintf("%s not found on %s: %v", profile.Name, devPath, lastErr))
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package tools
+
+// detect is a stub for non-Linux platforms.
+func (t *SensorReadTool) detect(args map[string]any) *ToolResult {
+	return ErrorResult("I2C is only supported on Linux")
+}
+
+// read is a stub for non-Linux platforms.
+func (t *SensorReadTool) read(args map[string]any) *ToolResult {
+	return ErrorResult("I2C is only supported on Linux")
+}
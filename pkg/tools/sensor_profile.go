@@ -0,0 +1,387 @@
+package tools
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed sensors/*.yaml
+var builtinSensorFS embed.FS
+
+var sensorNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// sensorProfile describes how to read an I2C sensor: where it lives, how to
+// recognize it, what to write before a measurement, which registers to read
+// and how to turn their bytes into calibrated values.
+type sensorProfile struct {
+	Name        string           `json:"name"               yaml:"name"`
+	Description string           `json:"description"        yaml:"description"`
+	Addresses   []int            `json:"addresses"          yaml:"addresses"`
+	Identify    *sensorIdentify  `json:"identify,omitempty" yaml:"identify"`
+	Init        []sensorStep     `json:"init,omitempty"     yaml:"init"`      // Run before every measurement
+	Registers   []sensorRegister `json:"registers"          yaml:"registers"` // Read after init, in order
+	Values      []sensorFormula  `json:"values,omitempty"   yaml:"values"`    // Intermediate values, in order
+	Checks      []sensorCheck    `json:"checks,omitempty"   yaml:"checks"`
+	Outputs     []sensorOutput   `json:"outputs"            yaml:"outputs"`
+
+	source string // "builtin" or the profile file
+}
+
+// sensorIdentify reads one register whose value tells the chip apart from
+// other devices answering on the same address.
+type sensorIdentify struct {
+	Register int   `json:"register" yaml:"register"`
+	Values   []int `json:"values"   yaml:"values"`
+}
+
+// sensorStep is one step of the init sequence: a write or a delay.
+type sensorStep struct {
+	Write   []int `json:"write,omitempty"    yaml:"write"`
+	DelayMs int   `json:"delay_ms,omitempty" yaml:"delay_ms"`
+}
+
+// sensorRegister is a block of bytes read into a named buffer. Without a
+// register the bytes are read directly, as command-based sensors expect.
+type sensorRegister struct {
+	Name     string `json:"name"               yaml:"name"`
+	Register *int   `json:"register,omitempty" yaml:"register"`
+	Length   int    `json:"length"             yaml:"length"`
+}
+
+type sensorFormula struct {
+	Name string `json:"name" yaml:"name"`
+	Expr string `json:"expr" yaml:"expr"`
+
+	expr *sensorExpr
+}
+
+// sensorCheck fails the reading with Message when Expr evaluates to 0.
+type sensorCheck struct {
+	Expr    string `json:"expr"    yaml:"expr"`
+	Message string `json:"message" yaml:"message"`
+
+	expr *sensorExpr
+}
+
+type sensorOutput struct {
+	Name     string `json:"name"               yaml:"name"`
+	Unit     string `json:"unit"               yaml:"unit"`
+	Expr     string `json:"expr"               yaml:"expr"`
+	Decimals *int   `json:"decimals,omitempty" yaml:"decimals"` // Default 2
+
+	expr *sensorExpr
+}
+
+// compile validates the profile and compiles its formulas.
+func (p *sensorProfile) compile() error {
+	var errs error
+	fail := func(format string, args ...any) { errs = errors.Join(errs, fmt.Errorf(format, args...)) }
+
+	if !sensorNamePattern.MatchString(p.Name) {
+		fail("name %q must be lowercase letters, digits, _ or -", p.Name)
+	}
+	if len(p.Addresses) == 0 {
+		fail("at least one address is required")
+	}
+	for _, addr := range p.Addresses {
+		if addr < 0x03 || addr > 0x77 {
+			fail("address 0x%02x is outside the 7-bit range (0x03-0x77)", addr)
+		}
+	}
+	if p.Identify != nil {
+		if p.Identify.Register < 0 || p.Identify.Register > 0xFF || len(p.Identify.Values) == 0 {
+			fail("identify needs a register (0x00-0xFF) and the values it may hold")
+		}
+	}
+	for i, step := range p.Init {
+		switch {
+		case len(step.Write) > 0 && step.DelayMs != 0, len(step.Write) == 0 && step.DelayMs == 0:
+			fail("init step %d must either write bytes or delay", i+1)
+		case step.DelayMs < 0 || step.DelayMs > 1000:
+			fail("init step %d delays %dms (max 1000)", i+1, step.DelayMs)
+		case len(step.Write) > 32:
+			fail("init step %d writes %d bytes (max 32)", i+1, len(step.Write))
+		}
+		for _, b := range step.Write {
+			if b < 0 || b > 0xFF {
+				fail("init step %d writes %d, which is not a byte", i+1, b)
+			}
+		}
+	}
+
+	// Names resolve in order: register blocks, then values as they are defined.
+	kinds := map[string]bool{} // name -> is a register block
+	define := func(name string, block bool) {
+		if !identPattern.MatchString(name) {
+			fail("%q is not a valid name", name)
+		} else if _, dup := kinds[name]; dup {
+			fail("%s is defined twice", name)
+		} else if _, fn := exprFuncs[name]; fn {
+			fail("%s is the name of a function", name)
+		}
+		kinds[name] = block
+	}
+	compile := func(what, src string) *sensorExpr {
+		e, err := compileExpr(src)
+		if err != nil {
+			fail("%s: %v", what, err)
+			return nil
+		}
+		e.refs(func(name string, bytes bool) {
+			block, ok := kinds[name]
+			switch {
+			case !ok:
+				fail("%s: %s is not defined before use", what, name)
+			case bytes && !block:
+				fail("%s: %s is a value, not a register block", what, name)
+			case !bytes && block:
+				fail("%s: register block %s must be read with a byte function such as u8(%s, 0)", what, name, name)
+			}
+		})
+		return e
+	}
+
+	if len(p.Registers) == 0 {
+		fail("at least one register block is required")
+	}
+	for _, r := range p.Registers {
+		define(r.Name, true)
+		if r.Length < 1 || r.Length > 256 {
+			fail("register block %s has length %d (1-256)", r.Name, r.Length)
+		}
+		if r.Register != nil && (*r.Register < 0 || *r.Register > 0xFF) {
+			fail("register block %s starts at %d, which is not a register", r.Name, *r.Register)
+		}
+	}
+	for i := range p.Values {
+		v := &p.Values[i]
+		v.expr = compile("value "+v.Name, v.Expr)
+		define(v.Name, false)
+	}
+	for i := range p.Checks {
+		c := &p.Checks[i]
+		c.expr = compile(fmt.Sprintf("check %d", i+1), c.Expr)
+		if c.Message == "" {
+			c.Message = "check failed: " + c.Expr
+		}
+	}
+	if len(p.Outputs) == 0 {
+		fail("at least one output is required")
+	}
+	for i := range p.Outputs {
+		o := &p.Outputs[i]
+		o.expr = compile("output "+o.Name, o.Expr)
+		if o.Decimals != nil && (*o.Decimals < 0 || *o.Decimals > 9) {
+			fail("output %s has %d decimals (0-9)", o.Name, *o.Decimals)
+		}
+	}
+	return errs
+}
+
+var identPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// parseSensorProfile decodes and compiles a profile; name decides the format.
+func parseSensorProfile(name string, data []byte) (*sensorProfile, error) {
+	var p sensorProfile
+	var err error
+	if strings.EqualFold(filepath.Ext(name), ".json") {
+		err = json.Unmarshal(data, &p)
+	} else {
+		err = yaml.Unmarshal(data, &p)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if err := p.compile(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return &p, nil
+}
+
+var builtinSensorProfiles = sync.OnceValue(func() []*sensorProfile {
+	entries, _ := fs.ReadDir(builtinSensorFS, "sensors")
+	profiles := make([]*sensorProfile, 0, len(entries))
+	for _, e := range entries {
+		data, err := builtinSensorFS.ReadFile("sensors/" + e.Name())
+		if err != nil {
+			continue
+		}
+		p, err := parseSensorProfile(e.Name(), data)
+		if err != nil {
+			// Built-in profiles are covered by tests; skip rather than crash.
+			continue
+		}
+		p.source = "builtin"
+		profiles = append(profiles, p)
+	}
+	return profiles
+})
+
+// loadSensorProfiles returns the built-in profiles together with those in
+// dir, which replace built-ins of the same name. Broken files are reported
+// in errs and skipped.
+func loadSensorProfiles(dir string) (profiles []*sensorProfile, errs []error) {
+	byName := map[string]*sensorProfile{}
+	var order []string
+	add := func(p *sensorProfile) {
+		if _, ok := byName[p.Name]; !ok {
+			order = append(order, p.Name)
+		}
+		byName[p.Name] = p
+	}
+	for _, p := range builtinSensorProfiles() {
+		add(p)
+	}
+
+	if dir != "" {
+		entries, err := os.ReadDir(dir)
+		if err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+		}
+		for _, e := range entries {
+			ext := strings.ToLower(filepath.Ext(e.Name()))
+			if e.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+				continue
+			}
+			path := filepath.Join(dir, e.Name())
+			data, err := os.ReadFile(path)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			p, err := parseSensorProfile(path, data)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			p.source = path
+			add(p)
+		}
+	}
+
+	for _, name := range order {
+		profiles = append(profiles, byName[name])
+	}
+	return profiles, errs
+}
+
+// sensorProfilesAt returns the names of the profiles that may answer on addr.
+func sensorProfilesAt(profiles []*sensorProfile, addr int) []string {
+	var names []string
+	for _, p := range profiles {
+		if slices.Contains(p.Addresses, addr) {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+// i2cConn is an open I2C device at one address.
+type i2cConn interface {
+	write(data []byte) error
+	read(buf []byte) error
+}
+
+func readI2CRegister(conn i2cConn, register *int, length int) ([]byte, error) {
+	if register != nil {
+		if err := conn.write([]byte{byte(*register)}); err != nil {
+			return nil, fmt.Errorf("failed to select register 0x%02x: %w", *register, err)
+		}
+	}
+	buf := make([]byte, length)
+	if err := conn.read(buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// identify reports whether the device behind conn is this sensor. Profiles
+// without an identify register match any device that answers.
+func (p *sensorProfile) identify(conn i2cConn) (bool, string, error) {
+	if p.Identify == nil {
+		return true, "", nil
+	}
+	buf, err := readI2CRegister(conn, &p.Identify.Register, 1)
+	if err != nil {
+		return false, "", err
+	}
+	id := fmt.Sprintf("0x%02x", buf[0])
+	return slices.Contains(p.Identify.Values, int(buf[0])), id, nil
+}
+
+type sensorValue struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit,omitempty"`
+}
+
+// measure runs the init sequence, reads the register blocks and evaluates
+// the outputs.
+func (p *sensorProfile) measure(conn i2cConn) ([]sensorValue, error) {
+	for i, step := range p.Init {
+		if step.DelayMs > 0 {
+			time.Sleep(time.Duration(step.DelayMs) * time.Millisecond)
+			continue
+		}
+		data := make([]byte, len(step.Write))
+		for j, b := range step.Write {
+			data[j] = byte(b)
+		}
+		if err := conn.write(data); err != nil {
+			return nil, fmt.Errorf("init step %d failed: %w", i+1, err)
+		}
+	}
+
+	env := &exprEnv{vars: map[string]float64{}, bufs: map[string][]byte{}}
+	for _, r := range p.Registers {
+		buf, err := readI2CRegister(conn, r.Register, r.Length)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", r.Name, err)
+		}
+		env.bufs[r.Name] = buf
+	}
+	for _, v := range p.Values {
+		x, err := v.expr.eval(env)
+		if err != nil {
+			return nil, fmt.Errorf("value %s: %w", v.Name, err)
+		}
+		env.vars[v.Name] = x
+	}
+	for _, c := range p.Checks {
+		ok, err := c.expr.eval(env)
+		if err != nil {
+			return nil, fmt.Errorf("check %q: %w", c.Expr, err)
+		}
+		if ok == 0 {
+			return nil, errors.New(c.Message)
+		}
+	}
+
+	values := make([]sensorValue, 0, len(p.Outputs))
+	for _, o := range p.Outputs {
+		x, err := o.expr.eval(env)
+		if err != nil {
+			return nil, fmt.Errorf("output %s: %w", o.Name, err)
+		}
+		decimals := 2
+		if o.Decimals != nil {
+			decimals = *o.Decimals
+		}
+		scale := math.Pow(10, float64(decimals))
+		values = append(values, sensorValue{Name: o.Name, Value: math.Round(x*scale) / scale, Unit: o.Unit})
+	}
+	return values, nil
+}
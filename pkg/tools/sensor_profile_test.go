@@ -0,0 +1,289 @@
+package tools
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeI2CDevice is a register-mapped device: a one byte write selects a
+// register, longer writes store bytes from it, reads continue from it.
+// When response is set, reads return it instead, like a command-based sensor.
+type fakeI2CDevice struct {
+	regs     [256]byte
+	ptr      int
+	writes   [][]byte
+	response []byte
+	absent   bool
+}
+
+func (d *fakeI2CDevice) write(data []byte) error {
+	if d.absent {
+		return errors.New("remote I/O error")
+	}
+	d.writes = append(d.writes, append([]byte(nil), data...))
+	d.ptr = int(data[0])
+	for i, b := range data[1:] {
+		d.regs[(d.ptr+i)&0xFF] = b
+	}
+	return nil
+}
+
+func (d *fakeI2CDevice) read(buf []byte) error {
+	if d.absent {
+		return errors.New("remote I/O error")
+	}
+	if d.response != nil {
+		copy(buf, d.response)
+		return nil
+	}
+	for i := range buf {
+		buf[i] = d.regs[(d.ptr+i)&0xFF]
+	}
+	return nil
+}
+
+func builtinProfile(t *testing.T, name string) *sensorProfile {
+	t.Helper()
+	p := findSensorProfile(builtinSensorProfiles(), name)
+	if p == nil {
+		t.Fatalf("built-in profile %s not found", name)
+	}
+	return p
+}
+
+func valueOf(t *testing.T, values []sensorValue, name string) float64 {
+	t.Helper()
+	for _, v := range values {
+		if v.Name == name {
+			return v.Value
+		}
+	}
+	t.Fatalf("no %s in %+v", name, values)
+	return 0
+}
+
+func TestBuiltinSensorProfiles(t *testing.T) {
+	entries, err := builtinSensorFS.ReadDir("sensors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		data, _ := builtinSensorFS.ReadFile("sensors/" + e.Name())
+		if _, err := parseSensorProfile(e.Name(), data); err != nil {
+			t.Errorf("built-in profile does not compile: %v", err)
+		}
+	}
+	if got := len(builtinSensorProfiles()); got != len(entries) {
+		t.Errorf("loaded %d built-in profiles, want %d", got, len(entries))
+	}
+	if got := sensorProfilesAt(builtinSensorProfiles(), 0x76); len(got) != 1 || got[0] != "bme280" {
+		t.Errorf("sensorProfilesAt(0x76) = %v, want [bme280]", got)
+	}
+}
+
+func TestSensorProfile_BME280(t *testing.T) {
+	// Compensation example from the Bosch BMP280 datasheet, which shares the
+	// temperature and pressure formulas, plus typical humidity trimming.
+	dev := &fakeI2CDevice{}
+	dev.regs[0xD0] = 0x60
+	for i, v := range []int{27504, 26435, -1000, 36477, -10685, 3024, 2855, 140, -7, 15500, -14600, 6000} {
+		binary.LittleEndian.PutUint16(dev.regs[0x88+2*i:], uint16(v))
+	}
+	const h1, h2, h3, h4, h5, h6 = 75, 362, 0, 313, 50, 30
+	dev.regs[0xA1] = h1
+	binary.LittleEndian.PutUint16(dev.regs[0xE1:], h2)
+	dev.regs[0xE3] = h3
+	dev.regs[0xE4] = h4 >> 4
+	dev.regs[0xE5] = h4&0x0F | (h5&0x0F)<<4
+	dev.regs[0xE6] = h5 >> 4
+	dev.regs[0xE7] = h6
+	const adcP, adcT, adcH = 415148, 519888, 30000
+	dev.regs[0xF7], dev.regs[0xF8], dev.regs[0xF9] = adcP>>12, adcP>>4&0xFF, adcP<<4&0xF0
+	dev.regs[0xFA], dev.regs[0xFB], dev.regs[0xFC] = adcT>>12, adcT>>4&0xFF, adcT<<4&0xF0
+	binary.BigEndian.PutUint16(dev.regs[0xFD:], adcH)
+
+	p := builtinProfile(t, "bme280")
+	if ok, id, err := p.identify(dev); !ok || err != nil {
+		t.Fatalf("identify = %v, %s, %v", ok, id, err)
+	}
+	values, err := p.measure(dev)
+	if err != nil {
+		t.Fatalf("measure: %v", err)
+	}
+
+	if got := valueOf(t, values, "temperature"); got != 25.08 {
+		t.Errorf("temperature = %v, want 25.08", got)
+	}
+	if got := valueOf(t, values, "pressure"); math.Abs(got-1006.53) > 0.01 {
+		t.Errorf("pressure = %v hPa, want 1006.53", got)
+	}
+
+	// Humidity, straight from the datasheet formula.
+	tFine := (adcT/16384.0-27504/1024.0)*26435 + (adcT/131072.0-27504/8192.0)*(adcT/131072.0-27504/8192.0)*-1000
+	vh := tFine - 76800
+	vh = (adcH - (h4*64.0 + h5/16384.0*vh)) * (h2 / 65536.0 * (1 + h6/67108864.0*vh*(1+h3/67108864.0*vh)))
+	vh *= 1 - h1*vh/524288
+	if got := valueOf(t, values, "humidity"); math.Abs(got-vh) > 0.05 {
+		t.Errorf("humidity = %v, want %.1f", got, vh)
+	}
+
+	// Forced mode is triggered before reading.
+	if len(dev.writes) < 3 || string(dev.writes[1]) != "\xF2\x01" || string(dev.writes[2]) != "\xF4\x25" {
+		t.Errorf("unexpected init writes: %x", dev.writes)
+	}
+
+	other := &fakeI2CDevice{}
+	other.regs[0xD0] = 0x58 // BMP280
+	if ok, id, _ := p.identify(other); ok || id != "0x58" {
+		t.Errorf("identify should reject chip id 0x58, got %v %s", ok, id)
+	}
+}
+
+func TestSensorProfile_SHT31(t *testing.T) {
+	resp := []byte{0x66, 0x66, 0, 0x80, 0x00, 0}
+	resp[2] = crc8Sensirion(resp[0:2])
+	resp[5] = crc8Sensirion(resp[3:5])
+	dev := &fakeI2CDevice{response: resp}
+
+	p := builtinProfile(t, "sht31")
+	values, err := p.measure(dev)
+	if err != nil {
+		t.Fatalf("measure: %v", err)
+	}
+	if got := valueOf(t, values, "temperature"); got != 25 {
+		t.Errorf("temperature = %v, want 25", got)
+	}
+	if got := valueOf(t, values, "humidity"); got != 50 {
+		t.Errorf("humidity = %v, want 50", got)
+	}
+	if len(dev.writes) != 1 || string(dev.writes[0]) != "\x24\x00" {
+		t.Errorf("expected the single shot command, got %x", dev.writes)
+	}
+
+	resp[5] ^= 0xFF
+	if _, err := p.measure(dev); err == nil || !strings.Contains(err.Error(), "humidity CRC mismatch") {
+		t.Errorf("expected CRC error, got %v", err)
+	}
+	if _, err := p.measure(&fakeI2CDevice{absent: true}); err == nil {
+		t.Error("expected an error from an absent device")
+	}
+}
+
+func TestSensorProfile_MPU6050(t *testing.T) {
+	dev := &fakeI2CDevice{}
+	dev.regs[0x75] = 0x68
+	for i, v := range []int16{0, -8192, 16384, -521, 131, 0, -262} {
+		binary.BigEndian.PutUint16(dev.regs[0x3B+2*i:], uint16(v))
+	}
+
+	p := builtinProfile(t, "mpu6050")
+	values, err := p.measure(dev)
+	if err != nil {
+		t.Fatalf("measure: %v", err)
+	}
+	for name, want := range map[string]float64{
+		"accel_x": 0, "accel_y": -0.5, "accel_z": 1, "temperature": 35, "gyro_x": 1, "gyro_z": -2,
+	} {
+		if got := valueOf(t, values, name); got != want {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestParseSensorProfile_Validation(t *testing.T) {
+	for _, tc := range []struct {
+		name, profile, want string
+	}{
+		{"bad address", "name: x\naddresses: [0x80]\nregisters: [{name: d, length: 1}]\noutputs: [{name: v, expr: 'u8(d, 0)'}]", "outside the 7-bit range"},
+		{"undefined", "name: x\naddresses: [0x40]\nregisters: [{name: d, length: 1}]\noutputs: [{name: v, expr: 'y * 2'}]", "y is not defined before use"},
+		{"block as value", "name: x\naddresses: [0x40]\nregisters: [{name: d, length: 1}]\noutputs: [{name: v, expr: 'd + 1'}]", "must be read with a byte function"},
+		{"value as block", "name: x\naddresses: [0x40]\nregisters: [{name: d, length: 1}]\nvalues: [{name: a, expr: '1'}]\noutputs: [{name: v, expr: 'u8(a, 0)'}]", "a is a value"},
+		{"used before defined", "name: x\naddresses: [0x40]\nregisters: [{name: d, length: 1}]\nvalues: [{name: a, expr: 'b'}, {name: b, expr: '1'}]\noutputs: [{name: v, expr: 'a'}]", "b is not defined before use"},
+		{"duplicate", "name: x\naddresses: [0x40]\nregisters: [{name: d, length: 1}]\nvalues: [{name: d, expr: '1'}]\noutputs: [{name: v, expr: 'd'}]", "d is defined twice"},
+		{"bad step", "name: x\naddresses: [0x40]\ninit: [{write: [1], delay_ms: 5}]\nregisters: [{name: d, length: 1}]\noutputs: [{name: v, expr: 'u8(d, 0)'}]", "must either write bytes or delay"},
+		{"no outputs", "name: x\naddresses: [0x40]\nregisters: [{name: d, length: 1}]", "at least one output"},
+		{"syntax", "name: x\naddresses: [0x40]\nregisters: [{name: d, length: 1}]\noutputs: [{name: v, expr: 'u8(d, 0) +'}]", "output v"},
+	} {
+		_, err := parseSensorProfile("test.yaml", []byte(tc.profile))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: error = %v, want %q", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestLoadSensorProfiles_Workspace(t *testing.T) {
+	workspace := t.TempDir()
+	dir := filepath.Join(workspace, "sensors")
+	os.MkdirAll(dir, 0o755)
+	os.WriteFile(filepath.Join(dir, "aht20.json"), []byte(`{
+		"name": "aht20",
+		"description": "Aosong AHT20 temperature and humidity sensor",
+		"addresses": [56],
+		"init": [{"write": [172, 51, 0]}, {"delay_ms": 80}],
+		"registers": [{"name": "data", "length": 7}],
+		"outputs": [
+			{"name": "humidity", "unit": "%RH", "expr": "(u24be(data, 1) >> 4) * 100 / 1048576"},
+			{"name": "temperature", "unit": "°C", "expr": "(u24be(data, 3) & 0xFFFFF) * 200 / 1048576 - 50"}
+		]
+	}`), 0o644)
+	os.WriteFile(filepath.Join(dir, "sht31.yaml"), []byte(
+		"name: sht31\ndescription: custom\naddresses: [0x44]\nregisters: [{name: d, length: 2}]\noutputs: [{name: raw, expr: 'u16be(d, 0)'}]\n",
+	), 0o644)
+	os.WriteFile(filepath.Join(dir, "broken.yaml"), []byte("name: broken\naddresses: [1]\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o644)
+
+	profiles, errs := loadSensorProfiles(dir)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "broken.yaml") {
+		t.Errorf("expected one error for broken.yaml, got %v", errs)
+	}
+	aht := findSensorProfile(profiles, "AHT20")
+	if aht == nil || aht.source != filepath.Join(dir, "aht20.json") {
+		t.Fatalf("workspace profile not loaded: %+v", aht)
+	}
+	if sht := findSensorProfile(profiles, "sht31"); sht == nil || sht.Description != "custom" {
+		t.Errorf("workspace profile should replace the built-in sht31, got %+v", sht)
+	}
+	if findSensorProfile(profiles, "bme280") == nil {
+		t.Error("built-in profiles should still be loaded")
+	}
+
+	dev := &fakeI2CDevice{response: []byte{0x1C, 0x80, 0x00, 0x06, 0x00, 0x00, 0x00}}
+	values, err := aht.measure(dev)
+	if err != nil {
+		t.Fatalf("measure: %v", err)
+	}
+	if got := valueOf(t, values, "humidity"); got != 50 {
+		t.Errorf("humidity = %v, want 50", got)
+	}
+	if got := valueOf(t, values, "temperature"); got != 25 {
+		t.Errorf("temperature = %v, want 25", got)
+	}
+
+	result := NewSensorReadTool(workspace).Execute(context.Background(), map[string]any{"action": "list"})
+	for _, want := range []string{"bme280", "aht20", "Skipped invalid profile"} {
+		if result.IsError || !strings.Contains(result.ForLLM, want) {
+			t.Errorf("list should contain %s, got: %s", want, result.ForLLM)
+		}
+	}
+}
+
+func TestConfirmSensorProfile(t *testing.T) {
+	if r := confirmSensorProfile(builtinProfile(t, "bme280"), map[string]any{}); r != nil {
+		t.Errorf("built-in profile should not need confirm: %s", r.ForLLM)
+	}
+
+	custom := &sensorProfile{Name: "aht20", source: "/ws/sensors/aht20.json"}
+	r := confirmSensorProfile(custom, map[string]any{})
+	if r == nil || !r.IsError || !strings.Contains(r.ForLLM, "confirm: true") {
+		t.Errorf("workspace profile without confirm = %+v, want an error", r)
+	}
+	if r := confirmSensorProfile(custom, map[string]any{"confirm": true}); r != nil {
+		t.Errorf("confirmed workspace profile refused: %s", r.ForLLM)
+	}
+}
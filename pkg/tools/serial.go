@@ -0,0 +1,234 @@
+package tools
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// serialPortPattern matches the serial devices the tool may open: on-chip
+// UARTs, USB adapters and the stable /dev/serial links.
+var serialPortPattern = regexp.MustCompile(
+	`^/dev/(tty(S|USB|ACM|AMA|SAC|GS|XRUSB|LP|MFD|THS|MXC|PS|AS)\d+|serial/by-(id|path)/[\w.:@+-]+)$`,
+)
+
+// SerialTool talks to devices on a UART: opening a tty with the requested
+// line settings, writing to it and reading until a timeout.
+type SerialTool struct {
+	portPattern *regexp.Regexp // Devices the tool may open
+}
+
+func NewSerialTool() *SerialTool {
+	return &SerialTool{portPattern: serialPortPattern}
+}
+
+func (t *SerialTool) Name() string {
+	return "serial"
+}
+
+func (t *SerialTool) Description() string {
+	return "Talk to devices on a serial port (UART). Actions: list (available ports), read (read until timeout, byte limit or an expected string), write (send data and optionally read the reply). Linux only."
+}
+
+func (t *SerialTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"action": map[string]any{
+				"type":        "string",
+				"enum":        []string{"list", "read", "write"},
+				"description": "Action to perform: list (available serial ports), read (read incoming data), write (send data, then read the reply if read_timeout_ms or until is set)",
+			},
+			"port": map[string]any{
+				"type":        "string",
+				"description": "Serial device (e.g. \"ttyS1\", \"/dev/ttyUSB0\" or a /dev/serial/by-id link). Required for read/write.",
+			},
+			"baud": map[string]any{
+				"type":        "integer",
+				"description": "Baud rate (e.g. 9600, 115200). Default: 115200.",
+			},
+			"data_bits": map[string]any{
+				"type":        "integer",
+				"description": "Data bits (5-8). Default: 8.",
+			},
+			"parity": map[string]any{
+				"type":        "string",
+				"enum":        []string{"none", "even", "odd"},
+				"description": "Parity. Default: none.",
+			},
+			"stop_bits": map[string]any{
+				"type":        "integer",
+				"description": "Stop bits (1 or 2). Default: 1.",
+			},
+			"flow_control": map[string]any{
+				"type":        "string",
+				"enum":        []string{"none", "rtscts"},
+				"description": "Hardware flow control. Default: none.",
+			},
+			"data": map[string]any{
+				"type":        "string",
+				"description": "Text to send, e.g. \"AT\\r\\n\". Use data or bytes with write.",
+			},
+			"bytes": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "integer"},
+				"description": "Raw bytes to send (0-255 each), for binary protocols.",
+			},
+			"timeout_ms": map[string]any{
+				"type":        "integer",
+				"description": "How long read waits for data (1-60000). Default: 1000.",
+			},
+			"read_timeout_ms": map[string]any{
+				"type":        "integer",
+				"description": "How long write waits for a reply (0-60000). Default: 0 (no reply is read unless until is set, then 1000).",
+			},
+			"until": map[string]any{
+				"type":        "string",
+				"description": "Stop reading as soon as this text is received (e.g. \"OK\\r\\n\").",
+			},
+			"max_bytes": map[string]any{
+				"type":        "integer",
+				"description": "Maximum bytes to read (1-65536). Default: 4096.",
+			},
+			"confirm": map[string]any{
+				"type":        "boolean",
+				"description": "Must be true for write operations. Safety guard to prevent accidental writes.",
+			},
+		},
+		"required": []string{"action"},
+	}
+}
+
+func (t *SerialTool) Execute(ctx context.Context, args map[string]any) *ToolResult {
+	if runtime.GOOS != "linux" {
+		return ErrorResult("Serial ports are only supported on Linux. This tool requires /dev/tty* device files.")
+	}
+
+	action, ok := args["action"].(string)
+	if !ok {
+		return ErrorResult("action is required")
+	}
+
+	switch action {
+	case "list":
+		return t.list()
+	case "read":
+		return t.read(ctx, args)
+	case "write":
+		return t.write(ctx, args)
+	default:
+		return ErrorResult(fmt.Sprintf("unknown action: %s (valid: list, read, write)", action))
+	}
+}
+
+// Helper functions for serial operations (used by platform-specific implementations)
+
+// serialConfig holds the line settings of a port.
+//
+//nolint:unused // Used by serial_linux.go
+type serialConfig struct {
+	baud     int
+	dataBits int
+	parity   string
+	stopBits int
+	rtscts   bool
+}
+
+// parseSerialPort resolves and validates the port in args
+//
+//nolint:unused // Used by serial_linux.go
+func (t *SerialTool) parseSerialPort(args map[string]any) (string, *ToolResult) {
+	port, ok := args["port"].(string)
+	if !ok || port == "" {
+		return "", ErrorResult("port is required (e.g. \"ttyS1\" or \"/dev/ttyUSB0\")")
+	}
+	if !strings.HasPrefix(port, "/") {
+		port = "/dev/" + port
+	}
+	if strings.Contains(port, "..") || !t.portPattern.MatchString(port) {
+		return "", ErrorResult(fmt.Sprintf("invalid serial port %q: must be a tty device such as /dev/ttyS1, /dev/ttyUSB0 or /dev/serial/by-id/... (use serial list)", port))
+	}
+	return port, nil
+}
+
+// parseSerialConfig extracts and validates the line settings from args
+//
+//nolint:unused // Used by serial_linux.go
+func parseSerialConfig(args map[string]any) (serialConfig, *ToolResult) {
+	cfg := serialConfig{baud: 115200, dataBits: 8, parity: "none", stopBits: 1}
+	if v, ok := args["baud"].(float64); ok {
+		cfg.baud = int(v)
+	}
+	if v, ok := args["data_bits"].(float64); ok {
+		cfg.dataBits = int(v)
+		if cfg.dataBits < 5 || cfg.dataBits > 8 {
+			return cfg, ErrorResult("data_bits must be between 5 and 8")
+		}
+	}
+	if v, ok := args["parity"].(string); ok && v != "" {
+		if v != "none" && v != "even" && v != "odd" {
+			return cfg, ErrorResult(fmt.Sprintf("invalid parity: %s (valid: none, even, odd)", v))
+		}
+		cfg.parity = v
+	}
+	if v, ok := args["stop_bits"].(float64); ok {
+		cfg.stopBits = int(v)
+		if cfg.stopBits != 1 && cfg.stopBits != 2 {
+			return cfg, ErrorResult("stop_bits must be 1 or 2")
+		}
+	}
+	if v, ok := args["flow_control"].(string); ok && v != "" {
+		if v != "none" && v != "rtscts" {
+			return cfg, ErrorResult(fmt.Sprintf("invalid flow_control: %s (valid: none, rtscts)", v))
+		}
+		cfg.rtscts = v == "rtscts"
+	}
+	return cfg, nil
+}
+
+// parseSerialData extracts the payload of a write from data or bytes
+//
+//nolint:unused // Used by serial_linux.go
+func parseSerialData(args map[string]any) ([]byte, *ToolResult) {
+	if text, ok := args["data"].(string); ok && text != "" {
+		return []byte(text), nil
+	}
+	raw, ok := args["bytes"].([]any)
+	if !ok || len(raw) == 0 {
+		return nil, ErrorResult("data or bytes is required for write")
+	}
+	if len(raw) > 65536 {
+		return nil, ErrorResult("too many bytes: maximum 65536 per write")
+	}
+	buf := make([]byte, len(raw))
+	for i, v := range raw {
+		f, ok := v.(float64)
+		if !ok || f < 0 || f > 255 || f != float64(int(f)) {
+			return nil, ErrorResult(fmt.Sprintf("invalid byte at index %d: must be 0-255", i))
+		}
+		buf[i] = byte(f)
+	}
+	return buf, nil
+}
+
+// formatSerialData describes received bytes as text when they are
+// printable, and always as hex.
+//
+//nolint:unused // Used by serial_linux.go
+func formatSerialData(data []byte) map[string]any {
+	out := map[string]any{
+		"length": len(data),
+		"hex":    hex.EncodeToString(data),
+	}
+	if utf8.Valid(data) && strings.IndexFunc(string(data), func(r rune) bool {
+		return !unicode.IsPrint(r) && !unicode.IsSpace(r)
+	}) < 0 {
+		out["text"] = string(data)
+	}
+	return out
+}
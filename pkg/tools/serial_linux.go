@@ -0,0 +1,325 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// serialBaudRates maps the supported baud rates to their termios constants.
+var serialBaudRates = map[int]uint32{
+	1200:    unix.B1200,
+	2400:    unix.B2400,
+	4800:    unix.B4800,
+	9600:    unix.B9600,
+	19200:   unix.B19200,
+	38400:   unix.B38400,
+	57600:   unix.B57600,
+	115200:  unix.B115200,
+	230400:  unix.B230400,
+	460800:  unix.B460800,
+	500000:  unix.B500000,
+	576000:  unix.B576000,
+	921600:  unix.B921600,
+	1000000: unix.B1000000,
+	1500000: unix.B1500000,
+	2000000: unix.B2000000,
+	3000000: unix.B3000000,
+}
+
+// serialWriteTimeout bounds how long a write waits for the port to drain.
+var serialWriteTimeout = 5 * time.Second
+
+var serialDataBits = map[int]uint32{5: unix.CS5, 6: unix.CS6, 7: unix.CS7, 8: unix.CS8}
+
+// openSerial opens a port in raw mode with the given line settings and
+// discards any input that arrived before it was opened.
+func openSerial(port string, cfg serialConfig) (int, *ToolResult) {
+	speed, ok := serialBaudRates[cfg.baud]
+	if !ok {
+		return -1, ErrorResult(fmt.Sprintf("unsupported baud rate %d (e.g. 9600, 115200, 921600)", cfg.baud))
+	}
+
+	// O_NONBLOCK keeps open from waiting for carrier detect on modem lines,
+	// and writes from blocking forever when flow control holds them back.
+	fd, err := unix.Open(port, unix.O_RDWR|unix.O_NOCTTY|unix.O_NONBLOCK|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return -1, ErrorResult(fmt.Sprintf("failed to open %s: %v (check permissions, e.g. the dialout group)", port, err))
+	}
+	fail := func(what string, err error) (int, *ToolResult) {
+		unix.Close(fd)
+		return -1, ErrorResult(fmt.Sprintf("failed to %s on %s: %v", what, port, err))
+	}
+
+	tio, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		return fail("read line settings", err)
+	}
+	tio.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR |
+		unix.ICRNL | unix.IXON | unix.IXOFF | unix.IXANY | unix.INPCK
+	tio.Oflag &^= unix.OPOST
+	tio.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	tio.Cflag &^= unix.CBAUD | unix.CSIZE | unix.PARENB | unix.PARODD | unix.CSTOPB | unix.CRTSCTS
+	tio.Cflag |= unix.CREAD | unix.CLOCAL | speed | serialDataBits[cfg.dataBits]
+	tio.Ispeed, tio.Ospeed = speed, speed
+	switch cfg.parity {
+	case "even":
+		tio.Cflag |= unix.PARENB
+		tio.Iflag |= unix.INPCK
+	case "odd":
+		tio.Cflag |= unix.PARENB | unix.PARODD
+		tio.Iflag |= unix.INPCK
+	}
+	if cfg.stopBits == 2 {
+		tio.Cflag |= unix.CSTOPB
+	}
+	if cfg.rtscts {
+		tio.Cflag |= unix.CRTSCTS
+	}
+	// Reads return whatever is buffered; timeouts are handled with poll.
+	tio.Cc[unix.VMIN] = 0
+	tio.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, unix.TCSETS, tio); err != nil {
+		return fail("apply line settings", err)
+	}
+
+	if err := unix.IoctlSetInt(fd, unix.TCFLSH, unix.TCIFLUSH); err != nil {
+		return fail("flush input", err)
+	}
+	return fd, nil
+}
+
+// readSerial reads until the timeout, max bytes or until is received.
+func readSerial(ctx context.Context, fd int, timeout time.Duration, maxBytes int, until []byte) ([]byte, error) {
+	var data []byte
+	buf := make([]byte, 1024)
+	deadline := time.Now().Add(timeout)
+	for len(data) < maxBytes {
+		if len(until) > 0 && bytes.Contains(data, until) {
+			break
+		}
+		if ctx.Err() != nil {
+			return data, ctx.Err()
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			break
+		}
+		// Poll in short slices so that cancelling the turn stops the read.
+		slice := min(remaining, 100*time.Millisecond)
+		fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+		n, err := unix.Poll(fds, int(slice.Milliseconds())+1)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil {
+			return data, err
+		}
+		if n == 0 {
+			continue
+		}
+		if fds[0].Revents&(unix.POLLHUP|unix.POLLERR) != 0 && fds[0].Revents&unix.POLLIN == 0 {
+			return data, fmt.Errorf("port closed")
+		}
+		got, err := unix.Read(fd, buf[:min(len(buf), maxBytes-len(data))])
+		if err == unix.EINTR || err == unix.EAGAIN {
+			continue
+		}
+		if err != nil {
+			return data, err
+		}
+		data = append(data, buf[:got]...)
+	}
+	return data, nil
+}
+
+// writeSerial writes all of data, giving up when the port accepts nothing
+// for serialWriteTimeout, e.g. because flow control holds it back.
+func writeSerial(ctx context.Context, fd int, data []byte) (int, error) {
+	written := 0
+	for written < len(data) {
+		if ctx.Err() != nil {
+			return written, ctx.Err()
+		}
+		n, err := unix.Write(fd, data[written:])
+		if err == unix.EINTR {
+			continue
+		}
+		if err == unix.EAGAIN {
+			fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLOUT}}
+			ready, err := unix.Poll(fds, int(serialWriteTimeout.Milliseconds()))
+			if err != nil && err != unix.EINTR {
+				return written, err
+			}
+			if ready == 0 {
+				return written, fmt.Errorf("port did not accept data for %v (check flow control)", serialWriteTimeout)
+			}
+			continue
+		}
+		if err != nil {
+			return written, err
+		}
+		written += n
+	}
+	return written, nil
+}
+
+// list reports the serial ports present on the system.
+func (t *SerialTool) list() *ToolResult {
+	type portInfo struct {
+		Port   string `json:"port"`
+		Target string `json:"target,omitempty"`
+		Driver string `json:"driver,omitempty"`
+	}
+
+	var ports []portInfo
+	for _, pattern := range []string{"/dev/tty*", "/dev/serial/by-id/*", "/dev/serial/by-path/*"} {
+		matches, _ := filepath.Glob(pattern)
+		for _, m := range matches {
+			if !t.portPattern.MatchString(m) {
+				continue
+			}
+			info := portInfo{Port: m}
+			if target, err := filepath.EvalSymlinks(m); err == nil && target != m {
+				info.Target = target
+			}
+			if driver, err := os.Readlink(filepath.Join("/sys/class/tty", filepath.Base(m), "device", "driver")); err == nil {
+				info.Driver = filepath.Base(driver)
+			}
+			// The 8250 driver registers placeholder ports with no UART behind them.
+			if info.Target == "" && info.Driver == "serial8250" && !serialPortPresent(m) {
+				continue
+			}
+			ports = append(ports, info)
+		}
+	}
+
+	if len(ports) == 0 {
+		return SilentResult(
+			"No serial ports found. Check that the UART is enabled in device tree, that pinmux routes the pins to it (see hardware skill), or that the USB adapter is plugged in",
+		)
+	}
+	result, _ := json.MarshalIndent(ports, "", "  ")
+	return SilentResult(fmt.Sprintf("Found %d serial port(s):\n%s", len(ports), string(result)))
+}
+
+// serialPortPresent reports whether an 8250 port has a UART behind it;
+// TIOCGSERIAL reports type 0 (PORT_UNKNOWN) for unused placeholders.
+func serialPortPresent(port string) bool {
+	fd, err := unix.Open(port, unix.O_RDWR|unix.O_NOCTTY|unix.O_NONBLOCK|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return false
+	}
+	defer unix.Close(fd)
+	var info [128]byte // Room for struct serial_struct, whose first int is the type
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), unix.TIOCGSERIAL, uintptr(unsafe.Pointer(&info)))
+	return errno == 0 && (info[0] != 0 || info[1] != 0 || info[2] != 0 || info[3] != 0)
+}
+
+// read reads incoming data from a port.
+func (t *SerialTool) read(ctx context.Context, args map[string]any) *ToolResult {
+	port, errResult := t.parseSerialPort(args)
+	if errResult != nil {
+		return errResult
+	}
+	cfg, errResult := parseSerialConfig(args)
+	if errResult != nil {
+		return errResult
+	}
+	timeoutMs, errResult := parseBoundedInt(args, "timeout_ms", 1000, 1, 60000)
+	if errResult != nil {
+		return errResult
+	}
+	maxBytes, errResult := parseBoundedInt(args, "max_bytes", 4096, 1, 65536)
+	if errResult != nil {
+		return errResult
+	}
+	until, _ := args["until"].(string)
+
+	fd, errResult := openSerial(port, cfg)
+	if errResult != nil {
+		return errResult
+	}
+	defer unix.Close(fd)
+
+	data, err := readSerial(ctx, fd, time.Duration(timeoutMs)*time.Millisecond, maxBytes, []byte(until))
+	if err != nil && len(data) == 0 {
+		return ErrorResult(fmt.Sprintf("failed to read from %s: %v", port, err))
+	}
+	return serialResult(port, 0, data, until)
+}
+
+// write sends data to a port and optionally reads the reply.
+func (t *SerialTool) write(ctx context.Context, args map[string]any) *ToolResult {
+	confirm, _ := args["confirm"].(bool)
+	if !confirm {
+		return ErrorResult("write operations require confirm: true. Please confirm with the user before writing to serial devices, as commands can reconfigure or reflash the device on the other end.")
+	}
+
+	port, errResult := t.parseSerialPort(args)
+	if errResult != nil {
+		return errResult
+	}
+	cfg, errResult := parseSerialConfig(args)
+	if errResult != nil {
+		return errResult
+	}
+	payload, errResult := parseSerialData(args)
+	if errResult != nil {
+		return errResult
+	}
+	until, _ := args["until"].(string)
+	defaultReadMs := 0
+	if until != "" {
+		defaultReadMs = 1000
+	}
+	readMs, errResult := parseBoundedInt(args, "read_timeout_ms", defaultReadMs, 0, 60000)
+	if errResult != nil {
+		return errResult
+	}
+	maxBytes, errResult := parseBoundedInt(args, "max_bytes", 4096, 1, 65536)
+	if errResult != nil {
+		return errResult
+	}
+
+	fd, errResult := openSerial(port, cfg)
+	if errResult != nil {
+		return errResult
+	}
+	defer unix.Close(fd)
+
+	written, err := writeSerial(ctx, fd, payload)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to write to %s after %d bytes: %v", port, written, err))
+	}
+
+	var reply []byte
+	if readMs > 0 {
+		var err error
+		reply, err = readSerial(ctx, fd, time.Duration(readMs)*time.Millisecond, maxBytes, []byte(until))
+		if err != nil && len(reply) == 0 {
+			return ErrorResult(fmt.Sprintf("wrote %d bytes to %s but failed to read the reply: %v", written, port, err))
+		}
+	}
+	return serialResult(port, written, reply, until)
+}
+
+func serialResult(port string, written int, data []byte, until string) *ToolResult {
+	out := map[string]any{"port": port}
+	if written > 0 {
+		out["bytes_written"] = written
+	}
+	out["received"] = formatSerialData(data)
+	if until != "" {
+		out["until_matched"] = bytes.Contains(data, []byte(until))
+	}
+	result, _ := json.MarshalIndent(out, "", "  ")
+	return SilentResult(string(result))
+}
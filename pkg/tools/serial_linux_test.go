@@ -0,0 +1,190 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPTY opens a pseudo-terminal pair to stand in for a UART: the tool
+// opens the returned slave path, the test plays the device on master.
+func openPTY(t *testing.T) (*os.File, string) {
+	t.Helper()
+	fd, err := unix.Open("/dev/ptmx", unix.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		t.Skipf("pseudo-terminals not available: %v", err)
+	}
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		unix.Close(fd)
+		t.Skipf("failed to unlock pty: %v", err)
+	}
+	n, err := unix.IoctlGetInt(fd, unix.TIOCGPTN)
+	if err != nil {
+		unix.Close(fd)
+		t.Skipf("failed to get pty number: %v", err)
+	}
+	master := os.NewFile(uintptr(fd), "/dev/ptmx")
+	t.Cleanup(func() { master.Close() })
+	return master, fmt.Sprintf("/dev/pts/%d", n)
+}
+
+func newTestSerialTool() *SerialTool {
+	return &SerialTool{portPattern: regexp.MustCompile(`^/dev/pts/\d+$`)}
+}
+
+func TestSerialTool_ReadUntil(t *testing.T) {
+	master, port := openPTY(t)
+	tool := newTestSerialTool()
+
+	go func() {
+		// Wait for the tool to open and flush the port before talking.
+		time.Sleep(200 * time.Millisecond)
+		master.Write([]byte("booting\r\nready> "))
+	}()
+
+	result := tool.Execute(context.Background(), map[string]any{
+		"action":     "read",
+		"port":       port,
+		"baud":       float64(9600),
+		"parity":     "even",
+		"timeout_ms": float64(3000),
+		"until":      "ready>",
+	})
+	if result.IsError {
+		t.Fatalf("read failed: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, `"until_matched": true`) || !strings.Contains(result.ForLLM, `booting\r\nready`) {
+		t.Errorf("expected the prompt to be read, got: %s", result.ForLLM)
+	}
+}
+
+func TestSerialTool_ReadTimeout(t *testing.T) {
+	_, port := openPTY(t)
+	tool := newTestSerialTool()
+
+	start := time.Now()
+	result := tool.Execute(context.Background(), map[string]any{
+		"action":     "read",
+		"port":       port,
+		"timeout_ms": float64(200),
+	})
+	if result.IsError {
+		t.Fatalf("read failed: %s", result.ForLLM)
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("read took %v, want about 200ms", elapsed)
+	}
+	if !strings.Contains(result.ForLLM, `"length": 0`) {
+		t.Errorf("expected no data, got: %s", result.ForLLM)
+	}
+}
+
+func TestSerialTool_WriteReadsReply(t *testing.T) {
+	master, port := openPTY(t)
+	tool := newTestSerialTool()
+
+	got := make(chan string, 1)
+	go func() {
+		buf := make([]byte, 64)
+		n, _ := master.Read(buf)
+		got <- string(buf[:n])
+		master.Write([]byte("\r\nOK\r\n"))
+	}()
+
+	result := tool.Execute(context.Background(), map[string]any{
+		"action":  "write",
+		"port":    port,
+		"data":    "AT\r\n",
+		"until":   "OK\r\n",
+		"confirm": true,
+	})
+	if result.IsError {
+		t.Fatalf("write failed: %s", result.ForLLM)
+	}
+	if sent := <-got; sent != "AT\r\n" {
+		t.Errorf("device received %q, want %q", sent, "AT\r\n")
+	}
+	for _, want := range []string{`"bytes_written": 4`, `"until_matched": true`, `"hex": "0d0a4f4b0d0a"`} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("result should contain %s, got: %s", want, result.ForLLM)
+		}
+	}
+}
+
+func TestSerialTool_WriteBytes(t *testing.T) {
+	master, port := openPTY(t)
+	tool := newTestSerialTool()
+
+	result := tool.Execute(context.Background(), map[string]any{
+		"action":  "write",
+		"port":    port,
+		"bytes":   []any{float64(0xAA), float64(0x01), float64(0x00)},
+		"confirm": true,
+	})
+	if result.IsError {
+		t.Fatalf("write failed: %s", result.ForLLM)
+	}
+	buf := make([]byte, 8)
+	n, err := master.Read(buf)
+	if err != nil || string(buf[:n]) != "\xaa\x01\x00" {
+		t.Errorf("device received %x (%v), want aa0100", buf[:n], err)
+	}
+}
+
+func TestSerialTool_Validation(t *testing.T) {
+	tool := NewSerialTool()
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name string
+		args map[string]any
+		want string
+	}{
+		{"write without confirm", map[string]any{"action": "write", "port": "ttyS1", "data": "x"}, "confirm: true"},
+		{"not a serial port", map[string]any{"action": "read", "port": "/dev/sda"}, "invalid serial port"},
+		{"traversal", map[string]any{"action": "read", "port": "/dev/serial/by-id/../../sda"}, "invalid serial port"},
+		{"bad parity", map[string]any{"action": "read", "port": "ttyS1", "parity": "mark"}, "invalid parity"},
+		{"bad data bits", map[string]any{"action": "read", "port": "ttyS1", "data_bits": float64(9)}, "data_bits"},
+		{"bad stop bits", map[string]any{"action": "read", "port": "ttyS1", "stop_bits": float64(3)}, "stop_bits"},
+		{"no payload", map[string]any{"action": "write", "port": "ttyS1", "confirm": true}, "data or bytes"},
+		{"bad byte", map[string]any{"action": "write", "port": "ttyS1", "bytes": []any{float64(256)}, "confirm": true}, "invalid byte"},
+	} {
+		result := tool.Execute(ctx, tc.args)
+		if !result.IsError || !strings.Contains(result.ForLLM, tc.want) {
+			t.Errorf("%s: expected error containing %q, got: %s", tc.name, tc.want, result.ForLLM)
+		}
+	}
+
+	_, port := openPTY(t)
+	tool = newTestSerialTool()
+	result := tool.Execute(ctx, map[string]any{"action": "read", "port": port, "baud": float64(12345)})
+	if !result.IsError || !strings.Contains(result.ForLLM, "unsupported baud rate") {
+		t.Errorf("expected unsupported baud rate, got: %s", result.ForLLM)
+	}
+}
+
+func TestSerialTool_ReadCancelled(t *testing.T) {
+	_, port := openPTY(t)
+	tool := newTestSerialTool()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	result := tool.Execute(ctx, map[string]any{
+		"action":     "read",
+		"port":       port,
+		"timeout_ms": float64(10000),
+	})
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("cancelled read took %v", elapsed)
+	}
+	if !result.IsError || !strings.Contains(result.ForLLM, "context deadline exceeded") {
+		t.Errorf("expected the read to stop with the context, got: %s", result.ForLLM)
+	}
+}
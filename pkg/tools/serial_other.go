@@ -0,0 +1,20 @@
+//go:build !linux
+
+package tools
+
+import "context"
+
+// list is a stub for non-Linux platforms.
+func (t *SerialTool) list() *ToolResult {
+	return ErrorResult("Serial ports are only supported on Linux")
+}
+
+// read is a stub for non-Linux platforms.
+func (t *SerialTool) read(ctx context.Context, args map[string]any) *ToolResult {
+	return ErrorResult("Serial ports are only supported on Linux")
+}
+
+// write is a stub for non-Linux platforms.
+func (t *SerialTool) write(ctx context.Context, args map[string]any) *ToolResult {
+	return ErrorResult("Serial ports are only supported on Linux")
+}
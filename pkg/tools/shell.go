@@ -22,6 +22,7 @@ type ExecTool struct {
 	denyPatterns        []*regexp.Regexp
 	allowPatterns       []*regexp.Regexp
 	restrictToWorkspace bool
+	env                 []string // Added to the environment of the command, e.g. skill tool arguments
 }
 
 var defaultDenyPatterns = []*regexp.Regexp{
@@ -183,6 +184,9 @@ func (t *ExecTool) Execute(ctx context.Context, args map[string]any) *ToolResult
 	if cwd != "" {
 		cmd.Dir = cwd
 	}
+	if len(t.env) > 0 {
+		cmd.Env = append(os.Environ(), t.env...)
+	}
 
 	prepareCommandForTermination(cmd)
 
@@ -0,0 +1,171 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/skills"
+)
+
+var rePlaceholder = regexp.MustCompile(`\{\{\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*\}\}`)
+
+// skillArgEnvPrefix prefixes the environment variables that carry the
+// arguments of a skill tool call.
+const skillArgEnvPrefix = "PICOCLAW_ARG_"
+
+// SkillTool is a script-backed tool declared in the frontmatter of a skill.
+// The command runs in the skill directory through the exec tool's runner,
+// so deny and allow patterns still apply. Built-in, global and pinned
+// registry skills are lifted out of the workspace restriction. Any other
+// workspace skill may have been written by the model itself, so its tools
+// keep the restriction and require confirm: true, like raw hardware writes.
+// Arguments reach the command as environment variables, never as shell
+// text. A skill tool runs arbitrary code, so it is always high-risk.
+type SkillTool struct {
+	skill   string
+	dir     string
+	spec    skills.ToolSpec
+	runner  ExecTool
+	confirm bool // calls need confirm: true
+}
+
+// NewSkillTool creates the tool spec of skill, running commands like exec.
+func NewSkillTool(skill skills.SkillInfo, spec skills.ToolSpec, exec *ExecTool) *SkillTool {
+	dir, err := filepath.Abs(filepath.Dir(skill.Path))
+	if err != nil {
+		dir = filepath.Dir(skill.Path)
+	}
+	runner := *exec
+	trusted := skill.Source != "workspace" || skills.Pinned(exec.workingDir, filepath.Base(dir))
+	runner.workingDir = dir
+	if trusted {
+		runner.restrictToWorkspace = false
+	}
+	if spec.Timeout > 0 {
+		runner.timeout = time.Duration(spec.Timeout) * time.Second
+	}
+	return &SkillTool{skill: skill.Name, dir: dir, spec: spec, runner: runner, confirm: !trusted}
+}
+
+func (t *SkillTool) Name() string {
+	return t.spec.Name
+}
+
+// HighRisk implements HighRiskTool.
+func (t *SkillTool) HighRisk() bool {
+	return true
+}
+
+// Skill returns the name of the skill that declared the tool.
+func (t *SkillTool) Skill() string {
+	return t.skill
+}
+
+func (t *SkillTool) Description() string {
+	desc := t.spec.Description
+	if desc == "" {
+		desc = "Run " + t.spec.Name
+	}
+	return fmt.Sprintf("%s (from skill %s)", desc, t.skill)
+}
+
+func (t *SkillTool) Parameters() map[string]any {
+	params := t.spec.Parameters
+	if params == nil {
+		params = map[string]any{"type": "object", "properties": map[string]any{}}
+	}
+	if !t.confirm {
+		return params
+	}
+
+	// Copy rather than add to the spec shared with the skill list.
+	props := map[string]any{
+		"confirm": map[string]any{
+			"type":        "boolean",
+			"description": "Must be true. This tool comes from a workspace skill that was not installed from a registry.",
+		},
+	}
+	if declared, ok := params["properties"].(map[string]any); ok {
+		for name, prop := range declared {
+			props[name] = prop
+		}
+	}
+	withConfirm := make(map[string]any, len(params)+1)
+	for key, value := range params {
+		withConfirm[key] = value
+	}
+	withConfirm["properties"] = props
+	return withConfirm
+}
+
+func (t *SkillTool) Execute(ctx context.Context, args map[string]any) *ToolResult {
+	if confirmed, _ := args["confirm"].(bool); t.confirm && !confirmed {
+		return ErrorResult(fmt.Sprintf("%s comes from the workspace skill %s, which was not installed from a registry, "+
+			"so it requires confirm: true. Please confirm with the user before running it, "+
+			"as it runs a shell command written into the workspace.", t.spec.Name, t.skill))
+	}
+	for _, name := range requiredParams(t.spec.Parameters) {
+		if _, ok := args[name]; !ok {
+			return ErrorResult(fmt.Sprintf("%s is required", name))
+		}
+	}
+
+	// Each placeholder becomes a quoted variable reference; the shell
+	// expands it to exactly one argument without parsing the value.
+	var env []string
+	command := rePlaceholder.ReplaceAllStringFunc(t.spec.Command, func(m string) string {
+		name := rePlaceholder.FindStringSubmatch(m)[1]
+		env = append(env, skillArgEnvPrefix+name+"="+argString(args[name]))
+		return shellVar(skillArgEnvPrefix + name)
+	})
+	runner := t.runner
+	runner.env = env
+	result := runner.Execute(ctx, map[string]any{"command": command})
+
+	// Output of skills installed from a registry is third-party content.
+	if source, ok := installedSkillSource(filepath.Dir(t.dir), filepath.Join(t.dir, "SKILL.md")); ok {
+		result.MarkUntrusted(t.spec.Name + ": " + source)
+	}
+	return result
+}
+
+func requiredParams(schema map[string]any) []string {
+	var names []string
+	switch required := schema["required"].(type) {
+	case []string:
+		names = required
+	case []any:
+		for _, r := range required {
+			if s, ok := r.(string); ok {
+				names = append(names, s)
+			}
+		}
+	}
+	return names
+}
+
+func argString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// shellVar references the environment variable name as a single argument
+// for sh, or for PowerShell on Windows where the exec tool uses it.
+func shellVar(name string) string {
+	if runtime.GOOS == "windows" {
+		return `"$env:` + name + `"`
+	}
+	return `"$` + name + `"`
+}
@@ -0,0 +1,170 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/skills"
+)
+
+func newTestSkillTool(t *testing.T, command string) (*SkillTool, string) {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "echoer")
+	os.MkdirAll(dir, 0o755)
+	os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte("---\nname: echoer\ndescription: x\n---\n"), 0o644)
+	info := skills.SkillInfo{Name: "echoer", Path: filepath.Join(dir, "SKILL.md")}
+	spec := skills.ToolSpec{
+		Name:    "echo_text",
+		Command: command,
+		Parameters: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"text": map[string]any{"type": "string"}},
+			"required":   []any{"text"},
+		},
+	}
+	return NewSkillTool(info, spec, NewExecTool(t.TempDir(), true)), dir
+}
+
+func TestSkillTool_Execute(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	tool, dir := newTestSkillTool(t, "printf '%s|' {{text}} {{count}}; pwd")
+
+	if !strings.Contains(tool.Description(), "from skill echoer") {
+		t.Errorf("Description() = %q", tool.Description())
+	}
+
+	result := tool.Execute(context.Background(), map[string]any{"text": "a'; echo injected", "count": 3})
+	if result.IsError {
+		t.Fatalf("Execute() error: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "a'; echo injected|3|") || strings.Contains(result.ForLLM, "\ninjected") {
+		t.Errorf("arguments not quoted: %q", result.ForLLM)
+	}
+	// Runs in the skill directory even though exec is restricted to the workspace.
+	if resolved, _ := filepath.EvalSymlinks(dir); !strings.Contains(result.ForLLM, resolved) {
+		t.Errorf("output %q does not show the skill directory %s", result.ForLLM, dir)
+	}
+	if result.Untrusted {
+		t.Error("local skill output marked untrusted")
+	}
+
+	if result := tool.Execute(context.Background(), map[string]any{}); !result.IsError ||
+		!strings.Contains(result.ForLLM, "text is required") {
+		t.Errorf("missing argument result = %+v", result)
+	}
+}
+
+func TestSkillTool_RegistrySkillIsUntrusted(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	tool, dir := newTestSkillTool(t, "echo {{text}}")
+	if err := skills.WriteOrigin(dir, "clawhub", "echoer", "1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	result := tool.Execute(context.Background(), map[string]any{"text": "hi"})
+	if !result.Untrusted || !strings.Contains(result.Source, "clawhub") {
+		t.Errorf("result = %+v, want untrusted from clawhub", result)
+	}
+}
+
+func TestSkillTool_DenyPatterns(t *testing.T) {
+	tool, _ := newTestSkillTool(t, "sudo rm -rf {{text}}")
+	if result := tool.Execute(context.Background(), map[string]any{"text": "x"}); !result.IsError ||
+		!strings.Contains(result.ForLLM, "safety guard") {
+		t.Errorf("result = %+v, want blocked", result)
+	}
+}
+
+func TestSkillTool_ArgumentsAreNotShellText(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	tool, _ := newTestSkillTool(t, "printf '[%s]' {{text}}")
+
+	// Neither expanded by the shell nor matched against the deny patterns.
+	arg := `$(echo pwned) "quoted" sudo`
+	result := tool.Execute(context.Background(), map[string]any{"text": arg})
+	if result.IsError {
+		t.Fatalf("Execute() error: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "["+arg+"]") {
+		t.Errorf("output = %q, want the argument verbatim", result.ForLLM)
+	}
+}
+
+func TestSkillTool_AlwaysHighRisk(t *testing.T) {
+	tool, _ := newTestSkillTool(t, "echo {{text}}")
+	reg := NewToolRegistry()
+	reg.Register(tool)
+
+	g := NewUntrustedGuard(UntrustedPolicyBlock, []string{"exec"}, "telegram", "42")
+	g.SetTools(reg)
+	g.Taint("web_fetch: https://example.com")
+	if r := g.Check(tool.Name(), map[string]any{"text": "x"}); r == nil {
+		t.Error("skill tool should be gated after untrusted content")
+	}
+}
+
+func TestSkillTool_WorkspaceSkillNeedsConfirm(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	workspace := t.TempDir()
+	dir := filepath.Join(workspace, "skills", "echoer")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte("---\nname: echoer\ndescription: x\n---\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	info := skills.SkillInfo{Name: "echoer", Path: filepath.Join(dir, "SKILL.md"), Source: "workspace"}
+	spec := skills.ToolSpec{Name: "echo_text", Command: "echo {{text}}", Parameters: map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"text": map[string]any{"type": "string"}},
+	}}
+	exec := NewExecTool(workspace, true)
+
+	// Written into the workspace, e.g. by the model: restricted and confirmed.
+	tool := NewSkillTool(info, spec, exec)
+	props := tool.Parameters()["properties"].(map[string]any)
+	if _, ok := props["confirm"]; !ok || props["text"] == nil {
+		t.Errorf("properties = %v, want text and confirm", props)
+	}
+	if _, ok := spec.Parameters["properties"].(map[string]any)["confirm"]; ok {
+		t.Error("Parameters() modified the skill's schema")
+	}
+	if !tool.runner.restrictToWorkspace {
+		t.Error("workspace skill tool lifted the workspace restriction")
+	}
+	if result := tool.Execute(context.Background(), map[string]any{"text": "hi"}); !result.IsError ||
+		!strings.Contains(result.ForLLM, "confirm: true") {
+		t.Errorf("unconfirmed result = %+v, want a confirm error", result)
+	}
+	if result := tool.Execute(context.Background(), map[string]any{"text": "hi", "confirm": true}); result.IsError {
+		t.Errorf("confirmed call failed: %s", result.ForLLM)
+	}
+
+	// Installed from a registry and unmodified: trusted.
+	if err := skills.RecordInstall(workspace, "clawhub", "echoer", "1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	tool = NewSkillTool(info, spec, exec)
+	if _, ok := tool.Parameters()["properties"].(map[string]any)["confirm"]; ok || tool.runner.restrictToWorkspace {
+		t.Error("pinned registry skill should not need confirm")
+	}
+
+	// Edited after installation: untrusted again.
+	if err := os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte("---\nname: echoer\ndescription: y\n---\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if tool = NewSkillTool(info, spec, exec); !tool.confirm {
+		t.Error("modified registry skill should need confirm")
+	}
+}
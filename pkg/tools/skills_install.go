@@ -2,14 +2,12 @@ package tools
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
-	"time"
 
-	"github.com/sipeed/picoclaw/pkg/fileutil"
 	"github.com/sipeed/picoclaw/pkg/logger"
 	"github.com/sipeed/picoclaw/pkg/skills"
 	"github.com/sipeed/picoclaw/pkg/utils"
@@ -100,8 +98,9 @@ func (t *InstallSkillTool) Execute(ctx context.Context, args map[string]any) *To
 			)
 		}
 	} else {
-		// Force: remove existing if present.
+		// Force: remove existing if present, along with its lock entry.
 		os.RemoveAll(targetDir)
+		skills.RemoveFromLock(t.workspace, slug)
 	}
 
 	// Resolve which registry to use.
@@ -159,6 +158,16 @@ func (t *InstallSkillTool) Execute(ctx context.Context, args map[string]any) *To
 		_ = err
 	}
 
+	// Record the install so it can be updated and rolled back later.
+	if err := skills.RecordInstall(t.workspace, registry.Name(), slug, result.Version); err != nil {
+		logger.ErrorCF("tool", "Failed to record skill in lockfile",
+			map[string]any{
+				"tool":  "install_skill",
+				"error": err.Error(),
+				"slug":  slug,
+			})
+	}
+
 	// Build result with moderation warning if suspicious.
 	var output string
 	if result.IsSuspicious {
@@ -170,34 +179,20 @@ func (t *InstallSkillTool) Execute(ctx context.Context, args map[string]any) *To
 	if result.Summary != "" {
 		output += fmt.Sprintf("Description: %s\n", result.Summary)
 	}
+	if meta, err := skills.ReadManifest(targetDir); err == nil {
+		info := skills.SkillInfo{Permissions: meta.Permissions, Tools: meta.Tools}
+		if perms := info.Needs(); len(perms) > 0 {
+			output += fmt.Sprintf("Permissions: %s\n", strings.Join(perms, ", "))
+		}
+		if req := meta.Requires; len(req.Bins)+len(req.Env) > 0 {
+			output += fmt.Sprintf("Requires: %s\n", strings.Join(append(req.Bins, req.Env...), ", "))
+		}
+	}
 	output += "\nThe skill is now available and can be loaded in the current session."
 
-	return SilentResult(output)
-}
-
-// originMeta tracks which registry a skill was installed from.
-type originMeta struct {
-	Version          int    `json:"version"`
-	Registry         string `json:"registry"`
-	Slug             string `json:"slug"`
-	InstalledVersion string `json:"installed_version"`
-	InstalledAt      int64  `json:"installed_at"`
+	return SilentResult(output).MarkUntrusted(fmt.Sprintf("skill %s from %s registry", slug, registry.Name()))
 }
 
 func writeOriginMeta(targetDir, registryName, slug, version string) error {
-	meta := originMeta{
-		Version:          1,
-		Registry:         registryName,
-		Slug:             slug,
-		InstalledVersion: version,
-		InstalledAt:      time.Now().UnixMilli(),
-	}
-
-	data, err := json.MarshalIndent(meta, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	// Use unified atomic write utility with explicit sync for flash storage reliability.
-	return fileutil.WriteFileAtomic(filepath.Join(targetDir, ".skill-origin.json"), data, 0o600)
+	return skills.WriteOrigin(targetDir, registryName, slug, version)
 }
@@ -69,7 +69,7 @@ func (t *FindSkillsTool) Execute(ctx context.Context, args map[string]any) *Tool
 	// Check cache first.
 	if t.cache != nil {
 		if cached, hit := t.cache.Get(query); hit {
-			return SilentResult(formatSearchResults(query, cached, true))
+			return SilentResult(formatSearchResults(query, cached, true)).MarkUntrusted("skill registry search")
 		}
 	}
 
@@ -84,7 +84,7 @@ func (t *FindSkillsTool) Execute(ctx context.Context, args map[string]any) *Tool
 		t.cache.Put(query, results)
 	}
 
-	return SilentResult(formatSearchResults(query, results, false))
+	return SilentResult(formatSearchResults(query, results, false)).MarkUntrusted("skill registry search")
 }
 
 func formatSearchResults(query string, results []skills.SearchResult, cached bool) string {
@@ -0,0 +1,111 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/skills"
+)
+
+// UseSkillTool loads the instructions of an installed skill into the turn,
+// or searches the installed skills that are not listed in the prompt.
+type UseSkillTool struct {
+	loader    *skills.SkillsLoader
+	workspace string
+}
+
+// NewUseSkillTool creates a UseSkillTool reading skills through loader.
+// workspace decides which skills count as installed from a registry.
+func NewUseSkillTool(loader *skills.SkillsLoader, workspace string) *UseSkillTool {
+	return &UseSkillTool{loader: loader, workspace: workspace}
+}
+
+func (t *UseSkillTool) Name() string {
+	return "use_skill"
+}
+
+func (t *UseSkillTool) Description() string {
+	return "Load the full instructions of an installed skill by name, to follow them for the rest of this task. " +
+		"Pass query instead of name to search installed skills that are not listed in the prompt."
+}
+
+func (t *UseSkillTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name": map[string]any{
+				"type":        "string",
+				"description": "Name of the skill to load",
+			},
+			"query": map[string]any{
+				"type":        "string",
+				"description": "Keywords to search installed skills by name and description",
+			},
+		},
+	}
+}
+
+func (t *UseSkillTool) Execute(ctx context.Context, args map[string]any) *ToolResult {
+	name, _ := args["name"].(string)
+	query, _ := args["query"].(string)
+	name, query = strings.TrimSpace(name), strings.TrimSpace(query)
+
+	switch {
+	case name != "":
+		return t.load(name)
+	case query != "":
+		return t.search(query)
+	default:
+		return ErrorResult("name or query is required")
+	}
+}
+
+func (t *UseSkillTool) load(name string) *ToolResult {
+	info, content, ok := t.loader.LoadSkillInfo(name)
+	if !ok {
+		return ErrorResult(fmt.Sprintf("skill %q is not installed; search with use_skill(query=...)", name))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Skill: %s\n", info.Name)
+	fmt.Fprintf(&sb, "Directory: %s (relative paths in the skill are relative to it)\n", filepath.Dir(info.Path))
+	if !info.Available() {
+		fmt.Fprintf(&sb, "Warning: this skill is unavailable because %s.\n", info.Unavailable)
+	}
+	if len(info.Tools) > 0 && info.Available() {
+		names := make([]string, 0, len(info.Tools))
+		for _, spec := range info.Tools {
+			names = append(names, spec.Name)
+		}
+		fmt.Fprintf(&sb, "Tools: %s\n", strings.Join(names, ", "))
+	}
+	sb.WriteString("\n")
+	sb.WriteString(content)
+
+	result := SilentResult(sb.String())
+	if source, ok := installedSkillSource(t.workspace, info.Path); ok {
+		result.MarkUntrusted(source)
+	}
+	return result
+}
+
+func (t *UseSkillTool) search(query string) *ToolResult {
+	matches := skills.RankSkills(t.loader.ListSkills(), query, 10)
+	if len(matches) == 0 {
+		return SilentResult(fmt.Sprintf("No installed skill matches %q. Use find_skills to search the registries.", query))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Installed skills matching %q:\n", query)
+	for _, info := range matches {
+		fmt.Fprintf(&sb, "- %s: %s", info.Name, info.Description)
+		if !info.Available() {
+			fmt.Fprintf(&sb, " (unavailable because %s)", info.Unavailable)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\nLoad one with use_skill(name=...).")
+	return SilentResult(sb.String())
+}
@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/skills"
+)
+
+func newUseSkillWorkspace(t *testing.T) string {
+	t.Helper()
+	workspace := t.TempDir()
+	for name, content := range map[string]string{
+		"weather": "---\nname: weather\ndescription: Forecasts for any city\n---\n# Weather\nRun scripts/forecast.sh\n",
+		"ci":      "---\nname: ci-status\ndescription: Check build pipelines\nrequires:\n  env: [PICOCLAW_TEST_CI_TOKEN]\n---\n# CI\n",
+	} {
+		dir := filepath.Join(workspace, "skills", name)
+		os.MkdirAll(dir, 0o755)
+		os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte(content), 0o644)
+	}
+	return workspace
+}
+
+func TestUseSkillTool_Load(t *testing.T) {
+	workspace := newUseSkillWorkspace(t)
+	tool := NewUseSkillTool(skills.NewSkillsLoader(workspace, "", ""), workspace)
+
+	result := tool.Execute(context.Background(), map[string]any{"name": "weather"})
+	if result.IsError || !strings.Contains(result.ForLLM, "Run scripts/forecast.sh") ||
+		strings.Contains(result.ForLLM, "description:") || result.Untrusted {
+		t.Fatalf("Execute() = %+v", result)
+	}
+
+	// Loaded by its frontmatter name, with the reason it cannot be used.
+	t.Setenv("PICOCLAW_TEST_CI_TOKEN", "")
+	result = tool.Execute(context.Background(), map[string]any{"name": "ci-status"})
+	if !strings.Contains(result.ForLLM, "unavailable because missing env vars: PICOCLAW_TEST_CI_TOKEN") {
+		t.Errorf("Execute() = %q", result.ForLLM)
+	}
+
+	if result := tool.Execute(context.Background(), map[string]any{"name": "nope"}); !result.IsError {
+		t.Error("loading a missing skill should fail")
+	}
+	if result := tool.Execute(context.Background(), map[string]any{}); !result.IsError {
+		t.Error("name or query should be required")
+	}
+}
+
+func TestUseSkillTool_RegistrySkillIsUntrusted(t *testing.T) {
+	workspace := newUseSkillWorkspace(t)
+	skills.WriteOrigin(filepath.Join(workspace, "skills", "weather"), "clawhub", "weather", "1.0.0")
+	tool := NewUseSkillTool(skills.NewSkillsLoader(workspace, "", ""), workspace)
+
+	if result := tool.Execute(context.Background(), map[string]any{"name": "weather"}); !result.Untrusted {
+		t.Error("registry skill content not marked untrusted")
+	}
+}
+
+func TestUseSkillTool_Search(t *testing.T) {
+	workspace := newUseSkillWorkspace(t)
+	tool := NewUseSkillTool(skills.NewSkillsLoader(workspace, "", ""), workspace)
+
+	result := tool.Execute(context.Background(), map[string]any{"query": "build pipeline"})
+	if !strings.Contains(result.ForLLM, "- ci-status: Check build pipelines") || strings.Contains(result.ForLLM, "weather") {
+		t.Errorf("search = %q", result.ForLLM)
+	}
+	result = tool.Execute(context.Background(), map[string]any{"query": "kubernetes"})
+	if !strings.Contains(result.ForLLM, "find_skills") {
+		t.Errorf("empty search = %q", result.ForLLM)
+	}
+}
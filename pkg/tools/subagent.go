@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/permissions"
 	"github.com/sipeed/picoclaw/pkg/providers"
 )
 
@@ -36,6 +37,9 @@ type SubagentManager struct {
 	hasMaxTokens   bool
 	hasTemperature bool
 	nextID         int
+
+	untrustedPolicy string
+	highRiskTools   []string
 }
 
 func NewSubagentManager(
@@ -65,6 +69,15 @@ func (sm *SubagentManager) SetLLMOptions(maxTokens int, temperature float64) {
 	sm.hasTemperature = true
 }
 
+// SetUntrustedPolicy sets how subagents treat high-risk tools after reading
+// untrusted content. See UntrustedGuard.
+func (sm *SubagentManager) SetUntrustedPolicy(policy string, highRiskTools []string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.untrustedPolicy = policy
+	sm.highRiskTools = highRiskTools
+}
+
 // SetTools sets the tool registry for subagent execution.
 // If not set, subagent will have access to the provided tools.
 func (sm *SubagentManager) SetTools(tools *ToolRegistry) {
@@ -151,6 +164,8 @@ After completing the task, provide a clear summary of what was done.`
 	temperature := sm.temperature
 	hasMaxTokens := sm.hasMaxTokens
 	hasTemperature := sm.hasTemperature
+	untrustedPolicy := sm.untrustedPolicy
+	highRiskTools := sm.highRiskTools
 	sm.mu.RUnlock()
 
 	var llmOptions map[string]any
@@ -165,11 +180,13 @@ After completing the task, provide a clear summary of what was done.`
 	}
 
 	loopResult, err := RunToolLoop(ctx, ToolLoopConfig{
-		Provider:      sm.provider,
-		Model:         sm.defaultModel,
-		Tools:         tools,
-		MaxIterations: maxIter,
-		LLMOptions:    llmOptions,
+		Provider:        sm.provider,
+		Model:           sm.defaultModel,
+		Tools:           tools,
+		MaxIterations:   maxIter,
+		LLMOptions:      llmOptions,
+		UntrustedPolicy: untrustedPolicy,
+		HighRiskTools:   highRiskTools,
 	}, messages, task.OriginChannel, task.OriginChatID)
 
 	sm.mu.Lock()
@@ -218,12 +235,21 @@ After completing the task, provide a clear summary of what was done.`
 	// Send announce message back to main agent
 	if sm.bus != nil {
 		announceContent := fmt.Sprintf("Task '%s' completed.\n\nResult:\n%s", task.Label, task.Result)
+		// The result turn runs with the role of whoever spawned the task.
+		var metadata map[string]string
+		if access := permissions.AccessFrom(ctx); access != nil {
+			metadata = map[string]string{
+				permissions.MetadataRole:     access.Role,
+				permissions.MetadataIdentity: access.Identity,
+			}
+		}
 		sm.bus.PublishInbound(bus.InboundMessage{
 			Channel:  "system",
 			SenderID: fmt.Sprintf("subagent:%s", task.ID),
 			// Format: "original_channel:original_chat_id" for routing back
-			ChatID:  fmt.Sprintf("%s:%s", task.OriginChannel, task.OriginChatID),
-			Content: announceContent,
+			ChatID:   fmt.Sprintf("%s:%s", task.OriginChannel, task.OriginChatID),
+			Content:  announceContent,
+			Metadata: metadata,
 		})
 	}
 }
@@ -326,6 +352,8 @@ func (t *SubagentTool) Execute(ctx context.Context, args map[string]any) *ToolRe
 	temperature := sm.temperature
 	hasMaxTokens := sm.hasMaxTokens
 	hasTemperature := sm.hasTemperature
+	untrustedPolicy := sm.untrustedPolicy
+	highRiskTools := sm.highRiskTools
 	sm.mu.RUnlock()
 
 	var llmOptions map[string]any
@@ -340,11 +368,13 @@ func (t *SubagentTool) Execute(ctx context.Context, args map[string]any) *ToolRe
 	}
 
 	loopResult, err := RunToolLoop(ctx, ToolLoopConfig{
-		Provider:      sm.provider,
-		Model:         sm.defaultModel,
-		Tools:         tools,
-		MaxIterations: maxIter,
-		LLMOptions:    llmOptions,
+		Provider:        sm.provider,
+		Model:           sm.defaultModel,
+		Tools:           tools,
+		MaxIterations:   maxIter,
+		LLMOptions:      llmOptions,
+		UntrustedPolicy: untrustedPolicy,
+		HighRiskTools:   highRiskTools,
 	}, messages, t.originChannel, t.originChatID)
 	if err != nil {
 		return ErrorResult(fmt.Sprintf("Subagent execution failed: %v", err)).WithError(err)
@@ -348,3 +348,54 @@ func TestSubagentTool_ForUserTruncation(t *testing.T) {
 		t.Error("ForLLM should contain reference to original task")
 	}
 }
+
+// toolCallingProvider asks for one write_file call, then answers with the
+// content of the tool result it got back.
+type toolCallingProvider struct {
+	MockLLMProvider
+}
+
+func (p *toolCallingProvider) Chat(
+	ctx context.Context,
+	messages []providers.Message,
+	tools []providers.ToolDefinition,
+	model string,
+	options map[string]any,
+) (*providers.LLMResponse, error) {
+	if last := messages[len(messages)-1]; last.Role == "tool" {
+		return &providers.LLMResponse{Content: last.Content}, nil
+	}
+	return &providers.LLMResponse{ToolCalls: []providers.ToolCall{{
+		ID:        "call_1",
+		Name:      "write_file",
+		Arguments: map[string]any{"path": "x.txt", "content": "x"},
+	}}}, nil
+}
+
+func TestSubagentTool_InheritsTaint(t *testing.T) {
+	writeFile := newMockTool("write_file", "writes")
+	registry := NewToolRegistry()
+	registry.Register(writeFile)
+
+	manager := NewSubagentManager(&toolCallingProvider{}, "test-model", t.TempDir(), nil)
+	manager.SetTools(registry)
+	manager.SetUntrustedPolicy(UntrustedPolicyBlock, nil)
+	tool := NewSubagentTool(manager)
+	tool.SetContext("telegram", "42")
+
+	parent := NewUntrustedGuard(UntrustedPolicyBlock, nil, "telegram", "42")
+	args := map[string]any{"task": "save the page"}
+
+	// An untainted parent turn: the subagent may write.
+	result := tool.Execute(parent.WithTaint(context.Background()), args)
+	if result.IsError || strings.Contains(result.ForLLM, "blocked") {
+		t.Fatalf("untainted subagent result = %+v", result)
+	}
+
+	// After the parent read a web page, the subagent starts tainted.
+	parent.Taint("web_fetch: https://example.com")
+	result = tool.Execute(parent.WithTaint(context.Background()), args)
+	if !strings.Contains(result.ForLLM, "write_file is blocked") || !strings.Contains(result.ForLLM, "example.com") {
+		t.Errorf("tainted subagent result = %q, want write_file blocked", result.ForLLM)
+	}
+}
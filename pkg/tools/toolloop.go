@@ -12,6 +12,7 @@ import (
 	"fmt"
 
 	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/permissions"
 	"github.com/sipeed/picoclaw/pkg/providers"
 	"github.com/sipeed/picoclaw/pkg/utils"
 )
@@ -23,6 +24,10 @@ type ToolLoopConfig struct {
 	Tools         *ToolRegistry
 	MaxIterations int
 	LLMOptions    map[string]any
+	// UntrustedPolicy and HighRiskTools configure the UntrustedGuard of the
+	// loop; zero values mean approve and DefaultHighRiskTools.
+	UntrustedPolicy string
+	HighRiskTools   []string
 }
 
 // ToolLoopResult contains the result of running the tool loop.
@@ -41,6 +46,9 @@ func RunToolLoop(
 ) (*ToolLoopResult, error) {
 	iteration := 0
 	var finalContent string
+	guard := NewUntrustedGuard(config.UntrustedPolicy, config.HighRiskTools, channel, chatID)
+	guard.SetTools(config.Tools)
+	guard.inheritTaint(ctx)
 
 	for iteration < config.MaxIterations {
 		iteration++
@@ -54,7 +62,11 @@ func RunToolLoop(
 		// 1. Build tool definitions
 		var providerToolDefs []providers.ToolDefinition
 		if config.Tools != nil {
-			providerToolDefs = config.Tools.ToProviderDefs()
+			var allow func(string) bool
+			if access := permissions.AccessFrom(ctx); access != nil {
+				allow = access.AllowsTool
+			}
+			providerToolDefs = config.Tools.ToProviderDefsFiltered(allow)
 		}
 
 		// 2. Set default LLM options
@@ -133,8 +145,10 @@ func RunToolLoop(
 
 			// Execute tool (no async callback for subagents - they run independently)
 			var toolResult *ToolResult
-			if config.Tools != nil {
-				toolResult = config.Tools.ExecuteWithContext(ctx, tc.Name, tc.Arguments, channel, chatID, nil)
+			if blocked := guard.Check(tc.Name, tc.Arguments); blocked != nil {
+				toolResult = blocked
+			} else if config.Tools != nil {
+				toolResult = config.Tools.ExecuteWithContext(guard.WithTaint(ctx), tc.Name, tc.Arguments, channel, chatID, nil)
 			} else {
 				toolResult = ErrorResult("No tools available")
 			}
@@ -144,6 +158,7 @@ func RunToolLoop(
 			if contentForLLM == "" && toolResult.Err != nil {
 				contentForLLM = toolResult.Err.Error()
 			}
+			contentForLLM = guard.Observe(toolResult, contentForLLM)
 
 			// Add tool result message
 			toolResultMsg := providers.Message{
@@ -499,10 +499,10 @@ func (t *WebSearchTool) Execute(ctx context.Context, args map[string]any) *ToolR
 		return ErrorResult(fmt.Sprintf("search failed: %v", err))
 	}
 
-	return &ToolResult{
+	return (&ToolResult{
 		ForLLM:  result,
 		ForUser: result,
-	}
+	}).MarkUntrusted("web_search: " + query)
 }
 
 type WebFetchTool struct {
@@ -651,7 +651,7 @@ func (t *WebFetchTool) Execute(ctx context.Context, args map[string]any) *ToolRe
 
 	resultJSON, _ := json.MarshalIndent(result, "", "  ")
 
-	return &ToolResult{
+	return (&ToolResult{
 		ForLLM: fmt.Sprintf(
 			"Fetched %d bytes from %s (extractor: %s, truncated: %v)",
 			len(text),
@@ -660,7 +660,7 @@ func (t *WebFetchTool) Execute(ctx context.Context, args map[string]any) *ToolRe
 			truncated,
 		),
 		ForUser: string(resultJSON),
-	}
+	}).MarkUntrusted("web_fetch: " + urlStr)
 }
 
 func (t *WebFetchTool) extractText(htmlContent string) string {
@@ -0,0 +1,335 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package tracing records spans of agent turns (routing, context build, LLM
+// calls, tool executions, outbound sends) and exports them via OTLP/HTTP or
+// to a local JSONL file. The trace ID of a turn doubles as its turn ID in
+// log fields.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// Span statuses.
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+// SpanData is a finished span as handed to exporters.
+type SpanData struct {
+	TraceID    string         `json:"trace_id"`
+	SpanID     string         `json:"span_id"`
+	ParentID   string         `json:"parent_id,omitempty"`
+	Name       string         `json:"name"`
+	Start      time.Time      `json:"start"`
+	End        time.Time      `json:"end"`
+	DurationMS float64        `json:"duration_ms"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+	Status     string         `json:"status"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// Span is an operation in progress. A nil *Span is valid and does nothing,
+// so callers never need to check whether tracing is on.
+type Span struct {
+	tracer *Tracer
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// SetAttr sets an attribute on the span.
+func (s *Span) SetAttr(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data.Attributes == nil {
+		s.data.Attributes = make(map[string]any)
+	}
+	s.data.Attributes[key] = value
+}
+
+// SetError marks the span failed. A nil err is ignored.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Status = StatusError
+	s.data.Error = err.Error()
+}
+
+// End finishes the span and queues it for export. Later calls do nothing.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	s.data.DurationMS = float64(s.data.End.Sub(s.data.Start).Microseconds()) / 1000
+	data := s.data
+	s.mu.Unlock()
+
+	if s.tracer != nil {
+		s.tracer.enqueue(data)
+	}
+}
+
+// TraceID returns the span's trace ID, or "" for a nil span.
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return s.data.TraceID
+}
+
+type spanKey struct{}
+
+// remoteParent is a parent span received from another component, e.g. via
+// a traceparent carried on a bus message.
+type remoteParent struct {
+	traceID string
+	spanID  string
+}
+
+// Start begins a span named name as a child of the span in ctx, or as the
+// root of a new trace. attrs may be nil.
+func Start(ctx context.Context, name string, attrs map[string]any) (context.Context, *Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	data := SpanData{
+		SpanID: newID(8),
+		Name:   name,
+		Start:  time.Now(),
+		Status: StatusOK,
+	}
+	switch parent := ctx.Value(spanKey{}).(type) {
+	case *Span:
+		data.TraceID = parent.data.TraceID
+		data.ParentID = parent.data.SpanID
+	case remoteParent:
+		data.TraceID = parent.traceID
+		data.ParentID = parent.spanID
+	default:
+		data.TraceID = newID(16)
+	}
+	if len(attrs) > 0 {
+		data.Attributes = make(map[string]any, len(attrs))
+		for k, v := range attrs {
+			data.Attributes[k] = v
+		}
+	}
+
+	span := &Span{tracer: Default(), data: data}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// SpanFrom returns the span in ctx, or nil.
+func SpanFrom(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// TurnID returns the trace ID of the turn ctx belongs to, or "".
+func TurnID(ctx context.Context) string {
+	switch parent := ctx.Value(spanKey{}).(type) {
+	case *Span:
+		return parent.data.TraceID
+	case remoteParent:
+		return parent.traceID
+	}
+	return ""
+}
+
+// Fields adds the turn ID of ctx to log fields. fields may be nil.
+func Fields(ctx context.Context, fields map[string]any) map[string]any {
+	id := TurnID(ctx)
+	if id == "" {
+		return fields
+	}
+	if fields == nil {
+		fields = make(map[string]any, 1)
+	}
+	fields["turn_id"] = id
+	return fields
+}
+
+// Traceparent returns the W3C traceparent of the span in ctx, or "".
+func Traceparent(ctx context.Context) string {
+	span := SpanFrom(ctx)
+	if span == nil {
+		return ""
+	}
+	return fmt.Sprintf("00-%s-%s-01", span.data.TraceID, span.data.SpanID)
+}
+
+// WithTraceparent returns a context whose next span continues the trace in
+// a W3C traceparent. Invalid values are ignored.
+func WithTraceparent(ctx context.Context, traceparent string) context.Context {
+	parts := strings.Split(traceparent, "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return ctx
+	}
+	return context.WithValue(ctx, spanKey{}, remoteParent{traceID: parts[1], spanID: parts[2]})
+}
+
+func newID(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Exporter sends finished spans somewhere.
+type Exporter interface {
+	Export(ctx context.Context, spans []SpanData) error
+	Close() error
+}
+
+const (
+	queueSize     = 2048
+	batchSize     = 128
+	flushInterval = 5 * time.Second
+)
+
+// Tracer batches finished spans and hands them to an exporter in the
+// background. Spans are dropped rather than blocking a turn when the queue
+// is full.
+type Tracer struct {
+	exporter Exporter
+	queue    chan SpanData
+	flush    chan chan struct{}
+	done     chan struct{}
+	stopped  chan struct{}
+	dropped  atomic.Int64
+	stopOnce sync.Once
+}
+
+// NewTracer starts a tracer exporting to exporter.
+func NewTracer(exporter Exporter) *Tracer {
+	t := &Tracer{
+		exporter: exporter,
+		queue:    make(chan SpanData, queueSize),
+		flush:    make(chan chan struct{}),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+func (t *Tracer) enqueue(data SpanData) {
+	select {
+	case t.queue <- data:
+	default:
+		if t.dropped.Add(1) == 1 {
+			logger.WarnCF("tracing", "Span queue full; dropping spans", nil)
+		}
+	}
+}
+
+func (t *Tracer) run() {
+	defer close(t.stopped)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	var batch []SpanData
+	export := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := t.exporter.Export(ctx, batch); err != nil {
+			logger.WarnCF("tracing", "Failed to export spans",
+				map[string]any{"spans": len(batch), "error": err.Error()})
+		}
+		batch = nil
+	}
+	drain := func() {
+		for {
+			select {
+			case data := <-t.queue:
+				batch = append(batch, data)
+			default:
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case data := <-t.queue:
+			batch = append(batch, data)
+			if len(batch) >= batchSize {
+				export()
+			}
+		case <-ticker.C:
+			export()
+		case ack := <-t.flush:
+			drain()
+			export()
+			close(ack)
+		case <-t.done:
+			drain()
+			export()
+			return
+		}
+	}
+}
+
+// Flush exports every queued span before returning.
+func (t *Tracer) Flush() {
+	ack := make(chan struct{})
+	select {
+	case t.flush <- ack:
+		<-ack
+	case <-t.done:
+	}
+}
+
+// Shutdown exports queued spans and closes the exporter.
+func (t *Tracer) Shutdown() error {
+	var err error
+	t.stopOnce.Do(func() {
+		close(t.done)
+		<-t.stopped
+		err = t.exporter.Close()
+	})
+	return err
+}
+
+var std atomic.Pointer[Tracer]
+
+// SetDefault installs the process-wide tracer. Passing nil stops exporting;
+// spans still get IDs so turn IDs appear in logs.
+func SetDefault(t *Tracer) {
+	std.Store(t)
+}
+
+// Default returns the process-wide tracer, or nil.
+func Default() *Tracer {
+	return std.Load()
+}
@@ -0,0 +1,247 @@
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+type memoryExporter struct {
+	mu     sync.Mutex
+	spans  []SpanData
+	closed bool
+}
+
+func (e *memoryExporter) Export(_ context.Context, spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *memoryExporter) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.closed = true
+	return nil
+}
+
+func (e *memoryExporter) byName() map[string]SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	m := make(map[string]SpanData, len(e.spans))
+	for _, s := range e.spans {
+		m[s.Name] = s
+	}
+	return m
+}
+
+// withTracer installs a tracer exporting to a memory exporter for the test.
+func withTracer(t *testing.T) (*Tracer, *memoryExporter) {
+	t.Helper()
+	exp := &memoryExporter{}
+	tr := NewTracer(exp)
+	prev := Default()
+	SetDefault(tr)
+	t.Cleanup(func() {
+		SetDefault(prev)
+		tr.Shutdown()
+	})
+	return tr, exp
+}
+
+func TestStart_ParentAndChild(t *testing.T) {
+	tr, exp := withTracer(t)
+
+	ctx, root := Start(context.Background(), "inbound.receive", map[string]any{"channel": "telegram"})
+	_, child := Start(ctx, "llm.call", nil)
+	child.SetError(errors.New("rate limited"))
+	child.End()
+	root.End()
+	tr.Flush()
+
+	spans := exp.byName()
+	r, c := spans["inbound.receive"], spans["llm.call"]
+	if r.TraceID == "" || r.ParentID != "" {
+		t.Fatalf("root span = %+v, want new trace without parent", r)
+	}
+	if c.TraceID != r.TraceID || c.ParentID != r.SpanID {
+		t.Errorf("child trace/parent = %s/%s, want %s/%s", c.TraceID, c.ParentID, r.TraceID, r.SpanID)
+	}
+	if c.Status != StatusError || c.Error != "rate limited" {
+		t.Errorf("child status = %s %q, want error", c.Status, c.Error)
+	}
+	if r.Attributes["channel"] != "telegram" {
+		t.Errorf("root attributes = %v", r.Attributes)
+	}
+}
+
+func TestSpan_EndTwice(t *testing.T) {
+	tr, exp := withTracer(t)
+
+	_, span := Start(context.Background(), "once", nil)
+	span.End()
+	span.End()
+	tr.Flush()
+
+	if len(exp.spans) != 1 {
+		t.Errorf("exported %d spans, want 1", len(exp.spans))
+	}
+}
+
+func TestSpan_NilIsSafe(t *testing.T) {
+	var span *Span
+	span.SetAttr("k", "v")
+	span.SetError(errors.New("x"))
+	span.End()
+	if span.TraceID() != "" {
+		t.Error("nil span has a trace ID")
+	}
+}
+
+func TestFields_TurnID(t *testing.T) {
+	if f := Fields(context.Background(), nil); f != nil {
+		t.Errorf("Fields without span = %v, want nil", f)
+	}
+
+	ctx, span := Start(context.Background(), "agent.run", nil)
+	defer span.End()
+	f := Fields(ctx, map[string]any{"tool": "exec"})
+	if f["turn_id"] != span.TraceID() || f["tool"] != "exec" {
+		t.Errorf("Fields = %v, want turn_id %s", f, span.TraceID())
+	}
+}
+
+func TestTraceparent_RoundTrip(t *testing.T) {
+	tr, exp := withTracer(t)
+
+	ctx, parent := Start(context.Background(), "agent.run", nil)
+	tp := Traceparent(ctx)
+	parent.End()
+
+	remote := WithTraceparent(context.Background(), tp)
+	if TurnID(remote) != parent.TraceID() {
+		t.Errorf("TurnID = %q, want %q", TurnID(remote), parent.TraceID())
+	}
+	_, send := Start(remote, "outbound.send", nil)
+	send.End()
+	tr.Flush()
+
+	spans := exp.byName()
+	if got := spans["outbound.send"].ParentID; got != spans["agent.run"].SpanID {
+		t.Errorf("outbound.send parent = %q, want %q", got, spans["agent.run"].SpanID)
+	}
+
+	if ctx := WithTraceparent(context.Background(), "garbage"); TurnID(ctx) != "" {
+		t.Error("invalid traceparent accepted")
+	}
+	if Traceparent(context.Background()) != "" {
+		t.Error("Traceparent without span should be empty")
+	}
+}
+
+func TestTracer_ShutdownExportsAndCloses(t *testing.T) {
+	exp := &memoryExporter{}
+	tr := NewTracer(exp)
+	prev := Default()
+	SetDefault(tr)
+	_, span := Start(context.Background(), "pending", nil)
+	span.End()
+	SetDefault(prev)
+
+	if err := tr.Shutdown(); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if len(exp.spans) != 1 || !exp.closed {
+		t.Errorf("spans = %d, closed = %v; want 1, true", len(exp.spans), exp.closed)
+	}
+	tr.Flush() // must not block after shutdown
+}
+
+func TestFileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces", "traces.jsonl")
+	exp, err := NewFileExporter(path)
+	if err != nil {
+		t.Fatalf("NewFileExporter: %v", err)
+	}
+	spans := []SpanData{{TraceID: "t1", SpanID: "s1", Name: "a"}, {TraceID: "t1", SpanID: "s2", Name: "b"}}
+	if err := exp.Export(context.Background(), spans); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	exp.Close()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var names []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var s SpanData
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		names = append(names, s.Name)
+	}
+	if len(names) != 2 || names[0] != "a" || names[1] != "b" {
+		t.Errorf("names = %v, want [a b]", names)
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var got otlpRequest
+	var path, auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	exp := NewOTLPExporter(srv.URL+"/", map[string]string{"Authorization": "Bearer x"}, "")
+	_, span := Start(context.Background(), "tool.execute", map[string]any{"tool": "exec", "bytes": 42})
+	span.SetError(errors.New("boom"))
+	span.End()
+	if err := exp.Export(context.Background(), []SpanData{span.data}); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	if path != "/v1/traces" || auth != "Bearer x" {
+		t.Errorf("path = %q, auth = %q", path, auth)
+	}
+	rs := got.ResourceSpans[0]
+	if v := rs.Resource.Attributes[0]; v.Key != "service.name" || *v.Value.StringValue != "picoclaw" {
+		t.Errorf("resource attribute = %+v", v)
+	}
+	s := rs.ScopeSpans[0].Spans[0]
+	if s.Name != "tool.execute" || len(s.TraceID) != 32 || len(s.SpanID) != 16 {
+		t.Errorf("span = %+v", s)
+	}
+	if s.Status.Code != otlpStatusError || s.Status.Message != "boom" {
+		t.Errorf("status = %+v", s.Status)
+	}
+	if s.Attributes[0].Key != "bytes" || *s.Attributes[0].Value.IntValue != "42" {
+		t.Errorf("attributes = %+v", s.Attributes)
+	}
+}
+
+func TestOTLPExporter_HTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "bad token", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	exp := NewOTLPExporter(srv.URL+"/v1/traces", nil, "picoclaw")
+	if err := exp.Export(context.Background(), []SpanData{{Name: "x"}}); err == nil {
+		t.Error("expected error for HTTP 401")
+	}
+}
@@ -0,0 +1,120 @@
+package triggers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// File event types.
+const (
+	FileCreated  = "created"
+	FileModified = "modified"
+	FileRemoved  = "removed"
+)
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// fileWatcher polls the files matching the rules' globs and reports what
+// changed between two polls. Polling keeps it portable and dependency-free;
+// the first poll only records the current state.
+type fileWatcher struct {
+	workspace string
+	patterns  []string
+	files     map[string]fileState
+}
+
+func newFileWatcher(workspace string, rules []*rule) *fileWatcher {
+	w := &fileWatcher{workspace: workspace}
+	seen := make(map[string]bool)
+	for _, r := range rules {
+		for _, p := range r.Paths {
+			if !seen[p] {
+				seen[p] = true
+				w.patterns = append(w.patterns, p)
+			}
+		}
+	}
+	return w
+}
+
+func (w *fileWatcher) run(ctx context.Context, interval time.Duration, fire func(Event)) {
+	w.files = w.scan()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, ev := range w.poll() {
+				fire(ev)
+			}
+		}
+	}
+}
+
+// poll scans again and returns the changes since the previous scan.
+func (w *fileWatcher) poll() []Event {
+	current := w.scan()
+	now := time.Now()
+	var events []Event
+	for path, st := range current {
+		prev, ok := w.files[path]
+		switch {
+		case !ok:
+			ev := fileEvent(FileCreated, path, st, now)
+			ev.changedAt = st.modTime
+			events = append(events, ev)
+		case !st.modTime.Equal(prev.modTime) || st.size != prev.size:
+			ev := fileEvent(FileModified, path, st, now)
+			ev.changedAt = st.modTime
+			events = append(events, ev)
+		}
+	}
+	for path, st := range w.files {
+		if _, ok := current[path]; !ok {
+			events = append(events, fileEvent(FileRemoved, path, st, now))
+		}
+	}
+	w.files = current
+	return events
+}
+
+func (w *fileWatcher) scan() map[string]fileState {
+	files := make(map[string]fileState)
+	for _, p := range w.patterns {
+		matches, err := filepath.Glob(filepath.Join(w.workspace, p))
+		if err != nil {
+			logger.WarnCF("triggers", "Bad file pattern", map[string]any{"pattern": p, "error": err.Error()})
+			continue
+		}
+		for _, m := range matches {
+			info, err := os.Stat(m)
+			if err != nil || info.IsDir() {
+				continue
+			}
+			rel, err := filepath.Rel(w.workspace, m)
+			if err != nil {
+				continue
+			}
+			files[filepath.ToSlash(rel)] = fileState{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return files
+}
+
+func fileEvent(typ, path string, st fileState, now time.Time) Event {
+	return Event{
+		Source: SourceFile,
+		Type:   typ,
+		Data:   map[string]any{"path": path, "size": st.size},
+		Time:   now,
+	}
+}
@@ -0,0 +1,406 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package triggers runs agent prompts in response to events: authenticated
+// webhooks, file changes in the workspace, USB hotplug and MaixCam
+// detections. Each rule renders its prompt from the event, runs it in its
+// own session and sends the response to a configured chat.
+package triggers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/metrics"
+	"github.com/sipeed/picoclaw/pkg/secrets"
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// Event sources.
+const (
+	SourceWebhook = "webhook"
+	SourceFile    = "file"
+	SourceUSB     = "usb"
+	SourceMaixCam = "maixcam"
+)
+
+// RulesFile is the workspace file with additional rules.
+const RulesFile = "TRIGGERS.json"
+
+var triggerRuns = metrics.NewCounter("picoclaw_trigger_runs_total",
+	"Trigger runs by rule and status (ok, error or skipped).", "trigger", "status")
+
+// Event is what a source reports. The prompt template sees it as ".".
+type Event struct {
+	Source  string
+	Type    string         // e.g. "created", "add", "person_detected", "push"
+	Data    map[string]any // Source-specific fields
+	Payload string         // Raw body of a webhook
+	Time    time.Time
+	Rule    string // Name of the rule being run, set per rule
+
+	changedAt time.Time // File source: modification time of a created or modified file
+}
+
+// Runner runs a prompt on an agent, e.g. *agent.AgentLoop.
+type Runner interface {
+	ProcessTrigger(ctx context.Context, agentID, sessionKey, content, channel, chatID string) (string, error)
+	// ResolveAgentID returns the ID of the agent ProcessTrigger runs for
+	// agentID, e.g. the default agent's when it is empty.
+	ResolveAgentID(agentID string) string
+}
+
+// Options configures a Service.
+type Options struct {
+	Rules        []config.TriggerRule
+	Workspace    string
+	Runner       Runner
+	Bus          *bus.MessageBus
+	Resolver     *secrets.Resolver // Resolves secret references in TRIGGERS.json; may be nil
+	PollInterval time.Duration     // File source; 0 means 2s
+}
+
+type rule struct {
+	config.TriggerRule
+	prompt *template.Template
+
+	mu       sync.Mutex
+	running  bool
+	lastRun  time.Time
+	lastDone time.Time // When the last run finished
+}
+
+// Service matches events against rules and runs them.
+type Service struct {
+	opts  Options
+	rules []*rule
+
+	watcher *fileWatcher
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewService validates the rules from opts and from RulesFile in the
+// workspace. Invalid rules are logged and skipped.
+func NewService(opts Options) *Service {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 2 * time.Second
+	}
+	s := &Service{opts: opts, ctx: context.Background()}
+
+	all := append([]config.TriggerRule{}, opts.Rules...)
+	fileRules, err := loadRulesFile(filepath.Join(opts.Workspace, RulesFile), opts.Resolver)
+	if err != nil {
+		logger.WarnCF("triggers", "Failed to load rules file", map[string]any{"error": err.Error()})
+	}
+	all = append(all, fileRules...)
+
+	seen := make(map[string]bool)
+	for _, rc := range all {
+		r, err := newRule(rc)
+		if err == nil && seen[rc.Name] {
+			err = fmt.Errorf("duplicate name")
+		}
+		if err != nil {
+			logger.WarnCF("triggers", "Skipping invalid rule", map[string]any{"rule": rc.Name, "error": err.Error()})
+			continue
+		}
+		seen[rc.Name] = true
+		s.rules = append(s.rules, r)
+	}
+	return s
+}
+
+func loadRulesFile(path string, resolver *secrets.Resolver) ([]config.TriggerRule, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Rules []config.TriggerRule `json:"rules"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", RulesFile, err)
+	}
+	for i := range file.Rules {
+		if resolver != nil && secrets.IsReference(file.Rules[i].Secret) {
+			secret, err := resolver.Resolve(file.Rules[i].Secret)
+			if err != nil {
+				return nil, fmt.Errorf("%s: rule %s: %w", RulesFile, file.Rules[i].Name, err)
+			}
+			file.Rules[i].Secret = secret
+		}
+	}
+	return file.Rules, nil
+}
+
+func newRule(rc config.TriggerRule) (*rule, error) {
+	if rc.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	switch rc.Source {
+	case SourceWebhook:
+		if rc.Secret == "" || secrets.IsReference(rc.Secret) {
+			return nil, fmt.Errorf("webhook rules need a secret")
+		}
+	case SourceFile:
+		if len(rc.Paths) == 0 {
+			return nil, fmt.Errorf("file rules need paths")
+		}
+		for _, p := range rc.Paths {
+			if filepath.IsAbs(p) || !filepath.IsLocal(p) {
+				return nil, fmt.Errorf("path %q must be relative to the workspace", p)
+			}
+			if _, err := filepath.Match(p, ""); err != nil {
+				return nil, fmt.Errorf("path %q: %w", p, err)
+			}
+		}
+	case SourceUSB, SourceMaixCam:
+	default:
+		return nil, fmt.Errorf("unknown source %q", rc.Source)
+	}
+	if rc.Prompt == "" {
+		return nil, fmt.Errorf("prompt is required")
+	}
+	tmpl, err := template.New(rc.Name).Parse(rc.Prompt)
+	if err != nil {
+		return nil, fmt.Errorf("prompt: %w", err)
+	}
+	return &rule{TriggerRule: rc, prompt: tmpl}, nil
+}
+
+// Rules returns the names of the active rules per source.
+func (s *Service) Rules() map[string][]string {
+	out := make(map[string][]string)
+	for _, r := range s.rules {
+		out[r.Source] = append(out[r.Source], r.Name)
+	}
+	return out
+}
+
+// HasSource reports whether any rule uses source.
+func (s *Service) HasSource(source string) bool {
+	for _, r := range s.rules {
+		if r.Source == source {
+			return true
+		}
+	}
+	return false
+}
+
+// Start begins watching files for file rules.
+func (s *Service) Start(ctx context.Context) {
+	s.ctx, s.cancel = context.WithCancel(ctx)
+
+	var fileRules []*rule
+	for _, r := range s.rules {
+		if r.Source == SourceFile {
+			fileRules = append(fileRules, r)
+		}
+	}
+	if len(fileRules) > 0 {
+		s.watcher = newFileWatcher(s.opts.Workspace, fileRules)
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.watcher.run(s.ctx, s.opts.PollInterval, s.Fire)
+		}()
+	}
+	logger.InfoCF("triggers", "Trigger service started", map[string]any{"rules": len(s.rules)})
+}
+
+// Stop stops the file watcher, cancels running prompts and waits for them.
+func (s *Service) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// Fire runs every rule of ev.Source that accepts ev. Rules run in the
+// background; a rule still running or cooling down ignores the event.
+func (s *Service) Fire(ev Event) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	for _, r := range s.rules {
+		if r.Source == ev.Source {
+			s.fireRule(r, ev)
+		}
+	}
+}
+
+// What fireRule did with an event.
+const (
+	fireStarted = "accepted" // The rule is running
+	fireIgnored = "ignored"  // The rule does not handle the event type or path
+	fireSkipped = "skipped"  // The rule is still running or cooling down
+)
+
+func (s *Service) fireRule(r *rule, ev Event) string {
+	if !r.accepts(ev) {
+		return fireIgnored
+	}
+	if r.ownChange(ev) {
+		logger.DebugCF("triggers", "File changed by the rule's own run, event ignored",
+			map[string]any{"rule": r.Name, "path": ev.Data["path"]})
+		return fireIgnored
+	}
+	if !r.claim(ev.Time) {
+		triggerRuns.Inc(r.Name, "skipped")
+		logger.DebugCF("triggers", "Rule busy or cooling down, event ignored",
+			map[string]any{"rule": r.Name, "type": ev.Type})
+		return fireSkipped
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.run(r, ev)
+	}()
+	return fireStarted
+}
+
+// wrapEventData returns v with every non-empty string wrapped as untrusted
+// content, descending into JSON objects and arrays.
+func wrapEventData(v any, source string) any {
+	switch v := v.(type) {
+	case string:
+		if v == "" {
+			return v
+		}
+		return tools.WrapUntrusted(v, source)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, value := range v {
+			out[key] = wrapEventData(value, source)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, value := range v {
+			out[i] = wrapEventData(value, source)
+		}
+		return out
+	}
+	return v
+}
+
+func (r *rule) accepts(ev Event) bool {
+	if len(r.Events) > 0 && !slices.Contains(r.Events, ev.Type) {
+		return false
+	}
+	if r.Source == SourceFile {
+		path, _ := ev.Data["path"].(string)
+		return r.matchesPath(path)
+	}
+	return true
+}
+
+func (r *rule) matchesPath(path string) bool {
+	for _, p := range r.Paths {
+		if ok, _ := filepath.Match(filepath.ToSlash(p), filepath.ToSlash(path)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// claim marks the rule running unless it already is or is cooling down.
+func (r *rule) claim(now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cooldown := time.Duration(r.CooldownSeconds) * time.Second
+	if r.running || (!r.lastRun.IsZero() && now.Sub(r.lastRun) < cooldown) {
+		return false
+	}
+	r.running = true
+	r.lastRun = now
+	return true
+}
+
+func (r *rule) release() {
+	r.mu.Lock()
+	r.running = false
+	r.lastDone = time.Now()
+	r.mu.Unlock()
+}
+
+// ownChange reports whether ev is a file the rule's current or last run
+// wrote, so a rule that writes to the paths it watches does not fire itself
+// again.
+func (r *rule) ownChange(ev Event) bool {
+	if ev.changedAt.IsZero() {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.lastRun.IsZero() || ev.changedAt.Before(r.lastRun) {
+		return false
+	}
+	return r.running || !ev.changedAt.After(r.lastDone)
+}
+
+func (s *Service) run(r *rule, ev Event) {
+	defer r.release()
+	ev.Rule = r.Name
+
+	// Event fields come from outside: the prompt gets them marked as data.
+	source := ev.Source + " trigger " + r.Name
+	if ev.Payload != "" {
+		ev.Payload = tools.WrapUntrusted(ev.Payload, source)
+	}
+	ev.Data, _ = wrapEventData(ev.Data, source).(map[string]any)
+
+	var prompt bytes.Buffer
+	if err := r.prompt.Execute(&prompt, ev); err != nil {
+		triggerRuns.Inc(r.Name, "error")
+		logger.ErrorCF("triggers", "Failed to render prompt", map[string]any{"rule": r.Name, "error": err.Error()})
+		return
+	}
+
+	channel, chatID := r.Channel, r.ChatID
+	if channel == "" || chatID == "" {
+		channel, chatID = "cli", "direct"
+	}
+
+	logger.InfoCF("triggers", "Running trigger", map[string]any{"rule": r.Name, "source": ev.Source, "type": ev.Type})
+	sessionKey := "trigger:" + r.Name
+	response, err := s.opts.Runner.ProcessTrigger(s.ctx, r.Agent, sessionKey,
+		prompt.String(), channel, chatID)
+	if err != nil {
+		triggerRuns.Inc(r.Name, "error")
+		logger.ErrorCF("triggers", "Trigger run failed", map[string]any{"rule": r.Name, "error": err.Error()})
+		return
+	}
+	triggerRuns.Inc(r.Name, "ok")
+
+	if r.Channel == "" || r.ChatID == "" || response == "" || s.opts.Bus == nil {
+		return
+	}
+	agentID := s.opts.Runner.ResolveAgentID(r.Agent)
+	s.opts.Bus.PublishOutbound(bus.OutboundMessage{
+		Channel:    r.Channel,
+		ChatID:     r.ChatID,
+		Content:    response,
+		Agent:      agentID,
+		SessionKey: "agent:" + agentID + ":" + sessionKey,
+		Sender:     sessionKey,
+	})
+}
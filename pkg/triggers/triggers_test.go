@@ -0,0 +1,324 @@
+package triggers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+type call struct {
+	agentID, sessionKey, prompt, channel, chatID string
+}
+
+type fakeRunner struct {
+	mu    sync.Mutex
+	calls []call
+	block chan struct{}
+}
+
+func (f *fakeRunner) ProcessTrigger(_ context.Context, agentID, sessionKey, content, channel, chatID string) (string, error) {
+	if f.block != nil {
+		<-f.block
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call{agentID, sessionKey, content, channel, chatID})
+	return "done: " + content, nil
+}
+
+func (f *fakeRunner) ResolveAgentID(agentID string) string {
+	if agentID == "" {
+		return "main"
+	}
+	return agentID
+}
+
+func (f *fakeRunner) get() []call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]call(nil), f.calls...)
+}
+
+func newTestService(t *testing.T, rules ...config.TriggerRule) (*Service, *fakeRunner, *bus.MessageBus) {
+	t.Helper()
+	runner := &fakeRunner{}
+	msgBus := bus.NewMessageBus()
+	s := NewService(Options{Rules: rules, Workspace: t.TempDir(), Runner: runner, Bus: msgBus})
+	return s, runner, msgBus
+}
+
+func TestNewService_SkipsInvalidRules(t *testing.T) {
+	s, _, _ := newTestService(t,
+		config.TriggerRule{Name: "ok", Source: SourceUSB, Prompt: "x"},
+		config.TriggerRule{Name: "ok", Source: SourceUSB, Prompt: "duplicate"},
+		config.TriggerRule{Name: "nosecret", Source: SourceWebhook, Prompt: "x"},
+		config.TriggerRule{Name: "escape", Source: SourceFile, Paths: []string{"../etc/*"}, Prompt: "x"},
+		config.TriggerRule{Name: "badtmpl", Source: SourceUSB, Prompt: "{{.Nope"},
+		config.TriggerRule{Name: "unknown", Source: "sms", Prompt: "x"},
+	)
+	if got := s.Rules(); len(got) != 1 || len(got[SourceUSB]) != 1 {
+		t.Errorf("Rules() = %v, want only the first usb rule", got)
+	}
+}
+
+func TestNewService_LoadsRulesFile(t *testing.T) {
+	workspace := t.TempDir()
+	data := `{"rules": [{"name": "plug", "source": "usb", "prompt": "{{.Data.product}} plugged in"}]}`
+	if err := os.WriteFile(filepath.Join(workspace, RulesFile), []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s := NewService(Options{
+		Rules:     []config.TriggerRule{{Name: "cam", Source: SourceMaixCam, Prompt: "x"}},
+		Workspace: workspace,
+	})
+	if !s.HasSource(SourceUSB) || !s.HasSource(SourceMaixCam) || s.HasSource(SourceFile) {
+		t.Errorf("Rules() = %v", s.Rules())
+	}
+}
+
+func TestFire_RendersPromptAndDelivers(t *testing.T) {
+	s, runner, msgBus := newTestService(t, config.TriggerRule{
+		Name:    "plug",
+		Source:  SourceUSB,
+		Events:  []string{"add"},
+		Prompt:  "{{.Rule}}: {{.Data.vendor}} {{.Data.product}} ({{.Type}})",
+		Agent:   "ops",
+		Channel: "telegram",
+		ChatID:  "42",
+	})
+
+	s.Fire(Event{Source: SourceUSB, Type: "remove", Data: map[string]any{"product": "ignored"}})
+	s.Fire(Event{Source: SourceMaixCam, Type: "add"})
+	s.Fire(Event{Source: SourceUSB, Type: "add", Data: map[string]any{"vendor": "Sipeed", "product": "MaixCam"}})
+	s.Stop()
+
+	calls := runner.get()
+	if len(calls) != 1 {
+		t.Fatalf("calls = %+v, want 1", calls)
+	}
+	got := calls[0]
+	if got.agentID != "ops" || got.sessionKey != "trigger:plug" || got.channel != "telegram" || got.chatID != "42" {
+		t.Errorf("call = %+v", got)
+	}
+	// Event data reaches the prompt marked as untrusted.
+	if !strings.HasPrefix(got.prompt, "plug: <<<UNTRUSTED_CONTENT") || !strings.HasSuffix(got.prompt, " (add)") ||
+		strings.Count(got.prompt, "<<<UNTRUSTED_CONTENT") != 2 ||
+		!strings.Contains(got.prompt, "\nSipeed\n") || !strings.Contains(got.prompt, "\nMaixCam\n") {
+		t.Errorf("prompt = %q", got.prompt)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	out, ok := msgBus.SubscribeOutbound(ctx)
+	if !ok || out.Channel != "telegram" || out.ChatID != "42" || out.Content != "done: "+got.prompt {
+		t.Errorf("outbound = %+v, %v", out, ok)
+	}
+	if out.Agent != "ops" || out.SessionKey != "agent:ops:trigger:plug" || out.Sender != "trigger:plug" {
+		t.Errorf("outbound attribution = %q, %q, %q", out.Agent, out.SessionKey, out.Sender)
+	}
+}
+
+func TestFire_BusyAndCooldown(t *testing.T) {
+	s, runner, _ := newTestService(t, config.TriggerRule{
+		Name: "cam", Source: SourceMaixCam, Prompt: "seen", CooldownSeconds: 60,
+	})
+	runner.block = make(chan struct{})
+
+	now := time.Now()
+	s.Fire(Event{Source: SourceMaixCam, Type: "person_detected", Time: now})
+	s.Fire(Event{Source: SourceMaixCam, Type: "person_detected", Time: now}) // still running
+	close(runner.block)
+	s.Stop()
+	s.Fire(Event{Source: SourceMaixCam, Type: "person_detected", Time: now.Add(30 * time.Second)}) // cooling down
+	s.Stop()
+	if n := len(runner.get()); n != 1 {
+		t.Fatalf("runs = %d, want 1", n)
+	}
+
+	s.Fire(Event{Source: SourceMaixCam, Type: "person_detected", Time: now.Add(61 * time.Second)})
+	s.Stop()
+	if n := len(runner.get()); n != 2 {
+		t.Errorf("runs after cooldown = %d, want 2", n)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	s, runner, _ := newTestService(t, config.TriggerRule{
+		Name:   "github",
+		Source: SourceWebhook,
+		Secret: "s3cret",
+		Prompt: "{{.Type}} to {{.Data.repository.full_name}}",
+	})
+	body := `{"repository": {"full_name": "sipeed/picoclaw"}}`
+	post := func(path string, header http.Header) int {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Unknown names look like bad credentials unless the caller holds a secret.
+	if code := post("/hooks/other", nil); code != http.StatusUnauthorized {
+		t.Errorf("unknown hook: %d, want 401", code)
+	}
+	if code := post("/hooks/other", http.Header{"Authorization": {"Bearer s3cret"}}); code != http.StatusNotFound {
+		t.Errorf("unknown hook with a valid secret: %d, want 404", code)
+	}
+	if code := post("/hooks/github", nil); code != http.StatusUnauthorized {
+		t.Errorf("no credentials: %d, want 401", code)
+	}
+	if code := post("/hooks/github", http.Header{"Authorization": {"Bearer wrong"}}); code != http.StatusUnauthorized {
+		t.Errorf("wrong token: %d, want 401", code)
+	}
+	if code := post("/hooks/github", http.Header{"X-Hub-Signature-256": {"sha256=00"}}); code != http.StatusUnauthorized {
+		t.Errorf("wrong signature: %d, want 401", code)
+	}
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(body))
+	signed := http.Header{
+		"X-Hub-Signature-256": {"sha256=" + hex.EncodeToString(mac.Sum(nil))},
+		"X-Github-Event":      {"push"},
+	}
+	if code := post("/hooks/github", signed); code != http.StatusAccepted {
+		t.Fatalf("signed request: %d, want 202", code)
+	}
+	s.Stop()
+	if code := post("/hooks/github?type=ping", http.Header{"Authorization": {"Bearer s3cret"}}); code != http.StatusAccepted {
+		t.Fatalf("bearer request: %d, want 202", code)
+	}
+	s.Stop()
+
+	calls := runner.get()
+	if len(calls) != 2 || !strings.HasPrefix(calls[0].prompt, "push to ") || !strings.HasPrefix(calls[1].prompt, "ping to ") ||
+		!strings.Contains(calls[0].prompt, "\nsipeed/picoclaw\n") {
+		t.Errorf("calls = %+v", calls)
+	}
+	// Without a delivery target the run still happens, answering to cli.
+	if calls[0].channel != "cli" || calls[0].chatID != "direct" {
+		t.Errorf("channel = %s:%s, want cli:direct", calls[0].channel, calls[0].chatID)
+	}
+
+	// A busy rule reports that the event was dropped.
+	runner.block = make(chan struct{})
+	bearer := http.Header{"Authorization": {"Bearer s3cret"}}
+	if code := post("/hooks/github", bearer); code != http.StatusAccepted {
+		t.Fatalf("first request: %d, want 202", code)
+	}
+	if code := post("/hooks/github", bearer); code != http.StatusTooManyRequests {
+		t.Errorf("request while busy: %d, want 429", code)
+	}
+	close(runner.block)
+	s.Stop()
+
+	req := httptest.NewRequest(http.MethodGet, "/hooks/github", nil)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: %d, want 405", rec.Code)
+	}
+}
+
+func TestFileWatcher(t *testing.T) {
+	workspace := t.TempDir()
+	inbox := filepath.Join(workspace, "inbox")
+	if err := os.MkdirAll(inbox, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	existing := filepath.Join(inbox, "old.md")
+	os.WriteFile(existing, []byte("old"), 0o600)
+
+	r, err := newRule(config.TriggerRule{Name: "inbox", Source: SourceFile, Paths: []string{"inbox/*.md"}, Prompt: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := newFileWatcher(workspace, []*rule{r})
+	w.files = w.scan()
+
+	os.WriteFile(filepath.Join(inbox, "new.md"), []byte("new"), 0o600)
+	os.WriteFile(filepath.Join(inbox, "ignored.txt"), []byte("x"), 0o600)
+	os.WriteFile(existing, []byte("changed"), 0o600)
+
+	got := map[string]string{}
+	for _, ev := range w.poll() {
+		got[ev.Data["path"].(string)] = ev.Type
+	}
+	if len(got) != 2 || got["inbox/new.md"] != FileCreated || got["inbox/old.md"] != FileModified {
+		t.Errorf("events = %v", got)
+	}
+
+	os.Remove(existing)
+	events := w.poll()
+	if len(events) != 1 || events[0].Type != FileRemoved || events[0].Data["path"] != "inbox/old.md" {
+		t.Errorf("events after remove = %+v", events)
+	}
+	if !r.accepts(events[0]) || r.accepts(Event{Source: SourceFile, Data: map[string]any{"path": "notes/a.md"}}) {
+		t.Error("rule path matching is wrong")
+	}
+}
+
+func TestFire_IgnoresOwnFileWrites(t *testing.T) {
+	s, runner, _ := newTestService(t, config.TriggerRule{
+		Name: "journal", Source: SourceFile, Paths: []string{"notes/*.md"}, Prompt: "{{.Data.path}} changed",
+	})
+	notes := filepath.Join(s.opts.Workspace, "notes")
+	if err := os.MkdirAll(notes, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	w := newFileWatcher(s.opts.Workspace, s.rules)
+	w.files = w.scan()
+	touch := func(name string, at time.Time) {
+		t.Helper()
+		path := filepath.Join(notes, name)
+		if err := os.WriteFile(path, []byte(at.String()), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, at, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pollAndFire := func() {
+		for _, ev := range w.poll() {
+			s.Fire(ev)
+		}
+		s.Stop()
+	}
+
+	touch("a.md", time.Now().Add(-time.Second))
+	runner.block = make(chan struct{})
+	for _, ev := range w.poll() {
+		s.Fire(ev)
+	}
+	// The run writes to a file the rule watches.
+	time.Sleep(10 * time.Millisecond)
+	touch("a.md", time.Now())
+	close(runner.block)
+	s.Stop()
+	runner.block = nil
+
+	pollAndFire()
+	if n := len(runner.get()); n != 1 {
+		t.Fatalf("runs = %d, want 1: the run's own write must not fire the rule", n)
+	}
+
+	touch("b.md", time.Now().Add(time.Second))
+	pollAndFire()
+	if n := len(runner.get()); n != 2 {
+		t.Errorf("runs after a later change = %d, want 2", n)
+	}
+}
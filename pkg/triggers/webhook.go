@@ -0,0 +1,126 @@
+package triggers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+// WebhookPrefix is the path webhook rules are served under: a rule named
+// "github" receives POST /hooks/github.
+const WebhookPrefix = "/hooks/"
+
+const (
+	maxWebhookBody    = 1 << 20 // Bytes read from a request
+	maxWebhookPayload = 16000   // Characters of the body given to the prompt
+)
+
+// ServeHTTP accepts webhook events. Requests authenticate with
+// "Authorization: Bearer <secret>" or an X-Hub-Signature-256 header holding
+// the HMAC-SHA256 of the body keyed with the secret, as GitHub sends it.
+// The event type comes from the "type" query parameter, X-GitHub-Event or
+// X-Event-Type, defaulting to "webhook".
+//
+// Unknown names answer 401 like bad credentials, so rule names cannot be
+// probed; only a caller holding some webhook's secret learns of a 404. An
+// event the rule ignores or drops as busy or cooling down is reported in the
+// response instead of 202.
+func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, WebhookPrefix)
+	var target *rule
+	known := false // The credentials match some webhook rule
+	for _, candidate := range s.rules {
+		if candidate.Source != SourceWebhook {
+			continue
+		}
+		if candidate.Name == name {
+			target = candidate
+		}
+		if !known && authorized(r, body, candidate.Secret) {
+			known = true
+		}
+	}
+	if target == nil && known {
+		http.Error(w, "unknown webhook", http.StatusNotFound)
+		return
+	}
+	if target == nil || !authorized(r, body, target.Secret) {
+		logger.WarnCF("triggers", "Rejected webhook with bad credentials",
+			map[string]any{"rule": name, "remote_addr": r.RemoteAddr})
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ev := Event{
+		Source:  SourceWebhook,
+		Type:    webhookType(r),
+		Data:    map[string]any{},
+		Payload: utils.Truncate(string(body), maxWebhookPayload),
+		Time:    time.Now(),
+	}
+	// JSON objects are exposed field by field; other bodies only as Payload.
+	_ = json.Unmarshal(body, &ev.Data)
+	for key, values := range r.URL.Query() {
+		if _, ok := ev.Data[key]; !ok && len(values) > 0 {
+			ev.Data[key] = values[0]
+		}
+	}
+
+	status := s.fireRule(target, ev)
+	code := http.StatusAccepted
+	switch status {
+	case fireIgnored:
+		code = http.StatusOK
+	case fireSkipped:
+		code = http.StatusTooManyRequests
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write([]byte(`{"status":"` + status + `"}` + "\n"))
+}
+
+func authorized(r *http.Request, body []byte, secret string) bool {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+	}
+	if sig, ok := strings.CutPrefix(r.Header.Get("X-Hub-Signature-256"), "sha256="); ok {
+		got, err := hex.DecodeString(sig)
+		if err != nil {
+			return false
+		}
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		return hmac.Equal(got, mac.Sum(nil))
+	}
+	return false
+}
+
+func webhookType(r *http.Request) string {
+	for _, v := range []string{r.URL.Query().Get("type"), r.Header.Get("X-GitHub-Event"), r.Header.Get("X-Event-Type")} {
+		if v != "" {
+			return v
+		}
+	}
+	return "webhook"
+}